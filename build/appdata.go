@@ -73,6 +73,12 @@ func WalletPassword() string {
 	return os.Getenv(siaWalletPassword)
 }
 
+// S3Credentials returns the siaS3AccessKey and siaS3SecretKey environment
+// variables.
+func S3Credentials() (accessKey, secretKey string) {
+	return os.Getenv(siaS3AccessKey), os.Getenv(siaS3SecretKey)
+}
+
 // ExchangeRate returns the siaExchangeRate environment variable.
 func ExchangeRate() string {
 	return os.Getenv(siaExchangeRate)
@@ -20,4 +20,12 @@ var (
 	// siaExchangeRate is the environment variable that can be set to
 	// show amounts (additionally) in a different currency
 	siaExchangeRate = "SIA_EXCHANGE_RATE"
+
+	// siaS3AccessKey is the environment variable that sets the access key id
+	// accepted by the S3 gateway
+	siaS3AccessKey = "SIA_S3_ACCESS_KEY"
+
+	// siaS3SecretKey is the environment variable that sets the secret access
+	// key accepted by the S3 gateway
+	siaS3SecretKey = "SIA_S3_SECRET_KEY"
 )
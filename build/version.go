@@ -14,7 +14,7 @@ const (
 	MaxEncodedVersionLength = 100
 
 	// Version is the current version of ttdxd.
-	Version = "1.5.5"
+	Version = "1.5.6"
 )
 
 // ReleaseTag contains the release tag, such as "rc3". It is supplied at build
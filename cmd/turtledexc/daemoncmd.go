@@ -3,6 +3,9 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -75,6 +78,36 @@ directory in the ttdxd data directory.`,
 		Run:   wrap(stackcmd),
 	}
 
+	tokensCmd = &cobra.Command{
+		Use:   "tokens",
+		Short: "List the API tokens",
+		Long:  "List the API tokens which can be used instead of the API password to access a subset of the API.",
+		Run:   wrap(tokenscmd),
+	}
+
+	tokensCreateCmd = &cobra.Command{
+		Use:   "create [name] [scopes]",
+		Short: "Create an API token",
+		Long: `Create an API token with a comma separated list of scopes. The token is
+only displayed once and needs to be provided instead of the API password.
+
+Available scopes:
+  admin          access to every endpoint
+  read           password protected endpoints which don't modify the node
+  renter         renter files, uploads, downloads and settings
+  skynet-upload  uploading and pinning skyfiles and updating registry entries
+  wallet-spend   sending coins and signing transactions
+  host-admin     host settings, announcements and storage folders`,
+		Run: wrap(tokenscreatecmd),
+	}
+
+	tokensRevokeCmd = &cobra.Command{
+		Use:   "revoke [id]",
+		Short: "Revoke an API token",
+		Long:  "Revoke an API token.",
+		Run:   wrap(tokensrevokecmd),
+	}
+
 	updateCmd = &cobra.Command{
 		Use:   "update",
 		Short: "Update TurtleDex",
@@ -210,6 +243,56 @@ func stopcmd() {
 	fmt.Println("TurtleDex daemon stopped.")
 }
 
+// tokenscmd is the handler for the command `ttdxc tokens` and lists the API
+// tokens.
+func tokenscmd() {
+	dtg, err := httpClient.DaemonTokensGet()
+	if err != nil {
+		die("Could not get API tokens:", err)
+	}
+	if len(dtg.Tokens) == 0 {
+		fmt.Println("No API tokens.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tName\tScopes\tCreated")
+	for _, t := range dtg.Tokens {
+		scopes := make([]string, 0, len(t.Scopes))
+		for _, scope := range t.Scopes {
+			scopes = append(scopes, string(scope))
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", t.ID, t.Name, strings.Join(scopes, ","), t.CreateTime.Format(time.RFC3339))
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// tokenscreatecmd is the handler for the command `ttdxc tokens create` and
+// creates a new API token.
+func tokenscreatecmd(name, scopesStr string) {
+	scopes, err := modules.ParseAPIScopes(scopesStr)
+	if err != nil {
+		die("Could not parse scopes:", err)
+	}
+	dtcp, err := httpClient.DaemonTokensCreatePost(name, scopes)
+	if err != nil {
+		die("Could not create API token:", err)
+	}
+	fmt.Printf("Created API token %v. Store the token securely, it won't be displayed again:\n", dtcp.ID)
+	fmt.Println(dtcp.Token)
+}
+
+// tokensrevokecmd is the handler for the command `ttdxc tokens revoke` and
+// revokes an API token.
+func tokensrevokecmd(id string) {
+	err := httpClient.DaemonTokensRevokePost(id)
+	if err != nil {
+		die("Could not revoke API token:", err)
+	}
+	fmt.Printf("Revoked API token %v.\n", id)
+}
+
 // stackcmd is the handler for the command `ttdxc stack` and writes the current
 // stack trace to an output file.
 func stackcmd() {
@@ -153,9 +153,62 @@ func parseAndAddSkykey(sup modules.SkyfileUploadParameters) modules.SkyfileUploa
 		}
 		sup.SkykeyID = ID
 	}
+	if skynetUploadRecipients != "" {
+		if skykeyName != "" || skykeyID != "" {
+			die("Can't use the recipients flag together with the skykeyname or skykeyid flag.")
+		}
+		for _, pkStr := range strings.Split(skynetUploadRecipients, ",") {
+			var pk skykey.RecipientPublicKey
+			err := pk.FromString(strings.TrimSpace(pkStr))
+			if err != nil {
+				die("Unable to parse recipient public key:", err)
+			}
+			sup.SkykeyRecipients = append(sup.SkykeyRecipients, pk)
+		}
+	}
 	return sup
 }
 
+// parseSkynetUploadCompression is a helper that parses the compression flag of
+// skynet uploads.
+func parseSkynetUploadCompression() modules.CompressionType {
+	ct, err := modules.NewCompressionType(skynetUploadCompression)
+	if err != nil {
+		die("Could not parse compression:", err)
+	}
+	return ct
+}
+
+// parseSkynetUploadHeaders is a helper that parses the header flags of skynet
+// uploads.
+func parseSkynetUploadHeaders() map[string]string {
+	if len(skynetUploadHeaders) == 0 {
+		return nil
+	}
+	headers := make(map[string]string, len(skynetUploadHeaders))
+	for _, h := range skynetUploadHeaders {
+		kv := strings.SplitN(h, ":", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			die("Could not parse header, expected 'Name: value':", h)
+		}
+		headers[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return headers
+}
+
+// parseSkynetUploadTryFiles is a helper that parses the tryfiles flag of skynet
+// uploads.
+func parseSkynetUploadTryFiles() []string {
+	if skynetUploadTryFiles == "" {
+		return nil
+	}
+	var tryFiles []string
+	for _, tf := range strings.Split(skynetUploadTryFiles, ",") {
+		tryFiles = append(tryFiles, strings.TrimSpace(tf))
+	}
+	return tryFiles
+}
+
 // sanitizeErr is a small helper function that sanitizes the output for the
 // given error string. It will print "-", if the error string is the equivalent
 // of a nil error.
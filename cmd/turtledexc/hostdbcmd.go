@@ -5,6 +5,7 @@ import (
 	"math/big"
 	"os"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -14,7 +15,17 @@ import (
 	"github.com/turtledex/errors"
 )
 
-const scanHistoryLen = 30
+const (
+	// hostHistoryDays is the number of days of a host's history which are
+	// shown by default.
+	hostHistoryDays = 7
+
+	// hostHistoryPriceChanges is the number of price changes of a host which
+	// are shown by default.
+	hostHistoryPriceChanges = 5
+
+	scanHistoryLen = 30
+)
 
 var (
 	hostdbNumHosts int
@@ -56,18 +67,27 @@ var (
 // printScoreBreakdown prints the score breakdown of a host, provided the info.
 func printScoreBreakdown(info *api.HostdbHostsGET) {
 	fmt.Println("\n  Score Breakdown:")
+	printScoreAdjustments(info.ScoreBreakdown)
+}
+
+// printScoreAdjustments prints the adjustments of a score breakdown.
+func printScoreAdjustments(sb modules.HostScoreBreakdown) {
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintf(w, "\t\tAge:\t %.3f\n", info.ScoreBreakdown.AgeAdjustment)
-	fmt.Fprintf(w, "\t\tBase Price:\t %.3f\n", info.ScoreBreakdown.BasePriceAdjustment)
-	fmt.Fprintf(w, "\t\tBurn:\t %.3f\n", info.ScoreBreakdown.BurnAdjustment)
-	fmt.Fprintf(w, "\t\tCollateral:\t %.3f\n", info.ScoreBreakdown.CollateralAdjustment/1e96)
-	fmt.Fprintf(w, "\t\tDuration:\t %.3f\n", info.ScoreBreakdown.DurationAdjustment)
-	fmt.Fprintf(w, "\t\tInteraction:\t %.3f\n", info.ScoreBreakdown.InteractionAdjustment)
-	fmt.Fprintf(w, "\t\tPrice:\t %.3f\n", info.ScoreBreakdown.PriceAdjustment*1e24)
-	fmt.Fprintf(w, "\t\tStorage:\t %.3f\n", info.ScoreBreakdown.StorageRemainingAdjustment)
-	fmt.Fprintf(w, "\t\tUptime:\t %.3f\n", info.ScoreBreakdown.UptimeAdjustment)
-	fmt.Fprintf(w, "\t\tVersion:\t %.3f\n", info.ScoreBreakdown.VersionAdjustment)
-	fmt.Fprintf(w, "\t\tConversion Rate:\t %.3f\n", info.ScoreBreakdown.ConversionRate)
+	fmt.Fprintf(w, "\t\tAge:\t %.3f\n", sb.AgeAdjustment)
+	fmt.Fprintf(w, "\t\tBase Price:\t %.3f\n", sb.BasePriceAdjustment)
+	fmt.Fprintf(w, "\t\tBurn:\t %.3f\n", sb.BurnAdjustment)
+	fmt.Fprintf(w, "\t\tCollateral:\t %.3f\n", sb.CollateralAdjustment/1e96)
+	fmt.Fprintf(w, "\t\tDuration:\t %.3f\n", sb.DurationAdjustment)
+	fmt.Fprintf(w, "\t\tInteraction:\t %.3f\n", sb.InteractionAdjustment)
+	fmt.Fprintf(w, "\t\tPrice:\t %.3f\n", sb.PriceAdjustment*1e24)
+	fmt.Fprintf(w, "\t\tStorage:\t %.3f\n", sb.StorageRemainingAdjustment)
+	fmt.Fprintf(w, "\t\tUptime:\t %.3f\n", sb.UptimeAdjustment)
+	fmt.Fprintf(w, "\t\tVersion:\t %.3f\n", sb.VersionAdjustment)
+	fmt.Fprintf(w, "\t\tScoring Policy:\t %.3f\n", sb.ScoringPolicyAdjustment)
+	fmt.Fprintf(w, "\t\tConversion Rate:\t %.3f\n", sb.ConversionRate)
+	for _, violation := range sb.ScoringPolicyViolations {
+		fmt.Fprintf(w, "\t\tViolation:\t %v\n", violation)
+	}
 	if err := w.Flush(); err != nil {
 		die("failed to flush writer")
 	}
@@ -372,7 +392,20 @@ func hostdbsetfiltermodecmd(cmd *cobra.Command, args []string) {
 func hostdbviewcmd(pubkey string) {
 	var publicKey types.TurtleDexPublicKey
 	publicKey.LoadString(pubkey)
-	info, err := httpClient.HostDbHostsGet(publicKey)
+
+	// If scoring policy flags were provided, preview the score of the host
+	// under the current scoring policy updated with the flags.
+	var info api.HostdbHostsGET
+	rg, err := httpClient.RenterGet()
+	if err != nil {
+		die("Could not get renter info:", err)
+	}
+	policy := rg.Settings.ScoringPolicy
+	if parseScoringPolicyFlags(&policy) {
+		info, err = httpClient.HostDbHostsScoringPolicyGet(publicKey, policy)
+	} else {
+		info, err = httpClient.HostDbHostsGet(publicKey)
+	}
 	if err != nil {
 		die("Could not fetch provided host:", err)
 	}
@@ -415,6 +448,11 @@ func hostdbviewcmd(pubkey string) {
 	}
 
 	printScoreBreakdown(&info)
+	if preview := info.ScoringPolicyPreview; preview != nil {
+		fmt.Println("\n  Scoring Policy Preview:")
+		fmt.Println("    Absolute Score:", preview.Score)
+		printScoreAdjustments(*preview)
+	}
 
 	// Compute the total measured uptime and total measured downtime for this
 	// host.
@@ -445,7 +483,114 @@ func hostdbviewcmd(pubkey string) {
 	fmt.Printf("  Historic Successful Interactions:  %.3f\n", info.Entry.HistoricSuccessfulInteractions)
 	fmt.Println("  Recent Failed Interactions:       ", info.Entry.RecentFailedInteractions)
 	fmt.Println("  Recent Successful Interactions:   ", info.Entry.RecentSuccessfulInteractions)
+	fmt.Println("  Failed Audits:                    ", info.Entry.AuditFailures)
+	fmt.Println("  Passed Audits:                    ", info.Entry.AuditSuccesses)
 	fmt.Printf("  Overall Uptime:                    %.3f\n", uptimeRatio)
 
+	printHostHistory(publicKey)
+
 	fmt.Println()
 }
+
+// printHostHistory prints the time series the hostdb keeps about a host. The
+// scans and jobs are aggregated per day. Unless the verbose flag is set, only
+// the last week and the most recent price changes are shown.
+func printHostHistory(pk types.TurtleDexPublicKey) {
+	hhg, err := httpClient.HostDbHostsHistoryGet(pk)
+	if errors.Contains(err, api.ErrAPICallNotRecognized) {
+		return
+	} else if err != nil {
+		die("Could not fetch host history:", err)
+	}
+	cutoff := time.Now().Add(-hostHistoryDays * 24 * time.Hour).Truncate(24 * time.Hour)
+	day := func(t time.Time) string {
+		return t.Truncate(24 * time.Hour).Format("2006-01-02")
+	}
+
+	// Aggregate the scans per day.
+	type scanDay struct {
+		day       string
+		scans     int
+		successes int
+		latency   time.Duration
+		score     types.Currency
+	}
+	var scanDays []*scanDay
+	for _, scan := range hhg.Scans {
+		if !verbose && scan.Timestamp.Before(cutoff) {
+			continue
+		}
+		if len(scanDays) == 0 || scanDays[len(scanDays)-1].day != day(scan.Timestamp) {
+			scanDays = append(scanDays, &scanDay{day: day(scan.Timestamp)})
+		}
+		sd := scanDays[len(scanDays)-1]
+		sd.scans++
+		if scan.Success {
+			sd.successes++
+			sd.latency += scan.Latency
+		}
+		sd.score = scan.Score
+	}
+	fmt.Println("\n  Scan History:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\t\tDay\tScans\tSuccessful\tAvg Latency\tScore")
+	for _, sd := range scanDays {
+		var avgLatency time.Duration
+		if sd.successes > 0 {
+			avgLatency = (sd.latency / time.Duration(sd.successes)).Round(time.Millisecond)
+		}
+		fmt.Fprintf(w, "\t\t%v\t%v\t%v\t%v\t%v\n", sd.day, sd.scans, sd.successes, avgLatency, sd.score)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer")
+	}
+
+	// Print the most recent price changes.
+	prices := hhg.Prices
+	if !verbose && len(prices) > hostHistoryPriceChanges {
+		prices = prices[len(prices)-hostHistoryPriceChanges:]
+	}
+	fmt.Println("\n  Price History:")
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\t\tSince\tStorage (TB / Mo)\tUpload (1 TB)\tDownload (1 TB)\tContract\tCollateral (TB / Mo)")
+	for _, p := range prices {
+		fmt.Fprintf(w, "\t\t%v\t%v\t%v\t%v\t%v\t%v\n", p.Timestamp.Format("2006-01-02 15:04"),
+			currencyUnits(p.StoragePrice.Mul(modules.BlockBytesPerMonthTerabyte)),
+			currencyUnits(p.UploadBandwidthPrice.Mul(modules.BytesPerTerabyte)),
+			currencyUnits(p.DownloadBandwidthPrice.Mul(modules.BytesPerTerabyte)),
+			currencyUnits(p.ContractPrice),
+			currencyUnits(p.Collateral.Mul(modules.BlockBytesPerMonthTerabyte)))
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer")
+	}
+
+	// Aggregate the jobs per day.
+	type jobDay struct {
+		day       string
+		successes uint64
+		failures  uint64
+	}
+	var jobDays []*jobDay
+	for _, jobs := range hhg.Jobs {
+		if !verbose && jobs.Timestamp.Before(cutoff) {
+			continue
+		}
+		if len(jobDays) == 0 || jobDays[len(jobDays)-1].day != day(jobs.Timestamp) {
+			jobDays = append(jobDays, &jobDay{day: day(jobs.Timestamp)})
+		}
+		jd := jobDays[len(jobDays)-1]
+		jd.successes += jobs.Successes
+		jd.failures += jobs.Failures
+	}
+	fmt.Println("\n  Job History:")
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "\t\tDay\tSuccessful\tFailed\tSuccess Rate")
+	for _, jd := range jobDays {
+		rate := float64(jd.successes) / float64(jd.successes+jd.failures) * 100
+		fmt.Fprintf(w, "\t\t%v\t%v\t%v\t%.2f%%\n", jd.day, jd.successes, jd.failures, rate)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer")
+	}
+}
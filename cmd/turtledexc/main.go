@@ -35,19 +35,33 @@ var (
 	hostFolderRemoveForce  bool   // force folder remove
 
 	// Renter Flags
-	dataPieces                string // the number of data pieces a file should be uploaded with
-	parityPieces              string // the number of parity pieces a file should be uploaded with
-	renterAllContracts        bool   // Show all active and expired contracts
-	renterBubbleAll           bool   // Bubble the entire directory tree
-	renterDeleteRoot          bool   // Delete path start from root instead of the UserFolder.
-	renterDownloadAsync       bool   // Downloads files asynchronously
-	renterDownloadRecursive   bool   // Downloads folders recursively.
-	renterDownloadRoot        bool   // Download path start from root instead of the UserFolder.
-	renterFuseMountAllowOther bool   // Mount fuse with 'AllowOther' set to true.
-	renterListRecursive       bool   // List files of folder recursively.
-	renterListRoot            bool   // List path start from root instead of the UserFolder.
-	renterRenameRoot          bool   // Rename files relative to root instead of the UserFolder.
-	renterShowHistory         bool   // Show download history in addition to download queue.
+	dataPieces                    string // the number of data pieces a file should be uploaded with
+	parityPieces                  string // the number of parity pieces a file should be uploaded with
+	renterAllContracts            bool   // Show all active and expired contracts
+	renterBubbleAll               bool   // Bubble the entire directory tree
+	renterDeleteRoot              bool   // Delete path start from root instead of the UserFolder.
+	renterDedup                   bool   // Deduplicate the chunks of uploaded files.
+	renterUploadCompression       string // Compression applied to uploaded files.
+	renterDownloadAsync           bool   // Downloads files asynchronously
+	renterDownloadRecursive       bool   // Downloads folders recursively.
+	renterDownloadRoot            bool   // Download path start from root instead of the UserFolder.
+	renterFuseMountAllowOther     bool   // Mount fuse with 'AllowOther' set to true.
+	renterFuseMountReadOnly       bool   // Mount fuse with 'ReadOnly' set to true.
+	renterListRecursive           bool   // List files of folder recursively.
+	renterListRoot                bool   // List path start from root instead of the UserFolder.
+	renterPlacementASN            uint64 // Max pieces per ASN of a placement policy.
+	renterPlacementASNFile        string // ip2asn file of a placement policy.
+	renterPlacementHostGroup      uint64 // Max pieces per host group of a placement policy.
+	renterPlacementHostGroupsFile string // Host groups file of a placement policy.
+	renterPlacementIPv4Subnet     uint64 // Max pieces per IPv4 /16 of a placement policy.
+	renterPlacementIPv6Subnet     uint64 // Max pieces per IPv6 /48 of a placement policy.
+	renterRenameRoot              bool   // Rename files relative to root instead of the UserFolder.
+	renterSectorCachePolicy       string // Eviction policy of the sector cache.
+	renterShowHistory             bool   // Show download history in addition to download queue.
+	renterSyncPropagateDeletions  bool   // Delete the TurtleDex files of deleted local files.
+	renterSyncPropagateRenames    bool   // Rename the TurtleDex files of renamed local files.
+	renterVersionsMaxVersions     uint64 // Number of versions retained by a versioning policy.
+	renterVersionsRetentionWindow string // Duration versions are retained for by a versioning policy.
 
 	// Renter Allowance Flags
 	allowanceFunds       string // amount of money to be used within a period
@@ -69,6 +83,31 @@ var (
 	allowanceMaxStoragePrice           string // max allowed price to store data on a host
 	allowanceMaxUploadBandwidthPrice   string // max allowed price to upload data to a host
 
+	// Scoring Policy Flags
+	scoringAgeWeight              string // weight of the age adjustment
+	scoringCollateralWeight       string // weight of the collateral adjustment
+	scoringInteractionsWeight     string // weight of the interactions adjustment
+	scoringPriceWeight            string // weight of the price adjustment
+	scoringStorageRemainingWeight string // weight of the storage remaining adjustment
+	scoringUptimeWeight           string // weight of the uptime adjustment
+	scoringVersionWeight          string // weight of the version adjustment
+
+	scoringMinAge              string // minimum age of a host
+	scoringMinCollateral       string // minimum collateral offered by a host
+	scoringMinRemainingStorage string // minimum remaining storage of a host
+	scoringMinUptime           string // minimum uptime ratio of a host
+	scoringMinVersion          string // minimum version of a host
+
+	scoringMaxContractPrice          string // maximum contract price of a host
+	scoringMaxDownloadBandwidthPrice string // maximum download bandwidth price of a host
+	scoringMaxStoragePrice           string // maximum storage price of a host
+	scoringMaxUploadBandwidthPrice   string // maximum upload bandwidth price of a host
+
+	// Renter Rebalance Flags
+	rebalanceBudget             string  // amount of money which may be spent on migrations per period
+	rebalanceMaxCostRatio       float64 // max cost of a host relative to the median host
+	rebalanceMinScorePercentile float64 // min score percentile of a host
+
 	// Skykey Flags
 	skykeyID              string // ID used to identify a Skykey.
 	skykeyName            string // Name used to identify a Skykey.
@@ -77,19 +116,28 @@ var (
 	skykeyType            string // Type used to create a new Skykey.
 
 	// Skynet Flags
-	skynetBlocklistHash            bool   // Indicates if the input for the blocklist is already a hash.
-	skynetDownloadPortal           string // Portal to use when trying to download a skylink.
-	skynetLsRecursive              bool   // List files of folder recursively.
-	skynetLsRoot                   bool   // Use root as the base instead of the Skynet folder.
-	skynetPinPortal                string // Portal to use when trying to pin a skylink.
-	skynetUnpinRoot                bool   // Use root as the base instead of the Skynet folder.
-	skynetUploadDefaultPath        string // Specify the file to serve when no specific file is specified.
-	skynetUploadDisableDefaultPath bool   // This skyfile will not have a default path. The only way to use it is to download it.
-	skynetUploadDryRun             bool   // Perform a dry-run of the upload. This returns the skylink without actually uploading the file to the network.
-	skynetUploadRoot               bool   // Use root as the base instead of the Skynet folder.
-	skynetUploadSeparately         bool   // When uploading all files from a directory, upload each file separately, generating individual skylinks.
-	skynetUploadSilent             bool   // Don't report progress while uploading
-	skynetPortalPublic             bool   // Specify if a portal is public or not
+	skynetBlocklistHash            bool     // Indicates if the input for the blocklist is already a hash.
+	skynetDownloadPortal           string   // Portal to use when trying to download a skylink.
+	skynetLsRecursive              bool     // List files of folder recursively.
+	skynetLsRoot                   bool     // Use root as the base instead of the Skynet folder.
+	skynetPinPortal                string   // Portal to use when trying to pin a skylink.
+	skynetPinsBaseChunkRedundancy  uint8    // Redundancy of the base chunk of a skylink kept pinned.
+	skynetPinsLabel                string   // Label of a skylink kept pinned.
+	skynetPinsRoot                 bool     // Use root as the base instead of the Skynet folder.
+	skynetPinsTurtleDexPath        string   // Siapath of a skylink kept pinned.
+	skynetUnpinRoot                bool     // Use root as the base instead of the Skynet folder.
+	skynetUploadCompression        string   // Specify the compression applied to the uploaded data.
+	skynetUploadDefaultPath        string   // Specify the file to serve when no specific file is specified.
+	skynetUploadDisableDefaultPath bool     // This skyfile will not have a default path. The only way to use it is to download it.
+	skynetUploadDryRun             bool     // Perform a dry-run of the upload. This returns the skylink without actually uploading the file to the network.
+	skynetUploadHeaders            []string // Custom HTTP headers to serve the skyfile with.
+	skynetUploadNotFoundPath       string   // Specify the file to serve with a 404 status when a requested path doesn't exist.
+	skynetUploadRecipients         string   // Comma separated public keys of the recipient skykeys to encrypt the skyfile for.
+	skynetUploadRoot               bool     // Use root as the base instead of the Skynet folder.
+	skynetUploadSeparately         bool     // When uploading all files from a directory, upload each file separately, generating individual skylinks.
+	skynetUploadSilent             bool     // Don't report progress while uploading
+	skynetUploadTryFiles           string   // Comma separated paths to try when a requested path doesn't exist.
+	skynetPortalPublic             bool     // Specify if a portal is public or not
 
 	// Utils Flags
 	dictionaryLanguage string // dictionary for seed utils
@@ -326,23 +374,43 @@ func initCmds() *cobra.Command {
 	root.AddCommand(hostdbCmd)
 	hostdbCmd.AddCommand(hostdbFiltermodeCmd, hostdbSetFiltermodeCmd, hostdbViewCmd)
 	hostdbCmd.Flags().IntVarP(&hostdbNumHosts, "numhosts", "n", 0, "Number of hosts to display from the hostdb")
+	addScoringPolicyFlags(hostdbViewCmd)
 
 	root.AddCommand(minerCmd)
 	minerCmd.AddCommand(minerStartCmd, minerStopCmd)
 
 	root.AddCommand(renterCmd)
-	renterCmd.AddCommand(renterAllowanceCmd, renterBubbleCmd, renterBackupCreateCmd, renterBackupListCmd, renterBackupLoadCmd,
+	renterCmd.AddCommand(renterAllowanceCmd, renterAuditCmd, renterBubbleCmd, renterBackupCreateCmd, renterBackupListCmd, renterBackupLoadCmd,
 		renterCleanCmd, renterContractsCmd, renterContractsRecoveryScanProgressCmd, renterDownloadCancelCmd,
 		renterDownloadsCmd, renterExportCmd, renterFilesDeleteCmd, renterFilesDownloadCmd,
 		renterFilesListCmd, renterFilesRenameCmd, renterFilesUnstuckCmd, renterFilesUploadCmd,
-		renterFuseCmd, renterLostCmd, renterPricesCmd, renterRatelimitCmd, renterSetAllowanceCmd,
-		renterSetLocalPathCmd, renterTriggerContractRecoveryScanCmd, renterUploadsCmd, renterWorkersCmd,
-		renterHealthSummaryCmd)
+		renterFuseCmd, renterLostCmd, renterPlacementCmd, renterPricesCmd, renterRatelimitCmd, renterRebalanceCmd, renterRedundancyPolicyCmd, renterSetAllowanceCmd,
+		renterScoringPolicyCmd, renterSectorCacheCmd, renterSetLocalPathCmd, renterSyncCmd, renterTriggerContractRecoveryScanCmd, renterUploadsCmd, renterWorkersCmd,
+		renterHealthSummaryCmd, renterVersionsCmd)
 	renterWorkersCmd.AddCommand(renterWorkersAccountsCmd, renterWorkersDownloadsCmd, renterWorkersPriceTableCmd, renterWorkersReadJobsCmd, renterWorkersHasSectorJobSCmd, renterWorkersUploadsCmd, renterWorkersReadRegistryCmd, renterWorkersUpdateRegistryCmd)
 
 	renterAllowanceCmd.AddCommand(renterAllowanceCancelCmd)
+	renterAuditCmd.AddCommand(renterAuditRunCmd)
+	renterPlacementCmd.AddCommand(renterPlacementSetCmd)
+	renterPlacementSetCmd.Flags().Uint64Var(&renterPlacementIPv4Subnet, "ipv4-subnet", 0, "the max number of pieces per IPv4 /16 subnet")
+	renterPlacementSetCmd.Flags().Uint64Var(&renterPlacementIPv6Subnet, "ipv6-subnet", 0, "the max number of pieces per IPv6 /48 subnet")
+	renterPlacementSetCmd.Flags().Uint64Var(&renterPlacementASN, "asn", 0, "the max number of pieces per autonomous system, requires --asn-file")
+	renterPlacementSetCmd.Flags().Uint64Var(&renterPlacementHostGroup, "host-group", 0, "the max number of pieces per host group, requires --host-groups-file")
+	renterPlacementSetCmd.Flags().StringVar(&renterPlacementASNFile, "asn-file", "", "the path of an ip2asn TSV file mapping ip ranges to autonomous systems")
+	renterPlacementSetCmd.Flags().StringVar(&renterPlacementHostGroupsFile, "host-groups-file", "", "the path of a file assigning hosts to groups")
+	renterRebalanceCmd.AddCommand(renterRebalanceSetCmd)
+	renterRebalanceSetCmd.Flags().StringVar(&rebalanceBudget, "budget", "", "the amount of money which may be spent on migrations per period, specified in currency units")
+	renterRebalanceSetCmd.Flags().Float64Var(&rebalanceMaxCostRatio, "max-cost-ratio", 0, "migrate data away from hosts which are more expensive than the median host by this factor")
+	renterRebalanceSetCmd.Flags().Float64Var(&rebalanceMinScorePercentile, "min-score-percentile", 0, "migrate data away from hosts with a score below this percentile of the hostdb, between 0 and 1")
+	renterScoringPolicyCmd.AddCommand(renterScoringPolicySetCmd)
+	addScoringPolicyFlags(renterScoringPolicySetCmd)
+	renterSectorCacheCmd.AddCommand(renterSectorCacheSetCmd)
+	renterSectorCacheSetCmd.Flags().StringVar(&renterSectorCachePolicy, "policy", "", "the eviction policy of the cache, 'lru' or 'lfu'")
+	renterSyncCmd.AddCommand(renterSyncAddCmd, renterSyncRemoveCmd)
+	renterSyncAddCmd.Flags().BoolVar(&renterSyncPropagateDeletions, "propagate-deletions", false, "delete the uploaded files of deleted local files")
+	renterSyncAddCmd.Flags().BoolVar(&renterSyncPropagateRenames, "propagate-renames", false, "rename the uploaded files of renamed local files")
 	renterBubbleCmd.Flags().BoolVarP(&renterBubbleAll, "all", "A", false, "Bubble the entire directory tree")
-	renterContractsCmd.AddCommand(renterContractsViewCmd)
+	renterContractsCmd.AddCommand(renterContractsPlanCmd, renterContractsViewCmd)
 	renterFilesUploadCmd.AddCommand(renterFilesUploadPauseCmd, renterFilesUploadResumeCmd)
 
 	renterContractsCmd.Flags().BoolVarP(&renterAllContracts, "all", "A", false, "Show all expired contracts in addition to active contracts")
@@ -355,8 +423,15 @@ func initCmds() *cobra.Command {
 	renterFilesListCmd.Flags().BoolVar(&renterListRoot, "root", false, "List files and folders from root instead of from the user home directory")
 	renterFilesUploadCmd.Flags().StringVar(&dataPieces, "data-pieces", "", "the number of data pieces a files should be uploaded with")
 	renterFilesUploadCmd.Flags().StringVar(&parityPieces, "parity-pieces", "", "the number of parity pieces a files should be uploaded with")
+	renterRedundancyPolicyCmd.Flags().StringVar(&dataPieces, "data-pieces", "", "the number of data pieces of the policy's erasure code")
+	renterRedundancyPolicyCmd.Flags().StringVar(&parityPieces, "parity-pieces", "", "the number of parity pieces of the policy's erasure code")
+	renterFilesUploadCmd.Flags().BoolVar(&renterDedup, "dedup", false, "deduplicate the file's chunks against previously deduplicated uploads")
+	renterFilesUploadCmd.Flags().StringVar(&renterUploadCompression, "compression", "", "compress the file before uploading it, supported types are 'none' and 'gzip'")
 	renterExportCmd.AddCommand(renterExportContractTxnsCmd)
 	renterFilesRenameCmd.Flags().BoolVar(&renterRenameRoot, "root", false, "Rename files relative to root instead of the user homedir")
+	renterVersionsCmd.AddCommand(renterVersionsPolicyCmd, renterVersionsPurgeCmd, renterVersionsRestoreCmd)
+	renterVersionsPolicyCmd.Flags().Uint64Var(&renterVersionsMaxVersions, "max-versions", 0, "the number of most recent versions to retain")
+	renterVersionsPolicyCmd.Flags().StringVar(&renterVersionsRetentionWindow, "retention-window", "", "retain all versions younger than this duration in seconds (s), hours (h), days (d) or weeks (w)")
 
 	renterSetAllowanceCmd.Flags().StringVar(&allowanceFunds, "amount", "", "amount of money in allowance, specified in currency units")
 	renterSetAllowanceCmd.Flags().StringVar(&allowancePeriod, "period", "", "period of allowance in blocks (b), hours (h), days (d) or weeks (w)")
@@ -376,24 +451,36 @@ func initCmds() *cobra.Command {
 
 	renterFuseCmd.AddCommand(renterFuseMountCmd, renterFuseUnmountCmd)
 	renterFuseMountCmd.Flags().BoolVarP(&renterFuseMountAllowOther, "allow-other", "", false, "Allow users other than the user that mounted the fuse directory to access and use the fuse directory")
+	renterFuseMountCmd.Flags().BoolVarP(&renterFuseMountReadOnly, "read-only", "", false, "Mount the fuse directory in read-only mode")
 
 	root.AddCommand(skynetCmd)
-	skynetCmd.AddCommand(skynetBackupCmd, skynetBlocklistCmd, skynetConvertCmd, skynetDownloadCmd, skynetIsBlockedCmd, skynetLsCmd, skynetPinCmd, skynetPortalsCmd, skynetRestoreCmd, skynetUnpinCmd, skynetUploadCmd)
+	skynetCmd.AddCommand(skynetBackupCmd, skynetBlocklistCmd, skynetConvertCmd, skynetDownloadCmd, skynetIsBlockedCmd, skynetLsCmd, skynetPinCmd, skynetPinsCmd, skynetPortalsCmd, skynetRegistryCmd, skynetRestoreCmd, skynetUnpinCmd, skynetUploadCmd)
+	skynetRegistryCmd.AddCommand(skynetRegistrySkylinkV2Cmd, skynetRegistryWatchCmd)
 	skynetConvertCmd.Flags().StringVar(&skykeyName, "skykeyname", "", "Specify the skykey to be used by name.")
 	skynetConvertCmd.Flags().StringVar(&skykeyID, "skykeyid", "", "Specify the skykey to be used by id.")
 	skynetUploadCmd.Flags().BoolVar(&skynetUploadRoot, "root", false, "Use the root folder as the base instead of the Skynet folder")
 	skynetUploadCmd.Flags().BoolVar(&skynetUploadDryRun, "dry-run", false, "Perform a dry-run of the upload, returning the skylink without actually uploading the file")
 	skynetUploadCmd.Flags().BoolVarP(&skynetUploadSeparately, "separately", "", false, "Upload each file separately, generating individual skylinks")
 	skynetUploadCmd.Flags().StringVar(&skynetUploadDefaultPath, "defaultpath", "", "Specify the file to serve when no specific file is specified.")
+	skynetUploadCmd.Flags().StringVar(&skynetUploadCompression, "compression", "", "Compress the data before uploading it, supported types are 'none' and 'gzip'")
 	skynetUploadCmd.Flags().BoolVarP(&skynetUploadDisableDefaultPath, "disabledefaultpath", "", false, "This skyfile will not have a default path. The only way to use it is to download it. Mutually exclusive with --defaultpath")
 	skynetUploadCmd.Flags().BoolVarP(&skynetUploadSilent, "silent", "", false, "Don't report progress while uploading")
+	skynetUploadCmd.Flags().StringArrayVar(&skynetUploadHeaders, "header", nil, "Custom HTTP header to serve the skyfile with, e.g. 'Cache-Control: max-age=3600'. Can be repeated.")
+	skynetUploadCmd.Flags().StringVar(&skynetUploadTryFiles, "tryfiles", "", "Comma separated paths to serve when a requested path doesn't exist, '$uri' is replaced with the requested path, e.g. '$uri.html,/index.html'. Directory uploads only.")
+	skynetUploadCmd.Flags().StringVar(&skynetUploadNotFoundPath, "notfoundpath", "", "Specify the file to serve with a 404 status when a requested path doesn't exist. Directory uploads only.")
 	skynetUploadCmd.Flags().StringVar(&skykeyID, "skykeyid", "", "Specify the skykey to be used by its key identifier.")
 	skynetUploadCmd.Flags().StringVar(&skykeyName, "skykeyname", "", "Specify the skykey to be used by name.")
+	skynetUploadCmd.Flags().StringVar(&skynetUploadRecipients, "recipients", "", "Comma separated public keys of at most 2 recipient skykeys to encrypt the skyfile for. Mutually exclusive with --skykeyname and --skykeyid")
 	skynetUnpinCmd.Flags().BoolVar(&skynetUnpinRoot, "root", false, "Use the root folder as the base instead of the Skynet folder")
 	skynetDownloadCmd.Flags().StringVar(&skynetDownloadPortal, "portal", "", "Use a Skynet portal to complete the download")
 	skynetLsCmd.Flags().BoolVarP(&skynetLsRecursive, "recursive", "R", false, "Recursively list skyfiles and folders")
 	skynetLsCmd.Flags().BoolVar(&skynetLsRoot, "root", false, "Use the root folder as the base instead of the Skynet folder")
 	skynetPinCmd.Flags().StringVar(&skynetPinPortal, "portal", "", "Use a Skynet portal to download the skylink in order to pin the skyfile")
+	skynetPinsCmd.AddCommand(skynetPinsAddCmd, skynetPinsLsCmd, skynetPinsRemoveCmd, skynetPinsStatusCmd)
+	skynetPinsAddCmd.Flags().StringVar(&skynetPinsLabel, "label", "", "Label of the pin")
+	skynetPinsAddCmd.Flags().StringVar(&skynetPinsTurtleDexPath, "siapath", "", "Siapath to pin the skyfile to")
+	skynetPinsAddCmd.Flags().BoolVar(&skynetPinsRoot, "root", false, "Use the root folder as the base instead of the Skynet folder")
+	skynetPinsAddCmd.Flags().Uint8Var(&skynetPinsBaseChunkRedundancy, "basechunkredundancy", 0, "Redundancy of the base chunk and number of hosts which should store it, 0 uses the default")
 	skynetBlocklistCmd.AddCommand(skynetBlocklistAddCmd, skynetBlocklistRemoveCmd)
 	skynetBlocklistAddCmd.Flags().BoolVar(&skynetBlocklistHash, "hash", false, "Indicates if the input is already a hash of the Skylink's Merkleroot")
 	skynetBlocklistRemoveCmd.Flags().BoolVar(&skynetBlocklistHash, "hash", false, "Indicates if the input is already a hash of the Skylink's Merkleroot")
@@ -401,7 +488,7 @@ func initCmds() *cobra.Command {
 	skynetPortalsAddCmd.Flags().BoolVar(&skynetPortalPublic, "public", false, "Add this Skynet portal as public")
 
 	root.AddCommand(skykeyCmd)
-	skykeyCmd.AddCommand(skykeyAddCmd, skykeyCreateCmd, skykeyDeleteCmd, skykeyGetCmd, skykeyGetIDCmd, skykeyListCmd)
+	skykeyCmd.AddCommand(skykeyAddCmd, skykeyCreateCmd, skykeyDeleteCmd, skykeyGetCmd, skykeyGetIDCmd, skykeyGetRecipientCmd, skykeyListCmd)
 	skykeyAddCmd.Flags().StringVar(&skykeyRenameAs, "rename-as", "", "The new name for the skykey being added")
 	skykeyCreateCmd.Flags().StringVar(&skykeyType, "type", "", "The type of the skykey")
 	skykeyDeleteCmd.AddCommand(skykeyDeleteNameCmd, skykeyDeleteIDCmd)
@@ -410,13 +497,14 @@ func initCmds() *cobra.Command {
 	skykeyListCmd.Flags().BoolVar(&skykeyShowPrivateKeys, "show-priv-keys", false, "Show private key data.")
 
 	// Daemon Commands
-	root.AddCommand(alertsCmd, globalRatelimitCmd, profileCmd, stackCmd, stopCmd, updateCmd, versionCmd)
+	root.AddCommand(alertsCmd, globalRatelimitCmd, profileCmd, stackCmd, stopCmd, tokensCmd, updateCmd, versionCmd)
 	profileCmd.AddCommand(profileStartCmd, profileStopCmd)
 	profileStartCmd.Flags().BoolVarP(&daemonCPUProfile, "cpu", "c", false, "Start the CPU profile")
 	profileStartCmd.Flags().BoolVarP(&daemonMemoryProfile, "memory", "m", false, "Start the Memory profile")
 	profileStartCmd.Flags().StringVar(&daemonProfileDirectory, "profileDir", "", "Specify the directory where the profile logs are to be saved")
 	profileStartCmd.Flags().BoolVarP(&daemonTraceProfile, "trace", "t", false, "Start the Trace profile")
 	stackCmd.Flags().StringVarP(&daemonStackOutputFile, "filename", "f", "stack.txt", "Specify the output file for the stack trace")
+	tokensCmd.AddCommand(tokensCreateCmd, tokensRevokeCmd)
 	updateCmd.AddCommand(updateCheckCmd)
 
 	root.AddCommand(utilsCmd)
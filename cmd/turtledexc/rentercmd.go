@@ -56,6 +56,24 @@ var (
 		Run:   wrap(renterallowancecmd),
 	}
 
+	renterAuditCmd = &cobra.Command{
+		Use:   "audit",
+		Short: "View the results of the integrity audits",
+		Long: `View the results of the integrity audits which spot check that hosts still
+store the renter's data. Every audit downloads a random segment of a random
+sample of pieces and contracts together with a merkle proof. Hosts which fail
+to prove that they store a piece are penalized in the hostdb and the piece is
+repaired.`,
+		Run: wrap(renterauditcmd),
+	}
+
+	renterAuditRunCmd = &cobra.Command{
+		Use:   "run",
+		Short: "Start an integrity audit",
+		Long:  "Start an integrity audit without waiting for the next scheduled one.",
+		Run:   wrap(renterauditruncmd),
+	}
+
 	renterBubbleCmd = &cobra.Command{
 		Use:   "bubble [directory]",
 		Short: "Call bubble on a directory.",
@@ -110,6 +128,19 @@ the command 'ttdxc renter lost' to see the renter's lost files.`,
 		Run:   wrap(rentercontractscmd),
 	}
 
+	renterContractsPlanCmd = &cobra.Command{
+		Use:   "plan [amount] [period] [hosts] [renew window]",
+		Short: "Preview the next round of contract maintenance",
+		Long: `Show the contracts the next round of contract maintenance would cancel, renew,
+refresh and form, together with their estimated costs. No money is spent and
+no contracts are changed.
+
+An allowance can be provided to preview the effect of changing the allowance,
+otherwise the current allowance is used. The hosts of the planned formations
+are picked randomly, so maintenance might form contracts with other hosts.`,
+		Run: rentercontractsplancmd,
+	}
+
 	renterContractsRecoveryScanProgressCmd = &cobra.Command{
 		Use:   "recoveryscanprogress",
 		Short: "Returns the recovery scan progress.",
@@ -179,10 +210,10 @@ the command 'ttdxc renter lost' to see the renter's lost files.`,
 		Use:   "mount [path] [siapath]",
 		Short: "Mount a TurtleDex folder to your disk",
 		Long: `Mount a TurtleDex folder to your disk. Applications will be able to see this folder
-as though it is a normal part of your filesystem.  Currently experimental, and
-read-only. When TurtleDex is ready to support read-write fuse mounting, ttdxc will be
-updated to mount in read-write mode as the default. If you must guarantee that
-read-only mode is used, you must use the API.`,
+as though it is a normal part of your filesystem.  Currently experimental. The
+folder is mounted in read-write mode by default, files written to the folder are
+uploaded once they are closed. Use --read-only to mount the folder in read-only
+mode.`,
 		Run: wrap(renterfusemountcmd),
 	}
 
@@ -194,6 +225,50 @@ local path where the TurtleDex folder is mounted.`,
 		Run: wrap(renterfuseunmountcmd),
 	}
 
+	renterSectorCacheCmd = &cobra.Command{
+		Use:   "sectorcache",
+		Short: "View the status of the sector cache",
+		Long:  "View the size, settings and hit rate of the renter's on-disk cache of downloaded sectors.",
+		Run:   wrap(rentersectorcachecmd),
+	}
+
+	renterSectorCacheSetCmd = &cobra.Command{
+		Use:   "set [maxsize]",
+		Short: "Set the maximum size of the sector cache",
+		Long: `Set the maximum size of the renter's on-disk cache of downloaded sectors,
+e.g. '10GB' or '500MiB'. A size of 0 disables the cache and removes all cached
+sectors. Once the cache is full, sectors are evicted according to the --policy
+which is either 'lru' (least recently used) or 'lfu' (least frequently used).`,
+		Run: wrap(rentersectorcachesetcmd),
+	}
+
+	renterSyncCmd = &cobra.Command{
+		Use:   "sync",
+		Short: "View the status of the sync folders",
+		Long: `View the local directories the renter keeps in sync with TurtleDex directories,
+together with the number of synced files and any conflicts.`,
+		Run: wrap(rentersynccmd),
+	}
+
+	renterSyncAddCmd = &cobra.Command{
+		Use:   "add [path] [siapath]",
+		Short: "Sync a local directory with a TurtleDex directory",
+		Long: `Start syncing a local directory with a TurtleDex directory. New and changed
+files within the local directory are uploaded automatically. Deleting or
+renaming local files only deletes or renames the uploaded files if
+--propagate-deletions or --propagate-renames is set. Files which were changed
+within TurtleDex since they were last synced are never overwritten, they are
+reported as conflicts instead.`,
+		Run: wrap(rentersyncaddcmd),
+	}
+
+	renterSyncRemoveCmd = &cobra.Command{
+		Use:   "remove [path]",
+		Short: "Stop syncing a local directory",
+		Long:  "Stop syncing a local directory. Files which were already uploaded are kept.",
+		Run:   wrap(rentersyncremovecmd),
+	}
+
 	renterSetLocalPathCmd = &cobra.Command{
 		Use:   "setlocalpath [siapath] [newlocalpath]",
 		Short: "Changes the local path of the file",
@@ -244,6 +319,101 @@ allowance of 500SC, 12w period, 50 hosts, and 4w renew window will be used.`,
 		Run: renterpricescmd,
 	}
 
+	renterPlacementCmd = &cobra.Command{
+		Use:   "placement",
+		Short: "View the placement policy",
+		Long:  "View the limits of the placement policy which spreads contracts and pieces across failure domains.",
+		Run:   wrap(renterplacementcmd),
+	}
+
+	renterPlacementSetCmd = &cobra.Command{
+		Use:   "set",
+		Short: "Set the placement policy",
+		Long: `Set the maximum number of hosts the renter forms contracts with and the
+maximum number of pieces of a chunk the renter uploads per failure domain.
+Failure domains are IPv4 /16 subnets, IPv6 /48 subnets, autonomous systems
+and user-defined host groups. Limits which aren't set are disabled.
+
+Limiting the pieces per autonomous system requires an ip2asn TSV file
+(--asn-file). Limiting the pieces per host group requires a host groups file
+(--host-groups-file) where every line assigns a host public key, ip address or
+cidr to a group, e.g. 'dc1 10.0.0.0/8'. The files are read when the policy is
+set, so changes to them require setting the policy again.
+
+To make sure that the outage of a single failure domain can't make a file
+unavailable, the limits can't exceed the number of parity pieces of the default
+redundancy.`,
+		Run: wrap(renterplacementsetcmd),
+	}
+
+	renterRebalanceCmd = &cobra.Command{
+		Use:   "rebalance",
+		Short: "View the status of the rebalancer",
+		Long: `View the rebalance policy, the budget spent on migrations in the current period
+and the contracts whose data is migrated away from degraded or overpriced
+hosts.`,
+		Run: wrap(renterrebalancecmd),
+	}
+
+	renterRebalanceSetCmd = &cobra.Command{
+		Use:   "set",
+		Short: "Set the rebalance policy",
+		Long: `Set the policy for migrating data away from hosts which are still usable but
+considerably worse or more expensive than the hosts the renter could use
+instead. The contracts with such hosts are no longer used for uploads or
+renewed and the repair loop moves their data to other hosts.
+
+The thresholds are relative to the active hosts of the hostdb. Data is
+migrated away from a host if its score is below the --min-score-percentile of
+the active hosts or if its cost of storing and transferring data is more than
+--max-cost-ratio times the median cost of the active hosts. A threshold of 0
+disables it. Once a host passes both thresholds again, its contract is used
+again.
+
+The --budget limits the estimated cost of the migrations per period. The
+migrations also count towards the contract churn and are therefore limited by
+the max period churn of the allowance. Setting no budget disables the
+rebalancer and cancels pending migrations.`,
+		Run: wrap(renterrebalancesetcmd),
+	}
+
+	renterScoringPolicyCmd = &cobra.Command{
+		Use:   "scoringpolicy",
+		Short: "View the host scoring policy",
+		Long:  "View the weights and limits of the policy which tunes how the renter scores hosts.",
+		Run:   wrap(renterscoringpolicycmd),
+	}
+
+	renterScoringPolicySetCmd = &cobra.Command{
+		Use:   "set",
+		Short: "Set the host scoring policy",
+		Long: `Update the weights and limits of the policy which tunes how the renter scores
+hosts. Only the provided flags are changed.
+
+Every adjustment of the host score is raised to the power of its weight. The
+default weight is 1, a weight of 0 disables the adjustment and weights greater
+than 1 make it more important.
+
+The limits are hard requirements. Hosts which don't meet them receive the
+lowest possible score and won't be selected for new contracts. A limit of 0
+removes it.
+
+The effect of a policy on a host can be previewed with 'hostdb view' and the
+same flags.`,
+		Run: wrap(renterscoringpolicysetcmd),
+	}
+
+	renterRedundancyPolicyCmd = &cobra.Command{
+		Use:   "redundancypolicy [path]",
+		Short: "Set the redundancy policy of a directory",
+		Long: `Set the erasure code used for the files within a directory and its
+subdirectories without a policy of their own. New uploads use the policy
+unless --data-pieces and --parity-pieces are specified for the upload.
+Existing files using a different erasure code are re-encoded in the
+background. Setting neither flag removes the policy.`,
+		Run: wrap(renterredundancypolicycmd),
+	}
+
 	renterRatelimitCmd = &cobra.Command{
 		Use:   "ratelimit [maxdownloadspeed] [maxuploadspeed]",
 		Short: "Set maxdownloadspeed and maxuploadspeed",
@@ -288,6 +458,38 @@ have a reasonable number (>30) of hosts in your hostdb.`,
 		Run:   wrap(renteruploadscmd),
 	}
 
+	renterVersionsCmd = &cobra.Command{
+		Use:   "versions [path]",
+		Short: "List the retained versions of a file",
+		Long:  "List the retained versions of a file which was deleted or overwritten within a directory with versioning enabled.",
+		Run:   wrap(renterversionscmd),
+	}
+
+	renterVersionsPolicyCmd = &cobra.Command{
+		Use:   "policy [path]",
+		Short: "Set the versioning policy of a directory",
+		Long: `Set the versioning policy of a directory. A version of a file is retained
+as long as it is one of the --max-versions most recent versions or younger
+than the --retention-window. Setting neither disables versioning. Versions
+which were retained before versioning was disabled are kept until they are
+purged.`,
+		Run: wrap(renterversionspolicycmd),
+	}
+
+	renterVersionsPurgeCmd = &cobra.Command{
+		Use:   "purge [path] [id]",
+		Short: "Delete retained versions of a file",
+		Long:  "Delete a retained version of a file. All versions of the file are deleted if no id is provided.",
+		Run:   renterversionspurgecmd,
+	}
+
+	renterVersionsRestoreCmd = &cobra.Command{
+		Use:   "restore [path] [id]",
+		Short: "Restore a version of a file",
+		Long:  "Restore a version of a file. If the file currently exists, it is retained as a new version.",
+		Run:   wrap(renterversionsrestorecmd),
+	}
+
 	renterWorkersCmd = &cobra.Command{
 		Use:   "workers",
 		Short: "View the Renter's workers",
@@ -442,6 +644,26 @@ func rentercmd() {
 		die(err)
 	}
 
+	// Print out the deduplication information if deduplication was used.
+	if ds := rg.DedupStatus; ds.UniqueChunks > 0 {
+		fmt.Printf(`
+Deduplication:
+  Unique Chunks:  %v
+  References:     %v
+  Saved Storage:  %v
+`, ds.UniqueChunks, ds.References, sizeString(ds.SavedStorage))
+	}
+
+	// Print out the sector cache information if the cache is enabled.
+	if scs := rg.SectorCacheStatus; scs.Enabled() {
+		fmt.Printf(`
+Sector Cache:
+  Size:    %v / %v
+  Hits:    %v
+  Misses:  %v
+`, sizeString(scs.Size), sizeString(scs.MaxSize), scs.Hits, scs.Misses)
+	}
+
 	if !verbose {
 		return
 	}
@@ -1694,6 +1916,89 @@ func rentertriggercontractrecoveryrescancmd() {
 	fmt.Println("Successfully triggered contract recovery scan.")
 }
 
+// rentercontractsplancmd is the handler for the command `ttdxc renter contracts
+// plan`. It displays the actions the next round of contract maintenance would
+// take.
+func rentercontractsplancmd(cmd *cobra.Command, args []string) {
+	allowance := parseAllowanceArgs(cmd, args)
+	plan, err := httpClient.RenterContractsPlanGet(allowance)
+	if err != nil {
+		die("Could not plan contract maintenance:", err)
+	}
+
+	walletBalance := currencyUnits(plan.WalletBalance)
+	if !plan.WalletUnlocked {
+		walletBalance = "locked"
+	}
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Contract Maintenance Plan:")
+	fmt.Fprintf(w, "  Block Height:\t%v\n", plan.BlockHeight)
+	fmt.Fprintf(w, "  Contract End Height:\t%v\n", plan.EndHeight)
+	fmt.Fprintf(w, "  Allowance:\t%v, %v hosts, %v blocks period, %v blocks renew window\n", currencyUnits(plan.Allowance.Funds), plan.Allowance.Hosts, plan.Allowance.Period, plan.Allowance.RenewWindow)
+	fmt.Fprintf(w, "  Unallocated Allowance:\t%v\n", currencyUnits(plan.FundsRemaining))
+	fmt.Fprintf(w, "  Wallet Balance:\t%v\n", walletBalance)
+	fmt.Fprintf(w, "  Total Cost:\t%v\n", currencyUnits(plan.TotalCost))
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+	if plan.WalletUnlocked && plan.TotalCost.Cmp(plan.WalletBalance) > 0 {
+		fmt.Println("\nWARNING: the wallet balance doesn't cover the total cost of the plan.")
+	}
+
+	// note returns the reason for an action, or the reason for skipping it.
+	note := func(action modules.PlannedContractAction) string {
+		if action.SkipReason != "" {
+			return "skipped: " + action.SkipReason
+		}
+		return action.Reason
+	}
+
+	fmt.Printf("\nCancellations (%v):\n", len(plan.Cancellations))
+	if len(plan.Cancellations) > 0 {
+		w = tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  Host PubKey\tNet Address\tContract ID\tSize\tReason")
+		for _, a := range plan.Cancellations {
+			fmt.Fprintf(w, "  %v\t%v\t%v\t%v\t%v\n", a.HostPublicKey.String(), a.NetAddress, a.ContractID, modules.FilesizeUnits(a.Size), a.Reason)
+		}
+		if err := w.Flush(); err != nil {
+			die("failed to flush writer:", err)
+		}
+	}
+
+	for _, set := range []struct {
+		name    string
+		actions []modules.PlannedContractAction
+	}{
+		{"Renewals", plan.Renewals},
+		{"Refreshes", plan.Refreshes},
+	} {
+		fmt.Printf("\n%v (%v):\n", set.name, len(set.actions))
+		if len(set.actions) == 0 {
+			continue
+		}
+		w = tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  Host PubKey\tNet Address\tContract ID\tSize\tEstimated Cost\tNote")
+		for _, a := range set.actions {
+			fmt.Fprintf(w, "  %v\t%v\t%v\t%v\t%v\t%v\n", a.HostPublicKey.String(), a.NetAddress, a.ContractID, modules.FilesizeUnits(a.Size), currencyUnits(a.EstimatedCost), note(a))
+		}
+		if err := w.Flush(); err != nil {
+			die("failed to flush writer:", err)
+		}
+	}
+
+	fmt.Printf("\nFormations (%v):\n", len(plan.Formations))
+	if len(plan.Formations) > 0 {
+		w = tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  Host PubKey\tNet Address\tEstimated Cost\tNote")
+		for _, a := range plan.Formations {
+			fmt.Fprintf(w, "  %v\t%v\t%v\t%v\n", a.HostPublicKey.String(), a.NetAddress, currencyUnits(a.EstimatedCost), note(a))
+		}
+		if err := w.Flush(); err != nil {
+			die("failed to flush writer:", err)
+		}
+	}
+}
+
 // rentercontractrecoveryscanprogresscmd returns the current progress of a
 // potentially ongoing recovery scan.
 func rentercontractrecoveryscanprogresscmd() {
@@ -1868,6 +2173,241 @@ func renterfilesrenamecmd(path, newpath string) {
 	fmt.Printf("Renamed %s to %s\n", path, newpath)
 }
 
+// rentersectorcachecmd is the handler for the command `ttdxc renter
+// sectorcache`. Prints the status of the sector cache.
+func rentersectorcachecmd() {
+	rg, err := httpClient.RenterGet()
+	if err != nil {
+		die("Could not get renter info:", err)
+	}
+	scs := rg.SectorCacheStatus
+	if !scs.Enabled() {
+		fmt.Println("The sector cache is disabled.")
+		return
+	}
+	var hitRate float64
+	if scs.Hits+scs.Misses > 0 {
+		hitRate = 100 * float64(scs.Hits) / float64(scs.Hits+scs.Misses)
+	}
+	fmt.Printf(`Sector Cache:
+  Size:             %v / %v
+  Entries:          %v
+  Eviction Policy:  %v
+  Hits:             %v (%.2f%%)
+  Misses:           %v
+  Served:           %v
+  Evictions:        %v
+`, sizeString(scs.Size), sizeString(scs.MaxSize), scs.Entries, scs.EvictionPolicy, scs.Hits, hitRate, scs.Misses, sizeString(scs.HitBytes), scs.Evictions)
+}
+
+// rentersectorcachesetcmd is the handler for the command `ttdxc renter
+// sectorcache set [maxsize]`. Updates the settings of the sector cache.
+func rentersectorcachesetcmd(maxSize string) {
+	size, err := parseFilesize(maxSize)
+	if err != nil {
+		die("Could not parse max size:", err)
+	}
+	settings := modules.SectorCacheSettings{
+		EvictionPolicy: modules.SectorCacheEvictionPolicy(renterSectorCachePolicy),
+	}
+	settings.MaxSize, err = strconv.ParseUint(size, 10, 64)
+	if err != nil {
+		die("Could not parse max size:", err)
+	}
+	err = httpClient.RenterSectorCachePost(settings)
+	if err != nil {
+		die("Could not update sector cache:", err)
+	}
+	if !settings.Enabled() {
+		fmt.Println("Disabled the sector cache")
+		return
+	}
+	fmt.Println("Set the maximum size of the sector cache to", sizeString(settings.MaxSize))
+}
+
+// rentersynccmd is the handler for the command `ttdxc renter sync`. Displays
+// the status of the sync folders.
+func rentersynccmd() {
+	rsg, err := httpClient.RenterSyncGet()
+	if err != nil {
+		die("Could not get sync folders:", err)
+	}
+	if len(rsg.Folders) == 0 {
+		fmt.Println("No sync folders.")
+		return
+	}
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return "never"
+		}
+		return t.Format(time.RFC822)
+	}
+	for _, folder := range rsg.Folders {
+		siaPathStr := folder.TurtleDexPath.String()
+		if siaPathStr == "" {
+			siaPathStr = "{root}"
+		}
+		w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "%v:\n", folder.LocalPath)
+		fmt.Fprintf(w, "  TurtleDexPath:\t%v\n", siaPathStr)
+		fmt.Fprintf(w, "  Watch Mode:\t%v\n", folder.WatchMode)
+		fmt.Fprintf(w, "  Propagate Deletions:\t%v\n", yesNo(folder.PropagateDeletions))
+		fmt.Fprintf(w, "  Propagate Renames:\t%v\n", yesNo(folder.PropagateRenames))
+		fmt.Fprintf(w, "  Last Sync:\t%v\n", formatTime(folder.LastSync))
+		fmt.Fprintf(w, "  Synced Files:\t%v\n", folder.SyncedFiles)
+		fmt.Fprintf(w, "  Uploads:\t%v\n", folder.Uploads)
+		fmt.Fprintf(w, "  Deletions:\t%v\n", folder.Deletions)
+		fmt.Fprintf(w, "  Renames:\t%v\n", folder.Renames)
+		if folder.LastError != "" {
+			fmt.Fprintf(w, "  Last Error:\t%v\n", folder.LastError)
+		}
+		fmt.Fprintf(w, "  Conflicts:\t%v\n", len(folder.Conflicts))
+		for _, conflict := range folder.Conflicts {
+			fmt.Fprintf(w, "    %v\t%v\n", conflict.LocalPath, conflict.Reason)
+		}
+		if err := w.Flush(); err != nil {
+			die("failed to flush writer:", err)
+		}
+		fmt.Println()
+	}
+}
+
+// rentersyncaddcmd is the handler for the command `ttdxc renter sync add
+// [path] [siapath]`. Starts syncing a local directory.
+func rentersyncaddcmd(path, siaPathStr string) {
+	path = abs(path)
+	var siaPath modules.TurtleDexPath
+	var err error
+	if siaPathStr == "" || siaPathStr == "/" {
+		siaPath = modules.RootTurtleDexPath()
+	} else {
+		siaPath, err = modules.NewTurtleDexPath(siaPathStr)
+		if err != nil {
+			die("Unable to parse the siapath:", err)
+		}
+	}
+	err = httpClient.RenterSyncAddPost(modules.SyncFolderConfig{
+		LocalPath:          path,
+		TurtleDexPath:      siaPath,
+		PropagateDeletions: renterSyncPropagateDeletions,
+		PropagateRenames:   renterSyncPropagateRenames,
+	})
+	if err != nil {
+		die("Unable to add sync folder:", err)
+	}
+	fmt.Printf("Syncing %v with %v\n", path, siaPathStr)
+}
+
+// rentersyncremovecmd is the handler for the command `ttdxc renter sync
+// remove [path]`. Stops syncing a local directory.
+func rentersyncremovecmd(path string) {
+	path = abs(path)
+	err := httpClient.RenterSyncRemovePost(path)
+	if err != nil {
+		die("Unable to remove sync folder:", err)
+	}
+	fmt.Printf("Stopped syncing %v\n", path)
+}
+
+// renterversionscmd is the handler for the command `ttdxc renter versions
+// [path]`. Lists the retained versions of a file.
+func renterversionscmd(path string) {
+	siaPath, err := modules.NewTurtleDexPath(path)
+	if err != nil {
+		die("Couldn't parse TurtleDexPath:", err)
+	}
+	rfv, err := httpClient.RenterVersionsGet(siaPath)
+	if err != nil {
+		die("Could not get versions:", err)
+	}
+	if len(rfv.Versions) == 0 {
+		fmt.Println("No versions retained.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tCreated\tModified\tSize\tHealth\tRedundancy")
+	for _, v := range rfv.Versions {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%.2f%%\t%.2f\n", v.ID, v.CreateTime.Format(time.RFC3339), v.ModificationTime.Format(time.RFC3339),
+			modules.FilesizeUnits(v.Filesize), modules.HealthPercentage(v.Health), v.Redundancy)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// renterversionspolicycmd is the handler for the command `ttdxc renter
+// versions policy [path]`. Sets the versioning policy of a directory.
+func renterversionspolicycmd(path string) {
+	siaPath, err := modules.NewTurtleDexPath(path)
+	if err != nil {
+		die("Couldn't parse TurtleDexPath:", err)
+	}
+	policy := modules.VersioningPolicy{
+		MaxVersions: renterVersionsMaxVersions,
+	}
+	if renterVersionsRetentionWindow != "" {
+		seconds, err := parseTimeout(renterVersionsRetentionWindow)
+		if err != nil {
+			die("Could not parse retention window:", err)
+		}
+		secs, err := strconv.ParseUint(seconds, 10, 64)
+		if err != nil {
+			die("Could not parse retention window:", err)
+		}
+		policy.RetentionWindow = time.Duration(secs) * time.Second
+	}
+	err = httpClient.RenterDirSetVersioningPost(siaPath, policy)
+	if err != nil {
+		die("Could not set versioning policy:", err)
+	}
+	if !policy.Enabled() {
+		fmt.Printf("Disabled versioning for %v\n", path)
+		return
+	}
+	fmt.Printf("Set versioning policy of %v\n", path)
+}
+
+// renterversionspurgecmd is the handler for the command `ttdxc renter versions
+// purge [path] [id]`. Deletes retained versions of a file.
+func renterversionspurgecmd(cmd *cobra.Command, args []string) {
+	var id string
+	switch len(args) {
+	case 1:
+	case 2:
+		id = args[1]
+	default:
+		_ = cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+	siaPath, err := modules.NewTurtleDexPath(args[0])
+	if err != nil {
+		die("Couldn't parse TurtleDexPath:", err)
+	}
+	err = httpClient.RenterVersionsPurgePost(siaPath, id)
+	if err != nil {
+		die("Could not purge versions:", err)
+	}
+	if id == "" {
+		fmt.Printf("Purged all versions of %v\n", args[0])
+		return
+	}
+	fmt.Printf("Purged version %v of %v\n", id, args[0])
+}
+
+// renterversionsrestorecmd is the handler for the command `ttdxc renter
+// versions restore [path] [id]`. Restores a version of a file.
+func renterversionsrestorecmd(path, id string) {
+	siaPath, err := modules.NewTurtleDexPath(path)
+	if err != nil {
+		die("Couldn't parse TurtleDexPath:", err)
+	}
+	err = httpClient.RenterVersionRestorePost(siaPath, id)
+	if err != nil {
+		die("Could not restore version:", err)
+	}
+	fmt.Printf("Restored version %v of %v\n", id, path)
+}
+
 // renterfusecmd displays the list of directories that are currently mounted via
 // fuse.
 func renterfusecmd() {
@@ -1909,10 +2449,6 @@ func renterfusecmd() {
 
 // renterfusemountcmd is the handler for the command `ttdxc renter fuse mount [path] [siapath]`.
 func renterfusemountcmd(path, siaPathStr string) {
-	// TODO: Once read-write is supported on the backend, the 'true' flag can be
-	// set to 'false' - ttdxc will support mounting read-write by default. Need
-	// to update the help string of the command to indicate that mounting will
-	// mount in read-write mode.
 	path = abs(path)
 	var siaPath modules.TurtleDexPath
 	var err error
@@ -1925,7 +2461,7 @@ func renterfusemountcmd(path, siaPathStr string) {
 		}
 	}
 	opts := modules.MountOptions{
-		ReadOnly:   true,
+		ReadOnly:   renterFuseMountReadOnly,
 		AllowOther: renterFuseMountAllowOther,
 	}
 	err = httpClient.RenterFuseMount(path, siaPath, opts)
@@ -2033,6 +2569,26 @@ func renterfilesuploadcmd(source, path string) {
 		die("Could not parse data and parity pieces:", err)
 	}
 
+	// Parse the compression type.
+	compression, err := modules.NewCompressionType(renterUploadCompression)
+	if err != nil {
+		die("Could not parse compression:", err)
+	}
+	if renterDedup && compression != modules.CompressionNone {
+		die("--dedup and --compression can't be combined")
+	}
+
+	// Define the upload function based on the flags.
+	uploadFile := func(file string, siaPath modules.TurtleDexPath) error {
+		if renterDedup {
+			return httpClient.RenterUploadDedupPost(abs(file), siaPath, uint64(numDataPieces), uint64(numParityPieces), false)
+		}
+		if compression != modules.CompressionNone {
+			return httpClient.RenterUploadCompressedPost(abs(file), siaPath, uint64(numDataPieces), uint64(numParityPieces), false, compression)
+		}
+		return httpClient.RenterUploadPost(abs(file), siaPath, uint64(numDataPieces), uint64(numParityPieces))
+	}
+
 	if stat.IsDir() {
 		// folder
 		var files []string
@@ -2062,7 +2618,7 @@ func renterfilesuploadcmd(source, path string) {
 			if err != nil {
 				die("Couldn't parse TurtleDexPath:", err)
 			}
-			err = httpClient.RenterUploadPost(abs(file), fTurtleDexPath, uint64(numDataPieces), uint64(numParityPieces))
+			err = uploadFile(file, fTurtleDexPath)
 			if err != nil {
 				failed++
 				fmt.Printf("Could not upload file %s :%v\n", file, err)
@@ -2076,7 +2632,7 @@ func renterfilesuploadcmd(source, path string) {
 		if err != nil {
 			die("Couldn't parse TurtleDexPath:", err)
 		}
-		err = httpClient.RenterUploadPost(abs(source), siaPath, uint64(numDataPieces), uint64(numParityPieces))
+		err = uploadFile(source, siaPath)
 		if err != nil {
 			die("Could not upload file:", err)
 		}
@@ -2109,11 +2665,10 @@ func renterfilesuploadresumecmd() {
 	fmt.Println("Renter uploads have been resumed")
 }
 
-// renterpricescmd is the handler for the command `ttdxc renter prices`, which
-// displays the prices of various storage operations. The user can submit an
-// allowance to have the estimate reflect those settings or the user can submit
-// nothing
-func renterpricescmd(cmd *cobra.Command, args []string) {
+// parseAllowanceArgs parses the optional [amount] [period] [hosts] [renew
+// window] arguments of a command. The empty allowance is returned if no
+// arguments are provided.
+func parseAllowanceArgs(cmd *cobra.Command, args []string) modules.Allowance {
 	allowance := modules.Allowance{}
 
 	if len(args) != 0 && len(args) != 4 {
@@ -2152,6 +2707,15 @@ func renterpricescmd(cmd *cobra.Command, args []string) {
 			die("Could not set allowance renew window:", err)
 		}
 	}
+	return allowance
+}
+
+// renterpricescmd is the handler for the command `ttdxc renter prices`, which
+// displays the prices of various storage operations. The user can submit an
+// allowance to have the estimate reflect those settings or the user can submit
+// nothing
+func renterpricescmd(cmd *cobra.Command, args []string) {
+	allowance := parseAllowanceArgs(cmd, args)
 
 	rpg, err := httpClient.RenterPricesGet(allowance)
 	if err != nil {
@@ -2187,6 +2751,431 @@ func renterpricescmd(cmd *cobra.Command, args []string) {
 	}
 }
 
+// renterplacementcmd is the handler for the command `ttdxc renter
+// placement`. Prints the placement policy.
+func renterplacementcmd() {
+	rg, err := httpClient.RenterGet()
+	if err != nil {
+		die("Could not get renter info:", err)
+	}
+	pp := rg.Settings.PlacementPolicy
+	if !pp.Enabled() {
+		fmt.Println("No placement policy is set.")
+		return
+	}
+	limit := func(max uint64) string {
+		if max == 0 {
+			return "unlimited"
+		}
+		return fmt.Sprint(max)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Placement Policy:")
+	fmt.Fprintf(w, "  Max Pieces per IPv4 /16:\t%v\n", limit(pp.MaxPiecesPerIPv4Subnet))
+	fmt.Fprintf(w, "  Max Pieces per IPv6 /48:\t%v\n", limit(pp.MaxPiecesPerIPv6Subnet))
+	fmt.Fprintf(w, "  Max Pieces per ASN:\t%v\n", limit(pp.MaxPiecesPerASN))
+	fmt.Fprintf(w, "  Max Pieces per Host Group:\t%v\n", limit(pp.MaxPiecesPerHostGroup))
+	if pp.ASNFile != "" {
+		fmt.Fprintf(w, "  ASN File:\t%v\n", pp.ASNFile)
+	}
+	if pp.HostGroupsFile != "" {
+		fmt.Fprintf(w, "  Host Groups File:\t%v\n", pp.HostGroupsFile)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// renterplacementsetcmd is the handler for the command `ttdxc renter
+// placement set`. Updates the placement policy.
+func renterplacementsetcmd() {
+	policy := modules.PlacementPolicy{
+		MaxPiecesPerIPv4Subnet: renterPlacementIPv4Subnet,
+		MaxPiecesPerIPv6Subnet: renterPlacementIPv6Subnet,
+		MaxPiecesPerASN:        renterPlacementASN,
+		MaxPiecesPerHostGroup:  renterPlacementHostGroup,
+	}
+	// The files are read by the daemon which might use a different working
+	// directory.
+	var err error
+	if renterPlacementASNFile != "" {
+		policy.ASNFile, err = filepath.Abs(renterPlacementASNFile)
+		if err != nil {
+			die("Could not get absolute path of ASN file:", err)
+		}
+	}
+	if renterPlacementHostGroupsFile != "" {
+		policy.HostGroupsFile, err = filepath.Abs(renterPlacementHostGroupsFile)
+		if err != nil {
+			die("Could not get absolute path of host groups file:", err)
+		}
+	}
+	err = httpClient.RenterPlacementPolicyPost(policy)
+	if err != nil {
+		die("Could not set placement policy:", err)
+	}
+	if !policy.Enabled() {
+		fmt.Println("Removed the placement policy")
+		return
+	}
+	fmt.Println("Set the placement policy")
+}
+
+// renterrebalancecmd is the handler for the command `ttdxc renter rebalance`.
+// Prints the rebalance policy and the pending migrations.
+func renterrebalancecmd() {
+	status, err := httpClient.RenterContractorRebalanceStatus()
+	if err != nil {
+		die("Could not get rebalance status:", err)
+	}
+	rp := status.Policy
+	if !rp.Enabled() {
+		fmt.Println("No rebalance policy is set.")
+		return
+	}
+	threshold := func(value float64, format string) string {
+		if value == 0 {
+			return "none"
+		}
+		return fmt.Sprintf(format, value)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Rebalance Policy:")
+	fmt.Fprintf(w, "  Budget:\t%v\n", currencyUnits(rp.Budget))
+	fmt.Fprintf(w, "  Spent Budget:\t%v\n", currencyUnits(status.SpentBudget))
+	fmt.Fprintf(w, "  Min Score Percentile:\t%v\n", threshold(rp.MinScorePercentile*100, "%.2f%%"))
+	fmt.Fprintf(w, "  Max Cost Ratio:\t%v\n", threshold(rp.MaxCostRatio, "%.2f"))
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+
+	if len(status.Migrations) == 0 {
+		fmt.Println("\nNo data is being migrated.")
+		return
+	}
+	fmt.Println("\nMigrations:")
+	w = tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  Host PubKey\tContract ID\tSize\tEstimated Cost\tReason\tStart Height")
+	for _, m := range status.Migrations {
+		fmt.Fprintf(w, "  %v\t%v\t%v\t%v\t%v\t%v\n", m.HostPublicKey.String(), m.ContractID, modules.FilesizeUnits(m.Size), currencyUnits(m.EstimatedCost), m.Reason, m.StartHeight)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// renterrebalancesetcmd is the handler for the command `ttdxc renter rebalance
+// set`. Updates the rebalance policy.
+func renterrebalancesetcmd() {
+	policy := modules.RebalancePolicy{
+		MaxCostRatio:       rebalanceMaxCostRatio,
+		MinScorePercentile: rebalanceMinScorePercentile,
+	}
+	if rebalanceBudget != "" {
+		hastings, err := types.ParseCurrency(rebalanceBudget)
+		if err != nil {
+			die("Could not parse budget:", err)
+		}
+		_, err = fmt.Sscan(hastings, &policy.Budget)
+		if err != nil {
+			die("Could not read budget:", err)
+		}
+	}
+	err := httpClient.RenterRebalancePolicyPost(policy)
+	if err != nil {
+		die("Could not set rebalance policy:", err)
+	}
+	if !policy.Enabled() {
+		fmt.Println("Disabled the rebalancer")
+		return
+	}
+	fmt.Println("Set the rebalance policy")
+}
+
+// addScoringPolicyFlags adds the flags of a scoring policy to a command.
+func addScoringPolicyFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&scoringAgeWeight, "age-weight", "", "the weight of the age adjustment")
+	cmd.Flags().StringVar(&scoringCollateralWeight, "collateral-weight", "", "the weight of the collateral adjustment")
+	cmd.Flags().StringVar(&scoringInteractionsWeight, "interactions-weight", "", "the weight of the interactions adjustment")
+	cmd.Flags().StringVar(&scoringPriceWeight, "price-weight", "", "the weight of the price adjustment")
+	cmd.Flags().StringVar(&scoringStorageRemainingWeight, "storage-remaining-weight", "", "the weight of the storage remaining adjustment")
+	cmd.Flags().StringVar(&scoringUptimeWeight, "uptime-weight", "", "the weight of the uptime adjustment")
+	cmd.Flags().StringVar(&scoringVersionWeight, "version-weight", "", "the weight of the version adjustment")
+	cmd.Flags().StringVar(&scoringMinAge, "min-age", "", "the minimum age of a host in blocks (b), hours (h), days (d) or weeks (w)")
+	cmd.Flags().StringVar(&scoringMinCollateral, "min-collateral", "", "the minimum collateral of a host per TB per month, specified in currency units")
+	cmd.Flags().StringVar(&scoringMinRemainingStorage, "min-remaining-storage", "", "the minimum remaining storage of a host in bytes (B), kilobytes (KB), megabytes (MB) etc.")
+	cmd.Flags().StringVar(&scoringMinUptime, "min-uptime", "", "the minimum uptime ratio of a host between 0 and 1")
+	cmd.Flags().StringVar(&scoringMinVersion, "min-version", "", "the minimum version of a host")
+	cmd.Flags().StringVar(&scoringMaxContractPrice, "max-contract-price", "", "the maximum contract price of a host, specified in currency units")
+	cmd.Flags().StringVar(&scoringMaxDownloadBandwidthPrice, "max-download-price", "", "the maximum download price of a host per TB, specified in currency units")
+	cmd.Flags().StringVar(&scoringMaxStoragePrice, "max-storage-price", "", "the maximum storage price of a host per TB per month, specified in currency units")
+	cmd.Flags().StringVar(&scoringMaxUploadBandwidthPrice, "max-upload-price", "", "the maximum upload price of a host per TB, specified in currency units")
+}
+
+// parseScoringPolicyFlags applies the scoring policy flags to the provided
+// policy. It returns whether any of the flags were set.
+func parseScoringPolicyFlags(policy *modules.HostScoringPolicy) bool {
+	var changed bool
+
+	// Parse the weights.
+	weights := []struct {
+		flag string
+		t    modules.HostScoreAdjustmentType
+	}{
+		{scoringAgeWeight, modules.HostScoreAge},
+		{scoringCollateralWeight, modules.HostScoreCollateral},
+		{scoringInteractionsWeight, modules.HostScoreInteractions},
+		{scoringPriceWeight, modules.HostScorePrice},
+		{scoringStorageRemainingWeight, modules.HostScoreStorageRemaining},
+		{scoringUptimeWeight, modules.HostScoreUptime},
+		{scoringVersionWeight, modules.HostScoreVersion},
+	}
+	for _, w := range weights {
+		if w.flag == "" {
+			continue
+		}
+		weight, err := strconv.ParseFloat(w.flag, 64)
+		if err != nil {
+			die(fmt.Sprintf("Could not parse %v weight:", w.t), err)
+		}
+		if policy.Weights == nil {
+			policy.Weights = make(map[modules.HostScoreAdjustmentType]float64)
+		}
+		policy.Weights[w.t] = weight
+		changed = true
+	}
+
+	// Parse the limits.
+	if scoringMinAge != "" {
+		blocks, err := parsePeriod(scoringMinAge)
+		if err != nil {
+			die("Could not parse min age:", err)
+		}
+		_, err = fmt.Sscan(blocks, &policy.MinAge)
+		if err != nil {
+			die("Could not read min age:", err)
+		}
+		changed = true
+	}
+	if scoringMinRemainingStorage != "" {
+		size, err := parseFilesize(scoringMinRemainingStorage)
+		if err != nil {
+			die("Could not parse min remaining storage:", err)
+		}
+		_, err = fmt.Sscan(size, &policy.MinRemainingStorage)
+		if err != nil {
+			die("Could not read min remaining storage:", err)
+		}
+		changed = true
+	}
+	if scoringMinUptime != "" {
+		uptime, err := strconv.ParseFloat(scoringMinUptime, 64)
+		if err != nil {
+			die("Could not parse min uptime:", err)
+		}
+		policy.MinUptime = uptime
+		changed = true
+	}
+	if scoringMinVersion != "" {
+		policy.MinVersion = scoringMinVersion
+		if scoringMinVersion == "0" {
+			policy.MinVersion = ""
+		}
+		changed = true
+	}
+
+	// Parse the prices and convert them from the units used by the flags into
+	// the units used by hosts.
+	prices := []struct {
+		flag    string
+		name    string
+		divisor types.Currency
+		price   *types.Currency
+	}{
+		{scoringMinCollateral, "min collateral", modules.BlockBytesPerMonthTerabyte, &policy.MinCollateral},
+		{scoringMaxContractPrice, "max contract price", types.NewCurrency64(1), &policy.MaxContractPrice},
+		{scoringMaxDownloadBandwidthPrice, "max download price", modules.BytesPerTerabyte, &policy.MaxDownloadBandwidthPrice},
+		{scoringMaxStoragePrice, "max storage price", modules.BlockBytesPerMonthTerabyte, &policy.MaxStoragePrice},
+		{scoringMaxUploadBandwidthPrice, "max upload price", modules.BytesPerTerabyte, &policy.MaxUploadBandwidthPrice},
+	}
+	for _, p := range prices {
+		if p.flag == "" {
+			continue
+		}
+		priceStr, err := types.ParseCurrency(p.flag)
+		if err != nil {
+			die("Could not parse "+p.name+":", err)
+		}
+		var price types.Currency
+		_, err = fmt.Sscan(priceStr, &price)
+		if err != nil {
+			die("Could not read "+p.name+":", err)
+		}
+		*p.price = price.Div(p.divisor)
+		changed = true
+	}
+	return changed
+}
+
+// renterscoringpolicycmd is the handler for the command `ttdxc renter
+// scoringpolicy`. Prints the scoring policy.
+func renterscoringpolicycmd() {
+	rg, err := httpClient.RenterGet()
+	if err != nil {
+		die("Could not get renter info:", err)
+	}
+	sp := rg.Settings.ScoringPolicy
+	limit := func(isZero bool, value interface{}) interface{} {
+		if isZero {
+			return "none"
+		}
+		return value
+	}
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Scoring Policy Weights:")
+	fmt.Fprintf(w, "  Age:\t%v\n", sp.Weight(modules.HostScoreAge))
+	fmt.Fprintf(w, "  Collateral:\t%v\n", sp.Weight(modules.HostScoreCollateral))
+	fmt.Fprintf(w, "  Interactions:\t%v\n", sp.Weight(modules.HostScoreInteractions))
+	fmt.Fprintf(w, "  Price:\t%v\n", sp.Weight(modules.HostScorePrice))
+	fmt.Fprintf(w, "  Storage Remaining:\t%v\n", sp.Weight(modules.HostScoreStorageRemaining))
+	fmt.Fprintf(w, "  Uptime:\t%v\n", sp.Weight(modules.HostScoreUptime))
+	fmt.Fprintf(w, "  Version:\t%v\n", sp.Weight(modules.HostScoreVersion))
+	fmt.Fprintln(w, "\nScoring Policy Limits:")
+	fmt.Fprintf(w, "  Min Age:\t%v\n", limit(sp.MinAge == 0, fmt.Sprintf("%v blocks", sp.MinAge)))
+	fmt.Fprintf(w, "  Min Collateral (TB / Mo):\t%v\n", limit(sp.MinCollateral.IsZero(), currencyUnits(sp.MinCollateral.Mul(modules.BlockBytesPerMonthTerabyte))))
+	fmt.Fprintf(w, "  Min Remaining Storage:\t%v\n", limit(sp.MinRemainingStorage == 0, modules.FilesizeUnits(sp.MinRemainingStorage)))
+	fmt.Fprintf(w, "  Min Uptime:\t%v\n", limit(sp.MinUptime == 0, fmt.Sprintf("%.2f%%", sp.MinUptime*100)))
+	fmt.Fprintf(w, "  Min Version:\t%v\n", limit(sp.MinVersion == "", sp.MinVersion))
+	fmt.Fprintf(w, "  Max Contract Price:\t%v\n", limit(sp.MaxContractPrice.IsZero(), currencyUnits(sp.MaxContractPrice)))
+	fmt.Fprintf(w, "  Max Download Price (1 TB):\t%v\n", limit(sp.MaxDownloadBandwidthPrice.IsZero(), currencyUnits(sp.MaxDownloadBandwidthPrice.Mul(modules.BytesPerTerabyte))))
+	fmt.Fprintf(w, "  Max Storage Price (TB / Mo):\t%v\n", limit(sp.MaxStoragePrice.IsZero(), currencyUnits(sp.MaxStoragePrice.Mul(modules.BlockBytesPerMonthTerabyte))))
+	fmt.Fprintf(w, "  Max Upload Price (1 TB):\t%v\n", limit(sp.MaxUploadBandwidthPrice.IsZero(), currencyUnits(sp.MaxUploadBandwidthPrice.Mul(modules.BytesPerTerabyte))))
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// renterscoringpolicysetcmd is the handler for the command `ttdxc renter
+// scoringpolicy set`. Updates the scoring policy.
+func renterscoringpolicysetcmd() {
+	rg, err := httpClient.RenterGet()
+	if err != nil {
+		die("Could not get renter info:", err)
+	}
+	policy := rg.Settings.ScoringPolicy
+	if !parseScoringPolicyFlags(&policy) {
+		die("No scoring policy flags were provided")
+	}
+	err = httpClient.RenterScoringPolicyPost(policy)
+	if err != nil {
+		die("Could not set scoring policy:", err)
+	}
+	fmt.Println("Set the scoring policy")
+}
+
+// renterauditcmd is the handler for the command `ttdxc renter audit`.
+// Displays the results of the integrity audits.
+func renterauditcmd() {
+	rag, err := httpClient.RenterAuditGet()
+	if err != nil {
+		die("Could not get audit status:", err)
+	}
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return "never"
+		}
+		return t.Format(time.RFC822)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Integrity Audit:")
+	fmt.Fprintf(w, "  Running:\t%v\n", yesNo(rag.Running))
+	fmt.Fprintf(w, "  Last Audit:\t%v\n", formatTime(rag.LastAudit))
+	fmt.Fprintf(w, "  Next Audit:\t%v\n", formatTime(rag.NextAudit))
+	fmt.Fprintf(w, "  Passed Checks:\t%v\n", rag.Passed)
+	fmt.Fprintf(w, "  Failed Checks:\t%v\n", rag.Failed)
+	fmt.Fprintf(w, "  Inconclusive Checks:\t%v\n", rag.Inconclusive)
+	fmt.Fprintf(w, "  Lost Pieces:\t%v\n", rag.LostPieces)
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+
+	// Only list the hosts which failed checks unless the verbose flag is set.
+	hosts := rag.Hosts
+	if !verbose {
+		hosts = hosts[:0]
+		for _, hs := range rag.Hosts {
+			if hs.Failed > 0 {
+				hosts = append(hosts, hs)
+			}
+		}
+	}
+	if len(hosts) > 0 {
+		sort.Slice(hosts, func(i, j int) bool {
+			if hosts[i].Failed != hosts[j].Failed {
+				return hosts[i].Failed > hosts[j].Failed
+			}
+			return hosts[i].HostPubKey.String() < hosts[j].HostPubKey.String()
+		})
+		fmt.Println()
+		w = tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Host\tPassed\tFailed\tInconclusive\tLost Pieces\tLast Failure")
+		for _, hs := range hosts {
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n", hs.HostPubKey, hs.Passed, hs.Failed, hs.Inconclusive, hs.LostPieces, formatTime(hs.LastFailure))
+		}
+		if err := w.Flush(); err != nil {
+			die("failed to flush writer:", err)
+		}
+	}
+
+	if len(rag.RecentFailures) > 0 {
+		fmt.Println()
+		fmt.Println("Recent Failures:")
+		for _, f := range rag.RecentFailures {
+			if f.Type == modules.AuditCheckReadSector {
+				fmt.Printf("  %v  %v  %v chunk %v piece %v: %v\n", formatTime(f.Time), f.HostPubKey, f.TurtleDexPath, f.ChunkIndex, f.PieceIndex, f.Error)
+			} else {
+				fmt.Printf("  %v  %v  contract: %v\n", formatTime(f.Time), f.HostPubKey, f.Error)
+			}
+		}
+	}
+}
+
+// renterauditruncmd is the handler for the command `ttdxc renter audit run`.
+// Starts an integrity audit.
+func renterauditruncmd() {
+	err := httpClient.RenterAuditPost()
+	if err != nil {
+		die("Could not start audit:", err)
+	}
+	fmt.Println("Started integrity audit")
+}
+
+// renterredundancypolicycmd is the handler for the command `ttdxc renter
+// redundancypolicy [path]`. Sets the redundancy policy of a directory.
+func renterredundancypolicycmd(path string) {
+	siaPath, err := modules.NewTurtleDexPath(path)
+	if err != nil {
+		die("Couldn't parse TurtleDexPath:", err)
+	}
+	numDataPieces, numParityPieces, err := api.ParseDataAndParityPieces(dataPieces, parityPieces)
+	if err != nil {
+		die("Could not parse data and parity pieces:", err)
+	}
+	policy := modules.RedundancyPolicy{
+		DataPieces:   numDataPieces,
+		ParityPieces: numParityPieces,
+	}
+	err = httpClient.RenterDirSetRedundancyPolicyPost(siaPath, policy)
+	if err != nil {
+		die("Could not set redundancy policy:", err)
+	}
+	if !policy.Enabled() {
+		fmt.Printf("Removed redundancy policy of %v\n", path)
+		return
+	}
+	fmt.Printf("Set redundancy policy of %v to %v data pieces and %v parity pieces\n", path, numDataPieces, numParityPieces)
+}
+
 // renterratelimitcmd is the handler for the command `ttdxc renter ratelimit`
 // which sets the maxuploadspeed and maxdownloadspeed in bytes-per-second for
 // the renter module
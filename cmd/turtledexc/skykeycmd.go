@@ -41,7 +41,9 @@ var (
 		Use:   "create [name]",
 		Short: "Create a skykey with the given name.",
 		Long: `Create a skykey  with the given name. The --type flag can be
-		used to specify the skykey type. Its default is private-id.`,
+		used to specify the skykey type. Its default is private-id. Skyfiles
+		can be encrypted for up to 2 skykeys of type recipient using their
+		public keys.`,
 		Run: wrap(skykeycreatecmd),
 	}
 
@@ -79,6 +81,14 @@ var (
 		Run:   wrap(skykeygetidcmd),
 	}
 
+	skykeyGetRecipientCmd = &cobra.Command{
+		Use:   "get-recipient [name]",
+		Short: "Get the recipient public key of a skykey by its name",
+		Long: `Get the public key of a recipient skykey by its name. Uploaders can
+		use it to encrypt skyfiles for the skykey without knowing the skykey itself.`,
+		Run: wrap(skykeygetrecipientcmd),
+	}
+
 	skykeyListCmd = &cobra.Command{
 		Use:   "ls",
 		Short: "List all skykeys",
@@ -227,6 +237,20 @@ func skykeygetidcmd(skykeyName string) {
 	fmt.Printf("Found skykey ID: %v\n", sk.ID().ToString())
 }
 
+// skykeygetrecipientcmd retrieves the recipient public key of a skykey using
+// its name.
+func skykeygetrecipientcmd(skykeyName string) {
+	sk, err := httpClient.SkykeyGetByName(skykeyName)
+	if err != nil {
+		die("Failed to retrieve skykey:", err)
+	}
+	pk, err := sk.RecipientPublicKey()
+	if err != nil {
+		die("Failed to get recipient public key, the skykey must be of type recipient:", err)
+	}
+	fmt.Printf("Found recipient public key: %v\n", pk.ToString())
+}
+
 // skykeylistcmd is a wrapper for skykeyListKeys that prints a list of all
 // skykeys.
 func skykeylistcmd() {
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -14,6 +15,7 @@ import (
 	"strings"
 	"sync"
 	"text/tabwriter"
+	"time"
 
 	"github.com/vbauerster/mpb/v5"
 
@@ -24,6 +26,7 @@ import (
 	"github.com/turtledex/TurtleDexCore/modules"
 	"github.com/turtledex/TurtleDexCore/modules/renter"
 	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem"
+	"github.com/turtledex/TurtleDexCore/types"
 	"github.com/turtledex/errors"
 )
 
@@ -110,6 +113,48 @@ maintaining the file in your renter.`,
 		Run: wrap(skynetpincmd),
 	}
 
+	skynetPinsCmd = &cobra.Command{
+		Use:   "pins",
+		Short: "Add, remove, or list the skylinks kept pinned by the renter.",
+		Long: `Add, remove, or list the skylinks kept pinned by the renter. The renter
+periodically checks how many hosts store the base sector of every pinned
+skylink and pins it again if its siafile is missing or too few hosts store it.`,
+		Run: wrap(skynetpinslscmd),
+	}
+
+	skynetPinsAddCmd = &cobra.Command{
+		Use:   "add [skylink]",
+		Short: "Keep a skylink pinned.",
+		Long: `Add a skylink to the skylinks kept pinned by the renter. The skylink is pinned
+by the next health check unless its siafile already exists. By default the
+skyfile is pinned to var/skynet/[skylink]. Use the --siapath flag to choose a
+different siapath within var/skynet/ or, together with --root, within the root
+folder.`,
+		Run: wrap(skynetpinsaddcmd),
+	}
+
+	skynetPinsLsCmd = &cobra.Command{
+		Use:   "ls",
+		Short: "List the skylinks kept pinned by the renter.",
+		Long:  "List the skylinks kept pinned by the renter along with their health.",
+		Run:   wrap(skynetpinslscmd),
+	}
+
+	skynetPinsRemoveCmd = &cobra.Command{
+		Use:   "rm [skylink]",
+		Short: "Stop keeping a skylink pinned.",
+		Long: `Stop keeping a skylink pinned and unpin it by deleting its siafile. The skyfile
+will continue to be available on Skynet if other nodes have pinned it.`,
+		Run: wrap(skynetpinsremovecmd),
+	}
+
+	skynetPinsStatusCmd = &cobra.Command{
+		Use:   "status [skylink]",
+		Short: "Show the detailed status of a skylink kept pinned by the renter.",
+		Long:  "Show the detailed status of a skylink kept pinned by the renter.",
+		Run:   wrap(skynetpinsstatuscmd),
+	}
+
 	skynetPortalsCmd = &cobra.Command{
 		Use:   "portals",
 		Short: "Add, remove, or list registered Skynet portals.",
@@ -132,6 +177,31 @@ by --public if you want it to be publicly available.`,
 		Run:   wrap(skynetportalsremovecmd),
 	}
 
+	skynetRegistryCmd = &cobra.Command{
+		Use:   "registry",
+		Short: "Perform actions related to the registry.",
+		Long:  "Perform actions related to the registry.",
+		Run:   skynetcmd,
+	}
+
+	skynetRegistrySkylinkV2Cmd = &cobra.Command{
+		Use:   "skylinkv2 [publickey] [datakey]",
+		Short: "Print the v2 skylink of a registry entry.",
+		Long: `Print the v2 skylink which points to the registry entry of the provided
+public key and data key. The v2 skylink resolves to the v1 skylink stored in
+the entry, so updating the entry changes the content the v2 skylink points to.`,
+		Run: wrap(skynetregistryskylinkv2cmd),
+	}
+
+	skynetRegistryWatchCmd = &cobra.Command{
+		Use:   "watch [publickey] [datakey]",
+		Short: "Watch a registry entry for updates.",
+		Long: `Subscribe to a registry entry and print every update to the entry until the
+command is interrupted. The latest known value of the entry is printed right
+away.`,
+		Run: wrap(skynetregistrywatchcmd),
+	}
+
 	skynetRestoreCmd = &cobra.Command{
 		Use:   "restore [backup source]",
 		Short: "Restore a skyfile from a backup file.",
@@ -570,6 +640,10 @@ func skynetPin(skylink string, siaPath modules.TurtleDexPath) (string, error) {
 		Reader:   reader,
 		Filename: sm.Filename,
 		Mode:     sm.Mode,
+
+		// Compressed skyfiles are downloaded decompressed and need to be
+		// compressed again to result in the same skylink.
+		Compression: sm.Compression,
 	}
 
 	// NOTE: Since the user can define a new siapath for the Skyfile the skylink
@@ -600,6 +674,67 @@ func skynetpincmd(sourceSkylink, destTurtleDexPath string) {
 	fmt.Printf("Skyfile pinned successfully\nSkylink: sia://%v\n", skylink)
 }
 
+// skynetregistryskylinkv2cmd prints the v2 skylink of a registry entry.
+func skynetregistryskylinkv2cmd(pubKeyStr, dataKeyStr string) {
+	var spk types.TurtleDexPublicKey
+	err := spk.LoadString(pubKeyStr)
+	if err != nil {
+		die("Could not parse public key:", err)
+	}
+	var dataKey crypto.Hash
+	err = dataKey.LoadString(dataKeyStr)
+	if err != nil {
+		die("Could not parse data key:", err)
+	}
+
+	skylink, err := httpClient.RegistrySkylinkV2(spk, dataKey)
+	if err != nil {
+		die("Unable to get v2 skylink:", err)
+	}
+	fmt.Println(skylink)
+}
+
+// skynetregistrywatchcmd subscribes to a registry entry and prints its
+// updates.
+func skynetregistrywatchcmd(pubKeyStr, dataKeyStr string) {
+	var spk types.TurtleDexPublicKey
+	err := spk.LoadString(pubKeyStr)
+	if err != nil {
+		die("Could not parse public key:", err)
+	}
+	var dataKey crypto.Hash
+	err = dataKey.LoadString(dataKeyStr)
+	if err != nil {
+		die("Could not parse data key:", err)
+	}
+
+	subscription, err := httpClient.RegistrySubscribe([]types.TurtleDexPublicKey{spk}, []crypto.Hash{dataKey})
+	if err != nil {
+		die("Unable to subscribe to registry entry:", err)
+	}
+	defer func() {
+		_ = subscription.Close()
+	}()
+	fmt.Println("Watching registry entry for updates")
+	for {
+		update, err := subscription.Next()
+		if errors.Contains(err, io.EOF) {
+			die("Subscription was closed by the daemon")
+		}
+		if err != nil {
+			die("Unable to receive update:", err)
+		}
+		fmt.Printf("Revision: %v\n", update.Revision)
+		data, err := hex.DecodeString(update.Data)
+		var skylink modules.Skylink
+		if err == nil && skylink.LoadBytes(data) == nil {
+			fmt.Printf("  Skylink: %v\n", skylink)
+		} else {
+			fmt.Printf("  Data: %v\n", update.Data)
+		}
+	}
+}
+
 // skynetrestorecmd will restore a skyfile from a backup writer.
 func skynetrestorecmd(backupPath string) {
 	// Open the backup file
@@ -748,6 +883,111 @@ func skynetuploadpipecmd(destTurtleDexPath string) {
 	return
 }
 
+// skynetpinsaddcmd is the handler for the command `ttdxc skynet pins add
+// [skylink]`. It adds a skylink to the skylinks kept pinned by the renter.
+func skynetpinsaddcmd(skylink string) {
+	pin := modules.SkynetPin{
+		Skylink:             strings.TrimPrefix(skylink, "sia://"),
+		Label:               skynetPinsLabel,
+		BaseChunkRedundancy: skynetPinsBaseChunkRedundancy,
+	}
+	if skynetPinsTurtleDexPath != "" {
+		siaPath, err := modules.NewTurtleDexPath(skynetPinsTurtleDexPath)
+		if err != nil {
+			die("Could not parse siapath:", err)
+		}
+		if !skynetPinsRoot {
+			siaPath, err = modules.SkynetFolder.Join(siaPath.String())
+			if err != nil {
+				die("Could not build siapath:", err)
+			}
+		}
+		pin.TurtleDexPath = siaPath
+	}
+	err := httpClient.SkynetPinsAddPost(pin)
+	if err != nil {
+		die("Could not add pin:", err)
+	}
+	fmt.Printf("Skylink sia://%v will be kept pinned\n", pin.Skylink)
+}
+
+// skynetpinslscmd is the handler for the command `ttdxc skynet pins ls`. It
+// lists the skylinks kept pinned by the renter.
+func skynetpinslscmd() {
+	spg, err := httpClient.SkynetPinsGet()
+	if err != nil {
+		die("Could not get pins:", err)
+	}
+	if len(spg.Pins) == 0 {
+		fmt.Println("No pinned skylinks.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Label\tSkylink\tHealthy\tHosts\tLast Check\n")
+	for _, pin := range spg.Pins {
+		label := pin.Label
+		if label == "" {
+			label = "-"
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v/%v\t%v\n", label, pin.Skylink, yesNo(pin.Healthy), pin.Hosts, pin.BaseChunkRedundancy, skynetPinsFormatTime(pin.LastCheck))
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// skynetpinsremovecmd is the handler for the command `ttdxc skynet pins rm
+// [skylink]`. It stops keeping a skylink pinned and unpins it.
+func skynetpinsremovecmd(skylink string) {
+	skylink = strings.TrimPrefix(skylink, "sia://")
+	err := httpClient.SkynetPinsRemovePost(skylink)
+	if err != nil {
+		die("Could not remove pin:", err)
+	}
+	fmt.Printf("Unpinned skylink sia://%v\n", skylink)
+}
+
+// skynetpinsstatuscmd is the handler for the command `ttdxc skynet pins status
+// [skylink]`. It shows the detailed status of a pinned skylink.
+func skynetpinsstatuscmd(skylink string) {
+	skylink = strings.TrimPrefix(skylink, "sia://")
+	spg, err := httpClient.SkynetPinsGet()
+	if err != nil {
+		die("Could not get pins:", err)
+	}
+	for _, pin := range spg.Pins {
+		if pin.Skylink != skylink {
+			continue
+		}
+		w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "Skylink:\tsia://%v\n", pin.Skylink)
+		fmt.Fprintf(w, "Label:\t%v\n", pin.Label)
+		fmt.Fprintf(w, "TurtleDexPath:\t%v\n", pin.TurtleDexPath)
+		fmt.Fprintf(w, "Healthy:\t%v\n", yesNo(pin.Healthy))
+		fmt.Fprintf(w, "Hosts:\t%v\n", pin.Hosts)
+		fmt.Fprintf(w, "Base Chunk Redundancy:\t%v\n", pin.BaseChunkRedundancy)
+		fmt.Fprintf(w, "Last Check:\t%v\n", skynetPinsFormatTime(pin.LastCheck))
+		fmt.Fprintf(w, "Last Pin:\t%v\n", skynetPinsFormatTime(pin.LastPin))
+		fmt.Fprintf(w, "Repins:\t%v\n", pin.Repins)
+		if pin.LastError != "" {
+			fmt.Fprintf(w, "Last Error:\t%v\n", pin.LastError)
+		}
+		if err := w.Flush(); err != nil {
+			die("failed to flush writer:", err)
+		}
+		return
+	}
+	die("Skylink isn't pinned:", skylink)
+}
+
+// skynetPinsFormatTime formats the time of a pin event.
+func skynetPinsFormatTime(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return t.Format(time.RFC822)
+}
+
 // skynetportalsgetcmd displays the list of persisted Skynet portals
 func skynetportalsgetcmd() {
 	portals, err := httpClient.SkynetPortalsGet()
@@ -958,7 +1198,11 @@ func skynetUploadDirectory(sourcePath, destTurtleDexPath string) {
 		Filename:            skyfilePath.Name(),
 		DefaultPath:         skynetUploadDefaultPath,
 		DisableDefaultPath:  skynetUploadDisableDefaultPath,
+		Headers:             parseSkynetUploadHeaders(),
+		TryFiles:            parseSkynetUploadTryFiles(),
+		NotFoundPath:        skynetUploadNotFoundPath,
 		ContentType:         writer.FormDataContentType(),
+		Compression:         parseSkynetUploadCompression(),
 	}
 	skylink, _, err := httpClient.SkynetSkyfileMultiPartPost(sup)
 	if err != nil {
@@ -978,8 +1222,10 @@ func skynetUploadFileFromReader(source io.Reader, filename string, siaPath modul
 		Filename: filename,
 		Mode:     mode,
 
-		DryRun: skynetUploadDryRun,
-		Reader: source,
+		DryRun:      skynetUploadDryRun,
+		Reader:      source,
+		Compression: parseSkynetUploadCompression(),
+		Headers:     parseSkynetUploadHeaders(),
 	}
 	sup = parseAndAddSkykey(sup)
 	skylink, _, err := httpClient.SkynetSkyfilePost(sup)
@@ -17,6 +17,7 @@ import (
 	"github.com/turtledex/TurtleDexCore/build"
 	"github.com/turtledex/TurtleDexCore/modules"
 	"github.com/turtledex/TurtleDexCore/node/api/server"
+	"github.com/turtledex/TurtleDexCore/node/s3gateway"
 	"github.com/turtledex/TurtleDexCore/profile"
 )
 
@@ -85,12 +86,20 @@ func processConfig(config Config) (Config, error) {
 	config.TurtleDexd.APIaddr = processNetAddr(config.TurtleDexd.APIaddr)
 	config.TurtleDexd.RPCaddr = processNetAddr(config.TurtleDexd.RPCaddr)
 	config.TurtleDexd.HostAddr = processNetAddr(config.TurtleDexd.HostAddr)
+	if config.TurtleDexd.S3Addr != "" {
+		config.TurtleDexd.S3Addr = processNetAddr(config.TurtleDexd.S3Addr)
+	}
+	if config.TurtleDexd.WebDAVAddr != "" {
+		config.TurtleDexd.WebDAVAddr = processNetAddr(config.TurtleDexd.WebDAVAddr)
+	}
 	config.TurtleDexd.Modules, err1 = processModules(config.TurtleDexd.Modules)
 	if config.TurtleDexd.Profile != "" {
 		config.TurtleDexd.Profile, err2 = profile.ProcessProfileFlags(config.TurtleDexd.Profile)
 	}
 	err3 := verifyAPISecurity(config)
-	err := build.JoinErrors([]error{err1, err2, err3}, ", and ")
+	err4 := verifyS3Config(config)
+	err5 := verifyWebDAVConfig(config)
+	err := build.JoinErrors([]error{err1, err2, err3, err4, err5}, ", and ")
 	if err != nil {
 		return Config{}, err
 	}
@@ -168,6 +177,67 @@ func tryAutoUnlock(srv *server.Server) {
 	}
 }
 
+// verifyS3Config checks that the S3 gateway credentials are set if the
+// gateway is enabled.
+func verifyS3Config(config Config) error {
+	if config.TurtleDexd.S3Addr == "" {
+		return nil
+	}
+	if !strings.Contains(config.TurtleDexd.Modules, "r") {
+		return errors.New("the S3 gateway requires the renter module")
+	}
+	if accessKey, secretKey := build.S3Credentials(); accessKey == "" || secretKey == "" {
+		return errors.New("the S3 gateway requires the SIA_S3_ACCESS_KEY and SIA_S3_SECRET_KEY environment variables to be set")
+	}
+	return nil
+}
+
+// startS3Gateway starts the S3 gateway if it was enabled.
+func startS3Gateway(config Config, srv *server.Server) error {
+	if config.TurtleDexd.S3Addr == "" {
+		return nil
+	}
+	accessKey, secretKey := build.S3Credentials()
+	err := srv.ServeS3(config.TurtleDexd.S3Addr, s3gateway.Credentials{
+		AccessKeyID:     accessKey,
+		SecretAccessKey: secretKey,
+	})
+	if err != nil {
+		return errors.AddContext(err, "failed to start S3 gateway")
+	}
+	fmt.Println("S3 gateway listening on", config.TurtleDexd.S3Addr)
+	return nil
+}
+
+// verifyWebDAVConfig checks that the renter is enabled if the WebDAV server is
+// enabled and that the server only listens on a non-localhost address if it
+// requires a password.
+func verifyWebDAVConfig(config Config) error {
+	if config.TurtleDexd.WebDAVAddr == "" {
+		return nil
+	}
+	if !strings.Contains(config.TurtleDexd.Modules, "r") {
+		return errors.New("the WebDAV server requires the renter module")
+	}
+	addr := modules.NetAddress(config.TurtleDexd.WebDAVAddr)
+	if !config.TurtleDexd.AuthenticateAPI && !addr.IsLoopback() {
+		return errors.New("cannot bind the WebDAV server to a non-localhost address without setting an api password")
+	}
+	return nil
+}
+
+// startWebDAV starts the WebDAV server if it was enabled.
+func startWebDAV(config Config, srv *server.Server) error {
+	if config.TurtleDexd.WebDAVAddr == "" {
+		return nil
+	}
+	if err := srv.ServeWebDAV(config.TurtleDexd.WebDAVAddr); err != nil {
+		return errors.AddContext(err, "failed to start WebDAV server")
+	}
+	fmt.Println("WebDAV server listening on", config.TurtleDexd.WebDAVAddr)
+	return nil
+}
+
 // startDaemon uses the config parameters to initialize TurtleDex modules and start
 // ttdxd.
 func startDaemon(config Config) (err error) {
@@ -201,6 +271,16 @@ func startDaemon(config Config) (err error) {
 	// Attempt to auto-unlock the wallet using the SIA_WALLET_PASSWORD env variable
 	tryAutoUnlock(srv)
 
+	// Start the S3 gateway if enabled.
+	if err := startS3Gateway(config, srv); err != nil {
+		return errors.Compose(err, srv.Close())
+	}
+
+	// Start the WebDAV server if enabled.
+	if err := startWebDAV(config, srv); err != nil {
+		return errors.Compose(err, srv.Close())
+	}
+
 	// listen for kill signals
 	sigChan := installKillSignalHandler()
 
@@ -37,6 +37,8 @@ type Config struct {
 		HostAddr      string
 		TurtleDexMuxTCPAddr string
 		TurtleDexMuxWSAddr  string
+		S3Addr        string
+		WebDAVAddr    string
 		AllowAPIBind  bool
 
 		Modules           string
@@ -185,6 +187,8 @@ func main() {
 	root.Flags().StringVarP(&globalConfig.TurtleDexd.RPCaddr, "rpc-addr", "", ":9981", "which port the gateway listens on")
 	root.Flags().StringVarP(&globalConfig.TurtleDexd.TurtleDexMuxTCPAddr, "siamux-addr", "", ":9983", "which port the TurtleDexMux listens on")
 	root.Flags().StringVarP(&globalConfig.TurtleDexd.TurtleDexMuxWSAddr, "siamux-addr-ws", "", ":9984", "which port the TurtleDexMux websocket listens on")
+	root.Flags().StringVarP(&globalConfig.TurtleDexd.S3Addr, "s3-addr", "", "", "which host:port the S3 gateway listens on, disabled if empty")
+	root.Flags().StringVarP(&globalConfig.TurtleDexd.WebDAVAddr, "webdav-addr", "", "", "which host:port the WebDAV server listens on, disabled if empty")
 	root.Flags().StringVarP(&globalConfig.TurtleDexd.Modules, "modules", "M", "cghrtwf", "enabled modules, see 'ttdxd modules' for more info")
 	root.Flags().BoolVarP(&globalConfig.TurtleDexd.AuthenticateAPI, "authenticate-api", "", true, "enable API password protection")
 	root.Flags().BoolVarP(&globalConfig.TurtleDexd.TempPassword, "temp-password", "", false, "enter a temporary API password during startup")
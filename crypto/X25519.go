@@ -17,6 +17,12 @@ type (
 // GenerateX25519KeyPair generates an ephemeral key pair for use in ECDH.
 func GenerateX25519KeyPair() (xsk X25519SecretKey, xpk X25519PublicKey) {
 	fastrand.Read(xsk[:])
+	xpk = xsk.PublicKey()
+	return
+}
+
+// PublicKey returns the public key which belongs to the secret key.
+func (xsk X25519SecretKey) PublicKey() (xpk X25519PublicKey) {
 	curve25519.ScalarBaseMult((*[32]byte)(&xpk), (*[32]byte)(&xsk))
 	return
 }
@@ -0,0 +1,261 @@
+package modules
+
+import (
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turtledex/errors"
+	"github.com/turtledex/fastrand"
+
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/persist"
+)
+
+const (
+	// APIScopeAdmin grants access to every API endpoint including the
+	// management of API tokens.
+	APIScopeAdmin APIScope = "admin"
+
+	// APIScopeRead grants access to the password protected endpoints which
+	// only return information without modifying the state of the node.
+	APIScopeRead APIScope = "read"
+
+	// APIScopeRenter grants access to the renter's files, directories,
+	// downloads, uploads and settings.
+	APIScopeRenter APIScope = "renter"
+
+	// APIScopeSkynetUpload grants access to uploading and pinning skyfiles and
+	// updating registry entries.
+	APIScopeSkynetUpload APIScope = "skynet-upload"
+
+	// APIScopeWalletSpend grants access to sending coins and signing
+	// transactions with the wallet.
+	APIScopeWalletSpend APIScope = "wallet-spend"
+
+	// APIScopeHostAdmin grants access to the host's settings, announcements
+	// and storage folders.
+	APIScopeHostAdmin APIScope = "host-admin"
+)
+
+const (
+	// apiTokenSize is the number of random bytes in an API token.
+	apiTokenSize = 32
+)
+
+var (
+	// APITokensName is the name of the API tokens file on disk.
+	APITokensName = "apitokens.json"
+
+	// apiTokensMetadata is the persist metadata of the API tokens file.
+	apiTokensMetadata = persist.Metadata{
+		Header:  "API Tokens",
+		Version: "1.0.0",
+	}
+
+	// APIScopes are all the known API scopes.
+	APIScopes = []APIScope{
+		APIScopeAdmin,
+		APIScopeRead,
+		APIScopeRenter,
+		APIScopeSkynetUpload,
+		APIScopeWalletSpend,
+		APIScopeHostAdmin,
+	}
+
+	// ErrUnknownAPIToken is returned when an API token can't be found.
+	ErrUnknownAPIToken = errors.New("unknown API token")
+
+	// ErrUnknownAPIScope is returned when parsing an unknown API scope.
+	ErrUnknownAPIScope = errors.New("unknown API scope")
+)
+
+type (
+	// APIScope is a set of API endpoints which can be accessed with an API
+	// token.
+	APIScope string
+
+	// APIToken describes a token which can be used instead of the API password
+	// to access the endpoints of its scopes. The token itself is only known at
+	// the time of creation.
+	APIToken struct {
+		ID         string     `json:"id"`
+		Name       string     `json:"name"`
+		Scopes     []APIScope `json:"scopes"`
+		CreateTime time.Time  `json:"createtime"`
+	}
+
+	// APITokens is a helper type to manage the API tokens of a node. Only the
+	// hashes of the tokens are persisted.
+	APITokens struct {
+		tokens map[crypto.Hash]APIToken
+
+		// path of the tokens on disk.
+		path string
+		mu   sync.Mutex
+	}
+
+	// apiTokensPersist is the persisted form of APITokens.
+	apiTokensPersist struct {
+		Tokens []persistAPIToken `json:"tokens"`
+	}
+
+	// persistAPIToken is the persisted form of an APIToken.
+	persistAPIToken struct {
+		APIToken
+		Hash crypto.Hash `json:"hash"`
+	}
+)
+
+// NewAPIScope parses an API scope.
+func NewAPIScope(s string) (APIScope, error) {
+	for _, scope := range APIScopes {
+		if string(scope) == s {
+			return scope, nil
+		}
+	}
+	return "", errors.AddContext(ErrUnknownAPIScope, s)
+}
+
+// ParseAPIScopes parses a comma separated list of API scopes.
+func ParseAPIScopes(s string) ([]APIScope, error) {
+	var scopes []APIScope
+	for _, str := range strings.Split(s, ",") {
+		str = strings.TrimSpace(str)
+		if str == "" {
+			continue
+		}
+		scope, err := NewAPIScope(str)
+		if err != nil {
+			return nil, err
+		}
+		scopes = append(scopes, scope)
+	}
+	if len(scopes) == 0 {
+		return nil, errors.New("at least one scope is required")
+	}
+	return scopes, nil
+}
+
+// HasScope returns whether the token grants access to the scope.
+func (t APIToken) HasScope(scope APIScope) bool {
+	for _, s := range t.Scopes {
+		if s == scope || s == APIScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// NewAPITokens loads the API tokens from disk or creates an empty set of
+// tokens if no file exists yet.
+func NewAPITokens(path string) (*APITokens, error) {
+	at := &APITokens{
+		tokens: make(map[crypto.Hash]APIToken),
+		path:   path,
+	}
+	var p apiTokensPersist
+	err := persist.LoadJSON(apiTokensMetadata, &p, path)
+	if os.IsNotExist(err) {
+		return at, nil
+	}
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to load API tokens")
+	}
+	for _, t := range p.Tokens {
+		at.tokens[t.Hash] = t.APIToken
+	}
+	return at, nil
+}
+
+// Authorize returns whether the token grants access to the scope.
+func (at *APITokens) Authorize(token string, scope APIScope) bool {
+	at.mu.Lock()
+	defer at.mu.Unlock()
+	h := crypto.HashBytes([]byte(token))
+	for th, t := range at.tokens {
+		if subtle.ConstantTimeCompare(th[:], h[:]) == 1 {
+			return t.HasScope(scope)
+		}
+	}
+	return false
+}
+
+// Create creates a new API token with the provided scopes. The returned string
+// is the token which needs to be provided to access the API.
+func (at *APITokens) Create(name string, scopes []APIScope) (APIToken, string, error) {
+	if len(scopes) == 0 {
+		return APIToken{}, "", errors.New("at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if _, err := NewAPIScope(string(scope)); err != nil {
+			return APIToken{}, "", err
+		}
+	}
+	token := hex.EncodeToString(fastrand.Bytes(apiTokenSize))
+	h := crypto.HashBytes([]byte(token))
+	t := APIToken{
+		ID:         hex.EncodeToString(h[:8]),
+		Name:       name,
+		Scopes:     scopes,
+		CreateTime: time.Now(),
+	}
+
+	at.mu.Lock()
+	defer at.mu.Unlock()
+	at.tokens[h] = t
+	if err := at.save(); err != nil {
+		delete(at.tokens, h)
+		return APIToken{}, "", err
+	}
+	return t, token, nil
+}
+
+// Revoke revokes the API token with the provided id.
+func (at *APITokens) Revoke(id string) error {
+	at.mu.Lock()
+	defer at.mu.Unlock()
+	for h, t := range at.tokens {
+		if t.ID != id {
+			continue
+		}
+		delete(at.tokens, h)
+		if err := at.save(); err != nil {
+			at.tokens[h] = t
+			return err
+		}
+		return nil
+	}
+	return errors.AddContext(ErrUnknownAPIToken, fmt.Sprintf("no token with id %v", id))
+}
+
+// Tokens returns all API tokens sorted by their creation time.
+func (at *APITokens) Tokens() []APIToken {
+	at.mu.Lock()
+	defer at.mu.Unlock()
+	tokens := make([]APIToken, 0, len(at.tokens))
+	for _, t := range at.tokens {
+		tokens = append(tokens, t)
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].CreateTime.Before(tokens[j].CreateTime)
+	})
+	return tokens
+}
+
+// save saves the API tokens to disk.
+func (at *APITokens) save() error {
+	var p apiTokensPersist
+	for h, t := range at.tokens {
+		p.Tokens = append(p.Tokens, persistAPIToken{
+			APIToken: t,
+			Hash:     h,
+		})
+	}
+	return persist.SaveJSON(apiTokensMetadata, p, at.path)
+}
@@ -0,0 +1,111 @@
+package modules
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/build"
+	"github.com/turtledex/TurtleDexCore/persist"
+)
+
+// TestAPITokens tests creating, authorizing, persisting and revoking API
+// tokens.
+func TestAPITokens(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	testDir := build.TempDir("apitokens", t.Name())
+	if err := os.MkdirAll(testDir, persist.DefaultDiskPermissionsTest); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(testDir, APITokensName)
+	at, err := NewAPITokens(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create a token and an admin token.
+	renterToken, renterSecret, err := at.Create("renter", []APIScope{APIScopeRead, APIScopeRenter})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, adminSecret, err := at.Create("admin", []APIScope{APIScopeAdmin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := at.Create("none", nil); err == nil {
+		t.Fatal("tokens without scopes shouldn't be created")
+	}
+	if _, _, err := at.Create("unknown", []APIScope{"foo"}); !errors.Contains(err, ErrUnknownAPIScope) {
+		t.Fatal("expected ErrUnknownAPIScope but got", err)
+	}
+
+	// Check the scopes of the tokens.
+	check := func(at *APITokens) {
+		if !at.Authorize(renterSecret, APIScopeRenter) || !at.Authorize(renterSecret, APIScopeRead) {
+			t.Fatal("renter token should be authorized for its scopes")
+		}
+		if at.Authorize(renterSecret, APIScopeWalletSpend) || at.Authorize(renterSecret, APIScopeAdmin) {
+			t.Fatal("renter token shouldn't be authorized for other scopes")
+		}
+		for _, scope := range APIScopes {
+			if !at.Authorize(adminSecret, scope) {
+				t.Fatal("admin token should be authorized for", scope)
+			}
+		}
+		if at.Authorize("", APIScopeRead) || at.Authorize(renterToken.ID, APIScopeRead) {
+			t.Fatal("unknown token shouldn't be authorized")
+		}
+	}
+	check(at)
+
+	// The tokens should be persisted.
+	at, err = NewAPITokens(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(at)
+	if tokens := at.Tokens(); len(tokens) != 2 || tokens[0].ID != renterToken.ID {
+		t.Fatal("wrong tokens", tokens)
+	}
+
+	// Revoke the renter token.
+	if err := at.Revoke(renterToken.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := at.Revoke(renterToken.ID); !errors.Contains(err, ErrUnknownAPIToken) {
+		t.Fatal("expected ErrUnknownAPIToken but got", err)
+	}
+	at, err = NewAPITokens(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if at.Authorize(renterSecret, APIScopeRenter) {
+		t.Fatal("revoked token shouldn't be authorized")
+	}
+	if len(at.Tokens()) != 1 {
+		t.Fatal("expected one token")
+	}
+}
+
+// TestParseAPIScopes tests parsing lists of API scopes.
+func TestParseAPIScopes(t *testing.T) {
+	scopes, err := ParseAPIScopes("read, renter,skynet-upload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scopes) != 3 || scopes[0] != APIScopeRead || scopes[1] != APIScopeRenter || scopes[2] != APIScopeSkynetUpload {
+		t.Fatal("wrong scopes", scopes)
+	}
+	if _, err := ParseAPIScopes(""); err == nil {
+		t.Fatal("expected error for empty scopes")
+	}
+	if _, err := ParseAPIScopes("read,foo"); !errors.Contains(err, ErrUnknownAPIScope) {
+		t.Fatal("expected ErrUnknownAPIScope but got", err)
+	}
+}
@@ -0,0 +1,82 @@
+package modules
+
+import (
+	"time"
+
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+const (
+	// AuditCheckReadSector is a spot check which downloads a random segment
+	// of a sector together with a proof that it belongs to the sector.
+	AuditCheckReadSector AuditCheckType = "readsector"
+
+	// AuditCheckReadOffset is a spot check which downloads a random segment
+	// of a contract together with a proof that it belongs to the contract's
+	// latest revision.
+	AuditCheckReadOffset AuditCheckType = "readoffset"
+)
+
+type (
+	// AuditCheckType is the type of a spot check performed by the renter's
+	// integrity audit.
+	AuditCheckType string
+
+	// AuditFailure describes a spot check which proved that a host no longer
+	// stores the data it is supposed to store. TurtleDexPath, ChunkIndex,
+	// PieceIndex and MerkleRoot are only set for AuditCheckReadSector checks.
+	AuditFailure struct {
+		Time       time.Time                `json:"time"`
+		Type       AuditCheckType           `json:"type"`
+		HostPubKey types.TurtleDexPublicKey `json:"hostpubkey"`
+		Error      string                   `json:"error"`
+
+		TurtleDexPath TurtleDexPath `json:"siapath"`
+		ChunkIndex    uint64        `json:"chunkindex"`
+		PieceIndex    uint64        `json:"pieceindex"`
+		MerkleRoot    crypto.Hash   `json:"merkleroot"`
+	}
+
+	// HostAuditStatus contains the audit results of a single host since the
+	// renter was started.
+	HostAuditStatus struct {
+		HostPubKey types.TurtleDexPublicKey `json:"hostpubkey"`
+
+		// Passed is the number of spot checks the host passed and Failed the
+		// number of spot checks which proved data loss. Inconclusive checks
+		// failed for other reasons, e.g. because the host was offline.
+		Passed       uint64 `json:"passed"`
+		Failed       uint64 `json:"failed"`
+		Inconclusive uint64 `json:"inconclusive"`
+
+		// LostPieces is the number of pieces which were removed from the
+		// renter's files after the host failed to prove that it stores them.
+		LostPieces uint64 `json:"lostpieces"`
+
+		LastAudit   time.Time `json:"lastaudit"`
+		LastFailure time.Time `json:"lastfailure"`
+	}
+
+	// RenterAuditStatus contains the results of the renter's integrity audits
+	// since the renter was started. The number of failed audits of every host
+	// is also persisted in the hostdb.
+	RenterAuditStatus struct {
+		// Running indicates whether an audit is currently in progress.
+		Running bool `json:"running"`
+
+		// LastAudit is the time the last audit finished and NextAudit the
+		// time the next audit is scheduled for.
+		LastAudit time.Time `json:"lastaudit"`
+		NextAudit time.Time `json:"nextaudit"`
+
+		// Totals of all hosts.
+		Passed       uint64 `json:"passed"`
+		Failed       uint64 `json:"failed"`
+		Inconclusive uint64 `json:"inconclusive"`
+		LostPieces   uint64 `json:"lostpieces"`
+
+		Hosts          []HostAuditStatus `json:"hosts"`
+		RecentFailures []AuditFailure    `json:"recentfailures"`
+	}
+)
@@ -0,0 +1,356 @@
+package modules
+
+// compression.go implements the seekable compression format used by compressed
+// siafile and skyfile uploads. The uncompressed data is split into frames of
+// CompressionFrameSize bytes which are compressed independently of each other.
+// The compressed frames are followed by an index containing the offset of
+// every frame within the compressed data and a fixed-size footer. That way a
+// reader can locate the frame containing any uncompressed offset by reading
+// the footer and index from the end of the compressed data, which allows for
+// range requests without decompressing the whole file.
+//
+// The layout of the compressed data is:
+//
+//   [frame 0] ... [frame n-1] [offset 0] ... [offset n-1] [footer]
+//
+// where every offset is an 8 byte little endian integer and the footer
+// consists of the number of frames, the size of the uncompressed data and the
+// compressionMagic.
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/turtledex/errors"
+)
+
+type (
+	// CompressionType is the type of compression used for an upload.
+	CompressionType string
+
+	// compressionReader is an io.Reader which compresses the data of the
+	// underlying reader into the seekable compression format.
+	compressionReader struct {
+		staticSrc  io.Reader
+		staticType CompressionType
+
+		frame   []byte
+		buf     bytes.Buffer
+		offsets []uint64
+		written uint64
+		size    uint64
+		done    bool
+	}
+
+	// decompressionStreamer is a Streamer which decompresses data in the
+	// seekable compression format read from the underlying Streamer.
+	decompressionStreamer struct {
+		staticStreamer Streamer
+		staticType     CompressionType
+
+		// staticOffsets contains the offset of every frame within the
+		// compressed data followed by the offset of the index.
+		staticOffsets []uint64
+		staticSize    uint64
+
+		offset     uint64
+		frame      []byte
+		frameIndex uint64
+		frameValid bool
+	}
+)
+
+const (
+	// CompressionNone indicates that an upload is not compressed.
+	CompressionNone CompressionType = ""
+
+	// CompressionGzip indicates that an upload is compressed using gzip.
+	CompressionGzip CompressionType = "gzip"
+)
+
+const (
+	// CompressionFrameSize is the amount of uncompressed data that is
+	// compressed into a single frame.
+	CompressionFrameSize = 1 << 20
+
+	// compressionFooterSize is the size of the footer at the end of the
+	// compressed data.
+	compressionFooterSize = 24
+
+	// maxCompressedFrameSize is the maximum size of a single compressed frame.
+	// Compression can slightly increase the size of incompressible data but
+	// never by that much.
+	maxCompressedFrameSize = 2 * CompressionFrameSize
+)
+
+var (
+	// compressionMagic identifies the footer of the seekable compression
+	// format.
+	compressionMagic = []byte("ttdxcmp1")
+
+	// ErrInvalidCompressedData is returned when compressed data can't be
+	// decompressed.
+	ErrInvalidCompressedData = errors.New("invalid compressed data")
+
+	// ErrUnknownCompressionType is returned when an unknown compression type
+	// is provided.
+	ErrUnknownCompressionType = errors.New("unknown compression type")
+)
+
+// NewCompressionType parses a compression type from a string. Both the empty
+// string and "none" result in CompressionNone.
+func NewCompressionType(s string) (CompressionType, error) {
+	switch ct := CompressionType(strings.ToLower(s)); ct {
+	case CompressionNone, "none":
+		return CompressionNone, nil
+	case CompressionGzip:
+		return ct, nil
+	default:
+		return CompressionNone, errors.AddContext(ErrUnknownCompressionType, fmt.Sprintf("'%v', supported types are 'none' and '%v'", s, CompressionGzip))
+	}
+}
+
+// String implements the fmt.Stringer interface.
+func (ct CompressionType) String() string {
+	if ct == CompressionNone {
+		return "none"
+	}
+	return string(ct)
+}
+
+// NewCompressionReader returns a reader which compresses the data read from r
+// using the given compression type. If ct is CompressionNone, r is returned.
+func NewCompressionReader(ct CompressionType, r io.Reader) (io.Reader, error) {
+	if ct == CompressionNone {
+		return r, nil
+	}
+	if _, err := NewCompressionType(string(ct)); err != nil {
+		return nil, err
+	}
+	return &compressionReader{
+		staticSrc:  r,
+		staticType: ct,
+		frame:      make([]byte, CompressionFrameSize),
+	}, nil
+}
+
+// Read implements the io.Reader interface.
+func (cr *compressionReader) Read(p []byte) (int, error) {
+	for cr.buf.Len() == 0 {
+		if cr.done {
+			return 0, io.EOF
+		}
+		if err := cr.readFrame(); err != nil {
+			return 0, err
+		}
+	}
+	return cr.buf.Read(p)
+}
+
+// readFrame reads the next frame from the underlying reader and compresses it
+// into the output buffer. After the last frame, the index and footer are
+// written.
+func (cr *compressionReader) readFrame() error {
+	n, err := io.ReadFull(cr.staticSrc, cr.frame)
+	if err != nil && !errors.Contains(err, io.EOF) && !errors.Contains(err, io.ErrUnexpectedEOF) {
+		return err
+	}
+	if n > 0 {
+		before := cr.buf.Len()
+		if err := compressFrame(cr.staticType, &cr.buf, cr.frame[:n]); err != nil {
+			return errors.AddContext(err, "failed to compress frame")
+		}
+		cr.offsets = append(cr.offsets, cr.written)
+		cr.written += uint64(cr.buf.Len() - before)
+		cr.size += uint64(n)
+	}
+	if err == nil {
+		return nil
+	}
+
+	// The underlying reader is exhausted, write the index and footer.
+	var b [8]byte
+	for _, offset := range cr.offsets {
+		binary.LittleEndian.PutUint64(b[:], offset)
+		cr.buf.Write(b[:])
+	}
+	binary.LittleEndian.PutUint64(b[:], uint64(len(cr.offsets)))
+	cr.buf.Write(b[:])
+	binary.LittleEndian.PutUint64(b[:], cr.size)
+	cr.buf.Write(b[:])
+	cr.buf.Write(compressionMagic)
+	cr.done = true
+	return nil
+}
+
+// compressFrame compresses a single frame and writes it to w.
+func compressFrame(ct CompressionType, w io.Writer, frame []byte) error {
+	switch ct {
+	case CompressionGzip:
+		gw := gzip.NewWriter(w)
+		if _, err := gw.Write(frame); err != nil {
+			return err
+		}
+		return gw.Close()
+	default:
+		return ErrUnknownCompressionType
+	}
+}
+
+// decompressFrame decompresses a single frame.
+func decompressFrame(ct CompressionType, frame []byte) ([]byte, error) {
+	switch ct {
+	case CompressionGzip:
+		gr, err := gzip.NewReader(bytes.NewReader(frame))
+		if err != nil {
+			return nil, err
+		}
+		data, err := ioutil.ReadAll(io.LimitReader(gr, CompressionFrameSize+1))
+		return data, errors.Compose(err, gr.Close())
+	default:
+		return nil, ErrUnknownCompressionType
+	}
+}
+
+// NewDecompressionStreamer returns a Streamer which decompresses the data of
+// s using the given compression type. If ct is CompressionNone, s is returned.
+// The returned streamer operates on the uncompressed data, so seeking works as
+// if the data was never compressed.
+func NewDecompressionStreamer(ct CompressionType, s Streamer) (Streamer, error) {
+	if ct == CompressionNone {
+		return s, nil
+	}
+	if _, err := NewCompressionType(string(ct)); err != nil {
+		return nil, err
+	}
+
+	// Read the footer.
+	compressedSize, err := s.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to determine size of compressed data")
+	}
+	if compressedSize < compressionFooterSize {
+		return nil, errors.AddContext(ErrInvalidCompressedData, "data is too short")
+	}
+	footer := make([]byte, compressionFooterSize)
+	if err := readAt(s, footer, uint64(compressedSize-compressionFooterSize)); err != nil {
+		return nil, errors.AddContext(err, "failed to read footer")
+	}
+	if !bytes.Equal(footer[16:], compressionMagic) {
+		return nil, errors.AddContext(ErrInvalidCompressedData, "footer is missing")
+	}
+	numFrames := binary.LittleEndian.Uint64(footer[:8])
+	size := binary.LittleEndian.Uint64(footer[8:16])
+	if numFrames != (size+CompressionFrameSize-1)/CompressionFrameSize {
+		return nil, errors.AddContext(ErrInvalidCompressedData, "number of frames doesn't match size")
+	}
+	indexSize := numFrames * 8
+	if indexSize > uint64(compressedSize-compressionFooterSize) {
+		return nil, errors.AddContext(ErrInvalidCompressedData, "index is too large")
+	}
+	indexOffset := uint64(compressedSize-compressionFooterSize) - indexSize
+
+	// Read the index.
+	index := make([]byte, indexSize)
+	if err := readAt(s, index, indexOffset); err != nil {
+		return nil, errors.AddContext(err, "failed to read index")
+	}
+	offsets := make([]uint64, 0, numFrames+1)
+	for i := uint64(0); i < numFrames; i++ {
+		offsets = append(offsets, binary.LittleEndian.Uint64(index[i*8:]))
+	}
+	offsets = append(offsets, indexOffset)
+	for i := 1; i < len(offsets); i++ {
+		if offsets[i] < offsets[i-1] || offsets[i]-offsets[i-1] > maxCompressedFrameSize {
+			return nil, errors.AddContext(ErrInvalidCompressedData, "invalid frame offsets")
+		}
+	}
+	return &decompressionStreamer{
+		staticStreamer: s,
+		staticType:     ct,
+		staticOffsets:  offsets,
+		staticSize:     size,
+	}, nil
+}
+
+// readAt reads len(b) bytes from s starting at offset.
+func readAt(s io.ReadSeeker, b []byte, offset uint64) error {
+	if _, err := s.Seek(int64(offset), io.SeekStart); err != nil {
+		return err
+	}
+	_, err := io.ReadFull(s, b)
+	return err
+}
+
+// Close implements the io.Closer interface.
+func (ds *decompressionStreamer) Close() error {
+	return ds.staticStreamer.Close()
+}
+
+// Read implements the io.Reader interface.
+func (ds *decompressionStreamer) Read(p []byte) (int, error) {
+	if ds.offset >= ds.staticSize {
+		return 0, io.EOF
+	}
+	frameIndex := ds.offset / CompressionFrameSize
+	if err := ds.loadFrame(frameIndex); err != nil {
+		return 0, err
+	}
+	n := copy(p, ds.frame[ds.offset-frameIndex*CompressionFrameSize:])
+	ds.offset += uint64(n)
+	return n, nil
+}
+
+// loadFrame makes sure that the frame with the given index is decompressed.
+func (ds *decompressionStreamer) loadFrame(frameIndex uint64) error {
+	if ds.frameValid && ds.frameIndex == frameIndex {
+		return nil
+	}
+	start, end := ds.staticOffsets[frameIndex], ds.staticOffsets[frameIndex+1]
+	compressed := make([]byte, end-start)
+	if err := readAt(ds.staticStreamer, compressed, start); err != nil {
+		return errors.AddContext(err, "failed to read compressed frame")
+	}
+	frame, err := decompressFrame(ds.staticType, compressed)
+	if err != nil {
+		return errors.Compose(ErrInvalidCompressedData, err)
+	}
+	// Every frame but the last one is exactly CompressionFrameSize bytes.
+	expectedSize := uint64(CompressionFrameSize)
+	if remaining := ds.staticSize - frameIndex*CompressionFrameSize; remaining < expectedSize {
+		expectedSize = remaining
+	}
+	if uint64(len(frame)) != expectedSize {
+		return errors.AddContext(ErrInvalidCompressedData, fmt.Sprintf("frame %v has size %v, expected %v", frameIndex, len(frame), expectedSize))
+	}
+	ds.frame = frame
+	ds.frameIndex = frameIndex
+	ds.frameValid = true
+	return nil
+}
+
+// Seek implements the io.Seeker interface. Offsets are relative to the
+// uncompressed data.
+func (ds *decompressionStreamer) Seek(offset int64, whence int) (int64, error) {
+	var newOffset int64
+	switch whence {
+	case io.SeekStart:
+		newOffset = offset
+	case io.SeekCurrent:
+		newOffset = int64(ds.offset) + offset
+	case io.SeekEnd:
+		newOffset = int64(ds.staticSize) + offset
+	default:
+		return int64(ds.offset), errors.New("invalid value for 'whence' in call to seek")
+	}
+	if newOffset < 0 {
+		return int64(ds.offset), errors.New("cannot seek to negative offset")
+	}
+	ds.offset = uint64(newOffset)
+	return newOffset, nil
+}
@@ -0,0 +1,147 @@
+package modules
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/turtledex/errors"
+	"github.com/turtledex/fastrand"
+)
+
+// testStreamer wraps a bytes.Reader to implement the Streamer interface.
+type testStreamer struct {
+	*bytes.Reader
+}
+
+// Close implements the io.Closer interface.
+func (ts testStreamer) Close() error { return nil }
+
+// TestNewCompressionType tests parsing compression types.
+func TestNewCompressionType(t *testing.T) {
+	tests := []struct {
+		in  string
+		out CompressionType
+		err error
+	}{
+		{"", CompressionNone, nil},
+		{"none", CompressionNone, nil},
+		{"gzip", CompressionGzip, nil},
+		{"GZIP", CompressionGzip, nil},
+		{"zip", CompressionNone, ErrUnknownCompressionType},
+	}
+	for _, test := range tests {
+		ct, err := NewCompressionType(test.in)
+		if err != test.err && !errors.Contains(err, test.err) {
+			t.Fatalf("%v: expected error %v but got %v", test.in, test.err, err)
+		}
+		if ct != test.out {
+			t.Fatalf("%v: expected %v but got %v", test.in, test.out, ct)
+		}
+	}
+}
+
+// TestCompressionRoundTrip tests compressing data and reading it back using
+// a decompression streamer.
+func TestCompressionRoundTrip(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	sizes := []int{0, 1, CompressionFrameSize, 2*CompressionFrameSize + CompressionFrameSize/2}
+	for _, size := range sizes {
+		// Use data that compresses well.
+		data := bytes.Repeat([]byte("compress me "), size/12+1)[:size]
+
+		// Compress it.
+		r, err := NewCompressionReader(CompressionGzip, bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		compressed, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if size >= CompressionFrameSize && len(compressed) >= size {
+			t.Fatalf("data wasn't compressed: %v >= %v", len(compressed), size)
+		}
+
+		// Decompress it.
+		ds, err := NewDecompressionStreamer(CompressionGzip, testStreamer{bytes.NewReader(compressed)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		end, err := ds.Seek(0, io.SeekEnd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if end != int64(size) {
+			t.Fatalf("wrong size %v != %v", end, size)
+		}
+		if _, err := ds.Seek(0, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		decompressed, err := ioutil.ReadAll(ds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decompressed, data) {
+			t.Fatal("data doesn't match")
+		}
+
+		// Read random ranges.
+		for i := 0; i < 10 && size > 0; i++ {
+			off := fastrand.Intn(size)
+			length := fastrand.Intn(size-off) + 1
+			if _, err := ds.Seek(int64(off), io.SeekStart); err != nil {
+				t.Fatal(err)
+			}
+			b := make([]byte, length)
+			if _, err := io.ReadFull(ds, b); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(b, data[off:off+length]) {
+				t.Fatal("range doesn't match", off, length)
+			}
+		}
+	}
+}
+
+// TestDecompressionStreamerInvalid tests that a decompression streamer can't
+// be created from invalid data.
+func TestDecompressionStreamerInvalid(t *testing.T) {
+	t.Parallel()
+
+	// Data without a footer.
+	_, err := NewDecompressionStreamer(CompressionGzip, testStreamer{bytes.NewReader(fastrand.Bytes(100))})
+	if !errors.Contains(err, ErrInvalidCompressedData) {
+		t.Fatal("expected ErrInvalidCompressedData but got", err)
+	}
+
+	// Data with a corrupted frame.
+	r, err := NewCompressionReader(CompressionGzip, bytes.NewReader(fastrand.Bytes(100)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	compressed, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	compressed[0]++
+	ds, err := NewDecompressionStreamer(CompressionGzip, testStreamer{bytes.NewReader(compressed)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ioutil.ReadAll(ds)
+	if !errors.Contains(err, ErrInvalidCompressedData) {
+		t.Fatal("expected ErrInvalidCompressedData but got", err)
+	}
+
+	// Unknown compression type.
+	_, err = NewDecompressionStreamer("zip", testStreamer{bytes.NewReader(compressed)})
+	if !errors.Contains(err, ErrUnknownCompressionType) {
+		t.Fatal("expected ErrUnknownCompressionType but got", err)
+	}
+}
@@ -0,0 +1,44 @@
+package modules
+
+import (
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+type (
+	// ContractMaintenancePlan describes the actions the next round of contract
+	// maintenance would take given an allowance and the current state of the
+	// contractor, the hostdb and the wallet. Creating a plan neither forms nor
+	// renews contracts nor changes their utility.
+	//
+	// Actions which maintenance would attempt but can't afford are contained
+	// in the plan with a SkipReason. The TotalCost only includes the actions
+	// which aren't skipped.
+	ContractMaintenancePlan struct {
+		Allowance      Allowance         `json:"allowance"`
+		BlockHeight    types.BlockHeight `json:"blockheight"`
+		EndHeight      types.BlockHeight `json:"endheight"`
+		FundsRemaining types.Currency    `json:"fundsremaining"`
+		WalletBalance  types.Currency    `json:"walletbalance"`
+		WalletUnlocked bool              `json:"walletunlocked"`
+
+		Cancellations []PlannedContractAction `json:"cancellations"`
+		Renewals      []PlannedContractAction `json:"renewals"`
+		Refreshes     []PlannedContractAction `json:"refreshes"`
+		Formations    []PlannedContractAction `json:"formations"`
+
+		TotalCost types.Currency `json:"totalcost"`
+	}
+
+	// PlannedContractAction describes a single action of a contract
+	// maintenance plan. The ContractID is empty for formations. Cancellations
+	// are contracts which would no longer be renewed and don't cost anything.
+	PlannedContractAction struct {
+		ContractID    types.FileContractID     `json:"contractid"`
+		HostPublicKey types.TurtleDexPublicKey `json:"hostpublickey"`
+		NetAddress    NetAddress               `json:"netaddress"`
+		Size          uint64                   `json:"size"`
+		EstimatedCost types.Currency           `json:"estimatedcost"`
+		Reason        string                   `json:"reason"`
+		SkipReason    string                   `json:"skipreason"`
+	}
+)
@@ -350,6 +350,11 @@ func (h *Host) managedUpdatePriceTable() {
 		ReadBaseCost:   hes.SectorAccessPrice, // roughly equal to 64 kib download
 		ReadLengthCost: types.NewCurrency64(1),
 
+		// Update related costs. Updating a sector requires the host to read
+		// and rewrite the whole sector.
+		UpdateSectorBaseCost:   hes.SectorAccessPrice.Mul64(2),
+		UpdateSectorLengthCost: types.NewCurrency64(1),
+
 		// Write related costs.
 		WriteBaseCost:   hes.SectorAccessPrice, // roughly equal to 64 kib download
 		WriteLengthCost: types.NewCurrency64(1),
@@ -779,6 +784,16 @@ func (h *Host) RegistryGet(pubKey types.TurtleDexPublicKey, tweak crypto.Hash) (
 	return h.staticRegistry.Get(pubKey, tweak)
 }
 
+// RegistryGetByEID retrieves a value from the registry using the entry's id.
+func (h *Host) RegistryGetByEID(eid modules.RegistryEntryID) (types.TurtleDexPublicKey, modules.SignedRegistryValue, bool) {
+	err := h.tg.Add()
+	if err != nil {
+		return types.TurtleDexPublicKey{}, modules.SignedRegistryValue{}, false
+	}
+	defer h.tg.Done()
+	return h.staticRegistry.GetByEntryID(eid)
+}
+
 // RegistryUpdate updates a value in the registry.
 func (h *Host) RegistryUpdate(rv modules.SignedRegistryValue, pubKey types.TurtleDexPublicKey, expiry types.BlockHeight) (modules.SignedRegistryValue, error) {
 	err := h.tg.Add()
@@ -110,6 +110,16 @@ func (tb *testProgramBuilder) AddSwapSectorInstruction(sector1Idx, sector2Idx ui
 	tb.staticValues.AddSwapSectorInstruction()
 }
 
+// AddUpdateSectorInstruction adds an UpdateSector instruction to the builder,
+// keeping track of running values.
+func (tb *testProgramBuilder) AddUpdateSectorInstruction(sectorIdx, offset uint64, data []byte, merkleProof bool) {
+	err := tb.staticPB.AddUpdateSectorInstruction(sectorIdx, offset, data, merkleProof)
+	if err != nil {
+		panic(err)
+	}
+	tb.staticValues.AddUpdateSectorInstruction(data)
+}
+
 // AddUpdateRegistryInstruction adds an UpdateRegistry instruction to the
 // builder, keeping track of running values.
 func (tb *testProgramBuilder) AddUpdateRegistryInstruction(spk types.TurtleDexPublicKey, rv modules.SignedRegistryValue) {
@@ -131,6 +141,17 @@ func (tb *testProgramBuilder) AddReadRegistryInstruction(spk types.TurtleDexPubl
 	return refund
 }
 
+// AddReadRegistryEIDInstruction adds an ReadRegistryEID instruction to the
+// builder, keeping track of running values.
+func (tb *testProgramBuilder) AddReadRegistryEIDInstruction(eid modules.RegistryEntryID, refunded bool) types.Currency {
+	refund, err := tb.staticPB.AddReadRegistryEIDInstruction(eid)
+	if err != nil {
+		panic(err)
+	}
+	tb.staticValues.AddReadRegistryEIDInstruction(refunded)
+	return refund
+}
+
 // Program returns the built program.
 func (tb *testProgramBuilder) Program() (modules.Program, modules.ProgramData) {
 	return tb.staticPB.Program()
@@ -0,0 +1,107 @@
+package mdm
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/types"
+	"github.com/turtledex/encoding"
+)
+
+// instructionReadRegistryEID defines an instruction to read an entry from the
+// registry by its entry id.
+type instructionReadRegistryEID struct {
+	commonInstruction
+
+	eidOffset uint64
+}
+
+// staticDecodeReadRegistryEIDInstruction creates a new 'ReadRegistryEID'
+// instruction from the provided generic instruction.
+func (p *program) staticDecodeReadRegistryEIDInstruction(instruction modules.Instruction) (instruction, error) {
+	// Check specifier.
+	if instruction.Specifier != modules.SpecifierReadRegistryEID {
+		return nil, fmt.Errorf("expected specifier %v but got %v",
+			modules.SpecifierReadRegistryEID, instruction.Specifier)
+	}
+	// Check args.
+	if len(instruction.Args) != modules.RPCIReadRegistryEIDLen {
+		return nil, fmt.Errorf("expected instruction to have len %v but was %v",
+			modules.RPCIReadRegistryEIDLen, len(instruction.Args))
+	}
+	// Read args.
+	eidOffset := binary.LittleEndian.Uint64(instruction.Args[:8])
+	return &instructionReadRegistryEID{
+		commonInstruction: commonInstruction{
+			staticData:  p.staticData,
+			staticState: p.staticProgramState,
+		},
+		eidOffset: eidOffset,
+	}, nil
+}
+
+// Execute executes the 'ReadRegistryEID' instruction.
+func (i *instructionReadRegistryEID) Execute(prevOutput output) (output, types.Currency) {
+	// Fetch the args.
+	eid, err := i.staticData.Hash(i.eidOffset)
+	if err != nil {
+		return errOutput(err), types.ZeroCurrency
+	}
+
+	// Prepare the output. An empty output.Output means the data wasn't found.
+	out := output{
+		NewSize:       prevOutput.NewSize,
+		NewMerkleRoot: prevOutput.NewMerkleRoot,
+		Output:        nil,
+	}
+
+	// Get the value. If this fails we are done.
+	spk, rv, found := i.staticState.host.RegistryGetByEID(modules.RegistryEntryID(eid))
+	if !found {
+		_, refund := modules.MDMReadRegistryCost(i.staticState.priceTable)
+		return out, refund
+	}
+
+	// Return the public key and tweak followed by the signature, revision and
+	// data. Unlike a regular 'ReadRegistry' the renter doesn't know the key
+	// and tweak upfront and needs them to verify the entry.
+	rev := make([]byte, 8)
+	binary.LittleEndian.PutUint64(rev, rv.Revision)
+	out.Output = encoding.Marshal(spk)
+	out.Output = append(out.Output, rv.Tweak[:]...)
+	out.Output = append(out.Output, rv.Signature[:]...)
+	out.Output = append(out.Output, rev...)
+	out.Output = append(out.Output, rv.Data...)
+	return out, types.ZeroCurrency
+}
+
+// Batch declares whether or not this instruction can be batched together with
+// the previous instruction. Just like regular registry reads, reads by entry
+// id are tiny and low latency.
+func (i *instructionReadRegistryEID) Batch() bool {
+	return true
+}
+
+// Collateral returns the collateral the host has to put up for this
+// instruction.
+func (i *instructionReadRegistryEID) Collateral() types.Currency {
+	return modules.MDMReadRegistryCollateral()
+}
+
+// Cost returns the Cost of this `ReadRegistryEID` instruction.
+func (i *instructionReadRegistryEID) Cost() (executionCost, refund types.Currency, err error) {
+	executionCost, refund = modules.MDMReadRegistryCost(i.staticState.priceTable)
+	return
+}
+
+// Memory returns the memory allocated by the 'ReadRegistryEID' instruction
+// beyond the lifetime of the instruction.
+func (i *instructionReadRegistryEID) Memory() uint64 {
+	return modules.MDMReadRegistryMemory()
+}
+
+// Time returns the execution time of an 'ReadRegistryEID' instruction.
+func (i *instructionReadRegistryEID) Time() (uint64, error) {
+	return modules.MDMTimeReadRegistryEID, nil
+}
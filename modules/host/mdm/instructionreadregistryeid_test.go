@@ -0,0 +1,92 @@
+package mdm
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/types"
+	"github.com/turtledex/encoding"
+	"github.com/turtledex/fastrand"
+)
+
+// TestInstructionReadRegistryEID tests the ReadRegistryEID instruction.
+func TestInstructionReadRegistryEID(t *testing.T) {
+	host := newTestHost()
+	mdm := New(host)
+	defer mdm.Stop()
+
+	// Add a registry value for a given random key/tweak pair.
+	sk, pk := crypto.GenerateKeyPair()
+	var tweak crypto.Hash
+	fastrand.Read(tweak[:])
+	data := fastrand.Bytes(modules.RegistryDataSize)
+	rev := fastrand.Uint64n(1000)
+	spk := types.TurtleDexPublicKey{
+		Algorithm: types.SignatureEd25519,
+		Key:       pk[:],
+	}
+	rv := modules.NewRegistryValue(tweak, data, rev).Sign(sk)
+	_, err := host.RegistryUpdate(rv, spk, types.BlockHeight(fastrand.Uint64n(1000)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	so := host.newTestStorageObligation(true)
+	pt := newTestPriceTable()
+	tb := newTestProgramBuilder(pt, 0)
+	tb.AddReadRegistryEIDInstruction(modules.DeriveRegistryEntryID(spk, tweak), false)
+
+	// Execute it.
+	outputs, err := mdm.ExecuteProgramWithBuilder(tb, so, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Assert output.
+	output := outputs[0]
+	revBytes := make([]byte, 8)
+	binary.LittleEndian.PutUint64(revBytes, rev)
+	expectedOutput := encoding.Marshal(spk)
+	expectedOutput = append(expectedOutput, tweak[:]...)
+	expectedOutput = append(expectedOutput, rv.Signature[:]...)
+	expectedOutput = append(expectedOutput, revBytes...)
+	expectedOutput = append(expectedOutput, rv.Data...)
+	err = output.assert(0, crypto.Hash{}, []crypto.Hash{}, expectedOutput, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestInstructionReadRegistryEIDNotFound tests the ReadRegistryEID
+// instruction for when an entry isn't found.
+func TestInstructionReadRegistryEIDNotFound(t *testing.T) {
+	host := newTestHost()
+	mdm := New(host)
+	defer mdm.Stop()
+
+	// Create a random entry id.
+	var eid modules.RegistryEntryID
+	fastrand.Read(eid[:])
+
+	so := host.newTestStorageObligation(true)
+	pt := newTestPriceTable()
+	tb := newTestProgramBuilder(pt, 0)
+	refund := tb.AddReadRegistryEIDInstruction(eid, true)
+
+	// Execute it.
+	outputs, remainingBudget, err := mdm.ExecuteProgramWithBuilderCustomBudget(tb, so, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outputs[0].Error != nil {
+		t.Fatal("error returned", outputs[0].Error)
+	}
+	if len(outputs[0].Output) != 0 {
+		t.Fatal("expected empty output")
+	}
+	if !remainingBudget.Remaining().Equals(refund) {
+		t.Fatal("remaining budget should equal refund", remainingBudget.Remaining().HumanString(), refund.HumanString())
+	}
+}
@@ -0,0 +1,178 @@
+package mdm
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/types"
+	"github.com/turtledex/encoding"
+	"github.com/turtledex/errors"
+)
+
+// instructionUpdateSector is an instruction that overwrites a range of bytes
+// within a sector of a file contract.
+type instructionUpdateSector struct {
+	commonInstruction
+
+	sectorIndexOffset uint64
+	offsetOffset      uint64
+	lengthOffset      uint64
+	dataOffset        uint64
+}
+
+// staticDecodeUpdateSectorInstruction creates a new 'UpdateSector'
+// instruction from the provided generic instruction.
+func (p *program) staticDecodeUpdateSectorInstruction(instruction modules.Instruction) (instruction, error) {
+	// Check specifier.
+	if instruction.Specifier != modules.SpecifierUpdateSector {
+		return nil, fmt.Errorf("expected specifier %v but got %v",
+			modules.SpecifierUpdateSector, instruction.Specifier)
+	}
+	// Check args.
+	if len(instruction.Args) != modules.RPCIUpdateSectorLen {
+		return nil, fmt.Errorf("expected instruction to have len %v but was %v",
+			modules.RPCIUpdateSectorLen, len(instruction.Args))
+	}
+	// Read args.
+	sectorIndexOffset := binary.LittleEndian.Uint64(instruction.Args[:8])
+	offsetOffset := binary.LittleEndian.Uint64(instruction.Args[8:16])
+	lengthOffset := binary.LittleEndian.Uint64(instruction.Args[16:24])
+	dataOffset := binary.LittleEndian.Uint64(instruction.Args[24:32])
+	return &instructionUpdateSector{
+		commonInstruction: commonInstruction{
+			staticData:        p.staticData,
+			staticMerkleProof: instruction.Args[32] == 1,
+			staticState:       p.staticProgramState,
+		},
+		sectorIndexOffset: sectorIndexOffset,
+		offsetOffset:      offsetOffset,
+		lengthOffset:      lengthOffset,
+		dataOffset:        dataOffset,
+	}, nil
+}
+
+// Batch declares whether or not this instruction can be batched together with
+// the previous instruction.
+func (i instructionUpdateSector) Batch() bool {
+	return false
+}
+
+// Execute executes the 'UpdateSector' instruction.
+func (i *instructionUpdateSector) Execute(prevOutput output) (output, types.Currency) {
+	// Fetch the operands.
+	sectorIndex, err := i.staticData.Uint64(i.sectorIndexOffset)
+	if err != nil {
+		return errOutput(err), types.ZeroCurrency
+	}
+	offset, err := i.staticData.Uint64(i.offsetOffset)
+	if err != nil {
+		return errOutput(err), types.ZeroCurrency
+	}
+	length, err := i.staticData.Uint64(i.lengthOffset)
+	if err != nil {
+		return errOutput(err), types.ZeroCurrency
+	}
+
+	// Validate the request.
+	ps := i.staticState
+	switch {
+	case sectorIndex >= uint64(len(ps.sectors.merkleRoots)):
+		err = fmt.Errorf("idx out-of-bounds: %v >= %v", sectorIndex, len(ps.sectors.merkleRoots))
+	case offset > modules.SectorSize || length > modules.SectorSize-offset:
+		err = fmt.Errorf("request is out of bounds %v + %v > %v", offset, length, modules.SectorSize)
+	case length == 0:
+		err = errors.New("length cannot be zero")
+	}
+	if err != nil {
+		return errOutput(err), types.ZeroCurrency
+	}
+
+	// Fetch the data.
+	data, err := i.staticData.Bytes(i.dataOffset, length)
+	if err != nil {
+		return errOutput(err), types.ZeroCurrency
+	}
+
+	// Read the old sector and apply the update to a copy of it.
+	oldRoot := ps.sectors.merkleRoots[sectorIndex]
+	oldSector, err := ps.sectors.readSector(ps.host, oldRoot)
+	if err != nil {
+		return errOutput(err), types.ZeroCurrency
+	}
+	newSector := make([]byte, len(oldSector))
+	copy(newSector, oldSector)
+	copy(newSector[offset:], data)
+
+	newMerkleRoot, err := ps.sectors.updateSector(sectorIndex, newSector)
+	if err != nil {
+		return errOutput(err), types.ZeroCurrency
+	}
+
+	// If no proof was requested we are done.
+	if !i.staticMerkleProof {
+		return output{
+			NewSize:       prevOutput.NewSize,
+			NewMerkleRoot: newMerkleRoot,
+		}, types.ZeroCurrency
+	}
+
+	// Create a range proof for the segments touched by the update. The
+	// segments outside of that range are the same for the old and new sector
+	// which allows the renter to verify both sector roots with the same
+	// proof.
+	start, end := modules.MDMUpdateSectorSegmentRange(offset, length)
+	resp := modules.MDMInstructionUpdateSectorResponse{
+		OldSectorRoot: oldRoot,
+		NewSectorRoot: ps.sectors.merkleRoots[sectorIndex],
+		OldSegments:   oldSector[start*crypto.SegmentSize : end*crypto.SegmentSize],
+		SectorProof:   crypto.MerkleRangeProof(oldSector, int(start), int(end)),
+	}
+
+	// Create the diff proof for the updated sector. Only a single leaf
+	// changed which means the proof is valid for both the old and the new
+	// contract root.
+	newRoots := ps.sectors.merkleRoots
+	ranges := []crypto.ProofRange{
+		{
+			Start: sectorIndex,
+			End:   sectorIndex + 1,
+		},
+	}
+	proof := crypto.MerkleDiffProof(ranges, uint64(len(newRoots)), nil, newRoots)
+
+	return output{
+		NewSize:       prevOutput.NewSize,
+		NewMerkleRoot: newMerkleRoot,
+		Output:        encoding.Marshal(resp),
+		Proof:         proof,
+	}, types.ZeroCurrency
+}
+
+// Collateral returns the collateral cost of updating a sector.
+func (i *instructionUpdateSector) Collateral() types.Currency {
+	return modules.MDMUpdateSectorCollateral()
+}
+
+// Cost returns the Cost of this `UpdateSector` instruction.
+func (i *instructionUpdateSector) Cost() (executionCost, _ types.Currency, err error) {
+	var length uint64
+	length, err = i.staticData.Uint64(i.lengthOffset)
+	if err != nil {
+		return
+	}
+	executionCost = modules.MDMUpdateSectorCost(i.staticState.priceTable, length)
+	return
+}
+
+// Memory returns the memory allocated by the 'UpdateSector' instruction
+// beyond the lifetime of the instruction.
+func (i *instructionUpdateSector) Memory() uint64 {
+	return modules.MDMUpdateSectorMemory()
+}
+
+// Time returns the execution time of an 'UpdateSector' instruction.
+func (i *instructionUpdateSector) Time() (uint64, error) {
+	return modules.MDMTimeUpdateSector, nil
+}
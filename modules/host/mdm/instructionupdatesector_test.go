@@ -0,0 +1,222 @@
+package mdm
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/types"
+	"github.com/turtledex/encoding"
+	"github.com/turtledex/fastrand"
+)
+
+// TestInstructionUpdateSector tests executing a program with a single
+// UpdateSector instruction.
+func TestInstructionUpdateSector(t *testing.T) {
+	host := newTestHost()
+	mdm := New(host)
+	defer mdm.Stop()
+
+	// Create a storage obligation with some random sectors. The sectors are
+	// added to the obligation's sector map to allow for removing them.
+	numSectors := 10
+	so := host.newTestStorageObligation(true)
+	so.AddRandomSectors(numSectors)
+	for _, root := range so.sectorRoots {
+		so.sectorMap[root] = host.sectors[root]
+	}
+
+	// Prepare a priceTable and duration.
+	pt := newTestPriceTable()
+	duration := types.BlockHeight(fastrand.Uint64n(5)) // random since it doesn't matter for update
+
+	// Run basic case.
+	t.Run("Basic", func(t *testing.T) {
+		testInstructionUpdateSectorBasic(t, mdm, host, uint64(numSectors), pt, duration, so)
+	})
+	// Run case for an out-of-bounds sector index.
+	t.Run("OutOfBounds", func(t *testing.T) {
+		testInstructionUpdateSectorOutOfBounds(t, mdm, uint64(numSectors), pt, duration, so)
+	})
+	// Run case for an offset which overflows when adding the length.
+	t.Run("OffsetOverflow", func(t *testing.T) {
+		testInstructionUpdateSectorOffsetOverflow(t, mdm, pt, duration, so)
+	})
+	// Run basic case but without requesting a proof.
+	t.Run("NoProof", func(t *testing.T) {
+		testInstructionUpdateSectorNoProof(t, mdm, host, uint64(numSectors), pt, duration, so)
+	})
+}
+
+// testInstructionUpdateSectorBasic tests updating a random range within a
+// random sector of a filecontract and verifies the returned proof.
+func testInstructionUpdateSectorBasic(t *testing.T, mdm *MDM, host *TestHost, numSectors uint64, pt *modules.RPCPriceTable, duration types.BlockHeight, so *TestStorageObligation) {
+	// Choose a random sector and range to update.
+	i := fastrand.Uint64n(numSectors)
+	offset := fastrand.Uint64n(modules.SectorSize)
+	length := fastrand.Uint64n(modules.SectorSize-offset) + 1
+	data := fastrand.Bytes(int(length))
+
+	ics := so.ContractSize()
+	imr := so.MerkleRoot()
+	oldRoots := append([]crypto.Hash{}, so.sectorRoots...)
+
+	// Use a builder to build the program.
+	tb := newTestProgramBuilder(pt, duration)
+	tb.AddUpdateSectorInstruction(i, offset, data, true)
+
+	// Execute it.
+	outputs, err := mdm.ExecuteProgramWithBuilder(tb, so, duration, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := outputs[0]
+
+	// Compute the expected new sector and root.
+	oldSector, err := host.ReadSector(oldRoots[i])
+	if err != nil {
+		t.Fatal(err)
+	}
+	newSector := append([]byte{}, oldSector...)
+	copy(newSector[offset:], data)
+	newRoots := append([]crypto.Hash{}, oldRoots...)
+	newRoots[i] = crypto.MerkleRoot(newSector)
+	nmr := cachedMerkleRoot(newRoots)
+
+	// Make sure the new merkle root doesn't match the old one.
+	if nmr == imr {
+		t.Fatal("nmr shouldn't match imr")
+	}
+	if output.NewSize != ics {
+		t.Fatalf("expected size %v but was %v", ics, output.NewSize)
+	}
+	if output.NewMerkleRoot != nmr {
+		t.Fatal("wrong new merkle root")
+	}
+
+	// Decode the response and verify the proof.
+	var resp modules.MDMInstructionUpdateSectorResponse
+	err = encoding.Unmarshal(output.Output, &resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = modules.VerifyUpdateSectorProof(resp, output.Proof, numSectors, i, offset, data, imr, nmr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The proof shouldn't verify for different data.
+	badData := append([]byte{}, data...)
+	badData[0]++
+	err = modules.VerifyUpdateSectorProof(resp, output.Proof, numSectors, i, offset, badData, imr, nmr)
+	if err == nil {
+		t.Fatal("proof shouldn't verify for modified data")
+	}
+
+	// Make sure the sector actually got updated.
+	if so.sectorRoots[i] != newRoots[i] {
+		t.Fatal("sector not updated correctly")
+	}
+	if !bytes.Equal(so.sectorMap[newRoots[i]], newSector) {
+		t.Fatal("sector data not updated correctly")
+	}
+	if _, exists := so.sectorMap[oldRoots[i]]; exists {
+		t.Fatal("old sector wasn't removed")
+	}
+
+	// Store the updated sector on the host for the following subtests.
+	host.sectors[newRoots[i]] = newSector
+}
+
+// testInstructionUpdateSectorOutOfBounds tests that specifying an invalid
+// index causes the execution to fail.
+func testInstructionUpdateSectorOutOfBounds(t *testing.T, mdm *MDM, numSectors uint64, pt *modules.RPCPriceTable, duration types.BlockHeight, so *TestStorageObligation) {
+	// Use a builder to build the program.
+	tb := newTestProgramBuilder(pt, duration)
+	tb.AddUpdateSectorInstruction(numSectors, 0, fastrand.Bytes(1), true)
+
+	// Execute it.
+	_, err := mdm.ExecuteProgramWithBuilder(tb, so, duration, true)
+	if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("idx out-of-bounds: %v >= %v", numSectors, numSectors)) {
+		t.Fatal("expected execution to fail with out of bounds error", err)
+	}
+}
+
+// testInstructionUpdateSectorOffsetOverflow tests that an offset close to the
+// maximum uint64, which would overflow when adding the length, is rejected.
+func testInstructionUpdateSectorOffsetOverflow(t *testing.T, mdm *MDM, pt *modules.RPCPriceTable, duration types.BlockHeight, so *TestStorageObligation) {
+	// Build a valid program and replace the offset in the program data since
+	// the builder refuses to build an out-of-bounds update.
+	tb := newTestProgramBuilder(pt, duration)
+	tb.AddUpdateSectorInstruction(0, 0, fastrand.Bytes(20), true)
+	program, programData := tb.Program()
+	binary.LittleEndian.PutUint64(programData[8:], math.MaxUint64-10)
+
+	// Execute it.
+	values := tb.Cost()
+	_, _, collateral, _ := values.Cost()
+	budget := values.Budget(true)
+	_, outputChan, err := mdm.ExecuteProgram(context.Background(), pt, program, budget, collateral, so, duration, uint64(len(programData)), bytes.NewReader(programData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var outputs []Output
+	for output := range outputChan {
+		outputs = append(outputs, output)
+	}
+	if len(outputs) != 1 || outputs[0].Error == nil || !strings.Contains(outputs[0].Error.Error(), "request is out of bounds") {
+		t.Fatal("expected execution to fail with out of bounds error", outputs)
+	}
+}
+
+// testInstructionUpdateSectorNoProof tests updating a random sector without
+// requesting a proof.
+func testInstructionUpdateSectorNoProof(t *testing.T, mdm *MDM, host *TestHost, numSectors uint64, pt *modules.RPCPriceTable, duration types.BlockHeight, so *TestStorageObligation) {
+	// Choose a random sector to update.
+	i := fastrand.Uint64n(numSectors)
+	data := fastrand.Bytes(int(crypto.SegmentSize))
+
+	ics := so.ContractSize()
+	oldRoots := append([]crypto.Hash{}, so.sectorRoots...)
+
+	// Use a builder to build the program.
+	tb := newTestProgramBuilder(pt, duration)
+	tb.AddUpdateSectorInstruction(i, 0, data, false)
+
+	// Execute it.
+	outputs, err := mdm.ExecuteProgramWithBuilder(tb, so, duration, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Compute the expected new root.
+	oldSector, err := host.ReadSector(oldRoots[i])
+	if err != nil {
+		t.Fatal(err)
+	}
+	newSector := append([]byte{}, oldSector...)
+	copy(newSector, data)
+	newRoots := append([]crypto.Hash{}, oldRoots...)
+	newRoots[i] = crypto.MerkleRoot(newSector)
+	nmr := cachedMerkleRoot(newRoots)
+
+	// Assert the output.
+	err = outputs[0].assert(ics, nmr, []crypto.Hash{}, []byte{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Make sure the sector actually got updated.
+	if so.sectorRoots[i] != newRoots[i] {
+		t.Fatal("sector not updated correctly")
+	}
+
+	// Store the updated sector on the host for the following subtests.
+	host.sectors[newRoots[i]] = newSector
+}
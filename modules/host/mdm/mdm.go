@@ -37,6 +37,7 @@ type Host interface {
 	ReadSector(sectorRoot crypto.Hash) ([]byte, error)
 	RegistryUpdate(rv modules.SignedRegistryValue, pubKey types.TurtleDexPublicKey, expiry types.BlockHeight) (modules.SignedRegistryValue, error)
 	RegistryGet(pubKey types.TurtleDexPublicKey, tweak crypto.Hash) (modules.SignedRegistryValue, bool)
+	RegistryGetByEID(eid modules.RegistryEntryID) (types.TurtleDexPublicKey, modules.SignedRegistryValue, bool)
 }
 
 // MDM (Merklized Data Machine) is a virtual machine that executes instructions
@@ -27,6 +27,7 @@ type (
 		blockHeight     types.BlockHeight
 		sectors         map[crypto.Hash][]byte
 		registry        map[crypto.Hash]modules.SignedRegistryValue
+		registryKeys    map[crypto.Hash]types.TurtleDexPublicKey
 		mu              sync.Mutex
 	}
 	// TestStorageObligation is a dummy storage obligation for testing which
@@ -49,6 +50,7 @@ func newCustomTestHost(generateSectors bool) *TestHost {
 	return &TestHost{
 		generateSectors: generateSectors,
 		registry:        make(map[crypto.Hash]modules.SignedRegistryValue),
+		registryKeys:    make(map[crypto.Hash]types.TurtleDexPublicKey),
 		sectors:         make(map[crypto.Hash][]byte),
 	}
 }
@@ -90,6 +92,17 @@ func (h *TestHost) RegistryGet(pubKey types.TurtleDexPublicKey, tweak crypto.Has
 	return v, true
 }
 
+// RegistryGetByEID retrieves a value from the registry by its entry id.
+func (h *TestHost) RegistryGetByEID(eid modules.RegistryEntryID) (types.TurtleDexPublicKey, modules.SignedRegistryValue, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	v, exists := h.registry[crypto.Hash(eid)]
+	if !exists {
+		return types.TurtleDexPublicKey{}, modules.SignedRegistryValue{}, false
+	}
+	return h.registryKeys[crypto.Hash(eid)], v, true
+}
+
 // RegistryUpdate updates a value in the registry.
 func (h *TestHost) RegistryUpdate(rv modules.SignedRegistryValue, pubKey types.TurtleDexPublicKey, expiry types.BlockHeight) (modules.SignedRegistryValue, error) {
 	h.mu.Lock()
@@ -105,6 +118,7 @@ func (h *TestHost) RegistryUpdate(rv modules.SignedRegistryValue, pubKey types.T
 	}
 
 	h.registry[key] = rv
+	h.registryKeys[key] = pubKey
 	return oldRV, nil
 }
 
@@ -233,6 +247,9 @@ func newTestPriceTable() *modules.RPCPriceTable {
 		WriteLengthCost:     types.NewCurrency64(1),
 		WriteStoreCost:      types.NewCurrency64(1),
 
+		UpdateSectorBaseCost:   types.NewCurrency64(1),
+		UpdateSectorLengthCost: types.NewCurrency64(1),
+
 		// Bandwidth costs
 		DownloadBandwidthCost: types.NewCurrency64(1),
 		UploadBandwidthCost:   types.NewCurrency64(1),
@@ -101,10 +101,14 @@ func decodeInstruction(p *program, i modules.Instruction) (instruction, error) {
 		return p.staticDecodeRevisionInstruction(i)
 	case modules.SpecifierSwapSector:
 		return p.staticDecodeSwapSectorInstruction(i)
+	case modules.SpecifierUpdateSector:
+		return p.staticDecodeUpdateSectorInstruction(i)
 	case modules.SpecifierUpdateRegistry:
 		return p.staticDecodeUpdateRegistryInstruction(i)
 	case modules.SpecifierReadRegistry:
 		return p.staticDecodeReadRegistryInstruction(i)
+	case modules.SpecifierReadRegistryEID:
+		return p.staticDecodeReadRegistryEIDInstruction(i)
 	default:
 		return nil, fmt.Errorf("unknown instruction specifier: %v", i.Specifier)
 	}
@@ -102,6 +102,53 @@ func (s *sectors) swapSectors(idx1, idx2 uint64) (crypto.Hash, error) {
 	return cachedMerkleRoot(s.merkleRoots), nil
 }
 
+// updateSector replaces the sector at idx with the updated sector data and
+// returns the new merkle root.
+func (s *sectors) updateSector(idx uint64, sectorData []byte) (crypto.Hash, error) {
+	if idx >= uint64(len(s.merkleRoots)) {
+		return crypto.Hash{}, fmt.Errorf("idx out-of-bounds: %v >= %v", idx, len(s.merkleRoots))
+	}
+	if uint64(len(sectorData)) != modules.SectorSize {
+		return crypto.Hash{}, fmt.Errorf("trying to update sector with data of length %v", len(sectorData))
+	}
+	oldRoot := s.merkleRoots[idx]
+	newRoot := crypto.MerkleRoot(sectorData)
+	if oldRoot == newRoot {
+		return cachedMerkleRoot(s.merkleRoots), nil
+	}
+
+	// Update the roots.
+	s.merkleRoots[idx] = newRoot
+
+	// Update the program cache for the old sector. Only the reference at idx
+	// is replaced. If the contract still references the old sector at
+	// another index, it must be neither removed from the cache nor marked as
+	// removed.
+	if !s.hasSector(oldRoot) {
+		_, gained := s.sectorsGained[oldRoot]
+		if gained {
+			// Remove the sector from the cache.
+			delete(s.sectorsGained, oldRoot)
+		} else {
+			// Mark the sector as removed in the cache.
+			s.sectorsRemoved[oldRoot] = struct{}{}
+		}
+	}
+
+	// Update the program cache for the new sector.
+	_, removed := s.sectorsRemoved[newRoot]
+	if removed {
+		// If the sector has been marked as removed, unmark it.
+		delete(s.sectorsRemoved, newRoot)
+	} else {
+		// Add the sector to the cache.
+		s.sectorsGained[newRoot] = sectorData
+	}
+
+	// Return the new merkle root of the contract.
+	return cachedMerkleRoot(s.merkleRoots), nil
+}
+
 // translateOffset translates an offset within a filecontract into a relative
 // offset within a sector and the sector's index within the contract.
 func (s *sectors) translateOffset(offset uint64) (uint64, uint64, error) {
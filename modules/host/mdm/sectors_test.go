@@ -244,6 +244,63 @@ func TestDropSectors(t *testing.T) {
 	}
 }
 
+// TestUpdateSector tests updating sectors in the cache.
+func TestUpdateSector(t *testing.T) {
+	// Initialize the sectors. The first sector is referenced twice.
+	sectorRoots := randomSectorRoots(initialContractSectors)
+	sectorRoots[1] = sectorRoots[0]
+	s := newSectors(append([]crypto.Hash{}, sectorRoots...))
+
+	// Update the first sector. The old sector is still referenced by the
+	// second sector and shouldn't be marked as removed.
+	sectorData := randomSectorData()
+	newRoot := crypto.MerkleRoot(sectorData)
+	root, err := s.updateSector(0, sectorData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sectorRoots[0] = newRoot
+	if root != cachedMerkleRoot(sectorRoots) {
+		t.Fatal("unexpected merkle root")
+	}
+	if _, removed := s.sectorsRemoved[sectorRoots[1]]; removed {
+		t.Fatal("sector which is still referenced was marked as removed")
+	}
+	if !bytes.Equal(s.sectorsGained[newRoot], sectorData) {
+		t.Fatal("new sector wasn't added to the cache")
+	}
+
+	// Update the second sector. Now the old sector is no longer referenced.
+	oldRoot := sectorRoots[1]
+	sectorData2 := randomSectorData()
+	_, err = s.updateSector(1, sectorData2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, removed := s.sectorsRemoved[oldRoot]; !removed {
+		t.Fatal("sector which is no longer referenced wasn't marked as removed")
+	}
+
+	// Update the first sector again. The sector gained by the first update
+	// should be removed from the cache.
+	_, err = s.updateSector(0, randomSectorData())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, gained := s.sectorsGained[newRoot]; gained {
+		t.Fatal("replaced sector wasn't removed from the cache")
+	}
+	if _, removed := s.sectorsRemoved[newRoot]; removed {
+		t.Fatal("sector gained by the program shouldn't be marked as removed")
+	}
+
+	// Updating an out-of-bounds index should fail.
+	_, err = s.updateSector(initialContractSectors, randomSectorData())
+	if err == nil {
+		t.Fatal("expected error when updating an out-of-bounds sector")
+	}
+}
+
 // TestHasSector tests checking if a sector exists in the cache or host.
 func TestHasSector(t *testing.T) {
 	// Initialize the sectors.
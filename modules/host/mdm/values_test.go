@@ -138,6 +138,19 @@ func (v *TestValues) AddSwapSectorInstruction() {
 	v.addInstruction(collateral, cost, types.ZeroCurrency, types.ZeroCurrency, memory, time, newData, readonly, batch)
 }
 
+// AddUpdateSectorInstruction adds an UpdateSector instruction to the builder,
+// keeping track of running values.
+func (v *TestValues) AddUpdateSectorInstruction(data []byte) {
+	collateral := modules.MDMUpdateSectorCollateral()
+	cost := modules.MDMUpdateSectorCost(v.staticPT, uint64(len(data)))
+	memory := modules.MDMUpdateSectorMemory()
+	time := uint64(modules.MDMTimeUpdateSector)
+	newData := 8 + 8 + 8 + len(data)
+	readonly := false
+	batch := false
+	v.addInstruction(collateral, cost, types.ZeroCurrency, types.ZeroCurrency, memory, time, newData, readonly, batch)
+}
+
 // AddUpdateRegistryInstruction adds a revision instruction to the builder, keeping
 // track of running values.
 func (v *TestValues) AddUpdateRegistryInstruction(spk types.TurtleDexPublicKey, rv modules.SignedRegistryValue) {
@@ -168,6 +181,23 @@ func (v *TestValues) AddReadRegistryInstruction(spk types.TurtleDexPublicKey, re
 	v.addInstruction(collateral, cost, refund, successRefund, memory, time, newData, readonly, batch)
 }
 
+// AddReadRegistryEIDInstruction adds a ReadRegistryEID instruction to the
+// builder, keeping track of running values.
+func (v *TestValues) AddReadRegistryEIDInstruction(refunded bool) {
+	memory := modules.MDMReadRegistryMemory()
+	collateral := modules.MDMReadRegistryCollateral()
+	cost, refund := modules.MDMReadRegistryCost(v.staticPT)
+	time := uint64(modules.MDMTimeReadRegistryEID)
+	newData := crypto.HashSize
+	readonly := true
+	batch := true
+	var successRefund types.Currency
+	if refunded {
+		successRefund = refund
+	}
+	v.addInstruction(collateral, cost, refund, successRefund, memory, time, newData, readonly, batch)
+}
+
 // Cost returns the current cost of the program which would result . If
 // 'finalized' is 'true', the memory cost of finalizing the program is included.
 func (v TestValues) Cost() (cost, failureRefund, collateral, instructionRefund types.Currency) {
@@ -148,6 +148,20 @@ func (r *Registry) Get(pubKey types.TurtleDexPublicKey, tweak crypto.Hash) (modu
 	return modules.NewSignedRegistryValue(v.tweak, v.data, v.revision, v.signature), true
 }
 
+// GetByEntryID fetches the entry associated with a registry entry id. Apart
+// from the value it also returns the public key of the entry.
+func (r *Registry) GetByEntryID(eid modules.RegistryEntryID) (types.TurtleDexPublicKey, modules.SignedRegistryValue, bool) {
+	r.mu.Lock()
+	v, ok := r.entries[crypto.Hash(eid)]
+	r.mu.Unlock()
+	if !ok {
+		return types.TurtleDexPublicKey{}, modules.SignedRegistryValue{}, false
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.key, modules.NewSignedRegistryValue(v.tweak, v.data, v.revision, v.signature), true
+}
+
 // Len returns the length of the registry.
 func (r *Registry) Len() uint64 {
 	r.mu.Lock()
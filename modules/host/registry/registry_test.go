@@ -407,6 +407,57 @@ func TestUpdate(t *testing.T) {
 	}
 }
 
+// TestGetByEntryID tests fetching entries from the registry by their entry id.
+func TestGetByEntryID(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := testDir(t.Name())
+
+	// Create a new registry.
+	registryPath := filepath.Join(dir, "registry")
+	r, err := New(registryPath, testingDefaultMaxEntries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(c io.Closer) {
+		if err := c.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}(r)
+
+	// Register a value.
+	rv, v, _ := randomValue(0)
+	_, err = r.Update(rv, v.key, v.expiry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Fetch it by its entry id.
+	spk, rv2, exists := r.GetByEntryID(modules.DeriveRegistryEntryID(v.key, v.tweak))
+	if !exists {
+		t.Fatal("entry doesn't exist")
+	}
+	if !spk.Equals(v.key) {
+		t.Fatal("wrong key returned")
+	}
+	if !reflect.DeepEqual(rv, rv2) {
+		t.Log(rv)
+		t.Log(rv2)
+		t.Fatal("wrong value returned")
+	}
+
+	// Fetch a random entry id.
+	var eid modules.RegistryEntryID
+	fastrand.Read(eid[:])
+	_, _, exists = r.GetByEntryID(eid)
+	if exists {
+		t.Fatal("entry shouldn't exist")
+	}
+}
+
 // TestRegistryLimit checks if the bitfield of the limit enforces its
 // preallocated size.
 func TestRegistryLimit(t *testing.T) {
@@ -0,0 +1,119 @@
+package modules
+
+import (
+	"time"
+
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+type (
+	// HostHistory contains the time series the hostdb keeps about a host.
+	// Unlike the scan history of a HostDBEntry, the time series are not
+	// compacted but only pruned once they exceed the retention period of the
+	// hostdb.
+	HostHistory struct {
+		Scans  []HostHistoryScan   `json:"scans"`
+		Prices []HostHistoryPrices `json:"prices"`
+		Jobs   []HostHistoryJobs   `json:"jobs"`
+	}
+
+	// HostHistoryScan is the outcome of a single scan of a host.
+	HostHistoryScan struct {
+		Timestamp time.Time     `json:"timestamp"`
+		Success   bool          `json:"success"`
+		Latency   time.Duration `json:"latency"`
+		Error     string        `json:"error,omitempty"`
+
+		// Score is the score of the host after the scan.
+		Score types.Currency `json:"score"`
+	}
+
+	// HostHistoryPrices are the prices of a host's settings and price table.
+	// A new entry is only added when the prices change.
+	HostHistoryPrices struct {
+		Timestamp time.Time `json:"timestamp"`
+
+		// Prices of the host's external settings.
+		BaseRPCPrice           types.Currency `json:"baserpcprice"`
+		Collateral             types.Currency `json:"collateral"`
+		ContractPrice          types.Currency `json:"contractprice"`
+		DownloadBandwidthPrice types.Currency `json:"downloadbandwidthprice"`
+		SectorAccessPrice      types.Currency `json:"sectoraccessprice"`
+		StoragePrice           types.Currency `json:"storageprice"`
+		UploadBandwidthPrice   types.Currency `json:"uploadbandwidthprice"`
+
+		// Costs of the host's price table.
+		DownloadBandwidthCost types.Currency `json:"downloadbandwidthcost"`
+		HasSectorBaseCost     types.Currency `json:"hassectorbasecost"`
+		InitBaseCost          types.Currency `json:"initbasecost"`
+		MemoryTimeCost        types.Currency `json:"memorytimecost"`
+		ReadBaseCost          types.Currency `json:"readbasecost"`
+		ReadLengthCost        types.Currency `json:"readlengthcost"`
+		UpdatePriceTableCost  types.Currency `json:"updatepricetablecost"`
+		UploadBandwidthCost   types.Currency `json:"uploadbandwidthcost"`
+		WriteBaseCost         types.Currency `json:"writebasecost"`
+		WriteLengthCost       types.Currency `json:"writelengthcost"`
+		WriteStoreCost        types.Currency `json:"writestorecost"`
+	}
+
+	// HostHistoryJobs counts the jobs the renter's worker for a host completed
+	// within a period starting at Timestamp.
+	HostHistoryJobs struct {
+		Timestamp time.Time `json:"timestamp"`
+		Successes uint64    `json:"successes"`
+		Failures  uint64    `json:"failures"`
+	}
+)
+
+// NewHostHistoryPrices creates a HostHistoryPrices from a host's settings and
+// price table. The price table is optional.
+func NewHostHistoryPrices(timestamp time.Time, settings HostExternalSettings, pt *RPCPriceTable) HostHistoryPrices {
+	prices := HostHistoryPrices{
+		Timestamp: timestamp,
+
+		BaseRPCPrice:           settings.BaseRPCPrice,
+		Collateral:             settings.Collateral,
+		ContractPrice:          settings.ContractPrice,
+		DownloadBandwidthPrice: settings.DownloadBandwidthPrice,
+		SectorAccessPrice:      settings.SectorAccessPrice,
+		StoragePrice:           settings.StoragePrice,
+		UploadBandwidthPrice:   settings.UploadBandwidthPrice,
+	}
+	if pt != nil {
+		prices.DownloadBandwidthCost = pt.DownloadBandwidthCost
+		prices.HasSectorBaseCost = pt.HasSectorBaseCost
+		prices.InitBaseCost = pt.InitBaseCost
+		prices.MemoryTimeCost = pt.MemoryTimeCost
+		prices.ReadBaseCost = pt.ReadBaseCost
+		prices.ReadLengthCost = pt.ReadLengthCost
+		prices.UpdatePriceTableCost = pt.UpdatePriceTableCost
+		prices.UploadBandwidthCost = pt.UploadBandwidthCost
+		prices.WriteBaseCost = pt.WriteBaseCost
+		prices.WriteLengthCost = pt.WriteLengthCost
+		prices.WriteStoreCost = pt.WriteStoreCost
+	}
+	return prices
+}
+
+// EqualPrices returns whether two entries have the same prices, ignoring their
+// timestamps.
+func (p HostHistoryPrices) EqualPrices(other HostHistoryPrices) bool {
+	return p.BaseRPCPrice.Equals(other.BaseRPCPrice) &&
+		p.Collateral.Equals(other.Collateral) &&
+		p.ContractPrice.Equals(other.ContractPrice) &&
+		p.DownloadBandwidthPrice.Equals(other.DownloadBandwidthPrice) &&
+		p.SectorAccessPrice.Equals(other.SectorAccessPrice) &&
+		p.StoragePrice.Equals(other.StoragePrice) &&
+		p.UploadBandwidthPrice.Equals(other.UploadBandwidthPrice) &&
+		p.DownloadBandwidthCost.Equals(other.DownloadBandwidthCost) &&
+		p.HasSectorBaseCost.Equals(other.HasSectorBaseCost) &&
+		p.InitBaseCost.Equals(other.InitBaseCost) &&
+		p.MemoryTimeCost.Equals(other.MemoryTimeCost) &&
+		p.ReadBaseCost.Equals(other.ReadBaseCost) &&
+		p.ReadLengthCost.Equals(other.ReadLengthCost) &&
+		p.UpdatePriceTableCost.Equals(other.UpdatePriceTableCost) &&
+		p.UploadBandwidthCost.Equals(other.UploadBandwidthCost) &&
+		p.WriteBaseCost.Equals(other.WriteBaseCost) &&
+		p.WriteLengthCost.Equals(other.WriteLengthCost) &&
+		p.WriteStoreCost.Equals(other.WriteStoreCost)
+}
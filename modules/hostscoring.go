@@ -0,0 +1,162 @@
+package modules
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/build"
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+const (
+	// HostScoreAge is the adjustment which penalizes hosts that were only
+	// recently announced.
+	HostScoreAge HostScoreAdjustmentType = "age"
+
+	// HostScoreCollateral is the adjustment which rewards hosts for providing
+	// collateral.
+	HostScoreCollateral HostScoreAdjustmentType = "collateral"
+
+	// HostScoreInteractions is the adjustment which penalizes hosts for
+	// failed interactions and audits.
+	HostScoreInteractions HostScoreAdjustmentType = "interactions"
+
+	// HostScorePrice is the adjustment which penalizes hosts for high prices.
+	HostScorePrice HostScoreAdjustmentType = "price"
+
+	// HostScoreStorageRemaining is the adjustment which penalizes hosts that
+	// don't have enough storage remaining.
+	HostScoreStorageRemaining HostScoreAdjustmentType = "storageremaining"
+
+	// HostScoreUptime is the adjustment which penalizes hosts for downtime.
+	HostScoreUptime HostScoreAdjustmentType = "uptime"
+
+	// HostScoreVersion is the adjustment which penalizes hosts running
+	// outdated versions.
+	HostScoreVersion HostScoreAdjustmentType = "version"
+)
+
+var (
+	// HostScoreAdjustmentTypes are the adjustments of the host score which can
+	// be weighted by a HostScoringPolicy.
+	HostScoreAdjustmentTypes = []HostScoreAdjustmentType{
+		HostScoreAge,
+		HostScoreCollateral,
+		HostScoreInteractions,
+		HostScorePrice,
+		HostScoreStorageRemaining,
+		HostScoreUptime,
+		HostScoreVersion,
+	}
+)
+
+type (
+	// HostScoreAdjustmentType is the type of an adjustment of the host score.
+	HostScoreAdjustmentType string
+
+	// HostScoringPolicy tunes how the hostdb scores hosts.
+	//
+	// Every adjustment of the host score is a value between 0 and 1 which is
+	// raised to the power of its weight before it is multiplied into the
+	// score. Adjustments without a weight use the default weight of 1, a
+	// weight of 0 disables the adjustment and weights greater than 1 increase
+	// its importance.
+	//
+	// The limits are hard requirements. Hosts which don't meet them receive
+	// the lowest possible score. A limit of 0 disables it. The prices and the
+	// collateral are in hastings per byte (per block for storage and
+	// collateral), matching the host's settings.
+	HostScoringPolicy struct {
+		Weights map[HostScoreAdjustmentType]float64 `json:"weights"`
+
+		MinAge              types.BlockHeight `json:"minage"`
+		MinCollateral       types.Currency    `json:"mincollateral"`
+		MinRemainingStorage uint64            `json:"minremainingstorage"`
+		MinUptime           float64           `json:"minuptime"`
+		MinVersion          string            `json:"minversion"`
+
+		MaxContractPrice          types.Currency `json:"maxcontractprice"`
+		MaxDownloadBandwidthPrice types.Currency `json:"maxdownloadbandwidthprice"`
+		MaxStoragePrice           types.Currency `json:"maxstorageprice"`
+		MaxUploadBandwidthPrice   types.Currency `json:"maxuploadbandwidthprice"`
+	}
+)
+
+// Copy returns a deep copy of the policy.
+func (hsp HostScoringPolicy) Copy() HostScoringPolicy {
+	cpy := hsp
+	if hsp.Weights != nil {
+		cpy.Weights = make(map[HostScoreAdjustmentType]float64, len(hsp.Weights))
+		for t, weight := range hsp.Weights {
+			cpy.Weights[t] = weight
+		}
+	}
+	return cpy
+}
+
+// Equals returns whether two policies are the same. Weights of 1 are equal to
+// weights which aren't set.
+func (hsp HostScoringPolicy) Equals(other HostScoringPolicy) bool {
+	for _, t := range HostScoreAdjustmentTypes {
+		if hsp.Weight(t) != other.Weight(t) {
+			return false
+		}
+	}
+	return hsp.MinAge == other.MinAge &&
+		hsp.MinCollateral.Equals(other.MinCollateral) &&
+		hsp.MinRemainingStorage == other.MinRemainingStorage &&
+		hsp.MinUptime == other.MinUptime &&
+		hsp.MinVersion == other.MinVersion &&
+		hsp.MaxContractPrice.Equals(other.MaxContractPrice) &&
+		hsp.MaxDownloadBandwidthPrice.Equals(other.MaxDownloadBandwidthPrice) &&
+		hsp.MaxStoragePrice.Equals(other.MaxStoragePrice) &&
+		hsp.MaxUploadBandwidthPrice.Equals(other.MaxUploadBandwidthPrice)
+}
+
+// Validate checks that the weights and limits of the policy are valid.
+func (hsp HostScoringPolicy) Validate() error {
+	// Check the weights in a deterministic order.
+	names := make([]string, 0, len(hsp.Weights))
+	for t := range hsp.Weights {
+		names = append(names, string(t))
+	}
+	sort.Strings(names)
+	for _, t := range names {
+		weight := hsp.Weights[HostScoreAdjustmentType(t)]
+		if !isHostScoreAdjustmentType(HostScoreAdjustmentType(t)) {
+			return fmt.Errorf("unknown host score adjustment '%v'", t)
+		}
+		if weight < 0 {
+			return fmt.Errorf("weight of %v can't be negative", t)
+		}
+	}
+	if hsp.MinUptime < 0 || hsp.MinUptime > 1 {
+		return errors.New("minimum uptime has to be between 0 and 1")
+	}
+	if hsp.MinVersion != "" && !build.IsVersion(hsp.MinVersion) {
+		return fmt.Errorf("invalid minimum version '%v'", hsp.MinVersion)
+	}
+	return nil
+}
+
+// Weight returns the weight of the adjustment of the provided type.
+func (hsp HostScoringPolicy) Weight(t HostScoreAdjustmentType) float64 {
+	weight, exists := hsp.Weights[t]
+	if !exists {
+		return 1
+	}
+	return weight
+}
+
+// isHostScoreAdjustmentType returns whether t is one of the
+// HostScoreAdjustmentTypes.
+func isHostScoreAdjustmentType(t HostScoreAdjustmentType) bool {
+	for _, hst := range HostScoreAdjustmentTypes {
+		if t == hst {
+			return true
+		}
+	}
+	return false
+}
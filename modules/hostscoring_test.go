@@ -0,0 +1,59 @@
+package modules
+
+import (
+	"testing"
+
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+// TestHostScoringPolicyValidate is a unit test for HostScoringPolicy.Validate.
+func TestHostScoringPolicyValidate(t *testing.T) {
+	tests := []struct {
+		policy HostScoringPolicy
+		valid  bool
+	}{
+		{HostScoringPolicy{}, true},
+		{HostScoringPolicy{Weights: map[HostScoreAdjustmentType]float64{HostScorePrice: 0, HostScoreUptime: 2.5}}, true},
+		{HostScoringPolicy{Weights: map[HostScoreAdjustmentType]float64{HostScorePrice: -1}}, false},
+		{HostScoringPolicy{Weights: map[HostScoreAdjustmentType]float64{"burn": 1}}, false},
+		{HostScoringPolicy{MinUptime: 0.95}, true},
+		{HostScoringPolicy{MinUptime: 1.5}, false},
+		{HostScoringPolicy{MinUptime: -0.5}, false},
+		{HostScoringPolicy{MinVersion: "1.5.0"}, true},
+		{HostScoringPolicy{MinVersion: "v1.5"}, false},
+	}
+	for i, test := range tests {
+		err := test.policy.Validate()
+		if (err == nil) != test.valid {
+			t.Errorf("%v: expected valid %v but got %v", i, test.valid, err)
+		}
+	}
+}
+
+// TestHostScoringPolicyEquals is a unit test for HostScoringPolicy.Equals and
+// HostScoringPolicy.Copy.
+func TestHostScoringPolicyEquals(t *testing.T) {
+	policy := HostScoringPolicy{
+		Weights:         map[HostScoreAdjustmentType]float64{HostScorePrice: 1},
+		MaxStoragePrice: types.NewCurrency64(100),
+	}
+	// A weight of 1 equals an unset weight.
+	if !policy.Equals(HostScoringPolicy{MaxStoragePrice: types.NewCurrency64(100)}) {
+		t.Fatal("policies should be equal")
+	}
+	// Changing the weights of a copy doesn't change the original.
+	cpy := policy.Copy()
+	if !cpy.Equals(policy) {
+		t.Fatal("copy should equal the original")
+	}
+	cpy.Weights[HostScorePrice] = 0
+	if policy.Weight(HostScorePrice) != 1 {
+		t.Fatal("changing the copy changed the original")
+	}
+	if cpy.Equals(policy) {
+		t.Fatal("policies with different weights shouldn't be equal")
+	}
+	if policy.Equals(HostScoringPolicy{}) {
+		t.Fatal("policies with different limits shouldn't be equal")
+	}
+}
@@ -2,6 +2,7 @@ package modules
 
 import (
 	"encoding/binary"
+	"fmt"
 	"sync"
 	"time"
 
@@ -78,6 +79,10 @@ const (
 	// MDMTimeSwapSector is the time for executing an 'SwapSector' instruction.
 	MDMTimeSwapSector = 1
 
+	// MDMTimeUpdateSector is the time for executing an 'UpdateSector'
+	// instruction.
+	MDMTimeUpdateSector = 10000
+
 	// MDMTimeWriteSector is the time for executing a 'WriteSector' instruction.
 	MDMTimeWriteSector = 10000
 
@@ -89,6 +94,10 @@ const (
 	// instruction.
 	MDMTimeReadRegistry = 1000
 
+	// MDMTimeReadRegistryEID is the time for executing an 'ReadRegistryEID'
+	// instruction.
+	MDMTimeReadRegistryEID = 1000
+
 	// RPCIAppendLen is the expected length of the 'Args' of an Append
 	// instructon.
 	RPCIAppendLen = 9
@@ -117,6 +126,12 @@ const (
 	// instructon.
 	RPCISwapSectorLen = 17 // 2 uint64 offsets + merkle proof flag
 
+	// RPCIUpdateSectorLen is the expected length of the 'Args' of an
+	// UpdateSector instruction.
+	// sectorIndexOffset + offsetOffset + lengthOffset + dataOffset + merkle
+	// proof flag = 4 * 8 + 1 bytes = 33 byte
+	RPCIUpdateSectorLen = 33
+
 	// RPCIUpdateRegistryLen is the expected length of the 'Args' of an
 	// UpdateRegistry instruction.
 	// tweakOffset + revisionOffset + signatureOffset + pubKeyOffset +
@@ -127,6 +142,11 @@ const (
 	// ReadRegistry instruction.
 	// tweakOffset + pubKeyOffset + pubKeyLength = 3 * 8 bytes = 24 byte
 	RPCIReadRegistryLen = 24
+
+	// RPCIReadRegistryEIDLen is the expected length of the 'Args' of an
+	// ReadRegistryEID instruction.
+	// entryIDOffset = 1 * 8 bytes = 8 byte
+	RPCIReadRegistryEIDLen = 8
 )
 
 var (
@@ -161,6 +181,9 @@ var (
 	// SpecifierSwapSector is the specifier for the SwapSector instruction.
 	SpecifierSwapSector = InstructionSpecifier{'S', 'w', 'a', 'p', 'S', 'e', 'c', 't', 'o', 'r'}
 
+	// SpecifierUpdateSector is the specifier for the UpdateSector instruction.
+	SpecifierUpdateSector = InstructionSpecifier{'U', 'p', 'd', 'a', 't', 'e', 'S', 'e', 'c', 't', 'o', 'r'}
+
 	// SpecifierUpdateRegistry is the specifier for the UpdateRegistry
 	// instruction.
 	SpecifierUpdateRegistry = InstructionSpecifier{'U', 'p', 'd', 'a', 't', 'e', 'R', 'e', 'g', 'i', 's', 't', 'r', 'y'}
@@ -169,6 +192,10 @@ var (
 	// instruction.
 	SpecifierReadRegistry = InstructionSpecifier{'R', 'e', 'a', 'd', 'R', 'e', 'g', 'i', 's', 't', 'r', 'y'}
 
+	// SpecifierReadRegistryEID is the specifier for the ReadRegistryEID
+	// instruction.
+	SpecifierReadRegistryEID = InstructionSpecifier{'R', 'e', 'a', 'd', 'R', 'e', 'g', 'i', 's', 't', 'r', 'y', 'E', 'I', 'D'}
+
 	// ErrInsufficientBandwidthBudget is returned when bandwidth can no longer
 	// be paid for with the provided budget.
 	ErrInsufficientBandwidthBudget = errors.New("insufficient budget for bandwidth")
@@ -190,6 +217,19 @@ type (
 		RevisionTxn types.Transaction
 	}
 
+	// MDMInstructionUpdateSectorResponse is the format of the MDM's
+	// UpdateSector instruction's output if a proof was requested. It contains
+	// the roots of the sector before and after the update as well as the
+	// original segments covering the updated range and a range proof for them.
+	// Since the segments outside of the updated range don't change, the same
+	// range proof proves both the old and the new sector root.
+	MDMInstructionUpdateSectorResponse struct {
+		OldSectorRoot crypto.Hash
+		NewSectorRoot crypto.Hash
+		OldSegments   []byte
+		SectorProof   []crypto.Hash
+	}
+
 	// SubscriptionID is a hash derived from the public key and tweak that a
 	// renter would like to subscribe to.
 	SubscriptionID crypto.Hash
@@ -226,6 +266,50 @@ func RPCIReadSector(rootOff, offsetOff, lengthOff uint64, merkleProof bool) Inst
 	}
 }
 
+// MDMUpdateSectorSegmentRange returns the range of segments [start,end) that
+// are affected by updating length bytes at offset within a sector.
+func MDMUpdateSectorSegmentRange(offset, length uint64) (start, end uint64) {
+	start = offset / crypto.SegmentSize
+	end = (offset + length + crypto.SegmentSize - 1) / crypto.SegmentSize
+	return
+}
+
+// VerifyUpdateSectorProof verifies the output and proof of an 'UpdateSector'
+// instruction which replaced the bytes at offset within the sector at
+// sectorIndex with data. The contract is expected to contain numSectors
+// sectors and have the merkle root oldRoot before and newRoot after the
+// update.
+func VerifyUpdateSectorProof(resp MDMInstructionUpdateSectorResponse, proof []crypto.Hash, numSectors, sectorIndex, offset uint64, data []byte, oldRoot, newRoot crypto.Hash) error {
+	// Verify the sector roots using the segment range proof.
+	start, end := MDMUpdateSectorSegmentRange(offset, uint64(len(data)))
+	if uint64(len(resp.OldSegments)) != (end-start)*crypto.SegmentSize {
+		return fmt.Errorf("expected %v bytes of segment data but got %v", (end-start)*crypto.SegmentSize, len(resp.OldSegments))
+	}
+	if !crypto.VerifyRangeProof(resp.OldSegments, resp.SectorProof, int(start), int(end), resp.OldSectorRoot) {
+		return errors.New("invalid range proof for old sector root")
+	}
+	newSegments := append([]byte{}, resp.OldSegments...)
+	copy(newSegments[offset-start*crypto.SegmentSize:], data)
+	if !crypto.VerifyRangeProof(newSegments, resp.SectorProof, int(start), int(end), resp.NewSectorRoot) {
+		return errors.New("invalid range proof for new sector root")
+	}
+
+	// Verify the contract roots using the diff proof.
+	ranges := []crypto.ProofRange{
+		{
+			Start: sectorIndex,
+			End:   sectorIndex + 1,
+		},
+	}
+	if !crypto.VerifyDiffProof(ranges, numSectors, proof, []crypto.Hash{resp.OldSectorRoot}, oldRoot) {
+		return errors.New("invalid diff proof for old contract root")
+	}
+	if !crypto.VerifyDiffProof(ranges, numSectors, proof, []crypto.Hash{resp.NewSectorRoot}, newRoot) {
+		return errors.New("invalid diff proof for new contract root")
+	}
+	return nil
+}
+
 // MDMAppendCost is the cost of executing an 'Append' instruction.
 func MDMAppendCost(pt *RPCPriceTable, duration types.BlockHeight) (types.Currency, types.Currency) {
 	// Cost for writing the Data.
@@ -277,6 +361,13 @@ func MDMSwapSectorCost(pt *RPCPriceTable) types.Currency {
 	return pt.SwapSectorCost
 }
 
+// MDMUpdateSectorCost is the cost of executing an 'UpdateSector' instruction
+// which updates length bytes of a sector. It is defined as:
+// 'updateSectorBaseCost' + 'updateSectorLengthCost' * 'length'
+func MDMUpdateSectorCost(pt *RPCPriceTable, length uint64) types.Currency {
+	return pt.UpdateSectorLengthCost.Mul64(length).Add(pt.UpdateSectorBaseCost)
+}
+
 // V154MDMUpdateRegistryCost is the cost of executing a 'UpdateRegistry'
 // instruction in host versions 1.5.4 and below.
 func V154MDMUpdateRegistryCost(pt *RPCPriceTable) (_, _ types.Currency) {
@@ -397,6 +488,12 @@ func MDMSwapSectorMemory() uint64 {
 	return 0 // 'SwapSector' doesn't hold on to any memory beyond the lifetime of the instruction.
 }
 
+// MDMUpdateSectorMemory returns the additional memory consumption of an
+// 'UpdateSector' instruction.
+func MDMUpdateSectorMemory() uint64 {
+	return SectorSize // The updated sector is added to the program's memory until the program is finalized.
+}
+
 // MDMUpdateRegistryMemory returns the additional memory consumption of a
 // 'UpdateRegistry' instruction.
 func MDMUpdateRegistryMemory() uint64 {
@@ -464,6 +561,12 @@ func MDMSwapSectorCollateral() types.Currency {
 	return types.ZeroCurrency
 }
 
+// MDMUpdateSectorCollateral returns the additional collateral an
+// 'UpdateSector' instruction requires the host to put up.
+func MDMUpdateSectorCollateral() types.Currency {
+	return types.ZeroCurrency // The size of the contract doesn't change.
+}
+
 // MDMUpdateRegistryCollateral returns the additional collateral a
 // 'UpdateRegistry' instruction requires the host to put up.
 func MDMUpdateRegistryCollateral() types.Currency {
@@ -490,9 +593,12 @@ func (p Program) ReadOnly() bool {
 		case SpecifierRevision:
 		case SpecifierSwapSector:
 			return false
+		case SpecifierUpdateSector:
+			return false
 		case SpecifierUpdateRegistry:
 			// considered read-only cause it doesn't update a contract
 		case SpecifierReadRegistry:
+		case SpecifierReadRegistryEID:
 		default:
 			build.Critical("ReadOnly: unknown instruction")
 		}
@@ -519,8 +625,11 @@ func (p Program) RequiresSnapshot() bool {
 			return true
 		case SpecifierSwapSector:
 			return true
+		case SpecifierUpdateSector:
+			return true
 		case SpecifierUpdateRegistry:
 		case SpecifierReadRegistry:
+		case SpecifierReadRegistryEID:
 		default:
 			build.Critical("RequiresSnapshot: unknown instruction")
 		}
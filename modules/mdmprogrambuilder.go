@@ -181,6 +181,41 @@ func (pb *ProgramBuilder) AddSwapSectorInstruction(sector1Idx, sector2Idx uint64
 	pb.readonly = false
 }
 
+// AddUpdateSectorInstruction adds an UpdateSector instruction to the program.
+// The instruction overwrites the bytes of the sector at sectorIdx starting at
+// offset with data.
+func (pb *ProgramBuilder) AddUpdateSectorInstruction(sectorIdx, offset uint64, data []byte, merkleProof bool) error {
+	length := uint64(len(data))
+	if length == 0 {
+		return errors.New("updated data can't be empty")
+	}
+	if offset > SectorSize || length > SectorSize-offset {
+		return fmt.Errorf("update is out of bounds %v + %v > %v", offset, length, SectorSize)
+	}
+	// Compute the argument offsets.
+	sectorIdxOffset := uint64(pb.programData.Len())
+	offsetOffset := sectorIdxOffset + 8
+	lengthOffset := offsetOffset + 8
+	dataOffset := lengthOffset + 8
+	// Extend the programData.
+	binary.Write(pb.programData, binary.LittleEndian, sectorIdx)
+	binary.Write(pb.programData, binary.LittleEndian, offset)
+	binary.Write(pb.programData, binary.LittleEndian, length)
+	binary.Write(pb.programData, binary.LittleEndian, data)
+	// Create the instruction.
+	i := NewUpdateSectorInstruction(sectorIdxOffset, offsetOffset, lengthOffset, dataOffset, merkleProof)
+	// Append instruction
+	pb.program = append(pb.program, i)
+	// Update cost, collateral and memory usage.
+	collateral := MDMUpdateSectorCollateral()
+	cost := MDMUpdateSectorCost(pb.staticPT, length)
+	memory := MDMUpdateSectorMemory()
+	time := uint64(MDMTimeUpdateSector)
+	pb.addInstruction(collateral, cost, types.ZeroCurrency, memory, time)
+	pb.readonly = false
+	return nil
+}
+
 // AddUpdateRegistryInstruction adds an UpdateRegistry instruction to the program.
 func (pb *ProgramBuilder) AddUpdateRegistryInstruction(spk types.TurtleDexPublicKey, rv SignedRegistryValue) error {
 	// Marshal pubKey.
@@ -306,6 +341,30 @@ func (pb *ProgramBuilder) AddReadRegistryInstruction(spk types.TurtleDexPublicKe
 	return refund, nil
 }
 
+// AddReadRegistryEIDInstruction adds an ReadRegistryEID instruction to the
+// program. The instruction looks up a registry entry by its RegistryEntryID.
+// Costs are the same as for a regular ReadRegistry instruction.
+func (pb *ProgramBuilder) AddReadRegistryEIDInstruction(eid RegistryEntryID) (types.Currency, error) {
+	// Compute the argument offsets.
+	eidOff := uint64(pb.programData.Len())
+	// Extend the programData.
+	_, err := pb.programData.Write(eid[:])
+	if err != nil {
+		return types.ZeroCurrency, errors.AddContext(err, "AddReadRegistryEIDInstruction: failed to extend programData")
+	}
+	// Create the instruction.
+	i := NewReadRegistryEIDInstruction(eidOff)
+	// Append instruction
+	pb.program = append(pb.program, i)
+	// Read cost, collateral and memory usage.
+	collateral := MDMReadRegistryCollateral()
+	cost, refund := MDMReadRegistryCost(pb.staticPT)
+	memory := MDMReadRegistryMemory()
+	time := uint64(MDMTimeReadRegistryEID)
+	pb.addInstruction(collateral, cost, refund, memory, time)
+	return refund, nil
+}
+
 // Cost returns the current cost of the program being built by the builder. If
 // 'finalized' is 'true', the memory cost of finalizing the program is included.
 func (pb *ProgramBuilder) Cost(finalized bool) (cost, storage, collateral types.Currency) {
@@ -382,6 +441,16 @@ func NewReadRegistryInstruction(pubKeyOff, pubKeyLen, tweakOff uint64) Instructi
 	return i
 }
 
+// NewReadRegistryEIDInstruction creates an Instruction from arguments.
+func NewReadRegistryEIDInstruction(eidOff uint64) Instruction {
+	i := Instruction{
+		Specifier: SpecifierReadRegistryEID,
+		Args:      make([]byte, RPCIReadRegistryEIDLen),
+	}
+	binary.LittleEndian.PutUint64(i.Args[:8], eidOff)
+	return i
+}
+
 // NewDropSectorsInstruction creates an Instruction from arguments.
 func NewDropSectorsInstruction(numSectorsOffset uint64, merkleProof bool) Instruction {
 	i := Instruction{
@@ -448,6 +517,22 @@ func NewSwapSectorInstruction(sector1Offset, sector2Offset uint64, merkleProof b
 	return i
 }
 
+// NewUpdateSectorInstruction creates a modules.Instruction from arguments.
+func NewUpdateSectorInstruction(sectorIdxOffset, offsetOffset, lengthOffset, dataOffset uint64, merkleProof bool) Instruction {
+	i := Instruction{
+		Specifier: SpecifierUpdateSector,
+		Args:      make([]byte, RPCIUpdateSectorLen),
+	}
+	binary.LittleEndian.PutUint64(i.Args[:8], sectorIdxOffset)
+	binary.LittleEndian.PutUint64(i.Args[8:16], offsetOffset)
+	binary.LittleEndian.PutUint64(i.Args[16:24], lengthOffset)
+	binary.LittleEndian.PutUint64(i.Args[24:32], dataOffset)
+	if merkleProof {
+		i.Args[32] = 1
+	}
+	return i
+}
+
 // NewRevisionInstruction creates a modules.Instruction from arguments.
 func NewRevisionInstruction(merkleRootOffset uint64) Instruction {
 	return Instruction{
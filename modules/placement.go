@@ -0,0 +1,103 @@
+package modules
+
+import (
+	"fmt"
+)
+
+const (
+	// PlacementDomainIPv4Subnet is the failure domain of hosts within the
+	// same IPv4 /16 subnet.
+	PlacementDomainIPv4Subnet PlacementDomainType = "ipv4subnet"
+
+	// PlacementDomainIPv6Subnet is the failure domain of hosts within the
+	// same IPv6 /48 subnet.
+	PlacementDomainIPv6Subnet PlacementDomainType = "ipv6subnet"
+
+	// PlacementDomainASN is the failure domain of hosts within the same
+	// autonomous system.
+	PlacementDomainASN PlacementDomainType = "asn"
+
+	// PlacementDomainHostGroup is the failure domain of hosts within the same
+	// user-defined host group, e.g. all hosts known to run in the same
+	// datacenter.
+	PlacementDomainHostGroup PlacementDomainType = "hostgroup"
+)
+
+const (
+	// PlacementIPv4SubnetBits is the size of the IPv4 subnets which are
+	// considered to be a single failure domain.
+	PlacementIPv4SubnetBits = 16
+
+	// PlacementIPv6SubnetBits is the size of the IPv6 subnets which are
+	// considered to be a single failure domain.
+	PlacementIPv6SubnetBits = 48
+)
+
+type (
+	// PlacementDomainType is the type of a failure domain.
+	PlacementDomainType string
+
+	// PlacementDomain is a single failure domain a host belongs to, e.g. the
+	// subnet 10.1.0.0/16 or the autonomous system 64496.
+	PlacementDomain struct {
+		Type PlacementDomainType `json:"type"`
+		ID   string              `json:"id"`
+	}
+
+	// PlacementPolicy limits the number of hosts the renter forms contracts
+	// with and the number of pieces of a chunk the renter uploads per failure
+	// domain. A limit of 0 disables the limit for that type of domain.
+	//
+	// The ASNFile is a local file in the ip2asn TSV format which maps IP
+	// ranges to autonomous systems. The HostGroupsFile is a local file where
+	// every line assigns a host public key, IP address or CIDR to a named
+	// group in the format '<group> <host>'.
+	PlacementPolicy struct {
+		MaxPiecesPerIPv4Subnet uint64 `json:"maxpiecesperipv4subnet"`
+		MaxPiecesPerIPv6Subnet uint64 `json:"maxpiecesperipv6subnet"`
+		MaxPiecesPerASN        uint64 `json:"maxpiecesperasn"`
+		MaxPiecesPerHostGroup  uint64 `json:"maxpiecesperhostgroup"`
+
+		ASNFile        string `json:"asnfile"`
+		HostGroupsFile string `json:"hostgroupsfile"`
+	}
+)
+
+// Enabled returns whether any of the limits of the policy are set.
+func (pp PlacementPolicy) Enabled() bool {
+	return pp.MaxPiecesPerIPv4Subnet > 0 || pp.MaxPiecesPerIPv6Subnet > 0 || pp.MaxPiecesPerASN > 0 || pp.MaxPiecesPerHostGroup > 0
+}
+
+// Validate checks that none of the limits of the policy exceed the number of
+// parity pieces of a chunk. Otherwise the outage of a single failure domain
+// could make a file unavailable.
+func (pp PlacementPolicy) Validate(parityPieces uint64) error {
+	for _, t := range []PlacementDomainType{PlacementDomainIPv4Subnet, PlacementDomainIPv6Subnet, PlacementDomainASN, PlacementDomainHostGroup} {
+		if max := pp.MaxPieces(t); max > parityPieces {
+			return fmt.Errorf("maximum pieces per %v domain can't exceed the %v parity pieces of a chunk", t, parityPieces)
+		}
+	}
+	return nil
+}
+
+// MaxPieces returns the maximum number of pieces per domain of the provided
+// type. 0 means that there is no limit.
+func (pp PlacementPolicy) MaxPieces(t PlacementDomainType) uint64 {
+	switch t {
+	case PlacementDomainIPv4Subnet:
+		return pp.MaxPiecesPerIPv4Subnet
+	case PlacementDomainIPv6Subnet:
+		return pp.MaxPiecesPerIPv6Subnet
+	case PlacementDomainASN:
+		return pp.MaxPiecesPerASN
+	case PlacementDomainHostGroup:
+		return pp.MaxPiecesPerHostGroup
+	default:
+		return 0
+	}
+}
+
+// String returns the domain in the format '<type>:<id>'.
+func (pd PlacementDomain) String() string {
+	return string(pd.Type) + ":" + pd.ID
+}
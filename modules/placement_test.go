@@ -0,0 +1,26 @@
+package modules
+
+import (
+	"testing"
+)
+
+// TestPlacementPolicyValidate is a unit test for PlacementPolicy.Validate.
+func TestPlacementPolicyValidate(t *testing.T) {
+	tests := []struct {
+		policy PlacementPolicy
+		valid  bool
+	}{
+		{PlacementPolicy{}, true},
+		{PlacementPolicy{MaxPiecesPerIPv4Subnet: 2, MaxPiecesPerIPv6Subnet: 2}, true},
+		{PlacementPolicy{MaxPiecesPerASN: 4, MaxPiecesPerHostGroup: 4}, true},
+		{PlacementPolicy{MaxPiecesPerIPv4Subnet: 5}, false},
+		{PlacementPolicy{MaxPiecesPerIPv6Subnet: 5}, false},
+		{PlacementPolicy{MaxPiecesPerASN: 5}, false},
+		{PlacementPolicy{MaxPiecesPerHostGroup: 5}, false},
+	}
+	for i, test := range tests {
+		if err := test.policy.Validate(4); (err == nil) != test.valid {
+			t.Errorf("%v: expected valid %v but got %v", i, test.valid, err)
+		}
+	}
+}
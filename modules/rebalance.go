@@ -0,0 +1,84 @@
+package modules
+
+import (
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+const (
+	// MigrationReasonLowScore indicates that data is migrated away from a host
+	// because its score fell below the score percentile of the policy.
+	MigrationReasonLowScore = "low score"
+
+	// MigrationReasonOverpriced indicates that data is migrated away from a
+	// host because its prices exceed the cost threshold of the policy.
+	MigrationReasonOverpriced = "overpriced"
+)
+
+type (
+	// RebalancePolicy configures the proactive migration of data away from
+	// hosts which are still usable but considerably worse or more expensive
+	// than the hosts the renter could use instead. The contracts with such
+	// hosts are marked as neither GoodForUpload nor GoodForRenew, which makes
+	// the repair loop move their pieces to better hosts.
+	//
+	// Both thresholds are relative to the active hosts of the hostdb, each of
+	// which counts the same. A host is migrated away from if its score is
+	// below the MinScorePercentile of the active hosts' scores or if its cost
+	// of storing and transferring a sector for a period is more than
+	// MaxCostRatio times the median cost of the active hosts. A threshold of
+	// 0 disables it. Once a host passes both thresholds again, its contract
+	// is no longer migrated and can be used again.
+	//
+	// The Budget limits the estimated amount of money spent on migrations per
+	// period. Migrations also count towards the churn of the contractor and
+	// are therefore limited by the MaxPeriodChurn of the allowance. A Budget
+	// of 0 disables the rebalancer.
+	RebalancePolicy struct {
+		Budget             types.Currency `json:"budget"`
+		MaxCostRatio       float64        `json:"maxcostratio"`
+		MinScorePercentile float64        `json:"minscorepercentile"`
+	}
+
+	// RebalanceStatus contains the rebalance policy of the contractor, the
+	// budget it spent on migrations in the current period and the contracts
+	// which are being migrated.
+	RebalanceStatus struct {
+		Policy      RebalancePolicy     `json:"policy"`
+		SpentBudget types.Currency      `json:"spentbudget"`
+		Migrations  []ContractMigration `json:"migrations"`
+	}
+
+	// ContractMigration describes a contract whose data is being migrated to
+	// other hosts.
+	ContractMigration struct {
+		ContractID    types.FileContractID     `json:"contractid"`
+		HostPublicKey types.TurtleDexPublicKey `json:"hostpublickey"`
+		Size          uint64                   `json:"size"`
+		EstimatedCost types.Currency           `json:"estimatedcost"`
+		Reason        string                   `json:"reason"`
+		StartHeight   types.BlockHeight        `json:"startheight"`
+	}
+)
+
+// Enabled returns whether the policy allows the contractor to migrate data.
+func (rp RebalancePolicy) Enabled() bool {
+	return !rp.Budget.IsZero() && (rp.MaxCostRatio > 0 || rp.MinScorePercentile > 0)
+}
+
+// Equals returns whether two policies are equal.
+func (rp RebalancePolicy) Equals(other RebalancePolicy) bool {
+	return rp.Budget.Equals(other.Budget) && rp.MaxCostRatio == other.MaxCostRatio && rp.MinScorePercentile == other.MinScorePercentile
+}
+
+// Validate checks that the thresholds of the policy are valid.
+func (rp RebalancePolicy) Validate() error {
+	if rp.MinScorePercentile < 0 || rp.MinScorePercentile >= 1 {
+		return errors.New("minimum score percentile has to be at least 0 and less than 1")
+	}
+	if rp.MaxCostRatio != 0 && rp.MaxCostRatio < 1 {
+		return errors.New("maximum cost ratio has to be 0 or at least 1")
+	}
+	return nil
+}
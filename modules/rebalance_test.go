@@ -0,0 +1,41 @@
+package modules
+
+import (
+	"testing"
+
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+// TestRebalancePolicy is a unit test for RebalancePolicy.Enabled and
+// RebalancePolicy.Validate.
+func TestRebalancePolicy(t *testing.T) {
+	budget := types.TurtleDexcoinPrecision
+	tests := []struct {
+		policy  RebalancePolicy
+		enabled bool
+		valid   bool
+	}{
+		{RebalancePolicy{}, false, true},
+		{RebalancePolicy{MinScorePercentile: 0.1}, false, true},
+		{RebalancePolicy{Budget: budget}, false, true},
+		{RebalancePolicy{Budget: budget, MinScorePercentile: 0.1}, true, true},
+		{RebalancePolicy{Budget: budget, MaxCostRatio: 2}, true, true},
+		{RebalancePolicy{Budget: budget, MinScorePercentile: 1}, true, false},
+		{RebalancePolicy{Budget: budget, MinScorePercentile: -0.1}, false, false},
+		{RebalancePolicy{Budget: budget, MaxCostRatio: 0.5}, true, false},
+	}
+	for i, test := range tests {
+		if test.policy.Enabled() != test.enabled {
+			t.Errorf("%v: expected enabled %v", i, test.enabled)
+		}
+		if err := test.policy.Validate(); (err == nil) != test.valid {
+			t.Errorf("%v: expected valid %v but got %v", i, test.valid, err)
+		}
+	}
+	if !tests[3].policy.Equals(RebalancePolicy{Budget: budget, MinScorePercentile: 0.1}) {
+		t.Fatal("policies should be equal")
+	}
+	if tests[3].policy.Equals(tests[4].policy) {
+		t.Fatal("policies shouldn't be equal")
+	}
+}
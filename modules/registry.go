@@ -2,6 +2,7 @@ package modules
 
 import (
 	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/types"
 )
 
 const (
@@ -24,6 +25,19 @@ const (
 	FileIDVersion = 1
 )
 
+type (
+	// RegistryEntryID is the hash of a registry entry's public key and tweak.
+	// It uniquely identifies an entry on a host and is what hosts use
+	// internally to look up entries.
+	RegistryEntryID crypto.Hash
+)
+
+// DeriveRegistryEntryID derives the entry id of a registry entry from its
+// public key and tweak.
+func DeriveRegistryEntryID(pubKey types.TurtleDexPublicKey, tweak crypto.Hash) RegistryEntryID {
+	return RegistryEntryID(crypto.HashAll(pubKey, tweak))
+}
+
 // RoundRegistrySize is a helper to correctly round up the size of a registry to
 // the closest valid one.
 func RoundRegistrySize(size uint64) uint64 {
@@ -407,6 +407,12 @@ type DirectoryInfo struct {
 	// Skynet Fields
 	SkynetFiles uint64 `json:"skynetfiles"`
 	SkynetSize  uint64 `json:"skynetsize"`
+
+	// Versioning is the versioning policy set on the directory.
+	Versioning VersioningPolicy `json:"versioning"`
+
+	// RedundancyPolicy is the redundancy policy set on the directory.
+	RedundancyPolicy RedundancyPolicy `json:"redundancypolicy"`
 }
 
 // Name implements os.FileInfo.
@@ -463,6 +469,73 @@ type FileUploadParams struct {
 	// to create a CipherKey with the given CipherType. This value override
 	// CipherType if it is set.
 	CipherKey crypto.CipherKey
+
+	// Dedup indicates that the file's chunks should be deduplicated against
+	// the chunks of other files uploaded with Dedup. It can't be used with a
+	// custom CipherKey and implies DisablePartialChunk. Deduplicated files
+	// can't be repaired from their local copy.
+	Dedup bool
+
+	// Compression is the type of compression applied to the data before it
+	// is uploaded. Compressed files can't be repaired from their local copy.
+	Compression CompressionType
+}
+
+// VersioningPolicy describes which prior versions of the files within a
+// directory are retained when a file is deleted or overwritten. A version is
+// retained as long as it is one of the MaxVersions most recent versions of the
+// file or younger than the RetentionWindow. Versioning is disabled if neither
+// is set. The policy applies to the directory and all of its subdirectories
+// which don't have a policy of their own.
+type VersioningPolicy struct {
+	MaxVersions     uint64        `json:"maxversions"`
+	RetentionWindow time.Duration `json:"retentionwindow"`
+}
+
+// Enabled returns whether the policy retains any versions.
+func (vp VersioningPolicy) Enabled() bool {
+	return vp.MaxVersions > 0 || vp.RetentionWindow > 0
+}
+
+// RedundancyPolicy describes the erasure code used for the files within a
+// directory. New files are uploaded using the policy unless an erasure code is
+// specified explicitly, and existing files using a different erasure code are
+// re-encoded in the background. The policy applies to the directory and all of
+// its subdirectories which don't have a policy of their own. It is disabled if
+// DataPieces is 0.
+type RedundancyPolicy struct {
+	DataPieces   int `json:"datapieces"`
+	ParityPieces int `json:"paritypieces"`
+}
+
+// Enabled returns whether the policy specifies an erasure code.
+func (rp RedundancyPolicy) Enabled() bool {
+	return rp.DataPieces > 0
+}
+
+// ErasureCoder returns the erasure coder described by the policy.
+func (rp RedundancyPolicy) ErasureCoder() (ErasureCoder, error) {
+	return NewRSSubCode(rp.DataPieces, rp.ParityPieces, crypto.SegmentSize)
+}
+
+// FileVersion describes a retained prior version of a file.
+type FileVersion struct {
+	// ID identifies the version among the versions of the file.
+	ID string `json:"id"`
+
+	// CreateTime is the time the version was replaced or deleted.
+	CreateTime time.Time `json:"createtime"`
+
+	// TurtleDexPath is the siapath of the versioned file and VersionPath is
+	// the siapath of the siafile holding the version.
+	TurtleDexPath TurtleDexPath `json:"siapath"`
+	VersionPath   TurtleDexPath `json:"versionpath"`
+
+	Filesize         uint64    `json:"filesize"`
+	Health           float64   `json:"health"`
+	ModificationTime time.Time `json:"modtime"`
+	Recoverable      bool      `json:"recoverable"`
+	Redundancy       float64   `json:"redundancy"`
 }
 
 // FileInfo provides information about a file.
@@ -471,6 +544,7 @@ type FileInfo struct {
 	Available        bool              `json:"available"`
 	ChangeTime       time.Time         `json:"changetime"`
 	CipherType       string            `json:"ciphertype"`
+	Compression      CompressionType   `json:"compression"`
 	CreateTime       time.Time         `json:"createtime"`
 	Expiration       types.BlockHeight `json:"expiration"`
 	Filesize         uint64            `json:"filesize"`
@@ -535,6 +609,12 @@ type HostDBEntry struct {
 
 	LastHistoricUpdate types.BlockHeight `json:"lasthistoricupdate"`
 
+	// Measurements that are taken when the renter audits the data stored on
+	// the host. Failed audits are evidence of lost or corrupted data.
+	AuditFailures    uint64    `json:"auditfailures"`
+	AuditSuccesses   uint64    `json:"auditsuccesses"`
+	LastAuditFailure time.Time `json:"lastauditfailure"`
+
 	// Measurements related to the IP subnet mask.
 	IPNets          []string  `json:"ipnets"`
 	LastIPNetChange time.Time `json:"lastipnetchange"`
@@ -576,6 +656,12 @@ type HostScoreBreakdown struct {
 	StorageRemainingAdjustment float64 `json:"storageremainingadjustment"`
 	UptimeAdjustment           float64 `json:"uptimeadjustment"`
 	VersionAdjustment          float64 `json:"versionadjustment"`
+
+	// ScoringPolicyAdjustment is the lowest possible adjustment if the host
+	// doesn't meet the limits of the renter's HostScoringPolicy and 1
+	// otherwise. ScoringPolicyViolations lists the limits the host violates.
+	ScoringPolicyAdjustment float64  `json:"scoringpolicyadjustment"`
+	ScoringPolicyViolations []string `json:"scoringpolicyviolations"`
 }
 
 // MemoryStatus contains information about the status of the memory managers in
@@ -589,6 +675,21 @@ type MemoryStatus struct {
 	System       MemoryManagerStatus `json:"system"`
 }
 
+// DedupStatus contains information about the renter's chunk deduplication
+// index.
+type DedupStatus struct {
+	// UniqueChunks is the number of distinct chunks in the index.
+	UniqueChunks uint64 `json:"uniquechunks"`
+
+	// References is the number of chunks of all files referencing the
+	// chunks in the index.
+	References uint64 `json:"references"`
+
+	// SavedStorage is the amount of storage in bytes that would have been
+	// used on hosts without deduplication.
+	SavedStorage uint64 `json:"savedstorage"`
+}
+
 // MemoryManagerStatus contains the memory status of a single memory manager.
 type MemoryManagerStatus struct {
 	Available uint64 `json:"available"`
@@ -641,11 +742,15 @@ type RenterPriceEstimation struct {
 
 // RenterSettings control the behavior of the Renter.
 type RenterSettings struct {
-	Allowance        Allowance     `json:"allowance"`
-	IPViolationCheck bool          `json:"ipviolationcheck"`
-	MaxUploadSpeed   int64         `json:"maxuploadspeed"`
-	MaxDownloadSpeed int64         `json:"maxdownloadspeed"`
-	UploadsStatus    UploadsStatus `json:"uploadsstatus"`
+	Allowance        Allowance           `json:"allowance"`
+	IPViolationCheck bool                `json:"ipviolationcheck"`
+	MaxUploadSpeed   int64               `json:"maxuploadspeed"`
+	MaxDownloadSpeed int64               `json:"maxdownloadspeed"`
+	PlacementPolicy  PlacementPolicy     `json:"placementpolicy"`
+	RebalancePolicy  RebalancePolicy     `json:"rebalancepolicy"`
+	ScoringPolicy    HostScoringPolicy   `json:"scoringpolicy"`
+	SectorCache      SectorCacheSettings `json:"sectorcache"`
+	UploadsStatus    UploadsStatus       `json:"uploadsstatus"`
 }
 
 // UploadsStatus contains information about the Renter's Uploads
@@ -1011,6 +1116,15 @@ type Renter interface {
 	// ContractorChurnStatus returns contract churn stats for the current period.
 	ContractorChurnStatus() ContractorChurnStatus
 
+	// ContractorRebalanceStatus returns the status of the migrations of data
+	// away from degraded or overpriced hosts.
+	ContractorRebalanceStatus() RebalanceStatus
+
+	// ContractMaintenancePlan returns the actions the next round of contract
+	// maintenance would take with the provided allowance without performing
+	// them. The current allowance is used if the empty allowance is provided.
+	ContractMaintenancePlan(allowance Allowance) (ContractMaintenancePlan, error)
+
 	// ContractUtility provides the contract utility for a given host key.
 	ContractUtility(pk types.TurtleDexPublicKey) (ContractUtility, bool)
 
@@ -1021,6 +1135,34 @@ type Renter interface {
 	// MemoryStatus returns the current status of the memory manager
 	MemoryStatus() (MemoryStatus, error)
 
+	// DedupStatus returns the current status of the chunk deduplication
+	// index.
+	DedupStatus() (DedupStatus, error)
+
+	// SectorCacheStatus returns the current status of the on-disk sector
+	// cache.
+	SectorCacheStatus() (SectorCacheStatus, error)
+
+	// AuditStatus returns the results of the integrity audits which spot
+	// check that the renter's hosts still store its data.
+	AuditStatus() (RenterAuditStatus, error)
+
+	// Audit triggers an integrity audit without waiting for the next
+	// scheduled one.
+	Audit() error
+
+	// SyncFolders returns the status of the local directories the renter
+	// keeps in sync with TurtleDex directories.
+	SyncFolders() ([]SyncFolderStatus, error)
+
+	// AddSyncFolder starts syncing a local directory with a TurtleDex
+	// directory.
+	AddSyncFolder(SyncFolderConfig) error
+
+	// RemoveSyncFolder stops syncing the local directory at localPath. Files
+	// which were already uploaded are kept.
+	RemoveSyncFolder(localPath string) error
+
 	// Mount mounts a FUSE filesystem at mountPoint, making the contents of sp
 	// available via the local filesystem.
 	Mount(mountPoint string, sp TurtleDexPath, opts MountOptions) error
@@ -1068,6 +1210,11 @@ type Renter interface {
 	// DeleteFile deletes a file entry from the renter.
 	DeleteFile(siaPath TurtleDexPath) error
 
+	// DeleteFileUnversioned deletes a file entry from the renter without
+	// retaining it as a version, even if versioning is enabled. It is used
+	// to remove partially uploaded files.
+	DeleteFileUnversioned(siaPath TurtleDexPath) error
+
 	// Download creates a download according to the parameters passed, including
 	// downloads of `offset` and `length` type. It returns a method to
 	// start the download.
@@ -1107,6 +1254,10 @@ type Renter interface {
 	// Host provides the DB entry and score breakdown for the requested host.
 	Host(pk types.TurtleDexPublicKey) (HostDBEntry, bool, error)
 
+	// HostHistory returns the time series the hostdb keeps about the
+	// requested host.
+	HostHistory(pk types.TurtleDexPublicKey) (HostHistory, bool, error)
+
 	// InitialScanComplete returns a boolean indicating if the initial scan of the
 	// hostdb is completed.
 	InitialScanComplete() (bool, error)
@@ -1118,6 +1269,11 @@ type Renter interface {
 	// RenameFile changes the path of a file.
 	RenameFile(siaPath, newTurtleDexPath TurtleDexPath) error
 
+	// ReplaceFile renames the file at src to dst, replacing the file at dst
+	// if there is one. The replaced file is restored if the rename fails and
+	// retained as a version if versioning is enabled for dst.
+	ReplaceFile(src, dst TurtleDexPath) error
+
 	// RenameDir changes the path of a dir.
 	RenameDir(oldPath, newPath TurtleDexPath) error
 
@@ -1131,10 +1287,22 @@ type Renter interface {
 	// used.
 	ReadRegistry(spk types.TurtleDexPublicKey, tweak crypto.Hash, timeout time.Duration) (SignedRegistryValue, error)
 
+	// ReadRegistryEID starts a registry lookup by entry id on all available
+	// workers. Apart from that it behaves like ReadRegistry.
+	ReadRegistryEID(eid RegistryEntryID, timeout time.Duration) (SignedRegistryValue, error)
+
+	// NewRegistrySubscriber creates a new subscriber which is notified about
+	// updates to the registry entries it subscribes to.
+	NewRegistrySubscriber() (RegistrySubscriber, error)
+
 	// ScoreBreakdown will return the score for a host db entry using the
 	// hostdb's weighting algorithm.
 	ScoreBreakdown(entry HostDBEntry) (HostScoreBreakdown, error)
 
+	// ScoreBreakdownWithPolicy returns the score a host db entry would have
+	// if the provided scoring policy was set.
+	ScoreBreakdownWithPolicy(entry HostDBEntry, policy HostScoringPolicy) (HostScoreBreakdown, error)
+
 	// Settings returns the Renter's current settings.
 	Settings() (RenterSettings, error)
 
@@ -1177,6 +1345,25 @@ type Renter interface {
 	// DirList lists the directories in a ttdxdir
 	DirList(siaPath TurtleDexPath) ([]DirectoryInfo, error)
 
+	// SetDirVersioning sets the versioning policy of a directory.
+	SetDirVersioning(siaPath TurtleDexPath, policy VersioningPolicy) error
+
+	// SetDirRedundancyPolicy sets the redundancy policy of a directory.
+	SetDirRedundancyPolicy(siaPath TurtleDexPath, policy RedundancyPolicy) error
+
+	// FileVersions returns the retained prior versions of a file, sorted from
+	// oldest to newest.
+	FileVersions(siaPath TurtleDexPath) ([]FileVersion, error)
+
+	// RestoreFileVersion restores a prior version of a file. The current
+	// version of the file is retained as a new version if versioning is
+	// enabled.
+	RestoreFileVersion(siaPath TurtleDexPath, id string) error
+
+	// PurgeFileVersions deletes a prior version of a file or all prior
+	// versions if no id is provided.
+	PurgeFileVersions(siaPath TurtleDexPath, id string) error
+
 	// AddSkykey adds the skykey to the renter's skykey manager.
 	AddSkykey(skykey.Skykey) error
 
@@ -1234,6 +1421,10 @@ type Renter interface {
 	// potentially more expensive, hosts.
 	DownloadSkylinkBaseSector(link Skylink, timeout time.Duration, pricePerMS types.Currency) (Streamer, error)
 
+	// ResolveSkylinkV2 resolves a v2 skylink to the v1 skylink stored in the
+	// registry entry it points to. A v1 skylink is returned unchanged.
+	ResolveSkylinkV2(link Skylink, timeout time.Duration) (Skylink, error)
+
 	// UploadSkyfile will upload data to the TurtleDex network from a reader and
 	// create a skyfile, returning the skylink that can be used to access the
 	// file.
@@ -1244,6 +1435,24 @@ type Renter interface {
 	// file.
 	UploadSkyfile(SkyfileUploadParameters, SkyfileUploadReader) (Skylink, error)
 
+	// CreateResumableSkyfileUpload creates a skyfile upload of length bytes
+	// which receives its data in multiple calls to
+	// WriteResumableSkyfileUpload.
+	CreateResumableSkyfileUpload(sup SkyfileUploadParameters, length uint64) (ResumableSkyfileUpload, error)
+
+	// ResumableSkyfileUpload returns the state of a resumable skyfile upload.
+	ResumableSkyfileUpload(id ResumableUploadID) (ResumableSkyfileUpload, error)
+
+	// WriteResumableSkyfileUpload appends data to a resumable skyfile upload
+	// starting at offset, which has to match the upload's current offset.
+	// Once all data was received, the skyfile is uploaded in the background
+	// the same way UploadSkyfile uploads it.
+	WriteResumableSkyfileUpload(id ResumableUploadID, offset uint64, data io.Reader) (ResumableSkyfileUpload, error)
+
+	// DeleteResumableSkyfileUpload aborts a resumable skyfile upload and
+	// deletes its staged data.
+	DeleteResumableSkyfileUpload(id ResumableUploadID) error
+
 	// Blocklist returns the merkleroots that are blocked
 	Blocklist() ([]crypto.Hash, error)
 
@@ -1254,6 +1463,17 @@ type Renter interface {
 	// allowed to spend on faster hosts.
 	PinSkylink(link Skylink, sup SkyfileUploadParameters, timeout time.Duration, pricePerMS types.Currency) error
 
+	// SkynetPins returns the status of the skylinks kept pinned by the
+	// renter.
+	SkynetPins() ([]SkynetPinStatus, error)
+
+	// AddSkynetPin adds a skylink to the skylinks kept pinned by the renter.
+	AddSkynetPin(pin SkynetPin) error
+
+	// RemoveSkynetPin stops keeping a skylink pinned and unpins it by
+	// deleting its siafile.
+	RemoveSkynetPin(link Skylink) error
+
 	// Portals returns the list of known skynet portals.
 	Portals() ([]SkynetPortal, error)
 
@@ -1289,6 +1509,29 @@ type Streamer interface {
 	io.Closer
 }
 
+// RegistrySubscriber is the interface implemented by the Renter's registry
+// subscribers which receive notifications about updated registry entries.
+type RegistrySubscriber interface {
+	// Subscribe subscribes to the entry with the given public key and tweak.
+	// If the latest value of the entry is already known, it is sent right
+	// away.
+	Subscribe(spk types.TurtleDexPublicKey, tweak crypto.Hash) error
+
+	// Unsubscribe unsubscribes from the entry with the given public key and
+	// tweak.
+	Unsubscribe(spk types.TurtleDexPublicKey, tweak crypto.Hash)
+
+	// Notifications returns the channel on which updated entries are sent.
+	// Updates are only sent if their revision number is higher than the
+	// revision number of the last update for the same entry. The channel is
+	// closed when the subscriber is closed.
+	Notifications() <-chan RPCRegistrySubscriptionNotificationEntryUpdate
+
+	// Close unsubscribes from all entries and closes the notification
+	// channel.
+	Close() error
+}
+
 // RenterDownloadParameters defines the parameters passed to the Renter's
 // Download method.
 type RenterDownloadParameters struct {
@@ -1369,6 +1612,10 @@ type HostDB interface {
 	// Host returns the HostDBEntry for a given host.
 	Host(pk types.TurtleDexPublicKey) (HostDBEntry, bool, error)
 
+	// HostHistory returns the time series of scans, prices and job outcomes
+	// for a given host.
+	HostHistory(pk types.TurtleDexPublicKey) (HostHistory, bool, error)
+
 	// IncrementSuccessfulInteractions increments the number of successful
 	// interactions with a host for a given key
 	IncrementSuccessfulInteractions(types.TurtleDexPublicKey) error
@@ -1377,6 +1624,14 @@ type HostDB interface {
 	// a host for a given key
 	IncrementFailedInteractions(types.TurtleDexPublicKey) error
 
+	// RecordAudit records the outcome of an audit of the data stored on a
+	// host.
+	RecordAudit(types.TurtleDexPublicKey, bool) error
+
+	// RecordJob records the outcome of a job a renter worker executed on a
+	// host.
+	RecordJob(types.TurtleDexPublicKey, bool) error
+
 	// initialScanComplete returns a boolean indicating if the initial scan of the
 	// hostdb is completed.
 	InitialScanComplete() (bool, error)
@@ -1385,6 +1640,13 @@ type HostDB interface {
 	// enabled or not.
 	IPViolationsCheck() (bool, error)
 
+	// PlacementDomains returns the failure domains of a host according to the
+	// placement policy and whether the host is known to the hostdb.
+	PlacementDomains(types.TurtleDexPublicKey) ([]PlacementDomain, bool, error)
+
+	// PlacementPolicy returns the placement policy of the hostdb.
+	PlacementPolicy() (PlacementPolicy, error)
+
 	// RandomHosts returns a set of random hosts, weighted by their estimated
 	// usefulness / attractiveness to the renter. RandomHosts will not return
 	// any offline or inactive hosts.
@@ -1399,6 +1661,17 @@ type HostDB interface {
 	// of the host.
 	ScoreBreakdown(HostDBEntry) (HostScoreBreakdown, error)
 
+	// ScoreBreakdownWithAllowance returns the score breakdown the host would
+	// have if the provided allowance was set.
+	ScoreBreakdownWithAllowance(HostDBEntry, Allowance) (HostScoreBreakdown, error)
+
+	// ScoreBreakdownWithPolicy returns the score breakdown the host would
+	// have if the provided scoring policy was set.
+	ScoreBreakdownWithPolicy(HostDBEntry, HostScoringPolicy) (HostScoreBreakdown, error)
+
+	// ScoringPolicy returns the policy which tunes the scoring of hosts.
+	ScoringPolicy() (HostScoringPolicy, error)
+
 	// SetAllowance updates the allowance used by the hostdb for weighing hosts by
 	// updating the host weight function. It will completely rebuild the hosttree so
 	// it should be used with care.
@@ -1408,6 +1681,14 @@ type HostDB interface {
 	// hostdb.
 	SetIPViolationCheck(enabled bool) error
 
+	// SetPlacementPolicy updates the placement policy which limits the number
+	// of hosts and pieces per failure domain.
+	SetPlacementPolicy(PlacementPolicy) error
+
+	// SetScoringPolicy updates the policy which tunes the scoring of hosts. It
+	// will completely rebuild the hosttree so it should be used with care.
+	SetScoringPolicy(HostScoringPolicy) error
+
 	// UpdateContracts rebuilds the knownContracts of the HostBD using the provided
 	// contracts.
 	UpdateContracts([]RenterContract) error
@@ -0,0 +1,415 @@
+package renter
+
+// The renter's integrity audit periodically spot checks that hosts still store
+// the renter's data. Health is derived from which hosts the renter has good
+// contracts with, which doesn't catch a host that silently lost data. Every
+// audit picks a random sample of pieces and downloads a random segment of each
+// of them together with a merkle proof. Additionally every host of the sample
+// has to prove that a random segment of its contract belongs to the latest
+// revision.
+//
+// A check fails if the host can't find the sector or if the proof is invalid.
+// Failures are recorded in the hostdb, which penalizes the host's score, and
+// the piece is removed from the file so that the repair loop replaces it.
+// Checks that fail for any other reason, e.g. a timeout or an offline host,
+// are inconclusive and don't affect the host.
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turtledex/errors"
+	"github.com/turtledex/fastrand"
+
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem/siafile"
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+// hostErrSectorNotFound is the error returned by hosts when they don't store a
+// requested sector.
+const hostErrSectorNotFound = "could not find the desired sector"
+
+type (
+	// auditor keeps track of the results of the renter's integrity audits.
+	auditor struct {
+		running   bool
+		lastAudit time.Time
+		nextAudit time.Time
+
+		passed       uint64
+		failed       uint64
+		inconclusive uint64
+		lostPieces   uint64
+
+		hosts          map[string]*modules.HostAuditStatus
+		recentFailures []modules.AuditFailure
+
+		// staticTriggerChan is used to start an audit before the next
+		// scheduled one.
+		staticTriggerChan chan struct{}
+
+		mu sync.Mutex
+	}
+
+	// auditPiece is a piece which was selected to be spot checked.
+	auditPiece struct {
+		siaPath    modules.TurtleDexPath
+		chunkIndex uint64
+		pieceIndex uint64
+		piece      siafile.Piece
+	}
+)
+
+// newAuditor creates a new auditor.
+func newAuditor() *auditor {
+	return &auditor{
+		hosts:             make(map[string]*modules.HostAuditStatus),
+		staticTriggerChan: make(chan struct{}, 1),
+	}
+}
+
+// auditErrProvesDataLoss returns true if the error of a spot check proves that
+// the host no longer stores the data it was asked for.
+func auditErrProvesDataLoss(err error) bool {
+	return err != nil &&
+		(errors.Contains(err, errSectorProofInvalid) ||
+			errors.Contains(err, errOffsetProofInvalid) ||
+			strings.Contains(err.Error(), hostErrSectorNotFound))
+}
+
+// managedRecord records the result of a spot check. The failure describes the
+// check and is only added to the recent failures if the check proved data
+// loss.
+func (a *auditor) managedRecord(check modules.AuditFailure, err error, lost bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	hs, exists := a.hosts[check.HostPubKey.String()]
+	if !exists {
+		hs = &modules.HostAuditStatus{HostPubKey: check.HostPubKey}
+		a.hosts[check.HostPubKey.String()] = hs
+	}
+	hs.LastAudit = check.Time
+
+	switch {
+	case err == nil:
+		hs.Passed++
+		a.passed++
+	case auditErrProvesDataLoss(err):
+		hs.Failed++
+		hs.LastFailure = check.Time
+		a.failed++
+		if lost {
+			hs.LostPieces++
+			a.lostPieces++
+		}
+		check.Error = err.Error()
+		a.recentFailures = append(a.recentFailures, check)
+		if len(a.recentFailures) > auditMaxRecentFailures {
+			a.recentFailures = a.recentFailures[len(a.recentFailures)-auditMaxRecentFailures:]
+		}
+	default:
+		hs.Inconclusive++
+		a.inconclusive++
+	}
+}
+
+// managedSetRunning updates whether an audit is in progress.
+func (a *auditor) managedSetRunning(running bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.running = running
+	if !running {
+		a.lastAudit = time.Now()
+	}
+}
+
+// managedSetNextAudit sets the time of the next scheduled audit.
+func (a *auditor) managedSetNextAudit(t time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.nextAudit = t
+}
+
+// managedStatus returns the status of the audits.
+func (a *auditor) managedStatus() modules.RenterAuditStatus {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	status := modules.RenterAuditStatus{
+		Running:        a.running,
+		LastAudit:      a.lastAudit,
+		NextAudit:      a.nextAudit,
+		Passed:         a.passed,
+		Failed:         a.failed,
+		Inconclusive:   a.inconclusive,
+		LostPieces:     a.lostPieces,
+		Hosts:          make([]modules.HostAuditStatus, 0, len(a.hosts)),
+		RecentFailures: append([]modules.AuditFailure{}, a.recentFailures...),
+	}
+	for _, hs := range a.hosts {
+		status.Hosts = append(status.Hosts, *hs)
+	}
+	return status
+}
+
+// callTrigger starts an audit unless one is already pending.
+func (a *auditor) callTrigger() {
+	select {
+	case a.staticTriggerChan <- struct{}{}:
+	default:
+	}
+}
+
+// Audit triggers an integrity audit without waiting for the next scheduled
+// one.
+func (r *Renter) Audit() error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if !r.g.Online() {
+		return errors.New("unable to audit hosts while the renter is offline")
+	}
+	r.staticAuditor.callTrigger()
+	return nil
+}
+
+// AuditStatus returns the results of the integrity audits since the renter was
+// started.
+func (r *Renter) AuditStatus() (modules.RenterAuditStatus, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.RenterAuditStatus{}, err
+	}
+	defer r.tg.Done()
+	return r.staticAuditor.managedStatus(), nil
+}
+
+// threadedAuditLoop periodically audits a random sample of the renter's
+// pieces.
+func (r *Renter) threadedAuditLoop() {
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	for {
+		r.staticAuditor.managedSetNextAudit(time.Now().Add(auditInterval))
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-time.After(auditInterval):
+		case <-r.staticAuditor.staticTriggerChan:
+		}
+		if !r.g.Online() {
+			r.repairLog.Println("Skipping audit since the renter is offline")
+			continue
+		}
+		r.managedAudit()
+	}
+}
+
+// managedAudit spot checks a random sample of pieces and the contracts of
+// their hosts.
+func (r *Renter) managedAudit() {
+	r.staticAuditor.managedSetRunning(true)
+	defer r.staticAuditor.managedSetRunning(false)
+
+	sample, err := r.managedAuditSample(auditSampleSize)
+	if err != nil {
+		r.repairLog.Println("WARN: unable to select pieces for audit:", err)
+		return
+	}
+	hosts := make(map[string]types.TurtleDexPublicKey)
+	for _, ap := range sample {
+		select {
+		case <-r.tg.StopChan():
+			return
+		default:
+		}
+		r.managedAuditPiece(ap)
+		hosts[ap.piece.HostPubKey.String()] = ap.piece.HostPubKey
+	}
+	for _, hpk := range hosts {
+		select {
+		case <-r.tg.StopChan():
+			return
+		default:
+		}
+		r.managedAuditContract(hpk)
+	}
+}
+
+// managedAuditSample selects up to n random pieces of the renter's files. Only
+// pieces stored on hosts with a worker are selected.
+func (r *Renter) managedAuditSample(n int) ([]auditPiece, error) {
+	var files []modules.TurtleDexPath
+	var mu sync.Mutex
+	flf := func(fi modules.FileInfo) {
+		if fi.Filesize == 0 {
+			return
+		}
+		mu.Lock()
+		files = append(files, fi.TurtleDexPath)
+		mu.Unlock()
+	}
+	err := r.staticFileSystem.CachedList(modules.RootTurtleDexPath(), true, flf, func(modules.DirectoryInfo) {})
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to list files")
+	}
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	// Select a random piece of a random file until the sample is complete.
+	// Files might not have pieces on any host with a worker, so the number of
+	// attempts is limited.
+	var sample []auditPiece
+	for attempts := 0; attempts < 4*n && len(sample) < n; attempts++ {
+		ap, ok, err := r.managedAuditRandomPiece(files[fastrand.Intn(len(files))])
+		if err != nil {
+			r.repairLog.Println("WARN: unable to select piece for audit:", err)
+			continue
+		}
+		if ok {
+			sample = append(sample, ap)
+		}
+	}
+	return sample, nil
+}
+
+// managedAuditRandomPiece selects a random piece of a random chunk of the file
+// at siaPath which is stored on a host with a worker.
+func (r *Renter) managedAuditRandomPiece(siaPath modules.TurtleDexPath) (_ auditPiece, _ bool, err error) {
+	node, err := r.staticFileSystem.OpenTurtleDexFile(siaPath)
+	if err != nil {
+		return auditPiece{}, false, err
+	}
+	defer func() {
+		err = errors.Compose(err, node.Close())
+	}()
+	numChunks := node.NumChunks()
+	if numChunks == 0 {
+		return auditPiece{}, false, nil
+	}
+	chunkIndex := fastrand.Uint64n(numChunks)
+	pieces, err := node.Pieces(chunkIndex)
+	if err != nil {
+		return auditPiece{}, false, err
+	}
+	var candidates []auditPiece
+	for pieceIndex, pieceSet := range pieces {
+		for _, piece := range pieceSet {
+			if _, err := r.staticWorkerPool.callWorker(piece.HostPubKey); err != nil {
+				continue
+			}
+			candidates = append(candidates, auditPiece{
+				siaPath:    siaPath,
+				chunkIndex: chunkIndex,
+				pieceIndex: uint64(pieceIndex),
+				piece:      piece,
+			})
+		}
+	}
+	if len(candidates) == 0 {
+		return auditPiece{}, false, nil
+	}
+	return candidates[fastrand.Intn(len(candidates))], true, nil
+}
+
+// managedAuditPiece downloads a random segment of the piece and verifies its
+// proof. If the host fails to prove that it stores the piece, the piece is
+// removed from the file.
+func (r *Renter) managedAuditPiece(ap auditPiece) {
+	w, err := r.staticWorkerPool.callWorker(ap.piece.HostPubKey)
+	if err != nil {
+		return // worker was removed since the sample was selected
+	}
+	offset := fastrand.Uint64n(modules.SectorSize/crypto.SegmentSize) * crypto.SegmentSize
+	ctx, cancel := context.WithTimeout(r.tg.StopCtx(), auditTimeout)
+	_, err = w.ReadSectorLowPrio(ctx, ap.piece.MerkleRoot, offset, crypto.SegmentSize)
+	cancel()
+
+	check := modules.AuditFailure{
+		Time:          time.Now(),
+		Type:          modules.AuditCheckReadSector,
+		HostPubKey:    ap.piece.HostPubKey,
+		TurtleDexPath: ap.siaPath,
+		ChunkIndex:    ap.chunkIndex,
+		PieceIndex:    ap.pieceIndex,
+		MerkleRoot:    ap.piece.MerkleRoot,
+	}
+	lost := false
+	if auditErrProvesDataLoss(err) {
+		r.repairLog.Printf("Host %v failed audit of piece %v of chunk %v of %v: %v", ap.piece.HostPubKey, ap.pieceIndex, ap.chunkIndex, ap.siaPath, err)
+		if rmErr := r.managedRemoveLostPiece(ap); rmErr != nil {
+			r.repairLog.Printf("WARN: unable to remove lost piece of %v: %v", ap.siaPath, rmErr)
+		} else {
+			lost = true
+		}
+	}
+	r.managedRecordAudit(check, err, lost)
+}
+
+// managedAuditContract downloads a random segment of the host's contract and
+// verifies its proof against the merkle root of the latest revision.
+func (r *Renter) managedAuditContract(hpk types.TurtleDexPublicKey) {
+	contract, ok := r.hostContractor.ContractByPublicKey(hpk)
+	if !ok || contract.Size() < crypto.SegmentSize {
+		return
+	}
+	w, err := r.staticWorkerPool.callWorker(hpk)
+	if err != nil {
+		return
+	}
+	offset := fastrand.Uint64n(contract.Size()/crypto.SegmentSize) * crypto.SegmentSize
+	ctx, cancel := context.WithTimeout(r.tg.StopCtx(), auditTimeout)
+	_, err = w.ReadOffset(ctx, offset, crypto.SegmentSize)
+	cancel()
+
+	if auditErrProvesDataLoss(err) {
+		r.repairLog.Printf("Host %v failed audit of contract %v at offset %v: %v", hpk, contract.ID, offset, err)
+	}
+	check := modules.AuditFailure{
+		Time:       time.Now(),
+		Type:       modules.AuditCheckReadOffset,
+		HostPubKey: hpk,
+	}
+	r.managedRecordAudit(check, err, false)
+}
+
+// managedRecordAudit records the result of a spot check in the auditor and, if
+// the check was conclusive, in the hostdb.
+func (r *Renter) managedRecordAudit(check modules.AuditFailure, err error, lost bool) {
+	r.staticAuditor.managedRecord(check, err, lost)
+	if err != nil && !auditErrProvesDataLoss(err) {
+		return
+	}
+	if err := r.hostDB.RecordAudit(check.HostPubKey, err == nil); err != nil {
+		r.log.Debugln("WARN: unable to record audit in hostdb:", err)
+	}
+}
+
+// managedRemoveLostPiece removes a piece which a host failed to prove that it
+// stores from its file and bubbles the file's health to trigger a repair.
+func (r *Renter) managedRemoveLostPiece(ap auditPiece) (err error) {
+	node, err := r.staticFileSystem.OpenTurtleDexFile(ap.siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, node.Close())
+	}()
+	err = node.RemovePiece(ap.piece.HostPubKey, ap.chunkIndex, ap.pieceIndex, ap.piece.MerkleRoot)
+	if err != nil {
+		return err
+	}
+	dirSiaPath, err := ap.siaPath.Dir()
+	if err != nil {
+		return err
+	}
+	go r.callThreadedBubbleMetadata(dirSiaPath)
+	return nil
+}
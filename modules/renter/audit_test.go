@@ -0,0 +1,107 @@
+package renter
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/turtledex/errors"
+	"github.com/turtledex/fastrand"
+
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+// TestAuditErrProvesDataLoss is a unit test for auditErrProvesDataLoss.
+func TestAuditErrProvesDataLoss(t *testing.T) {
+	tests := []struct {
+		err      error
+		dataLoss bool
+	}{
+		{nil, false},
+		{errors.New("worker unavailable"), false},
+		{errors.New("Read interrupted"), false},
+		{errSectorProofInvalid, true},
+		{errOffsetProofInvalid, true},
+		{errors.AddContext(errSectorProofInvalid, "context"), true},
+		{errors.New("jobReadSector: failed to execute managedRead: " + hostErrSectorNotFound), true},
+	}
+	for i, test := range tests {
+		if auditErrProvesDataLoss(test.err) != test.dataLoss {
+			t.Errorf("%v: expected %v for %v", i, test.dataLoss, test.err)
+		}
+	}
+}
+
+// TestAuditorRecord tests that the auditor keeps track of the results of spot
+// checks.
+func TestAuditorRecord(t *testing.T) {
+	a := newAuditor()
+	host1 := types.TurtleDexPublicKey{Key: fastrand.Bytes(crypto.EntropySize)}
+	host2 := types.TurtleDexPublicKey{Key: fastrand.Bytes(crypto.EntropySize)}
+	check := func(hpk types.TurtleDexPublicKey) modules.AuditFailure {
+		return modules.AuditFailure{
+			Time:       time.Now(),
+			Type:       modules.AuditCheckReadSector,
+			HostPubKey: hpk,
+		}
+	}
+
+	a.managedRecord(check(host1), nil, false)
+	a.managedRecord(check(host1), errSectorProofInvalid, true)
+	a.managedRecord(check(host2), errors.New("timeout"), false)
+	a.managedRecord(check(host2), errOffsetProofInvalid, false)
+
+	status := a.managedStatus()
+	if status.Passed != 1 || status.Failed != 2 || status.Inconclusive != 1 || status.LostPieces != 1 {
+		t.Fatal("wrong totals", status)
+	}
+	if len(status.Hosts) != 2 {
+		t.Fatal("expected 2 hosts but got", len(status.Hosts))
+	}
+	for _, hs := range status.Hosts {
+		var expected string
+		switch hs.HostPubKey.String() {
+		case host1.String():
+			expected = "1 1 0 1"
+		case host2.String():
+			expected = "0 1 1 0"
+		default:
+			t.Fatal("unknown host", hs.HostPubKey)
+		}
+		if s := fmt.Sprint(hs.Passed, hs.Failed, hs.Inconclusive, hs.LostPieces); s != expected {
+			t.Errorf("%v: expected %v but got %v", hs.HostPubKey, expected, s)
+		}
+		if hs.LastFailure.IsZero() {
+			t.Error("last failure should be set")
+		}
+	}
+	if len(status.RecentFailures) != 2 {
+		t.Fatal("expected 2 recent failures but got", len(status.RecentFailures))
+	}
+	if status.RecentFailures[1].Error != errOffsetProofInvalid.Error() {
+		t.Fatal("wrong error", status.RecentFailures[1].Error)
+	}
+
+	// Only the most recent failures are kept.
+	for i := 0; i < auditMaxRecentFailures; i++ {
+		a.managedRecord(check(host1), errSectorProofInvalid, false)
+	}
+	status = a.managedStatus()
+	if len(status.RecentFailures) != auditMaxRecentFailures {
+		t.Fatalf("expected %v recent failures but got %v", auditMaxRecentFailures, len(status.RecentFailures))
+	}
+	if status.RecentFailures[0].HostPubKey.String() != host1.String() {
+		t.Fatal("oldest failures should be dropped")
+	}
+
+	// Triggering an audit doesn't block if one is already pending.
+	a.callTrigger()
+	a.callTrigger()
+	select {
+	case <-a.staticTriggerChan:
+	default:
+		t.Fatal("audit should be triggered")
+	}
+}
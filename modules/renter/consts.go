@@ -190,6 +190,18 @@ const (
 	// maxStuckChunksInHeap is the maximum number of stuck chunks that the stuck
 	// loop will try to keep in the uploadHeap
 	maxStuckChunksInHeap = 25
+
+	// auditSampleSize is the number of randomly chosen pieces the renter spot
+	// checks during every audit.
+	auditSampleSize = 20
+
+	// auditMaxRecentFailures is the number of failed spot checks the renter
+	// keeps in memory to report them via the API.
+	auditMaxRecentFailures = 50
+
+	// syncMaxConflicts is the number of conflicts the renter keeps per sync
+	// folder. Older conflicts are dropped first.
+	syncMaxConflicts = 100
 )
 
 var (
@@ -201,6 +213,126 @@ var (
 		Testing:  5 * time.Second,
 	}).(time.Duration)
 
+	// pruneFileVersionsInterval defines how often the renter prunes the
+	// retained versions of files which are no longer covered by the versioning
+	// policy of their directory.
+	pruneFileVersionsInterval = build.Select(build.Var{
+		Dev:      10 * time.Minute,
+		Standard: 1 * time.Hour,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+
+	// dedupSaveInterval defines how often the changes to the dedup index are
+	// persisted.
+	dedupSaveInterval = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: 2 * time.Minute,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// reencodeFilesInterval defines how often the repair loop checks for files
+	// which need to be re-encoded to match the redundancy policy of their
+	// directory.
+	reencodeFilesInterval = build.Select(build.Var{
+		Dev:      10 * time.Minute,
+		Standard: 1 * time.Hour,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+
+	// migrationScanInterval defines how often the repair loop scans the files
+	// for chunks with pieces on hosts the contractor migrates data away from.
+	migrationScanInterval = build.Select(build.Var{
+		Dev:      10 * time.Minute,
+		Standard: 1 * time.Hour,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+
+	// auditInterval defines how often the renter spot checks a random sample
+	// of the pieces stored on its hosts.
+	auditInterval = build.Select(build.Var{
+		Dev:      10 * time.Minute,
+		Standard: 6 * time.Hour,
+		Testing:  time.Minute,
+	}).(time.Duration)
+
+	// auditTimeout is the maximum amount of time a single spot check may take
+	// before it is considered inconclusive.
+	auditTimeout = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: 2 * time.Minute,
+		Testing:  10 * time.Second,
+	}).(time.Duration)
+
+	// syncPollInterval defines how often sync folders are scanned for changes
+	// when they can't be watched for filesystem events. Watched folders are
+	// scanned this often as well to catch events which were missed.
+	syncPollInterval = build.Select(build.Var{
+		Dev:      30 * time.Second,
+		Standard: 5 * time.Minute,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+
+	// syncEventDelay is the amount of time a watched sync folder needs to be
+	// free of filesystem events before it is scanned. This batches the events
+	// of a file which is being written.
+	syncEventDelay = build.Select(build.Var{
+		Dev:      time.Second,
+		Standard: 2 * time.Second,
+		Testing:  100 * time.Millisecond,
+	}).(time.Duration)
+
+	// syncSettleTime is the minimum age of the last modification of a local
+	// file before it is synced. Younger files are likely still being written
+	// and are synced by a later scan.
+	syncSettleTime = build.Select(build.Var{
+		Dev:      2 * time.Second,
+		Standard: 10 * time.Second,
+		Testing:  100 * time.Millisecond,
+	}).(time.Duration)
+
+	// pinCheckInterval defines how often the renter checks the health of the
+	// skylinks it keeps pinned.
+	pinCheckInterval = build.Select(build.Var{
+		Dev:      5 * time.Minute,
+		Standard: time.Hour,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+
+	// pinRepinCooldown is the minimum amount of time between two attempts to
+	// pin the same skylink.
+	pinRepinCooldown = build.Select(build.Var{
+		Dev:      10 * time.Minute,
+		Standard: 6 * time.Hour,
+		Testing:  10 * time.Second,
+	}).(time.Duration)
+
+	// pinTimeout is the maximum amount of time a health check of a pinned
+	// skylink may take and the timeout for fetching its base sector when
+	// pinning it.
+	pinTimeout = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: 2 * time.Minute,
+		Testing:  10 * time.Second,
+	}).(time.Duration)
+
+	// resumableUploadExpiry is the amount of time after the last write after
+	// which an incomplete resumable skyfile upload is deleted. Completed
+	// uploads are kept equally long to allow clients to fetch their skylink.
+	resumableUploadExpiry = build.Select(build.Var{
+		Dev:      time.Hour,
+		Standard: 24 * time.Hour,
+		Testing:  time.Minute,
+	}).(time.Duration)
+
+	// resumableUploadMaxStaged is the maximum number of bytes all incomplete
+	// resumable skyfile uploads combined may stage on disk. The full length
+	// of an upload is reserved when it is created.
+	resumableUploadMaxStaged = build.Select(build.Var{
+		Dev:      uint64(1 << 32), // 4 GiB
+		Standard: uint64(1 << 36), // 64 GiB
+		Testing:  uint64(1 << 22), // 4 MiB
+	}).(uint64)
+
 	// healthLoopErrorSleepDuration indicates how long the health loop should
 	// sleep before retrying if there is an error preventing progress.
 	healthLoopErrorSleepDuration = build.Select(build.Var{
@@ -198,17 +198,23 @@ func (cl *churnLimiter) managedCanChurnContract(contract modules.RenterContract)
 	maxChurnBudget := cl.managedMaxChurnBudget()
 	cl.mu.Lock()
 	defer cl.mu.Unlock()
+	return canChurn(size, cl.remainingChurnBudget, cl.aggregateCurrentPeriodChurn, maxPeriodChurn, maxChurnBudget)
+}
 
+// canChurn returns true if and only if a contract of the given size can be
+// churned given the remaining churn budget and the aggregate churn of the
+// current period.
+func canChurn(size uint64, remainingChurnBudget int, aggregateChurn, maxPeriodChurn uint64, maxChurnBudget int) bool {
 	// Allow any size contract to be churned if the current budget is the max
 	// budget. This allows large contracts to be churned if there is enough budget
 	// remaining for the period, even if the contract is larger than the
 	// maxChurnBudget.
-	fitsInCurrentBudget := (cl.remainingChurnBudget-int(size) >= 0) || (cl.remainingChurnBudget == maxChurnBudget)
-	fitsInPeriodBudget := (int(maxPeriodChurn) - int(cl.aggregateCurrentPeriodChurn) - int(size)) >= 0
+	fitsInCurrentBudget := (remainingChurnBudget-int(size) >= 0) || (remainingChurnBudget == maxChurnBudget)
+	fitsInPeriodBudget := (int(maxPeriodChurn) - int(aggregateChurn) - int(size)) >= 0
 
 	// If there has been no churn in this period, allow any size contract to be
 	// churned.
-	fitsInPeriodBudget = fitsInPeriodBudget || (cl.aggregateCurrentPeriodChurn == 0)
+	fitsInPeriodBudget = fitsInPeriodBudget || (aggregateChurn == 0)
 
 	return fitsInPeriodBudget && fitsInCurrentBudget
 }
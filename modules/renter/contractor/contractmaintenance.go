@@ -272,18 +272,25 @@ func (c *Contractor) callInterruptContractMaintenance() {
 // managedFindMinAllowedHostScores uses a set of random hosts from the hostdb to
 // calculate minimum acceptable score for a host to be marked GFR and GFU.
 func (c *Contractor) managedFindMinAllowedHostScores() (types.Currency, types.Currency, error) {
+	c.mu.RLock()
+	hostCount := int(c.allowance.Hosts)
+	c.mu.RUnlock()
+
 	// Pull a new set of hosts from the hostdb that could be used as a new set
 	// to match the allowance. The lowest scoring host of these new hosts will
 	// be used as a baseline for determining whether our existing contracts are
 	// worthwhile.
-	c.mu.RLock()
-	hostCount := int(c.allowance.Hosts)
-	c.mu.RUnlock()
 	hosts, err := c.hdb.RandomHosts(hostCount+randomHostsBufferForScore, nil, nil)
 	if err != nil {
 		return types.Currency{}, types.Currency{}, err
 	}
+	return c.managedMinAllowedHostScores(hosts, c.hdb.ScoreBreakdown)
+}
 
+// managedMinAllowedHostScores calculates the minimum acceptable scores for a
+// host to be marked GFR and GFU from a set of hosts that could be used instead
+// of the current hosts. The hosts are scored using scoreBreakdown.
+func (c *Contractor) managedMinAllowedHostScores(hosts []modules.HostDBEntry, scoreBreakdown func(modules.HostDBEntry) (modules.HostScoreBreakdown, error)) (types.Currency, types.Currency, error) {
 	if len(hosts) == 0 {
 		return types.Currency{}, types.Currency{}, errors.New("No hosts returned in RandomHosts")
 	}
@@ -291,14 +298,14 @@ func (c *Contractor) managedFindMinAllowedHostScores() (types.Currency, types.Cu
 	// Find the minimum score that a host is allowed to have to be considered
 	// good for upload.
 	var minScoreGFR, minScoreGFU types.Currency
-	sb, err := c.hdb.ScoreBreakdown(hosts[0])
+	sb, err := scoreBreakdown(hosts[0])
 	if err != nil {
 		return types.Currency{}, types.Currency{}, err
 	}
 
 	lowestScore := sb.Score
 	for i := 1; i < len(hosts); i++ {
-		score, err := c.hdb.ScoreBreakdown(hosts[i])
+		score, err := scoreBreakdown(hosts[i])
 		if err != nil {
 			return types.Currency{}, types.Currency{}, err
 		}
@@ -314,7 +321,7 @@ func (c *Contractor) managedFindMinAllowedHostScores() (types.Currency, types.Cu
 	if c.staticDeps.Disrupt("HighMinHostScore") {
 		var maxScore types.Currency
 		for i := 1; i < len(hosts); i++ {
-			score, err := c.hdb.ScoreBreakdown(hosts[i])
+			score, err := scoreBreakdown(hosts[i])
 			if err != nil {
 				return types.Currency{}, types.Currency{}, err
 			}
@@ -978,6 +985,105 @@ func (c *Contractor) callUpdateUtility(safeContract *proto.SafeContract, newUtil
 	return safeContract.UpdateUtility(newUtility)
 }
 
+// managedRenewAndRefreshSets returns the contracts which need to be renewed
+// because they are about to expire and the contracts which need to be renewed
+// because they are out of money, paired with the amount of money to use in each
+// renewal. The utility of the contracts is looked up using contractUtility.
+func (c *Contractor) managedRenewAndRefreshSets(allowance modules.Allowance, blockHeight types.BlockHeight, contractUtility func(types.FileContractID) (modules.ContractUtility, bool)) (renewSet, refreshSet []fileContractRenewal) {
+	// Iterate through the contracts, figuring out which contracts to
+	// renew and how much extra funds to renew them with.
+	for _, contract := range c.staticContracts.ViewAll() {
+		c.log.Debugln("Examining a contract:", contract.HostPublicKey, contract.ID)
+		// Skip any host that does not match our whitelist/blacklist filter
+		// settings.
+		host, _, err := c.hdb.Host(contract.HostPublicKey)
+		if err != nil {
+			c.log.Println("WARN: error getting host", err)
+			continue
+		}
+		if host.Filtered {
+			c.log.Debugln("Contract skipped because it is filtered")
+			continue
+		}
+		// Skip hosts that can't use the current renter-host protocol.
+		if build.VersionCmp(host.Version, modules.MinimumSupportedRenterHostProtocolVersion) < 0 {
+			c.log.Debugln("Contract skipped because host is using an outdated version", host.Version)
+			continue
+		}
+
+		// Skip any contracts which do not exist or are otherwise unworthy for
+		// renewal.
+		utility, ok := contractUtility(contract.ID)
+		if !ok || !utility.GoodForRenew {
+			if blockHeight-contract.StartHeight < types.BlocksPerWeek {
+				c.log.Debugln("Contract did not last 1 week and is not being renewed", contract.ID)
+			}
+			c.log.Debugln("Contract skipped because it is not good for renew (utility.GoodForRenew, exists)", utility.GoodForRenew, ok)
+			continue
+		}
+
+		// If the contract needs to be renewed because it is about to expire,
+		// calculate a spending for the contract that is proportional to how
+		// much money was spend on the contract throughout this billing cycle
+		// (which is now ending).
+		if blockHeight+allowance.RenewWindow >= contract.EndHeight && !c.staticDeps.Disrupt("disableRenew") {
+			renewAmount, err := c.managedEstimateRenewFundingRequirements(contract, blockHeight, allowance)
+			if err != nil {
+				c.log.Debugln("Contract skipped because there was an error estimating renew funding requirements", renewAmount, err)
+				continue
+			}
+			renewSet = append(renewSet, fileContractRenewal{
+				id:         contract.ID,
+				amount:     renewAmount,
+				hostPubKey: contract.HostPublicKey,
+			})
+			c.log.Debugln("Contract has been added to the renew set for being past the renew height")
+			continue
+		}
+
+		// Check if the contract is empty. We define a contract as being empty
+		// if less than 'minContractFundRenewalThreshold' funds are remaining
+		// (3% at time of writing), or if there is less than 3 sectors worth of
+		// storage+upload+download remaining.
+		blockBytes := types.NewCurrency64(modules.SectorSize * uint64(allowance.Period))
+		sectorStoragePrice := host.StoragePrice.Mul(blockBytes)
+		sectorUploadBandwidthPrice := host.UploadBandwidthPrice.Mul64(modules.SectorSize)
+		sectorDownloadBandwidthPrice := host.DownloadBandwidthPrice.Mul64(modules.SectorSize)
+		sectorBandwidthPrice := sectorUploadBandwidthPrice.Add(sectorDownloadBandwidthPrice)
+		sectorPrice := sectorStoragePrice.Add(sectorBandwidthPrice)
+		percentRemaining, _ := big.NewRat(0, 1).SetFrac(contract.RenterFunds.Big(), contract.TotalCost.Big()).Float64()
+		lowFundsRefresh := c.staticDeps.Disrupt("LowFundsRefresh")
+		if lowFundsRefresh || ((contract.RenterFunds.Cmp(sectorPrice.Mul64(3)) < 0 || percentRemaining < MinContractFundRenewalThreshold) && !c.staticDeps.Disrupt("disableRenew")) {
+			// Renew the contract with double the amount of funds that the
+			// contract had previously. The reason that we double the funding
+			// instead of doing anything more clever is that we don't know what
+			// the usage pattern has been. The spending could have all occurred
+			// in one burst recently, and the user might need a contract that
+			// has substantially more money in it.
+			//
+			// We double so that heavily used contracts can grow in funding
+			// quickly without consuming too many transaction fees, however this
+			// does mean that a larger percentage of funds get locked away from
+			// the user in the event that the user stops uploading immediately
+			// after the renew.
+			refreshAmount := contract.TotalCost.Mul64(2)
+			minimum := allowance.Funds.MulFloat(fileContractMinimumFunding).Div64(allowance.Hosts)
+			if refreshAmount.Cmp(minimum) < 0 {
+				refreshAmount = minimum
+			}
+			refreshSet = append(refreshSet, fileContractRenewal{
+				id:         contract.ID,
+				amount:     refreshAmount,
+				hostPubKey: contract.HostPublicKey,
+			})
+			c.log.Debugln("Contract identified as needing to be added to refresh set", contract.RenterFunds, sectorPrice.Mul64(3), percentRemaining, MinContractFundRenewalThreshold)
+		} else {
+			c.log.Debugln("Contract did not get added to the refresh set", contract.RenterFunds, sectorPrice.Mul64(3), percentRemaining, MinContractFundRenewalThreshold)
+		}
+	}
+	return renewSet, refreshSet
+}
+
 // threadedContractMaintenance checks the set of contracts that the contractor
 // has against the allownace, renewing any contracts that need to be renewed,
 // dropping contracts which are no longer worthwhile, and adding contracts if
@@ -1037,6 +1143,10 @@ func (c *Contractor) threadedContractMaintenance() {
 		c.log.Debugln("Unable to mark contract utilities:", err)
 		return
 	}
+	err = c.managedRebalanceContracts()
+	if err != nil {
+		c.log.Println("Unable to rebalance contracts:", err)
+	}
 	err = c.hdb.UpdateContracts(c.staticContracts.ViewAll())
 	if err != nil {
 		c.log.Println("Unable to update hostdb contracts:", err)
@@ -1075,100 +1185,7 @@ func (c *Contractor) threadedContractMaintenance() {
 	// in the refreshSet. If the wallet does not have enough money, or if the
 	// allowance does not have enough money, the contractor will prefer to save
 	// data in the long term rather than renew a contract.
-	var renewSet []fileContractRenewal
-	var refreshSet []fileContractRenewal
-
-	// Iterate through the contracts again, figuring out which contracts to
-	// renew and how much extra funds to renew them with.
-	for _, contract := range c.staticContracts.ViewAll() {
-		c.log.Debugln("Examining a contract:", contract.HostPublicKey, contract.ID)
-		// Skip any host that does not match our whitelist/blacklist filter
-		// settings.
-		host, _, err := c.hdb.Host(contract.HostPublicKey)
-		if err != nil {
-			c.log.Println("WARN: error getting host", err)
-			continue
-		}
-		if host.Filtered {
-			c.log.Debugln("Contract skipped because it is filtered")
-			continue
-		}
-		// Skip hosts that can't use the current renter-host protocol.
-		if build.VersionCmp(host.Version, modules.MinimumSupportedRenterHostProtocolVersion) < 0 {
-			c.log.Debugln("Contract skipped because host is using an outdated version", host.Version)
-			continue
-		}
-
-		// Skip any contracts which do not exist or are otherwise unworthy for
-		// renewal.
-		utility, ok := c.managedContractUtility(contract.ID)
-		if !ok || !utility.GoodForRenew {
-			if blockHeight-contract.StartHeight < types.BlocksPerWeek {
-				c.log.Debugln("Contract did not last 1 week and is not being renewed", contract.ID)
-			}
-			c.log.Debugln("Contract skipped because it is not good for renew (utility.GoodForRenew, exists)", utility.GoodForRenew, ok)
-			continue
-		}
-
-		// If the contract needs to be renewed because it is about to expire,
-		// calculate a spending for the contract that is proportional to how
-		// much money was spend on the contract throughout this billing cycle
-		// (which is now ending).
-		if blockHeight+allowance.RenewWindow >= contract.EndHeight && !c.staticDeps.Disrupt("disableRenew") {
-			renewAmount, err := c.managedEstimateRenewFundingRequirements(contract, blockHeight, allowance)
-			if err != nil {
-				c.log.Debugln("Contract skipped because there was an error estimating renew funding requirements", renewAmount, err)
-				continue
-			}
-			renewSet = append(renewSet, fileContractRenewal{
-				id:         contract.ID,
-				amount:     renewAmount,
-				hostPubKey: contract.HostPublicKey,
-			})
-			c.log.Debugln("Contract has been added to the renew set for being past the renew height")
-			continue
-		}
-
-		// Check if the contract is empty. We define a contract as being empty
-		// if less than 'minContractFundRenewalThreshold' funds are remaining
-		// (3% at time of writing), or if there is less than 3 sectors worth of
-		// storage+upload+download remaining.
-		blockBytes := types.NewCurrency64(modules.SectorSize * uint64(allowance.Period))
-		sectorStoragePrice := host.StoragePrice.Mul(blockBytes)
-		sectorUploadBandwidthPrice := host.UploadBandwidthPrice.Mul64(modules.SectorSize)
-		sectorDownloadBandwidthPrice := host.DownloadBandwidthPrice.Mul64(modules.SectorSize)
-		sectorBandwidthPrice := sectorUploadBandwidthPrice.Add(sectorDownloadBandwidthPrice)
-		sectorPrice := sectorStoragePrice.Add(sectorBandwidthPrice)
-		percentRemaining, _ := big.NewRat(0, 1).SetFrac(contract.RenterFunds.Big(), contract.TotalCost.Big()).Float64()
-		lowFundsRefresh := c.staticDeps.Disrupt("LowFundsRefresh")
-		if lowFundsRefresh || ((contract.RenterFunds.Cmp(sectorPrice.Mul64(3)) < 0 || percentRemaining < MinContractFundRenewalThreshold) && !c.staticDeps.Disrupt("disableRenew")) {
-			// Renew the contract with double the amount of funds that the
-			// contract had previously. The reason that we double the funding
-			// instead of doing anything more clever is that we don't know what
-			// the usage pattern has been. The spending could have all occurred
-			// in one burst recently, and the user might need a contract that
-			// has substantially more money in it.
-			//
-			// We double so that heavily used contracts can grow in funding
-			// quickly without consuming too many transaction fees, however this
-			// does mean that a larger percentage of funds get locked away from
-			// the user in the event that the user stops uploading immediately
-			// after the renew.
-			refreshAmount := contract.TotalCost.Mul64(2)
-			minimum := allowance.Funds.MulFloat(fileContractMinimumFunding).Div64(allowance.Hosts)
-			if refreshAmount.Cmp(minimum) < 0 {
-				refreshAmount = minimum
-			}
-			refreshSet = append(refreshSet, fileContractRenewal{
-				id:         contract.ID,
-				amount:     refreshAmount,
-				hostPubKey: contract.HostPublicKey,
-			})
-			c.log.Debugln("Contract identified as needing to be added to refresh set", contract.RenterFunds, sectorPrice.Mul64(3), percentRemaining, MinContractFundRenewalThreshold)
-		} else {
-			c.log.Debugln("Contract did not get added to the refresh set", contract.RenterFunds, sectorPrice.Mul64(3), percentRemaining, MinContractFundRenewalThreshold)
-		}
-	}
+	renewSet, refreshSet := c.managedRenewAndRefreshSets(allowance, blockHeight, c.managedContractUtility)
 	if len(renewSet) != 0 || len(refreshSet) != 0 {
 		c.log.Printf("renewing %v contracts and refreshing %v contracts", len(renewSet), len(refreshSet))
 	}
@@ -107,6 +107,7 @@ type Contractor struct {
 	renewedTo            map[types.FileContractID]types.FileContractID
 
 	staticChurnLimiter *churnLimiter
+	staticRebalancer   *rebalancer
 	staticWatchdog     *watchdog
 }
 
@@ -476,6 +477,7 @@ func contractorBlockingStartup(cs modules.ConsensusSet, w modules.Wallet, tp mod
 		workerPool:           emptyWorkerPool{},
 	}
 	c.staticChurnLimiter = newChurnLimiter(c)
+	c.staticRebalancer = newRebalancer()
 	c.staticWatchdog = newWatchdog(c)
 
 	// Close the contract set and logger upon shutdown.
@@ -677,3 +679,13 @@ func (c *Contractor) RenewContract(conn net.Conn, fcid types.FileContractID, par
 	c.mu.Unlock()
 	return newContract, txnSet, nil
 }
+
+// UpdateSector takes an established stream to a host and overwrites a range of
+// bytes within a sector of the contract with that host.
+func (c *Contractor) UpdateSector(stream io.ReadWriter, fcid types.FileContractID, pt *modules.RPCPriceTable, pp modules.PaymentProvider, refundAccount modules.AccountID, sectorIndex, offset uint64, data []byte) (modules.RenterContract, types.Currency, error) {
+	contract, cost, err := c.staticContracts.UpdateSector(stream, fcid, pt, pp, refundAccount, sectorIndex, offset, data)
+	if err != nil {
+		return modules.RenterContract{}, cost, errors.AddContext(err, "UpdateSector: failed to update sector")
+	}
+	return contract, cost, nil
+}
@@ -58,8 +58,10 @@ type (
 		IncrementFailedInteractions(key types.TurtleDexPublicKey) error
 		InitialScanComplete() (complete bool, err error)
 		RandomHosts(n int, blacklist, addressBlacklist []types.TurtleDexPublicKey) ([]modules.HostDBEntry, error)
+		RandomHostsWithAllowance(n int, blacklist, addressBlacklist []types.TurtleDexPublicKey, allowance modules.Allowance) ([]modules.HostDBEntry, error)
 		UpdateContracts([]modules.RenterContract) error
 		ScoreBreakdown(modules.HostDBEntry) (modules.HostScoreBreakdown, error)
+		ScoreBreakdownWithAllowance(modules.HostDBEntry, modules.Allowance) (modules.HostScoreBreakdown, error)
 		SetAllowance(allowance modules.Allowance) error
 	}
 
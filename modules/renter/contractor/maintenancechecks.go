@@ -56,9 +56,41 @@ func (c *Contractor) renewedCheck(u modules.ContractUtility, renewed bool) (modu
 // managedCheckHostScore checks host scorebreakdown against minimum accepted
 // scores.  forceUpdate is true if the utility change must be taken.
 func (c *Contractor) managedCheckHostScore(contract modules.RenterContract, sb modules.HostScoreBreakdown, minScoreGFR, minScoreGFU types.Currency) (modules.ContractUtility, utilityUpdateStatus) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	c.mu.RLock()
+	allowance := c.allowance
+	c.mu.RUnlock()
+
+	u, status := c.hostScoreCheck(contract, sb, allowance, minScoreGFR, minScoreGFU)
+	if status == noUpdate {
+		return u, status
+	}
+
+	// Log if the utility has changed.
+	old := contract.Utility
+	if !u.GoodForRenew {
+		if old.GoodForUpload || old.GoodForRenew {
+			c.log.Printf("Marking contract as having no utility because of host score: %v", contract.ID)
+			c.logScoreBreakdown(minScoreGFR, sb)
+		}
+		if status == suggestedUtilityUpdate {
+			c.log.Println("Adding contract utility update to churnLimiter queue")
+		}
+		return u, status
+	}
+	if old.GoodForUpload {
+		c.log.Printf("Marking contract as not good for upload because of a poor score: %v", contract.ID)
+		c.logScoreBreakdown(minScoreGFU, sb)
+	}
+	if !old.GoodForRenew {
+		c.log.Println("Marking contract as being good for renew", contract.ID)
+	}
+	return u, status
+}
 
+// hostScoreCheck checks host scorebreakdown against minimum accepted scores
+// using the provided allowance. It neither logs nor accesses the contractor's
+// state which allows for using it to plan utility updates.
+func (c *Contractor) hostScoreCheck(contract modules.RenterContract, sb modules.HostScoreBreakdown, allowance modules.Allowance, minScoreGFR, minScoreGFU types.Currency) (modules.ContractUtility, utilityUpdateStatus) {
 	u := contract.Utility
 
 	// Check whether the contract is a payment contract. Payment contracts
@@ -67,29 +99,13 @@ func (c *Contractor) managedCheckHostScore(contract modules.RenterContract, sb m
 	if len(contract.Transaction.FileContractRevisions) > 0 {
 		size = contract.Transaction.FileContractRevisions[0].NewFileSize
 	}
-	paymentContract := !c.allowance.PaymentContractInitialFunding.IsZero() && size == 0
+	paymentContract := !allowance.PaymentContractInitialFunding.IsZero() && size == 0
 
 	// Contract has no utility if the score is poor. Cannot be marked as bad if
 	// the contract is a payment contract.
 	deadScore := sb.Score.Cmp(types.NewCurrency64(1)) <= 0
 	badScore := !minScoreGFR.IsZero() && sb.Score.Cmp(minScoreGFR) < 0
 	if deadScore || (badScore && !paymentContract) {
-		// Log if the utility has changed.
-		if u.GoodForUpload || u.GoodForRenew {
-			c.log.Printf("Marking contract as having no utility because of host score: %v", contract.ID)
-			c.log.Println("Min Score:", minScoreGFR)
-			c.log.Println("Score:    ", sb.Score)
-			c.log.Println("Age Adjustment:        ", sb.AgeAdjustment)
-			c.log.Println("Base Price Adjustment: ", sb.BasePriceAdjustment)
-			c.log.Println("Burn Adjustment:       ", sb.BurnAdjustment)
-			c.log.Println("Collateral Adjustment: ", sb.CollateralAdjustment)
-			c.log.Println("Duration Adjustment:   ", sb.DurationAdjustment)
-			c.log.Println("Interaction Adjustment:", sb.InteractionAdjustment)
-			c.log.Println("Price Adjustment:      ", sb.PriceAdjustment)
-			c.log.Println("Storage Adjustment:    ", sb.StorageRemainingAdjustment)
-			c.log.Println("Uptime Adjustment:     ", sb.UptimeAdjustment)
-			c.log.Println("Version Adjustment:    ", sb.VersionAdjustment)
-		}
 		u.GoodForUpload = false
 		u.GoodForRenew = false
 
@@ -99,30 +115,11 @@ func (c *Contractor) managedCheckHostScore(contract modules.RenterContract, sb m
 		if deadScore {
 			return u, necessaryUtilityUpdate
 		}
-		c.log.Println("Adding contract utility update to churnLimiter queue")
 		return u, suggestedUtilityUpdate
 	}
 
 	// Contract should not be used for uplodaing if the score is poor.
 	if !minScoreGFU.IsZero() && sb.Score.Cmp(minScoreGFU) < 0 {
-		if u.GoodForUpload {
-			c.log.Printf("Marking contract as not good for upload because of a poor score: %v", contract.ID)
-			c.log.Println("Min Score:", minScoreGFU)
-			c.log.Println("Score:    ", sb.Score)
-			c.log.Println("Age Adjustment:        ", sb.AgeAdjustment)
-			c.log.Println("Base Price Adjustment: ", sb.BasePriceAdjustment)
-			c.log.Println("Burn Adjustment:       ", sb.BurnAdjustment)
-			c.log.Println("Collateral Adjustment: ", sb.CollateralAdjustment)
-			c.log.Println("Duration Adjustment:   ", sb.DurationAdjustment)
-			c.log.Println("Interaction Adjustment:", sb.InteractionAdjustment)
-			c.log.Println("Price Adjustment:      ", sb.PriceAdjustment)
-			c.log.Println("Storage Adjustment:    ", sb.StorageRemainingAdjustment)
-			c.log.Println("Uptime Adjustment:     ", sb.UptimeAdjustment)
-			c.log.Println("Version Adjustment:    ", sb.VersionAdjustment)
-		}
-		if !u.GoodForRenew {
-			c.log.Println("Marking contract as being good for renew", contract.ID)
-		}
 		u.GoodForUpload = false
 		u.GoodForRenew = true
 		return u, necessaryUtilityUpdate
@@ -131,6 +128,23 @@ func (c *Contractor) managedCheckHostScore(contract modules.RenterContract, sb m
 	return u, noUpdate
 }
 
+// logScoreBreakdown logs the score breakdown of a host whose contract's
+// utility is changed because of its score.
+func (c *Contractor) logScoreBreakdown(minScore types.Currency, sb modules.HostScoreBreakdown) {
+	c.log.Println("Min Score:", minScore)
+	c.log.Println("Score:    ", sb.Score)
+	c.log.Println("Age Adjustment:        ", sb.AgeAdjustment)
+	c.log.Println("Base Price Adjustment: ", sb.BasePriceAdjustment)
+	c.log.Println("Burn Adjustment:       ", sb.BurnAdjustment)
+	c.log.Println("Collateral Adjustment: ", sb.CollateralAdjustment)
+	c.log.Println("Duration Adjustment:   ", sb.DurationAdjustment)
+	c.log.Println("Interaction Adjustment:", sb.InteractionAdjustment)
+	c.log.Println("Price Adjustment:      ", sb.PriceAdjustment)
+	c.log.Println("Storage Adjustment:    ", sb.StorageRemainingAdjustment)
+	c.log.Println("Uptime Adjustment:     ", sb.UptimeAdjustment)
+	c.log.Println("Version Adjustment:    ", sb.VersionAdjustment)
+}
+
 // managedCriticalUtilityChecks performs critical checks on a contract that
 // would require, with no exceptions, marking the contract as !GFR and/or !GFU.
 // Returns true if and only if and of the checks passed and require the utility
@@ -150,58 +164,99 @@ func (c *Contractor) managedCriticalUtilityChecks(sc *proto.SafeContract, host m
 	_, renewed := c.renewedTo[contract.ID]
 	c.mu.RUnlock()
 
+	u, reason, needsUpdate := c.criticalUtilityChecks(contract, sc.LastRevision().NewRevisionNumber, renewed, host, renewWindow, period, blockHeight)
+	if needsUpdate {
+		c.logUtilityUpdate(contract, u, reason)
+	}
+	return u, needsUpdate
+}
+
+// logUtilityUpdate logs the changes of a contract's utility caused by a failed
+// check.
+func (c *Contractor) logUtilityUpdate(contract modules.RenterContract, u modules.ContractUtility, reason string) {
+	old := contract.Utility
+	if !u.GoodForUpload && !u.GoodForRenew {
+		if old.GoodForUpload || old.GoodForRenew {
+			c.log.Printf("Marking contract as having no utility because %v: %v", reason, contract.ID)
+		}
+		return
+	}
+	if old.GoodForUpload && !u.GoodForUpload {
+		c.log.Printf("Marking contract as not good for upload because %v: %v", reason, contract.ID)
+	}
+	if !old.GoodForRenew && u.GoodForRenew {
+		c.log.Println("Marking contract as being good for renew:", contract.ID)
+	}
+}
+
+// criticalUtilityChecks performs the checks of managedCriticalUtilityChecks on
+// a contract with the given revision number. It also returns the reason of the
+// failed check. It doesn't log which allows for using it to plan utility
+// updates.
+func (c *Contractor) criticalUtilityChecks(contract modules.RenterContract, revisionNumber uint64, renewed bool, host modules.HostDBEntry, renewWindow, period, blockHeight types.BlockHeight) (modules.ContractUtility, string, bool) {
 	// A contract that has been renewed should be set to !GFU and !GFR.
 	u, needsUpdate := c.renewedCheck(contract.Utility, renewed)
 	if needsUpdate {
-		return u, needsUpdate
+		return u, "contract was renewed", needsUpdate
 	}
 
-	u, needsUpdate = c.maxRevisionCheck(contract.Utility, sc.LastRevision().NewRevisionNumber)
+	u, needsUpdate = c.maxRevisionCheck(contract.Utility, revisionNumber)
 	if needsUpdate {
-		return u, needsUpdate
+		return u, "contract reached its maximum revision", needsUpdate
 	}
 
 	u, needsUpdate = c.badContractCheck(contract.Utility)
 	if needsUpdate {
-		return u, needsUpdate
+		return u, "contract was marked as bad", needsUpdate
+	}
+
+	u, needsUpdate = c.migrationCheck(contract)
+	if needsUpdate {
+		return u, "data is migrated to other hosts", needsUpdate
 	}
 
 	u, needsUpdate = c.offlineCheck(contract, host)
 	if needsUpdate {
-		return u, needsUpdate
+		return u, "host is offline", needsUpdate
 	}
 
 	u, needsUpdate = c.upForRenewalCheck(contract, renewWindow, blockHeight)
 	if needsUpdate {
-		return u, needsUpdate
+		return u, "contract is up for renewal", needsUpdate
 	}
 
 	u, needsUpdate = c.sufficientFundsCheck(contract, host, period)
 	if needsUpdate {
-		return u, needsUpdate
+		return u, "contract has insufficient funds", needsUpdate
 	}
 
 	u, needsUpdate = c.outOfStorageCheck(contract, blockHeight)
 	if needsUpdate {
-		return u, needsUpdate
+		return u, "host is out of storage", needsUpdate
 	}
 
-	return contract.Utility, false
+	return contract.Utility, "", false
 }
 
 // managedHostInHostDBCheck checks if the host is in the hostdb and not
 // filtered.  Returns true if a check fails and the utility returned must be
 // used to update the contract state.
 func (c *Contractor) managedHostInHostDBCheck(contract modules.RenterContract) (modules.HostDBEntry, modules.ContractUtility, bool) {
+	host, u, needsUpdate := c.managedHostInHostDBUtility(contract)
+	if needsUpdate {
+		c.logUtilityUpdate(contract, u, "its host is not in the hostDB or is filtered")
+	}
+	return host, u, needsUpdate
+}
+
+// managedHostInHostDBUtility performs the check of managedHostInHostDBCheck
+// without logging.
+func (c *Contractor) managedHostInHostDBUtility(contract modules.RenterContract) (modules.HostDBEntry, modules.ContractUtility, bool) {
 	u := contract.Utility
 	host, exists, err := c.hdb.Host(contract.HostPublicKey)
 	// Contract has no utility if the host is not in the database. Or is
 	// filtered by the blacklist or whitelist. Or if there was an error
 	if !exists || host.Filtered || err != nil {
-		// Log if the utility has changed.
-		if u.GoodForUpload || u.GoodForRenew {
-			c.log.Printf("Marking contract as having no utility because found in hostDB: %v, or host is Filtered: %v - %v", exists, host.Filtered, contract.ID)
-		}
 		u.GoodForUpload = false
 		u.GoodForRenew = false
 		return host, u, true
@@ -222,10 +277,6 @@ func (c *Contractor) offlineCheck(contract modules.RenterContract, host modules.
 	u := contract.Utility
 	// Contract has no utility if the host is offline.
 	if isOffline(host) {
-		// Log if the utility has changed.
-		if u.GoodForUpload || u.GoodForRenew {
-			c.log.Println("Marking contract as having no utility because of host being offline", contract.ID)
-		}
 		u.GoodForUpload = false
 		u.GoodForRenew = false
 		return u, true
@@ -241,12 +292,6 @@ func (c *Contractor) upForRenewalCheck(contract modules.RenterContract, renewWin
 	// Contract should not be used for uploading if the time has come to
 	// renew the contract.
 	if blockHeight+renewWindow >= contract.EndHeight {
-		if u.GoodForUpload {
-			c.log.Println("Marking contract as not good for upload because it is time to renew the contract", contract.ID)
-		}
-		if !u.GoodForRenew {
-			c.log.Println("Marking contract as being good for renew:", contract.ID)
-		}
 		u.GoodForUpload = false
 		u.GoodForRenew = true
 		return u, true
@@ -271,12 +316,6 @@ func (c *Contractor) sufficientFundsCheck(contract modules.RenterContract, host
 	sectorPrice := sectorStoragePrice.Add(sectorBandwidthPrice)
 	percentRemaining, _ := big.NewRat(0, 1).SetFrac(contract.RenterFunds.Big(), contract.TotalCost.Big()).Float64()
 	if contract.RenterFunds.Cmp(sectorPrice.Mul64(3)) < 0 || percentRemaining < MinContractFundUploadThreshold {
-		if u.GoodForUpload {
-			c.log.Printf("Marking contract as not good for upload because of insufficient funds: %v vs. %v - %v", contract.RenterFunds.Cmp(sectorPrice.Mul64(3)) < 0, percentRemaining, contract.ID)
-		}
-		if !u.GoodForRenew {
-			c.log.Println("Marking contract as being good for renew:", contract.ID)
-		}
 		u.GoodForUpload = false
 		u.GoodForRenew = true
 		return u, true
@@ -295,12 +334,6 @@ func (c *Contractor) outOfStorageCheck(contract modules.RenterContract, blockHei
 	}
 	// Contract should not be used for uploading if the host is out of storage.
 	if blockHeight-u.LastOOSErr <= oosRetryInterval {
-		if u.GoodForUpload {
-			c.log.Println("Marking contract as not being good for upload due to the host running out of storage:", contract.ID)
-		}
-		if !u.GoodForRenew {
-			c.log.Println("Marking contract as being good for renew:", contract.ID)
-		}
 		u.GoodForUpload = false
 		u.GoodForRenew = true
 		return u, true
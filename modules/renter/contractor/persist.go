@@ -41,6 +41,7 @@ type contractorPersist struct {
 
 	// Subsystem persistence:
 	ChurnLimiter churnLimiterPersist `json:"churnlimiter"`
+	Rebalancer   rebalancerPersist   `json:"rebalancer"`
 	WatchdogData watchdogPersist     `json:"watchdogdata"`
 }
 
@@ -79,6 +80,7 @@ func (c *Contractor) persistData() contractorPersist {
 		data.RecoverableContracts = append(data.RecoverableContracts, contract)
 	}
 	data.ChurnLimiter = c.staticChurnLimiter.callPersistData()
+	data.Rebalancer = c.staticRebalancer.callPersistData()
 	data.WatchdogData = c.staticWatchdog.callPersistData()
 	return data
 }
@@ -149,6 +151,7 @@ func (c *Contractor) load() error {
 	}
 
 	c.staticChurnLimiter = newChurnLimiterFromPersist(c, data.ChurnLimiter)
+	c.staticRebalancer = newRebalancerFromPersist(data.Rebalancer)
 
 	c.staticWatchdog, err = newWatchdogFromPersist(c, data.WatchdogData)
 	if err != nil {
@@ -94,6 +94,9 @@ func TestSaveLoad(t *testing.T) {
 	c.staticChurnLimiter.aggregateCurrentPeriodChurn = 123456
 	c.staticChurnLimiter.remainingChurnBudget = -789
 
+	c.staticRebalancer = newRebalancer()
+	c.staticRebalancer.spentBudget = types.NewCurrency64(2468)
+
 	// save, clear, and reload
 	err := c.save()
 	if err != nil {
@@ -302,6 +305,11 @@ func TestSaveLoad(t *testing.T) {
 	if periodBudget != expectedPeriodBudget {
 		t.Fatal("Expected remainingChurnBudget", periodBudget)
 	}
+
+	// Check rebalancer state.
+	if status := c.staticRebalancer.managedStatus(); !status.SpentBudget.Equals64(2468) {
+		t.Fatal("Expected 2468 spent rebalance budget", status.SpentBudget)
+	}
 }
 
 // TestConvertPersist tests that contracts previously stored in the
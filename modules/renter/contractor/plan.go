@@ -0,0 +1,504 @@
+package contractor
+
+// plan.go contains the planner for contract maintenance. The planner runs the
+// decision logic of threadedContractMaintenance against an allowance and
+// reports which contracts maintenance would cancel, renew, refresh and form,
+// without negotiating with any hosts or updating any contracts.
+//
+// Maintenance picks the hosts for new contracts randomly and tries the next
+// host if forming a contract fails. The planned formations are therefore only
+// an example of the hosts maintenance might pick, but the number of contracts
+// and their funding are the same.
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+const (
+	// Reasons for the actions of a maintenance plan which aren't covered by
+	// the critical utility checks.
+	planReasonDeadScore   = "host has a dead score"
+	planReasonExpiring    = "contract is about to expire"
+	planReasonLowScore    = "host score is too low"
+	planReasonMigration   = "data is migrated to other hosts: "
+	planReasonMoreHosts   = "allowance requires more hosts"
+	planReasonNotInHostDB = "host is not in the hostdb or is filtered"
+	planReasonOutOfFunds  = "contract is running out of funds"
+	planReasonPortal      = "portals form contracts with all hosts"
+
+	// Reasons for skipping the actions of a maintenance plan.
+	planSkipInsufficientFunds = "insufficient funds remaining in the allowance"
+	planSkipWalletLocked      = "wallet is locked"
+)
+
+var (
+	// errPlanNotSynced is returned if contract maintenance is planned before
+	// consensus is synced.
+	errPlanNotSynced = errors.New("contract maintenance can't be planned before consensus is synced")
+)
+
+// planBudget tracks the funds remaining in the allowance while planning
+// contract maintenance.
+type planBudget struct {
+	fundsRemaining types.Currency
+	totalCost      types.Currency
+	walletUnlocked bool
+}
+
+// spend deducts the cost of an action from the remaining funds. It returns the
+// reason for skipping the action if maintenance wouldn't be able to perform it
+// and an empty string otherwise.
+func (pb *planBudget) spend(cost types.Currency) string {
+	if !pb.walletUnlocked {
+		return planSkipWalletLocked
+	}
+	if cost.Cmp(pb.fundsRemaining) > 0 {
+		return planSkipInsufficientFunds
+	}
+	pb.fundsRemaining = pb.fundsRemaining.Sub(cost)
+	pb.totalCost = pb.totalCost.Add(cost)
+	return ""
+}
+
+// churnSimulation simulates the budget of the churnLimiter while planning
+// contract maintenance.
+type churnSimulation struct {
+	remainingChurnBudget int
+	aggregateChurn       uint64
+	maxPeriodChurn       uint64
+	maxChurnBudget       int
+}
+
+// canChurn returns whether the contract could be churned given the simulated
+// churn budget.
+func (cs *churnSimulation) canChurn(contract modules.RenterContract) bool {
+	return canChurn(contract.Size(), cs.remainingChurnBudget, cs.aggregateChurn, cs.maxPeriodChurn, cs.maxChurnBudget)
+}
+
+// churn adds the contract to the simulated churn.
+func (cs *churnSimulation) churn(contract modules.RenterContract) {
+	size := contract.Size()
+	cs.aggregateChurn += size
+	cs.remainingChurnBudget -= int(size)
+}
+
+// ContractMaintenancePlan returns the actions the next round of contract
+// maintenance would take if the contractor used the provided allowance. No
+// contracts are formed, renewed or updated.
+func (c *Contractor) ContractMaintenancePlan(allowance modules.Allowance) (modules.ContractMaintenancePlan, error) {
+	if err := c.tg.Add(); err != nil {
+		return modules.ContractMaintenancePlan{}, err
+	}
+	defer c.tg.Done()
+
+	// Maintenance doesn't run before consensus is synced.
+	if !c.managedSynced() {
+		return modules.ContractMaintenancePlan{}, errPlanNotSynced
+	}
+
+	// Setting an allowance after the empty allowance starts a new period. See
+	// SetAllowance.
+	c.mu.RLock()
+	blockHeight := c.blockHeight
+	currentPeriod := c.currentPeriod
+	if reflect.DeepEqual(c.allowance, modules.Allowance{}) {
+		currentPeriod = blockHeight
+		if allowance.Period > allowance.RenewWindow {
+			currentPeriod -= allowance.RenewWindow
+		}
+	}
+	c.mu.RUnlock()
+
+	plan := modules.ContractMaintenancePlan{
+		Allowance:   allowance,
+		BlockHeight: blockHeight,
+		EndHeight:   currentPeriod + allowance.Period + allowance.RenewWindow,
+	}
+	unlocked, err := c.wallet.Unlocked()
+	plan.WalletUnlocked = unlocked && err == nil
+	if plan.WalletUnlocked {
+		plan.WalletBalance, _, _, err = c.wallet.ConfirmedBalance()
+		if err != nil {
+			return modules.ContractMaintenancePlan{}, errors.AddContext(err, "unable to get wallet balance")
+		}
+	}
+
+	// There is nothing to plan if no hosts are requested.
+	if allowance.Hosts == 0 {
+		return plan, nil
+	}
+
+	// Project the utilities of the contracts.
+	utilities, cancellations, err := c.managedPlanUtilities(allowance, blockHeight, plan.EndHeight)
+	if err != nil {
+		return modules.ContractMaintenancePlan{}, err
+	}
+	plan.Cancellations = cancellations
+
+	// Determine the funds remaining in the allowance the same way maintenance
+	// does.
+	spending, err := c.PeriodSpending()
+	if err != nil {
+		return modules.ContractMaintenancePlan{}, errors.AddContext(err, "unable to get period spending")
+	}
+	if spending.TotalAllocated.Cmp(allowance.Funds) < 0 {
+		plan.FundsRemaining = allowance.Funds.Sub(spending.TotalAllocated)
+	}
+	budget := &planBudget{
+		fundsRemaining: plan.FundsRemaining,
+		walletUnlocked: plan.WalletUnlocked,
+	}
+
+	// Plan the renewals and refreshes. Renewals take priority over refreshes.
+	renewSet, refreshSet := c.managedRenewAndRefreshSets(allowance, blockHeight, func(id types.FileContractID) (modules.ContractUtility, bool) {
+		u, ok := utilities[id]
+		return u, ok
+	})
+	renewed := make(map[types.FileContractID]struct{})
+	plan.Renewals = c.managedPlanRenewals(renewSet, planReasonExpiring, budget, renewed)
+	plan.Refreshes = c.managedPlanRenewals(refreshSet, planReasonOutOfFunds, budget, renewed)
+
+	// Renewed contracts are replaced by new contracts which are good for
+	// upload. Form new contracts for the remaining hosts.
+	uploadContracts := len(renewed)
+	for id, u := range utilities {
+		if _, exists := renewed[id]; !exists && u.GoodForUpload {
+			uploadContracts++
+		}
+	}
+	plan.Formations, err = c.managedPlanFormations(allowance, int(allowance.Hosts)-uploadContracts, utilities, budget)
+	if err != nil {
+		return modules.ContractMaintenancePlan{}, err
+	}
+	if allowance.PortalMode() {
+		formations, err := c.managedPlanPortalFormations(allowance, plan.Formations, budget)
+		if err != nil {
+			return modules.ContractMaintenancePlan{}, err
+		}
+		plan.Formations = append(plan.Formations, formations...)
+	}
+	plan.TotalCost = budget.totalCost
+	return plan, nil
+}
+
+// managedHostNetAddress returns the net address of a host or the empty address
+// if the host isn't in the hostdb.
+func (c *Contractor) managedHostNetAddress(pk types.TurtleDexPublicKey) modules.NetAddress {
+	host, _, err := c.hdb.Host(pk)
+	if err != nil {
+		return ""
+	}
+	return host.NetAddress
+}
+
+// managedPlanContractUtility returns the utility managedMarkContractUtility
+// would mark a contract with, the score of its host and the reason for the
+// change. A suggestedUtilityUpdate status indicates that the update is subject
+// to the churnLimiter.
+func (c *Contractor) managedPlanContractUtility(contract modules.RenterContract, allowance modules.Allowance, blockHeight types.BlockHeight, minScoreGFR, minScoreGFU types.Currency) (modules.ContractUtility, types.Currency, string, utilityUpdateStatus) {
+	if contract.Utility.Locked {
+		return contract.Utility, types.ZeroCurrency, "", noUpdate
+	}
+	host, u, needsUpdate := c.managedHostInHostDBUtility(contract)
+	if needsUpdate {
+		return u, types.ZeroCurrency, planReasonNotInHostDB, necessaryUtilityUpdate
+	}
+
+	c.mu.RLock()
+	_, renewed := c.renewedTo[contract.ID]
+	c.mu.RUnlock()
+	var revisionNumber uint64
+	if len(contract.Transaction.FileContractRevisions) > 0 {
+		revisionNumber = contract.Transaction.FileContractRevisions[0].NewRevisionNumber
+	}
+	u, reason, needsUpdate := c.criticalUtilityChecks(contract, revisionNumber, renewed, host, allowance.RenewWindow, allowance.Period, blockHeight)
+	if needsUpdate {
+		return u, types.ZeroCurrency, reason, necessaryUtilityUpdate
+	}
+
+	sb, err := c.hdb.ScoreBreakdownWithAllowance(host, allowance)
+	if err != nil {
+		return contract.Utility, types.ZeroCurrency, "", noUpdate
+	}
+	u, status := c.hostScoreCheck(contract, sb, allowance, minScoreGFR, minScoreGFU)
+	switch status {
+	case suggestedUtilityUpdate:
+		return u, sb.Score, planReasonLowScore, status
+	case necessaryUtilityUpdate:
+		if !u.GoodForRenew {
+			return u, sb.Score, planReasonDeadScore, status
+		}
+		return u, sb.Score, planReasonLowScore, status
+	}
+	u.GoodForUpload = true
+	u.GoodForRenew = true
+	return u, sb.Score, "", noUpdate
+}
+
+// managedPlanUtilities returns the utilities managedMarkContractsUtility and
+// managedRebalanceContracts would mark the active contracts with, and the
+// contracts which would no longer be renewed as a result.
+func (c *Contractor) managedPlanUtilities(allowance modules.Allowance, blockHeight, endHeight types.BlockHeight) (map[types.FileContractID]modules.ContractUtility, []modules.PlannedContractAction, error) {
+	scoreBreakdown := func(host modules.HostDBEntry) (modules.HostScoreBreakdown, error) {
+		return c.hdb.ScoreBreakdownWithAllowance(host, allowance)
+	}
+	hosts, err := c.hdb.RandomHostsWithAllowance(int(allowance.Hosts)+randomHostsBufferForScore, nil, nil, allowance)
+	if err != nil {
+		return nil, nil, errors.AddContext(err, "unable to get sample of hosts")
+	}
+	minScoreGFR, minScoreGFU, err := c.managedMinAllowedHostScores(hosts, scoreBreakdown)
+	if err != nil {
+		return nil, nil, errors.AddContext(err, "unable to find minimum allowed host scores")
+	}
+	remainingChurnBudget, _ := c.staticChurnLimiter.managedChurnBudget()
+	aggregateChurn, maxPeriodChurn := c.staticChurnLimiter.managedAggregateAndMaxChurn()
+	churn := &churnSimulation{
+		remainingChurnBudget: remainingChurnBudget,
+		aggregateChurn:       aggregateChurn,
+		maxPeriodChurn:       maxPeriodChurn,
+		maxChurnBudget:       c.staticChurnLimiter.managedMaxChurnBudget(),
+	}
+
+	contracts := c.staticContracts.ViewAll()
+	utilities := make(map[types.FileContractID]modules.ContractUtility, len(contracts))
+	reasons := make(map[types.FileContractID]string)
+	var suggested []contractScoreAndUtil
+	for _, contract := range contracts {
+		u, score, reason, status := c.managedPlanContractUtility(contract, allowance, blockHeight, minScoreGFR, minScoreGFU)
+		reasons[contract.ID] = reason
+		if status == suggestedUtilityUpdate {
+			suggested = append(suggested, contractScoreAndUtil{contract, score, u})
+			continue
+		}
+		utilities[contract.ID] = u
+		if contract.Utility.GoodForRenew && !u.GoodForRenew {
+			churn.churn(contract)
+		}
+	}
+
+	// Process the suggested updates the same way the churnLimiter does.
+	sort.Slice(suggested, func(i, j int) bool {
+		return suggested[i].score.Cmp(suggested[j].score) < 0
+	})
+	for _, s := range suggested {
+		turnedNotGFR := s.contract.Utility.GoodForRenew && !s.util.GoodForRenew
+		if turnedNotGFR && churn.canChurn(s.contract) {
+			churn.churn(s.contract)
+		} else if turnedNotGFR {
+			s.util.GoodForRenew = true
+		}
+		utilities[s.contract.ID] = s.util
+	}
+
+	// Apply the migrations the rebalancer would start.
+	err = c.managedPlanMigrations(allowance, blockHeight, endHeight, scoreBreakdown, utilities, reasons, churn)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var cancellations []modules.PlannedContractAction
+	for _, contract := range contracts {
+		if !contract.Utility.GoodForRenew || utilities[contract.ID].GoodForRenew {
+			continue
+		}
+		cancellations = append(cancellations, modules.PlannedContractAction{
+			ContractID:    contract.ID,
+			HostPublicKey: contract.HostPublicKey,
+			NetAddress:    c.managedHostNetAddress(contract.HostPublicKey),
+			Size:          contract.Size(),
+			Reason:        reasons[contract.ID],
+		})
+	}
+	sort.Slice(cancellations, func(i, j int) bool {
+		return cancellations[i].ContractID.String() < cancellations[j].ContractID.String()
+	})
+	return utilities, cancellations, nil
+}
+
+// managedPlanMigrations marks the contracts managedRebalanceContracts would
+// start migrating as !GoodForUpload and !GoodForRenew.
+func (c *Contractor) managedPlanMigrations(allowance modules.Allowance, blockHeight, endHeight types.BlockHeight, scoreBreakdown func(modules.HostDBEntry) (modules.HostScoreBreakdown, error), utilities map[types.FileContractID]modules.ContractUtility, reasons map[types.FileContractID]string, churn *churnSimulation) error {
+	status := c.staticRebalancer.managedStatus()
+	if !status.Policy.Enabled() {
+		return nil
+	}
+	hosts, err := c.hdb.ActiveHosts()
+	if err != nil {
+		return errors.AddContext(err, "unable to get active hosts")
+	}
+	if len(hosts) == 0 {
+		return nil
+	}
+	thresholds, err := computeMigrationThresholds(status.Policy, hosts, allowance.Period, scoreBreakdown)
+	if err != nil {
+		return err
+	}
+	candidates := c.managedMigrationCandidates(status.Policy, thresholds, allowance.Period, scoreBreakdown)
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].score.Cmp(candidates[j].score) < 0
+	})
+
+	var remainingBudget types.Currency
+	if status.Policy.Budget.Cmp(status.SpentBudget) > 0 {
+		remainingBudget = status.Policy.Budget.Sub(status.SpentBudget)
+	}
+	costPerSector := medianSectorCost(hosts, endHeight-blockHeight)
+	for _, candidate := range candidates {
+		contract := candidate.contract
+		u := utilities[contract.ID]
+		if !u.GoodForRenew || !churn.canChurn(contract) {
+			continue
+		}
+		cost := migrationCost(contract.Size(), costPerSector)
+		if cost.Cmp(remainingBudget) > 0 {
+			continue
+		}
+		remainingBudget = remainingBudget.Sub(cost)
+		churn.churn(contract)
+		u.GoodForUpload = false
+		u.GoodForRenew = false
+		utilities[contract.ID] = u
+		reasons[contract.ID] = planReasonMigration + candidate.reason
+	}
+	return nil
+}
+
+// managedPlanRenewals plans the renewals of a renew or refresh set. The
+// contracts which would be renewed are added to renewed.
+func (c *Contractor) managedPlanRenewals(set []fileContractRenewal, reason string, budget *planBudget, renewed map[types.FileContractID]struct{}) []modules.PlannedContractAction {
+	var renewals []modules.PlannedContractAction
+	for _, renewal := range set {
+		contract, exists := c.staticContracts.View(renewal.id)
+		if !exists {
+			continue
+		}
+		action := modules.PlannedContractAction{
+			ContractID:    renewal.id,
+			HostPublicKey: renewal.hostPubKey,
+			NetAddress:    c.managedHostNetAddress(contract.HostPublicKey),
+			Size:          contract.Size(),
+			EstimatedCost: renewal.amount,
+			Reason:        reason,
+			SkipReason:    budget.spend(renewal.amount),
+		}
+		if action.SkipReason == "" {
+			renewed[renewal.id] = struct{}{}
+		}
+		renewals = append(renewals, action)
+	}
+	return renewals
+}
+
+// managedPlanFormations plans the formation of the given number of new
+// contracts.
+func (c *Contractor) managedPlanFormations(allowance modules.Allowance, neededContracts int, utilities map[types.FileContractID]modules.ContractUtility, budget *planBudget) ([]modules.PlannedContractAction, error) {
+	if neededContracts <= 0 {
+		return nil, nil
+	}
+
+	// Assemble the same exclusion lists as threadedContractMaintenance.
+	var blacklist []types.TurtleDexPublicKey
+	var addressBlacklist []types.TurtleDexPublicKey
+	for _, contract := range c.staticContracts.ViewAll() {
+		blacklist = append(blacklist, contract.HostPublicKey)
+		u := utilities[contract.ID]
+		if !u.Locked || u.GoodForRenew || u.GoodForUpload {
+			addressBlacklist = append(addressBlacklist, contract.HostPublicKey)
+		}
+	}
+	c.mu.RLock()
+	for _, contract := range c.recoverableContracts {
+		blacklist = append(blacklist, contract.HostPublicKey)
+	}
+	c.mu.RUnlock()
+	hosts, err := c.hdb.RandomHostsWithAllowance(neededContracts*4+randomHostsBufferForScore, blacklist, addressBlacklist, allowance)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to get hosts for new contracts")
+	}
+
+	maxInitialContractFunds := allowance.Funds.Div64(allowance.Hosts).Mul64(MaxInitialContractFundingMulFactor).Div64(MaxInitialContractFundingDivFactor)
+	minInitialContractFunds := allowance.Funds.Div64(allowance.Hosts).Div64(MinInitialContractFundingDivFactor)
+	_, maxFee := c.tpool.FeeEstimation()
+	txnFee := maxFee.Mul64(modules.EstimatedFileContractTransactionSetSize)
+
+	var formations []modules.PlannedContractAction
+	for _, host := range hosts {
+		if neededContracts <= 0 {
+			break
+		}
+		// Skip the hosts managedNewContract would reject.
+		if host.StoragePrice.Cmp(maxStoragePrice) > 0 || host.MaxDuration < allowance.Period || checkFormContractGouging(allowance, host.HostExternalSettings) != nil {
+			continue
+		}
+		contractFunds := host.ContractPrice.Add(txnFee).Mul64(ContractFeeFundingMulFactor)
+		if contractFunds.Cmp(maxInitialContractFunds) > 0 {
+			contractFunds = maxInitialContractFunds
+		}
+		if contractFunds.Cmp(minInitialContractFunds) < 0 {
+			contractFunds = minInitialContractFunds
+		}
+		formation := modules.PlannedContractAction{
+			HostPublicKey: host.PublicKey,
+			NetAddress:    host.NetAddress,
+			EstimatedCost: contractFunds,
+			Reason:        planReasonMoreHosts,
+			SkipReason:    budget.spend(contractFunds),
+		}
+		formations = append(formations, formation)
+		// Maintenance stops forming contracts once the allowance runs out of
+		// funds.
+		if formation.SkipReason == planSkipInsufficientFunds {
+			break
+		}
+		neededContracts--
+	}
+	return formations, nil
+}
+
+// managedPlanPortalFormations plans the formation of the contracts portals form
+// with all hosts they don't have a contract with yet.
+func (c *Contractor) managedPlanPortalFormations(allowance modules.Allowance, formations []modules.PlannedContractAction, budget *planBudget) ([]modules.PlannedContractAction, error) {
+	allHosts, err := c.hdb.ActiveHosts()
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to get active hosts")
+	}
+	existing := make(map[string]struct{})
+	for _, contract := range c.staticContracts.ViewAll() {
+		existing[contract.HostPublicKey.String()] = struct{}{}
+	}
+	for _, formation := range formations {
+		existing[formation.HostPublicKey.String()] = struct{}{}
+	}
+
+	var portalFormations []modules.PlannedContractAction
+	for _, host := range allHosts {
+		if _, exists := existing[host.PublicKey.String()]; exists {
+			continue
+		}
+		sb, err := c.hdb.ScoreBreakdownWithAllowance(host, allowance)
+		if err != nil || sb.Score.Equals(types.NewCurrency64(1)) {
+			continue
+		}
+		if staticCheckFormPaymentContractGouging(allowance, host.HostExternalSettings) != nil {
+			continue
+		}
+		formation := modules.PlannedContractAction{
+			HostPublicKey: host.PublicKey,
+			NetAddress:    host.NetAddress,
+			EstimatedCost: allowance.PaymentContractInitialFunding,
+			Reason:        planReasonPortal,
+			SkipReason:    budget.spend(allowance.PaymentContractInitialFunding),
+		}
+		portalFormations = append(portalFormations, formation)
+		if formation.SkipReason == planSkipInsufficientFunds {
+			break
+		}
+	}
+	return portalFormations, nil
+}
@@ -0,0 +1,100 @@
+package contractor
+
+import (
+	"testing"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+// TestPlanBudget tests that the planBudget only spends the funds remaining in
+// the allowance and skips all actions while the wallet is locked.
+func TestPlanBudget(t *testing.T) {
+	budget := &planBudget{
+		fundsRemaining: types.NewCurrency64(100),
+		walletUnlocked: true,
+	}
+	if reason := budget.spend(types.NewCurrency64(60)); reason != "" {
+		t.Fatal("affordable action was skipped:", reason)
+	}
+	if reason := budget.spend(types.NewCurrency64(50)); reason != planSkipInsufficientFunds {
+		t.Fatal("unaffordable action wasn't skipped:", reason)
+	}
+	if reason := budget.spend(types.NewCurrency64(40)); reason != "" {
+		t.Fatal("affordable action was skipped:", reason)
+	}
+	if !budget.fundsRemaining.IsZero() || !budget.totalCost.Equals64(100) {
+		t.Fatal("wrong budget", budget.fundsRemaining, budget.totalCost)
+	}
+
+	budget = &planBudget{fundsRemaining: types.NewCurrency64(100)}
+	if reason := budget.spend(types.NewCurrency64(1)); reason != planSkipWalletLocked {
+		t.Fatal("action should be skipped while the wallet is locked:", reason)
+	}
+	if !budget.totalCost.IsZero() {
+		t.Fatal("skipped action shouldn't count towards the total cost")
+	}
+}
+
+// TestChurnSimulation tests that the churnSimulation applies the same limits
+// as the churnLimiter.
+func TestChurnSimulation(t *testing.T) {
+	cs := &churnSimulation{
+		remainingChurnBudget: 1000,
+		maxPeriodChurn:       1500,
+		maxChurnBudget:       1000,
+	}
+	// Any contract can be churned while no churn happened in the period and the
+	// budget is at its maximum.
+	if !cs.canChurn(contractWithSize(2000)) {
+		t.Fatal("contract should be churnable")
+	}
+	cs.churn(contractWithSize(600))
+	if cs.remainingChurnBudget != 400 || cs.aggregateChurn != 600 {
+		t.Fatal("wrong churn", cs.remainingChurnBudget, cs.aggregateChurn)
+	}
+	if cs.canChurn(contractWithSize(500)) {
+		t.Fatal("contract exceeding the remaining budget shouldn't be churnable")
+	}
+	if !cs.canChurn(contractWithSize(400)) {
+		t.Fatal("contract within the remaining budget should be churnable")
+	}
+}
+
+// TestHostScoreCheck tests that hostScoreCheck uses the provided allowance to
+// decide whether a contract is a payment contract.
+func TestHostScoreCheck(t *testing.T) {
+	var c *Contractor
+	contract := modules.RenterContract{
+		Utility: modules.ContractUtility{GoodForUpload: true, GoodForRenew: true},
+	}
+	sb := modules.HostScoreBreakdown{Score: types.NewCurrency64(10)}
+	minScore := types.NewCurrency64(100)
+
+	// Without payment contracts a low score is subject to the churnLimiter.
+	u, status := c.hostScoreCheck(contract, sb, modules.Allowance{}, minScore, minScore)
+	if status != suggestedUtilityUpdate || u.GoodForUpload || u.GoodForRenew {
+		t.Fatal("wrong utility", u, status)
+	}
+
+	// An empty payment contract is only marked as not good for upload.
+	allowance := modules.Allowance{PaymentContractInitialFunding: types.NewCurrency64(1)}
+	u, status = c.hostScoreCheck(contract, sb, allowance, minScore, minScore)
+	if status != necessaryUtilityUpdate || u.GoodForUpload || !u.GoodForRenew {
+		t.Fatal("wrong utility", u, status)
+	}
+
+	// A dead score always requires an update.
+	sb.Score = types.NewCurrency64(1)
+	u, status = c.hostScoreCheck(contract, sb, allowance, minScore, minScore)
+	if status != necessaryUtilityUpdate || u.GoodForUpload || u.GoodForRenew {
+		t.Fatal("wrong utility", u, status)
+	}
+
+	// A good score doesn't change the utility.
+	sb.Score = minScore
+	u, status = c.hostScoreCheck(contract, sb, allowance, minScore, minScore)
+	if status != noUpdate || u != contract.Utility {
+		t.Fatal("wrong utility", u, status)
+	}
+}
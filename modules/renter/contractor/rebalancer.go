@@ -0,0 +1,471 @@
+package contractor
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+// rebalancer keeps track of the contracts whose data is migrated away from
+// degraded or overpriced hosts and of the budget spent on these migrations in
+// the current period.
+type rebalancer struct {
+	policy      modules.RebalancePolicy
+	spentBudget types.Currency
+	migrations  map[types.FileContractID]modules.ContractMigration
+
+	mu sync.Mutex
+}
+
+// rebalancerPersist is the persisted state of a rebalancer.
+type rebalancerPersist struct {
+	Policy      modules.RebalancePolicy     `json:"policy"`
+	SpentBudget types.Currency              `json:"spentbudget"`
+	Migrations  []modules.ContractMigration `json:"migrations"`
+}
+
+// migrationThresholds are the thresholds of a rebalance policy computed from
+// the hosts the contractor could form contracts with.
+type migrationThresholds struct {
+	minScore types.Currency
+	maxCost  types.Currency
+}
+
+// migrationCandidate is a contract with a host that fails the thresholds of
+// the rebalance policy.
+type migrationCandidate struct {
+	contract modules.RenterContract
+	score    types.Currency
+	reason   string
+}
+
+// newRebalancer returns a new rebalancer.
+func newRebalancer() *rebalancer {
+	return &rebalancer{
+		migrations: make(map[types.FileContractID]modules.ContractMigration),
+	}
+}
+
+// newRebalancerFromPersist creates a new rebalancer using persisted state.
+func newRebalancerFromPersist(persistData rebalancerPersist) *rebalancer {
+	rb := newRebalancer()
+	rb.policy = persistData.Policy
+	rb.spentBudget = persistData.SpentBudget
+	for _, migration := range persistData.Migrations {
+		rb.migrations[migration.ContractID] = migration
+	}
+	return rb
+}
+
+// callPersistData returns the rebalancerPersist corresponding to this
+// rebalancer's state.
+func (rb *rebalancer) callPersistData() rebalancerPersist {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	return rebalancerPersist{
+		Policy:      rb.policy,
+		SpentBudget: rb.spentBudget,
+		Migrations:  rb.migrationsSorted(),
+	}
+}
+
+// callResetSpentBudget resets the budget spent on migrations. This method must
+// be called at the beginning of every new period.
+func (rb *rebalancer) callResetSpentBudget() {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	rb.spentBudget = types.ZeroCurrency
+}
+
+// migrationsSorted returns the migrations ordered by their start height.
+func (rb *rebalancer) migrationsSorted() []modules.ContractMigration {
+	migrations := make([]modules.ContractMigration, 0, len(rb.migrations))
+	for _, migration := range rb.migrations {
+		migrations = append(migrations, migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		if migrations[i].StartHeight != migrations[j].StartHeight {
+			return migrations[i].StartHeight < migrations[j].StartHeight
+		}
+		return migrations[i].ContractID.String() < migrations[j].ContractID.String()
+	})
+	return migrations
+}
+
+// managedMigrating returns whether the data of a contract is being migrated.
+func (rb *rebalancer) managedMigrating(id types.FileContractID) bool {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	_, exists := rb.migrations[id]
+	return exists
+}
+
+// managedPolicy returns the rebalance policy.
+func (rb *rebalancer) managedPolicy() modules.RebalancePolicy {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	return rb.policy
+}
+
+// managedSetPolicy updates the rebalance policy. Disabling the policy cancels
+// the pending migrations.
+func (rb *rebalancer) managedSetPolicy(policy modules.RebalancePolicy) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	rb.policy = policy
+	if !policy.Enabled() {
+		rb.migrations = make(map[types.FileContractID]modules.ContractMigration)
+	}
+}
+
+// managedStatus returns the status of the rebalancer.
+func (rb *rebalancer) managedStatus() modules.RebalanceStatus {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	return modules.RebalanceStatus{
+		Policy:      rb.policy,
+		SpentBudget: rb.spentBudget,
+		Migrations:  rb.migrationsSorted(),
+	}
+}
+
+// managedTryAddMigration adds a migration if its estimated cost fits into the
+// remaining budget of the period.
+func (rb *rebalancer) managedTryAddMigration(migration modules.ContractMigration) bool {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	spent := rb.spentBudget.Add(migration.EstimatedCost)
+	if spent.Cmp(rb.policy.Budget) > 0 {
+		return false
+	}
+	rb.spentBudget = spent
+	rb.migrations[migration.ContractID] = migration
+	return true
+}
+
+// managedRemoveMigration removes a migration which couldn't be started and
+// returns its estimated cost to the budget.
+func (rb *rebalancer) managedRemoveMigration(id types.FileContractID) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	migration, exists := rb.migrations[id]
+	if !exists {
+		return
+	}
+	delete(rb.migrations, id)
+	if rb.spentBudget.Cmp(migration.EstimatedCost) >= 0 {
+		rb.spentBudget = rb.spentBudget.Sub(migration.EstimatedCost)
+	}
+}
+
+// managedEndMigration removes the migration of a contract whose host passes the
+// thresholds of the rebalance policy again. The estimated cost of the
+// migration stays spent since the data might already have been migrated.
+func (rb *rebalancer) managedEndMigration(id types.FileContractID) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	delete(rb.migrations, id)
+}
+
+// managedPruneMigrations removes the migrations of contracts which are no
+// longer active.
+func (rb *rebalancer) managedPruneMigrations(active map[types.FileContractID]struct{}) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	for id := range rb.migrations {
+		if _, exists := active[id]; !exists {
+			delete(rb.migrations, id)
+		}
+	}
+}
+
+// sectorCost returns the cost of storing a sector on a host for the provided
+// duration and of uploading and downloading it once.
+func sectorCost(host modules.HostDBEntry, duration types.BlockHeight) types.Currency {
+	storage := host.StoragePrice.Mul64(modules.SectorSize).Mul64(uint64(duration))
+	upload := host.UploadBandwidthPrice.Mul64(modules.SectorSize)
+	download := host.DownloadBandwidthPrice.Mul64(modules.SectorSize)
+	return storage.Add(upload).Add(download)
+}
+
+// medianSectorCost returns the median sectorCost of a set of hosts.
+func medianSectorCost(hosts []modules.HostDBEntry, duration types.BlockHeight) types.Currency {
+	if len(hosts) == 0 {
+		return types.ZeroCurrency
+	}
+	costs := make([]types.Currency, 0, len(hosts))
+	for _, host := range hosts {
+		costs = append(costs, sectorCost(host, duration))
+	}
+	sort.Slice(costs, func(i, j int) bool {
+		return costs[i].Cmp(costs[j]) < 0
+	})
+	return costs[len(costs)/2]
+}
+
+// scorePercentile returns the score at the provided percentile of a set of
+// scores.
+func scorePercentile(scores []types.Currency, percentile float64) types.Currency {
+	if len(scores) == 0 {
+		return types.ZeroCurrency
+	}
+	sorted := append([]types.Currency{}, scores...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Cmp(sorted[j]) < 0
+	})
+	i := int(percentile * float64(len(sorted)))
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i]
+}
+
+// migrationCost estimates the cost of moving the data of a contract to a host
+// with the provided cost per sector.
+func migrationCost(size uint64, costPerSector types.Currency) types.Currency {
+	sectors := size / modules.SectorSize
+	if size%modules.SectorSize != 0 {
+		sectors++
+	}
+	return costPerSector.Mul64(sectors)
+}
+
+// RebalancePolicy returns the policy for migrating data away from degraded or
+// overpriced hosts.
+func (c *Contractor) RebalancePolicy() modules.RebalancePolicy {
+	return c.staticRebalancer.managedPolicy()
+}
+
+// RebalanceStatus returns the rebalance policy, the budget spent on migrations
+// in the current period and the contracts which are being migrated.
+func (c *Contractor) RebalanceStatus() modules.RebalanceStatus {
+	return c.staticRebalancer.managedStatus()
+}
+
+// SetRebalancePolicy sets the policy for migrating data away from degraded or
+// overpriced hosts.
+func (c *Contractor) SetRebalancePolicy(policy modules.RebalancePolicy) error {
+	if err := c.tg.Add(); err != nil {
+		return err
+	}
+	defer c.tg.Done()
+	if err := policy.Validate(); err != nil {
+		return errors.AddContext(err, "invalid rebalance policy")
+	}
+	c.staticRebalancer.managedSetPolicy(policy)
+
+	c.mu.Lock()
+	err := c.save()
+	c.mu.Unlock()
+	if err != nil {
+		return errors.AddContext(err, "unable to save rebalance policy")
+	}
+
+	// Interrupt any existing maintenance and launch a new round of
+	// maintenance to apply the new policy.
+	if err := c.tg.Add(); err != nil {
+		return err
+	}
+	go func() {
+		defer c.tg.Done()
+		c.callInterruptContractMaintenance()
+		c.threadedContractMaintenance()
+	}()
+	return nil
+}
+
+// migrationCheck checks whether the data of a contract is being migrated to
+// other hosts. Returns true if the utility returned must be used to update the
+// contract state.
+func (c *Contractor) migrationCheck(contract modules.RenterContract) (modules.ContractUtility, bool) {
+	u := contract.Utility
+	if c.staticRebalancer.managedMigrating(contract.ID) {
+		u.GoodForUpload = false
+		u.GoodForRenew = false
+		return u, true
+	}
+	return u, false
+}
+
+// computeMigrationThresholds computes the thresholds of the rebalance policy
+// from the active hosts of the hostdb. Every host counts the same, unlike in
+// samples of the hostdb which are weighted by score. The hosts are scored
+// using scoreBreakdown and their costs are computed for the provided period.
+func computeMigrationThresholds(policy modules.RebalancePolicy, hosts []modules.HostDBEntry, period types.BlockHeight, scoreBreakdown func(modules.HostDBEntry) (modules.HostScoreBreakdown, error)) (migrationThresholds, error) {
+	scores := make([]types.Currency, 0, len(hosts))
+	for _, host := range hosts {
+		sb, err := scoreBreakdown(host)
+		if err != nil {
+			return migrationThresholds{}, errors.AddContext(err, "unable to get score of active host")
+		}
+		scores = append(scores, sb.Score)
+	}
+	return migrationThresholds{
+		minScore: scorePercentile(scores, policy.MinScorePercentile),
+		maxCost:  medianSectorCost(hosts, period).MulFloat(policy.MaxCostRatio),
+	}, nil
+}
+
+// migrationReason returns the reason for migrating data away from a host with
+// the provided score or an empty string if the host passes the thresholds.
+func migrationReason(policy modules.RebalancePolicy, thresholds migrationThresholds, host modules.HostDBEntry, score types.Currency, period types.BlockHeight) string {
+	if policy.MinScorePercentile > 0 && score.Cmp(thresholds.minScore) < 0 {
+		return modules.MigrationReasonLowScore
+	}
+	if policy.MaxCostRatio > 0 && sectorCost(host, period).Cmp(thresholds.maxCost) > 0 {
+		return modules.MigrationReasonOverpriced
+	}
+	return ""
+}
+
+// managedMigrationCandidates returns the contracts with hosts which fail the
+// thresholds of the rebalance policy. The hosts are scored using
+// scoreBreakdown and their costs are computed for the provided period.
+func (c *Contractor) managedMigrationCandidates(policy modules.RebalancePolicy, thresholds migrationThresholds, period types.BlockHeight, scoreBreakdown func(modules.HostDBEntry) (modules.HostScoreBreakdown, error)) []migrationCandidate {
+	var candidates []migrationCandidate
+	for _, contract := range c.staticContracts.ViewAll() {
+		// Only contracts which are still good for renew and contain data are
+		// migrated. Contracts which aren't GoodForRenew are already being
+		// repaired.
+		u := contract.Utility
+		if !u.GoodForRenew || u.Locked || contract.Size() == 0 {
+			continue
+		}
+		host, exists, err := c.hdb.Host(contract.HostPublicKey)
+		if err != nil || !exists {
+			continue
+		}
+		sb, err := scoreBreakdown(host)
+		if err != nil {
+			continue
+		}
+		reason := migrationReason(policy, thresholds, host, sb.Score, period)
+		if reason == "" {
+			continue
+		}
+		candidates = append(candidates, migrationCandidate{
+			contract: contract,
+			score:    sb.Score,
+			reason:   reason,
+		})
+	}
+	return candidates
+}
+
+// managedRecoveredMigrations returns the contracts which are being migrated
+// although their hosts pass the thresholds of the rebalance policy again.
+func (c *Contractor) managedRecoveredMigrations(policy modules.RebalancePolicy, thresholds migrationThresholds, period types.BlockHeight, scoreBreakdown func(modules.HostDBEntry) (modules.HostScoreBreakdown, error)) []types.FileContractID {
+	var recovered []types.FileContractID
+	for _, migration := range c.staticRebalancer.managedStatus().Migrations {
+		host, exists, err := c.hdb.Host(migration.HostPublicKey)
+		if err != nil || !exists {
+			continue
+		}
+		sb, err := scoreBreakdown(host)
+		if err != nil {
+			continue
+		}
+		if migrationReason(policy, thresholds, host, sb.Score, period) == "" {
+			recovered = append(recovered, migration.ContractID)
+		}
+	}
+	return recovered
+}
+
+// managedRebalanceContracts marks the contracts with hosts which fail the
+// thresholds of the rebalance policy as !GoodForUpload and !GoodForRenew as
+// long as the budget and the churnLimiter allow it. The repair loop then
+// migrates their data to other hosts. The contracts with the worst hosts are
+// migrated first.
+func (c *Contractor) managedRebalanceContracts() error {
+	// Forget about the migrations of contracts which expired or were
+	// archived.
+	active := make(map[types.FileContractID]struct{})
+	for _, contract := range c.staticContracts.ViewAll() {
+		active[contract.ID] = struct{}{}
+	}
+	c.staticRebalancer.managedPruneMigrations(active)
+
+	policy := c.staticRebalancer.managedPolicy()
+	if !policy.Enabled() {
+		return nil
+	}
+
+	// The thresholds are computed from the active hosts which could replace
+	// the current hosts.
+	c.mu.RLock()
+	period := c.allowance.Period
+	blockHeight := c.blockHeight
+	remaining := c.contractEndHeight() - c.blockHeight
+	c.mu.RUnlock()
+	hosts, err := c.hdb.ActiveHosts()
+	if err != nil {
+		return errors.AddContext(err, "unable to get active hosts")
+	}
+	if len(hosts) == 0 {
+		return nil
+	}
+	thresholds, err := computeMigrationThresholds(policy, hosts, period, c.hdb.ScoreBreakdown)
+	if err != nil {
+		return err
+	}
+
+	// Stop migrating the data of contracts whose hosts recovered. The next
+	// round of maintenance marks them as GoodForUpload and GoodForRenew again
+	// if they pass the other checks.
+	var changed bool
+	for _, id := range c.managedRecoveredMigrations(policy, thresholds, period, c.hdb.ScoreBreakdown) {
+		c.staticRebalancer.managedEndMigration(id)
+		c.log.Printf("Host of contract %v passes the rebalance policy again, ending migration", id)
+		changed = true
+	}
+
+	candidates := c.managedMigrationCandidates(policy, thresholds, period, c.hdb.ScoreBreakdown)
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].score.Cmp(candidates[j].score) < 0
+	})
+
+	// Migrating the data of a contract requires storing it on another host for
+	// the remainder of the period.
+	costPerSector := medianSectorCost(hosts, remaining)
+	for _, candidate := range candidates {
+		contract := candidate.contract
+		if !c.staticChurnLimiter.managedCanChurnContract(contract) {
+			c.log.Debugln("Avoiding migration of contract because of churn limit:", contract.ID)
+			continue
+		}
+		migration := modules.ContractMigration{
+			ContractID:    contract.ID,
+			HostPublicKey: contract.HostPublicKey,
+			Size:          contract.Size(),
+			EstimatedCost: migrationCost(contract.Size(), costPerSector),
+			Reason:        candidate.reason,
+			StartHeight:   blockHeight,
+		}
+		if !c.staticRebalancer.managedTryAddMigration(migration) {
+			c.log.Debugln("Avoiding migration of contract because of rebalance budget:", contract.ID)
+			continue
+		}
+		u := contract.Utility
+		u.GoodForUpload = false
+		u.GoodForRenew = false
+		err := c.managedAcquireAndUpdateContractUtility(contract.ID, u)
+		if err != nil {
+			c.staticRebalancer.managedRemoveMigration(contract.ID)
+			c.log.Println("Unable to update utility of migrated contract:", err)
+			continue
+		}
+		c.log.Printf("Migrating data of contract %v away from host %v: %v", contract.ID, contract.HostPublicKey, candidate.reason)
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.save()
+}
@@ -0,0 +1,170 @@
+package contractor
+
+import (
+	"testing"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+// TestRebalancerBudget tests that the rebalancer only adds migrations which fit
+// into its budget and that the budget is tracked correctly.
+func TestRebalancerBudget(t *testing.T) {
+	rb := newRebalancer()
+	rb.managedSetPolicy(modules.RebalancePolicy{
+		Budget:             types.NewCurrency64(100),
+		MinScorePercentile: 0.1,
+	})
+
+	m1 := modules.ContractMigration{ContractID: types.FileContractID{1}, EstimatedCost: types.NewCurrency64(60), StartHeight: 2}
+	m2 := modules.ContractMigration{ContractID: types.FileContractID{2}, EstimatedCost: types.NewCurrency64(50), StartHeight: 1}
+	m3 := modules.ContractMigration{ContractID: types.FileContractID{3}, EstimatedCost: types.NewCurrency64(40), StartHeight: 3}
+	if !rb.managedTryAddMigration(m1) {
+		t.Fatal("migration within the budget should be added")
+	}
+	if rb.managedTryAddMigration(m2) {
+		t.Fatal("migration exceeding the budget shouldn't be added")
+	}
+	if !rb.managedTryAddMigration(m3) {
+		t.Fatal("migration within the budget should be added")
+	}
+	status := rb.managedStatus()
+	if !status.SpentBudget.Equals64(100) {
+		t.Fatal("wrong spent budget", status.SpentBudget)
+	}
+	if len(status.Migrations) != 2 || status.Migrations[0].ContractID != m1.ContractID {
+		t.Fatal("wrong migrations", status.Migrations)
+	}
+	if !rb.managedMigrating(m1.ContractID) || rb.managedMigrating(m2.ContractID) {
+		t.Fatal("wrong migration state")
+	}
+
+	// Removing a migration returns its cost to the budget.
+	rb.managedRemoveMigration(m3.ContractID)
+	if rb.managedMigrating(m3.ContractID) || !rb.managedStatus().SpentBudget.Equals64(60) {
+		t.Fatal("migration wasn't removed correctly")
+	}
+
+	// Ending a migration keeps its cost spent.
+	if !rb.managedTryAddMigration(m3) {
+		t.Fatal("migration within the budget should be added")
+	}
+	rb.managedEndMigration(m3.ContractID)
+	if rb.managedMigrating(m3.ContractID) || !rb.managedStatus().SpentBudget.Equals64(100) {
+		t.Fatal("migration wasn't ended correctly")
+	}
+	rb.callResetSpentBudget()
+	if !rb.managedTryAddMigration(m1) {
+		t.Fatal("migration within the budget should be added")
+	}
+
+	// The state survives persistence.
+	rb = newRebalancerFromPersist(rb.callPersistData())
+	if !rb.managedMigrating(m1.ContractID) || !rb.managedStatus().SpentBudget.Equals64(60) {
+		t.Fatal("state wasn't persisted")
+	}
+
+	// Pruning removes the migrations of inactive contracts.
+	rb.managedPruneMigrations(make(map[types.FileContractID]struct{}))
+	if rb.managedMigrating(m1.ContractID) {
+		t.Fatal("migration of inactive contract wasn't pruned")
+	}
+
+	// A new period resets the spent budget.
+	rb.callResetSpentBudget()
+	if !rb.managedStatus().SpentBudget.IsZero() {
+		t.Fatal("spent budget wasn't reset")
+	}
+
+	// Disabling the policy cancels pending migrations.
+	if !rb.managedTryAddMigration(m2) {
+		t.Fatal("migration within the budget should be added")
+	}
+	rb.managedSetPolicy(modules.RebalancePolicy{})
+	if rb.managedMigrating(m2.ContractID) {
+		t.Fatal("disabling the policy should cancel migrations")
+	}
+}
+
+// TestRebalancerThresholds is a unit test for the helpers which compute the
+// thresholds and costs of the rebalancer.
+func TestRebalancerThresholds(t *testing.T) {
+	// Test scorePercentile.
+	scores := []types.Currency{
+		types.NewCurrency64(50),
+		types.NewCurrency64(10),
+		types.NewCurrency64(40),
+		types.NewCurrency64(20),
+		types.NewCurrency64(30),
+	}
+	tests := []struct {
+		percentile float64
+		score      uint64
+	}{
+		{0, 10},
+		{0.2, 20},
+		{0.5, 30},
+		{0.99, 50},
+	}
+	for _, test := range tests {
+		if score := scorePercentile(scores, test.percentile); !score.Equals64(test.score) {
+			t.Errorf("percentile %v: expected %v but got %v", test.percentile, test.score, score)
+		}
+	}
+	if !scores[0].Equals64(50) {
+		t.Fatal("scorePercentile shouldn't modify its input")
+	}
+
+	// Test medianSectorCost.
+	hosts := make([]modules.HostDBEntry, 3)
+	for i := range hosts {
+		hosts[i].StoragePrice = types.NewCurrency64(uint64(i + 1))
+		hosts[i].UploadBandwidthPrice = types.NewCurrency64(1)
+	}
+	expected := types.NewCurrency64(modules.SectorSize).Mul64(2 * 10).Add(types.NewCurrency64(modules.SectorSize))
+	if cost := medianSectorCost(hosts, 10); !cost.Equals(expected) {
+		t.Fatal("wrong median sector cost", cost, expected)
+	}
+	if cost := sectorCost(hosts[1], 10); !cost.Equals(expected) {
+		t.Fatal("wrong sector cost", cost, expected)
+	}
+
+	// Test computeMigrationThresholds. Every host counts once and the score
+	// of a host is its storage price.
+	policy := modules.RebalancePolicy{
+		MinScorePercentile: 0.5,
+		MaxCostRatio:       1.5,
+	}
+	scoreBreakdown := func(host modules.HostDBEntry) (modules.HostScoreBreakdown, error) {
+		return modules.HostScoreBreakdown{Score: host.StoragePrice}, nil
+	}
+	thresholds, err := computeMigrationThresholds(policy, hosts, 10, scoreBreakdown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !thresholds.minScore.Equals64(2) || !thresholds.maxCost.Equals(expected.MulFloat(1.5)) {
+		t.Fatal("wrong thresholds", thresholds.minScore, thresholds.maxCost)
+	}
+
+	// Test migrationReason.
+	if reason := migrationReason(policy, thresholds, hosts[0], types.NewCurrency64(1), 10); reason != modules.MigrationReasonLowScore {
+		t.Fatal("wrong reason", reason)
+	}
+	if reason := migrationReason(policy, thresholds, hosts[1], types.NewCurrency64(2), 10); reason != "" {
+		t.Fatal("wrong reason", reason)
+	}
+	overpriced := hosts[2]
+	overpriced.StoragePrice = types.NewCurrency64(10)
+	if reason := migrationReason(policy, thresholds, overpriced, types.NewCurrency64(10), 10); reason != modules.MigrationReasonOverpriced {
+		t.Fatal("wrong reason", reason)
+	}
+	policy.MaxCostRatio = 0
+	if reason := migrationReason(policy, thresholds, overpriced, types.NewCurrency64(10), 10); reason != "" {
+		t.Fatal("wrong reason", reason)
+	}
+
+	// Test migrationCost.
+	if cost := migrationCost(modules.SectorSize+1, types.NewCurrency64(3)); !cost.Equals64(6) {
+		t.Fatal("wrong migration cost", cost)
+	}
+}
@@ -0,0 +1,441 @@
+package renter
+
+// dedup.go implements the deduplication of chunks across siafiles. Files that
+// are uploaded with deduplication enabled share the renter's dedup master key.
+// Every chunk of such a file is assigned a DedupID which is derived from the
+// chunk's content and stored in the siafile. The encryption keys of the
+// chunk's pieces are derived from that id instead of the chunk's index within
+// the file. That way, chunks with the same content result in the same pieces
+// which allows a new chunk to reference the pieces of an existing chunk instead
+// of uploading them again. The data of deduplicated files is uploaded in the
+// layout implemented in deduplayout.go, which fills every chunk with whole
+// content-defined segments of the data. That way identical data results in
+// identical chunks independently of its offset within the files.
+//
+// The renter keeps an index of the DedupIDs of all deduplicated chunks. Each
+// entry tracks the pieces of the first chunk that was fully uploaded with the
+// id and the number of chunks referencing it. When a file is deleted, its
+// references are removed from the index and entries without references are
+// dropped. Changes to the index are persisted in batches by
+// threadedSaveDedupIndex and on shutdown instead of after every chunk. Losing
+// the most recent changes in a crash only loses opportunities for
+// deduplication since the pieces of every chunk are tracked by its siafile.
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem/siafile"
+	"github.com/turtledex/TurtleDexCore/persist"
+)
+
+const (
+	// dedupPersistFile is the name of the file the dedup index is persisted
+	// to.
+	dedupPersistFile = "dedup.json"
+)
+
+var (
+	// dedupCipherType is the type of the dedup master key.
+	dedupCipherType = crypto.TypeThreefish
+
+	// dedupPersistMetadata is the metadata of the dedup index persist file.
+	dedupPersistMetadata = persist.Metadata{
+		Header:  "Renter Dedup Index",
+		Version: "1.5.5",
+	}
+
+	// errDedupCipherType is returned when trying to upload a file with
+	// deduplication and a custom encryption key or a cipher type other than
+	// the dedup cipher type.
+	errDedupCipherType = errors.New("deduplicated uploads can't specify a custom encryption key and only support the threefish cipher type")
+)
+
+type (
+	// dedupIndex is the renter's index of deduplicated chunks.
+	dedupIndex struct {
+		entries map[siafile.DedupID]*dedupEntry
+
+		// dirty indicates that the index was changed since it was last
+		// persisted.
+		dirty bool
+
+		staticMasterKey   crypto.CipherKey
+		staticPersistPath string
+		mu                sync.Mutex
+	}
+
+	// dedupEntry is an entry of the dedup index.
+	dedupEntry struct {
+		ID          siafile.DedupID                `json:"id"`
+		ErasureCode modules.ErasureCoderIdentifier `json:"erasurecode"`
+		PieceSize   uint64                         `json:"piecesize"`
+
+		// Pieces contains one piece for every piece index of the chunk. It is
+		// empty until the first chunk with the entry's id was fully uploaded.
+		Pieces []siafile.Piece `json:"pieces"`
+
+		// References is the number of chunks that use the entry's id.
+		References uint64 `json:"references"`
+	}
+
+	// dedupPersistence is the persisted form of the dedup index.
+	dedupPersistence struct {
+		MasterKey []byte       `json:"masterkey"`
+		Entries   []dedupEntry `json:"entries"`
+	}
+)
+
+// chunkKeyIndex returns the index that is used to derive the encryption keys
+// of a chunk's pieces. Deduplicated chunks derive their keys from their
+// DedupID instead of their index within the file.
+func chunkKeyIndex(chunkIndex uint64, id siafile.DedupID) uint64 {
+	if id == (siafile.DedupID{}) {
+		return chunkIndex
+	}
+	return binary.LittleEndian.Uint64(id[:8])
+}
+
+// newDedupIndex loads the dedup index from disk or creates a new one.
+func newDedupIndex(persistDir string) (*dedupIndex, error) {
+	di := &dedupIndex{
+		entries:           make(map[siafile.DedupID]*dedupEntry),
+		staticPersistPath: filepath.Join(persistDir, dedupPersistFile),
+	}
+	var dp dedupPersistence
+	err := persist.LoadJSON(dedupPersistMetadata, &dp, di.staticPersistPath)
+	if os.IsNotExist(err) {
+		// No persistence yet, generate a new master key.
+		di.staticMasterKey = crypto.GenerateTurtleDexKey(dedupCipherType)
+		return di, di.saveSync()
+	} else if err != nil {
+		return nil, errors.AddContext(err, "failed to load dedup index")
+	}
+	di.staticMasterKey, err = crypto.NewTurtleDexKey(dedupCipherType, dp.MasterKey)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to load dedup master key")
+	}
+	for i := range dp.Entries {
+		entry := dp.Entries[i]
+		di.entries[entry.ID] = &entry
+	}
+	return di, nil
+}
+
+// saveSync persists the dedup index.
+func (di *dedupIndex) saveSync() error {
+	dp := dedupPersistence{
+		MasterKey: di.staticMasterKey.Key(),
+		Entries:   make([]dedupEntry, 0, len(di.entries)),
+	}
+	for _, entry := range di.entries {
+		dp.Entries = append(dp.Entries, *entry)
+	}
+	err := persist.SaveJSON(dedupPersistMetadata, dp, di.staticPersistPath)
+	if err != nil {
+		return err
+	}
+	di.dirty = false
+	return nil
+}
+
+// staticIsDedupKey returns whether a file's master key is the dedup master key
+// which means that the file was uploaded with deduplication.
+func (di *dedupIndex) staticIsDedupKey(mk crypto.CipherKey) bool {
+	return mk.Type() == di.staticMasterKey.Type() && bytes.Equal(mk.Key(), di.staticMasterKey.Key())
+}
+
+// staticComputeID computes the DedupID of a chunk from its data pieces. Since
+// the chunks of deduplicated files consist of whole content-defined segments
+// padded with zeros, the id identifies the segments independently of their
+// offset within the file. The id is a keyed hash to prevent others from learning
+// about the content of the chunks from the ids.
+func (di *dedupIndex) staticComputeID(ec modules.ErasureCoder, pieceSize uint64, dataPieces [][]byte) (id siafile.DedupID) {
+	h := crypto.NewHash()
+	_, _ = h.Write(di.staticMasterKey.Key())
+	_, _ = h.Write([]byte(ec.Identifier()))
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], pieceSize)
+	_, _ = h.Write(b[:])
+	for _, piece := range dataPieces {
+		_, _ = h.Write(piece)
+	}
+	copy(id[:], h.Sum(nil))
+	// The zero id indicates a chunk without deduplication.
+	if id == (siafile.DedupID{}) {
+		id[0] = 1
+	}
+	return id
+}
+
+// managedAddReference adds a reference to the entry with the given id,
+// creating it if necessary.
+func (di *dedupIndex) managedAddReference(id siafile.DedupID, ec modules.ErasureCoder, pieceSize uint64) {
+	di.mu.Lock()
+	defer di.mu.Unlock()
+	entry, exists := di.entries[id]
+	if !exists {
+		entry = &dedupEntry{
+			ID:          id,
+			ErasureCode: ec.Identifier(),
+			PieceSize:   pieceSize,
+		}
+		di.entries[id] = entry
+	}
+	entry.References++
+	di.dirty = true
+}
+
+// managedLookup returns the pieces of the entry with the given id if the entry
+// was fully uploaded using the same erasure code and piece size.
+func (di *dedupIndex) managedLookup(id siafile.DedupID, ec modules.ErasureCoder, pieceSize uint64) ([]siafile.Piece, bool) {
+	di.mu.Lock()
+	defer di.mu.Unlock()
+	entry, exists := di.entries[id]
+	if !exists || len(entry.Pieces) == 0 {
+		return nil, false
+	}
+	if entry.ErasureCode != ec.Identifier() || entry.PieceSize != pieceSize || len(entry.Pieces) != ec.NumPieces() {
+		return nil, false
+	}
+	return append([]siafile.Piece{}, entry.Pieces...), true
+}
+
+// managedSetPieces sets the pieces of the entry with the given id after a
+// chunk with that id was fully uploaded. Entries which already have pieces are
+// not updated.
+func (di *dedupIndex) managedSetPieces(id siafile.DedupID, pieceSets [][]siafile.Piece) {
+	di.mu.Lock()
+	defer di.mu.Unlock()
+	entry, exists := di.entries[id]
+	if !exists || len(entry.Pieces) > 0 {
+		return
+	}
+	pieces := make([]siafile.Piece, 0, len(pieceSets))
+	for _, pieceSet := range pieceSets {
+		if len(pieceSet) == 0 {
+			return // incomplete chunk
+		}
+		pieces = append(pieces, pieceSet[0])
+	}
+	entry.Pieces = pieces
+	di.dirty = true
+}
+
+// managedRemoveReferences removes a reference from the entries with the given
+// ids. Entries without references are removed from the index. The index is
+// persisted once for all ids, which are usually the ids of a whole file or
+// directory.
+func (di *dedupIndex) managedRemoveReferences(ids []siafile.DedupID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	di.mu.Lock()
+	defer di.mu.Unlock()
+	for _, id := range ids {
+		entry, exists := di.entries[id]
+		if !exists {
+			continue
+		}
+		entry.References--
+		if entry.References == 0 {
+			delete(di.entries, id)
+		}
+	}
+	di.dirty = true
+	return di.saveSync()
+}
+
+// managedSave persists the dedup index if it was changed since it was last
+// persisted.
+func (di *dedupIndex) managedSave() error {
+	di.mu.Lock()
+	defer di.mu.Unlock()
+	if !di.dirty {
+		return nil
+	}
+	return di.saveSync()
+}
+
+// managedStatus returns the status of the dedup index.
+func (di *dedupIndex) managedStatus() modules.DedupStatus {
+	di.mu.Lock()
+	defer di.mu.Unlock()
+	var ds modules.DedupStatus
+	for _, entry := range di.entries {
+		ds.UniqueChunks++
+		ds.References += entry.References
+		if len(entry.Pieces) > 0 && entry.References > 1 {
+			ds.SavedStorage += (entry.References - 1) * uint64(len(entry.Pieces)) * modules.SectorSize
+		}
+	}
+	return ds
+}
+
+// managedNumEntries returns the number of entries in the index.
+func (di *dedupIndex) managedNumEntries() int {
+	di.mu.Lock()
+	defer di.mu.Unlock()
+	return len(di.entries)
+}
+
+// DedupStatus returns the status of the renter's deduplication index.
+func (r *Renter) DedupStatus() (modules.DedupStatus, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.DedupStatus{}, err
+	}
+	defer r.tg.Done()
+	return r.staticDedupIndex.managedStatus(), nil
+}
+
+// threadedSaveDedupIndex periodically persists the changes to the dedup
+// index.
+func (r *Renter) threadedSaveDedupIndex() {
+	err := r.tg.Add()
+	if err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	for {
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-time.After(dedupSaveInterval):
+		}
+		if err := r.staticDedupIndex.managedSave(); err != nil {
+			r.log.Println("WARN: Unable to save dedup index:", err)
+		}
+	}
+}
+
+// managedDedupIDs returns the DedupIDs of all chunks of a file.
+func (r *Renter) managedDedupIDs(entry *filesystem.FileNode) ([]siafile.DedupID, error) {
+	if !r.staticDedupIndex.staticIsDedupKey(entry.MasterKey()) {
+		return nil, nil
+	}
+	var ids []siafile.DedupID
+	for chunkIndex := uint64(0); chunkIndex < entry.NumChunks(); chunkIndex++ {
+		id, err := entry.ChunkDedupID(chunkIndex)
+		if err != nil {
+			return nil, err
+		}
+		if id != (siafile.DedupID{}) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// managedDedupIDsOfPath returns the DedupIDs of all chunks of the file at the
+// given path.
+func (r *Renter) managedDedupIDsOfPath(siaPath modules.TurtleDexPath) (_ []siafile.DedupID, err error) {
+	entry, err := r.staticFileSystem.OpenTurtleDexFile(siaPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = errors.Compose(err, entry.Close())
+	}()
+	return r.managedDedupIDs(entry)
+}
+
+// managedDedupIDsOfDir returns the DedupIDs of all chunks of all files within
+// the directory at the given path and its subdirectories.
+func (r *Renter) managedDedupIDsOfDir(siaPath modules.TurtleDexPath) ([]siafile.DedupID, error) {
+	// Don't bother walking the directory if there is nothing to dereference.
+	if r.staticDedupIndex.managedNumEntries() == 0 {
+		return nil, nil
+	}
+	var paths []modules.TurtleDexPath
+	var mu sync.Mutex
+	flf := func(fi modules.FileInfo) {
+		mu.Lock()
+		paths = append(paths, fi.TurtleDexPath)
+		mu.Unlock()
+	}
+	err := r.staticFileSystem.CachedList(siaPath, true, flf, func(modules.DirectoryInfo) {})
+	if err != nil {
+		return nil, err
+	}
+	var ids []siafile.DedupID
+	for _, path := range paths {
+		fileIDs, err := r.managedDedupIDsOfPath(path)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, fileIDs...)
+	}
+	return ids, nil
+}
+
+// managedPersistDedupID persists a DedupID that was newly assigned to a chunk
+// while fetching its logical data and adds a reference for it to the index.
+func (r *Renter) managedPersistDedupID(uc *unfinishedUploadChunk) error {
+	if !uc.dedupIDAssigned {
+		return nil
+	}
+	err := uc.fileEntry.SetChunkDedupID(uc.staticIndex, uc.dedupID)
+	if err != nil {
+		return errors.AddContext(err, "failed to set dedup id of chunk")
+	}
+	r.staticDedupIndex.managedAddReference(uc.dedupID, uc.fileEntry.ErasureCode(), uc.fileEntry.PieceSize())
+	return nil
+}
+
+// managedDeduplicateChunk tries to satisfy a chunk with the pieces of an
+// existing chunk with the same DedupID. If successful, the chunk doesn't need
+// to be uploaded anymore and true is returned. The physical data of the chunk
+// needs to be available when calling this method.
+func (r *Renter) managedDeduplicateChunk(uc *unfinishedUploadChunk) (bool, error) {
+	if uc.staticDedupIndex == nil || uc.dedupID == (siafile.DedupID{}) {
+		return false, nil
+	}
+
+	// Only chunks without any pieces are deduplicated. Everything else is
+	// repaired the usual way.
+	uc.mu.Lock()
+	piecesCompleted := uc.piecesCompleted
+	uc.mu.Unlock()
+	if piecesCompleted > 0 {
+		return false, nil
+	}
+	pieces, found := r.staticDedupIndex.managedLookup(uc.dedupID, uc.fileEntry.ErasureCode(), uc.fileEntry.PieceSize())
+	if !found {
+		return false, nil
+	}
+	for pieceIndex, piece := range pieces {
+		err := uc.fileEntry.AddPiece(piece.HostPubKey, uc.staticIndex, uint64(pieceIndex), piece.MerkleRoot)
+		if err != nil {
+			return false, errors.AddContext(err, "failed to add deduplicated piece")
+		}
+	}
+
+	// Mark all pieces as completed and release their memory.
+	uc.mu.Lock()
+	var memoryReleased uint64
+	for pieceIndex, piece := range pieces {
+		delete(uc.unusedHosts, piece.HostPubKey.String())
+		if uc.pieceUsage[pieceIndex] {
+			continue
+		}
+		uc.pieceUsage[pieceIndex] = true
+		uc.piecesCompleted++
+		memoryReleased += uint64(len(uc.physicalChunkData[pieceIndex]))
+		uc.physicalChunkData[pieceIndex] = nil
+	}
+	uc.memoryReleased += memoryReleased
+	uc.mu.Unlock()
+	uc.staticMemoryManager.Return(memoryReleased)
+	r.repairLog.Printf("Deduplicated chunk %v of %s", uc.staticIndex, uc.staticTurtleDexPath)
+	return true, nil
+}
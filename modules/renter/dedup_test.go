@@ -0,0 +1,126 @@
+package renter
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/turtledex/TurtleDexCore/build"
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem/siafile"
+	"github.com/turtledex/fastrand"
+)
+
+// TestChunkKeyIndex tests chunkKeyIndex.
+func TestChunkKeyIndex(t *testing.T) {
+	// A chunk without a DedupID uses its index.
+	if ki := chunkKeyIndex(5, siafile.DedupID{}); ki != 5 {
+		t.Fatal("wrong key index", ki)
+	}
+	// A chunk with a DedupID uses the id independently of its index.
+	var id siafile.DedupID
+	fastrand.Read(id[:])
+	if chunkKeyIndex(0, id) != chunkKeyIndex(5, id) {
+		t.Fatal("key index depends on chunk index")
+	}
+}
+
+// TestDedupIndex tests the reference counting and persistence of the dedup
+// index.
+func TestDedupIndex(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir("renter", t.Name())
+	if err := os.MkdirAll(dir, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	di, err := newDedupIndex(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !di.staticIsDedupKey(di.staticMasterKey) {
+		t.Fatal("master key should be the dedup key")
+	}
+	if di.staticIsDedupKey(crypto.GenerateTurtleDexKey(dedupCipherType)) {
+		t.Fatal("random key shouldn't be the dedup key")
+	}
+
+	// The same data should result in the same id.
+	ec := modules.NewRSSubCodeDefault()
+	data := [][]byte{fastrand.Bytes(64)}
+	id := di.staticComputeID(ec, modules.SectorSize, data)
+	if id != di.staticComputeID(ec, modules.SectorSize, data) {
+		t.Fatal("ids don't match")
+	}
+	if id == di.staticComputeID(ec, modules.SectorSize, [][]byte{fastrand.Bytes(64)}) {
+		t.Fatal("ids of different data match")
+	}
+
+	// Add two references. The entry shouldn't be usable before its pieces are
+	// set.
+	for i := 0; i < 2; i++ {
+		di.managedAddReference(id, ec, modules.SectorSize)
+	}
+	if _, ok := di.managedLookup(id, ec, modules.SectorSize); ok {
+		t.Fatal("entry without pieces shouldn't be found")
+	}
+
+	// Set the pieces.
+	pieceSets := make([][]siafile.Piece, ec.NumPieces())
+	for i := range pieceSets {
+		pieceSets[i] = make([]siafile.Piece, 1)
+		fastrand.Read(pieceSets[i][0].MerkleRoot[:])
+	}
+	di.managedSetPieces(id, pieceSets)
+	pieces, ok := di.managedLookup(id, ec, modules.SectorSize)
+	if !ok || len(pieces) != ec.NumPieces() {
+		t.Fatal("entry should be found", ok, len(pieces))
+	}
+	if _, ok := di.managedLookup(id, ec, modules.SectorSize/2); ok {
+		t.Fatal("entry with different piece size shouldn't be found")
+	}
+	ds := di.managedStatus()
+	if ds.UniqueChunks != 1 || ds.References != 2 || ds.SavedStorage != uint64(ec.NumPieces())*modules.SectorSize {
+		t.Fatal("wrong status", ds)
+	}
+
+	// Changes are only persisted when the index is saved.
+	di2, err := newDedupIndex(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if di2.managedNumEntries() != 0 {
+		t.Fatal("entries shouldn't be persisted before saving")
+	}
+	if err := di.managedSave(); err != nil {
+		t.Fatal(err)
+	}
+	di2, err = newDedupIndex(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !di2.staticIsDedupKey(di.staticMasterKey) {
+		t.Fatal("master key wasn't persisted")
+	}
+	if !reflect.DeepEqual(di.entries, di2.entries) {
+		t.Fatal("entries weren't persisted")
+	}
+
+	// Remove the references one by one.
+	if err := di2.managedRemoveReferences([]siafile.DedupID{id}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := di2.managedLookup(id, ec, modules.SectorSize); !ok {
+		t.Fatal("entry with a remaining reference should be found")
+	}
+	if err := di2.managedRemoveReferences([]siafile.DedupID{id}); err != nil {
+		t.Fatal(err)
+	}
+	if di2.managedNumEntries() != 0 {
+		t.Fatal("entry without references should be removed")
+	}
+}
@@ -0,0 +1,370 @@
+package renter
+
+// deduplayout.go implements the layout in which the data of deduplicated files
+// is uploaded. Chunks are deduplicated as a whole, which means that identical
+// data is only deduplicated if it starts at the same offset within a chunk.
+// To find identical data at arbitrary offsets, the data is split into segments
+// at content-defined boundaries which are found using a gear rolling hash.
+// Every chunk is filled with as many whole segments as fit into it and padded
+// with zeros. A chunk always ends early at an anchor, which is a segment
+// boundary with an even rarer hash. Inserting or removing data only changes
+// the segments around the modification. Since chunks always start at a segment
+// boundary, the chunks after the modification line up with the same segments
+// again at the latest after the next anchor and therefore stay the same.
+//
+// The padded chunks are followed by an index containing the length of the
+// data within every chunk and a fixed-size footer. The layout of the uploaded
+// data is:
+//
+//   [data 0][padding] ... [data n-1][padding] [length 0] ... [length n-1] [footer]
+//
+// where every length is an 8 byte little endian integer and the footer
+// consists of the number of chunks, the size of the data and the
+// dedupLayoutMagic.
+//
+// Segments are between a 32nd and an 8th of a chunk long, which means that
+// the padding of a chunk that doesn't end at an anchor is less than an 8th of
+// the chunk. Together with the chunks ending at anchors, the padding is about
+// an 8th of the data. The padding is the price for finding identical data
+// independently of its offset.
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math/bits"
+	"sort"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+type (
+	// dedupLayoutReader is an io.Reader which lays out the data of the
+	// underlying reader in the layout of deduplicated files.
+	dedupLayoutReader struct {
+		staticSrc       io.Reader
+		staticChunkSize uint64
+
+		data    []byte
+		buf     bytes.Buffer
+		lengths []uint64
+		size    uint64
+		eof     bool
+		done    bool
+	}
+
+	// dedupLayoutStreamer is a Streamer which reads the data of a file that
+	// was uploaded in the layout of deduplicated files.
+	dedupLayoutStreamer struct {
+		staticStreamer  modules.Streamer
+		staticChunkSize uint64
+
+		// staticOffsets contains the offset of the data of every chunk
+		// followed by the size of the data.
+		staticOffsets []uint64
+
+		offset uint64
+	}
+)
+
+const (
+	// dedupLayoutFooterSize is the size of the footer at the end of the laid
+	// out data.
+	dedupLayoutFooterSize = 24
+
+	// dedupSegmentMinDivisor and dedupSegmentMaxDivisor determine the minimum
+	// and maximum length of a segment as a fraction of the chunk size.
+	dedupSegmentMinDivisor = 32
+	dedupSegmentMaxDivisor = 8
+
+	// dedupAnchorBits is the number of additional zero bits of the rolling
+	// hash which make a segment boundary an anchor. About one in 64 segment
+	// boundaries is an anchor.
+	dedupAnchorBits = 6
+)
+
+var (
+	// dedupLayoutMagic identifies the footer of the layout of deduplicated
+	// files.
+	dedupLayoutMagic = []byte("ttdxddp1")
+
+	// dedupGearSpecifier is the specifier used to derive the gear table.
+	dedupGearSpecifier = types.NewSpecifier("DedupGear")
+
+	// dedupGearTable maps every byte to a random value for the gear rolling
+	// hash.
+	dedupGearTable = func() (table [256]uint64) {
+		for i := range table {
+			h := crypto.HashAll(dedupGearSpecifier, uint64(i))
+			table[i] = binary.LittleEndian.Uint64(h[:8])
+		}
+		return table
+	}()
+
+	// errInvalidDedupLayout is returned when data doesn't match the layout of
+	// deduplicated files.
+	errInvalidDedupLayout = errors.New("invalid deduplicated data")
+)
+
+// dedupSegmentBounds returns the minimum and maximum length of a segment for
+// the given chunk size.
+func dedupSegmentBounds(chunkSize uint64) (minSize, maxSize uint64) {
+	minSize = chunkSize / dedupSegmentMinDivisor
+	if minSize == 0 {
+		minSize = 1
+	}
+	maxSize = chunkSize / dedupSegmentMaxDivisor
+	if maxSize < minSize {
+		maxSize = minSize
+	}
+	return minSize, maxSize
+}
+
+// dedupSegmentLength returns the length of the segment at the start of data.
+// The segment ends at the first content-defined boundary after the minimum
+// segment length. If there is no such boundary, the segment ends at the end of
+// data or after the maximum segment length.
+func dedupSegmentLength(data []byte, chunkSize uint64) uint64 {
+	// Boundaries are positions where the top bits of the rolling hash are
+	// zero. The number of bits is chosen so that a boundary is found after
+	// about as many bytes as the minimum segment length.
+	minSize, maxSize := dedupSegmentBounds(chunkSize)
+	maskBits := bits.Len64(minSize) - 1
+	if maskBits < 1 {
+		maskBits = 1
+	}
+	mask := ^uint64(0) << uint(64-maskBits)
+
+	end := uint64(len(data))
+	if end > maxSize {
+		end = maxSize
+	}
+	if end <= minSize {
+		return end
+	}
+	var h uint64
+	for i := minSize; i < end; i++ {
+		h = (h << 1) + dedupGearTable[data[i]]
+		if h&mask == 0 {
+			return i + 1
+		}
+	}
+	return end
+}
+
+// dedupIsAnchor returns true if the segment boundary at the end of data is an
+// anchor. Anchors are boundaries where even more top bits of the rolling hash
+// are zero.
+func dedupIsAnchor(data []byte, chunkSize uint64) bool {
+	if len(data) > 64 {
+		data = data[len(data)-64:]
+	}
+	var h uint64
+	for _, b := range data {
+		h = (h << 1) + dedupGearTable[b]
+	}
+	minSize, _ := dedupSegmentBounds(chunkSize)
+	maskBits := bits.Len64(minSize) - 1 + dedupAnchorBits
+	return h&(^uint64(0)<<uint(64-maskBits)) == 0
+}
+
+// dedupPackedLength returns the number of bytes at the start of data which
+// are packed into the next chunk. A chunk is filled with as many whole
+// segments as fit into it but always ends at an anchor. Chunks that start at
+// different segment boundaries eventually end at the same boundary when
+// filling them, but that might take many chunks. The anchors guarantee that
+// they line up again at the next anchor. Unless eof is set, data contains at
+// least a full chunk.
+func dedupPackedLength(data []byte, chunkSize uint64, eof bool) uint64 {
+	_, maxSize := dedupSegmentBounds(chunkSize)
+	if uint64(len(data)) > chunkSize {
+		data = data[:chunkSize]
+	}
+	var n uint64
+	for n < uint64(len(data)) {
+		length := dedupSegmentLength(data[n:], chunkSize)
+		// A segment which ends at the end of the chunk without reaching its
+		// maximum length might continue beyond the chunk.
+		if !eof && n+length == uint64(len(data)) && length < maxSize && n > 0 {
+			break
+		}
+		n += length
+		if dedupIsAnchor(data[:n], chunkSize) {
+			break
+		}
+	}
+	return n
+}
+
+// newDedupLayoutReader returns a reader which lays out the data read from r
+// in the layout of deduplicated files with the given chunk size.
+func newDedupLayoutReader(r io.Reader, chunkSize uint64) *dedupLayoutReader {
+	return &dedupLayoutReader{
+		staticSrc:       r,
+		staticChunkSize: chunkSize,
+		data:            make([]byte, 0, chunkSize),
+	}
+}
+
+// Read implements the io.Reader interface.
+func (dr *dedupLayoutReader) Read(p []byte) (int, error) {
+	for dr.buf.Len() == 0 {
+		if dr.done {
+			return 0, io.EOF
+		}
+		if err := dr.readChunk(); err != nil {
+			return 0, err
+		}
+	}
+	return dr.buf.Read(p)
+}
+
+// readChunk reads the segments of the next chunk from the underlying reader
+// and writes them to the output buffer, padded to a full chunk. After the last
+// chunk, the index and footer are written.
+func (dr *dedupLayoutReader) readChunk() error {
+	// Fill the data up to a full chunk.
+	if !dr.eof {
+		n, err := io.ReadFull(dr.staticSrc, dr.data[len(dr.data):dr.staticChunkSize])
+		dr.data = dr.data[:len(dr.data)+n]
+		if errors.Contains(err, io.EOF) || errors.Contains(err, io.ErrUnexpectedEOF) {
+			dr.eof = true
+		} else if err != nil {
+			return err
+		}
+	}
+	if len(dr.data) > 0 {
+		n := dedupPackedLength(dr.data, dr.staticChunkSize, dr.eof)
+		dr.buf.Write(dr.data[:n])
+		dr.buf.Write(make([]byte, dr.staticChunkSize-n))
+		dr.lengths = append(dr.lengths, n)
+		dr.size += n
+		dr.data = dr.data[:copy(dr.data, dr.data[n:])]
+		return nil
+	}
+
+	// The underlying reader is exhausted, write the index and footer.
+	var b [8]byte
+	for _, length := range dr.lengths {
+		binary.LittleEndian.PutUint64(b[:], length)
+		dr.buf.Write(b[:])
+	}
+	binary.LittleEndian.PutUint64(b[:], uint64(len(dr.lengths)))
+	dr.buf.Write(b[:])
+	binary.LittleEndian.PutUint64(b[:], dr.size)
+	dr.buf.Write(b[:])
+	dr.buf.Write(dedupLayoutMagic)
+	dr.done = true
+	return nil
+}
+
+// newDedupLayoutStreamer returns a Streamer which reads the data of s which
+// was laid out in the layout of deduplicated files with the given chunk size.
+// The returned streamer operates on the data before it was laid out.
+func newDedupLayoutStreamer(s modules.Streamer, chunkSize uint64) (modules.Streamer, error) {
+	// Read the footer.
+	layoutSize, err := s.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to determine size of deduplicated data")
+	}
+	if layoutSize < dedupLayoutFooterSize {
+		return nil, errors.AddContext(errInvalidDedupLayout, "data is too short")
+	}
+	footer := make([]byte, dedupLayoutFooterSize)
+	if err := streamerReadAt(s, footer, uint64(layoutSize-dedupLayoutFooterSize)); err != nil {
+		return nil, errors.AddContext(err, "failed to read footer")
+	}
+	if !bytes.Equal(footer[16:], dedupLayoutMagic) {
+		return nil, errors.AddContext(errInvalidDedupLayout, "footer is missing")
+	}
+	numChunks := binary.LittleEndian.Uint64(footer[:8])
+	size := binary.LittleEndian.Uint64(footer[8:16])
+	if numChunks > uint64(layoutSize) || numChunks*(chunkSize+8) != uint64(layoutSize-dedupLayoutFooterSize) {
+		return nil, errors.AddContext(errInvalidDedupLayout, "number of chunks doesn't match size")
+	}
+
+	// Read the index.
+	index := make([]byte, numChunks*8)
+	if err := streamerReadAt(s, index, numChunks*chunkSize); err != nil {
+		return nil, errors.AddContext(err, "failed to read index")
+	}
+	offsets := make([]uint64, 0, numChunks+1)
+	var offset uint64
+	for i := uint64(0); i < numChunks; i++ {
+		length := binary.LittleEndian.Uint64(index[i*8:])
+		if length == 0 || length > chunkSize {
+			return nil, errors.AddContext(errInvalidDedupLayout, "invalid chunk length")
+		}
+		offsets = append(offsets, offset)
+		offset += length
+	}
+	if offset != size {
+		return nil, errors.AddContext(errInvalidDedupLayout, "chunk lengths don't match size")
+	}
+	offsets = append(offsets, size)
+	return &dedupLayoutStreamer{
+		staticStreamer:  s,
+		staticChunkSize: chunkSize,
+		staticOffsets:   offsets,
+	}, nil
+}
+
+// streamerReadAt reads len(b) bytes from s starting at offset.
+func streamerReadAt(s io.ReadSeeker, b []byte, offset uint64) error {
+	if _, err := s.Seek(int64(offset), io.SeekStart); err != nil {
+		return err
+	}
+	_, err := io.ReadFull(s, b)
+	return err
+}
+
+// Close implements the io.Closer interface.
+func (ds *dedupLayoutStreamer) Close() error {
+	return ds.staticStreamer.Close()
+}
+
+// Read implements the io.Reader interface. A single call reads at most until
+// the end of the data of the chunk containing the current offset.
+func (ds *dedupLayoutStreamer) Read(p []byte) (int, error) {
+	numChunks := len(ds.staticOffsets) - 1
+	if ds.offset >= ds.staticOffsets[numChunks] {
+		return 0, io.EOF
+	}
+	chunk := sort.Search(numChunks, func(i int) bool {
+		return ds.staticOffsets[i+1] > ds.offset
+	})
+	if remaining := ds.staticOffsets[chunk+1] - ds.offset; uint64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	physicalOffset := uint64(chunk)*ds.staticChunkSize + ds.offset - ds.staticOffsets[chunk]
+	if err := streamerReadAt(ds.staticStreamer, p, physicalOffset); err != nil {
+		return 0, errors.AddContext(err, "failed to read deduplicated data")
+	}
+	ds.offset += uint64(len(p))
+	return len(p), nil
+}
+
+// Seek implements the io.Seeker interface. Offsets are relative to the data
+// before it was laid out.
+func (ds *dedupLayoutStreamer) Seek(offset int64, whence int) (int64, error) {
+	var newOffset int64
+	switch whence {
+	case io.SeekStart:
+		newOffset = offset
+	case io.SeekCurrent:
+		newOffset = int64(ds.offset) + offset
+	case io.SeekEnd:
+		newOffset = int64(ds.staticOffsets[len(ds.staticOffsets)-1]) + offset
+	default:
+		return int64(ds.offset), errors.New("invalid value for 'whence' in call to seek")
+	}
+	if newOffset < 0 {
+		return int64(ds.offset), errors.New("cannot seek to negative offset")
+	}
+	ds.offset = uint64(newOffset)
+	return newOffset, nil
+}
@@ -0,0 +1,167 @@
+package renter
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/turtledex/errors"
+	"github.com/turtledex/fastrand"
+)
+
+// dedupTestStreamer wraps a bytes.Reader to implement the Streamer interface.
+type dedupTestStreamer struct {
+	*bytes.Reader
+}
+
+// Close implements the io.Closer interface.
+func (dedupTestStreamer) Close() error { return nil }
+
+// layoutDedupData lays out data in the layout of deduplicated files.
+func layoutDedupData(t *testing.T, data []byte, chunkSize uint64) []byte {
+	laidOut, err := ioutil.ReadAll(newDedupLayoutReader(bytes.NewReader(data), chunkSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return laidOut
+}
+
+// TestDedupLayoutRoundTrip tests laying out data and reading it back using a
+// dedup layout streamer.
+func TestDedupLayoutRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	chunkSize := uint64(4096)
+	sizes := []int{0, 1, int(chunkSize), 10*int(chunkSize) + 123}
+	for _, size := range sizes {
+		data := fastrand.Bytes(size)
+		laidOut := layoutDedupData(t, data, chunkSize)
+		if (uint64(len(laidOut))-dedupLayoutFooterSize)%(chunkSize+8) != 0 {
+			t.Fatal("chunks aren't padded to full chunks", len(laidOut))
+		}
+
+		ds, err := newDedupLayoutStreamer(dedupTestStreamer{bytes.NewReader(laidOut)}, chunkSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		end, err := ds.Seek(0, io.SeekEnd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if end != int64(size) {
+			t.Fatalf("wrong size %v != %v", end, size)
+		}
+		if _, err := ds.Seek(0, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		read, err := ioutil.ReadAll(ds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(read, data) {
+			t.Fatal("data doesn't match")
+		}
+
+		// Read random ranges.
+		for i := 0; i < 10 && size > 0; i++ {
+			off := fastrand.Intn(size)
+			length := fastrand.Intn(size-off) + 1
+			if _, err := ds.Seek(int64(off), io.SeekStart); err != nil {
+				t.Fatal(err)
+			}
+			b := make([]byte, length)
+			if _, err := io.ReadFull(ds, b); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(b, data[off:off+length]) {
+				t.Fatal("range doesn't match", off, length)
+			}
+		}
+	}
+
+	// Data without a footer is rejected.
+	_, err := newDedupLayoutStreamer(dedupTestStreamer{bytes.NewReader(fastrand.Bytes(100))}, chunkSize)
+	if !errors.Contains(err, errInvalidDedupLayout) {
+		t.Fatal("expected errInvalidDedupLayout but got", err)
+	}
+}
+
+// TestDedupLayoutShiftedData tests that data which is shifted by inserting a
+// prefix results in mostly the same chunks.
+func TestDedupLayoutShiftedData(t *testing.T) {
+	t.Parallel()
+
+	chunkSize := uint64(4096)
+	data := fastrand.Bytes(50 * int(chunkSize))
+	shifted := append(fastrand.Bytes(100), data...)
+
+	chunks := func(laidOut []byte) map[string]struct{} {
+		m := make(map[string]struct{})
+		for off := uint64(0); off+chunkSize <= uint64(len(laidOut)); off += chunkSize {
+			m[string(laidOut[off:off+chunkSize])] = struct{}{}
+		}
+		return m
+	}
+	laidOut := layoutDedupData(t, data, chunkSize)
+	original := chunks(laidOut)
+	var shared int
+	for chunk := range chunks(layoutDedupData(t, shifted, chunkSize)) {
+		if _, exists := original[chunk]; exists {
+			shared++
+		}
+	}
+	if shared < len(original)/2 {
+		t.Fatalf("only %v of %v chunks are shared", shared, len(original))
+	}
+
+	// Packing the segments keeps the padding small.
+	if overhead := float64(len(laidOut)) / float64(len(data)); overhead > 1.25 {
+		t.Fatal("too much padding", overhead)
+	}
+}
+
+// TestDedupPackedLength is a unit test for dedupPackedLength.
+func TestDedupPackedLength(t *testing.T) {
+	t.Parallel()
+
+	chunkSize := uint64(4096)
+	_, maxSize := dedupSegmentBounds(chunkSize)
+	data := fastrand.Bytes(2 * int(chunkSize))
+
+	// A chunk is filled with whole segments up to the first anchor. Without
+	// an anchor, the padding is smaller than the maximum segment length.
+	n := dedupPackedLength(data, chunkSize, false)
+	if n > chunkSize {
+		t.Fatal("wrong packed length", n)
+	}
+	var end uint64
+	for end < n {
+		end += dedupSegmentLength(data[end:], chunkSize)
+		if end < n && dedupIsAnchor(data[:end], chunkSize) {
+			t.Fatal("chunk doesn't end at the first anchor", end, n)
+		}
+	}
+	if end != n {
+		t.Fatal("chunk doesn't end at a segment boundary", end, n)
+	}
+	if !dedupIsAnchor(data[:n], chunkSize) && chunkSize-n >= maxSize {
+		t.Fatal("too much padding", n)
+	}
+
+	// At the end of the data, all remaining data is packed if it fits and
+	// there is no anchor.
+	for _, size := range []uint64{chunkSize / 2, chunkSize} {
+		expected := size
+		for end := uint64(0); end < size; {
+			end += dedupSegmentLength(data[end:size], chunkSize)
+			if end < size && dedupIsAnchor(data[:end], chunkSize) {
+				expected = end
+				break
+			}
+		}
+		if n := dedupPackedLength(data[:size], chunkSize, true); n != expected {
+			t.Fatal("wrong packed length", n, expected)
+		}
+	}
+}
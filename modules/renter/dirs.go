@@ -6,6 +6,7 @@ import (
 	"sync"
 
 	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem"
 	"github.com/turtledex/errors"
 )
 
@@ -26,7 +27,22 @@ func (r *Renter) DeleteDir(siaPath modules.TurtleDexPath) error {
 		return err
 	}
 	defer r.tg.Done()
-	return r.staticFileSystem.DeleteDir(siaPath)
+
+	// Get the DedupIDs of the files within the dir before deleting it.
+	dedupIDs, err := r.managedDedupIDsOfDir(siaPath)
+	if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+		return errors.AddContext(err, "unable to fetch dedup ids of directory")
+	}
+	err = r.staticFileSystem.DeleteDir(siaPath)
+	if err != nil {
+		return err
+	}
+
+	// Remove the files' references from the dedup index.
+	if err := r.staticDedupIndex.managedRemoveReferences(dedupIDs); err != nil {
+		r.log.Printf("Unable to remove dedup references of deleted dir %v: %v", siaPath, err)
+	}
+	return nil
 }
 
 // DirList lists the directories in a ttdxdir
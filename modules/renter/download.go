@@ -77,6 +77,7 @@ type (
 		offset              uint64        // Offset within the file to start the download. Must be less than the total filesize.
 		overdrive           int           // How many extra pieces to download to prevent slow hosts from being a bottleneck.
 		priority            uint64        // Files with a higher priority will be downloaded first.
+		stream              bool          // Whether the file's data is streamed instead of downloading its chunks.
 		staticMemoryManager *memoryManager
 	}
 )
@@ -265,19 +266,20 @@ func (r *Renter) managedDownload(p modules.RenterDownloadParameters) (_ *downloa
 	if p.Destination != "" && !filepath.IsAbs(p.Destination) {
 		return nil, errors.New("destination must be an absolute path")
 	}
-	if p.Offset == entry.Size() && entry.Size() != 0 {
+	size := entry.LogicalSize()
+	if p.Offset == size && size != 0 {
 		return nil, errors.New("offset equals filesize")
 	}
 	// Sentinel: if length == 0, download the entire file.
 	if p.Length == 0 {
-		if p.Offset > entry.Size() {
+		if p.Offset > size {
 			return nil, errors.New("offset cannot be greater than file size")
 		}
-		p.Length = entry.Size() - p.Offset
+		p.Length = size - p.Offset
 	}
 	// Check whether offset and length is valid.
-	if p.Offset < 0 || p.Offset+p.Length > entry.Size() {
-		return nil, fmt.Errorf("offset and length combination invalid, max byte is at index %d", entry.Size()-1)
+	if p.Offset < 0 || p.Offset+p.Length > size {
+		return nil, fmt.Errorf("offset and length combination invalid, max byte is at index %d", size-1)
 	}
 
 	// Instantiate the correct downloadWriter implementation.
@@ -308,8 +310,16 @@ func (r *Renter) managedDownload(p modules.RenterDownloadParameters) (_ *downloa
 		}
 	}
 
-	// Prepare snapshot.
-	snap, err := entry.SnapshotRange(p.TurtleDexPath, p.Offset, p.Length)
+	// Prepare snapshot. The offset and length of compressed and deduplicated
+	// files refer to the data before it was compressed or laid out which is
+	// why their data is streamed instead.
+	stream := entry.Compression() != modules.CompressionNone || entry.DedupLayout()
+	var snap *siafile.Snapshot
+	if stream {
+		snap, err = entry.Snapshot(p.TurtleDexPath)
+	} else {
+		snap, err = entry.SnapshotRange(p.TurtleDexPath, p.Offset, p.Length)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -327,6 +337,7 @@ func (r *Renter) managedDownload(p modules.RenterDownloadParameters) (_ *downloa
 		offset:        p.Offset,
 		overdrive:     3, // TODO: moderate default until full overdrive support is added.
 		priority:      5, // TODO: moderate default until full priority support is added.
+		stream:        stream,
 
 		staticMemoryManager: r.userDownloadMemoryManager, // user initiated download
 	})
@@ -374,7 +385,7 @@ func (r *Renter) managedNewDownload(params downloadParams) (*download, error) {
 	if params.offset < 0 {
 		return nil, errors.New("download offset cannot be a negative number")
 	}
-	if params.offset+params.length > params.file.Size() {
+	if !params.stream && params.offset+params.length > params.file.Size() {
 		return nil, errors.New("download is requesting data past the boundary of the file")
 	}
 
@@ -422,8 +433,14 @@ func (d *download) Start() error {
 		return nil
 	}
 
-	// Determine which chunks to download.
+	// Streamed downloads don't download the chunks themselves.
 	params := d.staticParams
+	if params.stream {
+		go d.threadedStream(params)
+		return nil
+	}
+
+	// Determine which chunks to download.
 	minChunk, minChunkOffset := params.file.ChunkIndexByOffset(params.offset)
 	maxChunk, maxChunkOffset := params.file.ChunkIndexByOffset(params.offset + params.length)
 
@@ -473,6 +490,7 @@ func (d *download) Start() error {
 			masterKey:   params.file.MasterKey(),
 
 			staticChunkIndex: i,
+			staticKeyIndex:   chunkKeyIndex(i, params.file.ChunkDedupID(i)),
 			staticCacheID:    fmt.Sprintf("%v:%v", d.staticTurtleDexPath, i),
 			staticChunkMap:   chunkMaps[i-minChunk],
 			staticChunkSize:  params.file.ChunkSize(),
@@ -536,6 +554,67 @@ func (d *download) Start() error {
 	return nil
 }
 
+// threadedStream performs a download by streaming the requested range of the
+// file's data to the destination.
+func (d *download) threadedStream(params downloadParams) {
+	err := d.managedStream(params)
+	if err != nil {
+		d.managedFail(err)
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.staticComplete() {
+		d.markComplete()
+	}
+}
+
+// managedStream streams the requested range of the file's data to the
+// destination. The data is written in chunk sized writes which is what the
+// destinations expect.
+func (d *download) managedStream(params downloadParams) (err error) {
+	if err := d.r.tg.Add(); err != nil {
+		return err
+	}
+	defer d.r.tg.Done()
+
+	s, err := d.r.managedFileStreamer(params.file, params.disableLocalFetch)
+	if err != nil {
+		return errors.AddContext(err, "unable to create streamer")
+	}
+	defer func() {
+		err = errors.Compose(err, s.Close())
+	}()
+	_, err = s.Seek(int64(params.offset), io.SeekStart)
+	if err != nil {
+		return errors.AddContext(err, "unable to seek to download offset")
+	}
+
+	ec := modules.NewPassthroughErasureCoder()
+	buf := make([]byte, params.file.ChunkSize())
+	for written := uint64(0); written < params.length; {
+		if d.staticComplete() {
+			return errors.New("download was completed before all data was streamed")
+		}
+		n := params.length - written
+		if n > uint64(len(buf)) {
+			n = uint64(len(buf))
+		}
+		_, err = io.ReadFull(s, buf[:n])
+		if err != nil {
+			return errors.AddContext(err, "unable to read data from streamer")
+		}
+		err = params.destination.WritePieces(ec, [][]byte{buf[:n]}, 0, int64(written), n)
+		if err != nil {
+			return errors.AddContext(err, "unable to write data to destination")
+		}
+		written += n
+		atomic.AddUint64(&d.atomicDataReceived, n)
+		atomic.AddUint64(&d.atomicTotalDataTransferred, n)
+	}
+	return nil
+}
+
 // DownloadByUID returns a single download from the history by it's UID.
 func (r *Renter) DownloadByUID(uid modules.DownloadID) (modules.DownloadInfo, bool) {
 	r.downloadHistoryMu.Lock()
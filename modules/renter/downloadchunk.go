@@ -41,7 +41,8 @@ type unfinishedDownloadChunk struct {
 	masterKey   crypto.CipherKey
 
 	// Fetch + Write instructions - read only or otherwise thread safe.
-	staticChunkIndex  uint64                       // Required for deriving the encryption keys for each piece.
+	staticChunkIndex  uint64                       // Index of the chunk within the file.
+	staticKeyIndex    uint64                       // Required for deriving the encryption keys for each piece.
 	staticCacheID     string                       // Used to uniquely identify a chunk in the chunk cache.
 	staticChunkMap    map[string]downloadPieceInfo // Maps from host PubKey to the info for the piece associated with that host
 	staticChunkSize   uint64
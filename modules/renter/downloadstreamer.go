@@ -491,7 +491,10 @@ func (r *Renter) Streamer(siaPath modules.TurtleDexPath, disableLocalFetch bool)
 	if err != nil {
 		return "", nil, err
 	}
-	s := r.managedStreamer(snap, disableLocalFetch)
+	s, err := r.managedFileStreamer(snap, disableLocalFetch)
+	if err != nil {
+		return "", nil, err
+	}
 	return siaPath.String(), s, nil
 }
 
@@ -510,8 +513,27 @@ func (r *Renter) StreamerByNode(node *filesystem.FileNode, disableLocalFetch boo
 	if err != nil {
 		return nil, err
 	}
-	s := r.managedStreamer(snap, disableLocalFetch)
-	return s, nil
+	return r.managedFileStreamer(snap, disableLocalFetch)
+}
+
+// managedFileStreamer creates a streamer for the data of a siafile snapshot.
+// The data is read from the layout of deduplicated files if the file is
+// deduplicated and decompressed if the file is compressed.
+func (r *Renter) managedFileStreamer(snapshot *siafile.Snapshot, disableLocalFetch bool) (_ modules.Streamer, err error) {
+	s := r.managedStreamer(snapshot, disableLocalFetch)
+	defer func() {
+		if err != nil {
+			err = errors.Compose(err, s.Close())
+		}
+	}()
+	var ls modules.Streamer = s
+	if snapshot.DedupLayout() {
+		ls, err = newDedupLayoutStreamer(s, snapshot.ChunkSize())
+		if err != nil {
+			return nil, err
+		}
+	}
+	return modules.NewDecompressionStreamer(snapshot.Compression(), ls)
 }
 
 // managedStreamer creates a streamer from a siafile snapshot and starts filling
@@ -1,7 +1,11 @@
 package renter
 
 import (
+	"time"
+
 	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem"
+	"github.com/turtledex/TurtleDexCore/persist"
 
 	"github.com/turtledex/errors"
 )
@@ -15,12 +19,56 @@ func (r *Renter) DeleteFile(siaPath modules.TurtleDexPath) error {
 	}
 	defer r.tg.Done()
 
+	// Retain the file as a version instead if versioning is enabled.
+	policy, err := r.managedVersioningPolicy(siaPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to get versioning policy")
+	}
+	if !policy.Enabled() {
+		return r.managedDeleteFile(siaPath)
+	}
+	err = r.managedArchiveFile(siaPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to retain siafile as version")
+	}
+	err = r.managedPruneFileVersions(siaPath, policy)
+	if err != nil {
+		r.log.Printf("Unable to prune versions of %v: %v", siaPath, err)
+	}
+	return nil
+}
+
+// DeleteFileUnversioned removes a file entry from the renter and deletes its
+// data from the hosts without retaining it as a version.
+func (r *Renter) DeleteFileUnversioned(siaPath modules.TurtleDexPath) error {
+	err := r.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	return r.managedDeleteFile(siaPath)
+}
+
+// managedDeleteFile removes a file entry from the renter and deletes its data
+// from the hosts, regardless of the versioning policy.
+func (r *Renter) managedDeleteFile(siaPath modules.TurtleDexPath) error {
+	// Get the DedupIDs of the file's chunks before deleting it.
+	dedupIDs, err := r.managedDedupIDsOfPath(siaPath)
+	if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+		return errors.AddContext(err, "unable to fetch dedup ids of siafile")
+	}
+
 	// Perform the delete operation.
 	err = r.staticFileSystem.DeleteFile(siaPath)
 	if err != nil {
 		return errors.AddContext(err, "unable to delete siafile from filesystem")
 	}
 
+	// Remove the file's references from the dedup index.
+	if err := r.staticDedupIndex.managedRemoveReferences(dedupIDs); err != nil {
+		r.log.Printf("Unable to remove dedup references of deleted siafile %v: %v", siaPath, err)
+	}
+
 	// Update the filesystem metadata.
 	//
 	// TODO: This is incorrect, should be running the metadata update call on a
@@ -90,7 +138,13 @@ func (r *Renter) RenameFile(currentName, newName modules.TurtleDexPath) error {
 		return err
 	}
 	defer r.tg.Done()
+	return r.managedRenameFile(currentName, newName)
+}
 
+// managedRenameFile takes an existing file and changes the nickname. The
+// original file must exist, and there must not be any file that already has
+// the replacement nickname.
+func (r *Renter) managedRenameFile(currentName, newName modules.TurtleDexPath) error {
 	// Rename file.
 	err := r.staticFileSystem.RenameFile(currentName, newName)
 	if err != nil {
@@ -120,6 +174,67 @@ func (r *Renter) RenameFile(currentName, newName modules.TurtleDexPath) error {
 	return nil
 }
 
+// ReplaceFile renames the file at src to dst, replacing the file at dst if
+// there is one.
+func (r *Renter) ReplaceFile(src, dst modules.TurtleDexPath) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	return r.managedReplaceFile(src, dst)
+}
+
+// managedReplaceFile renames the file at src to dst, replacing the file at dst
+// if there is one. The replaced file is moved aside first and moved back into
+// place if the rename fails. Once the rename succeeded, it is retained as a
+// version if versioning is enabled for dst and deleted otherwise.
+func (r *Renter) managedReplaceFile(src, dst modules.TurtleDexPath) error {
+	policy, err := r.managedVersioningPolicy(dst)
+	if err != nil {
+		return errors.AddContext(err, "unable to get versioning policy")
+	}
+	return r.managedReplaceFileWithPolicy(src, dst, policy)
+}
+
+// managedReplaceFileWithPolicy replaces the file at dst with the file at src
+// like managedReplaceFile but uses the provided versioning policy instead of
+// the one of dst.
+func (r *Renter) managedReplaceFileWithPolicy(src, dst modules.TurtleDexPath, policy modules.VersioningPolicy) (err error) {
+	var aside modules.TurtleDexPath
+	if policy.Enabled() {
+		aside, err = versionPath(dst, newVersionID(time.Now()))
+	} else {
+		aside, err = modules.TempFolder.Join(persist.RandomSuffix())
+	}
+	if err != nil {
+		return err
+	}
+
+	// Move the replaced file aside.
+	err = r.managedRenameFile(dst, aside)
+	if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+		return errors.AddContext(err, "unable to move replaced file aside")
+	}
+	replaced := err == nil
+
+	// Rename the file and restore the replaced file on failure.
+	err = r.managedRenameFile(src, dst)
+	if err != nil && replaced {
+		err = errors.Compose(err, errors.AddContext(r.managedRenameFile(aside, dst), "unable to restore replaced file"))
+	}
+	if err != nil || !replaced {
+		return err
+	}
+	if !policy.Enabled() {
+		return errors.AddContext(r.managedDeleteFile(aside), "unable to delete replaced file")
+	}
+	err = r.managedPruneFileVersions(dst, policy)
+	if err != nil {
+		r.log.Printf("Unable to prune versions of %v: %v", dst, err)
+	}
+	return nil
+}
+
 // SetFileStuck sets the Stuck field of the whole siafile to stuck.
 func (r *Renter) SetFileStuck(siaPath modules.TurtleDexPath, stuck bool) (err error) {
 	if err := r.tg.Add(); err != nil {
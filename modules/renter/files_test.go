@@ -15,6 +15,7 @@ import (
 	"github.com/turtledex/TurtleDexCore/crypto"
 	"github.com/turtledex/TurtleDexCore/modules"
 	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem/siafile"
 	"github.com/turtledex/TurtleDexCore/persist"
 )
 
@@ -449,6 +450,86 @@ func TestRenterRenameFile(t *testing.T) {
 	}
 }
 
+// TestRenterReplaceFile probes managedReplaceFile.
+func TestRenterReplaceFile(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	// uidOf returns the UID of the file at the given path.
+	uidOf := func(siaPath modules.TurtleDexPath) siafile.TurtleDexfileUID {
+		entry, err := r.staticFileSystem.OpenTurtleDexFile(siaPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer entry.Close()
+		return entry.UID()
+	}
+
+	// Replace a file that doesn't exist.
+	src, dst := newTurtleDexPath("src"), newTurtleDexPath("dst")
+	entry, err := r.createRenterTestFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcUID := entry.UID()
+	if err := entry.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.managedReplaceFile(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	if uidOf(dst) != srcUID {
+		t.Fatal("destination wasn't replaced by the source")
+	}
+
+	// Replace an existing file.
+	entry, err = r.createRenterTestFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcUID = entry.UID()
+	if err := entry.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.managedReplaceFile(src, dst); err != nil {
+		t.Fatal(err)
+	}
+	if uidOf(dst) != srcUID {
+		t.Fatal("destination wasn't replaced by the source")
+	}
+	if _, err := r.staticFileSystem.OpenTurtleDexFile(src); !errors.Contains(err, filesystem.ErrNotExist) {
+		t.Fatal("source should be gone", err)
+	}
+
+	// A failed rename leaves the destination untouched.
+	if err := r.managedReplaceFile(src, dst); !errors.Contains(err, filesystem.ErrNotExist) {
+		t.Fatal("expected ErrNotExist", err)
+	}
+	if uidOf(dst) != srcUID {
+		t.Fatal("destination wasn't restored")
+	}
+
+	// The replaced files shouldn't be left behind.
+	files, err := r.FileListCollect(modules.RootTurtleDexPath(), true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Fatal("unexpected number of files", len(files))
+	}
+}
+
 // TestRenterFileDir tests that the renter files are uploaded to the files
 // directory and not the root directory of the renter.
 func TestRenterFileDir(t *testing.T) {
@@ -167,6 +167,28 @@ func (n *DirNode) UpdateMetadata(md ttdxdir.Metadata) error {
 	return sd.UpdateMetadata(md)
 }
 
+// UpdateVersioning is a wrapper for TurtleDexDir.UpdateVersioning.
+func (n *DirNode) UpdateVersioning(policy modules.VersioningPolicy) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	sd, err := n.siaDir()
+	if err != nil {
+		return err
+	}
+	return sd.UpdateVersioning(policy)
+}
+
+// UpdateRedundancyPolicy is a wrapper for TurtleDexDir.UpdateRedundancyPolicy.
+func (n *DirNode) UpdateRedundancyPolicy(policy modules.RedundancyPolicy) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	sd, err := n.siaDir()
+	if err != nil {
+		return err
+	}
+	return sd.UpdateRedundancyPolicy(policy)
+}
+
 // managedList returns the files and dirs within the TurtleDexDir specified by siaPath.
 // offlineMap, goodForRenewMap and contractMap don't need to be provided if
 // 'cached' is set to 'true'.
@@ -615,6 +637,9 @@ func (n *DirNode) managedInfo(siaPath modules.TurtleDexPath) (modules.DirectoryI
 		// Skynet Fields
 		SkynetFiles: metadata.SkynetFiles,
 		SkynetSize:  metadata.SkynetSize,
+
+		Versioning:       metadata.Versioning,
+		RedundancyPolicy: metadata.RedundancyPolicy,
 	}, nil
 }
 
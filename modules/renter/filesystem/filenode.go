@@ -36,6 +36,19 @@ func (n *FileNode) AddPiece(pk types.TurtleDexPublicKey, chunkIndex, pieceIndex
 	return n.TurtleDexFile.AddPiece(pk, chunkIndex, pieceIndex, merkleRoot)
 }
 
+// RemovePiece wraps siafile.RemovePiece to guarantee that it's not called when
+// the fileNode was already closed.
+func (n *FileNode) RemovePiece(pk types.TurtleDexPublicKey, chunkIndex, pieceIndex uint64, merkleRoot crypto.Hash) (err error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.closed {
+		err := errors.New("RemovePiece called on close FileNode")
+		build.Critical(err)
+		return err
+	}
+	return n.TurtleDexFile.RemovePiece(pk, chunkIndex, pieceIndex, merkleRoot)
+}
+
 // Close calls close on the FileNode and also removes the FileNode from its
 // parent if it's no longer being used and if it doesn't have any children which
 // are currently in use. This happens iteratively for all parent as long as
@@ -122,9 +135,10 @@ func (n *FileNode) managedFileInfo(siaPath modules.TurtleDexPath, offline map[st
 		Available:        redundancy >= 1,
 		ChangeTime:       n.ChangeTime(),
 		CipherType:       n.MasterKey().Type().String(),
+		Compression:      n.Compression(),
 		CreateTime:       n.CreateTime(),
 		Expiration:       n.Expiration(contracts),
-		Filesize:         n.Size(),
+		Filesize:         n.LogicalSize(),
 		Health:           health,
 		LocalPath:        localPath,
 		MaxHealth:        maxHealth,
@@ -205,9 +219,10 @@ func (n *FileNode) staticCachedInfo(siaPath modules.TurtleDexPath) (modules.File
 		Available:        md.CachedUserRedundancy >= 1,
 		ChangeTime:       md.ChangeTime,
 		CipherType:       md.StaticMasterKeyType.String(),
+		Compression:      md.Compression,
 		CreateTime:       md.CreateTime,
 		Expiration:       md.CachedExpiration,
-		Filesize:         uint64(md.FileSize),
+		Filesize:         md.LogicalSize(),
 		Health:           md.CachedHealth,
 		LocalPath:        localPath,
 		MaxHealth:        maxHealth,
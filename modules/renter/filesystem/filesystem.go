@@ -387,6 +387,30 @@ func (fs *FileSystem) UpdateDirMetadata(siaPath modules.TurtleDexPath, metadata
 	return dir.UpdateMetadata(metadata)
 }
 
+// UpdateDirVersioning updates the versioning policy of a TurtleDexDir.
+func (fs *FileSystem) UpdateDirVersioning(siaPath modules.TurtleDexPath, policy modules.VersioningPolicy) (err error) {
+	dir, err := fs.OpenTurtleDexDir(siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, dir.Close())
+	}()
+	return dir.UpdateVersioning(policy)
+}
+
+// UpdateDirRedundancyPolicy updates the redundancy policy of a TurtleDexDir.
+func (fs *FileSystem) UpdateDirRedundancyPolicy(siaPath modules.TurtleDexPath, policy modules.RedundancyPolicy) (err error) {
+	dir, err := fs.OpenTurtleDexDir(siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, dir.Close())
+	}()
+	return dir.UpdateRedundancyPolicy(policy)
+}
+
 // managedTurtleDexPath returns the TurtleDexPath of a node.
 func (fs *FileSystem) managedTurtleDexPath(n *node) modules.TurtleDexPath {
 	return nodeTurtleDexPath(fs.managedAbsPath(), n)
@@ -319,6 +319,8 @@ func (sd *TurtleDexDir) UpdateBubbledMetadata(metadata Metadata) error {
 	defer sd.mu.Unlock()
 	metadata.Mode = sd.metadata.Mode
 	metadata.Version = sd.metadata.Version
+	metadata.Versioning = sd.metadata.Versioning
+	metadata.RedundancyPolicy = sd.metadata.RedundancyPolicy
 	return sd.updateMetadata(metadata)
 }
 
@@ -333,6 +335,26 @@ func (sd *TurtleDexDir) UpdateLastHealthCheckTime(aggregateLastHealthCheckTime,
 	return sd.updateMetadata(md)
 }
 
+// UpdateVersioning updates the versioning policy of the TurtleDexDir and saves
+// the changes to disk.
+func (sd *TurtleDexDir) UpdateVersioning(policy modules.VersioningPolicy) error {
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+	md := sd.metadata
+	md.Versioning = policy
+	return sd.updateMetadata(md)
+}
+
+// UpdateRedundancyPolicy updates the redundancy policy of the TurtleDexDir and
+// saves the changes to disk.
+func (sd *TurtleDexDir) UpdateRedundancyPolicy(policy modules.RedundancyPolicy) error {
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+	md := sd.metadata
+	md.RedundancyPolicy = policy
+	return sd.updateMetadata(md)
+}
+
 // UpdateMetadata updates the TurtleDexDir metadata on disk
 func (sd *TurtleDexDir) UpdateMetadata(metadata Metadata) error {
 	sd.mu.Lock()
@@ -382,6 +404,8 @@ func (sd *TurtleDexDir) updateMetadata(metadata Metadata) error {
 	sd.metadata.SkynetSize = metadata.SkynetSize
 
 	sd.metadata.Version = metadata.Version
+	sd.metadata.Versioning = metadata.Versioning
+	sd.metadata.RedundancyPolicy = metadata.RedundancyPolicy
 
 	// Testing check to ensure new fields aren't missed
 	if build.Release == "testing" && !reflect.DeepEqual(sd.metadata, metadata) {
@@ -100,6 +100,16 @@ type (
 
 		// Version is the used version of the header file.
 		Version string `json:"version"`
+
+		// Versioning is the versioning policy of the ttdxdir. It applies to
+		// the siafiles of the ttdxdir and of any sub ttdxdirs without a
+		// versioning policy of their own.
+		Versioning modules.VersioningPolicy `json:"versioning"`
+
+		// RedundancyPolicy is the redundancy policy of the ttdxdir. It
+		// applies to the siafiles of the ttdxdir and of any sub ttdxdirs
+		// without a redundancy policy of their own.
+		RedundancyPolicy modules.RedundancyPolicy `json:"redundancypolicy"`
 	}
 )
 
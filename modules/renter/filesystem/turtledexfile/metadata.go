@@ -49,6 +49,15 @@ type (
 		StaticPieceSize     uint64   `json:"piecesize"`     // size of a single piece of the file
 		LocalPath           string   `json:"localpath"`     // file to the local copy of the file used for repairing
 
+		// Compression is the type of compression that was applied to the data
+		// before uploading it. DedupLayout indicates that the data was
+		// uploaded in the layout of deduplicated files. DataSize is the size
+		// of the data before it was compressed or laid out, FileSize is the
+		// size of the uploaded data.
+		Compression modules.CompressionType `json:"compression"`
+		DedupLayout bool                    `json:"deduplayout"`
+		DataSize    int64                   `json:"datasize"`
+
 		// Fields for encryption
 		StaticMasterKey      []byte            `json:"masterkey"` // masterkey used to encrypt pieces
 		StaticMasterKeyType  crypto.CipherType `json:"masterkeytype"`
@@ -263,6 +272,41 @@ func (sf *TurtleDexFile) LocalPath() string {
 	return sf.staticMetadata.LocalPath
 }
 
+// Compression returns the type of compression that was applied to the file's
+// data before uploading it.
+func (sf *TurtleDexFile) Compression() modules.CompressionType {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.staticMetadata.Compression
+}
+
+// DedupLayout returns whether the file's data was uploaded in the layout of
+// deduplicated files.
+func (sf *TurtleDexFile) DedupLayout() bool {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.staticMetadata.DedupLayout
+}
+
+// LogicalSize returns the size of the file's data. For compressed and
+// deduplicated files that is the size of the data before it was compressed or
+// laid out.
+func (sf *TurtleDexFile) LogicalSize() uint64 {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.staticMetadata.LogicalSize()
+}
+
+// LogicalSize returns the size of the file's data. For compressed and
+// deduplicated files that is the size of the data before it was compressed or
+// laid out.
+func (md Metadata) LogicalSize() uint64 {
+	if md.Compression != modules.CompressionNone || md.DedupLayout {
+		return uint64(md.DataSize)
+	}
+	return uint64(md.FileSize)
+}
+
 // MasterKey returns the masterkey used to encrypt the file.
 func (sf *TurtleDexFile) MasterKey() crypto.CipherKey {
 	return sf.staticMasterKey()
@@ -335,6 +379,9 @@ func (md Metadata) backup() (b Metadata) {
 	b.UniqueID = md.UniqueID
 	b.FileSize = md.FileSize
 	b.LocalPath = md.LocalPath
+	b.Compression = md.Compression
+	b.DedupLayout = md.DedupLayout
+	b.DataSize = md.DataSize
 	b.DisablePartialChunk = md.DisablePartialChunk
 	b.HasPartialChunk = md.HasPartialChunk
 	b.ModTime = md.ModTime
@@ -388,6 +435,9 @@ func (md *Metadata) restore(b Metadata) {
 	md.UniqueID = b.UniqueID
 	md.FileSize = b.FileSize
 	md.LocalPath = b.LocalPath
+	md.Compression = b.Compression
+	md.DedupLayout = b.DedupLayout
+	md.DataSize = b.DataSize
 	md.DisablePartialChunk = b.DisablePartialChunk
 	md.PartialChunks = b.PartialChunks
 	md.HasPartialChunk = b.HasPartialChunk
@@ -554,6 +604,75 @@ func (sf *TurtleDexFile) SetLocalPath(path string) (err error) {
 	return sf.createAndApplyTransaction(updates...)
 }
 
+// SetCompression sets the type of compression that was applied to the file's
+// data before uploading it.
+func (sf *TurtleDexFile) SetCompression(ct modules.CompressionType) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	// backup the changed metadata before changing it. Revert the change on
+	// error.
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+
+	sf.staticMetadata.Compression = ct
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveMetadataUpdates()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
+// SetDedupLayout marks the file's data as uploaded in the layout of
+// deduplicated files.
+func (sf *TurtleDexFile) SetDedupLayout() (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	// backup the changed metadata before changing it. Revert the change on
+	// error.
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+
+	sf.staticMetadata.DedupLayout = true
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveMetadataUpdates()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
+// SetDataSize sets the size of the file's data before it was compressed or
+// laid out.
+func (sf *TurtleDexFile) SetDataSize(size uint64) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	// backup the changed metadata before changing it. Revert the change on
+	// error.
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+
+	sf.staticMetadata.DataSize = int64(size)
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveMetadataUpdates()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
 // Size returns the file's size.
 func (sf *TurtleDexFile) Size() uint64 {
 	sf.mu.RLock()
@@ -115,6 +115,9 @@ func TestBackupRestoreMetadata(t *testing.T) {
 		sf.staticMetadata.UniqueID = TurtleDexfileUID(fmt.Sprint(fastrand.Intn(100)))
 		sf.staticMetadata.FileSize = int64(fastrand.Intn(100))
 		sf.staticMetadata.LocalPath = string(fastrand.Bytes(100))
+		sf.staticMetadata.Compression = modules.CompressionType(fastrand.Bytes(4))
+		sf.staticMetadata.DedupLayout = !sf.staticMetadata.DedupLayout
+		sf.staticMetadata.DataSize = int64(fastrand.Intn(100))
 		sf.staticMetadata.DisablePartialChunk = !sf.staticMetadata.DisablePartialChunk
 		sf.staticMetadata.HasPartialChunk = !sf.staticMetadata.HasPartialChunk
 		sf.staticMetadata.PartialChunks = nil
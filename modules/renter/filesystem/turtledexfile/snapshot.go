@@ -19,6 +19,8 @@ type (
 	// representation of a siafile which only exists in memory.
 	Snapshot struct {
 		staticChunks          []Chunk
+		staticCompression     modules.CompressionType
+		staticDedupLayout     bool
 		staticFileSize        int64
 		staticPieceSize       uint64
 		staticErasureCode     modules.ErasureCoder
@@ -144,6 +146,18 @@ func (s *Snapshot) IsIncompletePartialChunk(chunkIndex uint64) bool {
 	return s.staticPartialChunks[idx].Status < CombinedChunkStatusCompleted
 }
 
+// Compression returns the type of compression that was applied to the file's
+// data before uploading it.
+func (s *Snapshot) Compression() modules.CompressionType {
+	return s.staticCompression
+}
+
+// DedupLayout returns whether the file's data was uploaded in the layout of
+// deduplicated files.
+func (s *Snapshot) DedupLayout() bool {
+	return s.staticDedupLayout
+}
+
 // LocalPath returns the localPath used to repair the file.
 func (s *Snapshot) LocalPath() string {
 	return s.staticLocalPath
@@ -166,6 +180,11 @@ func (s *Snapshot) NumChunks() uint64 {
 	return uint64(len(s.staticChunks))
 }
 
+// ChunkDedupID returns the DedupID of the chunk at the given index.
+func (s *Snapshot) ChunkDedupID(chunkIndex uint64) DedupID {
+	return s.staticChunks[chunkIndex].DedupID
+}
+
 // Pieces returns all the pieces for a chunk in a slice of slices that contains
 // all the pieces for a certain index.
 func (s *Snapshot) Pieces(chunkIndex uint64) [][]Piece {
@@ -270,7 +289,8 @@ func (sf *TurtleDexFile) readlockSnapshot(sp modules.TurtleDexPath, chunks []chu
 			}
 		}
 		exportedChunks = append(exportedChunks, Chunk{
-			Pieces: pieces,
+			DedupID: chunk.ExtensionInfo,
+			Pieces:  pieces,
 		})
 	}
 	// Get non-static metadata fields under lock.
@@ -280,9 +300,13 @@ func (sf *TurtleDexFile) readlockSnapshot(sp modules.TurtleDexPath, chunks []chu
 	hasPartial := sf.staticMetadata.HasPartialChunk
 	pcs := sf.staticMetadata.PartialChunks
 	localPath := sf.staticMetadata.LocalPath
+	compression := sf.staticMetadata.Compression
+	dedupLayout := sf.staticMetadata.DedupLayout
 
 	return &Snapshot{
 		staticChunks:          exportedChunks,
+		staticCompression:     compression,
+		staticDedupLayout:     dedupLayout,
 		staticPartialChunks:   pcs,
 		staticHasPartialChunk: hasPartial,
 		staticFileSize:        fileSize,
@@ -93,9 +93,15 @@ type (
 
 	// Chunk is an exported chunk. It contains exported pieces.
 	Chunk struct {
-		Pieces [][]Piece
+		DedupID DedupID
+		Pieces  [][]Piece
 	}
 
+	// DedupID identifies the content of a chunk of a file that was uploaded
+	// with deduplication. It is stored in the ExtensionInfo of the chunk. A
+	// zero DedupID indicates that the chunk is not deduplicated.
+	DedupID [16]byte
+
 	// piece represents a single piece of a chunk on disk
 	piece struct {
 		HostTableOffset uint32      // offset of the host's key within the pubKeyTable
@@ -435,6 +441,69 @@ func (sf *TurtleDexFile) AddPiece(pk types.TurtleDexPublicKey, chunkIndex, piece
 	return sf.createAndApplyTransaction(append(updates, chunkUpdate)...)
 }
 
+// RemovePiece removes a piece which was previously added using AddPiece from
+// the file, e.g. because the host lost it. It is not an error if the file
+// doesn't contain the piece.
+func (sf *TurtleDexFile) RemovePiece(pk types.TurtleDexPublicKey, chunkIndex, pieceIndex uint64, merkleRoot crypto.Hash) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if sf.deleted {
+		return errors.AddContext(ErrDeleted, "can't remove piece from deleted file")
+	}
+	// Backup the changed metadata before changing it. Revert the change on
+	// error.
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+
+	// Update cache.
+	defer sf.uploadProgressAndBytes()
+
+	// Handle piece being removed from the partial chunk.
+	if cci, ok := sf.isIncludedPartialChunk(chunkIndex); ok {
+		return sf.partialsTurtleDexFile.RemovePiece(pk, cci.Index, pieceIndex, merkleRoot)
+	}
+
+	// Check if the chunkIndex is valid.
+	if chunkIndex >= uint64(sf.numChunks) {
+		return fmt.Errorf("chunkIndex %v out of bounds (%v)", chunkIndex, sf.numChunks)
+	}
+	// Get the chunk from disk.
+	chunk, err := sf.chunk(int(chunkIndex))
+	if err != nil {
+		return errors.AddContext(err, "failed to get chunk")
+	}
+	// Check if the pieceIndex is valid.
+	if pieceIndex >= uint64(len(chunk.Pieces)) {
+		return fmt.Errorf("pieceIndex %v out of bounds (%v)", pieceIndex, len(chunk.Pieces))
+	}
+	// Remove the matching pieces from the chunk.
+	var pieceSet []piece
+	for _, p := range chunk.Pieces[pieceIndex] {
+		if p.MerkleRoot == merkleRoot && sf.hostKey(p.HostTableOffset).PublicKey.Equals(pk) {
+			continue
+		}
+		pieceSet = append(pieceSet, p)
+	}
+	if len(pieceSet) == len(chunk.Pieces[pieceIndex]) {
+		return nil
+	}
+	chunk.Pieces[pieceIndex] = pieceSet
+
+	// Update the ChangeTime.
+	sf.staticMetadata.ChangeTime = time.Now()
+
+	// Update the file atomically.
+	updates, err := sf.saveMetadataUpdates()
+	if err != nil {
+		return err
+	}
+	chunkUpdate := sf.saveChunkUpdate(chunk)
+	return sf.createAndApplyTransaction(append(updates, chunkUpdate)...)
+}
+
 // chunkHealth returns the health and user health of the chunk which is defined
 // as the percent of parity pieces remaining. When calculating the user health
 // we assume that an incomplete partial chunk has full health. For the regular
@@ -1013,6 +1082,45 @@ func (sf *TurtleDexFile) SetStuck(index uint64, stuck bool) (err error) {
 	return sf.setStuck(index, stuck)
 }
 
+// SetChunkDedupID sets the DedupID of the chunk at the given index.
+func (sf *TurtleDexFile) SetChunkDedupID(index uint64, id DedupID) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	// Partial chunks are never deduplicated.
+	if _, ok := sf.isIncludedPartialChunk(index); ok || sf.isIncompletePartialChunk(index) {
+		return errors.New("can't set the dedup id of a partial chunk")
+	}
+	if sf.deleted {
+		return errors.AddContext(ErrDeleted, "can't call SetChunkDedupID on deleted file")
+	}
+	if index >= uint64(sf.numChunks) {
+		return fmt.Errorf("chunkIndex %v out of bounds (%v)", index, sf.numChunks)
+	}
+	chunk, err := sf.chunk(int(index))
+	if err != nil {
+		return err
+	}
+	if chunk.ExtensionInfo == id {
+		return nil
+	}
+	chunk.ExtensionInfo = id
+	return sf.createAndApplyTransaction(sf.saveChunkUpdate(chunk))
+}
+
+// ChunkDedupID returns the DedupID of the chunk at the given index.
+func (sf *TurtleDexFile) ChunkDedupID(index uint64) (DedupID, error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if _, ok := sf.isIncludedPartialChunk(index); ok || sf.isIncompletePartialChunk(index) {
+		return DedupID{}, nil
+	}
+	chunk, err := sf.chunk(int(index))
+	if err != nil {
+		return DedupID{}, errors.AddContext(err, "failed to read chunk")
+	}
+	return chunk.ExtensionInfo, nil
+}
+
 // StuckChunkByIndex returns if the chunk at the index is marked as Stuck or not
 func (sf *TurtleDexFile) StuckChunkByIndex(index uint64) (bool, error) {
 	sf.mu.Lock()
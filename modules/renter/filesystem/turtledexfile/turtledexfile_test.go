@@ -817,6 +817,59 @@ func TestNumPieces(t *testing.T) {
 	}
 }
 
+// TestRemovePiece tests removing pieces from a file.
+func TestRemovePiece(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	sf, _, _ := newBlankTestFileAndWAL(2) // make sure we have 1 full chunk at the beginning of sf.fullChunks
+
+	// Add the same piece to 2 hosts.
+	pk1 := types.TurtleDexPublicKey{Key: fastrand.Bytes(crypto.EntropySize)}
+	pk2 := types.TurtleDexPublicKey{Key: fastrand.Bytes(crypto.EntropySize)}
+	var root crypto.Hash
+	fastrand.Read(root[:])
+	if err := sf.AddPiece(pk1, 0, 1, root); err != nil {
+		t.Fatal(err)
+	}
+	if err := sf.AddPiece(pk2, 0, 1, root); err != nil {
+		t.Fatal(err)
+	}
+
+	// Remove the piece of the first host.
+	if err := sf.RemovePiece(pk1, 0, 1, root); err != nil {
+		t.Fatal(err)
+	}
+	pieces, err := sf.Pieces(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pieces[1]) != 1 || !pieces[1][0].HostPubKey.Equals(pk2) {
+		t.Fatal("wrong pieces after removal", pieces[1])
+	}
+
+	// Removing pieces which don't exist is a no-op.
+	if err := sf.RemovePiece(pk1, 0, 1, root); err != nil {
+		t.Fatal(err)
+	}
+	if err := sf.RemovePiece(pk2, 0, 1, crypto.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+	pieces, err = sf.Pieces(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pieces[1]) != 1 {
+		t.Fatal("piece shouldn't have been removed", pieces[1])
+	}
+
+	// Invalid indices are rejected.
+	if err := sf.RemovePiece(pk2, sf.NumChunks(), 1, root); err == nil {
+		t.Fatal("expected error for invalid chunk index")
+	}
+}
+
 // TestDefragChunk tests if the defragChunk methods correctly prunes pieces
 // from a chunk.
 func TestDefragChunk(t *testing.T) {
@@ -1161,6 +1214,59 @@ func TestStuckChunks(t *testing.T) {
 	}
 }
 
+// TestChunkDedupID tests setting and persisting the DedupID of chunks.
+func TestChunkDedupID(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a siafile with at least 2 full chunks.
+	siaFilePath, _, source, rc, sk, fileSize, numChunks, fileMode := newTestFileParams(2, false)
+	sf, wal, _ := customTestFileAndWAL(siaFilePath, source, rc, sk, fileSize, numChunks, fileMode)
+
+	// Chunks shouldn't have a DedupID by default.
+	id, err := sf.ChunkDedupID(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != (DedupID{}) {
+		t.Fatal("chunk shouldn't have a DedupID")
+	}
+
+	// Set the DedupID of the second chunk.
+	fastrand.Read(id[:])
+	if err := sf.SetChunkDedupID(1, id); err != nil {
+		t.Fatal(err)
+	}
+
+	// Setting the DedupID of a chunk that doesn't exist should fail.
+	if err := sf.SetChunkDedupID(sf.NumChunks(), id); err == nil {
+		t.Fatal("expected error")
+	}
+
+	// Reload the file and check the ids.
+	sf, err = LoadTurtleDexFile(sf.TurtleDexFilePath(), wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loadedID, err := sf.ChunkDedupID(0); err != nil || loadedID != (DedupID{}) {
+		t.Fatal("wrong DedupID for first chunk", loadedID, err)
+	}
+	if loadedID, err := sf.ChunkDedupID(1); err != nil || loadedID != id {
+		t.Fatal("wrong DedupID for second chunk", loadedID, err)
+	}
+
+	// The snapshot should contain the ids as well.
+	snap, err := sf.Snapshot(modules.RandomTurtleDexPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.ChunkDedupID(0) != (DedupID{}) || snap.ChunkDedupID(1) != id {
+		t.Fatal("snapshot has wrong DedupIDs")
+	}
+}
+
 // TestUploadedBytes tests that uploadedBytes() returns the expected values for
 // total and unique uploaded bytes.
 func TestUploadedBytes(t *testing.T) {
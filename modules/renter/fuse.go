@@ -5,7 +5,9 @@ package renter
 import (
 	"context"
 	"io"
+	"io/ioutil"
 	"math"
+	"os"
 	"sync"
 	"sync/atomic"
 	"syscall"
@@ -14,6 +16,7 @@ import (
 	"github.com/hanwen/go-fuse/v2/fuse"
 	"github.com/turtledex/TurtleDexCore/modules"
 	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem"
+	"github.com/turtledex/TurtleDexCore/persist"
 	"github.com/turtledex/errors"
 )
 
@@ -38,6 +41,8 @@ type fuseDirnode struct {
 // NodeAccesser is necessary for telling certain programs that it is okay to
 // access the file.
 //
+// NodeCreater is necessary for creating new files in the directory.
+//
 // NodeFlusher is necessary for cleaning up resources such as the filesystem
 // node.
 //
@@ -46,31 +51,79 @@ type fuseDirnode struct {
 //
 // NodeLookuper is necessary to have files added to the filesystem tree.
 //
+// NodeMkdirer is necessary for creating new directories in the directory.
+//
 // NodeReaddirer is necessary to list the files in a directory.
 //
+// NodeRenamer is necessary for moving files and directories. Programs such as
+// rsync write to a temporary file and rename it once the transfer is
+// complete.
+//
+// NodeRmdirer is necessary for deleting empty directories.
+//
 // NodeStatfser is necessary to provide information about the filesystem that
 // contains the directory.
+//
+// NodeUnlinker is necessary for deleting files.
 var _ = (fs.NodeAccesser)((*fuseDirnode)(nil))
+var _ = (fs.NodeCreater)((*fuseDirnode)(nil))
 var _ = (fs.NodeFlusher)((*fuseDirnode)(nil))
 var _ = (fs.NodeGetattrer)((*fuseDirnode)(nil))
 var _ = (fs.NodeLookuper)((*fuseDirnode)(nil))
+var _ = (fs.NodeMkdirer)((*fuseDirnode)(nil))
 var _ = (fs.NodeReaddirer)((*fuseDirnode)(nil))
+var _ = (fs.NodeRenamer)((*fuseDirnode)(nil))
+var _ = (fs.NodeRmdirer)((*fuseDirnode)(nil))
 var _ = (fs.NodeStatfser)((*fuseDirnode)(nil))
+var _ = (fs.NodeUnlinker)((*fuseDirnode)(nil))
 
 // fuseFilenode is a fuse node for the fs package that covers a siafile.
 //
 // Data is fetched using a download streamer. This download streamer needs to be
 // closed when the filehandle is released.
+//
+// When the file is opened for writing, its contents are staged in a local file
+// instead. All reads and writes go to the staged copy until the file is
+// flushed, at which point the staged copy is uploaded to the renter, replacing
+// the previous version of the file.
 type fuseFilenode struct {
 	atomicClosed uint32
 
 	fs.Inode
 	staticFilesystem *fuseFS
-	staticFileNode   *filesystem.FileNode
-	stream           modules.Streamer
-	mu               sync.Mutex
+
+	// fileNode is the filesystem node of the file. It is 'nil' for files that
+	// were created through fuse and have not been uploaded yet. fileNode and
+	// staged are protected by fileMu.
+	fileNode *filesystem.FileNode
+	staged   *fuseStagedFile
+	fileMu   sync.Mutex
+
+	// commitMu serializes uploads of the staged file.
+	commitMu sync.Mutex
+
+	stream modules.Streamer
+	mu     sync.Mutex
 }
 
+// fuseStagedFile is the local copy of a fuse file that is open for writing.
+type fuseStagedFile struct {
+	file *os.File
+
+	// dirty indicates that the staged file contains changes which haven't
+	// been uploaded yet.
+	dirty bool
+
+	// refs is the number of open file handles which are using the staged
+	// file. The staged file is deleted once the last handle is released.
+	refs int
+}
+
+// fuseWriteHandle is the file handle returned for files that are opened for
+// writing. It allows Release to tell apart handles that hold a reference to
+// the staged file.
+type fuseWriteHandle struct{}
+
 // Ensure the file nodes satisfy the required interfaces.
 //
 // NodeAccesser is necessary for telling certain programs that it is okay to
@@ -85,20 +138,34 @@ type fuseFilenode struct {
 //
 // NodeReader is necessary for reading files.
 //
+// NodeReleaser is necessary for cleaning up the staged copy of a file once
+// all handles that were writing to it have been closed.
+//
+// NodeSetattrer is necessary for truncating files.
+//
 // NodeStatfser is necessary to provide information about the filesystem that
 // contains the file.
+//
+// NodeWriter is necessary for writing files.
 var _ = (fs.NodeAccesser)((*fuseFilenode)(nil))
 var _ = (fs.NodeFlusher)((*fuseFilenode)(nil))
 var _ = (fs.NodeGetattrer)((*fuseFilenode)(nil))
 var _ = (fs.NodeOpener)((*fuseFilenode)(nil))
 var _ = (fs.NodeReader)((*fuseFilenode)(nil))
+var _ = (fs.NodeReleaser)((*fuseFilenode)(nil))
+var _ = (fs.NodeSetattrer)((*fuseFilenode)(nil))
 var _ = (fs.NodeStatfser)((*fuseFilenode)(nil))
+var _ = (fs.NodeWriter)((*fuseFilenode)(nil))
 
 // fuseRoot is the root directory for a mounted fuse filesystem.
 type fuseFS struct {
 	options modules.MountOptions
 	root    *fuseDirnode
 
+	// stagingDir is the directory on disk where files that are open for
+	// writing are staged before they are uploaded.
+	stagingDir string
+
 	renter *Renter
 	server *fuse.Server
 }
@@ -107,12 +174,279 @@ type fuseFS struct {
 func errToStatus(err error) syscall.Errno {
 	if err == nil {
 		return syscall.F_OK
-	} else if errors.IsOSNotExist(err) {
+	} else if errors.IsOSNotExist(err) || errors.Contains(err, filesystem.ErrNotExist) {
 		return syscall.ENOENT
+	} else if errors.Contains(err, filesystem.ErrExists) {
+		return syscall.EEXIST
 	}
 	return syscall.EIO
 }
 
+// staticChildTurtleDexPath returns the siapath of the child with the given name.
+func (fdn *fuseDirnode) staticChildTurtleDexPath(name string) (modules.TurtleDexPath, error) {
+	dirTurtleDexPath := fdn.staticFilesystem.renter.staticFileSystem.DirTurtleDexPath(fdn.staticDirNode)
+	return dirTurtleDexPath.Join(name)
+}
+
+// uncommittedChild returns the file with the given name if it was created
+// through fuse but hasn't been uploaded yet. Such files only exist in the fuse
+// tree.
+func (fdn *fuseDirnode) uncommittedChild(name string) (*fuseFilenode, bool) {
+	child := fdn.GetChild(name)
+	if child == nil {
+		return nil, false
+	}
+	ffn, ok := child.Operations().(*fuseFilenode)
+	if !ok || !ffn.managedUncommitted() {
+		return nil, false
+	}
+	return ffn, true
+}
+
+// managedTurtleDexPath returns the siapath of the file. The siapath is derived
+// from the position of the file in the fuse tree so that files which haven't
+// been uploaded yet have a siapath as well.
+func (ffn *fuseFilenode) managedTurtleDexPath() (modules.TurtleDexPath, error) {
+	name, parent := ffn.Parent()
+	if parent == nil {
+		return modules.TurtleDexPath{}, errors.New("fuse file has been unlinked")
+	}
+	fdn, ok := parent.Operations().(*fuseDirnode)
+	if !ok {
+		return modules.TurtleDexPath{}, errors.New("parent of fuse file is not a fuse dir")
+	}
+	return fdn.staticChildTurtleDexPath(name)
+}
+
+// managedUncommitted returns true if the file was created through fuse and has
+// not been uploaded yet.
+func (ffn *fuseFilenode) managedUncommitted() bool {
+	ffn.fileMu.Lock()
+	defer ffn.fileMu.Unlock()
+	return ffn.fileNode == nil && ffn.staged != nil
+}
+
+// managedStage adds a reference to the staged copy of the file, creating the
+// staged copy if necessary. Unless truncate is set, the staged copy is
+// initialized with the current contents of the file. The contents are copied
+// without holding fileMu so that reads of the file aren't blocked meanwhile.
+func (ffn *fuseFilenode) managedStage(truncate bool) error {
+	for {
+		// If the file is already staged, add a reference.
+		ffn.fileMu.Lock()
+		if ffn.staged != nil {
+			err := ffn.staged.addRef(truncate)
+			ffn.fileMu.Unlock()
+			return err
+		}
+		fileNode := ffn.fileNode
+		ffn.fileMu.Unlock()
+
+		f, err := ffn.staticFilesystem.managedNewStagedFile(fileNode, truncate)
+		if err != nil {
+			return err
+		}
+
+		// Use the staged copy unless the file was staged or replaced in the
+		// meantime.
+		ffn.fileMu.Lock()
+		if ffn.staged == nil && ffn.fileNode == fileNode {
+			ffn.staged = &fuseStagedFile{
+				file: f,
+				// New and truncated files need to be uploaded even if they
+				// are never written to.
+				dirty: truncate || fileNode == nil,
+				refs:  1,
+			}
+			ffn.fileMu.Unlock()
+			return nil
+		}
+		ffn.fileMu.Unlock()
+		err = errors.Compose(f.Close(), os.Remove(f.Name()))
+		if err != nil {
+			return errors.AddContext(err, "unable to delete unused staged file")
+		}
+	}
+}
+
+// managedNewStagedFile creates a new staged file. Unless truncate is set or
+// fileNode is nil, the staged file is initialized with the contents of the
+// file.
+func (ffs *fuseFS) managedNewStagedFile(fileNode *filesystem.FileNode, truncate bool) (*os.File, error) {
+	f, err := ioutil.TempFile(ffs.stagingDir, "")
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to create staged file")
+	}
+	if fileNode == nil || truncate {
+		return f, nil
+	}
+	// Copy the current contents of the file into the staged file.
+	err = func() error {
+		stream, err := ffs.renter.StreamerByNode(fileNode, false)
+		if err != nil {
+			return errors.AddContext(err, "unable to open stream")
+		}
+		_, err = io.Copy(f, stream)
+		return errors.Compose(err, stream.Close())
+	}()
+	if err != nil {
+		err = errors.Compose(err, f.Close(), os.Remove(f.Name()))
+		return nil, errors.AddContext(err, "unable to copy file contents to staged file")
+	}
+	return f, nil
+}
+
+// addRef adds a reference to the staged file, truncating it if requested.
+func (sf *fuseStagedFile) addRef(truncate bool) error {
+	if truncate {
+		err := sf.file.Truncate(0)
+		if err != nil {
+			return errors.AddContext(err, "unable to truncate staged file")
+		}
+		sf.dirty = true
+	}
+	sf.refs++
+	return nil
+}
+
+// managedUnstage releases a reference to the staged copy of the file. The
+// staged copy is deleted once the last reference is released.
+func (ffn *fuseFilenode) managedUnstage() error {
+	ffn.fileMu.Lock()
+	defer ffn.fileMu.Unlock()
+	if ffn.staged == nil {
+		return nil
+	}
+	ffn.staged.refs--
+	if ffn.staged.refs > 0 {
+		return nil
+	}
+	f := ffn.staged.file
+	ffn.staged = nil
+	return errors.Compose(f.Close(), os.Remove(f.Name()))
+}
+
+// managedCommit uploads the staged copy of the file to the renter if it
+// contains changes, replacing the previous version of the file. A snapshot of
+// the staged copy is uploaded so that fileMu isn't held during the upload.
+// Writes made during the upload mark the staged copy as dirty again and are
+// uploaded by the next commit.
+func (ffn *fuseFilenode) managedCommit() error {
+	// Only one commit can be in progress at once.
+	ffn.commitMu.Lock()
+	defer ffn.commitMu.Unlock()
+
+	ffn.fileMu.Lock()
+	staged := ffn.staged
+	if staged == nil || !staged.dirty {
+		ffn.fileMu.Unlock()
+		return nil
+	}
+	siaPath, err := ffn.managedTurtleDexPath()
+	if err != nil {
+		// A file which has been unlinked while it was open is not uploaded.
+		staged.dirty = false
+		ffn.fileMu.Unlock()
+		return nil
+	}
+	prevNode := ffn.fileNode
+	snapshot, err := ffn.staticFilesystem.snapshotStagedFile(staged.file)
+	if err != nil {
+		ffn.fileMu.Unlock()
+		return errors.AddContext(err, "unable to snapshot staged file")
+	}
+	staged.dirty = false
+	ffn.fileMu.Unlock()
+
+	fileNode, err := ffn.managedUploadSnapshot(snapshot, siaPath, prevNode)
+	if cleanupErr := errors.Compose(snapshot.Close(), os.Remove(snapshot.Name())); cleanupErr != nil {
+		ffn.staticFilesystem.renter.log.Printf("Unable to delete snapshot of staged file %v: %v", siaPath, cleanupErr)
+	}
+	if err != nil {
+		// The changes still need to be uploaded.
+		ffn.fileMu.Lock()
+		if ffn.staged == staged {
+			staged.dirty = true
+		}
+		ffn.fileMu.Unlock()
+		return err
+	}
+
+	// Replace the file node with the node of the uploaded file.
+	ffn.fileMu.Lock()
+	defer ffn.fileMu.Unlock()
+	if ffn.fileNode != nil && atomic.CompareAndSwapUint32(&ffn.atomicClosed, 0, 1) {
+		err = ffn.fileNode.Close()
+	}
+	ffn.fileNode = fileNode
+	atomic.StoreUint32(&ffn.atomicClosed, 0)
+	return errors.AddContext(err, "unable to close previous file node")
+}
+
+// managedUploadSnapshot uploads a snapshot of the staged copy of the file,
+// replacing the previous version of the file at siaPath. The redundancy,
+// compression and deduplication of prevNode are kept. The node of the uploaded
+// file is returned.
+func (ffn *fuseFilenode) managedUploadSnapshot(snapshot *os.File, siaPath modules.TurtleDexPath, prevNode *filesystem.FileNode) (*filesystem.FileNode, error) {
+	// Upload the snapshot to a temporary path.
+	r := ffn.staticFilesystem.renter
+	tmpPath, err := modules.TempFolder.Join(persist.RandomSuffix())
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to create temporary path")
+	}
+	up := modules.FileUploadParams{
+		TurtleDexPath: tmpPath,
+	}
+	if prevNode != nil {
+		up.ErasureCode = prevNode.ErasureCode()
+		up.Compression = prevNode.Compression()
+		up.Dedup = r.staticDedupIndex.staticIsDedupKey(prevNode.MasterKey())
+	}
+	err = r.UploadStreamFromReader(up, snapshot)
+	if err != nil {
+		// The partially uploaded file is not a version of the file, so it is
+		// deleted without archiving it.
+		if deleteErr := r.managedDeleteFile(tmpPath); deleteErr != nil && !errors.Contains(deleteErr, filesystem.ErrNotExist) {
+			err = errors.Compose(err, deleteErr)
+		}
+		return nil, errors.AddContext(err, "unable to upload staged file")
+	}
+
+	// Replace the previous version of the file with the uploaded file.
+	err = r.managedReplaceFile(tmpPath, siaPath)
+	if err != nil {
+		err = errors.Compose(err, r.managedDeleteFile(tmpPath))
+		return nil, errors.AddContext(err, "unable to replace file with staged file")
+	}
+	fileNode, err := r.staticFileSystem.OpenTurtleDexFile(siaPath)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to open uploaded file")
+	}
+	return fileNode, nil
+}
+
+// snapshotStagedFile copies a staged file to a new file in the staging dir.
+// The returned file is positioned at its start.
+func (ffs *fuseFS) snapshotStagedFile(f *os.File) (*os.File, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to stat staged file")
+	}
+	snapshot, err := ioutil.TempFile(ffs.stagingDir, "")
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to create snapshot")
+	}
+	_, err = io.Copy(snapshot, io.NewSectionReader(f, 0, fi.Size()))
+	if err == nil {
+		_, err = snapshot.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		err = errors.Compose(err, snapshot.Close(), os.Remove(snapshot.Name()))
+		return nil, errors.AddContext(err, "unable to copy staged file")
+	}
+	return snapshot, nil
+}
+
 // Access reports whether a directory can be accessed by the caller.
 func (fdn *fuseDirnode) Access(ctx context.Context, mask uint32) syscall.Errno {
 	// TODO: parse the mask and return a more correct value instead of always
@@ -127,6 +461,32 @@ func (ffn *fuseFilenode) Access(ctx context.Context, mask uint32) syscall.Errno
 	return syscall.F_OK
 }
 
+// Create is called when a new file is created in the directory. The file is
+// staged on disk and uploaded once it is flushed.
+func (fdn *fuseDirnode) Create(ctx context.Context, name string, flags uint32, mode uint32, out *fuse.EntryOut) (*fs.Inode, fs.FileHandle, uint32, syscall.Errno) {
+	if fdn.staticFilesystem.options.ReadOnly {
+		return nil, nil, 0, syscall.EROFS
+	}
+	filenode := &fuseFilenode{
+		staticFilesystem: fdn.staticFilesystem,
+	}
+	err := filenode.managedStage(true)
+	if err != nil {
+		siaPath := fdn.staticFilesystem.renter.staticFileSystem.DirTurtleDexPath(fdn.staticDirNode)
+		fdn.staticFilesystem.renter.log.Printf("Unable to create file %v in dir %v: %v", name, siaPath, err)
+		return nil, nil, 0, errToStatus(err)
+	}
+	// The file doesn't have a UID until it has been uploaded, leaving Ino
+	// blank has fuse pick an inode number instead.
+	attrs := fs.StableAttr{
+		Mode: fuse.S_IFREG,
+	}
+	inode := fdn.NewInode(ctx, filenode, attrs)
+	out.Ino = inode.StableAttr().Ino
+	out.Mode = uint32(modules.DefaultFilePerm) | fuse.S_IFREG
+	return inode, &fuseWriteHandle{}, 0, errToStatus(nil)
+}
+
 // Flush is called when a directory is being closed.
 func (fdn *fuseDirnode) Flush(ctx context.Context, fh fs.FileHandle) syscall.Errno {
 	var err error
@@ -137,8 +497,18 @@ func (fdn *fuseDirnode) Flush(ctx context.Context, fh fs.FileHandle) syscall.Err
 	return errToStatus(err)
 }
 
-// Flush is called when a file is being closed.
+// Flush is called when a file is being closed. Any changes to the file are
+// uploaded before the file is closed.
 func (ffn *fuseFilenode) Flush(ctx context.Context, fh fs.FileHandle) syscall.Errno {
+	err := ffn.managedCommit()
+	if err != nil {
+		siaPath, _ := ffn.managedTurtleDexPath()
+		ffn.staticFilesystem.renter.log.Printf("error when committing fuse file %v: %v", siaPath, err)
+		return errToStatus(err)
+	}
+
+	ffn.fileMu.Lock()
+	defer ffn.fileMu.Unlock()
 	swapped := atomic.CompareAndSwapUint32(&ffn.atomicClosed, 0, 1)
 	if !swapped {
 		return errToStatus(nil)
@@ -155,10 +525,13 @@ func (ffn *fuseFilenode) Flush(ctx context.Context, fh fs.FileHandle) syscall.Er
 	}
 
 	// Check all of the errors.
-	closeErr := ffn.staticFileNode.Close()
-	err := errors.Compose(streamErr, closeErr)
+	var closeErr error
+	if ffn.fileNode != nil {
+		closeErr = ffn.fileNode.Close()
+	}
+	err = errors.Compose(streamErr, closeErr)
 	if err != nil {
-		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileTurtleDexPath(ffn.staticFileNode)
+		siaPath, _ := ffn.managedTurtleDexPath()
 		ffn.staticFilesystem.renter.log.Printf("error when flushing fuse file %v: %v", siaPath, err)
 		return errToStatus(err)
 	}
@@ -181,7 +554,7 @@ func (fdn *fuseDirnode) Lookup(ctx context.Context, name string, out *fuse.Entry
 		// Convert the file to an inode.
 		filenode := &fuseFilenode{
 			staticFilesystem: fdn.staticFilesystem,
-			staticFileNode:   fileNode,
+			fileNode:         fileNode,
 		}
 		attrs := fs.StableAttr{
 			Ino:  fileInfo.UID,
@@ -201,6 +574,14 @@ func (fdn *fuseDirnode) Lookup(ctx context.Context, name string, out *fuse.Entry
 
 	childDir, dirErr := fdn.staticDirNode.Dir(name)
 	if dirErr != nil {
+		// Files which haven't been uploaded yet are only known to the fuse
+		// tree.
+		if ffn, ok := fdn.uncommittedChild(name); ok {
+			var attr fuse.AttrOut
+			ffn.Getattr(ctx, nil, &attr)
+			out.Attr = attr.Attr
+			return &ffn.Inode, errToStatus(nil)
+		}
 		siaPath := fdn.staticFilesystem.renter.staticFileSystem.DirTurtleDexPath(fdn.staticDirNode)
 		fdn.staticFilesystem.renter.log.Printf("Unable to perform lookup on %v in dir %v; file err %v :: dir err %v", name, siaPath, fileErr, dirErr)
 		return nil, errToStatus(dirErr)
@@ -244,29 +625,92 @@ func (fdn *fuseDirnode) Getattr(ctx context.Context, fh fs.FileHandle, out *fuse
 // Getattr should try to minimize lock contention and should run very quickly if
 // possible.
 func (ffn *fuseFilenode) Getattr(ctx context.Context, fh fs.FileHandle, out *fuse.AttrOut) syscall.Errno {
-	fileInfo, err := ffn.staticFilesystem.renter.staticFileSystem.FileNodeInfo(ffn.staticFileNode)
+	ffn.fileMu.Lock()
+	fileNode := ffn.fileNode
+	var stagedInfo os.FileInfo
+	var stagedErr error
+	if ffn.staged != nil {
+		stagedInfo, stagedErr = ffn.staged.file.Stat()
+	}
+	ffn.fileMu.Unlock()
+
+	// A file that hasn't been uploaded yet only has a staged copy.
+	if fileNode == nil {
+		if stagedErr != nil {
+			ffn.staticFilesystem.renter.log.Printf("Unable to fetch info from staged file: %v", stagedErr)
+		} else if stagedInfo != nil {
+			out.Size = uint64(stagedInfo.Size())
+		}
+		out.Mode = uint32(modules.DefaultFilePerm) | syscall.S_IFREG
+		out.Ino = ffn.StableAttr().Ino
+		return errToStatus(nil)
+	}
+
+	fileInfo, err := ffn.staticFilesystem.renter.staticFileSystem.FileNodeInfo(fileNode)
 	if err != nil {
 		ffn.staticFilesystem.renter.log.Printf("Unable to fetch info from file: %v", err)
 	}
 
 	out.Size = fileInfo.Filesize
+	if stagedErr == nil && stagedInfo != nil {
+		out.Size = uint64(stagedInfo.Size())
+	}
 	out.Mode = uint32(fileInfo.Mode()) | syscall.S_IFREG
 	out.Ino = fileInfo.UID
 	return errToStatus(nil)
 }
 
-// Open will open a streamer for the file.
+// Mkdir creates a new directory within the directory.
+func (fdn *fuseDirnode) Mkdir(ctx context.Context, name string, mode uint32, out *fuse.EntryOut) (*fs.Inode, syscall.Errno) {
+	if fdn.staticFilesystem.options.ReadOnly {
+		return nil, syscall.EROFS
+	}
+	siaPath, err := fdn.staticChildTurtleDexPath(name)
+	if err != nil {
+		return nil, syscall.EINVAL
+	}
+	err = fdn.staticFilesystem.renter.CreateDir(siaPath, os.FileMode(mode)&os.ModePerm)
+	if err != nil {
+		fdn.staticFilesystem.renter.log.Printf("Unable to create fuse dir %v: %v", siaPath, err)
+		return nil, errToStatus(err)
+	}
+	return fdn.Lookup(ctx, name, out)
+}
+
+// Open will open a streamer for the file. If the file is opened for writing,
+// the file is staged on disk instead.
 //
 // TODO: Currently 'Open' returns '0' for the fuseFlags. I was unable to figure
 // out from the documentation what the flags are supposed to represent. So far,
 // this has not seemed to cause problems.
 func (ffn *fuseFilenode) Open(ctx context.Context, flags uint32) (fs.FileHandle, uint32, syscall.Errno) {
+	if flags&(syscall.O_WRONLY|syscall.O_RDWR) != 0 {
+		if ffn.staticFilesystem.options.ReadOnly {
+			return nil, 0, syscall.EROFS
+		}
+		err := ffn.managedStage(flags&syscall.O_TRUNC != 0)
+		if err != nil {
+			siaPath, _ := ffn.managedTurtleDexPath()
+			ffn.staticFilesystem.renter.log.Printf("Unable to stage file %v for writing: %v", siaPath, err)
+			return nil, 0, errToStatus(err)
+		}
+		return &fuseWriteHandle{}, 0, errToStatus(nil)
+	}
+
+	ffn.fileMu.Lock()
+	fileNode := ffn.fileNode
+	ffn.fileMu.Unlock()
+	// Files which haven't been uploaded yet are read from their staged copy.
+	if fileNode == nil {
+		return ffn, 0, errToStatus(nil)
+	}
+
 	ffn.mu.Lock()
 	defer ffn.mu.Unlock()
 
-	stream, err := ffn.staticFilesystem.renter.StreamerByNode(ffn.staticFileNode, false)
+	stream, err := ffn.staticFilesystem.renter.StreamerByNode(fileNode, false)
 	if err != nil {
-		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileTurtleDexPath(ffn.staticFileNode)
+		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileTurtleDexPath(fileNode)
 		ffn.staticFilesystem.renter.log.Printf("Unable to get stream for file %v: %v", siaPath, err)
 		return nil, 0, errToStatus(err)
 	}
@@ -276,6 +720,22 @@ func (ffn *fuseFilenode) Open(ctx context.Context, flags uint32) (fs.FileHandle,
 
 // Read will read data from the file and place it in dest.
 func (ffn *fuseFilenode) Read(ctx context.Context, f fs.FileHandle, dest []byte, offset int64) (fuse.ReadResult, syscall.Errno) {
+	// If the file is staged, the staged copy has the most recent contents of
+	// the file.
+	ffn.fileMu.Lock()
+	if ffn.staged != nil {
+		defer ffn.fileMu.Unlock()
+		n, err := ffn.staged.file.ReadAt(dest, offset)
+		if err != nil && !errors.Contains(err, io.EOF) {
+			siaPath, _ := ffn.managedTurtleDexPath()
+			ffn.staticFilesystem.renter.log.Printf("Error reading from offset %v during call to Read in staged file %s: %v", offset, siaPath.String(), err)
+			return nil, errToStatus(err)
+		}
+		return fuse.ReadResultData(dest[:n]), errToStatus(nil)
+	}
+	fileNode := ffn.fileNode
+	ffn.fileMu.Unlock()
+
 	// TODO: Right now only one call to Read from a file can be in effect at
 	// once, based on the way the streamer and the read call has been
 	// implemented. As the streamer gets updated to more readily support
@@ -284,9 +744,12 @@ func (ffn *fuseFilenode) Read(ctx context.Context, f fs.FileHandle, dest []byte,
 	ffn.mu.Lock()
 	defer ffn.mu.Unlock()
 
+	if ffn.stream == nil {
+		return nil, syscall.EBADF
+	}
 	_, err := ffn.stream.Seek(offset, io.SeekStart)
 	if err != nil {
-		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileTurtleDexPath(ffn.staticFileNode)
+		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileTurtleDexPath(fileNode)
 		ffn.staticFilesystem.renter.log.Printf("Error seeking to offset %v during call to Read in file %s: %v", offset, siaPath.String(), err)
 		return nil, errToStatus(err)
 	}
@@ -297,7 +760,7 @@ func (ffn *fuseFilenode) Read(ctx context.Context, f fs.FileHandle, dest []byte,
 	// often dropping parts of the tail of the file.
 	n, err := io.ReadFull(ffn.stream, dest)
 	if err != nil && !errors.Contains(err, io.EOF) && err != io.ErrUnexpectedEOF {
-		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileTurtleDexPath(ffn.staticFileNode)
+		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileTurtleDexPath(fileNode)
 		ffn.staticFilesystem.renter.log.Printf("Error reading from offset %v during call to Read in file %s: %v", offset, siaPath.String(), err)
 		return nil, errToStatus(err)
 	}
@@ -338,6 +801,165 @@ func (fdn *fuseDirnode) Readdir(ctx context.Context) (fs.DirStream, syscall.Errn
 	return fs.NewListDirStream(dirEntries), errToStatus(nil)
 }
 
+// Release is called when a file handle is closed. Once the last handle that
+// was writing to the file is released, the staged copy of the file is deleted.
+func (ffn *fuseFilenode) Release(ctx context.Context, f fs.FileHandle) syscall.Errno {
+	if _, ok := f.(*fuseWriteHandle); !ok {
+		return errToStatus(nil)
+	}
+	err := ffn.managedUnstage()
+	if err != nil {
+		siaPath, _ := ffn.managedTurtleDexPath()
+		ffn.staticFilesystem.renter.log.Printf("Unable to delete staged copy of fuse file %v: %v", siaPath, err)
+		return errToStatus(err)
+	}
+	return errToStatus(nil)
+}
+
+// Rename moves a file or directory from this directory to newParent. Like
+// rename(2), an existing file at the destination is replaced.
+func (fdn *fuseDirnode) Rename(ctx context.Context, name string, newParent fs.InodeEmbedder, newName string, flags uint32) syscall.Errno {
+	if fdn.staticFilesystem.options.ReadOnly {
+		return syscall.EROFS
+	}
+	// Flags such as RENAME_EXCHANGE and RENAME_NOREPLACE are not supported.
+	// Returning EINVAL has callers fall back to a regular rename.
+	if flags != 0 {
+		return syscall.EINVAL
+	}
+	newDir, ok := newParent.(*fuseDirnode)
+	if !ok {
+		return syscall.EXDEV
+	}
+	oldPath, err := fdn.staticChildTurtleDexPath(name)
+	if err != nil {
+		return syscall.EINVAL
+	}
+	newPath, err := newDir.staticChildTurtleDexPath(newName)
+	if err != nil {
+		return syscall.EINVAL
+	}
+
+	// Files which haven't been uploaded yet only need to be moved within the
+	// fuse tree, which fuse does once Rename returns.
+	if _, ok := fdn.uncommittedChild(name); ok {
+		return errToStatus(nil)
+	}
+
+	r := fdn.staticFilesystem.renter
+	fileNode, fileErr := fdn.staticDirNode.File(name)
+	if fileErr != nil {
+		err = r.RenameDir(oldPath, newPath)
+		if err != nil {
+			r.log.Printf("Unable to rename fuse dir %v to %v: %v", oldPath, newPath, err)
+			return errToStatus(err)
+		}
+		return errToStatus(nil)
+	}
+	err = fileNode.Close()
+	if err != nil {
+		r.log.Printf("Unable to close file node of %v: %v", oldPath, err)
+	}
+
+	// Replace the destination if it already exists. It is only removed once
+	// the file was renamed.
+	err = r.tg.Add()
+	if err != nil {
+		return errToStatus(err)
+	}
+	defer r.tg.Done()
+	err = r.managedReplaceFile(oldPath, newPath)
+	if err != nil {
+		r.log.Printf("Unable to rename fuse file %v to %v: %v", oldPath, newPath, err)
+		return errToStatus(err)
+	}
+	return errToStatus(nil)
+}
+
+// Rmdir deletes an empty directory within the directory.
+func (fdn *fuseDirnode) Rmdir(ctx context.Context, name string) syscall.Errno {
+	if fdn.staticFilesystem.options.ReadOnly {
+		return syscall.EROFS
+	}
+	siaPath, err := fdn.staticChildTurtleDexPath(name)
+	if err != nil {
+		return syscall.EINVAL
+	}
+	r := fdn.staticFilesystem.renter
+
+	// Only empty directories can be removed, the renter would delete the
+	// contents of the directory as well.
+	childDir, err := fdn.staticDirNode.Dir(name)
+	if err != nil {
+		return errToStatus(err)
+	}
+	fileinfos, dirinfos, err := r.staticFileSystem.CachedListOnNode(childDir)
+	err = errors.Compose(err, childDir.Close())
+	if err != nil {
+		r.log.Printf("Unable to list fuse dir %v: %v", siaPath, err)
+		return errToStatus(err)
+	}
+	// The first directory is always the self directory.
+	if len(fileinfos) > 0 || len(dirinfos) > 1 {
+		return syscall.ENOTEMPTY
+	}
+	err = r.DeleteDir(siaPath)
+	if err != nil {
+		r.log.Printf("Unable to delete fuse dir %v: %v", siaPath, err)
+		return errToStatus(err)
+	}
+	return errToStatus(nil)
+}
+
+// Setattr changes the attributes of a file. Only changing the size of the file
+// is supported, other attributes are ignored.
+func (ffn *fuseFilenode) Setattr(ctx context.Context, f fs.FileHandle, in *fuse.SetAttrIn, out *fuse.AttrOut) syscall.Errno {
+	size, ok := in.GetSize()
+	if !ok {
+		return ffn.Getattr(ctx, f, out)
+	}
+	if ffn.staticFilesystem.options.ReadOnly {
+		return syscall.EROFS
+	}
+
+	// If the file isn't open for writing, e.g. when truncate(1) is used, the
+	// file is staged for the duration of the call.
+	_, writable := f.(*fuseWriteHandle)
+	if !writable {
+		err := ffn.managedStage(size == 0)
+		if err != nil {
+			siaPath, _ := ffn.managedTurtleDexPath()
+			ffn.staticFilesystem.renter.log.Printf("Unable to stage file %v for truncating: %v", siaPath, err)
+			return errToStatus(err)
+		}
+	}
+	err := ffn.managedTruncate(size)
+	if !writable {
+		err = errors.Compose(err, ffn.managedCommit(), ffn.managedUnstage())
+	}
+	if err != nil {
+		siaPath, _ := ffn.managedTurtleDexPath()
+		ffn.staticFilesystem.renter.log.Printf("Unable to truncate fuse file %v: %v", siaPath, err)
+		return errToStatus(err)
+	}
+	return ffn.Getattr(ctx, f, out)
+}
+
+// managedTruncate changes the size of the staged copy of the file.
+func (ffn *fuseFilenode) managedTruncate(size uint64) error {
+	ffn.fileMu.Lock()
+	defer ffn.fileMu.Unlock()
+	if ffn.staged == nil {
+		return errors.New("file is not staged")
+	}
+	err := ffn.staged.file.Truncate(int64(size))
+	if err != nil {
+		return err
+	}
+	ffn.staged.dirty = true
+	return nil
+}
+
 // setStatfsOut is a method that will set the StatfsOut fields which are
 // consistent across the fuse filesystem.
 func (ffs *fuseFS) setStatfsOut(out *fuse.StatfsOut) error {
@@ -401,9 +1023,51 @@ func (fdn *fuseDirnode) Statfs(ctx context.Context, out *fuse.StatfsOut) syscall
 func (ffn *fuseFilenode) Statfs(ctx context.Context, out *fuse.StatfsOut) syscall.Errno {
 	err := ffn.staticFilesystem.setStatfsOut(out)
 	if err != nil {
-		siaPath := ffn.staticFilesystem.renter.staticFileSystem.FileTurtleDexPath(ffn.staticFileNode)
+		siaPath, _ := ffn.managedTurtleDexPath()
 		ffn.staticFilesystem.renter.log.Printf("Error fetching statfs for fuse file %v: %v", siaPath, err)
 		return errToStatus(err)
 	}
 	return errToStatus(nil)
 }
+
+// Unlink deletes a file within the directory.
+func (fdn *fuseDirnode) Unlink(ctx context.Context, name string) syscall.Errno {
+	if fdn.staticFilesystem.options.ReadOnly {
+		return syscall.EROFS
+	}
+	// Files which haven't been uploaded yet don't exist in the renter.
+	// Removing them from the fuse tree ensures they never will.
+	if _, ok := fdn.uncommittedChild(name); ok {
+		return errToStatus(nil)
+	}
+	siaPath, err := fdn.staticChildTurtleDexPath(name)
+	if err != nil {
+		return syscall.EINVAL
+	}
+	err = fdn.staticFilesystem.renter.DeleteFile(siaPath)
+	if err != nil {
+		fdn.staticFilesystem.renter.log.Printf("Unable to delete fuse file %v: %v", siaPath, err)
+		return errToStatus(err)
+	}
+	return errToStatus(nil)
+}
+
+// Write writes data to the staged copy of the file. The data is uploaded when
+// the file is flushed.
+func (ffn *fuseFilenode) Write(ctx context.Context, f fs.FileHandle, data []byte, offset int64) (uint32, syscall.Errno) {
+	ffn.fileMu.Lock()
+	defer ffn.fileMu.Unlock()
+	if ffn.staged == nil {
+		return 0, syscall.EBADF
+	}
+	n, err := ffn.staged.file.WriteAt(data, offset)
+	if n > 0 {
+		ffn.staged.dirty = true
+	}
+	if err != nil {
+		siaPath, _ := ffn.managedTurtleDexPath()
+		ffn.staticFilesystem.renter.log.Printf("Error writing to offset %v of staged file %v: %v", offset, siaPath, err)
+		return uint32(n), errToStatus(err)
+	}
+	return uint32(n), errToStatus(nil)
+}
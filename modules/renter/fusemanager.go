@@ -4,6 +4,8 @@ package renter
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"sync"
 
 	"github.com/hanwen/go-fuse/v2/fs"
@@ -14,6 +16,10 @@ import (
 
 var errNothingMounted = errors.New("nothing mounted at that path")
 
+// fuseStagingDir is the directory within the renter's persist dir where files
+// that are open for writing in a fuse filesystem are staged.
+const fuseStagingDir = "fusestaging"
+
 // A fuseManager manages mounted fuse filesystems.
 type fuseManager struct {
 	mountPoints map[string]*fuseFS
@@ -30,6 +36,12 @@ func newFuseManager(r *Renter) renterFuseManager {
 		renter:      r,
 	}
 
+	// Remove any staged files which were left behind by an unclean shutdown.
+	err := os.RemoveAll(filepath.Join(r.persistDir, fuseStagingDir))
+	if err != nil {
+		r.log.Printf("Unable to clean up the fuse staging dir: %v", err)
+	}
+
 	// Close the fuse manager on shutdown.
 	r.tg.OnStop(func() error {
 		return fm.managedCloseFuseManager()
@@ -98,9 +110,13 @@ func (fm *fuseManager) Mount(mountPoint string, sp modules.TurtleDexPath, opts m
 		}
 	}()
 
-	// Enforce read-only requirements for now.
+	// Create the dir that files are staged in before they are uploaded.
+	stagingDir := filepath.Join(fm.renter.persistDir, fuseStagingDir)
 	if !opts.ReadOnly {
-		return errors.New("fuse must be mounted as read-only")
+		err = os.MkdirAll(stagingDir, modules.DefaultDirPerm)
+		if err != nil {
+			return errors.AddContext(err, "unable to create the fuse staging dir")
+		}
 	}
 
 	// Get the mountpoint's root from the filesystem.
@@ -110,7 +126,8 @@ func (fm *fuseManager) Mount(mountPoint string, sp modules.TurtleDexPath, opts m
 	}
 	// Create the fuse filesystem object.
 	filesystem := &fuseFS{
-		options: opts,
+		options:    opts,
+		stagingDir: stagingDir,
 
 		renter: fm.renter,
 	}
@@ -15,6 +15,10 @@ const (
 	// interactions required before decay is applied.
 	historicInteractionDecayLimit = 500
 
+	// hostHistoryJobPeriod is the length of the periods for which the outcomes
+	// of the renter's jobs on a host are counted.
+	hostHistoryJobPeriod = time.Hour
+
 	// hostRequestTimeout indicates how long a host has to respond to a dial.
 	hostRequestTimeout = 2 * time.Minute
 
@@ -114,6 +118,14 @@ var (
 )
 
 var (
+	// hostHistoryRetention is the amount of time the data points of the
+	// host time series are kept for.
+	hostHistoryRetention = build.Select(build.Var{
+		Standard: 30 * 24 * time.Hour,
+		Dev:      24 * time.Hour,
+		Testing:  time.Hour,
+	}).(time.Duration)
+
 	// maxScanSleep is the maximum amount of time that the hostdb will sleep
 	// between performing scans of the hosts.
 	maxScanSleep = build.Select(build.Var{
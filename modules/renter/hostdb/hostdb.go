@@ -73,6 +73,10 @@ type HostDB struct {
 	allowance  modules.Allowance
 	weightFunc hosttree.WeightFunc
 
+	// scoringPolicy tunes the adjustments of the weightFunc and adds hard
+	// limits which hosts need to meet.
+	scoringPolicy modules.HostScoringPolicy
+
 	// txnFees are the most recent fees used in the score estimation. It is
 	// used to determine if the transaction fees have changed enough to warrant
 	// rebuilding the hosttree with an updated weight function.
@@ -103,6 +107,17 @@ type HostDB struct {
 	filteredHosts      map[string]types.TurtleDexPublicKey
 	filterMode         modules.FilterMode
 
+	// placement assigns hosts to failure domains and limits the number of
+	// hosts selected per domain according to the placementPolicy. If the
+	// files of the policy can't be loaded on startup, the placement only
+	// enforces the limits which don't depend on them.
+	placement       *hosttree.Placement
+	placementPolicy modules.PlacementPolicy
+
+	// staticHistory contains the time series of scans, prices and job
+	// outcomes of the hosts.
+	staticHistory *hostHistories
+
 	blockHeight types.BlockHeight
 	lastChange  modules.ConsensusChangeID
 }
@@ -137,6 +152,7 @@ func (hdb *HostDB) modify(host modules.HostDBEntry) error {
 
 // remove removes the HostDBEntry from both hosttrees
 func (hdb *HostDB) remove(pk types.TurtleDexPublicKey) error {
+	hdb.staticHistory.managedRemove(pk)
 	err := hdb.staticHostTree.Remove(pk)
 	_, ok := hdb.filteredHosts[pk.String()]
 	isWhitelist := hdb.filterMode == modules.HostDBActiveWhitelist
@@ -233,6 +249,7 @@ func hostdbBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 		knownContracts: make(map[string]contractInfo),
 		scanMap:        make(map[string]struct{}),
 		staticAlerter:  modules.NewAlerter("hostdb"),
+		staticHistory:  newHostHistories(),
 	}
 
 	// Set the allowance, txnFees and hostweight function.
@@ -269,6 +286,13 @@ func hostdbBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 	hdb.staticHostTree = hosttree.New(hdb.weightFunc, deps.Resolver())
 	hdb.staticFilteredTree = hdb.staticHostTree
 
+	// Initialize the placement without any limits. An empty policy doesn't
+	// require loading any files.
+	hdb.placement, err = hosttree.NewPlacement(modules.PlacementPolicy{}, deps.Resolver())
+	if err != nil {
+		return nil, err
+	}
+
 	// Load the prior persistence structures.
 	hdb.mu.Lock()
 	err = hdb.load()
@@ -276,6 +300,10 @@ func hostdbBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 	if err != nil && !os.IsNotExist(err) {
 		return nil, err
 	}
+	err = hdb.loadHistory()
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to load the host history")
+	}
 	err = hdb.tg.AfterStop(func() error {
 		hdb.mu.Lock()
 		err := hdb.saveSync()
@@ -284,6 +312,11 @@ func hostdbBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 			hdb.staticLog.Println("Unable to save the hostdb:", err)
 			return err
 		}
+		err = hdb.managedSaveHistory()
+		if err != nil {
+			hdb.staticLog.Println("Unable to save the host history:", err)
+			return err
+		}
 		return nil
 	})
 	if err != nil {
@@ -650,6 +683,48 @@ func (hdb *HostDB) IPViolationsCheck() (bool, error) {
 	return !hdb.disableIPViolationCheck, nil
 }
 
+// PlacementDomains returns the failure domains of a host according to the
+// current placement policy. Only the types of domains which are limited by the
+// policy are returned.
+func (hdb *HostDB) PlacementDomains(spk types.TurtleDexPublicKey) ([]modules.PlacementDomain, bool, error) {
+	if err := hdb.tg.Add(); err != nil {
+		return nil, false, errors.AddContext(err, "error adding hostdb threadgroup:")
+	}
+	defer hdb.tg.Done()
+	hdb.mu.RLock()
+	host, exists := hdb.staticHostTree.Select(spk)
+	placement := hdb.placement
+	hdb.mu.RUnlock()
+	if !exists {
+		return nil, false, nil
+	}
+	// Resolving the domains might require a DNS lookup so it happens without
+	// holding the lock.
+	return placement.Domains(host), true, nil
+}
+
+// PlacementPolicy returns the placement policy of the hostdb.
+func (hdb *HostDB) PlacementPolicy() (modules.PlacementPolicy, error) {
+	if err := hdb.tg.Add(); err != nil {
+		return modules.PlacementPolicy{}, errors.AddContext(err, "error adding hostdb threadgroup:")
+	}
+	defer hdb.tg.Done()
+	hdb.mu.RLock()
+	defer hdb.mu.RUnlock()
+	return hdb.placementPolicy, nil
+}
+
+// ScoringPolicy returns the policy which tunes the scoring of hosts.
+func (hdb *HostDB) ScoringPolicy() (modules.HostScoringPolicy, error) {
+	if err := hdb.tg.Add(); err != nil {
+		return modules.HostScoringPolicy{}, errors.AddContext(err, "error adding hostdb threadgroup:")
+	}
+	defer hdb.tg.Done()
+	hdb.mu.RLock()
+	defer hdb.mu.RUnlock()
+	return hdb.scoringPolicy.Copy(), nil
+}
+
 // SetAllowance updates the allowance used by the hostdb for weighing hosts by
 // updating the host weight function. It will completely rebuild the hosttree so
 // it should be used with care.
@@ -690,6 +765,56 @@ func (hdb *HostDB) SetIPViolationCheck(enabled bool) error {
 	return nil
 }
 
+// SetPlacementPolicy updates the placement policy of the hostdb. The ASN and
+// host groups files of the policy are loaded immediately which means that
+// changes to those files require setting the policy again.
+func (hdb *HostDB) SetPlacementPolicy(policy modules.PlacementPolicy) error {
+	if err := hdb.tg.Add(); err != nil {
+		return errors.AddContext(err, "error adding hostdb threadgroup:")
+	}
+	defer hdb.tg.Done()
+	if err := policy.Validate(uint64(modules.RenterDefaultParityPieces)); err != nil {
+		return errors.AddContext(err, "invalid placement policy")
+	}
+
+	placement, err := hosttree.NewPlacement(policy, hdb.staticDeps.Resolver())
+	if err != nil {
+		return err
+	}
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+	hdb.placement = placement
+	hdb.placementPolicy = policy
+	return hdb.saveSync()
+}
+
+// SetScoringPolicy updates the policy which tunes the scoring of hosts by
+// updating the host weight function. It will completely rebuild the hosttree so
+// it should be used with care.
+func (hdb *HostDB) SetScoringPolicy(policy modules.HostScoringPolicy) error {
+	if err := hdb.tg.Add(); err != nil {
+		return errors.AddContext(err, "error adding hostdb threadgroup:")
+	}
+	defer hdb.tg.Done()
+	if err := policy.Validate(); err != nil {
+		return errors.AddContext(err, "invalid scoring policy")
+	}
+
+	// Update the policy.
+	hdb.mu.Lock()
+	hdb.scoringPolicy = policy.Copy()
+	err := hdb.saveSync()
+	allowance := hdb.allowance
+	hdb.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// Update the weight function.
+	wf := hdb.managedCalculateHostWeightFn(allowance)
+	return hdb.managedSetWeightFunction(wf)
+}
+
 // UpdateContracts rebuilds the knownContracts of the HostBD using the provided
 // contracts.
 func (hdb *HostDB) UpdateContracts(contracts []modules.RenterContract) error {
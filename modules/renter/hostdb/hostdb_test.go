@@ -51,6 +51,7 @@ func bareHostDB() *HostDB {
 		allowance:      modules.DefaultAllowance,
 		staticLog:      logger,
 		knownContracts: make(map[string]contractInfo),
+		staticHistory:  newHostHistories(),
 	}
 	hdb.weightFunc = hdb.managedCalculateHostWeightFn(hdb.allowance)
 	hdb.staticHostTree = hosttree.New(hdb.weightFunc, &modules.ProductionResolver{})
@@ -2,6 +2,7 @@ package hostdb
 
 import (
 	"math"
+	"time"
 
 	"github.com/turtledex/TurtleDexCore/modules"
 	"github.com/turtledex/TurtleDexCore/types"
@@ -129,3 +130,30 @@ func (hdb *HostDB) IncrementFailedInteractions(key types.TurtleDexPublicKey) err
 	hdb.staticHostTree.Modify(host)
 	return nil
 }
+
+// RecordAudit records the outcome of an audit of the data stored on the host
+// with the given key.
+func (hdb *HostDB) RecordAudit(key types.TurtleDexPublicKey, success bool) error {
+	if err := hdb.tg.Add(); err != nil {
+		return errors.AddContext(err, "error adding hostdb threadgroup:")
+	}
+	defer hdb.tg.Done()
+
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+
+	// Fetch the host.
+	host, haveHost := hdb.staticHostTree.Select(key)
+	if !haveHost {
+		return errors.AddContext(errHostNotFoundInTree, "unable to record audit:")
+	}
+
+	// Record the outcome.
+	if success {
+		host.AuditSuccesses++
+	} else {
+		host.AuditFailures++
+		host.LastAuditFailure = time.Now()
+	}
+	return hdb.modify(host)
+}
@@ -0,0 +1,191 @@
+package hostdb
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/persist"
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+var (
+	// historyFilename defines the name of the file that holds the time series
+	// of the hosts.
+	historyFilename = "hosthistory.json"
+
+	// historyMetadata defines the metadata of the host history file.
+	historyMetadata = persist.Metadata{
+		Header:  "HostDB Host History",
+		Version: "1.0",
+	}
+)
+
+// hostHistories contains the time series the hostdb keeps about hosts. It has
+// its own lock to allow the renter's workers to record the outcome of their
+// jobs without contending for the hostdb's lock.
+type hostHistories struct {
+	// histories maps the serialized public keys of hosts to their time
+	// series.
+	histories map[string]*modules.HostHistory
+	mu        sync.Mutex
+}
+
+// newHostHistories creates an empty hostHistories object.
+func newHostHistories() *hostHistories {
+	return &hostHistories{
+		histories: make(map[string]*modules.HostHistory),
+	}
+}
+
+// history returns the history of a host, creating it if necessary.
+func (hh *hostHistories) history(pk types.TurtleDexPublicKey) *modules.HostHistory {
+	history, exists := hh.histories[pk.String()]
+	if !exists {
+		history = new(modules.HostHistory)
+		hh.histories[pk.String()] = history
+	}
+	return history
+}
+
+// managedHistory returns a copy of the history of a host and whether the host
+// has a history.
+func (hh *hostHistories) managedHistory(pk types.TurtleDexPublicKey) (modules.HostHistory, bool) {
+	hh.mu.Lock()
+	defer hh.mu.Unlock()
+	history, exists := hh.histories[pk.String()]
+	if !exists {
+		return modules.HostHistory{}, false
+	}
+	return modules.HostHistory{
+		Scans:  append([]modules.HostHistoryScan{}, history.Scans...),
+		Prices: append([]modules.HostHistoryPrices{}, history.Prices...),
+		Jobs:   append([]modules.HostHistoryJobs{}, history.Jobs...),
+	}, true
+}
+
+// managedRecordJob records the outcome of a job in the period containing the
+// provided time.
+func (hh *hostHistories) managedRecordJob(pk types.TurtleDexPublicKey, success bool, now time.Time) {
+	hh.mu.Lock()
+	defer hh.mu.Unlock()
+	history := hh.history(pk)
+	period := now.Truncate(hostHistoryJobPeriod)
+	if len(history.Jobs) == 0 || history.Jobs[len(history.Jobs)-1].Timestamp.Before(period) {
+		history.Jobs = append(history.Jobs, modules.HostHistoryJobs{Timestamp: period})
+	}
+	jobs := &history.Jobs[len(history.Jobs)-1]
+	if success {
+		jobs.Successes++
+	} else {
+		jobs.Failures++
+	}
+}
+
+// managedRecordScan records the outcome of a scan and the prices of the host
+// if they changed since the last scan.
+func (hh *hostHistories) managedRecordScan(pk types.TurtleDexPublicKey, scan modules.HostHistoryScan, prices *modules.HostHistoryPrices) {
+	hh.mu.Lock()
+	defer hh.mu.Unlock()
+	history := hh.history(pk)
+	history.Scans = append(history.Scans, scan)
+	if prices == nil {
+		return
+	}
+	if len(history.Prices) > 0 && history.Prices[len(history.Prices)-1].EqualPrices(*prices) {
+		return
+	}
+	history.Prices = append(history.Prices, *prices)
+}
+
+// managedRemove removes the history of a host.
+func (hh *hostHistories) managedRemove(pk types.TurtleDexPublicKey) {
+	hh.mu.Lock()
+	defer hh.mu.Unlock()
+	delete(hh.histories, pk.String())
+}
+
+// managedPrune removes all data points which are older than the cutoff. The
+// most recent prices of a host are kept since they are still in effect.
+func (hh *hostHistories) managedPrune(cutoff time.Time) {
+	hh.mu.Lock()
+	defer hh.mu.Unlock()
+	for key, history := range hh.histories {
+		i := 0
+		for i < len(history.Scans) && history.Scans[i].Timestamp.Before(cutoff) {
+			i++
+		}
+		history.Scans = history.Scans[i:]
+
+		i = 0
+		for i < len(history.Prices)-1 && history.Prices[i+1].Timestamp.Before(cutoff) {
+			i++
+		}
+		history.Prices = history.Prices[i:]
+
+		i = 0
+		for i < len(history.Jobs) && history.Jobs[i].Timestamp.Add(hostHistoryJobPeriod).Before(cutoff) {
+			i++
+		}
+		history.Jobs = history.Jobs[i:]
+
+		if len(history.Scans) == 0 && len(history.Jobs) == 0 {
+			delete(hh.histories, key)
+		}
+	}
+}
+
+// HostHistory returns the time series of scans, prices and job outcomes for a
+// given host.
+func (hdb *HostDB) HostHistory(pk types.TurtleDexPublicKey) (modules.HostHistory, bool, error) {
+	if err := hdb.tg.Add(); err != nil {
+		return modules.HostHistory{}, false, errors.AddContext(err, "error adding hostdb threadgroup:")
+	}
+	defer hdb.tg.Done()
+	history, exists := hdb.staticHistory.managedHistory(pk)
+	return history, exists, nil
+}
+
+// RecordJob records the outcome of a job a renter worker executed on the host
+// with the given key.
+func (hdb *HostDB) RecordJob(pk types.TurtleDexPublicKey, success bool) error {
+	if err := hdb.tg.Add(); err != nil {
+		return errors.AddContext(err, "error adding hostdb threadgroup:")
+	}
+	defer hdb.tg.Done()
+	hdb.staticHistory.managedRecordJob(pk, success, time.Now())
+	return nil
+}
+
+// managedSaveHistory prunes the time series of the hosts and saves them to
+// disk.
+func (hdb *HostDB) managedSaveHistory() error {
+	hh := hdb.staticHistory
+	hh.managedPrune(time.Now().Add(-hostHistoryRetention))
+	hh.mu.Lock()
+	defer hh.mu.Unlock()
+	return hdb.staticDeps.SaveFileSync(historyMetadata, hh.histories, filepath.Join(hdb.persistDir, historyFilename))
+}
+
+// loadHistory loads the time series of the hosts from disk.
+func (hdb *HostDB) loadHistory() error {
+	histories := make(map[string]*modules.HostHistory)
+	err := hdb.staticDeps.LoadFile(historyMetadata, &histories, filepath.Join(hdb.persistDir, historyFilename))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if histories == nil {
+		histories = make(map[string]*modules.HostHistory)
+	}
+	hh := hdb.staticHistory
+	hh.mu.Lock()
+	hh.histories = histories
+	hh.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,80 @@
+package hostdb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/turtledex/fastrand"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+// TestHostHistories is a unit test for recording and pruning the time series
+// of hosts.
+func TestHostHistories(t *testing.T) {
+	hh := newHostHistories()
+	pk := types.TurtleDexPublicKey{Key: fastrand.Bytes(32)}
+	if _, exists := hh.managedHistory(pk); exists {
+		t.Fatal("host shouldn't have a history")
+	}
+
+	// Record jobs in two periods.
+	start := time.Now().Truncate(hostHistoryJobPeriod)
+	hh.managedRecordJob(pk, true, start)
+	hh.managedRecordJob(pk, false, start.Add(hostHistoryJobPeriod/2))
+	hh.managedRecordJob(pk, true, start.Add(hostHistoryJobPeriod))
+	history, exists := hh.managedHistory(pk)
+	if !exists {
+		t.Fatal("host should have a history")
+	}
+	if len(history.Jobs) != 2 {
+		t.Fatal("expected 2 periods but got", len(history.Jobs))
+	}
+	if history.Jobs[0].Successes != 1 || history.Jobs[0].Failures != 1 || history.Jobs[1].Successes != 1 {
+		t.Fatal("wrong job counts", history.Jobs)
+	}
+
+	// Record scans. Prices are only added when they change.
+	settings := DefaultHostDBEntry.HostExternalSettings
+	for i := 0; i < 3; i++ {
+		ts := start.Add(time.Duration(i) * time.Minute)
+		prices := modules.NewHostHistoryPrices(ts, settings, nil)
+		hh.managedRecordScan(pk, modules.HostHistoryScan{Timestamp: ts, Success: true}, &prices)
+	}
+	settings.StoragePrice = settings.StoragePrice.Mul64(2)
+	prices := modules.NewHostHistoryPrices(start.Add(time.Hour), settings, nil)
+	hh.managedRecordScan(pk, modules.HostHistoryScan{Timestamp: start.Add(time.Hour), Success: true}, &prices)
+	hh.managedRecordScan(pk, modules.HostHistoryScan{Timestamp: start.Add(2 * time.Hour), Error: "offline"}, nil)
+	history, _ = hh.managedHistory(pk)
+	if len(history.Scans) != 5 {
+		t.Fatal("expected 5 scans but got", len(history.Scans))
+	}
+	if len(history.Prices) != 2 {
+		t.Fatal("expected 2 price changes but got", len(history.Prices))
+	}
+
+	// The returned history is a copy.
+	history.Scans[0].Success = false
+	history, _ = hh.managedHistory(pk)
+	if !history.Scans[0].Success {
+		t.Fatal("history was modified through the copy")
+	}
+
+	// Prune everything before the last scan. The first job period and 4 scans
+	// are removed but the most recent prices are kept.
+	hh.managedPrune(start.Add(time.Hour + time.Minute))
+	history, _ = hh.managedHistory(pk)
+	if len(history.Jobs) != 1 || len(history.Scans) != 1 || len(history.Prices) != 1 {
+		t.Fatal("wrong history after pruning", len(history.Jobs), len(history.Scans), len(history.Prices))
+	}
+	if !history.Prices[0].StoragePrice.Equals(settings.StoragePrice) {
+		t.Fatal("the most recent prices should be kept")
+	}
+
+	// Once all scans and jobs are pruned the history is removed.
+	hh.managedPrune(start.Add(3 * time.Hour))
+	if _, exists := hh.managedHistory(pk); exists {
+		t.Fatal("history should be removed")
+	}
+}
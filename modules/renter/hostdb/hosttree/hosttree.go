@@ -306,6 +306,14 @@ func (ht *HostTree) Select(spk types.TurtleDexPublicKey) (modules.HostDBEntry, b
 // intentionally being given a low score to indicate that the host should not be
 // used.
 func (ht *HostTree) SelectRandom(n int, blacklist, addressBlacklist []types.TurtleDexPublicKey) []modules.HostDBEntry {
+	return ht.SelectRandomWithPlacement(n, blacklist, addressBlacklist, nil, nil)
+}
+
+// SelectRandomWithPlacement works as SelectRandom but additionally limits the
+// number of hosts per failure domain according to the provided placement.
+// The hosts in 'placementBlacklist' count towards the limits of their domains
+// without being considered themselves. A nil placement disables the limits.
+func (ht *HostTree) SelectRandomWithPlacement(n int, blacklist, addressBlacklist, placementBlacklist []types.TurtleDexPublicKey, placement *Placement) []modules.HostDBEntry {
 	ht.mu.Lock()
 	defer ht.mu.Unlock()
 
@@ -323,6 +331,19 @@ func (ht *HostTree) SelectRandom(n int, blacklist, addressBlacklist []types.Turt
 		// Add the node to the addressFilter.
 		filter.Add(node.entry.NetAddress)
 	}
+	// Create a placement filter and add the hosts from the
+	// placementBlacklist.
+	var placementFilter *PlacementFilter
+	if placement != nil {
+		placementFilter = placement.NewFilter()
+		for _, pubkey := range placementBlacklist {
+			node, exists := ht.hosts[pubkey.String()]
+			if !exists {
+				continue
+			}
+			placementFilter.Add(node.entry.HostDBEntry)
+		}
+	}
 	// Remove hosts we want to blacklist from the tree but remember them to make
 	// sure we can insert them later.
 	for _, pubkey := range blacklist {
@@ -349,14 +370,18 @@ func (ht *HostTree) SelectRandom(n int, blacklist, addressBlacklist []types.Turt
 			len(node.entry.ScanHistory) > 0 &&
 			node.entry.ScanHistory[len(node.entry.ScanHistory)-1].Success &&
 			!filter.Filtered(node.entry.NetAddress) &&
+			(placementFilter == nil || !placementFilter.Filtered(node.entry.HostDBEntry)) &&
 			node.entry.weight.Cmp(weightOne) > 0 {
 			// The host must be online and accepting contracts to be returned
 			// by the random function. It also has to pass the addressFilter
-			// check.
+			// and placementFilter checks.
 			hosts = append(hosts, node.entry.HostDBEntry)
 
-			// If the host passed the filter, we add it to the filter.
+			// If the host passed the filters, we add it to the filters.
 			filter.Add(node.entry.NetAddress)
+			if placementFilter != nil {
+				placementFilter.Add(node.entry.HostDBEntry)
+			}
 		}
 
 		removedEntries = append(removedEntries, node.entry)
@@ -0,0 +1,298 @@
+package hosttree
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+var (
+	// errNoASNFile is returned if a policy limits the pieces per ASN without
+	// specifying the file to look up the ASNs of hosts.
+	errNoASNFile = errors.New("limiting the pieces per ASN requires an ASN file")
+
+	// errNoHostGroupsFile is returned if a policy limits the pieces per host
+	// group without specifying the file which defines the groups.
+	errNoHostGroupsFile = errors.New("limiting the pieces per host group requires a host groups file")
+)
+
+type (
+	// Placement assigns hosts to failure domains according to a
+	// PlacementPolicy. It is immutable after creation which makes it safe to
+	// share between threads.
+	Placement struct {
+		staticPolicy   modules.PlacementPolicy
+		staticResolver modules.Resolver
+
+		// staticASNRanges are the IP ranges of the ASN file sorted by their
+		// first address.
+		staticASNRanges []asnRange
+
+		// staticHostGroups maps the public keys of hosts to their group and
+		// staticNetGroups contains the groups of IP addresses and subnets.
+		staticHostGroups map[string]string
+		staticNetGroups  []netGroup
+	}
+
+	// PlacementFilter counts the hosts selected per failure domain and
+	// filters hosts which would exceed the limits of the placement policy.
+	PlacementFilter struct {
+		counts    map[modules.PlacementDomain]uint64
+		placement *Placement
+	}
+
+	// asnRange is a range of IP addresses announced by an autonomous system.
+	asnRange struct {
+		start net.IP
+		end   net.IP
+		asn   string
+	}
+
+	// netGroup assigns a subnet to a host group.
+	netGroup struct {
+		ipnet *net.IPNet
+		group string
+	}
+)
+
+// NewPlacement creates a Placement for the provided policy. It loads the ASN
+// and host groups files of the policy if they are required by the policy.
+func NewPlacement(policy modules.PlacementPolicy, resolver modules.Resolver) (*Placement, error) {
+	p := &Placement{
+		staticPolicy:     policy,
+		staticResolver:   resolver,
+		staticHostGroups: make(map[string]string),
+	}
+	if policy.MaxPiecesPerASN > 0 {
+		if policy.ASNFile == "" {
+			return nil, errNoASNFile
+		}
+		ranges, err := loadASNFile(policy.ASNFile)
+		if err != nil {
+			return nil, errors.AddContext(err, "unable to load ASN file")
+		}
+		p.staticASNRanges = ranges
+	}
+	if policy.MaxPiecesPerHostGroup > 0 {
+		if policy.HostGroupsFile == "" {
+			return nil, errNoHostGroupsFile
+		}
+		hostGroups, netGroups, err := loadHostGroupsFile(policy.HostGroupsFile)
+		if err != nil {
+			return nil, errors.AddContext(err, "unable to load host groups file")
+		}
+		p.staticHostGroups = hostGroups
+		p.staticNetGroups = netGroups
+	}
+	return p, nil
+}
+
+// Policy returns the policy of the placement.
+func (p *Placement) Policy() modules.PlacementPolicy {
+	return p.staticPolicy
+}
+
+// Domains returns the failure domains of a host. Only the types of domains
+// which are limited by the policy are returned. If the addresses of a host
+// can't be resolved, only its host group is returned.
+func (p *Placement) Domains(host modules.HostDBEntry) []modules.PlacementDomain {
+	if !p.staticPolicy.Enabled() {
+		return nil
+	}
+	var domains []modules.PlacementDomain
+	seen := make(map[modules.PlacementDomain]struct{})
+	add := func(t modules.PlacementDomainType, id string) {
+		d := modules.PlacementDomain{Type: t, ID: id}
+		if _, exists := seen[d]; exists {
+			return
+		}
+		seen[d] = struct{}{}
+		domains = append(domains, d)
+	}
+
+	// The public key of a host takes precedence over its addresses when
+	// looking up its group.
+	groupFound := false
+	if group, exists := p.staticHostGroups[host.PublicKey.String()]; exists && p.staticPolicy.MaxPiecesPerHostGroup > 0 {
+		add(modules.PlacementDomainHostGroup, group)
+		groupFound = true
+	}
+
+	addresses, err := p.staticResolver.LookupIP(host.NetAddress.Host())
+	if err != nil {
+		return domains
+	}
+	for _, ip := range addresses {
+		if ip4 := ip.To4(); ip4 != nil && p.staticPolicy.MaxPiecesPerIPv4Subnet > 0 {
+			mask := net.CIDRMask(modules.PlacementIPv4SubnetBits, 32)
+			add(modules.PlacementDomainIPv4Subnet, (&net.IPNet{IP: ip4.Mask(mask), Mask: mask}).String())
+		} else if ip4 == nil && p.staticPolicy.MaxPiecesPerIPv6Subnet > 0 {
+			mask := net.CIDRMask(modules.PlacementIPv6SubnetBits, 128)
+			add(modules.PlacementDomainIPv6Subnet, (&net.IPNet{IP: ip.Mask(mask), Mask: mask}).String())
+		}
+		if p.staticPolicy.MaxPiecesPerASN > 0 {
+			if asn, exists := p.lookupASN(ip); exists {
+				add(modules.PlacementDomainASN, asn)
+			}
+		}
+		if p.staticPolicy.MaxPiecesPerHostGroup > 0 && !groupFound {
+			for _, ng := range p.staticNetGroups {
+				if ng.ipnet.Contains(ip) {
+					add(modules.PlacementDomainHostGroup, ng.group)
+					break
+				}
+			}
+		}
+	}
+	return domains
+}
+
+// NewFilter creates a new, empty PlacementFilter for the placement.
+func (p *Placement) NewFilter() *PlacementFilter {
+	return &PlacementFilter{
+		counts:    make(map[modules.PlacementDomain]uint64),
+		placement: p,
+	}
+}
+
+// lookupASN returns the ASN of the range containing the provided ip.
+func (p *Placement) lookupASN(ip net.IP) (string, bool) {
+	ip = ip.To16()
+	// Find the last range which starts at or before the ip.
+	i := sort.Search(len(p.staticASNRanges), func(i int) bool {
+		return bytes.Compare(p.staticASNRanges[i].start, ip) > 0
+	}) - 1
+	if i < 0 || bytes.Compare(ip, p.staticASNRanges[i].end) > 0 {
+		return "", false
+	}
+	return p.staticASNRanges[i].asn, true
+}
+
+// Add adds a host to the filter, increasing the counts of all of its failure
+// domains.
+func (pf *PlacementFilter) Add(host modules.HostDBEntry) {
+	for _, d := range pf.placement.Domains(host) {
+		pf.counts[d]++
+	}
+}
+
+// Filtered checks whether adding the host to the filter would exceed the
+// maximum number of pieces of any of its failure domains.
+func (pf *PlacementFilter) Filtered(host modules.HostDBEntry) bool {
+	for _, d := range pf.placement.Domains(host) {
+		max := pf.placement.staticPolicy.MaxPieces(d.Type)
+		if max > 0 && pf.counts[d] >= max {
+			return true
+		}
+	}
+	return false
+}
+
+// loadASNFile loads the IP ranges of an ip2asn TSV file. Every line of the
+// file starts with the first and last address of the range followed by the
+// ASN announcing it. Ranges which are not announced (ASN 0) are ignored.
+func loadASNFile(path string) (_ []asnRange, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = errors.Compose(err, f.Close())
+	}()
+
+	var ranges []asnRange
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("line %v: expected at least 3 fields but got %v", line, len(fields))
+		}
+		start, end := net.ParseIP(fields[0]), net.ParseIP(fields[1])
+		if start == nil || end == nil {
+			return nil, fmt.Errorf("line %v: invalid ip range %v - %v", line, fields[0], fields[1])
+		}
+		if bytes.Compare(start.To16(), end.To16()) > 0 {
+			return nil, fmt.Errorf("line %v: range start %v is greater than its end %v", line, start, end)
+		}
+		if fields[2] == "0" {
+			continue
+		}
+		ranges = append(ranges, asnRange{
+			start: start.To16(),
+			end:   end.To16(),
+			asn:   fields[2],
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		return bytes.Compare(ranges[i].start, ranges[j].start) < 0
+	})
+	return ranges, nil
+}
+
+// loadHostGroupsFile loads the host groups of a host groups file. Every line
+// of the file assigns a host to a group in the format '<group> <host>' where
+// the host is either a public key, an IP address or a CIDR. Empty lines and
+// lines starting with '#' are ignored.
+func loadHostGroupsFile(path string) (_ map[string]string, _ []netGroup, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		err = errors.Compose(err, f.Close())
+	}()
+
+	hostGroups := make(map[string]string)
+	var netGroups []netGroup
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, nil, fmt.Errorf("line %v: expected '<group> <host>'", line)
+		}
+		group, host := fields[0], fields[1]
+
+		// Check for a CIDR or IP address first.
+		if _, ipnet, err := net.ParseCIDR(host); err == nil {
+			netGroups = append(netGroups, netGroup{ipnet: ipnet, group: group})
+			continue
+		}
+		if ip := net.ParseIP(host); ip != nil {
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			ipnet := &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+			netGroups = append(netGroups, netGroup{ipnet: ipnet, group: group})
+			continue
+		}
+		// Otherwise the host has to be a public key.
+		var spk types.TurtleDexPublicKey
+		if err := spk.LoadString(host); err != nil {
+			return nil, nil, fmt.Errorf("line %v: '%v' is neither a public key, ip address nor cidr", line, host)
+		}
+		hostGroups[spk.String()] = group
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	return hostGroups, netGroups, nil
+}
@@ -0,0 +1,222 @@
+package hosttree
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/turtledex/TurtleDexCore/build"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+// testPlacementResolver is a resolver for the placement tests which resolves
+// hostnames using a map.
+type testPlacementResolver map[string][]net.IP
+
+func (r testPlacementResolver) LookupIP(host string) ([]net.IP, error) {
+	ips, exists := r[host]
+	if !exists {
+		return nil, fmt.Errorf("unknown host %v", host)
+	}
+	return ips, nil
+}
+
+// newPlacementTestEntry creates a host entry with the provided hostname.
+func newPlacementTestEntry(host string) modules.HostDBEntry {
+	entry := makeHostDBEntry()
+	entry.NetAddress = modules.NetAddress(host + ":1234")
+	return entry
+}
+
+// writePlacementTestFile writes a file with the provided content to its own
+// directory within the test directory. build.TempDir clears the directory, so
+// sharing one would remove the files written before.
+func writePlacementTestFile(t *testing.T, name, content string) string {
+	dir := build.TempDir("hosttree", t.Name(), name)
+	if err := os.MkdirAll(dir, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(content), modules.DefaultFilePerm); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestPlacementDomains tests assigning hosts to failure domains.
+func TestPlacementDomains(t *testing.T) {
+	resolver := testPlacementResolver{
+		"v4":        {net.ParseIP("10.1.2.3")},
+		"v6":        {net.ParseIP("2001:db8:1:2::1")},
+		"dualstack": {net.ParseIP("10.1.200.1"), net.ParseIP("2001:db8:1:ffff::1")},
+		"grouped":   {net.ParseIP("192.168.0.1")},
+	}
+	asnFile := writePlacementTestFile(t, "ip2asn.tsv", `# comment
+10.0.0.0	10.255.255.255	64496	ZZ	EXAMPLE-NET
+172.16.0.0	172.31.255.255	0	None	Not routed
+2001:db8::	2001:db8:ffff:ffff:ffff:ffff:ffff:ffff	64497	ZZ	EXAMPLE-NET6
+`)
+	pinned := newPlacementTestEntry("v4")
+	groupsFile := writePlacementTestFile(t, "groups", fmt.Sprintf(`# comment
+dc1 192.168.0.0/16
+dc2 2001:db8:1:2::1
+dc3 %v
+`, pinned.PublicKey))
+
+	p, err := NewPlacement(modules.PlacementPolicy{
+		MaxPiecesPerIPv4Subnet: 1,
+		MaxPiecesPerIPv6Subnet: 1,
+		MaxPiecesPerASN:        1,
+		MaxPiecesPerHostGroup:  1,
+		ASNFile:                asnFile,
+		HostGroupsFile:         groupsFile,
+	}, resolver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ipv4 := func(id string) modules.PlacementDomain {
+		return modules.PlacementDomain{Type: modules.PlacementDomainIPv4Subnet, ID: id}
+	}
+	ipv6 := func(id string) modules.PlacementDomain {
+		return modules.PlacementDomain{Type: modules.PlacementDomainIPv6Subnet, ID: id}
+	}
+	asn := func(id string) modules.PlacementDomain {
+		return modules.PlacementDomain{Type: modules.PlacementDomainASN, ID: id}
+	}
+	group := func(id string) modules.PlacementDomain {
+		return modules.PlacementDomain{Type: modules.PlacementDomainHostGroup, ID: id}
+	}
+	tests := []struct {
+		entry   modules.HostDBEntry
+		domains []modules.PlacementDomain
+	}{
+		{pinned, []modules.PlacementDomain{group("dc3"), ipv4("10.1.0.0/16"), asn("64496")}},
+		{newPlacementTestEntry("v4"), []modules.PlacementDomain{ipv4("10.1.0.0/16"), asn("64496")}},
+		{newPlacementTestEntry("v6"), []modules.PlacementDomain{ipv6("2001:db8:1::/48"), asn("64497"), group("dc2")}},
+		{newPlacementTestEntry("dualstack"), []modules.PlacementDomain{ipv4("10.1.0.0/16"), asn("64496"), ipv6("2001:db8:1::/48"), asn("64497")}},
+		{newPlacementTestEntry("grouped"), []modules.PlacementDomain{ipv4("192.168.0.0/16"), group("dc1")}},
+		{newPlacementTestEntry("unknown"), nil},
+	}
+	for i, test := range tests {
+		domains := p.Domains(test.entry)
+		if fmt.Sprint(domains) != fmt.Sprint(test.domains) {
+			t.Errorf("%v: expected %v but got %v", i, test.domains, domains)
+		}
+	}
+
+	// Without limits, hosts don't have any domains.
+	p, err = NewPlacement(modules.PlacementPolicy{}, resolver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if domains := p.Domains(pinned); len(domains) != 0 {
+		t.Fatal("expected no domains", domains)
+	}
+
+	// Limits which depend on files require the files.
+	_, err = NewPlacement(modules.PlacementPolicy{MaxPiecesPerASN: 1}, resolver)
+	if err != errNoASNFile {
+		t.Fatalf("expected %v but got %v", errNoASNFile, err)
+	}
+	_, err = NewPlacement(modules.PlacementPolicy{MaxPiecesPerHostGroup: 1}, resolver)
+	if err != errNoHostGroupsFile {
+		t.Fatalf("expected %v but got %v", errNoHostGroupsFile, err)
+	}
+	invalidFile := writePlacementTestFile(t, "invalid", "dc1 not-a-host\n")
+	_, err = NewPlacement(modules.PlacementPolicy{MaxPiecesPerHostGroup: 1, HostGroupsFile: invalidFile}, resolver)
+	if err == nil {
+		t.Fatal("invalid host groups file should be rejected")
+	}
+}
+
+// TestPlacementFilter tests that the PlacementFilter limits the number of
+// hosts per failure domain.
+func TestPlacementFilter(t *testing.T) {
+	resolver := testPlacementResolver{
+		"host1": {net.ParseIP("10.1.0.1")},
+		"host2": {net.ParseIP("10.1.1.1")},
+		"host3": {net.ParseIP("10.1.2.1")},
+		"host4": {net.ParseIP("10.2.0.1")},
+	}
+	p, err := NewPlacement(modules.PlacementPolicy{MaxPiecesPerIPv4Subnet: 2}, resolver)
+	if err != nil {
+		t.Fatal(err)
+	}
+	filter := p.NewFilter()
+	host1 := newPlacementTestEntry("host1")
+	host2 := newPlacementTestEntry("host2")
+	host3 := newPlacementTestEntry("host3")
+	host4 := newPlacementTestEntry("host4")
+
+	// The first 2 hosts of the subnet pass the filter, the third one
+	// doesn't.
+	for _, host := range []modules.HostDBEntry{host1, host2} {
+		if filter.Filtered(host) {
+			t.Fatal("host shouldn't be filtered", host.NetAddress)
+		}
+		filter.Add(host)
+	}
+	if !filter.Filtered(host3) {
+		t.Fatal("host3 should be filtered")
+	}
+	// Hosts of another subnet aren't affected.
+	if filter.Filtered(host4) {
+		t.Fatal("host4 shouldn't be filtered")
+	}
+}
+
+// TestSelectRandomWithPlacement tests that SelectRandomWithPlacement respects
+// the limits of the placement policy.
+func TestSelectRandomWithPlacement(t *testing.T) {
+	resolver := testPlacementResolver{}
+	tree := New(func(hdbe modules.HostDBEntry) ScoreBreakdown {
+		return newCustomScoreBreakdown(types.NewCurrency64(20))
+	}, resolver)
+
+	// Add 10 hosts, 5 of them in each of 2 /16 subnets.
+	var subnetA []types.TurtleDexPublicKey
+	for i := 0; i < 10; i++ {
+		host := fmt.Sprintf("host%v", i)
+		resolver[host] = []net.IP{net.IPv4(10, byte(i%2), byte(i), 1)}
+		entry := newPlacementTestEntry(host)
+		if i%2 == 0 {
+			subnetA = append(subnetA, entry.PublicKey)
+		}
+		if err := tree.Insert(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p, err := NewPlacement(modules.PlacementPolicy{MaxPiecesPerIPv4Subnet: 2}, resolver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a placement all hosts are selected.
+	if hosts := tree.SelectRandomWithPlacement(10, nil, nil, nil, nil); len(hosts) != 10 {
+		t.Fatal("expected 10 hosts but got", len(hosts))
+	}
+	// With a placement only 2 hosts per subnet are selected.
+	hosts := tree.SelectRandomWithPlacement(10, nil, nil, nil, p)
+	if len(hosts) != 4 {
+		t.Fatal("expected 4 hosts but got", len(hosts))
+	}
+	// Hosts of the placementBlacklist count towards the limits.
+	hosts = tree.SelectRandomWithPlacement(10, subnetA[:1], nil, subnetA[:1], p)
+	if len(hosts) != 3 {
+		t.Fatal("expected 3 hosts but got", len(hosts))
+	}
+	hosts = tree.SelectRandomWithPlacement(10, subnetA[:2], nil, subnetA[:2], p)
+	if len(hosts) != 2 {
+		t.Fatal("expected 2 hosts but got", len(hosts))
+	}
+	for _, host := range hosts {
+		if resolver[host.NetAddress.Host()][0].To4()[1] != 1 {
+			t.Fatal("host of full subnet was selected", host.NetAddress)
+		}
+	}
+}
@@ -28,6 +28,9 @@ type HostAdjustments struct {
 	StorageRemainingAdjustment float64
 	UptimeAdjustment           float64
 	VersionAdjustment          float64
+
+	ScoringPolicyAdjustment float64
+	ScoringPolicyViolations []string
 }
 
 var (
@@ -80,6 +83,9 @@ func (h HostAdjustments) HostScoreBreakdown(totalScore types.Currency, ignoreAge
 		StorageRemainingAdjustment: h.StorageRemainingAdjustment,
 		UptimeAdjustment:           h.UptimeAdjustment,
 		VersionAdjustment:          h.VersionAdjustment,
+
+		ScoringPolicyAdjustment: h.ScoringPolicyAdjustment,
+		ScoringPolicyViolations: h.ScoringPolicyViolations,
 	}
 }
 
@@ -97,7 +103,8 @@ func (h HostAdjustments) Score() types.Currency {
 		h.PriceAdjustment *
 		h.StorageRemainingAdjustment *
 		h.UptimeAdjustment *
-		h.VersionAdjustment
+		h.VersionAdjustment *
+		h.ScoringPolicyAdjustment
 
 	// Return a types.Currency.
 	weight := baseWeight.MulFloat(fullPenalty)
@@ -57,6 +57,11 @@ const (
 	// the bad points do not rack up very quickly.
 	interactionExponentiation = 10
 
+	// auditFailureWeight determines how many failed interactions a failed
+	// audit counts as. Failed audits are strong evidence that the host lost
+	// data, which is a lot worse than a failed RPC.
+	auditFailureWeight = 10
+
 	// priceExponentiationLarge is the number of times that the weight is
 	// divided by the price when the price is large relative to the allowance.
 	// The exponentiation is a lot higher because we care greatly about high
@@ -217,14 +222,15 @@ func (hdb *HostDB) durationAdjustments(entry modules.HostDBEntry, allowance modu
 
 // interactionAdjustments determine the penalty to be applied to a host for the
 // historic and current interactions with that host. This function focuses on
-// historic interactions and ignores recent interactions.
+// historic interactions and ignores recent interactions. Audits of the data
+// stored on the host count as interactions as well.
 func (hdb *HostDB) interactionAdjustments(entry modules.HostDBEntry) float64 {
 	// Give the host a baseline of 30 successful interactions and 1 failed
 	// interaction. This gives the host a baseline if we've had few
 	// interactions with them. The 1 failed interaction will become
 	// irrelevant after sufficient interactions with the host.
-	hsi := entry.HistoricSuccessfulInteractions + 30
-	hfi := entry.HistoricFailedInteractions + 1
+	hsi := entry.HistoricSuccessfulInteractions + float64(entry.AuditSuccesses) + 30
+	hfi := entry.HistoricFailedInteractions + float64(entry.AuditFailures)*auditFailureWeight + 1
 
 	// Determine the intraction ratio based off of the historic interactions.
 	ratio := float64(hsi) / float64(hsi+hfi)
@@ -483,37 +489,7 @@ func (hdb *HostDB) uptimeAdjustments(entry modules.HostDBEntry) float64 {
 
 	// Compute the total measured uptime and total measured downtime for this
 	// host.
-	downtime := entry.HistoricDowntime
-	uptime := entry.HistoricUptime
-	recentTime := entry.ScanHistory[0].Timestamp
-	recentSuccess := entry.ScanHistory[0].Success
-	for _, scan := range entry.ScanHistory[1:] {
-		if recentTime.After(scan.Timestamp) {
-			if build.DEBUG {
-				hdb.staticLog.Critical("Host entry scan history not sorted.")
-			} else {
-				hdb.staticLog.Print("WARN: Host entry scan history not sorted.")
-			}
-			// Ignore the unsorted scan entry.
-			continue
-		}
-		if recentSuccess {
-			uptime += scan.Timestamp.Sub(recentTime)
-		} else {
-			downtime += scan.Timestamp.Sub(recentTime)
-		}
-		recentTime = scan.Timestamp
-		recentSuccess = scan.Success
-	}
-
-	// One more check to incorporate the uptime or downtime of the most recent
-	// scan, we assume that if we scanned them right now, their uptime /
-	// downtime status would be equal to what it currently is.
-	if recentSuccess {
-		uptime += time.Now().Sub(recentTime)
-	} else {
-		downtime += time.Now().Sub(recentTime)
-	}
+	uptime, downtime := hdb.uptimeDowntime(entry)
 
 	// Sanity check against 0 total time.
 	if uptime == 0 && downtime == 0 {
@@ -551,36 +527,138 @@ func (hdb *HostDB) uptimeAdjustments(entry modules.HostDBEntry) float64 {
 	return math.Pow(uptimeRatio, exp)
 }
 
+// uptimeDowntime computes the total measured uptime and downtime of a host
+// from its historic uptime and downtime and its scan history. The host is
+// assumed to still be in the state of its most recent scan.
+func (hdb *HostDB) uptimeDowntime(entry modules.HostDBEntry) (uptime, downtime time.Duration) {
+	downtime = entry.HistoricDowntime
+	uptime = entry.HistoricUptime
+	if len(entry.ScanHistory) == 0 {
+		return uptime, downtime
+	}
+	recentTime := entry.ScanHistory[0].Timestamp
+	recentSuccess := entry.ScanHistory[0].Success
+	for _, scan := range entry.ScanHistory[1:] {
+		if recentTime.After(scan.Timestamp) {
+			if build.DEBUG {
+				hdb.staticLog.Critical("Host entry scan history not sorted.")
+			} else {
+				hdb.staticLog.Print("WARN: Host entry scan history not sorted.")
+			}
+			// Ignore the unsorted scan entry.
+			continue
+		}
+		if recentSuccess {
+			uptime += scan.Timestamp.Sub(recentTime)
+		} else {
+			downtime += scan.Timestamp.Sub(recentTime)
+		}
+		recentTime = scan.Timestamp
+		recentSuccess = scan.Success
+	}
+
+	// One more check to incorporate the uptime or downtime of the most recent
+	// scan, we assume that if we scanned them right now, their uptime /
+	// downtime status would be equal to what it currently is.
+	if recentSuccess {
+		uptime += time.Now().Sub(recentTime)
+	} else {
+		downtime += time.Now().Sub(recentTime)
+	}
+	return uptime, downtime
+}
+
+// scoringPolicyAdjustments checks that the host meets the limits of the
+// scoring policy. Hosts which violate any of the limits receive the lowest
+// possible score. The violated limits are returned as well.
+func (hdb *HostDB) scoringPolicyAdjustments(entry modules.HostDBEntry, policy modules.HostScoringPolicy) (float64, []string) {
+	var violations []string
+	if policy.MinAge > 0 && (hdb.blockHeight < entry.FirstSeen || hdb.blockHeight-entry.FirstSeen < policy.MinAge) {
+		violations = append(violations, fmt.Sprintf("host is younger than %v blocks", policy.MinAge))
+	}
+	if !policy.MinCollateral.IsZero() && entry.Collateral.Cmp(policy.MinCollateral) < 0 {
+		violations = append(violations, "collateral is below the minimum")
+	}
+	if policy.MinRemainingStorage > 0 && entry.RemainingStorage < policy.MinRemainingStorage {
+		violations = append(violations, "remaining storage is below the minimum")
+	}
+	// Hosts need a few scans before their uptime is meaningful.
+	if policy.MinUptime > 0 && len(entry.ScanHistory) > 2 {
+		uptime, downtime := hdb.uptimeDowntime(entry)
+		if uptime+downtime > 0 && float64(uptime)/float64(uptime+downtime) < policy.MinUptime {
+			violations = append(violations, fmt.Sprintf("uptime is below %.2f%%", policy.MinUptime*100))
+		}
+	}
+	if policy.MinVersion != "" && build.VersionCmp(entry.Version, policy.MinVersion) < 0 {
+		violations = append(violations, fmt.Sprintf("version is older than %v", policy.MinVersion))
+	}
+	if !policy.MaxContractPrice.IsZero() && entry.ContractPrice.Cmp(policy.MaxContractPrice) > 0 {
+		violations = append(violations, "contract price exceeds the maximum")
+	}
+	if !policy.MaxDownloadBandwidthPrice.IsZero() && entry.DownloadBandwidthPrice.Cmp(policy.MaxDownloadBandwidthPrice) > 0 {
+		violations = append(violations, "download price exceeds the maximum")
+	}
+	if !policy.MaxStoragePrice.IsZero() && entry.StoragePrice.Cmp(policy.MaxStoragePrice) > 0 {
+		violations = append(violations, "storage price exceeds the maximum")
+	}
+	if !policy.MaxUploadBandwidthPrice.IsZero() && entry.UploadBandwidthPrice.Cmp(policy.MaxUploadBandwidthPrice) > 0 {
+		violations = append(violations, "upload price exceeds the maximum")
+	}
+	if len(violations) > 0 {
+		return math.SmallestNonzeroFloat64, violations
+	}
+	return 1, nil
+}
+
 // managedCalculateHostWeightFn creates a hosttree.WeightFunc given an
 // Allowance.
 //
 // NOTE: the hosttree.WeightFunc that is returned accesses fields of the hostdb.
 // The hostdb lock must be held while utilizing the WeightFunc
 func (hdb *HostDB) managedCalculateHostWeightFn(allowance modules.Allowance) hosttree.WeightFunc {
-	// Get the txnFees.
+	// Get the txnFees and the scoring policy.
 	hdb.mu.RLock()
 	txnFees := hdb.txnFees
+	policy := hdb.scoringPolicy
 	hdb.mu.RUnlock()
-	// Create the weight function.
+	return hdb.calculateHostWeightFn(allowance, txnFees, policy)
+}
+
+// calculateHostWeightFn creates a hosttree.WeightFunc given an Allowance, the
+// txnFees and a scoring policy. Every adjustment which can be weighted by the
+// policy is raised to the power of its weight.
+//
+// NOTE: the hosttree.WeightFunc that is returned accesses fields of the hostdb.
+// The hostdb lock must be held while utilizing the WeightFunc
+func (hdb *HostDB) calculateHostWeightFn(allowance modules.Allowance, txnFees types.Currency, policy modules.HostScoringPolicy) hosttree.WeightFunc {
+	weighted := func(t modules.HostScoreAdjustmentType, adjustment float64) float64 {
+		return math.Pow(adjustment, policy.Weight(t))
+	}
 	return func(entry modules.HostDBEntry) hosttree.ScoreBreakdown {
+		policyAdjustment, violations := hdb.scoringPolicyAdjustments(entry, policy)
 		return hosttree.HostAdjustments{
 			AcceptContractAdjustment:   hdb.acceptContractAdjustments(entry),
-			AgeAdjustment:              hdb.lifetimeAdjustments(entry),
+			AgeAdjustment:              weighted(modules.HostScoreAge, hdb.lifetimeAdjustments(entry)),
 			BasePriceAdjustment:        hdb.basePriceAdjustments(entry),
 			BurnAdjustment:             1,
-			CollateralAdjustment:       hdb.collateralAdjustments(entry, allowance),
+			CollateralAdjustment:       weighted(modules.HostScoreCollateral, hdb.collateralAdjustments(entry, allowance)),
 			DurationAdjustment:         hdb.durationAdjustments(entry, allowance),
-			InteractionAdjustment:      hdb.interactionAdjustments(entry),
-			PriceAdjustment:            hdb.priceAdjustments(entry, allowance, txnFees),
-			StorageRemainingAdjustment: hdb.storageRemainingAdjustments(entry, allowance),
-			UptimeAdjustment:           hdb.uptimeAdjustments(entry),
-			VersionAdjustment:          versionAdjustments(entry),
+			InteractionAdjustment:      weighted(modules.HostScoreInteractions, hdb.interactionAdjustments(entry)),
+			PriceAdjustment:            weighted(modules.HostScorePrice, hdb.priceAdjustments(entry, allowance, txnFees)),
+			StorageRemainingAdjustment: weighted(modules.HostScoreStorageRemaining, hdb.storageRemainingAdjustments(entry, allowance)),
+			UptimeAdjustment:           weighted(modules.HostScoreUptime, hdb.uptimeAdjustments(entry)),
+			VersionAdjustment:          weighted(modules.HostScoreVersion, versionAdjustments(entry)),
+
+			ScoringPolicyAdjustment: policyAdjustment,
+			ScoringPolicyViolations: violations,
 		}
 	}
 }
 
 // EstimateHostScore takes a HostExternalSettings and returns the estimated
 // score of that host in the hostdb, assuming no penalties for age or uptime.
+// The scoring policy of the hostdb is applied, apart from its age and uptime
+// limits.
 func (hdb *HostDB) EstimateHostScore(entry modules.HostDBEntry, allowance modules.Allowance) (modules.HostScoreBreakdown, error) {
 	if err := hdb.tg.Add(); err != nil {
 		return modules.HostScoreBreakdown{}, err
@@ -599,6 +677,59 @@ func (hdb *HostDB) ScoreBreakdown(entry modules.HostDBEntry) (modules.HostScoreB
 	return hdb.managedScoreBreakdown(entry, false, false, false)
 }
 
+// ScoreBreakdownWithPolicy returns the score breakdown the host would have if
+// the provided scoring policy was set. The conversion rate is computed as if
+// all hosts were scored with the provided policy.
+func (hdb *HostDB) ScoreBreakdownWithPolicy(entry modules.HostDBEntry, policy modules.HostScoringPolicy) (modules.HostScoreBreakdown, error) {
+	if err := hdb.tg.Add(); err != nil {
+		return modules.HostScoreBreakdown{}, err
+	}
+	defer hdb.tg.Done()
+	if err := policy.Validate(); err != nil {
+		return modules.HostScoreBreakdown{}, errors.AddContext(err, "invalid scoring policy")
+	}
+	hosts, err := hdb.ActiveHosts()
+	if err != nil {
+		return modules.HostScoreBreakdown{}, errors.AddContext(err, "error getting Active hosts:")
+	}
+
+	// Compute the totalScore.
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+	weightFunc := hdb.calculateHostWeightFn(hdb.allowance, hdb.txnFees, policy)
+	totalScore := types.Currency{}
+	for _, host := range hosts {
+		totalScore = totalScore.Add(weightFunc(host).Score())
+	}
+	// Compute the breakdown.
+	return weightFunc(entry).HostScoreBreakdown(totalScore, false, false, false), nil
+}
+
+// ScoreBreakdownWithAllowance returns the score breakdown the host would have
+// if the provided allowance was set. The conversion rate is computed as if all
+// hosts were scored with the provided allowance.
+func (hdb *HostDB) ScoreBreakdownWithAllowance(entry modules.HostDBEntry, allowance modules.Allowance) (modules.HostScoreBreakdown, error) {
+	if err := hdb.tg.Add(); err != nil {
+		return modules.HostScoreBreakdown{}, err
+	}
+	defer hdb.tg.Done()
+	hosts, err := hdb.ActiveHosts()
+	if err != nil {
+		return modules.HostScoreBreakdown{}, errors.AddContext(err, "error getting Active hosts:")
+	}
+
+	// Compute the totalScore.
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+	weightFunc := hdb.calculateHostWeightFn(allowance, hdb.txnFees, hdb.scoringPolicy)
+	totalScore := types.Currency{}
+	for _, host := range hosts {
+		totalScore = totalScore.Add(weightFunc(host).Score())
+	}
+	// Compute the breakdown.
+	return weightFunc(entry).HostScoreBreakdown(totalScore, false, false, false), nil
+}
+
 // managedEstimatedScoreBreakdown computes the score breakdown of a host.
 // Certain adjustments can be ignored.
 func (hdb *HostDB) managedEstimatedScoreBreakdown(entry modules.HostDBEntry, allowance modules.Allowance, ignoreAge, ignoreDuration, ignoreUptime bool) (modules.HostScoreBreakdown, error) {
@@ -606,11 +737,21 @@ func (hdb *HostDB) managedEstimatedScoreBreakdown(entry modules.HostDBEntry, all
 	if err != nil {
 		return modules.HostScoreBreakdown{}, errors.AddContext(err, "error getting Active hosts:")
 	}
-	weightFunc := hdb.managedCalculateHostWeightFn(allowance)
-
 	// Compute the totalScore.
 	hdb.mu.Lock()
 	defer hdb.mu.Unlock()
+
+	// Ignore the limits of the scoring policy that correspond to the ignored
+	// adjustments.
+	policy := hdb.scoringPolicy
+	if ignoreAge {
+		policy.MinAge = 0
+	}
+	if ignoreUptime {
+		policy.MinUptime = 0
+	}
+	weightFunc := hdb.calculateHostWeightFn(allowance, hdb.txnFees, policy)
+
 	totalScore := types.Currency{}
 	for _, host := range hosts {
 		totalScore = totalScore.Add(hdb.weightFunc(host).Score())
@@ -2,6 +2,7 @@ package hostdb
 
 import (
 	"math"
+	"math/big"
 	"testing"
 	"time"
 
@@ -626,6 +627,138 @@ func TestHostWeightUptimeDifferences4(t *testing.T) {
 	}
 }
 
+// TestHostWeightAuditFailures checks that hosts which failed integrity audits
+// have lower weights and that a failed audit weighs more than a failed
+// interaction.
+func TestHostWeightAuditFailures(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	hdb := bareHostDB()
+
+	entry := DefaultHostDBEntry
+	entry.HistoricSuccessfulInteractions = 100
+	entry2 := entry
+	entry2.AuditFailures = 1
+	entry3 := entry
+	entry3.HistoricFailedInteractions = 1
+
+	w1 := hdb.weightFunc(entry).Score()
+	w2 := hdb.weightFunc(entry2).Score()
+	w3 := hdb.weightFunc(entry3).Score()
+	if w1.Cmp(w2) <= 0 {
+		t.Log(w1)
+		t.Log(w2)
+		t.Error("A host with a failed audit should have a lower score")
+	}
+	if w3.Cmp(w2) <= 0 {
+		t.Log(w2)
+		t.Log(w3)
+		t.Error("A failed audit should be penalized more than a failed interaction")
+	}
+}
+
+// TestHostWeightScoringPolicyWeights checks that the weights of a scoring
+// policy change the impact of the adjustments on the score.
+func TestHostWeightScoringPolicyWeights(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	hdb := bareHostDB()
+	err := hdb.SetAllowance(DefaultTestAllowance)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry := DefaultHostDBEntry
+	entry2 := DefaultHostDBEntry
+	entry2.StoragePrice = entry.StoragePrice.Mul64(2)
+
+	// By default the cheaper host has the higher score.
+	defaultFn := hdb.calculateHostWeightFn(hdb.allowance, hdb.txnFees, modules.HostScoringPolicy{})
+	w1 := defaultFn(entry).Score()
+	w2 := defaultFn(entry2).Score()
+	if w1.Cmp(w2) <= 0 {
+		t.Fatal("cheaper host should have a higher score", w1, w2)
+	}
+
+	// A weight of 1 doesn't change the score.
+	policy := modules.HostScoringPolicy{
+		Weights: map[modules.HostScoreAdjustmentType]float64{
+			modules.HostScorePrice: 1,
+		},
+	}
+	if hdb.calculateHostWeightFn(hdb.allowance, hdb.txnFees, policy)(entry).Score().Cmp(w1) != 0 {
+		t.Error("a weight of 1 shouldn't change the score")
+	}
+
+	// A weight of 0 disables the price adjustment.
+	policy.Weights[modules.HostScorePrice] = 0
+	fn := hdb.calculateHostWeightFn(hdb.allowance, hdb.txnFees, policy)
+	if fn(entry).Score().Cmp(fn(entry2).Score()) != 0 {
+		t.Error("hosts should have the same score if the price is ignored")
+	}
+
+	// A greater weight increases the difference between the hosts.
+	policy.Weights[modules.HostScorePrice] = 2
+	fn = hdb.calculateHostWeightFn(hdb.allowance, hdb.txnFees, policy)
+	defaultRatio := new(big.Rat).SetFrac(w1.Big(), w2.Big())
+	weightedRatio := new(big.Rat).SetFrac(fn(entry).Score().Big(), fn(entry2).Score().Big())
+	if weightedRatio.Cmp(defaultRatio) <= 0 {
+		t.Error("a greater weight should increase the impact of the price", defaultRatio, weightedRatio)
+	}
+}
+
+// TestHostWeightScoringPolicyLimits checks that hosts which violate the limits
+// of a scoring policy receive the lowest score.
+func TestHostWeightScoringPolicyLimits(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	hdb := bareHostDB()
+	err := hdb.SetAllowance(DefaultTestAllowance)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry := DefaultHostDBEntry
+	tests := []struct {
+		name   string
+		policy modules.HostScoringPolicy
+	}{
+		{"collateral", modules.HostScoringPolicy{MinCollateral: entry.Collateral.Mul64(2)}},
+		{"storage", modules.HostScoringPolicy{MinRemainingStorage: entry.RemainingStorage + 1}},
+		{"version", modules.HostScoringPolicy{MinVersion: "999.0.0"}},
+		{"contract price", modules.HostScoringPolicy{MaxContractPrice: entry.ContractPrice.Div64(2)}},
+		{"storage price", modules.HostScoringPolicy{MaxStoragePrice: entry.StoragePrice.Div64(2)}},
+	}
+	w := hdb.calculateHostWeightFn(hdb.allowance, hdb.txnFees, modules.HostScoringPolicy{})(entry)
+	for _, test := range tests {
+		fn := hdb.calculateHostWeightFn(hdb.allowance, hdb.txnFees, test.policy)
+		sb := fn(entry).HostScoreBreakdown(types.NewCurrency64(1), false, false, false)
+		if len(sb.ScoringPolicyViolations) != 1 {
+			t.Errorf("%v: expected 1 violation but got %v", test.name, sb.ScoringPolicyViolations)
+		}
+		if fn(entry).Score().Cmp(w.Score()) >= 0 {
+			t.Errorf("%v: host violating the policy should have a lower score", test.name)
+		}
+	}
+
+	// Hosts which meet the limits aren't affected.
+	policy := modules.HostScoringPolicy{
+		MinCollateral:   entry.Collateral,
+		MinVersion:      entry.Version,
+		MaxStoragePrice: entry.StoragePrice,
+	}
+	fn := hdb.calculateHostWeightFn(hdb.allowance, hdb.txnFees, policy)
+	if fn(entry).Score().Cmp(w.Score()) != 0 {
+		t.Error("host meeting the limits should have the same score")
+	}
+}
+
 // TestHostWeightConstants checks a few relationships between the constants in
 // the hostdb.
 func TestHostWeightConstants(t *testing.T) {
@@ -32,6 +32,8 @@ type hdbPersist struct {
 	LastChange               modules.ConsensusChangeID
 	FilteredHosts            map[string]types.TurtleDexPublicKey
 	FilterMode               modules.FilterMode
+	PlacementPolicy          modules.PlacementPolicy
+	ScoringPolicy            modules.HostScoringPolicy
 }
 
 // persistData returns the data in the hostdb that will be saved to disk.
@@ -43,6 +45,8 @@ func (hdb *HostDB) persistData() (data hdbPersist) {
 	data.LastChange = hdb.lastChange
 	data.FilteredHosts = hdb.filteredHosts
 	data.FilterMode = hdb.filterMode
+	data.PlacementPolicy = hdb.placementPolicy
+	data.ScoringPolicy = hdb.scoringPolicy
 	return data
 }
 
@@ -69,6 +73,32 @@ func (hdb *HostDB) load() error {
 	hdb.filteredHosts = data.FilteredHosts
 	hdb.filterMode = data.FilterMode
 
+	// Load the placement. If the files of the policy can't be loaded we fall
+	// back to the limits which don't depend on them to avoid failing the
+	// startup of the hostdb. The policy in effect is reported and persisted
+	// from then on.
+	placement, err := hosttree.NewPlacement(data.PlacementPolicy, hdb.staticDeps.Resolver())
+	if err != nil {
+		hdb.staticLog.Println("WARN: unable to load placement policy, ignoring ASN and host group limits:", err)
+		fallback := data.PlacementPolicy
+		fallback.MaxPiecesPerASN = 0
+		fallback.MaxPiecesPerHostGroup = 0
+		placement, err = hosttree.NewPlacement(fallback, hdb.staticDeps.Resolver())
+		if err != nil {
+			return err
+		}
+	}
+	hdb.placement = placement
+	hdb.placementPolicy = placement.Policy()
+
+	// Update the weight function to apply the scoring policy. The host tree
+	// is still empty at this point.
+	hdb.scoringPolicy = data.ScoringPolicy
+	hdb.weightFunc = hdb.calculateHostWeightFn(hdb.allowance, hdb.txnFees, hdb.scoringPolicy)
+	if err := hdb.staticHostTree.SetWeightFunction(hdb.weightFunc); err != nil {
+		return err
+	}
+
 	if len(hdb.filteredHosts) > 0 {
 		hdb.staticFilteredTree = hosttree.New(hdb.weightFunc, modules.ProdDependencies.Resolver())
 	}
@@ -117,6 +147,10 @@ func (hdb *HostDB) threadedSaveLoop() {
 			if err != nil {
 				hdb.staticLog.Println("Difficulties saving the hostdb:", err)
 			}
+			err = hdb.managedSaveHistory()
+			if err != nil {
+				hdb.staticLog.Println("Difficulties saving the host history:", err)
+			}
 		}
 	}
 }
@@ -2,6 +2,8 @@ package hostdb
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 	"path/filepath"
 	"testing"
 	"time"
@@ -144,6 +146,134 @@ func TestSaveLoad(t *testing.T) {
 	}
 }
 
+// TestSaveLoadPlacementPolicy tests that the placement policy is persisted and
+// that a policy with missing files is still loaded.
+func TestSaveLoadPlacementPolicy(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	hdbt, err := newHDBTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Set a policy which limits the pieces per host group.
+	groupsFile := filepath.Join(hdbt.persistDir, "groups")
+	if err := ioutil.WriteFile(groupsFile, []byte("dc1 10.0.0.0/8\n"), modules.DefaultFilePerm); err != nil {
+		t.Fatal(err)
+	}
+	policy := modules.PlacementPolicy{
+		MaxPiecesPerIPv4Subnet: 2,
+		MaxPiecesPerHostGroup:  3,
+		HostGroupsFile:         groupsFile,
+	}
+	if err := hdbt.hdb.SetPlacementPolicy(policy); err != nil {
+		t.Fatal(err)
+	}
+	// Policies with missing files are rejected.
+	invalidPolicy := policy
+	invalidPolicy.HostGroupsFile = filepath.Join(hdbt.persistDir, "missing")
+	if err := hdbt.hdb.SetPlacementPolicy(invalidPolicy); err == nil {
+		t.Fatal("policy with missing file should be rejected")
+	}
+	// Policies with limits above the parity pieces are rejected.
+	invalidPolicy = policy
+	invalidPolicy.MaxPiecesPerIPv4Subnet = uint64(modules.RenterDefaultParityPieces) + 1
+	if err := hdbt.hdb.SetPlacementPolicy(invalidPolicy); err == nil {
+		t.Fatal("policy with too high limit should be rejected")
+	}
+
+	// Remove the file and reload the hostdb. The policy should be loaded
+	// without the host group limit since it can't be enforced.
+	if err := os.Remove(groupsFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := hdbt.hdb.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var errChan <-chan error
+	hdbt.hdb, errChan = NewCustomHostDB(hdbt.gateway, hdbt.cs, hdbt.tpool, hdbt.mux, filepath.Join(hdbt.persistDir, modules.RenterDir), &quitAfterLoadDeps{})
+	if err := <-errChan; err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := hdbt.hdb.PlacementPolicy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := policy
+	expected.MaxPiecesPerHostGroup = 0
+	if loaded != expected {
+		t.Fatalf("expected %v but got %v", expected, loaded)
+	}
+	hdbt.hdb.mu.RLock()
+	effective := hdbt.hdb.placement.Policy()
+	hdbt.hdb.mu.RUnlock()
+	if effective != loaded {
+		t.Fatal("wrong effective policy", effective)
+	}
+}
+
+// TestSaveLoadScoringPolicy tests that the scoring policy is persisted and
+// applied to the weight function after loading the hostdb.
+func TestSaveLoadScoringPolicy(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	hdbt, err := newHDBTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Set a policy which ignores prices and limits the storage price.
+	policy := modules.HostScoringPolicy{
+		Weights: map[modules.HostScoreAdjustmentType]float64{
+			modules.HostScorePrice: 0,
+		},
+		MaxStoragePrice: DefaultHostDBEntry.StoragePrice,
+	}
+	if err := hdbt.hdb.SetScoringPolicy(policy); err != nil {
+		t.Fatal(err)
+	}
+	// Invalid policies are rejected.
+	invalidPolicy := policy.Copy()
+	invalidPolicy.Weights[modules.HostScoreUptime] = -1
+	if err := hdbt.hdb.SetScoringPolicy(invalidPolicy); err == nil {
+		t.Fatal("policy with negative weight should be rejected")
+	}
+
+	// Reload the hostdb.
+	if err := hdbt.hdb.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var errChan <-chan error
+	hdbt.hdb, errChan = NewCustomHostDB(hdbt.gateway, hdbt.cs, hdbt.tpool, hdbt.mux, filepath.Join(hdbt.persistDir, modules.RenterDir), &quitAfterLoadDeps{})
+	if err := <-errChan; err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := hdbt.hdb.ScoringPolicy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Equals(policy) {
+		t.Fatalf("expected %v but got %v", policy, loaded)
+	}
+
+	// The weight function should use the loaded policy.
+	entry := DefaultHostDBEntry
+	entry.StoragePrice = entry.StoragePrice.Mul64(2)
+	hdbt.hdb.mu.RLock()
+	sb := hdbt.hdb.weightFunc(entry).HostScoreBreakdown(types.NewCurrency64(1), false, false, false)
+	hdbt.hdb.mu.RUnlock()
+	if sb.PriceAdjustment != 1 {
+		t.Error("price adjustment should be ignored", sb.PriceAdjustment)
+	}
+	if len(sb.ScoringPolicyViolations) != 1 {
+		t.Error("expected a violation of the max storage price", sb.ScoringPolicyViolations)
+	}
+}
+
 // TestRescan tests that the hostdb will rescan the blockchain properly, picking
 // up new hosts which appear in an alternate past.
 func TestRescan(t *testing.T) {
@@ -11,19 +11,21 @@ import (
 // RandomHosts implements the HostDB interface's RandomHosts() method. It takes
 // a number of hosts to return, and a slice of netaddresses to ignore, and
 // returns a slice of entries. If the IP violation check was disabled, the
-// addressBlacklist is ignored.
+// addressBlacklist is ignored by the IP violation check. The hosts of the
+// addressBlacklist always count towards the limits of the placement policy.
 func (hdb *HostDB) RandomHosts(n int, blacklist, addressBlacklist []types.TurtleDexPublicKey) ([]modules.HostDBEntry, error) {
 	hdb.mu.RLock()
 	initialScanComplete := hdb.initialScanComplete
 	ipCheckDisabled := hdb.disableIPViolationCheck
+	placement := hdb.placement
 	hdb.mu.RUnlock()
 	if !initialScanComplete {
 		return []modules.HostDBEntry{}, ErrInitialScanIncomplete
 	}
 	if ipCheckDisabled {
-		return hdb.staticFilteredTree.SelectRandom(n, blacklist, nil), nil
+		return hdb.staticFilteredTree.SelectRandomWithPlacement(n, blacklist, nil, addressBlacklist, placement), nil
 	}
-	return hdb.staticFilteredTree.SelectRandom(n, blacklist, addressBlacklist), nil
+	return hdb.staticFilteredTree.SelectRandomWithPlacement(n, blacklist, addressBlacklist, addressBlacklist, placement), nil
 }
 
 // RandomHostsWithAllowance works as RandomHosts but uses a temporary hosttree
@@ -34,6 +36,7 @@ func (hdb *HostDB) RandomHostsWithAllowance(n int, blacklist, addressBlacklist [
 	initialScanComplete := hdb.initialScanComplete
 	filteredHosts := hdb.filteredHosts
 	filterType := hdb.filterMode
+	placement := hdb.placement
 	hdb.mu.RUnlock()
 	if !initialScanComplete && !hdb.staticDeps.Disrupt("InitialScanComplete") {
 		return []modules.HostDBEntry{}, ErrInitialScanIncomplete
@@ -59,5 +62,5 @@ func (hdb *HostDB) RandomHostsWithAllowance(n int, blacklist, addressBlacklist [
 	}
 
 	// Select hosts from the temporary hosttree.
-	return ht.SelectRandom(n, blacklist, addressBlacklist), insertErrs
+	return ht.SelectRandomWithPlacement(n, blacklist, addressBlacklist, addressBlacklist, placement), insertErrs
 }
@@ -394,6 +394,7 @@ func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 	hdb.mu.Unlock()
 
 	var settings modules.HostExternalSettings
+	var pt *modules.RPCPriceTable
 	var latency time.Duration
 	err = func() error {
 		timeout := hostRequestTimeout
@@ -475,7 +476,7 @@ func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 
 		// Try opening a connection to the siamux, this is a very lightweight
 		// way of checking that RHP3 is supported.
-		_, err = fetchPriceTable(hdb.staticMux, siamuxAddr, timeout, modules.TurtleDexPKToMuxPK(entry.PublicKey))
+		pt, err = fetchPriceTable(hdb.staticMux, siamuxAddr, timeout, modules.TurtleDexPKToMuxPK(entry.PublicKey))
 		if err != nil {
 			hdb.staticLog.Debugf("%v siamux ping not successful: %v\n", entry.PublicKey, err)
 			return err
@@ -502,6 +503,26 @@ func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 	// delete the entry from the scan map as the scan has been successful.
 	hdb.updateEntry(entry, err)
 
+	// Add the scan to the history of the host unless it was discarded or the
+	// host was removed.
+	updatedEntry, exists := hdb.staticHostTree.Select(entry.PublicKey)
+	if exists && (success || hdb.gateway.Online()) {
+		scan := modules.HostHistoryScan{
+			Timestamp: time.Now(),
+			Success:   success,
+			Latency:   latency,
+			Score:     hdb.weightFunc(updatedEntry).Score(),
+		}
+		var prices *modules.HostHistoryPrices
+		if success {
+			p := modules.NewHostHistoryPrices(scan.Timestamp, settings, pt)
+			prices = &p
+		} else {
+			scan.Error = err.Error()
+		}
+		hdb.staticHistory.managedRecordScan(entry.PublicKey, scan, prices)
+	}
+
 	// Add the scan to the initialScanLatencies if it was successful.
 	if success && len(hdb.initialScanLatencies) < minScansForSpeedup {
 		hdb.initialScanLatencies = append(hdb.initialScanLatencies, latency)
@@ -0,0 +1,154 @@
+package renter
+
+// migration.go contains the logic for moving the pieces stored on hosts the
+// contractor's rebalancer migrates data away from.
+//
+// The rebalancer marks the contracts with degraded or overpriced hosts as
+// !GoodForRenew, so the pieces stored on these hosts no longer count towards
+// the health of their chunks. Usually only a few pieces of every chunk are
+// affected, so the chunks don't drop below the RepairThreshold and wouldn't be
+// picked up by the directory heap. Instead, the repair loop periodically scans
+// the files for chunks with pieces which are only stored on migrating hosts
+// and adds them to the upload heap.
+
+import (
+	"sync"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem/siafile"
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+// chunkNeedsMigration returns whether a chunk has a piece which is stored on a
+// migrating host but not on any other host that counts towards the chunk's
+// redundancy.
+func chunkNeedsMigration(pieces [][]siafile.Piece, migrating map[string]struct{}, offline, goodForRenew map[string]bool) bool {
+	for _, pieceSet := range pieces {
+		var counted, onMigratingHost bool
+		for _, piece := range pieceSet {
+			hpk := piece.HostPubKey.String()
+			if _, exists := migrating[hpk]; exists {
+				onMigratingHost = true
+				continue
+			}
+			gfr, exists := goodForRenew[hpk]
+			isOffline, exists2 := offline[hpk]
+			if exists && gfr && exists2 && !isOffline {
+				counted = true
+			}
+		}
+		if onMigratingHost && !counted {
+			return true
+		}
+	}
+	return false
+}
+
+// managedMigratingHosts returns the hosts the contractor migrates data away
+// from.
+func (r *Renter) managedMigratingHosts() map[string]struct{} {
+	migrating := make(map[string]struct{})
+	for _, migration := range r.hostContractor.RebalanceStatus().Migrations {
+		migrating[migration.HostPublicKey.String()] = struct{}{}
+	}
+	return migrating
+}
+
+// managedAddMigrationChunksToHeap adds the chunks which have pieces that need
+// to be migrated to the upload heap until the heap is full. Files which don't
+// fit into the heap are picked up by the next scan.
+func (r *Renter) managedAddMigrationChunksToHeap(hosts map[string]struct{}, offline, goodForRenew map[string]bool) error {
+	migrating := r.managedMigratingHosts()
+	if len(migrating) == 0 {
+		return nil
+	}
+
+	// Collect the files which can be repaired.
+	var files []modules.TurtleDexPath
+	var mu sync.Mutex
+	flf := func(fi modules.FileInfo) {
+		if !fi.Recoverable && !fi.OnDisk {
+			return
+		}
+		mu.Lock()
+		files = append(files, fi.TurtleDexPath)
+		mu.Unlock()
+	}
+	err := r.staticFileSystem.CachedList(modules.RootTurtleDexPath(), true, flf, func(modules.DirectoryInfo) {})
+	if err != nil {
+		return errors.AddContext(err, "unable to list files")
+	}
+
+	var added int
+	for _, siaPath := range files {
+		select {
+		case <-r.tg.StopChan():
+			return errors.New("renter shutdown before all migration chunks were added to the heap")
+		default:
+		}
+		if r.uploadHeap.managedLen() >= maxUploadHeapChunks {
+			break
+		}
+		node, err := r.staticFileSystem.OpenTurtleDexFile(siaPath)
+		if errors.Contains(err, filesystem.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return errors.AddContext(err, "unable to open file")
+		}
+		added += r.managedPushMigrationChunks(node, hosts, migrating, offline, goodForRenew)
+		if err := node.Close(); err != nil {
+			return errors.AddContext(err, "unable to close file")
+		}
+	}
+	if added > 0 {
+		r.repairLog.Printf("Added %v chunks with pieces on migrating hosts to the repair heap", added)
+	}
+	return nil
+}
+
+// managedPushMigrationChunks pushes the unstuck chunks of a file which have
+// pieces that need to be migrated onto the upload heap. It returns the number
+// of chunks which were pushed.
+func (r *Renter) managedPushMigrationChunks(node *filesystem.FileNode, hosts, migrating map[string]struct{}, offline, goodForRenew map[string]bool) int {
+	pks := make(map[string]types.TurtleDexPublicKey)
+	for _, pk := range node.HostPublicKeys() {
+		pks[string(pk.Key)] = pk
+	}
+	var pushed int
+	for index := uint64(0); index < node.NumChunks() && r.uploadHeap.managedLen() < maxUploadHeapChunks; index++ {
+		// Stuck chunks are handled by the stuck loop.
+		stuck, err := node.StuckChunkByIndex(index)
+		if err != nil || stuck {
+			continue
+		}
+		pieces, err := node.Pieces(index)
+		if err != nil || !chunkNeedsMigration(pieces, migrating, offline, goodForRenew) {
+			continue
+		}
+		chunk, err := r.managedBuildUnfinishedChunk(node, index, hosts, pks, memoryPriorityLow, offline, goodForRenew, r.repairMemoryManager)
+		if err != nil {
+			r.repairLog.Debugln("Error when building a migration chunk:", err)
+			continue
+		}
+		// Only push the chunk if its data can be fetched.
+		var added bool
+		if chunk.health <= 1 || chunk.onDisk {
+			added, err = r.managedPushChunkForRepair(chunk, chunkTypeLocalChunk)
+			if err != nil {
+				r.repairLog.Println("WARN: Error pushing migration chunk for repair", err)
+			}
+		}
+		if !added {
+			if err := chunk.fileEntry.Close(); err != nil {
+				r.repairLog.Println("Error closing file entry:", err)
+			}
+			continue
+		}
+		pushed++
+	}
+	return pushed
+}
@@ -0,0 +1,48 @@
+package renter
+
+import (
+	"testing"
+
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem/siafile"
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+// TestChunkNeedsMigration is a unit test for chunkNeedsMigration.
+func TestChunkNeedsMigration(t *testing.T) {
+	pk := func(b byte) types.TurtleDexPublicKey {
+		return types.TurtleDexPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{b}}
+	}
+	migratingHost, goodHost, badHost := pk(1), pk(2), pk(3)
+	migrating := map[string]struct{}{migratingHost.String(): {}}
+	offline := map[string]bool{
+		migratingHost.String(): false,
+		goodHost.String():      false,
+		badHost.String():       false,
+	}
+	goodForRenew := map[string]bool{
+		migratingHost.String(): false,
+		goodHost.String():      true,
+		badHost.String():       false,
+	}
+
+	tests := []struct {
+		pieces [][]siafile.Piece
+		needed bool
+	}{
+		// No pieces.
+		{[][]siafile.Piece{{}, {}}, false},
+		// No piece on a migrating host.
+		{[][]siafile.Piece{{{HostPubKey: goodHost}}, {{HostPubKey: badHost}}}, false},
+		// A piece only stored on a migrating host.
+		{[][]siafile.Piece{{{HostPubKey: goodHost}}, {{HostPubKey: migratingHost}}}, true},
+		// A piece stored on a migrating host and a bad host.
+		{[][]siafile.Piece{{{HostPubKey: migratingHost}, {HostPubKey: badHost}}}, true},
+		// A piece stored on a migrating host and a good host.
+		{[][]siafile.Piece{{{HostPubKey: migratingHost}, {HostPubKey: goodHost}}}, false},
+	}
+	for i, test := range tests {
+		if needed := chunkNeedsMigration(test.pieces, migrating, offline, goodForRenew); needed != test.needed {
+			t.Errorf("%v: expected %v but got %v", i, test.needed, needed)
+		}
+	}
+}
@@ -59,6 +59,7 @@ type (
 	persistence struct {
 		MaxDownloadSpeed int64
 		MaxUploadSpeed   int64
+		SectorCache      modules.SectorCacheSettings
 		UploadedBackups  []modules.UploadedBackup
 		SyncedContracts  []types.FileContractID
 	}
@@ -495,6 +495,55 @@ func (pcws *projectChunkWorkerSet) managedTryUpdateWorkerState() error {
 	return nil
 }
 
+// managedDownloadFromCache tries to download the data from the renter's
+// sector cache. It returns false if the cache doesn't contain enough pieces to
+// recover the data.
+func (pcws *projectChunkWorkerSet) managedDownloadFromCache(offset, length uint64) (chan *downloadResponse, bool) {
+	sc := pcws.staticRenter.staticSectorCache
+	if !sc.managedEnabled() {
+		return nil, false
+	}
+
+	// Fetch the pieces from the cache.
+	ec := pcws.staticErasureCoder
+	pieceOffset, pieceLength := getPieceOffsetAndLen(ec, offset, length)
+	dataPieces := make([][]byte, ec.NumPieces())
+	found := 0
+	for pieceIndex, root := range pcws.staticPieceRoots {
+		if found == ec.MinPieces() {
+			break
+		}
+		key := pcws.staticMasterKey.Derive(pcws.staticChunkIndex, uint64(pieceIndex))
+		data, cached := sc.managedGet(sectorCacheID(root, key), pieceOffset, pieceLength)
+		if !cached {
+			continue
+		}
+		dataPieces[pieceIndex] = data
+		found++
+	}
+	if found < ec.MinPieces() {
+		sc.managedRecordRead(false, 0)
+		return nil, false
+	}
+	sc.managedRecordRead(true, length)
+
+	// Recover the data using a pdc without any workers.
+	pdc := &projectDownloadChunk{
+		offsetInChunk: offset,
+		lengthInChunk: length,
+
+		pieceOffset: pieceOffset,
+		pieceLength: pieceLength,
+
+		dataPieces: dataPieces,
+
+		downloadResponseChan: make(chan *downloadResponse, 1),
+		workerSet:            pcws,
+	}
+	pdc.finalize()
+	return pdc.downloadResponseChan, true
+}
+
 // managedDownload will download a range from a chunk. This call is
 // asynchronous. It will return as soon as the initial sector download requests
 // have been sent to the workers. This means that it will block until enough
@@ -539,6 +588,11 @@ func (pcws *projectChunkWorkerSet) managedDownload(ctx context.Context, pricePer
 		return nil, errors.New("invalid request performed - this chunk has encryption overhead and therefore the full chunk must be downloaded")
 	}
 
+	// Serve the download from the sector cache if enough pieces are cached.
+	if respChan, cached := pcws.managedDownloadFromCache(offset, length); cached {
+		return respChan, nil
+	}
+
 	// Refresh the pcws. This will only cause a refresh if one is necessary.
 	err := pcws.managedTryUpdateWorkerState()
 	if err != nil {
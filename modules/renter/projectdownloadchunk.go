@@ -254,6 +254,9 @@ func (pdc *projectDownloadChunk) handleJobReadResponse(jrr *jobReadResponse) {
 		return
 	}
 
+	// Add the decrypted piece to the sector cache.
+	pdc.workerSet.staticRenter.callAddToSectorCache(pdc.workerSet.staticPieceRoots[pieceIndex], key, pdc.pieceOffset, jrr.staticData)
+
 	// The download succeeded, add the piece to the appropriate index.
 	pdc.dataPieces[pieceIndex] = jrr.staticData
 	jrr.staticData = nil // Just in case there's a reference to the job response elsewhere.
@@ -545,6 +545,75 @@ func (c *SafeContract) managedCommitAppend(t *unappliedWalTxn, signedTxn types.T
 	return nil
 }
 
+// managedRecordUpdateSectorIntent creates a WAL update that replaces the root
+// of the sector at index and queues this update for application.
+func (c *SafeContract) managedRecordUpdateSectorIntent(rev types.FileContractRevision, root crypto.Hash, index int) (*unappliedWalTxn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// construct new header
+	// NOTE: this header will not include the host signature
+	newHeader := c.header
+	newHeader.Transaction.FileContractRevisions = []types.FileContractRevision{rev}
+	newHeader.Transaction.TransactionSignatures = nil
+
+	t, err := c.newWalTxn([]writeaheadlog.Update{
+		c.makeUpdateSetHeader(newHeader),
+		c.makeUpdateSetRoot(root, index),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := <-t.SignalSetupComplete(); err != nil {
+		return nil, err
+	}
+	c.unappliedTxns = append(c.unappliedTxns, t)
+	return t, nil
+}
+
+// managedCommitUpdateSector ignores the header update in the given
+// transaction and instead applies the provided signedTxn. See
+// managedCommitAppend.
+func (c *SafeContract) managedCommitUpdateSector(t *unappliedWalTxn, signedTxn types.Transaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// construct new header
+	newHeader := c.header
+	newHeader.Transaction = signedTxn
+
+	if err := c.applySetHeader(newHeader); err != nil {
+		return err
+	}
+
+	// pluck the setRoot update from the WAL txn
+	for _, u := range t.Updates {
+		switch u.Name {
+		case updateNameSetHeader:
+			// do nothing - we already applied a new version of this update
+		case updateNameSetRoot:
+			var sru updateSetRoot
+			if err := encoding.Unmarshal(u.Instructions, &sru); err != nil {
+				return err
+			}
+			if err := c.applySetRoot(sru.Root, sru.Index); err != nil {
+				return err
+			}
+		default:
+			build.Critical("unexpected update", u.Name)
+		}
+	}
+
+	if err := c.staticHeaderFile.Sync(); err != nil {
+		return err
+	}
+	if err := t.SignalUpdatesApplied(); err != nil {
+		return err
+	}
+	if err := c.clearUnappliedTxns(); err != nil {
+		return errors.AddContext(err, "failed to clear unapplied txns")
+	}
+	return nil
+}
+
 // managedRecordDownloadIntent creates a WAL update that updates the header with
 // the new download costs.
 func (c *SafeContract) managedRecordDownloadIntent(rev types.FileContractRevision, bandwidthCost types.Currency) (*unappliedWalTxn, error) {
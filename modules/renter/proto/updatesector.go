@@ -0,0 +1,179 @@
+package proto
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/turtledex/encoding"
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+// UpdateSector takes an established stream to a host and overwrites the bytes
+// at offset within the sector at sectorIndex of the contract with data. The
+// caller is expected to have written the ExecuteProgram RPC specifier and the
+// price table's UID to the stream. The program is paid for by the payment
+// provider. After verifying the host's proof, the new revision is signed and
+// the contract is updated. The cost of the program is returned alongside the
+// updated contract, even if the update failed after paying for it.
+func (cs *ContractSet) UpdateSector(stream io.ReadWriter, fcid types.FileContractID, pt *modules.RPCPriceTable, pp modules.PaymentProvider, refundAccount modules.AccountID, sectorIndex, offset uint64, data []byte) (_ modules.RenterContract, cost types.Currency, err error) {
+	// Fetch the contract.
+	sc, ok := cs.Acquire(fcid)
+	if !ok {
+		return modules.RenterContract{}, types.ZeroCurrency, errors.New("UpdateSector: failed to acquire contract")
+	}
+	defer cs.Return(sc)
+	contract := sc.header // for convenience
+	rev := contract.LastRevision()
+
+	// Sanity-check the request.
+	numSectors := rev.NewFileSize / modules.SectorSize
+	if sectorIndex >= numSectors {
+		return modules.RenterContract{}, types.ZeroCurrency, fmt.Errorf("UpdateSector: sector index out-of-bounds: %v >= %v", sectorIndex, numSectors)
+	}
+
+	// Build the program. The duration doesn't matter since updating a sector
+	// doesn't increase the size of the contract.
+	pb := modules.NewProgramBuilder(pt, 0)
+	err = pb.AddUpdateSectorInstruction(sectorIndex, offset, data, true)
+	if err != nil {
+		return modules.RenterContract{}, types.ZeroCurrency, errors.AddContext(err, "UpdateSector: failed to build program")
+	}
+	program, programData := pb.Program()
+	programCost, _, _ := pb.Cost(true)
+
+	// Prepare a buffer so we can optimize our writes.
+	buffer := bytes.NewBuffer(nil)
+
+	// Provide payment.
+	err = pp.ProvidePayment(buffer, contract.HostPublicKey(), modules.RPCExecuteProgram, programCost, refundAccount, pt.HostBlockHeight)
+	if err != nil {
+		return modules.RenterContract{}, types.ZeroCurrency, errors.AddContext(err, "UpdateSector: failed to provide payment")
+	}
+
+	// Send the request and the program data.
+	err = modules.RPCWrite(buffer, modules.RPCExecuteProgramRequest{
+		FileContractID:    fcid,
+		Program:           program,
+		ProgramDataLength: uint64(len(programData)),
+	})
+	if err != nil {
+		return modules.RenterContract{}, types.ZeroCurrency, errors.AddContext(err, "UpdateSector: failed to write RPCExecuteProgramRequest")
+	}
+	_, err = buffer.Write(programData)
+	if err != nil {
+		return modules.RenterContract{}, types.ZeroCurrency, err
+	}
+	_, err = stream.Write(buffer.Bytes())
+	if err != nil {
+		return modules.RenterContract{}, types.ZeroCurrency, errors.AddContext(err, "UpdateSector: failed to write program")
+	}
+	cost = programCost
+
+	// Read the cancellation token and the response.
+	var ct modules.MDMCancellationToken
+	err = modules.RPCRead(stream, &ct)
+	if err != nil {
+		return modules.RenterContract{}, cost, errors.AddContext(err, "UpdateSector: failed to read cancellation token")
+	}
+	var resp modules.RPCExecuteProgramResponse
+	err = modules.RPCRead(stream, &resp)
+	if err != nil {
+		return modules.RenterContract{}, cost, errors.AddContext(err, "UpdateSector: failed to read response")
+	}
+	output := make([]byte, resp.OutputLength)
+	_, err = io.ReadFull(stream, output)
+	if err != nil {
+		return modules.RenterContract{}, cost, errors.AddContext(err, "UpdateSector: failed to read output")
+	}
+	if resp.Error != nil {
+		return modules.RenterContract{}, cost, errors.AddContext(resp.Error, "UpdateSector: host failed to execute program")
+	}
+
+	// Verify the proof.
+	if resp.NewSize != rev.NewFileSize {
+		return modules.RenterContract{}, cost, fmt.Errorf("UpdateSector: host changed the contract size from %v to %v", rev.NewFileSize, resp.NewSize)
+	}
+	var usr modules.MDMInstructionUpdateSectorResponse
+	err = encoding.Unmarshal(output, &usr)
+	if err != nil {
+		return modules.RenterContract{}, cost, errors.AddContext(err, "UpdateSector: failed to decode output")
+	}
+	err = modules.VerifyUpdateSectorProof(usr, resp.Proof, numSectors, sectorIndex, offset, data, rev.NewFileMerkleRoot, resp.NewMerkleRoot)
+	if err != nil {
+		return modules.RenterContract{}, cost, errors.AddContext(err, "UpdateSector: invalid proof")
+	}
+
+	// Create the new revision.
+	transfer := resp.AdditionalCollateral.Add(resp.FailureRefund)
+	newRevision, err := rev.ExecuteProgramRevision(rev.NewRevisionNumber+1, transfer, resp.NewMerkleRoot, resp.NewSize)
+	if err != nil {
+		return modules.RenterContract{}, cost, errors.AddContext(err, "UpdateSector: failed to create revision")
+	}
+	newValidProofValues := make([]types.Currency, len(newRevision.NewValidProofOutputs))
+	for i := range newRevision.NewValidProofOutputs {
+		newValidProofValues[i] = newRevision.NewValidProofOutputs[i].Value
+	}
+	newMissedProofValues := make([]types.Currency, len(newRevision.NewMissedProofOutputs))
+	for i := range newRevision.NewMissedProofOutputs {
+		newMissedProofValues[i] = newRevision.NewMissedProofOutputs[i].Value
+	}
+
+	// Record the changes we are about to make to the contract.
+	walTxn, err := sc.managedRecordUpdateSectorIntent(newRevision, usr.NewSectorRoot, int(sectorIndex))
+	if err != nil {
+		return modules.RenterContract{}, cost, errors.AddContext(err, "UpdateSector: failed to record update sector intent")
+	}
+
+	// Sign the revision and send it to the host.
+	txn := types.Transaction{
+		FileContractRevisions: []types.FileContractRevision{newRevision},
+		TransactionSignatures: []types.TransactionSignature{
+			{
+				ParentID:       crypto.Hash(newRevision.ParentID),
+				CoveredFields:  types.CoveredFields{FileContractRevisions: []uint64{0}},
+				PublicKeyIndex: 0, // renter key is always first -- see formContract
+			},
+			{
+				ParentID:       crypto.Hash(newRevision.ParentID),
+				PublicKeyIndex: 1,
+				CoveredFields:  types.CoveredFields{FileContractRevisions: []uint64{0}},
+				Signature:      nil, // to be provided by host
+			},
+		},
+	}
+	sig := crypto.SignHash(txn.SigHash(0, pt.HostBlockHeight), contract.SecretKey)
+	txn.TransactionSignatures[0].Signature = sig[:]
+	err = modules.RPCWrite(stream, modules.RPCExecuteProgramRevisionSigningRequest{
+		Signature:            sig[:],
+		NewRevisionNumber:    newRevision.NewRevisionNumber,
+		NewValidProofValues:  newValidProofValues,
+		NewMissedProofValues: newMissedProofValues,
+	})
+	if err != nil {
+		return modules.RenterContract{}, cost, errors.AddContext(err, "UpdateSector: failed to write RPCExecuteProgramRevisionSigningRequest")
+	}
+
+	// Read the host's signature and verify it.
+	var signingResp modules.RPCExecuteProgramRevisionSigningResponse
+	err = modules.RPCRead(stream, &signingResp)
+	if err != nil {
+		return modules.RenterContract{}, cost, errors.AddContext(err, "UpdateSector: failed to read RPCExecuteProgramRevisionSigningResponse")
+	}
+	txn.TransactionSignatures[1].Signature = signingResp.Signature
+	err = modules.VerifyFileContractRevisionTransactionSignatures(newRevision, txn.TransactionSignatures, pt.HostBlockHeight)
+	if err != nil {
+		return modules.RenterContract{}, cost, errors.AddContext(err, "UpdateSector: host signature verification failed")
+	}
+
+	// Update the contract.
+	err = sc.managedCommitUpdateSector(walTxn, txn)
+	if err != nil {
+		return modules.RenterContract{}, cost, errors.AddContext(err, "UpdateSector: failed to commit update sector")
+	}
+	return sc.Metadata(), cost, nil
+}
@@ -0,0 +1,397 @@
+package renter
+
+// redundancy.go contains the logic for applying the redundancy policies of
+// directories to the siafiles within them.
+//
+// A RedundancyPolicy set on a directory determines the erasure code of new
+// uploads within the directory and its subdirectories unless an erasure code
+// is specified explicitly. Existing siafiles using a different erasure code are
+// re-encoded in the background. The repair loop periodically launches a scan
+// for such siafiles. Each of them is re-encoded by streaming its data from the
+// network into a new siafile within the ReencodeFolder, which replaces the
+// original siafile once its data is available.
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem"
+)
+
+var (
+	// errVarFolderRedundancyPolicy is returned when trying to set a
+	// redundancy policy for a directory within the VarFolder.
+	errVarFolderRedundancyPolicy = errors.New("redundancy policies are not supported within the var folder")
+
+	// errReencodeFileChanged is returned when a file was modified while it
+	// was being re-encoded.
+	errReencodeFileChanged = errors.New("file was modified while being re-encoded")
+)
+
+type (
+	// reencoder keeps track of the background re-encoding of siafiles.
+	reencoder struct {
+		// lastScan is the time the last scan for siafiles to re-encode was
+		// started. running indicates whether a scan is currently in progress
+		// and scanNeeded indicates that a redundancy policy has changed since
+		// the last scan was started.
+		lastScan   time.Time
+		running    bool
+		scanNeeded bool
+
+		mu sync.Mutex
+	}
+
+	// reencodeCandidate is a siafile whose erasure code doesn't match the
+	// redundancy policy of its directory.
+	reencodeCandidate struct {
+		siaPath modules.TurtleDexPath
+		ec      modules.ErasureCoder
+	}
+)
+
+// managedTryStart marks a scan as running and returns true if no scan is
+// running and either a scan was requested or reencodeFilesInterval has passed
+// since the last scan.
+func (re *reencoder) managedTryStart() bool {
+	re.mu.Lock()
+	defer re.mu.Unlock()
+	if re.running || (!re.scanNeeded && time.Since(re.lastScan) < reencodeFilesInterval) {
+		return false
+	}
+	re.lastScan = time.Now()
+	re.running = true
+	re.scanNeeded = false
+	return true
+}
+
+// managedDone marks the running scan as done.
+func (re *reencoder) managedDone() {
+	re.mu.Lock()
+	defer re.mu.Unlock()
+	re.running = false
+}
+
+// managedRequestScan requests a new scan which is started by the next
+// iteration of the repair loop.
+func (re *reencoder) managedRequestScan() {
+	re.mu.Lock()
+	defer re.mu.Unlock()
+	re.scanNeeded = true
+}
+
+// isReencodable returns whether a siafile should be re-encoded if its erasure
+// code doesn't match its redundancy policy. Skyfiles, system files and files
+// which can't currently be downloaded are not re-encoded.
+func isReencodable(fi modules.FileInfo) bool {
+	if isVarPath(fi.TurtleDexPath) || strings.HasPrefix(fi.TurtleDexPath.Path, modules.BackupFolder.Path+"/") {
+		return false
+	}
+	return len(fi.Skylinks) == 0 && fi.Recoverable && fi.Filesize > 0
+}
+
+// redundancyPolicyOf returns the policy which applies to a siapath given the
+// enabled policies of all directories.
+func redundancyPolicyOf(policies map[modules.TurtleDexPath]modules.RedundancyPolicy, siaPath modules.TurtleDexPath) (modules.RedundancyPolicy, bool) {
+	dir, err := siaPath.Dir()
+	for err == nil {
+		if policy, ok := policies[dir]; ok {
+			return policy, true
+		}
+		if dir.IsRoot() {
+			break
+		}
+		dir, err = dir.Dir()
+	}
+	return modules.RedundancyPolicy{}, false
+}
+
+// SetDirRedundancyPolicy sets the redundancy policy of a directory.
+func (r *Renter) SetDirRedundancyPolicy(siaPath modules.TurtleDexPath, policy modules.RedundancyPolicy) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if isVarPath(siaPath) {
+		return errVarFolderRedundancyPolicy
+	}
+	if !policy.Enabled() {
+		policy = modules.RedundancyPolicy{}
+	} else if _, err := policy.ErasureCoder(); err != nil {
+		return errors.AddContext(err, "invalid redundancy policy")
+	}
+	err := r.staticFileSystem.UpdateDirRedundancyPolicy(siaPath, policy)
+	if err != nil {
+		return err
+	}
+
+	// Wake up the repair loop to re-encode the affected files.
+	r.staticReencoder.managedRequestScan()
+	select {
+	case r.uploadHeap.repairNeeded <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// managedUploadErasureCoder returns the erasure coder for a new upload to the
+// siapath which didn't specify an erasure code. This is the erasure coder of
+// the file's redundancy policy or the default erasure coder if there is none.
+func (r *Renter) managedUploadErasureCoder(siaPath modules.TurtleDexPath) (modules.ErasureCoder, error) {
+	policy, err := r.managedRedundancyPolicy(siaPath)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to get redundancy policy")
+	}
+	if !policy.Enabled() {
+		return modules.NewRSSubCodeDefault(), nil
+	}
+	return policy.ErasureCoder()
+}
+
+// managedRedundancyPolicy returns the redundancy policy which applies to a
+// file. This is the policy of the closest ancestor directory which has a
+// policy set.
+func (r *Renter) managedRedundancyPolicy(siaPath modules.TurtleDexPath) (modules.RedundancyPolicy, error) {
+	if isVarPath(siaPath) {
+		return modules.RedundancyPolicy{}, nil
+	}
+	dir, err := siaPath.Dir()
+	if err != nil {
+		return modules.RedundancyPolicy{}, err
+	}
+	for {
+		policy, err := r.managedDirRedundancyPolicy(dir)
+		if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+			return modules.RedundancyPolicy{}, err
+		}
+		if err == nil && policy.Enabled() {
+			return policy, nil
+		}
+		if dir.IsRoot() {
+			return modules.RedundancyPolicy{}, nil
+		}
+		dir, err = dir.Dir()
+		if err != nil {
+			return modules.RedundancyPolicy{}, err
+		}
+	}
+}
+
+// managedDirRedundancyPolicy returns the redundancy policy set on a
+// directory.
+func (r *Renter) managedDirRedundancyPolicy(siaPath modules.TurtleDexPath) (_ modules.RedundancyPolicy, err error) {
+	dir, err := r.staticFileSystem.OpenTurtleDexDir(siaPath)
+	if err != nil {
+		return modules.RedundancyPolicy{}, err
+	}
+	defer func() {
+		err = errors.Compose(err, dir.Close())
+	}()
+	md, err := dir.Metadata()
+	if err != nil {
+		return modules.RedundancyPolicy{}, err
+	}
+	return md.RedundancyPolicy, nil
+}
+
+// managedFilesToReencode returns the siafiles whose erasure code doesn't match
+// the redundancy policy of their directory.
+func (r *Renter) managedFilesToReencode() ([]reencodeCandidate, error) {
+	// Collect the enabled policies and the files which could be re-encoded.
+	policies := make(map[modules.TurtleDexPath]modules.RedundancyPolicy)
+	var files []modules.TurtleDexPath
+	var mu sync.Mutex
+	flf := func(fi modules.FileInfo) {
+		if !isReencodable(fi) {
+			return
+		}
+		mu.Lock()
+		files = append(files, fi.TurtleDexPath)
+		mu.Unlock()
+	}
+	dlf := func(di modules.DirectoryInfo) {
+		if !di.RedundancyPolicy.Enabled() {
+			return
+		}
+		mu.Lock()
+		policies[di.TurtleDexPath] = di.RedundancyPolicy
+		mu.Unlock()
+	}
+	err := r.staticFileSystem.CachedList(modules.RootTurtleDexPath(), true, flf, dlf)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to list files")
+	}
+	if len(policies) == 0 {
+		return nil, nil
+	}
+
+	// Compare the erasure code of the files with their policy.
+	var candidates []reencodeCandidate
+	for _, siaPath := range files {
+		policy, ok := redundancyPolicyOf(policies, siaPath)
+		if !ok {
+			continue
+		}
+		ec, err := policy.ErasureCoder()
+		if err != nil {
+			r.repairLog.Printf("WARN: invalid redundancy policy for %v: %v", siaPath, err)
+			continue
+		}
+		node, err := r.staticFileSystem.OpenTurtleDexFile(siaPath)
+		if errors.Contains(err, filesystem.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.AddContext(err, "unable to open file")
+		}
+		mismatch := node.ErasureCode().Identifier() != ec.Identifier()
+		if err := node.Close(); err != nil {
+			return nil, errors.AddContext(err, "unable to close file")
+		}
+		if mismatch {
+			candidates = append(candidates, reencodeCandidate{
+				siaPath: siaPath,
+				ec:      ec,
+			})
+		}
+	}
+	return candidates, nil
+}
+
+// managedReencodeFile re-encodes a siafile using the provided erasure coder.
+// The data of the file is streamed into a new siafile within the
+// ReencodeFolder which then replaces the original file.
+func (r *Renter) managedReencodeFile(siaPath modules.TurtleDexPath, ec modules.ErasureCoder) (err error) {
+	// Remember the properties of the file which need to be preserved.
+	node, err := r.staticFileSystem.OpenTurtleDexFile(siaPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to open file")
+	}
+	uid := node.UID()
+	current := node.ErasureCode().Identifier()
+	localPath := node.LocalPath()
+	skylinks := node.Metadata().Skylinks
+	mode := node.Mode()
+	compression := node.Compression()
+	masterKey := node.MasterKey()
+	if err := node.Close(); err != nil {
+		return errors.AddContext(err, "unable to close file")
+	}
+	if current == ec.Identifier() {
+		return nil
+	}
+
+	// Remove the leftovers of a previously interrupted re-encode.
+	tmpPath, err := modules.ReencodeFolder.Join(siaPath.Path)
+	if err != nil {
+		return err
+	}
+	err = r.managedDeleteFile(tmpPath)
+	if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+		return errors.AddContext(err, "unable to delete previously re-encoded file")
+	}
+	// Delete the new siafile on failure.
+	defer func() {
+		if err == nil {
+			return
+		}
+		if deleteErr := r.managedDeleteFile(tmpPath); deleteErr != nil && !errors.Contains(deleteErr, filesystem.ErrNotExist) {
+			err = errors.Compose(err, deleteErr)
+		}
+	}()
+
+	// Stream the file into the new siafile. The stream is decompressed and
+	// compressed again by the upload which results in the same data.
+	_, stream, err := r.Streamer(siaPath, false)
+	if err != nil {
+		return errors.AddContext(err, "unable to create streamer")
+	}
+	up := modules.FileUploadParams{
+		TurtleDexPath: tmpPath,
+		ErasureCode:   ec,
+		CipherType:    masterKey.Type(),
+		Dedup:         r.staticDedupIndex.staticIsDedupKey(masterKey),
+		Compression:   compression,
+	}
+	fileNode, err := r.callUploadStreamFromReader(up, stream)
+	if err := stream.Close(); err != nil {
+		r.repairLog.Printf("Unable to close streamer of %v: %v", siaPath, err)
+	}
+	if err != nil {
+		return errors.AddContext(err, "unable to upload re-encoded file")
+	}
+	err = fileNode.SetMode(mode)
+	if err == nil && localPath != "" {
+		err = fileNode.SetLocalPath(localPath)
+	}
+	for _, s := range skylinks {
+		if err != nil {
+			break
+		}
+		var skylink modules.Skylink
+		err = skylink.LoadString(s)
+		if err == nil {
+			err = fileNode.AddSkylink(skylink)
+		}
+	}
+	err = errors.Compose(err, fileNode.Close())
+	if err != nil {
+		return errors.AddContext(err, "unable to update re-encoded file")
+	}
+
+	// Make sure the file wasn't replaced in the meantime.
+	node, err = r.staticFileSystem.OpenTurtleDexFile(siaPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to open file")
+	}
+	changed := node.UID() != uid
+	if err := node.Close(); err != nil {
+		return errors.AddContext(err, "unable to close file")
+	}
+	if changed {
+		return errReencodeFileChanged
+	}
+
+	// Replace the file. The original file is restored if the re-encoded file
+	// can't be moved into place. It is not retained as a version since the
+	// re-encoded file contains the same data.
+	err = r.managedReplaceFileWithPolicy(tmpPath, siaPath, modules.VersioningPolicy{})
+	return errors.AddContext(err, "unable to move re-encoded file into place")
+}
+
+// threadedReencodeFiles re-encodes all siafiles whose erasure code doesn't
+// match the redundancy policy of their directory.
+func (r *Renter) threadedReencodeFiles() {
+	defer r.staticReencoder.managedDone()
+	err := r.tg.Add()
+	if err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	candidates, err := r.managedFilesToReencode()
+	if err != nil {
+		r.repairLog.Println("WARN: unable to find files to re-encode:", err)
+		return
+	}
+	for _, c := range candidates {
+		// Stop re-encoding if the renter shuts down, goes offline or the
+		// repairs are paused.
+		select {
+		case <-r.tg.StopChan():
+			return
+		default:
+		}
+		if !r.g.Online() || r.uploadHeap.managedIsPaused() {
+			return
+		}
+		r.repairLog.Printf("Re-encoding %v using %v", c.siaPath, c.ec.Identifier())
+		if err := r.managedReencodeFile(c.siaPath, c.ec); err != nil {
+			r.repairLog.Printf("WARN: unable to re-encode %v: %v", c.siaPath, err)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package renter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+)
+
+// TestRedundancyPolicyOf is a unit test for redundancyPolicyOf.
+func TestRedundancyPolicyOf(t *testing.T) {
+	newPath := func(s string) modules.TurtleDexPath {
+		sp, err := modules.NewTurtleDexPath(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sp
+	}
+	root := modules.RedundancyPolicy{DataPieces: 10, ParityPieces: 20}
+	archive := modules.RedundancyPolicy{DataPieces: 10, ParityPieces: 30}
+	policies := map[modules.TurtleDexPath]modules.RedundancyPolicy{
+		modules.RootTurtleDexPath(): root,
+		newPath("archive"):          archive,
+	}
+
+	tests := []struct {
+		siaPath string
+		policy  modules.RedundancyPolicy
+	}{
+		{"file", root},
+		{"other/file", root},
+		{"archive/file", archive},
+		{"archive/a/b/file", archive},
+		{"archived/file", root},
+	}
+	for _, test := range tests {
+		policy, ok := redundancyPolicyOf(policies, newPath(test.siaPath))
+		if !ok || policy != test.policy {
+			t.Errorf("%v: expected %v but got %v", test.siaPath, test.policy, policy)
+		}
+	}
+
+	// Without a policy for the root, files outside of the archive don't have
+	// a policy.
+	delete(policies, modules.RootTurtleDexPath())
+	if _, ok := redundancyPolicyOf(policies, newPath("other/file")); ok {
+		t.Fatal("file shouldn't have a policy")
+	}
+}
+
+// TestReencoderTryStart tests that only one re-encoding scan is running at a
+// time and that scans are only started when needed.
+func TestReencoderTryStart(t *testing.T) {
+	re := new(reencoder)
+	if !re.managedTryStart() {
+		t.Fatal("initial scan should start")
+	}
+	if re.managedTryStart() {
+		t.Fatal("scan shouldn't start while another one is running")
+	}
+	re.managedDone()
+	if re.managedTryStart() {
+		t.Fatal("scan shouldn't start before the interval passed")
+	}
+	re.managedRequestScan()
+	if !re.managedTryStart() {
+		t.Fatal("requested scan should start")
+	}
+	re.managedDone()
+	re.lastScan = time.Now().Add(-reencodeFilesInterval)
+	if !re.managedTryStart() {
+		t.Fatal("scan should start after the interval passed")
+	}
+}
+
+// TestRenterRedundancyPolicy tests setting redundancy policies on directories
+// and applying them to new uploads.
+func TestRenterRedundancyPolicy(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	// Create a directory with a policy and a subdirectory.
+	dir, err := modules.NewTurtleDexPath("archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	subDir, err := dir.Join("sub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.CreateDir(subDir, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	policy := modules.RedundancyPolicy{DataPieces: 10, ParityPieces: 20}
+	if err := r.SetDirRedundancyPolicy(dir, policy); err != nil {
+		t.Fatal(err)
+	}
+	dirs, err := r.DirList(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dirs[0].RedundancyPolicy != policy {
+		t.Fatal("wrong policy", dirs[0].RedundancyPolicy)
+	}
+
+	// checkErasureCoder checks the erasure coder used for new uploads.
+	checkErasureCoder := func(siaPath modules.TurtleDexPath, expected modules.ErasureCoder) {
+		t.Helper()
+		ec, err := r.managedUploadErasureCoder(siaPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ec.Identifier() != expected.Identifier() {
+			t.Fatalf("%v: expected %v but got %v", siaPath, expected.Identifier(), ec.Identifier())
+		}
+	}
+	policyEC, err := policy.ErasureCoder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := subDir.Join("file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := modules.NewTurtleDexPath("other/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkErasureCoder(file, policyEC)
+	checkErasureCoder(other, modules.NewRSSubCodeDefault())
+
+	// A policy of the subdirectory takes precedence.
+	subPolicy := modules.RedundancyPolicy{DataPieces: 5, ParityPieces: 10}
+	if err := r.SetDirRedundancyPolicy(subDir, subPolicy); err != nil {
+		t.Fatal(err)
+	}
+	subPolicyEC, err := subPolicy.ErasureCoder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkErasureCoder(file, subPolicyEC)
+
+	// Removing the policies results in the default erasure coder.
+	if err := r.SetDirRedundancyPolicy(subDir, modules.RedundancyPolicy{}); err != nil {
+		t.Fatal(err)
+	}
+	checkErasureCoder(file, policyEC)
+	if err := r.SetDirRedundancyPolicy(dir, modules.RedundancyPolicy{}); err != nil {
+		t.Fatal(err)
+	}
+	checkErasureCoder(file, modules.NewRSSubCodeDefault())
+
+	// Invalid policies and policies within the var folder are rejected.
+	err = r.SetDirRedundancyPolicy(dir, modules.RedundancyPolicy{DataPieces: 300})
+	if err == nil {
+		t.Fatal("invalid policy should be rejected")
+	}
+	err = r.SetDirRedundancyPolicy(modules.SkynetFolder, policy)
+	if !errors.Contains(err, errVarFolderRedundancyPolicy) {
+		t.Fatalf("expected %v but got %v", errVarFolderRedundancyPolicy, err)
+	}
+}
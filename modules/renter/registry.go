@@ -109,6 +109,34 @@ func (r *Renter) ReadRegistry(spk types.TurtleDexPublicKey, tweak crypto.Hash, t
 	return srv, err
 }
 
+// ReadRegistryEID starts a registry lookup by entry id on all available
+// workers. Apart from that it behaves like ReadRegistry.
+func (r *Renter) ReadRegistryEID(eid modules.RegistryEntryID, timeout time.Duration) (modules.SignedRegistryValue, error) {
+	// Create a context. If the timeout is greater than zero, have the context
+	// expire when the timeout triggers.
+	ctx := r.tg.StopCtx()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(r.tg.StopCtx(), timeout)
+		defer cancel()
+	}
+
+	// Block until there is memory available, and then ensure the memory gets
+	// returned.
+	// Since registry entries are very small we use a fairly generous multiple.
+	if !r.registryMemoryManager.Request(ctx, readRegistryMemory, memoryPriorityHigh) {
+		return modules.SignedRegistryValue{}, errors.New("timeout while waiting in job queue - server is busy")
+	}
+	defer r.registryMemoryManager.Return(readRegistryMemory)
+
+	// Start the ReadRegistry jobs.
+	srv, err := r.managedReadRegistryEID(ctx, eid)
+	if errors.Contains(err, ErrRegistryLookupTimeout) {
+		err = errors.AddContext(err, fmt.Sprintf("timed out after %vs", timeout.Seconds()))
+	}
+	return srv, err
+}
+
 // UpdateRegistry updates the registries on all workers with the given
 // registry value.
 func (r *Renter) UpdateRegistry(spk types.TurtleDexPublicKey, srv modules.SignedRegistryValue, timeout time.Duration) error {
@@ -142,6 +170,26 @@ func (r *Renter) UpdateRegistry(spk types.TurtleDexPublicKey, srv modules.Signed
 // response. Otherwise the response with the highest revision number will be
 // used.
 func (r *Renter) managedReadRegistry(ctx context.Context, spk types.TurtleDexPublicKey, tweak crypto.Hash) (modules.SignedRegistryValue, error) {
+	newJob := func(ctx context.Context, w *worker, responseChan chan *jobReadRegistryResponse) *jobReadRegistry {
+		return w.newJobReadRegistry(ctx, responseChan, spk, tweak)
+	}
+	return r.managedReadRegistryLookup(ctx, minRegistryVersion, newJob)
+}
+
+// managedReadRegistryEID starts a registry lookup by entry id on all available
+// workers which support it. Apart from that it behaves like
+// managedReadRegistry.
+func (r *Renter) managedReadRegistryEID(ctx context.Context, eid modules.RegistryEntryID) (modules.SignedRegistryValue, error) {
+	newJob := func(ctx context.Context, w *worker, responseChan chan *jobReadRegistryResponse) *jobReadRegistry {
+		return w.newJobReadRegistryEID(ctx, responseChan, eid)
+	}
+	return r.managedReadRegistryLookup(ctx, minRegistryEIDVersion, newJob)
+}
+
+// managedReadRegistryLookup launches the jobs created by newJob on all workers
+// with at least minVersion and returns the response with the highest
+// revision number.
+func (r *Renter) managedReadRegistryLookup(ctx context.Context, minVersion string, newJob func(context.Context, *worker, chan *jobReadRegistryResponse) *jobReadRegistry) (modules.SignedRegistryValue, error) {
 	// Create a context that dies when the function ends, this will cancel all
 	// of the worker jobs that get created by this function.
 	ctx, cancel := context.WithCancel(ctx)
@@ -158,7 +206,7 @@ func (r *Renter) managedReadRegistry(ctx context.Context, spk types.TurtleDexPub
 	numRegistryWorkers := 0
 	for _, worker := range workers {
 		cache := worker.staticCache()
-		if build.VersionCmp(cache.staticHostVersion, minRegistryVersion) < 0 {
+		if build.VersionCmp(cache.staticHostVersion, minVersion) < 0 {
 			continue
 		}
 
@@ -173,7 +221,7 @@ func (r *Renter) managedReadRegistry(ctx context.Context, spk types.TurtleDexPub
 			continue
 		}
 
-		jrr := worker.newJobReadRegistry(ctx, staticResponseChan, spk, tweak)
+		jrr := newJob(ctx, worker, staticResponseChan)
 		if !worker.staticJobReadRegistryQueue.callAdd(jrr) {
 			// This will filter out any workers that are on cooldown or
 			// otherwise can't participate in the project.
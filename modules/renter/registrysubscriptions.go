@@ -0,0 +1,516 @@
+package renter
+
+import (
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/turtledex/TurtleDexCore/build"
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/types"
+	"github.com/turtledex/errors"
+	"github.com/turtledex/fastrand"
+	"github.com/turtledex/siamux"
+)
+
+var (
+	// errRegistrySubscriberClosed is returned when subscribing with a
+	// subscriber that has already been closed.
+	errRegistrySubscriberClosed = errors.New("registry subscriber has been closed")
+
+	// registrySubscriberNotificationBuffer is the number of notifications that
+	// are buffered for a subscriber. If the buffer is full, new notifications
+	// for the subscriber are dropped.
+	registrySubscriberNotificationBuffer = 100
+
+	// subscriptionExtensionWindow is the amount of time before the end of a
+	// subscription period at which a session is funded and extended.
+	subscriptionExtensionWindow = modules.SubscriptionPeriod / 2
+
+	// subscriptionManagerInterval is the interval at which the subscription
+	// manager checks for workers which don't have a subscription session yet.
+	subscriptionManagerInterval = build.Select(build.Var{
+		Dev:      time.Second * 10,
+		Standard: time.Minute,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// subscriptionSessionCooldown is the amount of time the subscription
+	// manager waits before starting a new session with a host after the
+	// previous session failed.
+	subscriptionSessionCooldown = build.Select(build.Var{
+		Dev:      time.Second * 10,
+		Standard: time.Minute,
+		Testing:  time.Second,
+	}).(time.Duration)
+)
+
+type (
+	// registrySubscriptionManager multiplexes the registry subscriptions of all
+	// subscribers across the workers of the renter. Every worker whose host
+	// supports subscriptions runs a subscription session which is subscribed
+	// to the union of the entries that the subscribers are interested in.
+	// Notifications from all hosts are deduplicated by revision number before
+	// they are forwarded to the subscribers.
+	registrySubscriptionManager struct {
+		// subscriptions contains all entries that at least one subscriber is
+		// subscribed to.
+		subscriptions map[modules.SubscriptionID]*registrySubscription
+
+		// sessions contains the running subscription sessions by host. If a
+		// session fails, cooldowns prevents a new session from being started
+		// with the same host right away.
+		sessions  map[string]*subscriptionSession
+		cooldowns map[string]time.Time
+
+		staticRenter     *Renter
+		staticSubscriber types.Specifier
+		staticWakeChan   chan struct{}
+		mu               sync.Mutex
+	}
+
+	// registrySubscription is a single entry that the manager is subscribed
+	// to.
+	registrySubscription struct {
+		staticPubKey types.TurtleDexPublicKey
+		staticTweak  crypto.Hash
+
+		// latest is the entry with the highest revision number seen so far.
+		latest      *modules.SignedRegistryValue
+		subscribers map[*registrySubscriber]struct{}
+	}
+
+	// registrySubscriber is a subscriber which receives notifications for the
+	// entries it subscribed to. Its fields are protected by the mutex of the
+	// manager.
+	registrySubscriber struct {
+		closed        bool
+		subscriptions map[modules.SubscriptionID]struct{}
+
+		staticManager       *registrySubscriptionManager
+		staticNotifications chan modules.RPCRegistrySubscriptionNotificationEntryUpdate
+	}
+
+	// subscriptionSession is a subscription session with a single host.
+	// subscribed is only accessed by the goroutine running the session.
+	subscriptionSession struct {
+		subscribed map[modules.SubscriptionID]struct{}
+
+		staticWakeChan chan struct{}
+		staticWorker   *worker
+	}
+)
+
+// newRegistrySubscriptionManager creates a new subscription manager and
+// registers the listener for notifications with the renter's siamux.
+func newRegistrySubscriptionManager(r *Renter) (*registrySubscriptionManager, error) {
+	rsm := &registrySubscriptionManager{
+		subscriptions:  make(map[modules.SubscriptionID]*registrySubscription),
+		sessions:       make(map[string]*subscriptionSession),
+		cooldowns:      make(map[string]time.Time),
+		staticRenter:   r,
+		staticWakeChan: make(chan struct{}, 1),
+	}
+	fastrand.Read(rsm.staticSubscriber[:])
+
+	// Hosts send notifications on new streams to the subscriber.
+	listenerName := hex.EncodeToString(rsm.staticSubscriber[:])
+	err := r.staticMux.NewListener(listenerName, rsm.threadedHandleNotification)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to register subscription listener")
+	}
+	err = r.tg.OnStop(func() error {
+		return r.staticMux.CloseListener(listenerName)
+	})
+	if err != nil {
+		return nil, err
+	}
+	go rsm.threadedManageSessions()
+	return rsm, nil
+}
+
+// NewRegistrySubscriber creates a new subscriber for registry entries.
+func (r *Renter) NewRegistrySubscriber() (modules.RegistrySubscriber, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+	return r.staticRegistrySubscriptionManager.newSubscriber(), nil
+}
+
+// newSubscriber creates a new subscriber.
+func (rsm *registrySubscriptionManager) newSubscriber() *registrySubscriber {
+	return &registrySubscriber{
+		subscriptions:       make(map[modules.SubscriptionID]struct{}),
+		staticManager:       rsm,
+		staticNotifications: make(chan modules.RPCRegistrySubscriptionNotificationEntryUpdate, registrySubscriberNotificationBuffer),
+	}
+}
+
+// Close unsubscribes the subscriber from all entries and closes its
+// notification channel.
+func (s *registrySubscriber) Close() error {
+	rsm := s.staticManager
+	rsm.mu.Lock()
+	defer rsm.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	for id := range s.subscriptions {
+		rsm.unsubscribe(s, id)
+	}
+	s.closed = true
+	close(s.staticNotifications)
+	return nil
+}
+
+// Notifications returns the channel on which updated entries are sent.
+func (s *registrySubscriber) Notifications() <-chan modules.RPCRegistrySubscriptionNotificationEntryUpdate {
+	return s.staticNotifications
+}
+
+// Subscribe subscribes to the entry with the given public key and tweak. If the
+// manager already knows a value for the entry, it is sent to the subscriber
+// right away.
+func (s *registrySubscriber) Subscribe(spk types.TurtleDexPublicKey, tweak crypto.Hash) error {
+	rsm := s.staticManager
+	rsm.mu.Lock()
+	defer rsm.mu.Unlock()
+	if s.closed {
+		return errRegistrySubscriberClosed
+	}
+	id := modules.RegistrySubscriptionID(spk, tweak)
+	if _, exists := s.subscriptions[id]; exists {
+		return nil
+	}
+	s.subscriptions[id] = struct{}{}
+
+	sub, exists := rsm.subscriptions[id]
+	if !exists {
+		sub = &registrySubscription{
+			staticPubKey: spk,
+			staticTweak:  tweak,
+			subscribers:  make(map[*registrySubscriber]struct{}),
+		}
+		rsm.subscriptions[id] = sub
+		rsm.wakeSessions()
+	}
+	sub.subscribers[s] = struct{}{}
+	if sub.latest != nil {
+		s.notify(modules.RPCRegistrySubscriptionNotificationEntryUpdate{
+			Entry:  *sub.latest,
+			PubKey: spk,
+		})
+	}
+	return nil
+}
+
+// Unsubscribe unsubscribes from the entry with the given public key and tweak.
+func (s *registrySubscriber) Unsubscribe(spk types.TurtleDexPublicKey, tweak crypto.Hash) {
+	rsm := s.staticManager
+	rsm.mu.Lock()
+	defer rsm.mu.Unlock()
+	rsm.unsubscribe(s, modules.RegistrySubscriptionID(spk, tweak))
+}
+
+// notify sends a notification to the subscriber without blocking. If the
+// subscriber's buffer is full, the notification is dropped.
+func (s *registrySubscriber) notify(update modules.RPCRegistrySubscriptionNotificationEntryUpdate) bool {
+	select {
+	case s.staticNotifications <- update:
+		return true
+	default:
+		return false
+	}
+}
+
+// unsubscribe removes the subscription of a subscriber to an entry. Once an
+// entry has no subscribers left, the sessions unsubscribe from it.
+func (rsm *registrySubscriptionManager) unsubscribe(s *registrySubscriber, id modules.SubscriptionID) {
+	if _, exists := s.subscriptions[id]; !exists {
+		return
+	}
+	delete(s.subscriptions, id)
+	sub, exists := rsm.subscriptions[id]
+	if !exists {
+		build.Critical("subscriber is subscribed to an entry unknown to the manager")
+		return
+	}
+	delete(sub.subscribers, s)
+	if len(sub.subscribers) == 0 {
+		delete(rsm.subscriptions, id)
+		rsm.wakeSessions()
+	}
+}
+
+// wakeSessions signals the manager and all sessions that the set of
+// subscriptions has changed.
+func (rsm *registrySubscriptionManager) wakeSessions() {
+	select {
+	case rsm.staticWakeChan <- struct{}{}:
+	default:
+	}
+	for _, session := range rsm.sessions {
+		select {
+		case session.staticWakeChan <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// managedNotify forwards an updated entry to all of its subscribers unless a
+// notification with the same or a higher revision has already been forwarded.
+func (rsm *registrySubscriptionManager) managedNotify(update modules.RPCRegistrySubscriptionNotificationEntryUpdate) {
+	rsm.mu.Lock()
+	defer rsm.mu.Unlock()
+	id := modules.RegistrySubscriptionID(update.PubKey, update.Entry.Tweak)
+	sub, exists := rsm.subscriptions[id]
+	if !exists {
+		return
+	}
+	if sub.latest != nil && update.Entry.Revision <= sub.latest.Revision {
+		return
+	}
+	entry := update.Entry
+	sub.latest = &entry
+	for s := range sub.subscribers {
+		if !s.notify(update) {
+			rsm.staticRenter.log.Debugf("dropped registry notification for a subscriber with a full buffer")
+		}
+	}
+}
+
+// threadedHandleNotification handles a stream opened by a host to notify the
+// renter about an updated entry.
+func (rsm *registrySubscriptionManager) threadedHandleNotification(stream siamux.Stream) {
+	r := rsm.staticRenter
+	defer func() {
+		if err := stream.Close(); err != nil {
+			r.log.Debugln("failed to close notification stream", err)
+		}
+	}()
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	var nt modules.RPCRegistrySubscriptionNotificationType
+	err := modules.RPCRead(stream, &nt)
+	if err != nil {
+		r.log.Debugln("failed to read notification type", err)
+		return
+	}
+	switch nt.Type {
+	case modules.SubscriptionResponseSubscriptionSuccess:
+		// The host confirmed extending the subscription, nothing to do.
+		return
+	case modules.SubscriptionResponseRegistryValue:
+	default:
+		r.log.Debugln("received unknown notification type", nt.Type)
+		return
+	}
+
+	var update modules.RPCRegistrySubscriptionNotificationEntryUpdate
+	err = modules.RPCRead(stream, &update)
+	if err != nil {
+		r.log.Debugln("failed to read entry update", err)
+		return
+	}
+	// Anyone can open a stream to the subscriber, only forward entries with a
+	// valid signature.
+	err = update.Entry.Verify(update.PubKey.ToPublicKey())
+	if err != nil {
+		r.log.Debugln("received entry update with invalid signature", err)
+		return
+	}
+	rsm.managedNotify(update)
+}
+
+// threadedManageSessions starts subscription sessions with all workers that
+// support them for as long as there are subscriptions.
+func (rsm *registrySubscriptionManager) threadedManageSessions() {
+	r := rsm.staticRenter
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	for {
+		rsm.managedStartSessions()
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-rsm.staticWakeChan:
+		case <-time.After(subscriptionManagerInterval):
+		}
+	}
+}
+
+// managedStartSessions starts a session with every worker that doesn't have
+// one yet.
+func (rsm *registrySubscriptionManager) managedStartSessions() {
+	workers := rsm.staticRenter.staticWorkerPool.callWorkers()
+	rsm.mu.Lock()
+	defer rsm.mu.Unlock()
+	if len(rsm.subscriptions) == 0 {
+		return
+	}
+	for _, w := range workers {
+		hostKey := w.staticHostPubKeyStr
+		if _, exists := rsm.sessions[hostKey]; exists {
+			continue
+		}
+		if time.Now().Before(rsm.cooldowns[hostKey]) {
+			continue
+		}
+		if build.VersionCmp(w.staticCache().staticHostVersion, minRegistrySubscriptionVersion) < 0 {
+			continue
+		}
+		session := &subscriptionSession{
+			subscribed:     make(map[modules.SubscriptionID]struct{}),
+			staticWakeChan: make(chan struct{}, 1),
+			staticWorker:   w,
+		}
+		rsm.sessions[hostKey] = session
+		go rsm.threadedRunSession(session)
+	}
+}
+
+// threadedRunSession runs a subscription session and removes it from the
+// manager once it ends.
+func (rsm *registrySubscriptionManager) threadedRunSession(session *subscriptionSession) {
+	r := rsm.staticRenter
+	hostKey := session.staticWorker.staticHostPubKeyStr
+	defer func() {
+		rsm.mu.Lock()
+		delete(rsm.sessions, hostKey)
+		rsm.mu.Unlock()
+	}()
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	err := rsm.managedRunSession(session)
+	if err != nil {
+		r.log.Printf("subscription session with host %v failed: %v", hostKey, err)
+		rsm.mu.Lock()
+		rsm.cooldowns[hostKey] = time.Now().Add(subscriptionSessionCooldown)
+		rsm.mu.Unlock()
+	}
+}
+
+// managedSessionDiff returns the entries a session needs to subscribe to and
+// the ids of the entries it needs to unsubscribe from to match the subscriptions of the manager.
+func (rsm *registrySubscriptionManager) managedSessionDiff(session *subscriptionSession) (subscribe []modules.RPCRegistrySubscriptionRequest, unsubscribe []modules.SubscriptionID, done bool) {
+	rsm.mu.Lock()
+	defer rsm.mu.Unlock()
+	for id, sub := range rsm.subscriptions {
+		if _, subscribed := session.subscribed[id]; subscribed {
+			continue
+		}
+		subscribe = append(subscribe, modules.RPCRegistrySubscriptionRequest{
+			PubKey: sub.staticPubKey,
+			Tweak:  sub.staticTweak,
+		})
+	}
+	for id := range session.subscribed {
+		if _, exists := rsm.subscriptions[id]; exists {
+			continue
+		}
+		// The manager no longer knows the pubkey and tweak of the entry. The
+		// session keeps track of them in the requests it sent.
+		unsubscribe = append(unsubscribe, id)
+	}
+	return subscribe, unsubscribe, len(rsm.subscriptions) == 0
+}
+
+// managedRunSession begins a subscription with the worker's host and keeps it
+// in sync with the subscriptions of the manager. The session is funded and
+// extended periodically until there are no subscriptions left or the renter
+// shuts down.
+func (rsm *registrySubscriptionManager) managedRunSession(session *subscriptionSession) (err error) {
+	r := rsm.staticRenter
+	w := session.staticWorker
+
+	// Make sure the price table is valid for the first period.
+	if w.managedPriceTableForSubscription(modules.SubscriptionPeriod) == nil {
+		return nil // shutdown
+	}
+	stream, err := w.managedBeginSubscription(initialSubscriptionBudget, w.staticAccount.staticID, rsm.staticSubscriber)
+	if err != nil {
+		return errors.AddContext(err, "failed to begin subscription")
+	}
+	deadline := time.Now().Add(modules.SubscriptionPeriod)
+	defer func() {
+		if err != nil {
+			err = errors.Compose(err, stream.Close())
+		}
+	}()
+
+	// requests keeps track of the requests the session subscribed with to be
+	// able to unsubscribe again.
+	requests := make(map[modules.SubscriptionID]modules.RPCRegistrySubscriptionRequest)
+	for {
+		// Bring the session's subscriptions up to date.
+		subscribe, unsubscribeIDs, done := rsm.managedSessionDiff(session)
+		if done {
+			return modules.RPCStopSubscription(stream)
+		}
+		if len(unsubscribeIDs) > 0 {
+			unsubscribe := make([]modules.RPCRegistrySubscriptionRequest, 0, len(unsubscribeIDs))
+			for _, id := range unsubscribeIDs {
+				unsubscribe = append(unsubscribe, requests[id])
+			}
+			err = modules.RPCUnsubscribeFromRVs(stream, unsubscribe)
+			if err != nil {
+				return errors.AddContext(err, "failed to unsubscribe")
+			}
+			for _, id := range unsubscribeIDs {
+				delete(session.subscribed, id)
+				delete(requests, id)
+			}
+		}
+		if len(subscribe) > 0 {
+			initialValues, err := modules.RPCSubscribeToRVs(stream, subscribe)
+			if err != nil {
+				return errors.AddContext(err, "failed to subscribe")
+			}
+			for _, req := range subscribe {
+				id := modules.RegistrySubscriptionID(req.PubKey, req.Tweak)
+				session.subscribed[id] = struct{}{}
+				requests[id] = req
+			}
+			for _, update := range initialValues {
+				rsm.managedNotify(update)
+			}
+		}
+
+		// Wait for changes to the subscriptions or for the subscription to
+		// need an extension.
+		select {
+		case <-r.tg.StopChan():
+			return modules.RPCStopSubscription(stream)
+		case <-session.staticWakeChan:
+			continue
+		case <-time.After(time.Until(deadline.Add(-subscriptionExtensionWindow))):
+		}
+
+		// Refill the budget. The host doesn't tell us how much of the budget
+		// is left, so we refill half of the initial budget every period.
+		err = w.managedFundSubscription(stream, initialSubscriptionBudget.Div64(2))
+		if err != nil {
+			return errors.AddContext(err, "failed to fund subscription")
+		}
+		pt := w.managedPriceTableForSubscription(time.Until(deadline) + modules.SubscriptionPeriod)
+		if pt == nil {
+			return modules.RPCStopSubscription(stream) // shutdown
+		}
+		err = modules.RPCExtendSubscription(stream, pt)
+		if err != nil {
+			return errors.AddContext(err, "failed to extend subscription")
+		}
+		deadline = deadline.Add(modules.SubscriptionPeriod)
+	}
+}
@@ -0,0 +1,176 @@
+package renter
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/turtledex/TurtleDexCore/build"
+	"github.com/turtledex/TurtleDexCore/modules"
+)
+
+// TestRegistrySubscriptionManagerNotify is a unit test for forwarding
+// notifications to subscribers.
+func TestRegistrySubscriptionManagerNotify(t *testing.T) {
+	t.Parallel()
+
+	rsm := &registrySubscriptionManager{
+		subscriptions:  make(map[modules.SubscriptionID]*registrySubscription),
+		sessions:       make(map[string]*subscriptionSession),
+		cooldowns:      make(map[string]time.Time),
+		staticWakeChan: make(chan struct{}, 1),
+	}
+	sub1 := rsm.newSubscriber()
+	sub2 := rsm.newSubscriber()
+
+	rv, spk, sk := randomRegistryValue()
+	update := modules.RPCRegistrySubscriptionNotificationEntryUpdate{
+		Entry:  rv,
+		PubKey: spk,
+	}
+
+	// Without subscribers, the update is ignored.
+	rsm.managedNotify(update)
+	if len(rsm.subscriptions) != 0 {
+		t.Fatal("manager shouldn't track entries without subscribers")
+	}
+
+	// Subscribe the first subscriber and notify it.
+	if err := sub1.Subscribe(spk, rv.Tweak); err != nil {
+		t.Fatal(err)
+	}
+	rsm.managedNotify(update)
+	if n := <-sub1.Notifications(); !reflect.DeepEqual(n, update) {
+		t.Fatal("wrong notification", n)
+	}
+
+	// The same revision shouldn't be forwarded twice.
+	rsm.managedNotify(update)
+	if len(sub1.Notifications()) != 0 {
+		t.Fatal("duplicate notification was forwarded")
+	}
+
+	// The second subscriber should receive the latest value right away.
+	if err := sub2.Subscribe(spk, rv.Tweak); err != nil {
+		t.Fatal(err)
+	}
+	if n := <-sub2.Notifications(); !reflect.DeepEqual(n, update) {
+		t.Fatal("wrong notification", n)
+	}
+
+	// A higher revision is forwarded to both subscribers.
+	update.Entry = modules.NewRegistryValue(rv.Tweak, rv.Data, rv.Revision+1).Sign(sk)
+	rsm.managedNotify(update)
+	for _, sub := range []*registrySubscriber{sub1, sub2} {
+		if n := <-sub.Notifications(); !reflect.DeepEqual(n, update) {
+			t.Fatal("wrong notification", n)
+		}
+	}
+
+	// Unsubscribe the first subscriber. The entry should still be tracked.
+	sub1.Unsubscribe(spk, rv.Tweak)
+	if len(rsm.subscriptions) != 1 {
+		t.Fatal("entry should still be tracked")
+	}
+
+	// Closing the second subscriber should remove the entry and close the
+	// channel.
+	if err := sub2.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(rsm.subscriptions) != 0 {
+		t.Fatal("entry should no longer be tracked")
+	}
+	if _, ok := <-sub2.Notifications(); ok {
+		t.Fatal("channel should be closed")
+	}
+	if err := sub2.Subscribe(spk, rv.Tweak); err != errRegistrySubscriberClosed {
+		t.Fatal("expected errRegistrySubscriberClosed but got", err)
+	}
+}
+
+// TestRegistrySubscriptions tests subscribing to a registry entry on a host.
+func TestRegistrySubscriptions(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	wt, err := newWorkerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Store an entry on the host.
+	rv, spk, sk := randomRegistryValue()
+	err = wt.UpdateRegistry(context.Background(), spk, rv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Subscribe to the entry.
+	subscriber, err := wt.rt.renter.NewRegistrySubscriber()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := subscriber.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	err = subscriber.Subscribe(spk, rv.Tweak)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nextRevision waits for the next notification and returns its revision.
+	nextRevision := func() (uint64, error) {
+		select {
+		case n := <-subscriber.Notifications():
+			return n.Entry.Revision, nil
+		case <-time.After(time.Minute):
+			return 0, fmt.Errorf("no notification received")
+		}
+	}
+
+	// The initial value should be received once the session with the host is
+	// established.
+	if rev, err := nextRevision(); err != nil || rev != rv.Revision {
+		t.Fatal("unexpected initial value", rev, err)
+	}
+
+	// Update the entry a few times, spanning multiple subscription periods to
+	// make sure the session is extended.
+	for i := 0; i < 3; i++ {
+		rv = modules.NewRegistryValue(rv.Tweak, rv.Data, rv.Revision+1).Sign(sk)
+		err = wt.UpdateRegistry(context.Background(), spk, rv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rev, err := nextRevision(); err != nil || rev != rv.Revision {
+			t.Fatal("unexpected update", rev, err)
+		}
+		time.Sleep(modules.SubscriptionPeriod)
+	}
+
+	// There should be exactly one session.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		rsm := wt.rt.renter.staticRegistrySubscriptionManager
+		rsm.mu.Lock()
+		defer rsm.mu.Unlock()
+		if len(rsm.sessions) != 1 {
+			return fmt.Errorf("expected 1 session but got %v", len(rsm.sessions))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
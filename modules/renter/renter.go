@@ -25,6 +25,7 @@ package renter
 
 import (
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"path/filepath"
@@ -95,6 +96,10 @@ type hostContractor interface {
 	// ChurnStatus returns contract churn stats for the current period.
 	ChurnStatus() modules.ContractorChurnStatus
 
+	// ContractMaintenancePlan returns the actions the next round of contract
+	// maintenance would take with the provided allowance.
+	ContractMaintenancePlan(modules.Allowance) (modules.ContractMaintenancePlan, error)
+
 	// ContractUtility returns the utility field for a given contract, along
 	// with a bool indicating if it exists.
 	ContractUtility(types.TurtleDexPublicKey) (modules.ContractUtility, bool)
@@ -134,6 +139,14 @@ type hostContractor interface {
 	// Session creates a Session from the specified contract ID.
 	Session(types.TurtleDexPublicKey, <-chan struct{}) (contractor.Session, error)
 
+	// RebalancePolicy returns the policy for migrating data away from
+	// degraded or overpriced hosts.
+	RebalancePolicy() modules.RebalancePolicy
+
+	// RebalanceStatus returns the status of the migrations of data away from
+	// degraded or overpriced hosts.
+	RebalanceStatus() modules.RebalanceStatus
+
 	// RecoverableContracts returns the contracts that the contractor deems
 	// recoverable. That means they are not expired yet and also not part of the
 	// active contracts. Usually this should return an empty slice unless the host
@@ -151,10 +164,18 @@ type hostContractor interface {
 	// given contract with that host.
 	RenewContract(conn net.Conn, fcid types.FileContractID, params modules.ContractParams, txnBuilder modules.TransactionBuilder, tpool modules.TransactionPool, hdb modules.HostDB, pt *modules.RPCPriceTable) (modules.RenterContract, []types.Transaction, error)
 
+	// SetRebalancePolicy sets the policy for migrating data away from
+	// degraded or overpriced hosts.
+	SetRebalancePolicy(modules.RebalancePolicy) error
+
 	// Synced returns a channel that is closed when the contractor is fully
 	// synced with the peer-to-peer network.
 	Synced() <-chan struct{}
 
+	// UpdateSector takes an established stream to a host and overwrites a
+	// range of bytes within a sector of the given contract with that host.
+	UpdateSector(stream io.ReadWriter, fcid types.FileContractID, pt *modules.RPCPriceTable, pp modules.PaymentProvider, refundAccount modules.AccountID, sectorIndex, offset uint64, data []byte) (modules.RenterContract, types.Currency, error)
+
 	// UpdateWorkerPool updates the workerpool currently in use by the contractor.
 	UpdateWorkerPool(modules.WorkerPool)
 }
@@ -261,10 +282,18 @@ type Renter struct {
 	repairLog                          *persist.Logger
 	staticAccountManager               *accountManager
 	staticAlerter                      *modules.GenericAlerter
+	staticAuditor                      *auditor
+	staticDedupIndex                   *dedupIndex
 	staticFileSystem                   *filesystem.FileSystem
 	staticFuseManager                  renterFuseManager
+	staticPinManager                   *pinManager
+	staticReencoder                    *reencoder
+	staticRegistrySubscriptionManager  *registrySubscriptionManager
+	staticResumableUploads             *resumableUploads
+	staticSectorCache                  *sectorCache
 	staticSkykeyManager                *skykey.SkykeyManager
 	staticStreamBufferSet              *streamBufferSet
+	staticSyncer                       *syncer
 	tg                                 threadgroup.ThreadGroup
 	tpool                              modules.TransactionPool
 	wal                                *writeaheadlog.WAL
@@ -611,6 +640,9 @@ func (r *Renter) SetSettings(s modules.RenterSettings) error {
 	if s.MaxDownloadSpeed < 0 || s.MaxUploadSpeed < 0 {
 		return errors.New("bandwidth limits cannot be negative")
 	}
+	if _, err := modules.NewSectorCacheEvictionPolicy(string(s.SectorCache.EvictionPolicy)); err != nil {
+		return err
+	}
 
 	// Set allowance.
 	err := r.hostContractor.SetAllowance(s.Allowance)
@@ -621,15 +653,50 @@ func (r *Renter) SetSettings(s modules.RenterSettings) error {
 	// Set IPViolationsCheck
 	r.hostDB.SetIPViolationCheck(s.IPViolationCheck)
 
+	// Set the placement policy. This happens even if the policy didn't change
+	// to reload the ASN and host groups files.
+	err = r.hostDB.SetPlacementPolicy(s.PlacementPolicy)
+	if err != nil {
+		return errors.AddContext(err, "unable to set placement policy")
+	}
+
+	// Set the scoring policy if it changed.
+	scoringPolicy, err := r.hostDB.ScoringPolicy()
+	if err != nil {
+		return err
+	}
+	if !scoringPolicy.Equals(s.ScoringPolicy) {
+		err = r.hostDB.SetScoringPolicy(s.ScoringPolicy)
+		if err != nil {
+			return errors.AddContext(err, "unable to set scoring policy")
+		}
+	}
+
+	// Set the rebalance policy if it changed.
+	if !r.hostContractor.RebalancePolicy().Equals(s.RebalancePolicy) {
+		err = r.hostContractor.SetRebalancePolicy(s.RebalancePolicy)
+		if err != nil {
+			return errors.AddContext(err, "unable to set rebalance policy")
+		}
+	}
+
 	// Set the bandwidth limits.
 	err = r.setBandwidthLimits(s.MaxDownloadSpeed, s.MaxUploadSpeed)
 	if err != nil {
 		return err
 	}
+
+	// Set the sector cache settings.
+	err = r.staticSectorCache.managedSetSettings(s.SectorCache)
+	if err != nil {
+		return err
+	}
+
 	// Save the changes.
 	id := r.mu.Lock()
 	r.persist.MaxDownloadSpeed = s.MaxDownloadSpeed
 	r.persist.MaxUploadSpeed = s.MaxUploadSpeed
+	r.persist.SectorCache = r.staticSectorCache.managedSettings()
 	err = r.saveSync()
 	r.mu.Unlock(id)
 	if err != nil {
@@ -726,6 +793,11 @@ func (r *Renter) Host(spk types.TurtleDexPublicKey) (modules.HostDBEntry, bool,
 	return r.hostDB.Host(spk)
 }
 
+// HostHistory returns the time series the hostdb keeps about a host.
+func (r *Renter) HostHistory(spk types.TurtleDexPublicKey) (modules.HostHistory, bool, error) {
+	return r.hostDB.HostHistory(spk)
+}
+
 // InitialScanComplete returns a boolean indicating if the initial scan of the
 // hostdb is completed.
 func (r *Renter) InitialScanComplete() (bool, error) { return r.hostDB.InitialScanComplete() }
@@ -735,6 +807,12 @@ func (r *Renter) ScoreBreakdown(e modules.HostDBEntry) (modules.HostScoreBreakdo
 	return r.hostDB.ScoreBreakdown(e)
 }
 
+// ScoreBreakdownWithPolicy returns the score breakdown of a host with the
+// provided scoring policy.
+func (r *Renter) ScoreBreakdownWithPolicy(e modules.HostDBEntry, p modules.HostScoringPolicy) (modules.HostScoreBreakdown, error) {
+	return r.hostDB.ScoreBreakdownWithPolicy(e, p)
+}
+
 // EstimateHostScore returns the estimated host score
 func (r *Renter) EstimateHostScore(e modules.HostDBEntry, a modules.Allowance) (modules.HostScoreBreakdown, error) {
 	if reflect.DeepEqual(a, modules.Allowance{}) {
@@ -778,6 +856,26 @@ func (r *Renter) ContractorChurnStatus() modules.ContractorChurnStatus {
 	return r.hostContractor.ChurnStatus()
 }
 
+// ContractorRebalanceStatus returns the status of the migrations of data away
+// from degraded or overpriced hosts.
+func (r *Renter) ContractorRebalanceStatus() modules.RebalanceStatus {
+	return r.hostContractor.RebalanceStatus()
+}
+
+// ContractMaintenancePlan returns the actions the next round of contract
+// maintenance would take with the provided allowance without performing them.
+// The current allowance is used if the empty allowance is provided.
+func (r *Renter) ContractMaintenancePlan(allowance modules.Allowance) (modules.ContractMaintenancePlan, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.ContractMaintenancePlan{}, err
+	}
+	defer r.tg.Done()
+	if reflect.DeepEqual(allowance, modules.Allowance{}) {
+		allowance = r.hostContractor.Allowance()
+	}
+	return r.hostContractor.ContractMaintenancePlan(allowance)
+}
+
 // InitRecoveryScan starts scanning the whole blockchain for recoverable
 // contracts within a separate thread.
 func (r *Renter) InitRecoveryScan() error {
@@ -822,12 +920,24 @@ func (r *Renter) Settings() (modules.RenterSettings, error) {
 	if err != nil {
 		return modules.RenterSettings{}, errors.AddContext(err, "error getting IPViolationsCheck:")
 	}
+	placementPolicy, err := r.hostDB.PlacementPolicy()
+	if err != nil {
+		return modules.RenterSettings{}, errors.AddContext(err, "error getting PlacementPolicy:")
+	}
+	scoringPolicy, err := r.hostDB.ScoringPolicy()
+	if err != nil {
+		return modules.RenterSettings{}, errors.AddContext(err, "error getting ScoringPolicy:")
+	}
 	paused, endTime := r.uploadHeap.managedPauseStatus()
 	return modules.RenterSettings{
 		Allowance:        r.hostContractor.Allowance(),
 		IPViolationCheck: enabled,
 		MaxDownloadSpeed: download,
 		MaxUploadSpeed:   upload,
+		PlacementPolicy:  placementPolicy,
+		RebalancePolicy:  r.hostContractor.RebalancePolicy(),
+		ScoringPolicy:    scoringPolicy,
+		SectorCache:      r.staticSectorCache.managedSettings(),
 		UploadsStatus: modules.UploadsStatus{
 			Paused:       paused,
 			PauseEndTime: endTime,
@@ -1051,6 +1161,8 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 
 	r.staticFuseManager = newFuseManager(r)
 	r.stuckStack = callNewStuckStack()
+	r.staticReencoder = new(reencoder)
+	r.staticAuditor = newAuditor()
 
 	// Add SkynetBlocklist
 	sb, err := skynetblocklist.New(r.persistDir)
@@ -1072,12 +1184,52 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 		return nil, err
 	}
 
+	// Load the dedup index and save its remaining changes on shutdown.
+	r.staticDedupIndex, err = newDedupIndex(r.persistDir)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to load dedup index")
+	}
+	err = r.tg.AfterStop(r.staticDedupIndex.managedSave)
+	if err != nil {
+		return nil, err
+	}
+
+	// Load the sector cache.
+	r.staticSectorCache, err = newSectorCache(filepath.Join(r.persistDir, sectorCacheDir), r.persist.SectorCache, r.log)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to load sector cache")
+	}
+
+	// Load the skynet pins.
+	r.staticPinManager, err = newPinManager(r.persistDir)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to load skynet pins")
+	}
+
+	// Load the resumable skyfile uploads.
+	r.staticResumableUploads, err = newResumableUploads(r.persistDir)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to load resumable uploads")
+	}
+
+	// Load the sync folders.
+	r.staticSyncer, err = newSyncer(r.persistDir)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to load sync folders")
+	}
+
 	// After persist is initialized, create the worker pool.
 	r.staticWorkerPool = r.newWorkerPool()
 
 	// Set the worker pool on the contractor.
 	r.hostContractor.UpdateWorkerPool(r.staticWorkerPool)
 
+	// Create the registry subscription manager.
+	r.staticRegistrySubscriptionManager, err = newRegistrySubscriptionManager(r)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to create registry subscription manager")
+	}
+
 	// Create the skykey manager.
 	// In testing, keep the skykeys with the rest of the renter data.
 	skykeyManDir := build.SkynetDir()
@@ -1094,6 +1246,12 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 	r.managedUpdateRenterContractsAndUtilities()
 	go r.threadedUpdateRenterContractsAndUtilities()
 
+	// Kick off a thread that prunes the retained versions of files.
+	go r.threadedPruneFileVersions()
+
+	// Kick off a thread that persists the changes to the dedup index.
+	go r.threadedSaveDedupIndex()
+
 	// Spin up background threads which are not depending on the renter being
 	// up-to-date with consensus.
 	if !r.deps.Disrupt("DisableRepairAndHealthLoops") {
@@ -1137,11 +1295,19 @@ func renterAsyncStartup(r *Renter, cs modules.ConsensusSet) error {
 	if !r.deps.Disrupt("DisableRepairAndHealthLoops") {
 		go r.threadedUploadAndRepair()
 		go r.threadedStuckFileLoop()
+		go r.threadedAuditLoop()
+		go r.threadedPinLoop()
+	}
+	// Spin up a thread for every sync folder.
+	for _, sf := range r.staticSyncer.managedFolders() {
+		go r.threadedSyncFolder(sf)
 	}
 	// Spin up the snapshot synchronization thread.
 	if !r.deps.Disrupt("DisableSnapshotSync") {
 		go r.threadedSynchronizeSnapshots()
 	}
+	// Resume the resumable skyfile uploads interrupted by the last shutdown.
+	r.managedResumeResumableSkyfileUploads()
 	return nil
 }
 
@@ -0,0 +1,434 @@
+package renter
+
+// sectorcache.go implements an on-disk cache of downloaded sector data. Unlike
+// the stream buffers, which only keep data in memory while a stream is open,
+// the sector cache survives restarts which avoids fetching and paying for the
+// same data over and over again.
+//
+// Every entry contains a contiguous, segment aligned range of a decrypted
+// piece. Entries are identified by the merkle root of the piece together with
+// the key used to decrypt it. Including the key makes sure that knowing the
+// root of a sector isn't enough to obtain the plaintext of someone else's
+// download. The data of an entry is only added after the worker verified it
+// against the root and it is protected by a checksum on disk.
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/persist"
+)
+
+const (
+	// sectorCacheDir is the name of the directory within the renter's
+	// persist directory that contains the sector cache.
+	sectorCacheDir = "sectorcache"
+
+	// sectorCacheEntryOverhead is the number of bytes every entry uses on
+	// disk in addition to its data. Every entry is prefixed by its offset
+	// within the piece and followed by a checksum.
+	sectorCacheEntryOverhead = 8 + crypto.HashSize
+
+	// sectorCacheTmpSuffix is the suffix of entries which are being written.
+	sectorCacheTmpSuffix = ".tmp"
+)
+
+var (
+	// errSectorCacheCorrupt is returned when an entry of the sector cache
+	// doesn't match its checksum.
+	errSectorCacheCorrupt = errors.New("sector cache entry is corrupt")
+)
+
+type (
+	// sectorCache is the renter's on-disk cache of downloaded sector data.
+	sectorCache struct {
+		entries  map[crypto.Hash]*sectorCacheEntry
+		settings modules.SectorCacheSettings
+		size     uint64
+
+		// Stats.
+		evictions uint64
+		hitBytes  uint64
+		hits      uint64
+		misses    uint64
+
+		staticDir string
+		staticLog *persist.Logger
+		mu        sync.Mutex
+
+		// staticWriteMu serializes writing entries to disk.
+		staticWriteMu sync.Mutex
+	}
+
+	// sectorCacheEntry describes the range of a piece which is cached on disk.
+	sectorCacheEntry struct {
+		offset uint64
+		length uint64
+
+		hits       uint64
+		lastAccess time.Time
+	}
+)
+
+// newSectorCache loads the sector cache from the provided directory.
+func newSectorCache(dir string, settings modules.SectorCacheSettings, log *persist.Logger) (*sectorCache, error) {
+	sc := &sectorCache{
+		entries:   make(map[crypto.Hash]*sectorCacheEntry),
+		staticDir: dir,
+		staticLog: log,
+	}
+	if err := os.MkdirAll(dir, modules.DefaultDirPerm); err != nil {
+		return nil, errors.AddContext(err, "failed to create sector cache dir")
+	}
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to read sector cache dir")
+	}
+	for _, fi := range fis {
+		// The sector cache doesn't create directories, leave them alone.
+		if fi.IsDir() {
+			continue
+		}
+		id, entry, err := loadSectorCacheEntry(dir, fi)
+		if err != nil {
+			// Remove entries which were only partially written or can't be
+			// read. An entry which can't be removed is ignored.
+			if err := os.Remove(filepath.Join(dir, fi.Name())); err != nil {
+				sc.staticLog.Printf("WARN: failed to remove invalid sector cache entry %v: %v", fi.Name(), err)
+			}
+			continue
+		}
+		sc.entries[id] = entry
+		sc.size += entry.length + sectorCacheEntryOverhead
+	}
+	return sc, sc.managedSetSettings(settings)
+}
+
+// loadSectorCacheEntry loads the metadata of an entry from disk. The time of
+// the last access is initialized to the time the entry was written.
+func loadSectorCacheEntry(dir string, fi os.FileInfo) (crypto.Hash, *sectorCacheEntry, error) {
+	var id crypto.Hash
+	if fi.IsDir() || strings.HasSuffix(fi.Name(), sectorCacheTmpSuffix) {
+		return id, nil, errors.New("not a sector cache entry")
+	}
+	if err := id.LoadString(fi.Name()); err != nil {
+		return id, nil, err
+	}
+	if fi.Size() < sectorCacheEntryOverhead {
+		return id, nil, errSectorCacheCorrupt
+	}
+	f, err := os.Open(filepath.Join(dir, fi.Name()))
+	if err != nil {
+		return id, nil, err
+	}
+	defer f.Close()
+	var offset [8]byte
+	if _, err := f.Read(offset[:]); err != nil {
+		return id, nil, err
+	}
+	return id, &sectorCacheEntry{
+		offset:     binary.LittleEndian.Uint64(offset[:]),
+		length:     uint64(fi.Size()) - sectorCacheEntryOverhead,
+		lastAccess: fi.ModTime(),
+	}, nil
+}
+
+// sectorCacheID returns the id of the entry for the piece with the provided
+// root which is decrypted using the provided key.
+func sectorCacheID(root crypto.Hash, key crypto.CipherKey) crypto.Hash {
+	return crypto.HashAll(root, key.Type(), key.Key())
+}
+
+// covers returns whether the entry contains the provided range.
+func (e *sectorCacheEntry) covers(offset, length uint64) bool {
+	return offset >= e.offset && offset+length <= e.offset+e.length
+}
+
+// path returns the path of the entry with the provided id.
+func (sc *sectorCache) path(id crypto.Hash) string {
+	return filepath.Join(sc.staticDir, hex.EncodeToString(id[:]))
+}
+
+// evict evicts entries according to the eviction policy until the cache is
+// within its maximum size. The entry with the id 'keep' is never evicted to
+// allow new entries to be added to a full cache.
+func (sc *sectorCache) evict(keep crypto.Hash) {
+	if sc.size <= sc.settings.MaxSize {
+		return
+	}
+	ids := make([]crypto.Hash, 0, len(sc.entries))
+	for id := range sc.entries {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		ei, ej := sc.entries[ids[i]], sc.entries[ids[j]]
+		if sc.settings.EvictionPolicy == modules.SectorCacheEvictLFU && ei.hits != ej.hits {
+			return ei.hits < ej.hits
+		}
+		return ei.lastAccess.Before(ej.lastAccess)
+	})
+	for _, id := range ids {
+		if sc.size <= sc.settings.MaxSize {
+			break
+		}
+		if id == keep {
+			continue
+		}
+		sc.remove(id)
+		sc.evictions++
+	}
+}
+
+// remove removes an entry from the cache.
+func (sc *sectorCache) remove(id crypto.Hash) {
+	entry, exists := sc.entries[id]
+	if !exists {
+		return
+	}
+	delete(sc.entries, id)
+	sc.size -= entry.length + sectorCacheEntryOverhead
+	// The entry might already be gone from disk. Either way, it is no longer
+	// used.
+	_ = os.Remove(sc.path(id))
+}
+
+// managedAdd adds the data at the provided offset of a piece to the cache. If
+// the piece is already cached, the new data is merged with the cached data if
+// the ranges overlap. Otherwise the larger range is kept.
+func (sc *sectorCache) managedAdd(id crypto.Hash, offset uint64, data []byte) error {
+	sc.staticWriteMu.Lock()
+	defer sc.staticWriteMu.Unlock()
+
+	sc.mu.Lock()
+	if !sc.settings.Enabled() || uint64(len(data))+sectorCacheEntryOverhead > sc.settings.MaxSize {
+		sc.mu.Unlock()
+		return nil
+	}
+	length := uint64(len(data))
+	var existing sectorCacheEntry
+	entry, exists := sc.entries[id]
+	if exists {
+		existing = *entry
+	}
+	sc.mu.Unlock()
+
+	// Merge the data with the existing entry if possible.
+	if exists && existing.covers(offset, length) {
+		return nil
+	}
+	overlaps := exists && offset <= existing.offset+existing.length && existing.offset <= offset+length
+	if exists && overlaps {
+		existingData, err := sc.readEntry(id, existing)
+		if err != nil {
+			sc.mu.Lock()
+			sc.remove(id)
+			sc.mu.Unlock()
+			return err
+		}
+		start := offset
+		if existing.offset < start {
+			start = existing.offset
+		}
+		end := offset + length
+		if existing.offset+existing.length > end {
+			end = existing.offset + existing.length
+		}
+		merged := make([]byte, end-start)
+		copy(merged[existing.offset-start:], existingData)
+		copy(merged[offset-start:], data)
+		offset, data, length = start, merged, end-start
+	} else if exists && existing.length >= length {
+		return nil
+	}
+
+	// Write the entry to disk.
+	buf := make([]byte, 8, length+sectorCacheEntryOverhead)
+	binary.LittleEndian.PutUint64(buf, offset)
+	buf = append(buf, data...)
+	checksum := crypto.HashBytes(buf)
+	buf = append(buf, checksum[:]...)
+	path := sc.path(id)
+	if err := ioutil.WriteFile(path+sectorCacheTmpSuffix, buf, modules.DefaultFilePerm); err != nil {
+		return errors.AddContext(err, "failed to write sector cache entry")
+	}
+	if err := os.Rename(path+sectorCacheTmpSuffix, path); err != nil {
+		return errors.AddContext(err, "failed to rename sector cache entry")
+	}
+
+	// Update the index.
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if old, exists := sc.entries[id]; exists {
+		sc.size -= old.length + sectorCacheEntryOverhead
+		existing.hits = old.hits
+	}
+	sc.entries[id] = &sectorCacheEntry{
+		offset:     offset,
+		length:     length,
+		hits:       existing.hits,
+		lastAccess: time.Now(),
+	}
+	sc.size += length + sectorCacheEntryOverhead
+	sc.evict(id)
+	return nil
+}
+
+// managedEnabled returns whether the cache is enabled. A nil cache is never
+// enabled.
+func (sc *sectorCache) managedEnabled() bool {
+	if sc == nil {
+		return false
+	}
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.settings.Enabled()
+}
+
+// managedGetPiece returns the range of the piece with the provided root and
+// key if it is cached and updates the stats of the cache accordingly.
+func (sc *sectorCache) managedGetPiece(root crypto.Hash, key crypto.CipherKey, offset, length uint64) ([]byte, bool) {
+	if !sc.managedEnabled() {
+		return nil, false
+	}
+	data, cached := sc.managedGet(sectorCacheID(root, key), offset, length)
+	sc.managedRecordRead(cached, length)
+	return data, cached
+}
+
+// managedGet returns the data of a piece within the provided range if it is
+// cached.
+func (sc *sectorCache) managedGet(id crypto.Hash, offset, length uint64) ([]byte, bool) {
+	sc.mu.Lock()
+	entry, exists := sc.entries[id]
+	if !exists || !entry.covers(offset, length) {
+		sc.mu.Unlock()
+		return nil, false
+	}
+	e := *entry
+	sc.mu.Unlock()
+
+	data, err := sc.readEntry(id, e)
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if err != nil {
+		// Only remove the entry if it wasn't replaced in the meantime.
+		if current, exists := sc.entries[id]; exists && current.offset == e.offset && current.length == e.length {
+			sc.remove(id)
+		}
+		return nil, false
+	}
+	if current, exists := sc.entries[id]; exists {
+		current.hits++
+		current.lastAccess = time.Now()
+	}
+	return data[offset-e.offset:][:length], true
+}
+
+// managedRecordRead updates the hit and miss stats of the cache.
+func (sc *sectorCache) managedRecordRead(hit bool, n uint64) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if hit {
+		sc.hits++
+		sc.hitBytes += n
+	} else {
+		sc.misses++
+	}
+}
+
+// managedSetSettings updates the settings of the cache and evicts entries if
+// the cache exceeds its new maximum size.
+func (sc *sectorCache) managedSetSettings(settings modules.SectorCacheSettings) error {
+	policy, err := modules.NewSectorCacheEvictionPolicy(string(settings.EvictionPolicy))
+	if err != nil {
+		return err
+	}
+	settings.EvictionPolicy = policy
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.settings = settings
+	sc.evict(crypto.Hash{})
+	return nil
+}
+
+// managedSettings returns the settings of the cache.
+func (sc *sectorCache) managedSettings() modules.SectorCacheSettings {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.settings
+}
+
+// managedStatus returns the status of the cache.
+func (sc *sectorCache) managedStatus() modules.SectorCacheStatus {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return modules.SectorCacheStatus{
+		SectorCacheSettings: sc.settings,
+
+		Entries:   uint64(len(sc.entries)),
+		Size:      sc.size,
+		Hits:      sc.hits,
+		Misses:    sc.misses,
+		HitBytes:  sc.hitBytes,
+		Evictions: sc.evictions,
+	}
+}
+
+// readEntry reads the data of an entry from disk and verifies its checksum.
+func (sc *sectorCache) readEntry(id crypto.Hash, entry sectorCacheEntry) ([]byte, error) {
+	buf, err := ioutil.ReadFile(sc.path(id))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(buf)) != entry.length+sectorCacheEntryOverhead {
+		return nil, errSectorCacheCorrupt
+	}
+	content, checksum := buf[:len(buf)-crypto.HashSize], buf[len(buf)-crypto.HashSize:]
+	if h := crypto.HashBytes(content); !bytes.Equal(h[:], checksum) {
+		return nil, errSectorCacheCorrupt
+	}
+	if binary.LittleEndian.Uint64(content[:8]) != entry.offset {
+		return nil, errSectorCacheCorrupt
+	}
+	return content[8:], nil
+}
+
+// callAddToSectorCache adds the range of a downloaded and decrypted piece to
+// the sector cache in a background thread.
+func (r *Renter) callAddToSectorCache(root crypto.Hash, key crypto.CipherKey, offset uint64, data []byte) {
+	sc := r.staticSectorCache
+	if !sc.managedEnabled() {
+		return
+	}
+	id := sectorCacheID(root, key)
+	data = append([]byte(nil), data...)
+	err := r.tg.Launch(func() {
+		if err := sc.managedAdd(id, offset, data); err != nil {
+			r.log.Println("Unable to add piece to the sector cache:", err)
+		}
+	})
+	if err != nil {
+		r.log.Debugln("Unable to launch thread to add piece to the sector cache:", err)
+	}
+}
+
+// SectorCacheStatus returns the status of the renter's sector cache.
+func (r *Renter) SectorCacheStatus() (modules.SectorCacheStatus, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.SectorCacheStatus{}, err
+	}
+	defer r.tg.Done()
+	return r.staticSectorCache.managedStatus(), nil
+}
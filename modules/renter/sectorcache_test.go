@@ -0,0 +1,203 @@
+package renter
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/turtledex/fastrand"
+
+	"github.com/turtledex/TurtleDexCore/build"
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/persist"
+)
+
+// newTestSectorCache creates a sector cache in a temporary directory.
+func newTestSectorCache(t *testing.T, settings modules.SectorCacheSettings) *sectorCache {
+	dir := build.TempDir("renter", t.Name())
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dir, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	log, err := persist.NewLogger(ioutil.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc, err := newSectorCache(dir, settings, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sc
+}
+
+// TestSectorCache tests adding, merging, loading and reading entries of the
+// sector cache.
+func TestSectorCache(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	sc := newTestSectorCache(t, modules.SectorCacheSettings{MaxSize: modules.SectorSize})
+	piece := fastrand.Bytes(int(10 * crypto.SegmentSize))
+	var root crypto.Hash
+	fastrand.Read(root[:])
+	key := crypto.GenerateTurtleDexKey(crypto.TypeDefaultRenter)
+	id := sectorCacheID(root, key)
+
+	// The id depends on the key.
+	if id == sectorCacheID(root, crypto.GenerateTurtleDexKey(crypto.TypeDefaultRenter)) {
+		t.Fatal("ids of different keys match")
+	}
+
+	// Add the middle of the piece.
+	if err := sc.managedAdd(id, 2*crypto.SegmentSize, piece[2*crypto.SegmentSize:5*crypto.SegmentSize]); err != nil {
+		t.Fatal(err)
+	}
+	if data, cached := sc.managedGet(id, 3*crypto.SegmentSize, crypto.SegmentSize); !cached || !bytes.Equal(data, piece[3*crypto.SegmentSize:4*crypto.SegmentSize]) {
+		t.Fatal("wrong data", cached)
+	}
+	if _, cached := sc.managedGet(id, 0, 3*crypto.SegmentSize); cached {
+		t.Fatal("range shouldn't be cached")
+	}
+
+	// Add an overlapping range at the beginning of the piece. The ranges
+	// should be merged.
+	if err := sc.managedAdd(id, 0, piece[:3*crypto.SegmentSize]); err != nil {
+		t.Fatal(err)
+	}
+	if data, cached := sc.managedGet(id, 0, 5*crypto.SegmentSize); !cached || !bytes.Equal(data, piece[:5*crypto.SegmentSize]) {
+		t.Fatal("wrong data", cached)
+	}
+
+	// Add a smaller, disjoint range. It should be ignored.
+	if err := sc.managedAdd(id, 8*crypto.SegmentSize, piece[8*crypto.SegmentSize:]); err != nil {
+		t.Fatal(err)
+	}
+	if _, cached := sc.managedGet(id, 8*crypto.SegmentSize, crypto.SegmentSize); cached {
+		t.Fatal("smaller range shouldn't replace the cached range")
+	}
+	status := sc.managedStatus()
+	if status.Entries != 1 || status.Size != 5*crypto.SegmentSize+sectorCacheEntryOverhead {
+		t.Fatal("wrong status", status)
+	}
+
+	// Reload the cache. Directories within the cache directory, which the
+	// cache doesn't create itself, are ignored.
+	subDir := filepath.Join(sc.staticDir, "subdir")
+	if err := os.MkdirAll(subDir, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(subDir, "file"), []byte{1}, modules.DefaultFilePerm); err != nil {
+		t.Fatal(err)
+	}
+	sc, err := newSectorCache(sc.staticDir, sc.managedSettings(), sc.staticLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, cached := sc.managedGet(id, 0, 5*crypto.SegmentSize); !cached || !bytes.Equal(data, piece[:5*crypto.SegmentSize]) {
+		t.Fatal("wrong data after reload", cached)
+	}
+	if _, err := os.Stat(filepath.Join(subDir, "file")); err != nil {
+		t.Fatal("directory was modified", err)
+	}
+
+	// Corrupt the entry on disk. It should be removed on the next read.
+	buf, err := ioutil.ReadFile(sc.path(id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf[10]++
+	if err := ioutil.WriteFile(sc.path(id), buf, modules.DefaultFilePerm); err != nil {
+		t.Fatal(err)
+	}
+	if _, cached := sc.managedGet(id, 0, crypto.SegmentSize); cached {
+		t.Fatal("corrupt entry shouldn't be returned")
+	}
+	if status := sc.managedStatus(); status.Entries != 0 || status.Size != 0 {
+		t.Fatal("corrupt entry should have been removed", status)
+	}
+
+	// Disabling the cache should remove all entries.
+	if err := sc.managedAdd(id, 0, piece); err != nil {
+		t.Fatal(err)
+	}
+	if err := sc.managedSetSettings(modules.SectorCacheSettings{}); err != nil {
+		t.Fatal(err)
+	}
+	if status := sc.managedStatus(); status.Entries != 0 || status.Size != 0 {
+		t.Fatal("cache should be empty", status)
+	}
+	if _, err := os.Stat(sc.path(id)); !os.IsNotExist(err) {
+		t.Fatal("entry should have been removed from disk", err)
+	}
+	if err := sc.managedAdd(id, 0, piece); err != nil {
+		t.Fatal(err)
+	}
+	if sc.managedStatus().Entries != 0 {
+		t.Fatal("disabled cache shouldn't add entries")
+	}
+}
+
+// TestSectorCacheEviction tests the eviction policies of the sector cache.
+func TestSectorCacheEviction(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	tests := []struct {
+		policy  modules.SectorCacheEvictionPolicy
+		evicted int
+	}{
+		{modules.SectorCacheEvictLRU, 0},
+		{modules.SectorCacheEvictLFU, 1},
+	}
+	for _, test := range tests {
+		t.Run(string(test.policy), func(t *testing.T) {
+			// Create a cache which fits 3 entries.
+			size := uint64(crypto.SegmentSize)
+			sc := newTestSectorCache(t, modules.SectorCacheSettings{
+				MaxSize:        3 * (size + sectorCacheEntryOverhead),
+				EvictionPolicy: test.policy,
+			})
+			ids := make([]crypto.Hash, 4)
+			for i := range ids {
+				fastrand.Read(ids[i][:])
+			}
+			for _, id := range ids[:3] {
+				if err := sc.managedAdd(id, 0, fastrand.Bytes(int(size))); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// Access the first entry twice and the others once. The first
+			// entry is the least recently used and the second entry is the
+			// least frequently used.
+			for _, i := range []int{0, 0, 1, 2} {
+				if _, cached := sc.managedGet(ids[i], 0, size); !cached {
+					t.Fatal("entry should be cached", i)
+				}
+			}
+
+			// Adding another entry should evict one entry.
+			if err := sc.managedAdd(ids[3], 0, fastrand.Bytes(int(size))); err != nil {
+				t.Fatal(err)
+			}
+			for i, id := range ids {
+				_, cached := sc.managedGet(id, 0, size)
+				if cached == (i == test.evicted) {
+					t.Fatalf("entry %v: expected cached to be %v", i, i != test.evicted)
+				}
+			}
+			if status := sc.managedStatus(); status.Evictions != 1 || status.Entries != 3 {
+				t.Fatal("wrong status", status)
+			}
+		})
+	}
+}
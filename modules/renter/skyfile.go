@@ -62,6 +62,10 @@ var (
 	// not supported for a Skynet action.
 	ErrEncryptionNotSupported = errors.New("skykey encryption not supported")
 
+	// ErrCompressionNotSupported is the error returned when compression is not
+	// supported for a Skynet action.
+	ErrCompressionNotSupported = errors.New("compression not supported")
+
 	// ErrInvalidMetadata is the error returned when the metadata is not valid.
 	ErrInvalidMetadata = errors.New("metadata is invalid")
 
@@ -157,11 +161,18 @@ func (r *Renter) CreateSkylinkFromTurtleDexfile(sup modules.SkyfileUploadParamet
 		err = errors.Compose(err, fileNode.Close())
 	}()
 
+	// The data of compressed siafiles is stored in a format that skyfiles
+	// don't support.
+	if fileNode.Compression() != modules.CompressionNone {
+		return modules.Skylink{}, ErrCompressionNotSupported
+	}
+
 	// Override the metadata with the info from the fileNode.
 	metadata := modules.SkyfileMetadata{
 		Filename: siaPath.Name(),
 		Mode:     fileNode.Mode(),
 		Length:   fileNode.Size(),
+		Headers:  sup.Headers,
 	}
 	return r.managedCreateSkylinkFromFileNode(sup, metadata, fileNode, nil)
 }
@@ -181,7 +192,7 @@ func (r *Renter) managedCreateSkylinkFromFileNode(sup modules.SkyfileUploadParam
 	// Check if any of the skylinks associated with the siafile are blocked
 	if r.isFileNodeBlocked(fileNode) {
 		// Skylink is blocked, return error and try and delete file
-		return modules.Skylink{}, errors.Compose(ErrSkylinkBlocked, r.DeleteFile(sup.TurtleDexPath))
+		return modules.Skylink{}, errors.Compose(ErrSkylinkBlocked, r.managedDeleteFile(sup.TurtleDexPath))
 	}
 
 	// Check that the encryption key and erasure code is compatible with the
@@ -226,6 +237,9 @@ func (r *Renter) managedCreateSkylinkFromFileNode(sup modules.SkyfileUploadParam
 	// If we're uploading in plaintext, we put the key in the baseSector
 	if !encryptionEnabled(&sup) {
 		copy(sl.KeyData[:], masterKey.Key())
+	} else {
+		// Skyfiles encrypted for recipients put their key data there instead.
+		copy(sl.KeyData[:], sup.FileSpecificKeyData)
 	}
 
 	// Create the base sector.
@@ -252,7 +266,7 @@ func (r *Renter) managedCreateSkylinkFromFileNode(sup modules.SkyfileUploadParam
 	// Check if the new skylink is blocked
 	if r.staticSkynetBlocklist.IsBlocked(skylink) {
 		// Skylink is blocked, return error and try and delete file
-		return modules.Skylink{}, errors.Compose(ErrSkylinkBlocked, r.DeleteFile(sup.TurtleDexPath))
+		return modules.Skylink{}, errors.Compose(ErrSkylinkBlocked, r.managedDeleteFile(sup.TurtleDexPath))
 	}
 
 	// Add the skylink to the siafiles.
@@ -456,6 +470,8 @@ func (r *Renter) managedUploadSkyfileSmallFile(sup modules.SkyfileUploadParamete
 		// If encryption is set in the upload params, this will be overwritten.
 		CipherType: crypto.TypePlain,
 	}
+	// Skyfiles encrypted for recipients store their key data in the layout.
+	copy(sl.KeyData[:], sup.FileSpecificKeyData)
 
 	// Create the base sector. This is done as late as possible so that any
 	// errors are caught before a large block of memory is allocated.
@@ -594,12 +610,28 @@ func (r *Renter) DownloadSkylink(link modules.Skylink, timeout time.Duration, pr
 		return modules.SkyfileLayout{}, modules.SkyfileMetadata{}, nil, ErrSkylinkBlocked
 	}
 
+	// Resolve the link in case it's a v2 skylink.
+	link, err := r.managedResolveSkylinkV2(link, timeout)
+	if err != nil {
+		return modules.SkyfileLayout{}, modules.SkyfileMetadata{}, nil, err
+	}
+
 	// Download the data
 	layout, metadata, streamer, err := r.managedDownloadSkylink(link, timeout, pricePerMS)
 	if errors.Contains(err, ErrProjectTimedOut) {
 		err = errors.AddContext(err, fmt.Sprintf("timed out after %vs", timeout.Seconds()))
 	}
-	return layout, metadata, streamer, err
+	if err != nil {
+		return layout, metadata, streamer, err
+	}
+
+	// Decompress the data if the skyfile is compressed.
+	ds, err := modules.NewDecompressionStreamer(metadata.Compression, streamer)
+	if err != nil {
+		err = errors.AddContext(err, "unable to decompress skyfile")
+		return modules.SkyfileLayout{}, modules.SkyfileMetadata{}, nil, errors.Compose(err, streamer.Close())
+	}
+	return layout, metadata, ds, nil
 }
 
 // DownloadSkylinkBaseSector will take a link and turn it into the data of
@@ -615,6 +647,12 @@ func (r *Renter) DownloadSkylinkBaseSector(link modules.Skylink, timeout time.Du
 		return nil, ErrSkylinkBlocked
 	}
 
+	// Resolve the link in case it's a v2 skylink.
+	link, err := r.managedResolveSkylinkV2(link, timeout)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create the context
 	ctx := r.tg.StopCtx()
 	if timeout > 0 {
@@ -634,6 +672,51 @@ func (r *Renter) DownloadSkylinkBaseSector(link modules.Skylink, timeout time.Du
 	return StreamerFromSlice(baseSector), err
 }
 
+// ResolveSkylinkV2 resolves a v2 skylink to the v1 skylink stored in the
+// registry entry it points to. A v1 skylink is returned unchanged.
+func (r *Renter) ResolveSkylinkV2(link modules.Skylink, timeout time.Duration) (modules.Skylink, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.Skylink{}, err
+	}
+	defer r.tg.Done()
+
+	// Check if link is blocked
+	if r.staticSkynetBlocklist.IsBlocked(link) {
+		return modules.Skylink{}, ErrSkylinkBlocked
+	}
+	return r.managedResolveSkylinkV2(link, timeout)
+}
+
+// managedResolveSkylinkV2 resolves a v2 skylink by looking up the registry
+// entry it points to. The resolved skylink is checked against the blocklist.
+// A v1 skylink is returned unchanged.
+func (r *Renter) managedResolveSkylinkV2(link modules.Skylink, timeout time.Duration) (modules.Skylink, error) {
+	if !link.IsSkylinkV2() {
+		return link, nil
+	}
+
+	// Look up the registry entry.
+	srv, err := r.ReadRegistryEID(link.RegistryEntryID(), timeout)
+	if errors.Contains(err, ErrRegistryEntryNotFound) {
+		return modules.Skylink{}, errors.Compose(err, ErrRootNotFound)
+	}
+	if err != nil {
+		return modules.Skylink{}, errors.AddContext(err, "failed to read registry entry of v2 skylink")
+	}
+
+	// Parse the v1 skylink from the entry.
+	resolved, err := modules.NewSkylinkV2FromRegistryValue(srv.RegistryValue)
+	if err != nil {
+		return modules.Skylink{}, errors.AddContext(err, "failed to resolve v2 skylink")
+	}
+
+	// Check if the resolved link is blocked.
+	if r.staticSkynetBlocklist.IsBlocked(resolved) {
+		return modules.Skylink{}, ErrSkylinkBlocked
+	}
+	return resolved, nil
+}
+
 // managedDownloadSkylink will take a link and turn it into the metadata and
 // data of a download.
 func (r *Renter) managedDownloadSkylink(link modules.Skylink, timeout time.Duration, pricePerMS types.Currency) (modules.SkyfileLayout, modules.SkyfileMetadata, modules.Streamer, error) {
@@ -671,6 +754,12 @@ func (r *Renter) PinSkylink(skylink modules.Skylink, lup modules.SkyfileUploadPa
 		return ErrSkylinkBlocked
 	}
 
+	// Pinning a v2 skylink pins the content it currently points to.
+	skylink, err := r.managedResolveSkylinkV2(skylink, timeout)
+	if err != nil {
+		return err
+	}
+
 	// Fetch the leading chunk.
 	baseSector, err := r.DownloadByRoot(skylink.MerkleRoot(), 0, modules.SectorSize, timeout, pricePerMS)
 	if err != nil {
@@ -826,6 +915,11 @@ func (r *Renter) RestoreSkyfile(reader io.Reader) (modules.Skylink, error) {
 		// Set the default path params
 		DefaultPath:        sm.DefaultPath,
 		DisableDefaultPath: sm.DisableDefaultPath,
+
+		// Set the serving params
+		Headers:      sm.Headers,
+		TryFiles:     sm.TryFiles,
+		NotFoundPath: sm.NotFoundPath,
 	}
 	skyfileEstablishDefaults(&sup)
 
@@ -864,6 +958,13 @@ func (r *Renter) RestoreSkyfile(reader io.Reader) (modules.Skylink, error) {
 		restoreReader = modules.NewSkyfileMultipartReader(multiReader, multiReaderFanout, sup)
 	}
 
+	// The backup contains the decompressed data of compressed skyfiles. It
+	// needs to be compressed again to restore the same skylink.
+	restoreReader, err = modules.NewCompressedSkyfileReader(restoreReader, sm.Compression)
+	if err != nil {
+		return modules.Skylink{}, errors.AddContext(err, "unable to create compressed reader")
+	}
+
 	// Upload the Base Sector of the skyfile
 	err = r.managedUploadBaseSector(sup, baseSector, skylink)
 	if err != nil {
@@ -923,14 +1024,14 @@ func (r *Renter) RestoreSkyfile(reader io.Reader) (modules.Skylink, error) {
 	// Check if any of the skylinks associated with the siafile are blocked
 	if r.isFileNodeBlocked(fileNode) {
 		// Skylink is blocked, return error and try and delete file
-		return modules.Skylink{}, errors.Compose(ErrSkylinkBlocked, r.DeleteFile(sup.TurtleDexPath))
+		return modules.Skylink{}, errors.Compose(ErrSkylinkBlocked, r.managedDeleteFile(sup.TurtleDexPath))
 	}
 
 	// Add the skylink to the siafiles.
 	err = fileNode.AddSkylink(skylink)
 	if err != nil {
 		err = errors.AddContext(err, "unable to add skylink to the sianodes")
-		return modules.Skylink{}, errors.Compose(err, r.DeleteFile(sup.TurtleDexPath))
+		return modules.Skylink{}, errors.Compose(err, r.managedDeleteFile(sup.TurtleDexPath))
 	}
 
 	return skylink, nil
@@ -955,18 +1056,24 @@ func (r *Renter) UploadSkyfile(sup modules.SkyfileUploadParameters, reader modul
 	// attempt or after a dry run
 	defer func() {
 		if err != nil || sup.DryRun {
-			if err := r.DeleteFile(sup.TurtleDexPath); err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+			if err := r.managedDeleteFile(sup.TurtleDexPath); err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
 				r.log.Printf("error deleting siafile after upload error: %v", err)
 			}
 
 			extendedPath := sup.TurtleDexPath.String() + modules.ExtendedSuffix
 			extendedTurtleDexPath, _ := modules.NewTurtleDexPath(extendedPath)
-			if err := r.DeleteFile(extendedTurtleDexPath); err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+			if err := r.managedDeleteFile(extendedTurtleDexPath); err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
 				r.log.Printf("error deleting extended siafile after upload error: %v\n", err)
 			}
 		}
 	}()
 
+	// Compress the data if requested.
+	reader, err = modules.NewCompressedSkyfileReader(reader, sup.Compression)
+	if err != nil {
+		return modules.Skylink{}, errors.AddContext(err, "unable to upload skyfile")
+	}
+
 	// Upload the skyfile
 	skylink, err = r.managedUploadSkyfile(sup, reader)
 	if err != nil {
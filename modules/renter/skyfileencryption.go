@@ -14,7 +14,13 @@ import (
 	"github.com/aead/chacha20/chacha"
 )
 
-var errNoSkykeyMatchesSkyfileEncryptionID = errors.New("Unable to find matching skykey for public ID encryption")
+var (
+	errNoSkykeyMatchesSkyfileEncryptionID = errors.New("Unable to find matching skykey for public ID encryption")
+
+	// errRecipientsWithSkykey is returned when an upload specifies both
+	// recipients and a skykey to encrypt the skyfile with.
+	errRecipientsWithSkykey = errors.New("skykey recipients can't be combined with a skykey name or id")
+)
 
 // deriveFanoutKey returns the crypto.CipherKey that should be used for
 // decrypting the fanout stream from the skyfile stored using this layout.
@@ -40,6 +46,26 @@ func (r *Renter) checkSkyfileEncryptionIDMatch(encryptionIdentifier []byte, nonc
 	return skykey.Skykey{}, errNoSkykeyMatchesSkyfileEncryptionID
 }
 
+// checkSkyfileRecipientMatch tries to find a recipient Skykey the skyfile was
+// encrypted for and returns the file-specific skykey of the skyfile. It returns
+// an error if it is not found.
+func (r *Renter) checkSkyfileRecipientMatch(baseSector []byte) (skykey.Skykey, error) {
+	allSkykeys := r.staticSkykeyManager.Skykeys()
+	for _, sk := range allSkykeys {
+		if sk.Type != skykey.TypeRecipient {
+			continue
+		}
+		fileSkykey, err := modules.RecipientFileSkykey(baseSector, sk)
+		if err == nil {
+			return fileSkykey, nil
+		}
+		if !errors.Contains(err, modules.ErrSkyfileNotForRecipient) {
+			r.log.Debugln("Skykey recipient match err", err)
+		}
+	}
+	return skykey.Skykey{}, errNoSkykeyMatchesSkyfileEncryptionID
+}
+
 // decryptBaseSector attempts to decrypt the baseSector. If it has the necessary
 // Skykey, it will decrypt the baseSector in-place. It returns the file-specific
 // skykey to be used for decrypting the rest of the associated skyfile.
@@ -74,14 +100,23 @@ func (r *Renter) decryptBaseSector(baseSector []byte) (skykey.Skykey, error) {
 	if errors.Contains(err, skykey.ErrNoSkykeysWithThatID) {
 		masterSkykey, err = r.checkSkyfileEncryptionIDMatch(keyID[:], nonce)
 	}
-	if err != nil {
-		return skykey.Skykey{}, errors.AddContext(err, "Unable to find associated skykey")
-	}
 
-	// Derive the file-specific key.
-	fileSkykey, err := masterSkykey.SubkeyWithNonce(nonce)
-	if err != nil {
-		return skykey.Skykey{}, errors.AddContext(err, "Unable to derive file-specific subkey")
+	// If there is still no match, the skyfile might have been encrypted for
+	// one of the recipient skykeys, which recovers the file-specific key
+	// directly. Otherwise derive the file-specific key.
+	var fileSkykey skykey.Skykey
+	if errors.Contains(err, errNoSkykeyMatchesSkyfileEncryptionID) {
+		fileSkykey, err = r.checkSkyfileRecipientMatch(baseSector)
+		if err != nil {
+			return skykey.Skykey{}, errors.AddContext(err, "Unable to find associated skykey")
+		}
+	} else if err != nil {
+		return skykey.Skykey{}, errors.AddContext(err, "Unable to find associated skykey")
+	} else {
+		fileSkykey, err = masterSkykey.SubkeyWithNonce(nonce)
+		if err != nil {
+			return skykey.Skykey{}, errors.AddContext(err, "Unable to derive file-specific subkey")
+		}
 	}
 
 	// Derive the base sector subkey and use it to decrypt the base sector.
@@ -161,6 +196,13 @@ func encryptBaseSectorWithSkykey(baseSector []byte, plaintextLayout modules.Skyf
 		}
 		copy(encryptedLayout.KeyData[:skykey.SkykeyIDLen], encryptedIdentifier[:])
 
+	// Skyfiles encrypted for recipients store the key data which allows the
+	// recipients to recover the file-specific key instead of a nonce.
+	case skykey.TypeRecipient:
+		copy(encryptedLayout.KeyData[:], plaintextLayout.KeyData[:])
+		copy(baseSector[:modules.SkyfileLayoutSize], encryptedLayout.Encode())
+		return nil
+
 	default:
 		build.Critical("No encryption implemented for this skykey type")
 		return errors.AddContext(errors.New("No encryption implemented for skykey type"), string(sk.Type))
@@ -177,9 +219,9 @@ func encryptBaseSectorWithSkykey(baseSector []byte, plaintextLayout modules.Skyf
 
 // encryptionEnabled checks if encryption is enabled for the
 // SkyfileUploadParameters. It returns true if either the SkykeyName or SkykeyID
-// is set
+// is set or if there are SkykeyRecipients.
 func encryptionEnabled(sup *modules.SkyfileUploadParameters) bool {
-	return sup.SkykeyName != "" || sup.SkykeyID != skykey.SkykeyID{}
+	return sup.SkykeyName != "" || sup.SkykeyID != skykey.SkykeyID{} || len(sup.SkykeyRecipients) > 0
 }
 
 // generateCipherKey generates a Cipher Key for the FileUploadParams from the
@@ -207,6 +249,14 @@ func (r *Renter) generateFilekey(sup *modules.SkyfileUploadParameters, nonce []b
 		return nil
 	}
 
+	// Skyfiles encrypted for recipients don't use a local skykey.
+	if len(sup.SkykeyRecipients) > 0 {
+		if sup.SkykeyName != "" || sup.SkykeyID != (skykey.SkykeyID{}) {
+			return errRecipientsWithSkykey
+		}
+		return generateRecipientFilekey(sup, sup.SkykeyRecipients)
+	}
+
 	// Get the Key
 	var key skykey.Skykey
 	var err error
@@ -219,6 +269,19 @@ func (r *Renter) generateFilekey(sup *modules.SkyfileUploadParameters, nonce []b
 		return errors.AddContext(err, "unable to get skykey")
 	}
 
+	// Recipient skykeys encrypt the skyfile for their own public key. They
+	// can't derive a file-specific key from a nonce.
+	if key.Type == skykey.TypeRecipient {
+		if len(nonce) != 0 {
+			return errors.New("skyfiles can't be converted using a recipient skykey")
+		}
+		pk, err := key.RecipientPublicKey()
+		if err != nil {
+			return errors.AddContext(err, "unable to get recipient public key")
+		}
+		return generateRecipientFilekey(sup, []skykey.RecipientPublicKey{pk})
+	}
+
 	// Generate the Subkey
 	if len(nonce) == 0 {
 		sup.FileSpecificSkykey, err = key.GenerateFileSpecificSubkey()
@@ -230,3 +293,16 @@ func (r *Renter) generateFilekey(sup *modules.SkyfileUploadParameters, nonce []b
 	}
 	return nil
 }
+
+// generateRecipientFilekey generates the FileSpecificSkykey and the
+// FileSpecificKeyData of a skyfile encrypted for the recipients and sets them
+// in the SkyfileUploadParameters.
+func generateRecipientFilekey(sup *modules.SkyfileUploadParameters, recipients []skykey.RecipientPublicKey) error {
+	fileSkykey, keyData, err := skykey.GenerateRecipientSubkey(recipients)
+	if err != nil {
+		return errors.AddContext(err, "unable to generate recipient subkey")
+	}
+	sup.FileSpecificSkykey = fileSkykey
+	sup.FileSpecificKeyData = keyData
+	return nil
+}
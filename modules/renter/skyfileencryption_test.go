@@ -396,3 +396,124 @@ func TestBaseSectorKeyID(t *testing.T) {
 		t.Fatal("Expected to find the skyfile encryption ID")
 	}
 }
+
+// TestSkyfileBaseSectorRecipientEncryption tests encrypting base sectors for
+// recipient skykeys and decrypting them.
+func TestSkyfileBaseSectorRecipientEncryption(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := rt.renter
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Create two local recipient skykeys and a recipient which is unknown to
+	// the renter. Skyfiles are only encrypted for the second skykey, so the
+	// first one needs to be rejected.
+	if _, err := r.CreateSkykey(t.Name()+"-first", skykey.TypeRecipient); err != nil {
+		t.Fatal(err)
+	}
+	sk, err := r.CreateSkykey(t.Name(), skykey.TypeRecipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, err := sk.RecipientPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherPK := skykey.RecipientPublicKey(crypto.X25519SecretKey{1}.PublicKey())
+
+	// Create a file that fits in one base sector.
+	fileBytes := fastrand.Bytes(1000)
+	metadataBytes, err := modules.SkyfileMetadataBytes(modules.SkyfileMetadata{Filename: "encryption_test_file"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	baseSectorFor := func(recipients ...skykey.RecipientPublicKey) ([]byte, modules.SkyfileUploadParameters) {
+		sup := modules.SkyfileUploadParameters{SkykeyRecipients: recipients}
+		if err := r.generateFilekey(&sup, nil); err != nil {
+			t.Fatal(err)
+		}
+		ll := modules.SkyfileLayout{
+			Version:      modules.SkyfileVersion,
+			Filesize:     uint64(len(fileBytes)),
+			MetadataSize: uint64(len(metadataBytes)),
+			CipherType:   crypto.TypePlain,
+		}
+		copy(ll.KeyData[:], sup.FileSpecificKeyData)
+		baseSector, _ := modules.BuildBaseSector(ll.Encode(), nil, metadataBytes, fileBytes)
+		if err := encryptBaseSectorWithSkykey(baseSector, ll, sup.FileSpecificSkykey); err != nil {
+			t.Fatal(err)
+		}
+		return baseSector, sup
+	}
+
+	// The renter decrypts base sectors encrypted for its skykey, regardless
+	// of the slot it was assigned.
+	for _, recipients := range [][]skykey.RecipientPublicKey{{pk}, {otherPK, pk}, {pk, otherPK}} {
+		baseSector, sup := baseSectorFor(recipients...)
+		if !modules.IsEncryptedBaseSector(baseSector) {
+			t.Fatal("expected base sector to be encrypted")
+		}
+		fileSkykey, err := r.decryptBaseSector(baseSector)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fileSkykey.Name != sk.Name || fileSkykey.Type != skykey.TypeRecipient || !bytes.Equal(fileSkykey.Entropy, sup.FileSpecificSkykey.Entropy) {
+			t.Fatal("wrong file-specific skykey")
+		}
+		_, _, sm, payload, err := modules.ParseSkyfileMetadata(baseSector)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sm.Filename != "encryption_test_file" || !bytes.Equal(payload, fileBytes) {
+			t.Fatal("wrong decrypted base sector")
+		}
+
+		// The renter can re-encrypt the decrypted base sector for pinning.
+		layout, _, _, _, err := modules.ParseSkyfileMetadata(baseSector)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := encryptBaseSectorWithSkykey(baseSector, layout, fileSkykey); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.decryptBaseSector(baseSector); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Base sectors encrypted for other recipients can't be decrypted.
+	baseSector, _ := baseSectorFor(otherPK)
+	if _, err := r.decryptBaseSector(baseSector); !errors.Contains(err, errNoSkykeyMatchesSkyfileEncryptionID) {
+		t.Fatal("expected errNoSkykeyMatchesSkyfileEncryptionID", err)
+	}
+
+	// Uploading with the recipient skykey's name encrypts for its public key.
+	sup := modules.SkyfileUploadParameters{SkykeyName: sk.Name}
+	if err := r.generateFilekey(&sup, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(sup.FileSpecificKeyData) != skykey.RecipientKeyDataLen {
+		t.Fatal("expected key data to be set")
+	}
+
+	// Recipients can't be combined with a skykey and are limited.
+	sup = modules.SkyfileUploadParameters{SkykeyName: sk.Name, SkykeyRecipients: []skykey.RecipientPublicKey{pk}}
+	if err := r.generateFilekey(&sup, nil); !errors.Contains(err, errRecipientsWithSkykey) {
+		t.Fatal("expected errRecipientsWithSkykey", err)
+	}
+	sup = modules.SkyfileUploadParameters{SkykeyRecipients: make([]skykey.RecipientPublicKey, skykey.MaxRecipients+1)}
+	if err := r.generateFilekey(&sup, nil); !errors.Contains(err, skykey.ErrTooManyRecipients) {
+		t.Fatal("expected ErrTooManyRecipients", err)
+	}
+}
@@ -0,0 +1,822 @@
+package renter
+
+// skyfileresumable.go implements skyfile uploads which receive their data in
+// multiple requests. This allows clients to resume an upload after a dropped
+// connection instead of starting over.
+//
+// Every resumable upload is stored in two files within the resumable uploads
+// directory. The json file contains the upload parameters and the data file
+// contains the data received so far. The offset of an upload is the size of
+// its data file, which means that every byte written to disk before a
+// connection drops or the renter shuts down doesn't need to be sent again.
+// The full length of an upload is reserved when it is created to limit the
+// total number of bytes staged on disk.
+//
+// Once all data was received, the data file is uploaded as a skyfile in the
+// background using the same parameters as a regular upload, which results in
+// the same skylink. The progress of that upload is persisted as well. An
+// upload interrupted by a shutdown is resumed on startup and the fanout of a
+// large skyfile isn't uploaded again if it was uploaded before the shutdown.
+
+import (
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turtledex/errors"
+	"github.com/turtledex/fastrand"
+
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem"
+	"github.com/turtledex/TurtleDexCore/persist"
+	"github.com/turtledex/TurtleDexCore/skykey"
+)
+
+const (
+	// resumableUploadsDir is the name of the directory within the renter's
+	// persist directory which contains the resumable uploads.
+	resumableUploadsDir = "resumableuploads"
+
+	// resumableUploadDataExt and resumableUploadMetadataExt are the
+	// extensions of the data file and the metadata file of a resumable
+	// upload.
+	resumableUploadDataExt     = ".data"
+	resumableUploadMetadataExt = ".json"
+)
+
+var (
+	// resumableUploadMetadata is the metadata of the persisted state of a
+	// resumable upload.
+	resumableUploadMetadata = persist.Metadata{
+		Header:  "Resumable Skyfile Upload",
+		Version: "1.5.5",
+	}
+
+	// ErrResumableUploadNotFound is returned when accessing a resumable upload
+	// which doesn't exist or expired.
+	ErrResumableUploadNotFound = errors.New("resumable upload not found")
+
+	// ErrResumableUploadOffset is returned when writing to a resumable upload
+	// at an offset which doesn't match its current offset.
+	ErrResumableUploadOffset = errors.New("offset doesn't match the offset of the resumable upload")
+
+	// ErrResumableUploadLocked is returned when writing to a resumable upload
+	// while another write is in progress.
+	ErrResumableUploadLocked = errors.New("resumable upload is locked by another write")
+
+	// ErrResumableUploadTooLarge is returned when more data is written to a
+	// resumable upload than its length.
+	ErrResumableUploadTooLarge = errors.New("data exceeds the length of the resumable upload")
+
+	// ErrResumableUploadMaxSize is returned when creating a resumable upload
+	// which exceeds the maximum size of a resumable upload.
+	ErrResumableUploadMaxSize = errors.New("length exceeds the maximum size of a resumable upload")
+
+	// ErrResumableUploadQuota is returned when creating a resumable upload
+	// would exceed the number of bytes which may be staged on disk.
+	ErrResumableUploadQuota = errors.New("not enough space left to stage the resumable upload")
+)
+
+type (
+	// resumableUploads keeps track of the renter's resumable skyfile uploads.
+	resumableUploads struct {
+		uploads map[modules.ResumableUploadID]*resumableUpload
+
+		staticDir string
+		mu        sync.Mutex
+	}
+
+	// resumableUpload is a single resumable skyfile upload. writing is set
+	// while data is written to the upload or its skyfile is uploaded.
+	resumableUpload struct {
+		persisted resumableUploadPersistence
+		writing   bool
+	}
+
+	// resumableUploadParams are the persisted parameters of a resumable
+	// upload which are needed to upload the skyfile once all data was
+	// received.
+	resumableUploadParams struct {
+		BaseChunkRedundancy uint8                   `json:"basechunkredundancy"`
+		Compression         modules.CompressionType `json:"compression"`
+		Force               bool                    `json:"force"`
+		Mode                os.FileMode             `json:"mode"`
+		SkykeyName          string                  `json:"skykeyname"`
+		SkykeyID            skykey.SkykeyID         `json:"skykeyid"`
+	}
+
+	// resumableUploadProgress is the persisted progress of uploading the
+	// skyfile of a resumable upload. Uploading is set while the skyfile is
+	// uploaded. FanoutUploaded is set once the fanout of a large skyfile was
+	// uploaded. Nonce is the nonce of the file-specific key of an encrypted
+	// skyfile which is needed to derive the key of the fanout again.
+	resumableUploadProgress struct {
+		Uploading      bool   `json:"uploading"`
+		FanoutUploaded bool   `json:"fanoutuploaded"`
+		Nonce          []byte `json:"nonce"`
+	}
+
+	// resumableUploadPersistence is the persisted state of a resumable
+	// upload. The persisted offset is ignored when loading the upload since
+	// the size of the data file is authoritative.
+	resumableUploadPersistence struct {
+		Upload   modules.ResumableSkyfileUpload `json:"upload"`
+		Params   resumableUploadParams          `json:"params"`
+		Progress resumableUploadProgress        `json:"progress"`
+	}
+)
+
+// newResumableUploads loads the resumable uploads from disk and deletes the
+// expired ones.
+func newResumableUploads(persistDir string) (*resumableUploads, error) {
+	ru := &resumableUploads{
+		uploads:   make(map[modules.ResumableUploadID]*resumableUpload),
+		staticDir: filepath.Join(persistDir, resumableUploadsDir),
+	}
+	err := os.MkdirAll(ru.staticDir, modules.DefaultDirPerm)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to create resumable uploads dir")
+	}
+	fis, err := ioutil.ReadDir(ru.staticDir)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to read resumable uploads dir")
+	}
+	for _, fi := range fis {
+		if !strings.HasSuffix(fi.Name(), resumableUploadMetadataExt) {
+			continue
+		}
+		id := modules.ResumableUploadID(strings.TrimSuffix(fi.Name(), resumableUploadMetadataExt))
+		var p resumableUploadPersistence
+		err = persist.LoadJSON(resumableUploadMetadata, &p, ru.metadataPath(id))
+		if err != nil {
+			return nil, errors.AddContext(err, "unable to load resumable upload")
+		}
+		p.Upload.Offset, err = ru.dataSize(p.Upload)
+		if err != nil {
+			return nil, errors.AddContext(err, "unable to load resumable upload data")
+		}
+		ru.uploads[id] = &resumableUpload{persisted: p}
+	}
+	return ru, ru.managedPruneExpired()
+}
+
+// dataPath returns the path of the data file of a resumable upload.
+func (ru *resumableUploads) dataPath(id modules.ResumableUploadID) string {
+	return filepath.Join(ru.staticDir, string(id)+resumableUploadDataExt)
+}
+
+// metadataPath returns the path of the metadata file of a resumable upload.
+func (ru *resumableUploads) metadataPath(id modules.ResumableUploadID) string {
+	return filepath.Join(ru.staticDir, string(id)+resumableUploadMetadataExt)
+}
+
+// dataSize returns the number of bytes staged for a resumable upload. A data
+// file exceeding the length of the upload is truncated. A completed upload
+// doesn't have a data file anymore.
+func (ru *resumableUploads) dataSize(u modules.ResumableSkyfileUpload) (uint64, error) {
+	if u.Skylink != "" {
+		return u.Length, nil
+	}
+	fi, err := os.Stat(ru.dataPath(u.ID))
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	if uint64(fi.Size()) > u.Length {
+		return u.Length, os.Truncate(ru.dataPath(u.ID), int64(u.Length))
+	}
+	return uint64(fi.Size()), nil
+}
+
+// saveSync persists the state of a resumable upload.
+func (ru *resumableUploads) saveSync(p resumableUploadPersistence) error {
+	return persist.SaveJSON(resumableUploadMetadata, p, ru.metadataPath(p.Upload.ID))
+}
+
+// stagedBytes returns the number of bytes reserved by the uploads which
+// haven't been completed yet.
+func (ru *resumableUploads) stagedBytes() uint64 {
+	var staged uint64
+	for _, u := range ru.uploads {
+		if u.persisted.Upload.Skylink == "" {
+			staged += u.persisted.Upload.Length
+		}
+	}
+	return staged
+}
+
+// managedCreate creates a new resumable upload.
+func (ru *resumableUploads) managedCreate(sup modules.SkyfileUploadParameters, length uint64) (modules.ResumableSkyfileUpload, error) {
+	if length > modules.ResumableUploadMaxSize {
+		return modules.ResumableSkyfileUpload{}, ErrResumableUploadMaxSize
+	}
+	if err := ru.managedPruneExpired(); err != nil {
+		return modules.ResumableSkyfileUpload{}, err
+	}
+	p := resumableUploadPersistence{
+		Upload: modules.ResumableSkyfileUpload{
+			ID:            modules.ResumableUploadID(hex.EncodeToString(fastrand.Bytes(16))),
+			TurtleDexPath: sup.TurtleDexPath,
+			Filename:      sup.Filename,
+			Length:        length,
+			Expires:       time.Now().Add(resumableUploadExpiry),
+		},
+		Params: resumableUploadParams{
+			BaseChunkRedundancy: sup.BaseChunkRedundancy,
+			Compression:         sup.Compression,
+			Force:               sup.Force,
+			Mode:                sup.Mode,
+			SkykeyName:          sup.SkykeyName,
+			SkykeyID:            sup.SkykeyID,
+		},
+	}
+
+	// Reserve the length of the upload. The upload stays locked until its
+	// files were created.
+	ru.mu.Lock()
+	if ru.stagedBytes()+length > resumableUploadMaxStaged {
+		ru.mu.Unlock()
+		return modules.ResumableSkyfileUpload{}, ErrResumableUploadQuota
+	}
+	u := &resumableUpload{persisted: p, writing: true}
+	ru.uploads[p.Upload.ID] = u
+	ru.mu.Unlock()
+
+	err := ru.createFiles(p)
+	ru.mu.Lock()
+	defer ru.mu.Unlock()
+	if err != nil {
+		delete(ru.uploads, p.Upload.ID)
+		return modules.ResumableSkyfileUpload{}, err
+	}
+	u.writing = false
+	return p.Upload, nil
+}
+
+// createFiles creates the data and metadata files of a new resumable upload.
+func (ru *resumableUploads) createFiles(p resumableUploadPersistence) (err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Compose(err, ru.deleteFiles(p.Upload.ID))
+		}
+	}()
+	f, err := os.OpenFile(ru.dataPath(p.Upload.ID), os.O_RDWR|os.O_CREATE|os.O_EXCL, modules.DefaultFilePerm)
+	if err != nil {
+		return errors.AddContext(err, "unable to create data file")
+	}
+	if err := f.Close(); err != nil {
+		return errors.AddContext(err, "unable to close data file")
+	}
+	if err := ru.saveSync(p); err != nil {
+		return errors.AddContext(err, "unable to persist resumable upload")
+	}
+	return nil
+}
+
+// managedPersistence returns the persisted state of a resumable upload.
+func (ru *resumableUploads) managedPersistence(id modules.ResumableUploadID) (resumableUploadPersistence, error) {
+	ru.mu.Lock()
+	defer ru.mu.Unlock()
+	u, exists := ru.uploads[id]
+	if !exists {
+		return resumableUploadPersistence{}, ErrResumableUploadNotFound
+	}
+	return u.persisted, nil
+}
+
+// managedUpload returns a resumable upload.
+func (ru *resumableUploads) managedUpload(id modules.ResumableUploadID) (modules.ResumableSkyfileUpload, error) {
+	ru.mu.Lock()
+	defer ru.mu.Unlock()
+	u, exists := ru.uploads[id]
+	if !exists || time.Now().After(u.persisted.Upload.Expires) {
+		return modules.ResumableSkyfileUpload{}, ErrResumableUploadNotFound
+	}
+	return u.persisted.Upload, nil
+}
+
+// managedWrite appends data to a resumable upload. The data written before
+// an error occurred is kept.
+func (ru *resumableUploads) managedWrite(id modules.ResumableUploadID, offset uint64, data io.Reader) (modules.ResumableSkyfileUpload, error) {
+	ru.mu.Lock()
+	u, exists := ru.uploads[id]
+	var err error
+	switch {
+	case !exists || time.Now().After(u.persisted.Upload.Expires):
+		err = ErrResumableUploadNotFound
+	case u.writing:
+		err = ErrResumableUploadLocked
+	case u.persisted.Upload.Offset != offset:
+		err = ErrResumableUploadOffset
+	}
+	if err != nil {
+		ru.mu.Unlock()
+		return modules.ResumableSkyfileUpload{}, err
+	}
+	u.writing = true
+	upload := u.persisted.Upload
+	ru.mu.Unlock()
+
+	written, err := ru.appendData(upload, data)
+
+	// Update the offset to the number of bytes which made it to disk.
+	ru.mu.Lock()
+	u.writing = false
+	u.persisted.Upload.Offset += written
+	u.persisted.Upload.Expires = time.Now().Add(resumableUploadExpiry)
+	p := u.persisted
+	ru.mu.Unlock()
+	err = errors.Compose(err, ru.saveSync(p))
+	return p.Upload, err
+}
+
+// appendData appends data to the data file of a resumable upload and returns
+// the number of bytes written.
+func (ru *resumableUploads) appendData(u modules.ResumableSkyfileUpload, data io.Reader) (uint64, error) {
+	if u.Skylink != "" {
+		// The upload is already complete.
+		return 0, nil
+	}
+	f, err := os.OpenFile(ru.dataPath(u.ID), os.O_WRONLY|os.O_APPEND, modules.DefaultFilePerm)
+	if err != nil {
+		return 0, errors.AddContext(err, "unable to open data file")
+	}
+	written, err := io.Copy(f, io.LimitReader(data, int64(u.Length-u.Offset)))
+	err = errors.Compose(err, f.Sync(), f.Close())
+	if err != nil {
+		return uint64(written), errors.AddContext(err, "unable to write data")
+	}
+	if u.Offset+uint64(written) == u.Length {
+		n, _ := data.Read(make([]byte, 1))
+		if n > 0 {
+			return uint64(written), ErrResumableUploadTooLarge
+		}
+	}
+	return uint64(written), nil
+}
+
+// managedStartUpload locks a resumable upload which received all of its data
+// and marks its skyfile as being uploaded. false is returned if the skyfile
+// was already uploaded.
+func (ru *resumableUploads) managedStartUpload(id modules.ResumableUploadID) (modules.ResumableSkyfileUpload, bool, error) {
+	ru.mu.Lock()
+	u, exists := ru.uploads[id]
+	var err error
+	switch {
+	case !exists:
+		err = ErrResumableUploadNotFound
+	case u.writing:
+		err = ErrResumableUploadLocked
+	}
+	if err != nil {
+		ru.mu.Unlock()
+		return modules.ResumableSkyfileUpload{}, false, err
+	}
+	if u.persisted.Upload.Skylink != "" {
+		ru.mu.Unlock()
+		return u.persisted.Upload, false, nil
+	}
+	u.writing = true
+	u.persisted.Upload.Error = ""
+	u.persisted.Progress.Uploading = true
+	p := u.persisted
+	ru.mu.Unlock()
+
+	if err := ru.saveSync(p); err != nil {
+		ru.managedUnlock(id)
+		return modules.ResumableSkyfileUpload{}, false, errors.AddContext(err, "unable to persist resumable upload")
+	}
+	return p.Upload, true, nil
+}
+
+// managedLockInterrupted locks the uploads whose skyfile was being uploaded
+// when the renter shut down and returns their ids.
+func (ru *resumableUploads) managedLockInterrupted() []modules.ResumableUploadID {
+	ru.mu.Lock()
+	defer ru.mu.Unlock()
+	var ids []modules.ResumableUploadID
+	for id, u := range ru.uploads {
+		if u.persisted.Progress.Uploading && u.persisted.Upload.Skylink == "" && !u.writing {
+			u.writing = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// managedSetFanoutUploaded records that the fanout of an upload was uploaded
+// using the file-specific key with the given nonce.
+func (ru *resumableUploads) managedSetFanoutUploaded(id modules.ResumableUploadID, nonce []byte) error {
+	ru.mu.Lock()
+	u, exists := ru.uploads[id]
+	if !exists {
+		ru.mu.Unlock()
+		return ErrResumableUploadNotFound
+	}
+	u.persisted.Progress.FanoutUploaded = true
+	u.persisted.Progress.Nonce = nonce
+	p := u.persisted
+	ru.mu.Unlock()
+	return ru.saveSync(p)
+}
+
+// managedFail records the error of a failed skyfile upload. The staged data is
+// kept to allow for retrying the upload.
+func (ru *resumableUploads) managedFail(id modules.ResumableUploadID, uploadErr error) error {
+	ru.mu.Lock()
+	u, exists := ru.uploads[id]
+	if !exists {
+		ru.mu.Unlock()
+		return ErrResumableUploadNotFound
+	}
+	u.persisted.Upload.Error = uploadErr.Error()
+	u.persisted.Progress = resumableUploadProgress{}
+	p := u.persisted
+	ru.mu.Unlock()
+	return ru.saveSync(p)
+}
+
+// withData calls fn with a reader of the data staged for a resumable upload.
+// If compress is set, the data is compressed using the compression type of
+// the upload.
+func (ru *resumableUploads) withData(p resumableUploadPersistence, compress bool, fn func(io.Reader) error) (err error) {
+	f, err := os.Open(ru.dataPath(p.Upload.ID))
+	if err != nil {
+		return errors.AddContext(err, "unable to open data file")
+	}
+	defer func() {
+		err = errors.Compose(err, f.Close())
+	}()
+	var data io.Reader = f
+	if compress {
+		data, err = modules.NewCompressionReader(p.Params.Compression, f)
+		if err != nil {
+			return err
+		}
+	}
+	return fn(data)
+}
+
+// uploadSize returns the size of the data of a resumable upload after
+// compressing it.
+func (ru *resumableUploads) uploadSize(p resumableUploadPersistence) (uint64, error) {
+	if p.Params.Compression == modules.CompressionNone {
+		return p.Upload.Length, nil
+	}
+	var size int64
+	err := ru.withData(p, true, func(data io.Reader) (err error) {
+		size, err = io.Copy(ioutil.Discard, data)
+		return err
+	})
+	return uint64(size), err
+}
+
+// managedUnlock unlocks a resumable upload.
+func (ru *resumableUploads) managedUnlock(id modules.ResumableUploadID) {
+	ru.mu.Lock()
+	defer ru.mu.Unlock()
+	if u, exists := ru.uploads[id]; exists {
+		u.writing = false
+	}
+}
+
+// managedComplete records the skylink of a completed upload and deletes its
+// data file.
+func (ru *resumableUploads) managedComplete(id modules.ResumableUploadID, skylink modules.Skylink) (modules.ResumableSkyfileUpload, error) {
+	ru.mu.Lock()
+	u, exists := ru.uploads[id]
+	if !exists {
+		ru.mu.Unlock()
+		return modules.ResumableSkyfileUpload{}, ErrResumableUploadNotFound
+	}
+	u.persisted.Upload.Skylink = skylink.String()
+	u.persisted.Upload.Error = ""
+	u.persisted.Upload.Expires = time.Now().Add(resumableUploadExpiry)
+	u.persisted.Progress = resumableUploadProgress{}
+	p := u.persisted
+	ru.mu.Unlock()
+
+	if err := ru.saveSync(p); err != nil {
+		return modules.ResumableSkyfileUpload{}, errors.AddContext(err, "unable to persist resumable upload")
+	}
+	if err := os.Remove(ru.dataPath(id)); err != nil {
+		return modules.ResumableSkyfileUpload{}, errors.AddContext(err, "unable to remove data file")
+	}
+	return p.Upload, nil
+}
+
+// managedDelete deletes a resumable upload.
+func (ru *resumableUploads) managedDelete(id modules.ResumableUploadID) error {
+	ru.mu.Lock()
+	u, exists := ru.uploads[id]
+	if !exists {
+		ru.mu.Unlock()
+		return ErrResumableUploadNotFound
+	}
+	if u.writing {
+		ru.mu.Unlock()
+		return ErrResumableUploadLocked
+	}
+	delete(ru.uploads, id)
+	ru.mu.Unlock()
+	return ru.deleteFiles(id)
+}
+
+// managedPruneExpired deletes all expired uploads which aren't being written
+// to.
+func (ru *resumableUploads) managedPruneExpired() error {
+	ru.mu.Lock()
+	var expired []modules.ResumableUploadID
+	for id, u := range ru.uploads {
+		if !u.writing && time.Now().After(u.persisted.Upload.Expires) {
+			expired = append(expired, id)
+			delete(ru.uploads, id)
+		}
+	}
+	ru.mu.Unlock()
+
+	var errs error
+	for _, id := range expired {
+		errs = errors.Compose(errs, ru.deleteFiles(id))
+	}
+	return errors.AddContext(errs, "unable to delete expired resumable uploads")
+}
+
+// deleteFiles deletes the data and metadata files of a resumable upload.
+func (ru *resumableUploads) deleteFiles(id modules.ResumableUploadID) error {
+	var errs error
+	for _, path := range []string{ru.dataPath(id), ru.metadataPath(id)} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			errs = errors.Compose(errs, err)
+		}
+	}
+	return errs
+}
+
+// skyfileUploadParameters returns the parameters to upload the skyfile of a
+// resumable upload with.
+func (p resumableUploadPersistence) skyfileUploadParameters() modules.SkyfileUploadParameters {
+	return modules.SkyfileUploadParameters{
+		TurtleDexPath:       p.Upload.TurtleDexPath,
+		Force:               p.Params.Force,
+		BaseChunkRedundancy: p.Params.BaseChunkRedundancy,
+		Filename:            p.Upload.Filename,
+		Mode:                p.Params.Mode,
+		Compression:         p.Params.Compression,
+		SkykeyName:          p.Params.SkykeyName,
+		SkykeyID:            p.Params.SkykeyID,
+	}
+}
+
+// CreateResumableSkyfileUpload creates a new resumable skyfile upload of
+// length bytes.
+func (r *Renter) CreateResumableSkyfileUpload(sup modules.SkyfileUploadParameters, length uint64) (modules.ResumableSkyfileUpload, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.ResumableSkyfileUpload{}, err
+	}
+	defer r.tg.Done()
+
+	// Fail early instead of after receiving all the data if the skyfile
+	// can't be uploaded to its siapath.
+	if !sup.Force {
+		if _, err := r.File(sup.TurtleDexPath); err == nil {
+			return modules.ResumableSkyfileUpload{}, errors.New("a file already exists at the siapath of the upload")
+		}
+	}
+	u, err := r.staticResumableUploads.managedCreate(sup, length)
+	if err != nil || length > 0 {
+		return u, err
+	}
+	// An empty upload is complete right away.
+	return r.managedWriteResumableSkyfileUpload(u.ID, 0, strings.NewReader(""))
+}
+
+// ResumableSkyfileUpload returns the state of a resumable skyfile upload.
+func (r *Renter) ResumableSkyfileUpload(id modules.ResumableUploadID) (modules.ResumableSkyfileUpload, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.ResumableSkyfileUpload{}, err
+	}
+	defer r.tg.Done()
+	return r.staticResumableUploads.managedUpload(id)
+}
+
+// WriteResumableSkyfileUpload appends data to a resumable skyfile upload. The
+// skyfile is uploaded once all data was received.
+func (r *Renter) WriteResumableSkyfileUpload(id modules.ResumableUploadID, offset uint64, data io.Reader) (modules.ResumableSkyfileUpload, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.ResumableSkyfileUpload{}, err
+	}
+	defer r.tg.Done()
+	return r.managedWriteResumableSkyfileUpload(id, offset, data)
+}
+
+// managedWriteResumableSkyfileUpload appends data to a resumable skyfile
+// upload and starts uploading the skyfile in the background once all data was
+// received. If uploading the skyfile fails, the data is kept and writing no
+// data at the final offset retries the upload.
+func (r *Renter) managedWriteResumableSkyfileUpload(id modules.ResumableUploadID, offset uint64, data io.Reader) (modules.ResumableSkyfileUpload, error) {
+	ru := r.staticResumableUploads
+	u, err := ru.managedWrite(id, offset, data)
+	if err != nil || !u.Complete() || u.Skylink != "" {
+		return u, err
+	}
+
+	// All data was received, upload the skyfile. The upload stays locked
+	// while the skyfile is uploaded to prevent concurrent uploads.
+	u, started, err := ru.managedStartUpload(id)
+	if err != nil || !started {
+		return u, err
+	}
+	go r.threadedUploadResumableSkyfile(id, false)
+	return u, nil
+}
+
+// managedResumeResumableSkyfileUploads resumes the skyfile uploads which were
+// interrupted by a shutdown.
+func (r *Renter) managedResumeResumableSkyfileUploads() {
+	for _, id := range r.staticResumableUploads.managedLockInterrupted() {
+		go r.threadedUploadResumableSkyfile(id, true)
+	}
+}
+
+// threadedUploadResumableSkyfile uploads the skyfile of a locked resumable
+// upload which received all of its data and unlocks it afterwards. If the
+// upload is interrupted by a shutdown, its progress is kept to resume it on
+// startup.
+func (r *Renter) threadedUploadResumableSkyfile(id modules.ResumableUploadID, resumed bool) {
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+	ru := r.staticResumableUploads
+	defer ru.managedUnlock(id)
+
+	skylink, err := r.managedUploadResumableSkyfile(id, resumed)
+	if err != nil {
+		select {
+		case <-r.tg.StopChan():
+			return
+		default:
+		}
+		r.log.Printf("Unable to upload skyfile of resumable upload %v: %v", id, err)
+		err = ru.managedFail(id, err)
+	} else {
+		_, err = ru.managedComplete(id, skylink)
+	}
+	if err != nil {
+		r.log.Printf("Unable to update resumable upload %v: %v", id, err)
+	}
+}
+
+// managedUploadResumableSkyfile uploads the skyfile of a resumable upload.
+// The siafiles left behind by an interrupted upload are deleted first, except
+// for the siafile of a fanout which was uploaded completely.
+func (r *Renter) managedUploadResumableSkyfile(id modules.ResumableUploadID, resumed bool) (modules.Skylink, error) {
+	ru := r.staticResumableUploads
+	p, err := ru.managedPersistence(id)
+	if err != nil {
+		return modules.Skylink{}, err
+	}
+	sup := p.skyfileUploadParameters()
+	extendedPath, err := modules.NewTurtleDexPath(sup.TurtleDexPath.String() + modules.ExtendedSuffix)
+	if err != nil {
+		return modules.Skylink{}, errors.AddContext(err, "unable to create TurtleDexPath for large skyfile extended data")
+	}
+	if resumed {
+		siaPaths := []modules.TurtleDexPath{sup.TurtleDexPath}
+		if !p.Progress.FanoutUploaded {
+			siaPaths = append(siaPaths, extendedPath)
+		}
+		for _, siaPath := range siaPaths {
+			if err := r.managedDeleteFile(siaPath); err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+				return modules.Skylink{}, errors.AddContext(err, "unable to delete siafile of interrupted upload")
+			}
+		}
+	}
+
+	// Skyfiles which might fit into the base sector don't have a fanout and
+	// are uploaded like regular skyfiles.
+	size, err := ru.uploadSize(p)
+	if err != nil {
+		return modules.Skylink{}, errors.AddContext(err, "unable to get size of compressed data")
+	}
+	if size < modules.SectorSize {
+		var skylink modules.Skylink
+		err = ru.withData(p, false, func(data io.Reader) (err error) {
+			skylink, err = r.UploadSkyfile(sup, modules.NewSkyfileReader(data, sup))
+			return err
+		})
+		return skylink, err
+	}
+	return r.managedUploadResumableSkyfileLargeFile(p, extendedPath)
+}
+
+// managedUploadResumableSkyfileLargeFile uploads the skyfile of a resumable
+// upload which doesn't fit into the base sector. This is equivalent to
+// managedUploadSkyfileLargeFile except that the progress is persisted after
+// uploading the fanout. If the upload fails, the siafiles are deleted unless
+// the renter is shutting down.
+func (r *Renter) managedUploadResumableSkyfileLargeFile(p resumableUploadPersistence, extendedPath modules.TurtleDexPath) (skylink modules.Skylink, err error) {
+	ru := r.staticResumableUploads
+	sup := p.skyfileUploadParameters()
+	skyfileEstablishDefaults(&sup)
+	err = r.generateFilekey(&sup, p.Progress.Nonce)
+	if err != nil {
+		return modules.Skylink{}, errors.AddContext(err, "unable to upload skyfile")
+	}
+	defer func() {
+		if err == nil {
+			return
+		}
+		select {
+		case <-r.tg.StopChan():
+			return
+		default:
+		}
+		for _, siaPath := range []modules.TurtleDexPath{sup.TurtleDexPath, extendedPath} {
+			if err := r.managedDeleteFile(siaPath); err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+				r.log.Printf("error deleting siafile after upload error: %v", err)
+			}
+		}
+	}()
+
+	// Upload the fanout unless it was uploaded before a shutdown. Skyfiles
+	// encrypted for recipients use a random key which can't be derived again,
+	// so their fanout is always uploaded again.
+	if !p.Progress.FanoutUploaded {
+		fup, err := fileUploadParams(extendedPath, modules.RenterDefaultDataPieces, modules.RenterDefaultParityPieces, sup.Force, crypto.TypePlain)
+		if err != nil {
+			return modules.Skylink{}, errors.AddContext(err, "unable to create FileUploadParams for large file")
+		}
+		err = generateCipherKey(&fup, sup)
+		if err != nil {
+			return modules.Skylink{}, errors.AddContext(err, "unable to create Cipher key for FileUploadParams")
+		}
+		err = ru.withData(p, true, func(data io.Reader) error {
+			fileNode, err := r.callUploadStreamFromReader(fup, data)
+			if err != nil {
+				return err
+			}
+			return fileNode.Close()
+		})
+		if err != nil {
+			return modules.Skylink{}, errors.AddContext(err, "unable to upload large skyfile")
+		}
+		if len(sup.FileSpecificKeyData) == 0 {
+			var nonce []byte
+			if encryptionEnabled(&sup) {
+				nonce = sup.FileSpecificSkykey.Nonce()
+			}
+			err = ru.managedSetFanoutUploaded(p.Upload.ID, nonce)
+			if err != nil {
+				return modules.Skylink{}, errors.AddContext(err, "unable to persist resumable upload")
+			}
+		}
+	}
+
+	// Create the skylink from the siafile of the fanout.
+	fileNode, err := r.staticFileSystem.OpenTurtleDexFile(extendedPath)
+	if err != nil {
+		return modules.Skylink{}, errors.AddContext(err, "unable to open siafile of large skyfile")
+	}
+	defer func() {
+		if err := fileNode.Close(); err != nil {
+			r.log.Printf("Could not close node, err: %s\n", err.Error())
+		}
+	}()
+	metadata := modules.SkyfileMetadata{
+		Filename: sup.Filename,
+		Mode:     sup.Mode,
+		Length:   p.Upload.Length,
+	}
+	if sup.Compression != modules.CompressionNone {
+		metadata.Compression = sup.Compression
+	}
+	err = ru.withData(p, true, func(data io.Reader) (err error) {
+		skylink, err = r.managedCreateSkylinkFromFileNode(sup, metadata, fileNode, data)
+		return err
+	})
+	if err != nil {
+		return modules.Skylink{}, errors.AddContext(err, "unable to create skylink from filenode")
+	}
+	if r.staticSkynetBlocklist.IsBlocked(skylink) {
+		return modules.Skylink{}, ErrSkylinkBlocked
+	}
+	return skylink, nil
+}
+
+// DeleteResumableSkyfileUpload aborts a resumable skyfile upload.
+func (r *Renter) DeleteResumableSkyfileUpload(id modules.ResumableUploadID) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	return r.staticResumableUploads.managedDelete(id)
+}
@@ -0,0 +1,271 @@
+package renter
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/turtledex/errors"
+	"github.com/turtledex/fastrand"
+
+	"github.com/turtledex/TurtleDexCore/build"
+	"github.com/turtledex/TurtleDexCore/modules"
+)
+
+// TestResumableUploads tests writing to resumable uploads and reloading them
+// from disk.
+func TestResumableUploads(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir("renter", t.Name())
+	if err := os.MkdirAll(dir, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	ru, err := newResumableUploads(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sup := modules.SkyfileUploadParameters{
+		TurtleDexPath:       modules.RandomTurtleDexPath(),
+		BaseChunkRedundancy: 2,
+		Filename:            "file",
+		Mode:                0640,
+	}
+	data := fastrand.Bytes(100)
+	u, err := ru.managedCreate(sup, uint64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Write the first half of the data.
+	u, err = ru.managedWrite(u.ID, 0, bytes.NewReader(data[:50]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Offset != 50 || u.Complete() {
+		t.Fatal("wrong offset", u.Offset)
+	}
+
+	// Writing at the wrong offset fails.
+	if _, err := ru.managedWrite(u.ID, 0, bytes.NewReader(data)); !errors.Contains(err, ErrResumableUploadOffset) {
+		t.Fatal("expected ErrResumableUploadOffset", err)
+	}
+
+	// Writing to a locked upload fails.
+	ru.mu.Lock()
+	ru.uploads[u.ID].writing = true
+	ru.mu.Unlock()
+	if _, err := ru.managedWrite(u.ID, 50, bytes.NewReader(data[50:])); !errors.Contains(err, ErrResumableUploadLocked) {
+		t.Fatal("expected ErrResumableUploadLocked", err)
+	}
+	ru.managedUnlock(u.ID)
+
+	// Reload the uploads. The offset and parameters are restored.
+	ru, err = newResumableUploads(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err = ru.managedUpload(u.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Offset != 50 || u.Length != 100 || !u.TurtleDexPath.Equals(sup.TurtleDexPath) {
+		t.Fatal("wrong upload", u)
+	}
+	restored := ru.uploads[u.ID].persisted.skyfileUploadParameters()
+	if restored.BaseChunkRedundancy != sup.BaseChunkRedundancy || restored.Filename != sup.Filename || restored.Mode != sup.Mode {
+		t.Fatal("wrong parameters", restored)
+	}
+
+	// Writing more data than the length of the upload fails but the data
+	// up to the length is kept.
+	extra := append(append([]byte{}, data[50:]...), 1)
+	u, err = ru.managedWrite(u.ID, 50, bytes.NewReader(extra))
+	if !errors.Contains(err, ErrResumableUploadTooLarge) {
+		t.Fatal("expected ErrResumableUploadTooLarge", err)
+	}
+	if !u.Complete() {
+		t.Fatal("upload should be complete", u.Offset)
+	}
+	staged, err := ioutil.ReadFile(ru.dataPath(u.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(staged, data) {
+		t.Fatal("wrong data")
+	}
+
+	// Start uploading the skyfile. The upload is locked and is resumed after
+	// a restart.
+	if _, started, err := ru.managedStartUpload(u.ID); err != nil || !started {
+		t.Fatal("upload wasn't started", err)
+	}
+	if _, err := ru.managedWrite(u.ID, 100, bytes.NewReader(nil)); !errors.Contains(err, ErrResumableUploadLocked) {
+		t.Fatal("expected ErrResumableUploadLocked", err)
+	}
+	nonce := fastrand.Bytes(24)
+	if err := ru.managedSetFanoutUploaded(u.ID, nonce); err != nil {
+		t.Fatal(err)
+	}
+	ru, err = newResumableUploads(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := ru.managedLockInterrupted(); len(ids) != 1 || ids[0] != u.ID {
+		t.Fatal("upload wasn't interrupted", ids)
+	}
+	if ids := ru.managedLockInterrupted(); len(ids) != 0 {
+		t.Fatal("upload was locked twice", ids)
+	}
+	progress := ru.uploads[u.ID].persisted.Progress
+	if !progress.Uploading || !progress.FanoutUploaded || !bytes.Equal(progress.Nonce, nonce) {
+		t.Fatal("wrong progress", progress)
+	}
+
+	// Fail the upload. The error is reported and the progress is reset.
+	if err := ru.managedFail(u.ID, errors.New("failed")); err != nil {
+		t.Fatal(err)
+	}
+	ru.managedUnlock(u.ID)
+	if u, err = ru.managedUpload(u.ID); err != nil || u.Error != "failed" {
+		t.Fatal("wrong upload", u, err)
+	}
+	if progress := ru.uploads[u.ID].persisted.Progress; progress.Uploading || progress.FanoutUploaded || progress.Nonce != nil {
+		t.Fatal("wrong progress", progress)
+	}
+
+	// Complete the upload. The data file is removed and the skylink is kept.
+	var skylink modules.Skylink
+	u, err = ru.managedComplete(u.ID, skylink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(ru.dataPath(u.ID)); !os.IsNotExist(err) {
+		t.Fatal("data file wasn't removed", err)
+	}
+	ru, err = newResumableUploads(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u, err = ru.managedUpload(u.ID); err != nil || !u.Complete() || u.Skylink != skylink.String() || u.Error != "" {
+		t.Fatal("wrong upload", u, err)
+	}
+	if _, started, err := ru.managedStartUpload(u.ID); err != nil || started {
+		t.Fatal("completed upload was started", err)
+	}
+
+	// Delete the upload.
+	if err := ru.managedDelete(u.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ru.managedUpload(u.ID); !errors.Contains(err, ErrResumableUploadNotFound) {
+		t.Fatal("expected ErrResumableUploadNotFound", err)
+	}
+	if _, err := os.Stat(ru.metadataPath(u.ID)); !os.IsNotExist(err) {
+		t.Fatal("metadata file wasn't removed", err)
+	}
+}
+
+// TestResumableUploadsExpiry tests that expired uploads are deleted.
+func TestResumableUploadsExpiry(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir("renter", t.Name())
+	if err := os.MkdirAll(dir, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	ru, err := newResumableUploads(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sup := modules.SkyfileUploadParameters{TurtleDexPath: modules.RandomTurtleDexPath()}
+	u, err := ru.managedCreate(sup, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Expire the upload.
+	ru.mu.Lock()
+	ru.uploads[u.ID].persisted.Upload.Expires = time.Now().Add(-time.Second)
+	p := ru.uploads[u.ID].persisted
+	ru.mu.Unlock()
+	if err := ru.saveSync(p); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ru.managedUpload(u.ID); !errors.Contains(err, ErrResumableUploadNotFound) {
+		t.Fatal("expected ErrResumableUploadNotFound", err)
+	}
+	if _, err := ru.managedWrite(u.ID, 0, bytes.NewReader(make([]byte, 10))); !errors.Contains(err, ErrResumableUploadNotFound) {
+		t.Fatal("expected ErrResumableUploadNotFound", err)
+	}
+
+	// Reloading prunes the upload.
+	ru, err = newResumableUploads(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ru.uploads) != 0 {
+		t.Fatal("expired upload wasn't pruned")
+	}
+	for _, path := range []string{ru.dataPath(u.ID), ru.metadataPath(u.ID)} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Fatal("file wasn't removed", path, err)
+		}
+	}
+}
+
+// TestResumableUploadsLimits tests the maximum size of a resumable upload and
+// the limit of bytes staged for resumable uploads.
+func TestResumableUploadsLimits(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir("renter", t.Name())
+	if err := os.MkdirAll(dir, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	ru, err := newResumableUploads(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sup := modules.SkyfileUploadParameters{TurtleDexPath: modules.RandomTurtleDexPath()}
+	if _, err := ru.managedCreate(sup, modules.ResumableUploadMaxSize+1); !errors.Contains(err, ErrResumableUploadMaxSize) {
+		t.Fatal("expected ErrResumableUploadMaxSize", err)
+	}
+
+	// Reserve the whole quota.
+	var ids []modules.ResumableUploadID
+	for staged := uint64(0); staged+modules.ResumableUploadMaxSize <= resumableUploadMaxStaged; staged += modules.ResumableUploadMaxSize {
+		u, err := ru.managedCreate(sup, modules.ResumableUploadMaxSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, u.ID)
+	}
+	if _, err := ru.managedCreate(sup, resumableUploadMaxStaged%modules.ResumableUploadMaxSize+1); !errors.Contains(err, ErrResumableUploadQuota) {
+		t.Fatal("expected ErrResumableUploadQuota", err)
+	}
+
+	// Completed and deleted uploads don't count towards the quota.
+	if _, err := ru.managedComplete(ids[0], modules.Skylink{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ru.managedDelete(ids[1]); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := ru.managedCreate(sup, modules.ResumableUploadMaxSize); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
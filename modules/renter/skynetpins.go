@@ -0,0 +1,530 @@
+package renter
+
+// skynetpins.go implements the renter's pin manager. The pin manager keeps a
+// list of skylinks the renter intends to keep alive and periodically checks
+// their health.
+//
+// A check counts the hosts storing the base sector and the fanout chunks of a
+// skylink by launching a HasSector job on every worker. The skylink is pinned
+// again if its siafile is missing or if enough hosts responded and too few of
+// them store the base sector or the pieces of a fanout chunk. Hosts which
+// don't respond don't count as evidence of missing data, which prevents
+// pinning everything again while the workers are starting up.
+//
+// Pinning a skylink again uploads it to a temporary siapath first. The
+// existing siafiles are only replaced once the upload succeeded.
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem"
+	"github.com/turtledex/TurtleDexCore/persist"
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+const (
+	// pinPersistFile is the name of the file the pins are persisted to.
+	pinPersistFile = "skynetpins.json"
+)
+
+var (
+	// pinPersistMetadata is the metadata of the pin persist file.
+	pinPersistMetadata = persist.Metadata{
+		Header:  "Renter Skynet Pins",
+		Version: "1.5.5",
+	}
+
+	// pinPricePerMS is the budget for faster hosts when fetching the base
+	// sector of a skylink which is pinned again. It matches the default of
+	// the skynet API.
+	pinPricePerMS = types.TurtleDexcoinPrecision.MulFloat(1e-7)
+
+	// errPinExists is returned when adding a pin for a skylink which is
+	// already pinned.
+	errPinExists = errors.New("the skylink is already pinned")
+
+	// errPinNotFound is returned when removing a pin which doesn't exist.
+	errPinNotFound = errors.New("the skylink isn't pinned")
+
+	// errPinSiaPathUsed is returned when the siapath of a pin is used by a
+	// file with a different skylink or by another pin.
+	errPinSiaPathUsed = errors.New("the siapath is used by a different skyfile")
+
+	// errPinSkylinkV2 is returned when adding a pin for a v2 skylink.
+	errPinSkylinkV2 = errors.New("only v1 skylinks can be kept pinned")
+)
+
+type (
+	// pinManager keeps track of the skylinks the renter keeps pinned.
+	pinManager struct {
+		pins map[string]*modules.SkynetPinStatus
+
+		staticPersistPath string
+		staticTriggerChan chan struct{}
+		mu                sync.Mutex
+	}
+
+	// pinPersistence is the persisted form of the pin manager.
+	pinPersistence struct {
+		Pins []modules.SkynetPinStatus `json:"pins"`
+	}
+)
+
+// newPinManager creates a new pin manager and loads its pins from disk.
+func newPinManager(persistDir string) (*pinManager, error) {
+	pm := &pinManager{
+		pins:              make(map[string]*modules.SkynetPinStatus),
+		staticPersistPath: filepath.Join(persistDir, pinPersistFile),
+		staticTriggerChan: make(chan struct{}, 1),
+	}
+	var pp pinPersistence
+	err := persist.LoadJSON(pinPersistMetadata, &pp, pm.staticPersistPath)
+	if os.IsNotExist(err) {
+		return pm, nil
+	} else if err != nil {
+		return nil, errors.AddContext(err, "failed to load skynet pins")
+	}
+	for i := range pp.Pins {
+		pm.pins[pp.Pins[i].Skylink] = &pp.Pins[i]
+	}
+	return pm, nil
+}
+
+// saveSync persists the pins.
+func (pm *pinManager) saveSync() error {
+	pp := pinPersistence{
+		Pins: make([]modules.SkynetPinStatus, 0, len(pm.pins)),
+	}
+	for _, ps := range pm.pins {
+		pp.Pins = append(pp.Pins, *ps)
+	}
+	return persist.SaveJSON(pinPersistMetadata, pp, pm.staticPersistPath)
+}
+
+// callTrigger starts a health check unless one is already pending.
+func (pm *pinManager) callTrigger() {
+	select {
+	case pm.staticTriggerChan <- struct{}{}:
+	default:
+	}
+}
+
+// managedAdd adds a pin.
+func (pm *pinManager) managedAdd(pin modules.SkynetPin) error {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	if _, exists := pm.pins[pin.Skylink]; exists {
+		return errPinExists
+	}
+	for _, ps := range pm.pins {
+		if ps.TurtleDexPath.Equals(pin.TurtleDexPath) {
+			return errPinSiaPathUsed
+		}
+	}
+	pm.pins[pin.Skylink] = &modules.SkynetPinStatus{SkynetPin: pin}
+	return pm.saveSync()
+}
+
+// managedRemove removes a pin and returns it.
+func (pm *pinManager) managedRemove(skylink string) (modules.SkynetPin, error) {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	ps, exists := pm.pins[skylink]
+	if !exists {
+		return modules.SkynetPin{}, errPinNotFound
+	}
+	delete(pm.pins, skylink)
+	return ps.SkynetPin, pm.saveSync()
+}
+
+// managedStatus returns the status of all pins sorted by label and skylink.
+func (pm *pinManager) managedStatus() []modules.SkynetPinStatus {
+	pm.mu.Lock()
+	statuses := make([]modules.SkynetPinStatus, 0, len(pm.pins))
+	for _, ps := range pm.pins {
+		statuses = append(statuses, *ps)
+	}
+	pm.mu.Unlock()
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Label != statuses[j].Label {
+			return statuses[i].Label < statuses[j].Label
+		}
+		return statuses[i].Skylink < statuses[j].Skylink
+	})
+	return statuses
+}
+
+// managedRecordCheck records the result of a health check.
+func (pm *pinManager) managedRecordCheck(skylink string, hosts uint64, healthy bool, err error) error {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	ps, exists := pm.pins[skylink]
+	if !exists {
+		return nil // removed during the check
+	}
+	ps.Hosts = hosts
+	ps.Healthy = healthy
+	ps.LastCheck = time.Now()
+	ps.LastError = ""
+	if err != nil {
+		ps.LastError = err.Error()
+	}
+	return pm.saveSync()
+}
+
+// managedRecordPin records an attempt to pin a skylink again.
+func (pm *pinManager) managedRecordPin(skylink string, err error) error {
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	ps, exists := pm.pins[skylink]
+	if !exists {
+		return nil // removed during the check
+	}
+	ps.LastPin = time.Now()
+	if err == nil {
+		ps.Repins++
+	}
+	return pm.saveSync()
+}
+
+// SkynetPins returns the status of the skylinks kept pinned by the renter.
+func (r *Renter) SkynetPins() ([]modules.SkynetPinStatus, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+	return r.staticPinManager.managedStatus(), nil
+}
+
+// AddSkynetPin adds a skylink to the skylinks kept pinned by the renter. The
+// skylink is pinned by the next health check unless its siafile already
+// exists.
+func (r *Renter) AddSkynetPin(pin modules.SkynetPin) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	var skylink modules.Skylink
+	if err := skylink.LoadString(pin.Skylink); err != nil {
+		return errors.AddContext(err, "unable to parse skylink")
+	}
+	if !skylink.IsSkylinkV1() {
+		return errPinSkylinkV2
+	}
+	if r.staticSkynetBlocklist.IsBlocked(skylink) {
+		return ErrSkylinkBlocked
+	}
+	pin.Skylink = skylink.String()
+	if pin.TurtleDexPath.IsEmpty() {
+		var err error
+		pin.TurtleDexPath, err = modules.SkynetFolder.Join(pin.Skylink)
+		if err != nil {
+			return errors.AddContext(err, "unable to create siapath for pin")
+		}
+	}
+	if pin.BaseChunkRedundancy == 0 {
+		pin.BaseChunkRedundancy = SkyfileDefaultBaseChunkRedundancy
+	}
+
+	// Make sure the siapath isn't used by a different file.
+	fi, err := r.File(pin.TurtleDexPath)
+	if err == nil && !fileHasSkylink(fi, pin.Skylink) {
+		return errPinSiaPathUsed
+	} else if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+		return errors.AddContext(err, "unable to check siapath of pin")
+	}
+	if err := r.staticPinManager.managedAdd(pin); err != nil {
+		return err
+	}
+	r.staticPinManager.callTrigger()
+	return nil
+}
+
+// RemoveSkynetPin stops keeping a skylink pinned and unpins it by deleting its
+// siafile.
+func (r *Renter) RemoveSkynetPin(link modules.Skylink) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	pin, err := r.staticPinManager.managedRemove(link.String())
+	if err != nil {
+		return err
+	}
+
+	// Only delete the siafile if it still belongs to the skylink.
+	fi, err := r.File(pin.TurtleDexPath)
+	if errors.Contains(err, filesystem.ErrNotExist) || (err == nil && !fileHasSkylink(fi, pin.Skylink)) {
+		return nil
+	} else if err != nil {
+		return errors.AddContext(err, "unable to check siafile of pin")
+	}
+	return errors.AddContext(r.DeleteFile(pin.TurtleDexPath), "unable to delete siafile of pin")
+}
+
+// threadedPinLoop periodically checks the health of the pinned skylinks.
+func (r *Renter) threadedPinLoop() {
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	for {
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-time.After(pinCheckInterval):
+		case <-r.staticPinManager.staticTriggerChan:
+		}
+		if !r.g.Online() {
+			r.log.Debugln("Skipping check of pinned skylinks since the renter is offline")
+			continue
+		}
+		for _, ps := range r.staticPinManager.managedStatus() {
+			select {
+			case <-r.tg.StopChan():
+				return
+			default:
+			}
+			r.managedCheckPin(ps)
+		}
+	}
+}
+
+// managedCheckPin checks the health of a pinned skylink and pins it again if
+// necessary.
+func (r *Renter) managedCheckPin(ps modules.SkynetPinStatus) {
+	var skylink modules.Skylink
+	err := skylink.LoadString(ps.Skylink)
+	if err == nil && r.staticSkynetBlocklist.IsBlocked(skylink) {
+		err = ErrSkylinkBlocked
+	}
+	if err != nil {
+		r.managedRecordPinCheck(ps, 0, false, err)
+		return
+	}
+
+	// Check the siafile.
+	fi, err := r.File(ps.TurtleDexPath)
+	missing := errors.Contains(err, filesystem.ErrNotExist)
+	if err == nil && !fileHasSkylink(fi, ps.Skylink) {
+		err = errPinSiaPathUsed
+	}
+	if err != nil && !missing {
+		r.managedRecordPinCheck(ps, 0, false, err)
+		return
+	}
+
+	// Fetch the roots of the fanout chunks. If the base sector can't be
+	// fetched, only the base sector is checked.
+	chunks, minPieces, fanoutErr := r.managedPinFanoutChunks(skylink)
+	if fanoutErr != nil {
+		r.log.Debugf("Unable to fetch fanout of pinned skylink %v: %v", ps.Skylink, fanoutErr)
+	}
+	roots := []crypto.Hash{skylink.MerkleRoot()}
+	seen := map[crypto.Hash]struct{}{skylink.MerkleRoot(): {}}
+	for _, chunk := range chunks {
+		for _, root := range chunk {
+			if _, exists := seen[root]; !exists {
+				seen[root] = struct{}{}
+				roots = append(roots, root)
+			}
+		}
+	}
+
+	// Count the hosts storing the base sector and the fanout pieces.
+	target := int(ps.BaseChunkRedundancy)
+	responded, hostCounts := r.managedPinHostCount(roots)
+	hosts := hostCounts[skylink.MerkleRoot()]
+	healthy := !missing && hosts >= target && fanoutChunksHealthy(chunks, hostCounts, minPieces)
+	switch {
+	case healthy:
+	case !missing && responded < target:
+		err = fmt.Errorf("only %v hosts responded to the health check, %v are needed", responded, target)
+	case time.Since(ps.LastPin) < pinRepinCooldown:
+		err = errors.New("waiting for the cooldown of the last pin attempt to expire")
+	default:
+		// Pin the skylink again.
+		err = r.managedRepin(skylink, ps)
+		if err != nil {
+			r.log.Printf("Unable to pin skylink %v again: %v", ps.Skylink, err)
+		} else {
+			r.log.Printf("Pinned skylink %v again, %v hosts stored its base sector", ps.Skylink, hosts)
+		}
+		if recordErr := r.staticPinManager.managedRecordPin(ps.Skylink, err); recordErr != nil {
+			r.log.Print("Unable to persist skynet pins:", recordErr)
+		}
+		if err == nil {
+			_, hostCounts = r.managedPinHostCount(roots)
+			hosts = hostCounts[skylink.MerkleRoot()]
+			healthy = hosts >= target && fanoutChunksHealthy(chunks, hostCounts, minPieces)
+		} else {
+			err = errors.AddContext(err, "unable to pin skylink again")
+		}
+	}
+	r.managedRecordPinCheck(ps, hosts, healthy, err)
+}
+
+// managedRepin pins a skylink again. The skylink is pinned to a temporary
+// siapath first and only replaces the siafiles of the pin once pinning
+// succeeded. That way a failed attempt doesn't delete the existing siafiles.
+func (r *Renter) managedRepin(skylink modules.Skylink, ps modules.SkynetPinStatus) error {
+	tmpPath, err := modules.TempFolder.Join(persist.RandomSuffix())
+	if err != nil {
+		return errors.AddContext(err, "unable to create temporary siapath")
+	}
+	tmpExtendedPath, err := modules.NewTurtleDexPath(tmpPath.String() + modules.ExtendedSuffix)
+	if err != nil {
+		return errors.AddContext(err, "unable to create temporary extended siapath")
+	}
+	extendedPath, err := modules.NewTurtleDexPath(ps.TurtleDexPath.String() + modules.ExtendedSuffix)
+	if err != nil {
+		return errors.AddContext(err, "unable to create extended siapath")
+	}
+
+	// Pin the skylink to the temporary siapath. A partial pin isn't a version
+	// of the pin's siafiles so it is deleted without archiving it.
+	lup := modules.SkyfileUploadParameters{
+		TurtleDexPath:       tmpPath,
+		BaseChunkRedundancy: ps.BaseChunkRedundancy,
+	}
+	err = r.PinSkylink(skylink, lup, pinTimeout, pinPricePerMS)
+	if err != nil {
+		return errors.Compose(err, r.managedDeleteTmpPin(tmpPath), r.managedDeleteTmpPin(tmpExtendedPath))
+	}
+
+	// Replace the siafiles of the pin. The extended siafile only exists if
+	// the skyfile has a fanout.
+	_, err = r.File(tmpExtendedPath)
+	if err == nil {
+		err = r.managedReplaceFile(tmpExtendedPath, extendedPath)
+	} else if errors.Contains(err, filesystem.ErrNotExist) {
+		err = nil
+	}
+	if err != nil {
+		return errors.Compose(err, r.managedDeleteTmpPin(tmpPath), r.managedDeleteTmpPin(tmpExtendedPath))
+	}
+	err = r.managedReplaceFile(tmpPath, ps.TurtleDexPath)
+	if err != nil {
+		return errors.Compose(err, r.managedDeleteTmpPin(tmpPath))
+	}
+	return nil
+}
+
+// managedDeleteTmpPin deletes a siafile of a failed pin attempt if it exists.
+func (r *Renter) managedDeleteTmpPin(siaPath modules.TurtleDexPath) error {
+	err := r.managedDeleteFile(siaPath)
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// managedPinFanoutChunks fetches the base sector of a skylink and returns the
+// roots of its fanout chunks and the minimum number of pieces a healthy fanout
+// chunk needs to be stored on.
+func (r *Renter) managedPinFanoutChunks(skylink modules.Skylink) ([][]crypto.Hash, int, error) {
+	baseSector, err := r.DownloadByRoot(skylink.MerkleRoot(), 0, modules.SectorSize, pinTimeout, pinPricePerMS)
+	if err != nil {
+		return nil, 0, errors.AddContext(err, "unable to fetch base sector")
+	}
+	if modules.IsEncryptedBaseSector(baseSector) {
+		_, err = r.decryptBaseSector(baseSector)
+		if err != nil {
+			return nil, 0, errors.AddContext(err, "unable to decrypt base sector")
+		}
+	}
+	layout, fanoutBytes, _, _, err := modules.ParseSkyfileMetadata(baseSector)
+	if err != nil {
+		return nil, 0, errors.AddContext(err, "unable to parse base sector")
+	}
+	chunks, err := layout.DecodeFanoutIntoChunks(fanoutBytes)
+	if err != nil {
+		return nil, 0, errors.AddContext(err, "unable to decode fanout")
+	}
+	return chunks, int(layout.FanoutDataPieces) + int(layout.FanoutParityPieces), nil
+}
+
+// fanoutChunksHealthy returns whether every fanout chunk is stored on at least
+// minPieces hosts. The hosts storing the pieces of a chunk are summed up since
+// the pieces of a chunk are either all different or, for 1-of-N erasure
+// coding, a single piece which is stored on N hosts.
+func fanoutChunksHealthy(chunks [][]crypto.Hash, hostCounts map[crypto.Hash]int, minPieces int) bool {
+	for _, chunk := range chunks {
+		pieces := 0
+		for _, root := range chunk {
+			pieces += hostCounts[root]
+		}
+		if pieces < minPieces {
+			return false
+		}
+	}
+	return true
+}
+
+// managedRecordPinCheck records the result of a health check and logs a
+// failure to persist it.
+func (r *Renter) managedRecordPinCheck(ps modules.SkynetPinStatus, hosts int, healthy bool, err error) {
+	if recordErr := r.staticPinManager.managedRecordCheck(ps.Skylink, uint64(hosts), healthy, err); recordErr != nil {
+		r.log.Print("Unable to persist skynet pins:", recordErr)
+	}
+}
+
+// managedPinHostCount launches a HasSector job for the roots on every worker
+// and returns how many workers responded and how many of them store each of
+// the roots.
+func (r *Renter) managedPinHostCount(roots []crypto.Hash) (responded int, hosts map[crypto.Hash]int) {
+	hosts = make(map[crypto.Hash]int)
+	ctx, cancel := context.WithTimeout(r.tg.StopCtx(), pinTimeout)
+	defer cancel()
+
+	// The response channel is buffered to hold a response from every worker
+	// so that no worker blocks sending its response.
+	workers := r.staticWorkerPool.callWorkers()
+	responseChan := make(chan *jobHasSectorResponse, len(workers))
+	launched := 0
+	for _, w := range workers {
+		if w.staticJobHasSectorQueue.callAdd(w.newJobHasSector(ctx, responseChan, roots...)) {
+			launched++
+		}
+	}
+	for i := 0; i < launched; i++ {
+		var resp *jobHasSectorResponse
+		select {
+		case resp = <-responseChan:
+		case <-ctx.Done():
+			return
+		}
+		if resp.staticErr != nil {
+			continue
+		}
+		responded++
+		for j, available := range resp.staticAvailables {
+			if available && j < len(roots) {
+				hosts[roots[j]]++
+			}
+		}
+	}
+	return
+}
+
+// fileHasSkylink returns true if the skylink is one of the file's skylinks.
+func fileHasSkylink(fi modules.FileInfo, skylink string) bool {
+	for _, sl := range fi.Skylinks {
+		if sl == skylink {
+			return true
+		}
+	}
+	return false
+}
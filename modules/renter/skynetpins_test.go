@@ -0,0 +1,152 @@
+package renter
+
+import (
+	"os"
+	"testing"
+
+	"github.com/turtledex/errors"
+	"github.com/turtledex/fastrand"
+
+	"github.com/turtledex/TurtleDexCore/build"
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
+)
+
+// TestPinManager tests adding, removing and persisting skynet pins.
+func TestPinManager(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir("renter", t.Name())
+	if err := os.MkdirAll(dir, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	pm, err := newPinManager(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pinA := modules.SkynetPin{
+		Skylink:             "a",
+		Label:               "label",
+		TurtleDexPath:       modules.RandomTurtleDexPath(),
+		BaseChunkRedundancy: 3,
+	}
+	pinB := modules.SkynetPin{
+		Skylink:             "b",
+		TurtleDexPath:       modules.RandomTurtleDexPath(),
+		BaseChunkRedundancy: 3,
+	}
+	for _, pin := range []modules.SkynetPin{pinA, pinB} {
+		if err := pm.managedAdd(pin); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := pm.managedAdd(pinA); !errors.Contains(err, errPinExists) {
+		t.Fatal("expected errPinExists", err)
+	}
+	pinC := pinB
+	pinC.Skylink = "c"
+	if err := pm.managedAdd(pinC); !errors.Contains(err, errPinSiaPathUsed) {
+		t.Fatal("expected errPinSiaPathUsed", err)
+	}
+
+	// Adding a pin triggers a check.
+	pm.callTrigger()
+	select {
+	case <-pm.staticTriggerChan:
+	default:
+		t.Fatal("check wasn't triggered")
+	}
+
+	// Record a check and a pin.
+	if err := pm.managedRecordPin(pinA.Skylink, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := pm.managedRecordCheck(pinA.Skylink, 3, true, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := pm.managedRecordCheck(pinB.Skylink, 1, false, errors.New("failure")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reload the pins. Pins without a label are sorted first.
+	pm, err = newPinManager(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses := pm.managedStatus()
+	if len(statuses) != 2 || statuses[0].SkynetPin != pinB || statuses[1].SkynetPin != pinA {
+		t.Fatal("wrong pins", statuses)
+	}
+	if a := statuses[1]; !a.Healthy || a.Hosts != 3 || a.Repins != 1 || a.LastPin.IsZero() || a.LastCheck.IsZero() || a.LastError != "" {
+		t.Fatal("wrong status", a)
+	}
+	if b := statuses[0]; b.Healthy || b.Hosts != 1 || b.Repins != 0 || b.LastError != "failure" {
+		t.Fatal("wrong status", b)
+	}
+
+	// Remove a pin.
+	pin, err := pm.managedRemove(pinA.Skylink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pin != pinA {
+		t.Fatal("wrong pin removed", pin)
+	}
+	if _, err := pm.managedRemove(pinA.Skylink); !errors.Contains(err, errPinNotFound) {
+		t.Fatal("expected errPinNotFound", err)
+	}
+	// Recording a check of a removed pin is a no-op.
+	if err := pm.managedRecordCheck(pinA.Skylink, 3, true, nil); err != nil {
+		t.Fatal(err)
+	}
+	pm, err = newPinManager(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if statuses := pm.managedStatus(); len(statuses) != 1 || statuses[0].Skylink != pinB.Skylink {
+		t.Fatal("wrong pins", statuses)
+	}
+}
+
+// TestFileHasSkylink is a unit test for fileHasSkylink.
+func TestFileHasSkylink(t *testing.T) {
+	fi := modules.FileInfo{Skylinks: []string{"a", "b"}}
+	if !fileHasSkylink(fi, "b") || fileHasSkylink(fi, "c") || fileHasSkylink(modules.FileInfo{}, "a") {
+		t.Fatal("wrong result")
+	}
+}
+
+// TestFanoutChunksHealthy is a unit test for fanoutChunksHealthy.
+func TestFanoutChunksHealthy(t *testing.T) {
+	var a, b, c crypto.Hash
+	fastrand.Read(a[:])
+	fastrand.Read(b[:])
+	fastrand.Read(c[:])
+
+	// A skyfile without a fanout is always healthy.
+	if !fanoutChunksHealthy(nil, nil, 3) {
+		t.Fatal("skyfile without fanout should be healthy")
+	}
+
+	// 1-of-3 chunks consist of a single piece stored on 3 hosts.
+	hostCounts := map[crypto.Hash]int{a: 3, b: 2}
+	if !fanoutChunksHealthy([][]crypto.Hash{{a}}, hostCounts, 3) {
+		t.Fatal("chunk stored on 3 hosts should be healthy")
+	}
+	if fanoutChunksHealthy([][]crypto.Hash{{a}, {b}}, hostCounts, 3) {
+		t.Fatal("chunk stored on 2 hosts shouldn't be healthy")
+	}
+
+	// The pieces of a 2-of-3 chunk are summed up.
+	hostCounts = map[crypto.Hash]int{a: 1, b: 1, c: 1}
+	if !fanoutChunksHealthy([][]crypto.Hash{{a, b, c}}, hostCounts, 3) {
+		t.Fatal("chunk with all pieces should be healthy")
+	}
+	delete(hostCounts, c)
+	if fanoutChunksHealthy([][]crypto.Hash{{a, b, c}}, hostCounts, 3) {
+		t.Fatal("chunk with a missing piece shouldn't be healthy")
+	}
+}
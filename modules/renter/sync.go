@@ -0,0 +1,814 @@
+package renter
+
+// sync.go implements the renter's sync folders. A sync folder is a local
+// directory which the renter keeps in sync with a TurtleDex directory.
+//
+// Every sync folder is handled by its own thread which scans the local
+// directory whenever it is notified of filesystem events or, if the folder
+// can't be watched, periodically. A scan compares the local files with their
+// state when they were last synced. New and changed files are uploaded with
+// their local path as tracking path. A new file with the same size and
+// modification time as a missing one is considered a rename. Renames and
+// deletions are only propagated to the TurtleDex directory if the folder is
+// configured to do so.
+//
+// The renter remembers the size and creation time of every TurtleDex file it
+// uploads. If the TurtleDex file doesn't match them anymore when the local
+// file changes, the TurtleDex file was replaced by someone else. Instead of
+// overwriting it, the renter reports a conflict and tries again during the
+// next scan.
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem"
+	"github.com/turtledex/TurtleDexCore/persist"
+)
+
+const (
+	// syncPersistFile is the name of the file the sync folders are persisted
+	// to.
+	syncPersistFile = "sync.json"
+
+	// syncConflictRemoteChanged is the reason of a conflict caused by a
+	// TurtleDex file which changed since it was last synced.
+	syncConflictRemoteChanged = "the TurtleDex file was changed since it was last synced"
+
+	// syncConflictRemoteExists is the reason of a conflict caused by a
+	// TurtleDex file which wasn't uploaded by the sync folder.
+	syncConflictRemoteExists = "a TurtleDex file which wasn't uploaded from the local file already exists"
+)
+
+var (
+	// syncPersistMetadata is the metadata of the sync folder persist file.
+	syncPersistMetadata = persist.Metadata{
+		Header:  "Renter Sync Folders",
+		Version: "1.5.5",
+	}
+
+	// errSyncFolderExists is returned when adding a sync folder for a local
+	// directory which is already synced.
+	errSyncFolderExists = errors.New("the local directory is already synced")
+
+	// errSyncFolderNotAbs is returned when adding a sync folder with a
+	// relative local path.
+	errSyncFolderNotAbs = errors.New("the local path of a sync folder needs to be absolute")
+
+	// errSyncFolderNotDir is returned when adding a sync folder for a local
+	// path which isn't a directory.
+	errSyncFolderNotDir = errors.New("the local path of a sync folder needs to be a directory")
+
+	// errSyncFolderNotFound is returned when removing a sync folder that
+	// doesn't exist.
+	errSyncFolderNotFound = errors.New("no sync folder found for the local directory")
+
+	// errSyncFolderOverlap is returned when adding a sync folder for a local
+	// directory within another sync folder or containing one.
+	errSyncFolderOverlap = errors.New("the local directory overlaps with another sync folder")
+
+	// errVarFolderSync is returned when trying to sync a local directory with
+	// a directory within the VarFolder.
+	errVarFolderSync = errors.New("local directories can't be synced with the var folder")
+)
+
+type (
+	// syncer keeps track of the renter's sync folders.
+	syncer struct {
+		folders map[string]*syncFolder
+
+		staticPersistPath string
+		mu                sync.Mutex
+	}
+
+	// syncFolder is a local directory the renter keeps in sync with a
+	// TurtleDex directory.
+	syncFolder struct {
+		config modules.SyncFolderConfig
+
+		// files contains the state of the synced files by their path relative
+		// to the local directory, using forward slashes.
+		files     map[string]syncFileState
+		conflicts map[string]modules.SyncConflict
+
+		watchMode modules.SyncWatchMode
+		lastSync  time.Time
+		uploads   uint64
+		deletions uint64
+		renames   uint64
+		lastError string
+
+		// threadStarted indicates whether the thread syncing the folder was
+		// started. staticStopChan is closed when the sync folder is removed.
+		threadStarted  bool
+		staticStopChan chan struct{}
+	}
+
+	// syncFileState is the state of a local file when it was last synced.
+	// RemoteSize and RemoteCreateTime identify the TurtleDex file which was
+	// uploaded from it.
+	syncFileState struct {
+		Size             int64     `json:"size"`
+		ModTime          time.Time `json:"modtime"`
+		RemoteSize       uint64    `json:"remotesize"`
+		RemoteCreateTime time.Time `json:"remotecreatetime"`
+	}
+
+	// syncLocalFile is a file found while scanning a sync folder. Files which
+	// are settling were modified too recently to be synced.
+	syncLocalFile struct {
+		size     int64
+		modTime  time.Time
+		settling bool
+	}
+
+	// syncRename is a synced file which was renamed locally.
+	syncRename struct {
+		from string
+		to   string
+	}
+
+	// syncWatcher notifies a sync folder of filesystem events within the
+	// directories it watches.
+	syncWatcher interface {
+		// Watch adds a directory to the watched directories. Watching a
+		// directory again is a no-op.
+		Watch(dir string) error
+
+		// Events returns a channel which receives a value after filesystem
+		// events happened.
+		Events() <-chan struct{}
+
+		// Close stops watching all directories.
+		Close() error
+	}
+
+	// syncFolderPersistence is the persisted form of a sync folder.
+	syncFolderPersistence struct {
+		Config    modules.SyncFolderConfig        `json:"config"`
+		Files     map[string]syncFileState        `json:"files"`
+		Conflicts map[string]modules.SyncConflict `json:"conflicts"`
+		LastSync  time.Time                       `json:"lastsync"`
+		Uploads   uint64                          `json:"uploads"`
+		Deletions uint64                          `json:"deletions"`
+		Renames   uint64                          `json:"renames"`
+	}
+
+	// syncPersistence is the persisted form of the syncer.
+	syncPersistence struct {
+		Folders []syncFolderPersistence `json:"folders"`
+	}
+)
+
+// newSyncFolder creates a new sync folder.
+func newSyncFolder(config modules.SyncFolderConfig) *syncFolder {
+	return &syncFolder{
+		config:         config,
+		files:          make(map[string]syncFileState),
+		conflicts:      make(map[string]modules.SyncConflict),
+		watchMode:      modules.SyncWatchPolling,
+		staticStopChan: make(chan struct{}),
+	}
+}
+
+// newSyncer loads the sync folders from disk or creates a new syncer.
+func newSyncer(persistDir string) (*syncer, error) {
+	s := &syncer{
+		folders:           make(map[string]*syncFolder),
+		staticPersistPath: filepath.Join(persistDir, syncPersistFile),
+	}
+	var sp syncPersistence
+	err := persist.LoadJSON(syncPersistMetadata, &sp, s.staticPersistPath)
+	if os.IsNotExist(err) {
+		return s, nil
+	} else if err != nil {
+		return nil, errors.AddContext(err, "failed to load sync folders")
+	}
+	for _, fp := range sp.Folders {
+		sf := newSyncFolder(fp.Config)
+		for path, state := range fp.Files {
+			sf.files[path] = state
+		}
+		for path, conflict := range fp.Conflicts {
+			sf.conflicts[path] = conflict
+		}
+		sf.lastSync = fp.LastSync
+		sf.uploads = fp.Uploads
+		sf.deletions = fp.Deletions
+		sf.renames = fp.Renames
+		s.folders[fp.Config.LocalPath] = sf
+	}
+	return s, nil
+}
+
+// saveSync persists the sync folders.
+func (s *syncer) saveSync() error {
+	sp := syncPersistence{
+		Folders: make([]syncFolderPersistence, 0, len(s.folders)),
+	}
+	for _, sf := range s.folders {
+		fp := syncFolderPersistence{
+			Config:    sf.config,
+			Files:     sf.files,
+			Conflicts: sf.conflicts,
+			LastSync:  sf.lastSync,
+			Uploads:   sf.uploads,
+			Deletions: sf.deletions,
+			Renames:   sf.renames,
+		}
+		sp.Folders = append(sp.Folders, fp)
+	}
+	return persist.SaveJSON(syncPersistMetadata, sp, s.staticPersistPath)
+}
+
+// managedAdd adds a new sync folder.
+func (s *syncer) managedAdd(config modules.SyncFolderConfig) (*syncFolder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.folders[config.LocalPath]; exists {
+		return nil, errSyncFolderExists
+	}
+	for localPath := range s.folders {
+		if pathContains(localPath, config.LocalPath) || pathContains(config.LocalPath, localPath) {
+			return nil, errSyncFolderOverlap
+		}
+	}
+	sf := newSyncFolder(config)
+	s.folders[config.LocalPath] = sf
+	return sf, s.saveSync()
+}
+
+// managedRemove removes a sync folder and stops its thread.
+func (s *syncer) managedRemove(localPath string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sf, exists := s.folders[localPath]
+	if !exists {
+		return errSyncFolderNotFound
+	}
+	close(sf.staticStopChan)
+	delete(s.folders, localPath)
+	return s.saveSync()
+}
+
+// managedFolders returns all sync folders.
+func (s *syncer) managedFolders() []*syncFolder {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	folders := make([]*syncFolder, 0, len(s.folders))
+	for _, sf := range s.folders {
+		folders = append(folders, sf)
+	}
+	return folders
+}
+
+// managedFolderState returns the config of a sync folder and a copy of the
+// state of its synced files.
+func (s *syncer) managedFolderState(sf *syncFolder) (modules.SyncFolderConfig, map[string]syncFileState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	files := make(map[string]syncFileState, len(sf.files))
+	for path, state := range sf.files {
+		files[path] = state
+	}
+	return sf.config, files
+}
+
+// managedClaimThread returns true if no thread was started for the sync folder
+// yet. Only the caller which receives true may sync the folder.
+func (s *syncer) managedClaimThread(sf *syncFolder) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sf.threadStarted {
+		return false
+	}
+	sf.threadStarted = true
+	return true
+}
+
+// managedSetWatchMode sets the watch mode of a sync folder.
+func (s *syncer) managedSetWatchMode(sf *syncFolder, mode modules.SyncWatchMode) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sf.watchMode = mode
+}
+
+// managedRecordUpload records that a file was uploaded or that an existing
+// TurtleDex file was adopted if uploaded is false.
+func (s *syncer) managedRecordUpload(sf *syncFolder, path string, state syncFileState, uploaded bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sf.files[path] = state
+	delete(sf.conflicts, path)
+	if uploaded {
+		sf.uploads++
+	}
+}
+
+// managedRecordRename records that a file was renamed.
+func (s *syncer) managedRecordRename(sf *syncFolder, rn syncRename) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sf.files[rn.to] = sf.files[rn.from]
+	delete(sf.files, rn.from)
+	delete(sf.conflicts, rn.from)
+	delete(sf.conflicts, rn.to)
+	sf.renames++
+}
+
+// managedRecordRemoval records that a local file is gone. deleted indicates
+// whether the TurtleDex file was deleted as well.
+func (s *syncer) managedRecordRemoval(sf *syncFolder, path string, deleted bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(sf.files, path)
+	delete(sf.conflicts, path)
+	if deleted {
+		sf.deletions++
+	}
+}
+
+// managedRecordConflict records a conflict. The oldest conflicts are dropped
+// if there are more than syncMaxConflicts.
+func (s *syncer) managedRecordConflict(sf *syncFolder, path string, siaPath modules.TurtleDexPath, reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	conflict, exists := sf.conflicts[path]
+	if exists && conflict.Reason == reason {
+		return
+	}
+	sf.conflicts[path] = modules.SyncConflict{
+		LocalPath:     filepath.Join(sf.config.LocalPath, filepath.FromSlash(path)),
+		TurtleDexPath: siaPath,
+		Reason:        reason,
+		Time:          time.Now(),
+	}
+	for len(sf.conflicts) > syncMaxConflicts {
+		var oldest string
+		for p, c := range sf.conflicts {
+			if oldest == "" || c.Time.Before(sf.conflicts[oldest].Time) {
+				oldest = p
+			}
+		}
+		delete(sf.conflicts, oldest)
+	}
+}
+
+// managedFinishSync records the end of a scan of a sync folder and persists
+// its state.
+func (s *syncer) managedFinishSync(sf *syncFolder, err error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sf.lastSync = time.Now()
+	sf.lastError = ""
+	if err != nil {
+		sf.lastError = err.Error()
+	}
+	if _, exists := s.folders[sf.config.LocalPath]; !exists {
+		// The folder was removed during the scan.
+		return nil
+	}
+	return s.saveSync()
+}
+
+// managedStatus returns the status of all sync folders sorted by their local
+// path.
+func (s *syncer) managedStatus() []modules.SyncFolderStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	statuses := make([]modules.SyncFolderStatus, 0, len(s.folders))
+	for _, sf := range s.folders {
+		status := modules.SyncFolderStatus{
+			SyncFolderConfig: sf.config,
+			WatchMode:        sf.watchMode,
+			LastSync:         sf.lastSync,
+			SyncedFiles:      uint64(len(sf.files)),
+			Uploads:          sf.uploads,
+			Deletions:        sf.deletions,
+			Renames:          sf.renames,
+			Conflicts:        make([]modules.SyncConflict, 0, len(sf.conflicts)),
+			LastError:        sf.lastError,
+		}
+		for _, conflict := range sf.conflicts {
+			status.Conflicts = append(status.Conflicts, conflict)
+		}
+		sort.Slice(status.Conflicts, func(i, j int) bool {
+			return status.Conflicts[i].Time.Before(status.Conflicts[j].Time)
+		})
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].LocalPath < statuses[j].LocalPath
+	})
+	return statuses
+}
+
+// pathContains returns true if the local path child is within the local
+// directory parent.
+func pathContains(parent, child string) bool {
+	rel, err := filepath.Rel(parent, child)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// syncRemoteMatches returns true if the TurtleDex file is the one which was
+// uploaded when the local file was last synced.
+func syncRemoteMatches(state syncFileState, remote modules.FileInfo) bool {
+	return state.RemoteSize == remote.Filesize && state.RemoteCreateTime.Equal(remote.CreateTime)
+}
+
+// syncDiff compares the files found in a sync folder with the state of the
+// files when they were last synced. It returns the new and changed files which
+// need to be uploaded, the renamed files and the missing files. Files which
+// are settling are neither uploaded nor considered missing. If detectRenames
+// is false, renamed files are reported as a new and a missing file.
+func syncDiff(local map[string]syncLocalFile, synced map[string]syncFileState, detectRenames bool) (changed []string, renames []syncRename, missing []string) {
+	var added []string
+	for path, lf := range local {
+		if lf.settling {
+			continue
+		}
+		state, exists := synced[path]
+		if !exists {
+			added = append(added, path)
+		} else if state.Size != lf.size || !state.ModTime.Equal(lf.modTime) {
+			changed = append(changed, path)
+		}
+	}
+	for path := range synced {
+		if _, exists := local[path]; !exists {
+			missing = append(missing, path)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(missing)
+
+	// Renaming a file doesn't change its size and modification time. Match
+	// every new file with the first missing file which has the same size and
+	// modification time.
+	if detectRenames {
+		remainingAdded := added[:0]
+		for _, path := range added {
+			lf := local[path]
+			match := -1
+			for i, from := range missing {
+				state := synced[from]
+				if state.Size == lf.size && state.ModTime.Equal(lf.modTime) {
+					match = i
+					break
+				}
+			}
+			if match == -1 {
+				remainingAdded = append(remainingAdded, path)
+				continue
+			}
+			renames = append(renames, syncRename{from: missing[match], to: path})
+			missing = append(missing[:match], missing[match+1:]...)
+		}
+		added = remainingAdded
+	}
+	changed = append(changed, added...)
+	sort.Strings(changed)
+	return changed, renames, missing
+}
+
+// syncWalk returns the regular files within a local directory by their path
+// relative to the directory, using forward slashes. Every directory is passed
+// to watch if it is not nil. The walk fails if any directory can't be read to
+// avoid mistaking its files for deleted ones.
+func syncWalk(root string, watch func(dir string)) (map[string]syncLocalFile, error) {
+	files := make(map[string]syncLocalFile)
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if watch != nil {
+				watch(path)
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = syncLocalFile{
+			size:     info.Size(),
+			modTime:  info.ModTime(),
+			settling: time.Since(info.ModTime()) < syncSettleTime,
+		}
+		return nil
+	})
+	return files, err
+}
+
+// AddSyncFolder starts syncing a local directory with a TurtleDex directory.
+func (r *Renter) AddSyncFolder(config modules.SyncFolderConfig) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if !filepath.IsAbs(config.LocalPath) {
+		return errSyncFolderNotAbs
+	}
+	config.LocalPath = filepath.Clean(config.LocalPath)
+	fi, err := os.Stat(config.LocalPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to stat local directory")
+	}
+	if !fi.IsDir() {
+		return errSyncFolderNotDir
+	}
+	if isVarPath(config.TurtleDexPath) {
+		return errVarFolderSync
+	}
+	sf, err := r.staticSyncer.managedAdd(config)
+	if err != nil {
+		return err
+	}
+	go r.threadedSyncFolder(sf)
+	return nil
+}
+
+// RemoveSyncFolder stops syncing a local directory. Files which were already
+// uploaded are kept.
+func (r *Renter) RemoveSyncFolder(localPath string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	return r.staticSyncer.managedRemove(filepath.Clean(localPath))
+}
+
+// SyncFolders returns the status of the renter's sync folders.
+func (r *Renter) SyncFolders() ([]modules.SyncFolderStatus, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+	return r.staticSyncer.managedStatus(), nil
+}
+
+// threadedSyncFolder keeps a sync folder in sync until it is removed or the
+// renter shuts down.
+func (r *Renter) threadedSyncFolder(sf *syncFolder) {
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+	if !r.staticSyncer.managedClaimThread(sf) {
+		return
+	}
+
+	// Watch the folder for filesystem events if possible.
+	var events <-chan struct{}
+	w, err := newSyncWatcher()
+	if err != nil {
+		r.log.Printf("Unable to watch sync folder %v, falling back to polling: %v", sf.config.LocalPath, err)
+		w = nil
+	} else {
+		defer func() {
+			if err := w.Close(); err != nil {
+				r.log.Printf("Unable to close watcher of sync folder %v: %v", sf.config.LocalPath, err)
+			}
+		}()
+		events = w.Events()
+		r.staticSyncer.managedSetWatchMode(sf, modules.SyncWatchInotify)
+	}
+
+	for {
+		settling := r.managedSyncFolder(sf, w)
+
+		// Scan the folder again once settling files are old enough to be
+		// synced, after filesystem events or after the poll interval.
+		wait := syncPollInterval
+		if settling && syncSettleTime < wait {
+			wait = syncSettleTime
+		}
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-sf.staticStopChan:
+			return
+		case <-time.After(wait):
+		case <-events:
+			if !r.managedAwaitSyncEvents(sf, events) {
+				return
+			}
+		}
+	}
+}
+
+// managedAwaitSyncEvents waits until no filesystem events happened within a
+// sync folder for syncEventDelay. It returns false if the sync folder was
+// removed or the renter shuts down in the meantime.
+func (r *Renter) managedAwaitSyncEvents(sf *syncFolder, events <-chan struct{}) bool {
+	for {
+		select {
+		case <-r.tg.StopChan():
+			return false
+		case <-sf.staticStopChan:
+			return false
+		case <-events:
+		case <-time.After(syncEventDelay):
+			return true
+		}
+	}
+}
+
+// managedSyncFolder scans a sync folder and syncs the changed files. It
+// returns true if files were skipped because they are still settling.
+func (r *Renter) managedSyncFolder(sf *syncFolder, w syncWatcher) (settling bool) {
+	config, synced := r.staticSyncer.managedFolderState(sf)
+	var syncErr error
+	defer func() {
+		if err := r.staticSyncer.managedFinishSync(sf, syncErr); err != nil {
+			r.log.Printf("Unable to persist sync folder %v: %v", config.LocalPath, err)
+		}
+	}()
+
+	var watch func(string)
+	if w != nil {
+		watch = func(dir string) {
+			if err := w.Watch(dir); err != nil {
+				syncErr = errors.AddContext(err, "unable to watch directory "+dir)
+			}
+		}
+	}
+	local, err := syncWalk(config.LocalPath, watch)
+	if err != nil {
+		syncErr = errors.AddContext(err, "unable to scan local directory")
+		return false
+	}
+	for _, lf := range local {
+		settling = settling || lf.settling
+	}
+
+	changed, renames, missing := syncDiff(local, synced, config.PropagateRenames)
+	handleErr := func(path string, err error) {
+		if err != nil {
+			r.log.Printf("Unable to sync %v in sync folder %v: %v", path, config.LocalPath, err)
+			syncErr = err
+		}
+	}
+	for _, rn := range renames {
+		renamed, err := r.managedSyncRename(sf, config, rn, synced[rn.from])
+		handleErr(rn.to, err)
+		if err == nil && !renamed {
+			// The TurtleDex file can't be renamed, handle the rename like a
+			// deletion and a new file instead.
+			missing = append(missing, rn.from)
+			changed = append(changed, rn.to)
+		}
+	}
+	for _, path := range changed {
+		state, exists := synced[path]
+		handleErr(path, r.managedSyncUpload(sf, config, path, local[path], state, exists))
+	}
+	for _, path := range missing {
+		handleErr(path, r.managedSyncRemoval(sf, config, path, synced[path]))
+	}
+	return settling
+}
+
+// managedSyncRemoteFile returns the TurtleDex file of a synced file and
+// whether it exists.
+func (r *Renter) managedSyncRemoteFile(siaPath modules.TurtleDexPath) (modules.FileInfo, bool, error) {
+	remote, err := r.File(siaPath)
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		return modules.FileInfo{}, false, nil
+	}
+	return remote, err == nil, err
+}
+
+// managedSyncUpload uploads a new or changed local file unless the TurtleDex
+// file was changed since the local file was last synced.
+func (r *Renter) managedSyncUpload(sf *syncFolder, config modules.SyncFolderConfig, path string, lf syncLocalFile, state syncFileState, synced bool) error {
+	siaPath, err := config.TurtleDexPath.Join(path)
+	if err != nil {
+		return err
+	}
+	localPath := filepath.Join(config.LocalPath, filepath.FromSlash(path))
+	remote, exists, err := r.managedSyncRemoteFile(siaPath)
+	if err != nil {
+		return err
+	}
+	if exists {
+		switch {
+		case synced && syncRemoteMatches(state, remote):
+			// The TurtleDex file was uploaded by the sync folder and can be
+			// replaced.
+		case !synced && remote.LocalPath == localPath && remote.Filesize == uint64(lf.size):
+			// The TurtleDex file was uploaded from the local file before the
+			// sync folder was added. Adopt it instead of uploading it again.
+			r.staticSyncer.managedRecordUpload(sf, path, syncFileState{
+				Size:             lf.size,
+				ModTime:          lf.modTime,
+				RemoteSize:       remote.Filesize,
+				RemoteCreateTime: remote.CreateTime,
+			}, false)
+			return nil
+		case synced:
+			r.staticSyncer.managedRecordConflict(sf, path, siaPath, syncConflictRemoteChanged)
+			return nil
+		default:
+			r.staticSyncer.managedRecordConflict(sf, path, siaPath, syncConflictRemoteExists)
+			return nil
+		}
+	}
+
+	err = r.Upload(modules.FileUploadParams{
+		Source:        localPath,
+		TurtleDexPath: siaPath,
+		Force:         exists,
+	})
+	if err != nil {
+		return errors.AddContext(err, "unable to upload file")
+	}
+	remote, err = r.File(siaPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to get uploaded file")
+	}
+	r.staticSyncer.managedRecordUpload(sf, path, syncFileState{
+		Size:             lf.size,
+		ModTime:          lf.modTime,
+		RemoteSize:       remote.Filesize,
+		RemoteCreateTime: remote.CreateTime,
+	}, true)
+	return nil
+}
+
+// managedSyncRename renames the TurtleDex file of a locally renamed file. It
+// returns false if the TurtleDex file can't be renamed because it was changed
+// since it was last synced or because the new path is taken.
+func (r *Renter) managedSyncRename(sf *syncFolder, config modules.SyncFolderConfig, rn syncRename, state syncFileState) (bool, error) {
+	from, err := config.TurtleDexPath.Join(rn.from)
+	if err != nil {
+		return false, err
+	}
+	to, err := config.TurtleDexPath.Join(rn.to)
+	if err != nil {
+		return false, err
+	}
+	remote, exists, err := r.managedSyncRemoteFile(from)
+	if err != nil || !exists || !syncRemoteMatches(state, remote) {
+		return false, err
+	}
+	_, exists, err = r.managedSyncRemoteFile(to)
+	if err != nil || exists {
+		return false, err
+	}
+	err = r.RenameFile(from, to)
+	if err != nil {
+		return false, errors.AddContext(err, "unable to rename file")
+	}
+	// Point the renamed file to its new location on disk so that it can still
+	// be repaired from the local file.
+	err = r.SetFileTrackingPath(to, filepath.Join(config.LocalPath, filepath.FromSlash(rn.to)))
+	if err != nil {
+		r.log.Printf("Unable to update tracking path of %v: %v", to, err)
+	}
+	r.staticSyncer.managedRecordRename(sf, rn)
+	return true, nil
+}
+
+// managedSyncRemoval handles a synced file which no longer exists locally. If
+// deletions are propagated, the TurtleDex file is deleted unless it was
+// changed since the local file was last synced.
+func (r *Renter) managedSyncRemoval(sf *syncFolder, config modules.SyncFolderConfig, path string, state syncFileState) error {
+	if !config.PropagateDeletions {
+		r.staticSyncer.managedRecordRemoval(sf, path, false)
+		return nil
+	}
+	siaPath, err := config.TurtleDexPath.Join(path)
+	if err != nil {
+		return err
+	}
+	remote, exists, err := r.managedSyncRemoteFile(siaPath)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		r.staticSyncer.managedRecordRemoval(sf, path, false)
+		return nil
+	}
+	if !syncRemoteMatches(state, remote) {
+		r.staticSyncer.managedRecordConflict(sf, path, siaPath, syncConflictRemoteChanged)
+		return nil
+	}
+	err = r.DeleteFile(siaPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to delete file")
+	}
+	r.staticSyncer.managedRecordRemoval(sf, path, true)
+	return nil
+}
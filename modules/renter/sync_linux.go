@@ -0,0 +1,91 @@
+// +build linux
+
+package renter
+
+import (
+	"os"
+	"sync"
+	"syscall"
+
+	"github.com/turtledex/errors"
+)
+
+// syncInotifyMask contains the inotify events which indicate that the
+// contents of a watched directory changed.
+const syncInotifyMask = syscall.IN_CLOSE_WRITE | syscall.IN_CREATE | syscall.IN_DELETE |
+	syscall.IN_MODIFY | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_ATTRIB
+
+// inotifyWatcher is a syncWatcher using inotify.
+type inotifyWatcher struct {
+	// staticFile wraps the nonblocking inotify file descriptor staticFD.
+	// This allows Close to interrupt a pending read. staticFile.Fd must not
+	// be used since it switches the descriptor to blocking mode.
+	staticFD     int
+	staticFile   *os.File
+	staticEvents chan struct{}
+
+	watched map[string]struct{}
+	mu      sync.Mutex
+}
+
+// newSyncWatcher creates a new inotify watcher.
+func newSyncWatcher() (syncWatcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_NONBLOCK | syscall.IN_CLOEXEC)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to initialize inotify")
+	}
+	w := &inotifyWatcher{
+		staticFD:     fd,
+		staticFile:   os.NewFile(uintptr(fd), "inotify"),
+		staticEvents: make(chan struct{}, 1),
+		watched:      make(map[string]struct{}),
+	}
+	go w.threadedReadEvents()
+	return w, nil
+}
+
+// threadedReadEvents reads the inotify events until the watcher is closed.
+// The events themselves are discarded since any event triggers a scan of the
+// whole sync folder.
+func (w *inotifyWatcher) threadedReadEvents() {
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		n, err := w.staticFile.Read(buf)
+		if err != nil {
+			return
+		}
+		if n == 0 {
+			continue
+		}
+		select {
+		case w.staticEvents <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Watch adds a directory to the watched directories.
+func (w *inotifyWatcher) Watch(dir string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, exists := w.watched[dir]; exists {
+		return nil
+	}
+	_, err := syscall.InotifyAddWatch(w.staticFD, dir, syncInotifyMask)
+	if err != nil {
+		return err
+	}
+	w.watched[dir] = struct{}{}
+	return nil
+}
+
+// Events returns the channel which receives a value after filesystem events
+// happened.
+func (w *inotifyWatcher) Events() <-chan struct{} {
+	return w.staticEvents
+}
+
+// Close stops watching all directories.
+func (w *inotifyWatcher) Close() error {
+	return w.staticFile.Close()
+}
@@ -0,0 +1,17 @@
+// +build !linux
+
+package renter
+
+import (
+	"github.com/turtledex/errors"
+)
+
+// errSyncWatchUnsupported is returned when sync folders can't be watched for
+// filesystem events on the current operating system.
+var errSyncWatchUnsupported = errors.New("watching for filesystem events is not supported on this operating system")
+
+// newSyncWatcher always returns an error since there is no syncWatcher for
+// this operating system. Sync folders are polled instead.
+func newSyncWatcher() (syncWatcher, error) {
+	return nil, errSyncWatchUnsupported
+}
@@ -0,0 +1,228 @@
+package renter
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/turtledex/TurtleDexCore/build"
+	"github.com/turtledex/TurtleDexCore/modules"
+)
+
+// TestSyncDiff is a unit test for syncDiff.
+func TestSyncDiff(t *testing.T) {
+	t1 := time.Unix(1000, 0)
+	t2 := time.Unix(2000, 0)
+	synced := map[string]syncFileState{
+		"unchanged": {Size: 1, ModTime: t1},
+		"changed":   {Size: 1, ModTime: t1},
+		"renamed":   {Size: 2, ModTime: t1},
+		"deleted":   {Size: 3, ModTime: t1},
+		"settling":  {Size: 4, ModTime: t1},
+	}
+	local := map[string]syncLocalFile{
+		"unchanged": {size: 1, modTime: t1},
+		"changed":   {size: 1, modTime: t2},
+		"dir/moved": {size: 2, modTime: t1},
+		"new":       {size: 5, modTime: t1},
+		"settling":  {size: 6, modTime: t2, settling: true},
+		"young":     {size: 7, modTime: t2, settling: true},
+	}
+
+	// Without rename detection the renamed file is new and missing.
+	changed, renames, missing := syncDiff(local, synced, false)
+	if !reflect.DeepEqual(changed, []string{"changed", "dir/moved", "new"}) {
+		t.Fatal("wrong changed files", changed)
+	}
+	if len(renames) != 0 {
+		t.Fatal("unexpected renames", renames)
+	}
+	if !reflect.DeepEqual(missing, []string{"deleted", "renamed"}) {
+		t.Fatal("wrong missing files", missing)
+	}
+
+	// With rename detection the renamed file is matched with the new file of
+	// the same size and modification time.
+	changed, renames, missing = syncDiff(local, synced, true)
+	if !reflect.DeepEqual(changed, []string{"changed", "new"}) {
+		t.Fatal("wrong changed files", changed)
+	}
+	if !reflect.DeepEqual(renames, []syncRename{{from: "renamed", to: "dir/moved"}}) {
+		t.Fatal("wrong renames", renames)
+	}
+	if !reflect.DeepEqual(missing, []string{"deleted"}) {
+		t.Fatal("wrong missing files", missing)
+	}
+}
+
+// TestPathContains is a unit test for pathContains.
+func TestPathContains(t *testing.T) {
+	tests := []struct {
+		parent, child string
+		contains      bool
+	}{
+		{"/a", "/a", true},
+		{"/a", "/a/b", true},
+		{"/a/b", "/a", false},
+		{"/a", "/ab", false},
+		{"/a", "/b/a", false},
+		{"/a", "/a/..b", true},
+	}
+	for _, test := range tests {
+		if contains := pathContains(test.parent, test.child); contains != test.contains {
+			t.Errorf("pathContains(%v, %v) should be %v", test.parent, test.child, test.contains)
+		}
+	}
+}
+
+// TestSyncWalk tests that syncWalk finds the regular files of a directory and
+// marks recently modified ones as settling.
+func TestSyncWalk(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir("renter", t.Name())
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	old := filepath.Join(dir, "sub", "old")
+	young := filepath.Join(dir, "young")
+	for _, path := range []string{old, young} {
+		if err := ioutil.WriteFile(path, []byte("data"), modules.DefaultFilePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	oldTime := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(old, oldTime, oldTime); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(old, filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	var watched []string
+	files, err := syncWalk(dir, func(d string) { watched = append(watched, d) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatal("wrong number of files", files)
+	}
+	if lf := files["sub/old"]; lf.size != 4 || lf.settling || !lf.modTime.Equal(oldTime) {
+		t.Fatal("wrong old file", lf)
+	}
+	if lf := files["young"]; !lf.settling {
+		t.Fatal("young file should be settling", lf)
+	}
+	if !reflect.DeepEqual(watched, []string{dir, filepath.Join(dir, "sub")}) {
+		t.Fatal("wrong watched directories", watched)
+	}
+
+	// Walking a missing directory fails.
+	if _, err := syncWalk(filepath.Join(dir, "missing"), nil); err == nil {
+		t.Fatal("walking a missing directory should fail")
+	}
+}
+
+// TestSyncerPersist tests adding, removing and persisting sync folders.
+func TestSyncerPersist(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir("renter", t.Name())
+	if err := os.MkdirAll(dir, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	s, err := newSyncer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := modules.SyncFolderConfig{
+		LocalPath:          "/sync/a",
+		TurtleDexPath:      modules.RandomTurtleDexPath(),
+		PropagateDeletions: true,
+	}
+	sf, err := s.managedAdd(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.managedAdd(config); err != errSyncFolderExists {
+		t.Fatal("expected errSyncFolderExists", err)
+	}
+	for _, localPath := range []string{"/sync", "/sync/a/b"} {
+		c := config
+		c.LocalPath = localPath
+		if _, err := s.managedAdd(c); err != errSyncFolderOverlap {
+			t.Fatal("expected errSyncFolderOverlap", localPath, err)
+		}
+	}
+	if !s.managedClaimThread(sf) || s.managedClaimThread(sf) {
+		t.Fatal("thread should only be claimed once")
+	}
+
+	// Record some activity.
+	state := syncFileState{Size: 1, ModTime: time.Unix(1000, 0), RemoteSize: 1, RemoteCreateTime: time.Unix(2000, 0)}
+	s.managedRecordUpload(sf, "file", state, true)
+	s.managedRecordRename(sf, syncRename{from: "file", to: "renamed"})
+	s.managedRecordConflict(sf, "conflict", config.TurtleDexPath, syncConflictRemoteExists)
+	if err := s.managedFinishSync(sf, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reload the syncer and check the status.
+	s, err = newSyncer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses := s.managedStatus()
+	if len(statuses) != 1 {
+		t.Fatal("wrong number of sync folders", len(statuses))
+	}
+	status := statuses[0]
+	if status.SyncFolderConfig != config || status.WatchMode != modules.SyncWatchPolling {
+		t.Fatal("wrong config", status)
+	}
+	if status.SyncedFiles != 1 || status.Uploads != 1 || status.Renames != 1 || status.Deletions != 0 {
+		t.Fatal("wrong counters", status)
+	}
+	if len(status.Conflicts) != 1 || status.Conflicts[0].LocalPath != filepath.Join("/sync/a", "conflict") {
+		t.Fatal("wrong conflicts", status.Conflicts)
+	}
+	sf = s.folders[config.LocalPath]
+	if _, files := s.managedFolderState(sf); !files["renamed"].RemoteCreateTime.Equal(state.RemoteCreateTime) {
+		t.Fatal("wrong file state", files)
+	}
+
+	// Removing a local file resolves its conflict.
+	s.managedRecordRemoval(sf, "conflict", false)
+	if len(s.managedStatus()[0].Conflicts) != 0 {
+		t.Fatal("conflict wasn't resolved")
+	}
+
+	// Remove the folder.
+	if err := s.managedRemove(config.LocalPath); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-sf.staticStopChan:
+	default:
+		t.Fatal("stop chan wasn't closed")
+	}
+	if err := s.managedRemove(config.LocalPath); err != errSyncFolderNotFound {
+		t.Fatal("expected errSyncFolderNotFound", err)
+	}
+	s, err = newSyncer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.managedStatus()) != 0 {
+		t.Fatal("folder wasn't removed")
+	}
+}
@@ -10,6 +10,8 @@ package renter
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 
 	"github.com/turtledex/errors"
@@ -52,6 +54,12 @@ func (r *Renter) Upload(up modules.FileUploadParams) error {
 		return errors.AddContext(err, "unable to close file after checking permissions")
 	}
 
+	// Compressed and deduplicated files are uploaded by streaming their data
+	// since their uploaded data differs from the local copy.
+	if up.Compression != modules.CompressionNone || up.Dedup {
+		return r.managedUploadStreamed(up)
+	}
+
 	// Delete existing file if overwrite flag is set. Ignore ErrUnknownPath.
 	if up.Force {
 		err := r.DeleteFile(up.TurtleDexPath)
@@ -62,7 +70,10 @@ func (r *Renter) Upload(up modules.FileUploadParams) error {
 
 	// Fill in any missing upload params with sensible defaults.
 	if up.ErasureCode == nil {
-		up.ErasureCode = modules.NewRSSubCodeDefault()
+		up.ErasureCode, err = r.managedUploadErasureCoder(up.TurtleDexPath)
+		if err != nil {
+			return err
+		}
 	}
 
 	// Check that we have contracts to upload to. We need at least data +
@@ -125,3 +136,101 @@ func (r *Renter) Upload(up modules.FileUploadParams) error {
 	}
 	return nil
 }
+
+// managedUploadStreamed creates the TurtleDexFile for a local file whose
+// uploaded data differs from the local copy and uploads it in the background by
+// streaming the data to the network. The size of the uploaded data is
+// determined up front, which means that the file is complete even if the
+// upload is interrupted. The local copy is tracked for repairs, which
+// transform the local data the same way.
+func (r *Renter) managedUploadStreamed(up modules.FileUploadParams) (err error) {
+	file, err := os.Open(up.Source)
+	if err != nil {
+		return errors.AddContext(err, "unable to open the source file")
+	}
+	defer func() {
+		if err != nil {
+			err = errors.Compose(err, file.Close())
+		}
+	}()
+	// The size of the file is set before the upload, which doesn't work with
+	// partial chunks.
+	up.DisablePartialChunk = true
+	var ct crypto.CipherType
+	if up.CipherType == ct {
+		up.CipherType = crypto.TypeDefaultRenter
+	}
+	fileNode, err := r.managedInitUploadStream(up)
+	if err != nil {
+		return errors.AddContext(err, "unable to create the file")
+	}
+	err = managedSetUploadDataSize(fileNode, file)
+	if err != nil {
+		return errors.Compose(errors.AddContext(err, "unable to determine the size of the uploaded data"), fileNode.Close())
+	}
+	go r.threadedUploadStreamed(fileNode, file)
+	return nil
+}
+
+// managedSetUploadDataSize reads the data of a local file the way it is
+// uploaded to set the size of the TurtleDexFile and the size of the original
+// data. The file is rewound afterwards.
+func managedSetUploadDataSize(fileNode *filesystem.FileNode, file *os.File) error {
+	cr := &countingReader{staticReader: file}
+	ur, err := newUploadDataReader(cr, fileNode.Compression(), fileNode.DedupLayout(), fileNode.ChunkSize())
+	if err != nil {
+		return err
+	}
+	size, err := io.Copy(ioutil.Discard, ur)
+	if err != nil {
+		return err
+	}
+	numChunks := uint64(size) / fileNode.ChunkSize()
+	if uint64(size)%fileNode.ChunkSize() != 0 {
+		numChunks++
+	}
+	if err := fileNode.GrowNumChunks(numChunks); err != nil {
+		return err
+	}
+	if err := fileNode.SetFileSize(uint64(size)); err != nil {
+		return err
+	}
+	if err := fileNode.SetDataSize(cr.n); err != nil {
+		return err
+	}
+	_, err = file.Seek(0, io.SeekStart)
+	return err
+}
+
+// threadedUploadStreamed streams the data of a local file to the network.
+// Chunks that aren't uploaded when the upload fails are repaired from the
+// local file by the repair loop.
+func (r *Renter) threadedUploadStreamed(fileNode *filesystem.FileNode, file *os.File) {
+	err := r.tg.Add()
+	if err != nil {
+		err = errors.Compose(err, file.Close(), fileNode.Close())
+		r.log.Debugln("Unable to upload", fileNode.TurtleDexFilePath(), err)
+		return
+	}
+	defer r.tg.Done()
+
+	err = r.callUploadStreamToFileNode(fileNode, file)
+	err = errors.Compose(err, file.Close(), fileNode.Close())
+	if err != nil {
+		r.log.Printf("Streamed upload of %v failed, leaving the remaining chunks to the repair loop: %v", fileNode.TurtleDexFilePath(), err)
+	}
+}
+
+// newUploadDataReader returns a reader for the data that is uploaded for the
+// data of r. The data of compressed files is compressed and the data of
+// deduplicated files is laid out in the layout of deduplicated files.
+func newUploadDataReader(r io.Reader, compression modules.CompressionType, dedupLayout bool, chunkSize uint64) (io.Reader, error) {
+	reader, err := modules.NewCompressionReader(compression, r)
+	if err != nil {
+		return nil, err
+	}
+	if dedupLayout {
+		reader = newDedupLayoutReader(reader, chunkSize)
+	}
+	return reader, nil
+}
@@ -1,12 +1,17 @@
 package renter
 
 import (
+	"bytes"
+	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/turtledex/TurtleDexCore/crypto"
 	"github.com/turtledex/TurtleDexCore/modules"
 	"github.com/turtledex/errors"
+	"github.com/turtledex/fastrand"
 )
 
 // TestRenterUploadDirectory verifies that the renter returns an error if a
@@ -48,3 +53,81 @@ func TestRenterUploadDirectory(t *testing.T) {
 		t.Fatal("expected ErrUploadDirectory, got", err)
 	}
 }
+
+// TestSetUploadDataSize verifies that the size of a compressed and
+// deduplicated file is set before its data is uploaded.
+func TestSetUploadDataSize(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Create a compressible local file spanning multiple chunks.
+	data := bytes.Repeat(fastrand.Bytes(1000), 2000)
+	source := filepath.Join(rt.dir, "file")
+	if err := ioutil.WriteFile(source, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	fileNode, err := rt.renter.managedInitUploadStream(modules.FileUploadParams{
+		Source:              source,
+		TurtleDexPath:       modules.RandomTurtleDexPath(),
+		CipherType:          crypto.TypeDefaultRenter,
+		Compression:         modules.CompressionGzip,
+		Dedup:               true,
+		DisablePartialChunk: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := fileNode.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if err := managedSetUploadDataSize(fileNode, file); err != nil {
+		t.Fatal(err)
+	}
+
+	// The file has the size of the transformed data and remembers the size
+	// of the original data.
+	ur, err := newUploadDataReader(bytes.NewReader(data), modules.CompressionGzip, true, fileNode.ChunkSize())
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploaded, err := ioutil.ReadAll(ur)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fileNode.Size() != uint64(len(uploaded)) {
+		t.Fatal("wrong file size", fileNode.Size(), len(uploaded))
+	}
+	if fileNode.LogicalSize() != uint64(len(data)) {
+		t.Fatal("wrong data size", fileNode.LogicalSize(), len(data))
+	}
+	if fileNode.LocalPath() != source {
+		t.Fatal("local path wasn't recorded", fileNode.LocalPath())
+	}
+
+	// The file is rewound.
+	if off, err := file.Seek(0, io.SeekCurrent); err != nil || off != 0 {
+		t.Fatal("file wasn't rewound", off, err)
+	}
+}
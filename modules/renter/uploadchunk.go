@@ -3,6 +3,7 @@ package renter
 import (
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"sync"
 	"time"
@@ -68,6 +69,15 @@ type unfinishedUploadChunk struct {
 	// available it will be tried before the repair path or remote repair.
 	sourceReader io.ReadCloser
 
+	// Deduplication information. staticDedupIndex is only set for chunks of
+	// files that were uploaded with deduplication. dedupID is the chunk's
+	// DedupID which is assigned when the logical data is fetched for the first
+	// time, in which case dedupIDAssigned is set. Both are only accessed by the
+	// thread fetching the logical data.
+	staticDedupIndex *dedupIndex
+	dedupID          siafile.DedupID
+	dedupIDAssigned  bool
+
 	// Performance information.
 	chunkCreationTime        time.Time
 	chunkPoppedFromHeapTime  time.Time
@@ -118,6 +128,14 @@ type unfinishedUploadChunk struct {
 	workersRemaining int                 // number of inactive workers still able to upload a piece.
 	workersStandby   []*worker           // workers that can be used if other workers fail.
 
+	// Placement information, also protected by mu. staticPlacementPolicy
+	// limits the number of pieces per failure domain. placementUsage counts
+	// the pieces per domain that are either uploaded or being uploaded and
+	// piecePlacement remembers the domains each piece was counted towards.
+	staticPlacementPolicy modules.PlacementPolicy
+	placementUsage        map[modules.PlacementDomain]uint64
+	piecePlacement        map[uint64][]modules.PlacementDomain
+
 	cancelMU sync.Mutex     // cancelMU needs to be held when adding to cancelWG and reading/writing canceled.
 	canceled bool           // cancel the work on this chunk.
 	cancelWG sync.WaitGroup // WaitGroup to wait on after canceling the uploadchunk.
@@ -192,6 +210,39 @@ func (uc *unfinishedUploadChunk) chunkComplete() bool {
 	return false
 }
 
+// addPlacement counts the piece with the provided index towards the failure
+// domains of the host storing it.
+func (uc *unfinishedUploadChunk) addPlacement(pieceIndex uint64, domains []modules.PlacementDomain) {
+	if len(domains) == 0 {
+		return
+	}
+	uc.piecePlacement[pieceIndex] = domains
+	for _, d := range domains {
+		uc.placementUsage[d]++
+	}
+}
+
+// placementAllowed checks whether a host within the provided failure domains
+// can store another piece of the chunk without exceeding the placement policy.
+func (uc *unfinishedUploadChunk) placementAllowed(domains []modules.PlacementDomain) bool {
+	for _, d := range domains {
+		max := uc.staticPlacementPolicy.MaxPieces(d.Type)
+		if max > 0 && uc.placementUsage[d] >= max {
+			return false
+		}
+	}
+	return true
+}
+
+// removePlacement removes the piece with the provided index from the failure
+// domains it was counted towards.
+func (uc *unfinishedUploadChunk) removePlacement(pieceIndex uint64) {
+	for _, d := range uc.piecePlacement[pieceIndex] {
+		uc.placementUsage[d]--
+	}
+	delete(uc.piecePlacement, pieceIndex)
+}
+
 // readDataPieces reads dataPieces from a io.Reader and stores them in a
 // [][]byte ready to be encoded using an ErasureCoder.
 func readDataPieces(r io.Reader, ec modules.ErasureCoder, pieceSize uint64) ([][]byte, uint64, error) {
@@ -211,10 +262,12 @@ func readDataPieces(r io.Reader, ec modules.ErasureCoder, pieceSize uint64) ([][
 // padAndEncryptPiece will add padding to a unfinishedUploadChunk's piece at
 // index i and then encrypt it.
 func (uc *unfinishedUploadChunk) padAndEncryptPiece(i int) {
-	padAndEncryptPiece(uc.staticIndex, uint64(i), uc.logicalChunkData, uc.fileEntry.MasterKey())
+	padAndEncryptPiece(chunkKeyIndex(uc.staticIndex, uc.dedupID), uint64(i), uc.logicalChunkData, uc.fileEntry.MasterKey())
 }
 
-// padAndEncryptPiece will add padding to a piece and then encrypt it.
+// padAndEncryptPiece will add padding to a piece and then encrypt it. The key
+// is derived from the chunk's key index which is usually the index of the
+// chunk within the file.
 func padAndEncryptPiece(chunkIndex, pieceIndex uint64, logicalChunkData [][]byte, masterKey crypto.CipherKey) {
 	// If the piece is not a full sector, pad it with empty bytes. The padding
 	// is done before applying encryption, meaning the data fed to the host does
@@ -349,8 +402,13 @@ func (r *Renter) threadedFetchAndRepairChunk(chunk *unfinishedUploadChunk) {
 		}
 	}
 
-	// Fetch the logical data for the chunk.
+	// Fetch the logical data for the chunk. If the chunk was assigned a new
+	// DedupID while fetching the data, it needs to be persisted before any
+	// pieces are uploaded.
 	err = r.managedFetchLogicalChunkData(chunk)
+	if err == nil {
+		err = r.managedPersistDedupID(chunk)
+	}
 	if err != nil {
 		// Return the erasure coding memory. This is not handled by the cleanup
 		// code.
@@ -409,6 +467,17 @@ func (r *Renter) threadedFetchAndRepairChunk(chunk *unfinishedUploadChunk) {
 		return
 	}
 
+	// Check if the chunk can be satisfied with the pieces of an existing
+	// chunk. If so, there is nothing left to upload.
+	deduplicated, err := r.managedDeduplicateChunk(chunk)
+	if err != nil {
+		r.repairLog.Printf("Unable to deduplicate chunk %v of %s: %v", chunk.staticIndex, chunk.staticTurtleDexPath, err)
+	}
+	if deduplicated {
+		r.managedCleanUpUploadChunk(chunk)
+		return
+	}
+
 	// Distribute the chunk to the workers.
 	r.staticUploadChunkDistributionQueue.callAddUploadChunk(chunk)
 }
@@ -471,11 +540,22 @@ func (uc *unfinishedUploadChunk) staticReadLogicalData(r io.Reader) (uint64, err
 	if err != nil {
 		return 0, err
 	}
+	uc.staticEncodeLogicalData(dataPieces)
+	return total, nil
+}
+
+// staticEncodeLogicalData erasure codes the data pieces of the chunk, forming
+// the chunk's logical data. Chunks of deduplicated files that don't have a
+// DedupID yet are assigned one based on the data.
+func (uc *unfinishedUploadChunk) staticEncodeLogicalData(dataPieces [][]byte) {
+	if uc.staticDedupIndex != nil && uc.dedupID == (siafile.DedupID{}) {
+		uc.dedupID = uc.staticDedupIndex.staticComputeID(uc.fileEntry.ErasureCode(), uc.fileEntry.PieceSize(), dataPieces)
+		uc.dedupIDAssigned = true
+	}
 	// Encode the data pieces, forming the chunk's logical data.
 	//
 	// TODO: Ideally there is a way to only encode the shards that we need.
 	uc.logicalChunkData, _ = uc.fileEntry.ErasureCode().EncodeShards(dataPieces)
-	return total, nil
 }
 
 // staticFetchLogicalDataFromReader will load the logical data for a chunk from
@@ -499,9 +579,12 @@ func (r *Renter) staticFetchLogicalDataFromReader(uc *unfinishedUploadChunk) (er
 
 	// Adjust the filesize. Since we don't know the length of the stream
 	// beforehand we simply assume that a whole chunk will be added to the
-	// file. That's why we subtract the difference between the size of a
-	// chunk and n here.
-	adjustedSize := uc.fileEntry.Size() - uc.length + n
+	// file. If less than a chunk was read, the stream ended within this chunk
+	// and the file ends there too.
+	if n == uc.length {
+		return nil
+	}
+	adjustedSize := uint64(uc.offset) + n
 	if errSize := uc.fileEntry.SetFileSize(adjustedSize); errSize != nil {
 		return errors.AddContext(errSize, "failed to adjust FileSize")
 	}
@@ -548,12 +631,26 @@ func (r *Renter) managedFetchLogicalChunkData(uc *unfinishedUploadChunk) error {
 		defer func() {
 			err = errors.Compose(err, osFile.Close())
 		}()
-		sr := io.NewSectionReader(osFile, uc.offset, int64(uc.length))
+		var sr io.Reader = io.NewSectionReader(osFile, uc.offset, int64(uc.length))
+		// The uploaded data of compressed and deduplicated files differs
+		// from the local data. It is recreated by transforming the local data
+		// up to the chunk.
+		compression, dedupLayout := uc.fileEntry.Compression(), uc.fileEntry.DedupLayout()
+		if compression != modules.CompressionNone || dedupLayout {
+			ur, err := newUploadDataReader(osFile, compression, dedupLayout, uc.fileEntry.ChunkSize())
+			if err != nil {
+				return errors.AddContext(err, "unable to transform the local file")
+			}
+			if _, err := io.CopyN(ioutil.Discard, ur, uc.offset); err != nil {
+				return errors.AddContext(err, "unable to skip to the chunk in the local file")
+			}
+			sr = io.LimitReader(ur, int64(uc.length))
+		}
 		dataPieces, _, err := readDataPieces(sr, uc.fileEntry.ErasureCode(), uc.fileEntry.PieceSize())
 		if err != nil {
 			return errors.AddContext(err, "unable to read the data from the local file")
 		}
-		uc.logicalChunkData, _ = uc.fileEntry.ErasureCode().EncodeShards(dataPieces)
+		uc.staticEncodeLogicalData(dataPieces)
 		err = uc.staticEncryptAndCheckIntegrity()
 		if err != nil {
 			return errors.AddContext(err, "local file failed the integrity check")
@@ -651,6 +748,7 @@ func (r *Renter) managedCleanUpUploadChunk(uc *unfinishedUploadChunk) {
 	uc.memoryReleased += memoryReleased
 	totalMemoryReleased := uc.memoryReleased
 	workersRemaining := uc.workersRemaining
+	piecesCompleted := uc.piecesCompleted
 	uc.mu.Unlock()
 
 	// If there are pieces available, add the standby workers to collect them.
@@ -665,6 +763,17 @@ func (r *Renter) managedCleanUpUploadChunk(uc *unfinishedUploadChunk) {
 	if chunkComplete && !released {
 		r.managedUpdateUploadChunkStuckStatus(uc)
 
+		// Make the pieces of a fully uploaded chunk available for
+		// deduplication.
+		if uc.staticDedupIndex != nil && uc.dedupID != (siafile.DedupID{}) && piecesCompleted >= uc.staticPiecesNeeded {
+			pieces, err := uc.fileEntry.Pieces(uc.staticIndex)
+			if err != nil {
+				r.log.Print("managedCleanUpUploadChunk: failed to update dedup index", err)
+			} else {
+				uc.staticDedupIndex.managedSetPieces(uc.dedupID, pieces)
+			}
+		}
+
 		// Update the file's metadata.
 		offlineMap, goodForRenewMap, contracts, used := r.managedRenterContractsAndUtilities()
 		err := r.managedUpdateFileMetadata(uc.fileEntry, offlineMap, goodForRenewMap, contracts, used)
@@ -557,6 +557,16 @@ func (r *Renter) managedBuildUnfinishedChunk(entry *filesystem.FileNode, chunkIn
 
 		pieceUsage:  make([]bool, entry.ErasureCode().NumPieces()),
 		unusedHosts: make(map[string]struct{}, len(hosts)),
+
+		placementUsage: make(map[modules.PlacementDomain]uint64),
+		piecePlacement: make(map[uint64][]modules.PlacementDomain),
+	}
+
+	// Fetch the placement policy which limits the number of pieces per
+	// failure domain.
+	uuc.staticPlacementPolicy, err = r.hostDB.PlacementPolicy()
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to get the placement policy")
 	}
 
 	// Every chunk can have a different set of unused hosts.
@@ -595,6 +605,12 @@ func (r *Renter) managedBuildUnfinishedChunk(entry *filesystem.FileNode, chunkIn
 			if exists && goodForRenew && exists2 && !offline && exists3 && !redundantPiece {
 				uuc.pieceUsage[pieceIndex] = true
 				uuc.piecesCompleted++
+
+				// Count the piece towards the failure domains of its host.
+				if uuc.staticPlacementPolicy.Enabled() {
+					domains := r.staticWorkerPool.callPlacementDomains(piece.HostPubKey)
+					uuc.addPlacement(uint64(pieceIndex), domains)
+				}
 			}
 
 			// In all cases, if this host already has a piece, the host cannot
@@ -609,6 +625,16 @@ func (r *Renter) managedBuildUnfinishedChunk(entry *filesystem.FileNode, chunkIn
 			uuc.staticExpectedPieceRoots[pieceIndex] = pieceSet[0].MerkleRoot
 		}
 	}
+	// Chunks of deduplicated files need to know their DedupID to derive the
+	// encryption keys of their pieces.
+	if r.staticDedupIndex.staticIsDedupKey(entry.MasterKey()) {
+		uuc.staticDedupIndex = r.staticDedupIndex
+		uuc.dedupID, err = entry.ChunkDedupID(chunkIndex)
+		if err != nil {
+			return nil, errors.AddContext(err, "unable to get the dedup id of the chunk")
+		}
+	}
+
 	// Now that we have calculated the completed pieces for the chunk we can
 	// calculate the health of the chunk to avoid a call to ChunkHealth
 	uuc.health = 1 - (float64(uuc.piecesCompleted-uuc.staticMinimumPieces) / float64(uuc.staticPiecesNeeded-uuc.staticMinimumPieces))
@@ -1471,7 +1497,11 @@ func (r *Renter) threadedUploadAndRepair() {
 	// adds a layer of robustness in case the repair loop gets stuck or can't
 	// work through the full heap quickly because the user keeps uploading new
 	// files and keeping a minimum number of chunks in the repair heap.
+	//
+	// Every 'migrationScanInterval', the files are scanned for chunks with
+	// pieces on hosts the contractor migrates data away from.
 	resetTime := time.Now().Add(repairLoopResetFrequency)
+	var migrationScanTime time.Time
 	for {
 		// Return if the renter has shut down.
 		select {
@@ -1550,6 +1580,23 @@ func (r *Renter) threadedUploadAndRepair() {
 			r.repairLog.Printf("Added %v backup chunks to the upload heap", numBackupChunks)
 		}
 
+		// Add any chunks with pieces on migrating hosts. These chunks might
+		// not need to be repaired according to their health and are therefore
+		// not found through the directory heap.
+		if time.Now().After(migrationScanTime) {
+			migrationScanTime = time.Now().Add(migrationScanInterval)
+			err = r.managedAddMigrationChunksToHeap(hosts, offline, goodForRenew)
+			if err != nil {
+				r.repairLog.Println("WARN: error adding migration chunks to the heap:", err)
+			}
+		}
+
+		// Launch the re-encoding of files which don't match the redundancy
+		// policy of their directory.
+		if r.staticReencoder.managedTryStart() {
+			go r.threadedReencodeFiles()
+		}
+
 		// Check if there is work to do. If the filesystem is healthy and the
 		// heap is empty, there is no work to do and the thread should block
 		// until there is work to do.
@@ -1573,6 +1620,8 @@ func (r *Renter) threadedUploadAndRepair() {
 				r.repairLog.Debugln("repair loop triggered by new upload channel")
 			case <-r.uploadHeap.repairNeeded:
 				r.repairLog.Debugln("repair loop triggered by repair needed channel")
+			case <-time.After(time.Until(migrationScanTime)):
+				r.repairLog.Debugln("repair loop triggered by migration scan")
 			case <-r.tg.StopChan():
 				return
 			}
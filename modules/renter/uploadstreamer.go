@@ -45,6 +45,19 @@ type StreamShard struct {
 	signalChan chan struct{}
 }
 
+// countingReader counts the bytes read from the underlying reader.
+type countingReader struct {
+	staticReader io.Reader
+	n            uint64
+}
+
+// Read implements the io.Reader interface.
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.staticReader.Read(p)
+	cr.n += uint64(n)
+	return n, err
+}
+
 // NewStreamShard creates a new stream shard from a reader.
 func NewStreamShard(r io.Reader, peek []byte) *StreamShard {
 	return &StreamShard{
@@ -137,10 +150,13 @@ func (r *Renter) UploadStreamFromReader(up modules.FileUploadParams, reader io.R
 // TurtleDexFile for the upload.
 func (r *Renter) managedInitUploadStream(up modules.FileUploadParams) (*filesystem.FileNode, error) {
 	siaPath, ec, force, repair, cipherType := up.TurtleDexPath, up.ErasureCode, up.Force, up.Repair, up.CipherType
-	// Check if ec was set. If not use defaults.
+	// Check if ec was set. If not use the redundancy policy or defaults.
 	var err error
 	if ec == nil && !repair {
-		ec = modules.NewRSSubCodeDefault()
+		ec, err = r.managedUploadErasureCoder(siaPath)
+		if err != nil {
+			return nil, err
+		}
 		up.ErasureCode = ec
 	} else if ec != nil && repair {
 		return nil, errors.New("can't provide erasure code settings when doing repairs")
@@ -150,6 +166,14 @@ func (r *Renter) managedInitUploadStream(up modules.FileUploadParams) (*filesyst
 	if force && repair {
 		return nil, errors.New("'force' and 'repair' can't both be set")
 	}
+	// Check the compression type. Repairs use the compression of the existing
+	// file.
+	if _, err := modules.NewCompressionType(string(up.Compression)); err != nil {
+		return nil, err
+	}
+	if up.Compression != modules.CompressionNone && repair {
+		return nil, errors.New("can't provide compression settings when doing repairs")
+	}
 
 	// Delete existing file if overwrite flag is set. Ignore ErrUnknownPath.
 	if force {
@@ -182,13 +206,37 @@ func (r *Renter) managedInitUploadStream(up modules.FileUploadParams) (*filesyst
 	if up.CipherKey == nil {
 		cipherKey = crypto.GenerateTurtleDexKey(cipherType)
 	}
+	// Deduplicated files use the dedup master key and can't have partial
+	// chunks.
+	if up.Dedup {
+		var ct crypto.CipherType
+		if up.CipherKey != nil || (cipherType != ct && cipherType != dedupCipherType) {
+			return nil, errDedupCipherType
+		}
+		cipherKey = r.staticDedupIndex.staticMasterKey
+		up.DisablePartialChunk = true
+	}
 
 	// Create the TurtleDexfile and add to renter
 	err = r.staticFileSystem.NewTurtleDexFile(siaPath, up.Source, up.ErasureCode, cipherKey, 0, defaultFilePerm, up.DisablePartialChunk)
 	if err != nil {
 		return nil, err
 	}
-	return r.staticFileSystem.OpenTurtleDexFile(siaPath)
+	entry, err := r.staticFileSystem.OpenTurtleDexFile(siaPath)
+	if err != nil {
+		return nil, err
+	}
+	if up.Compression != modules.CompressionNone {
+		if err := entry.SetCompression(up.Compression); err != nil {
+			return nil, errors.Compose(err, entry.Close())
+		}
+	}
+	if up.Dedup {
+		if err := entry.SetDedupLayout(); err != nil {
+			return nil, errors.Compose(err, entry.Close())
+		}
+	}
+	return entry, nil
 }
 
 // callUploadStreamFromReader reads from the provided reader until io.EOF is
@@ -200,23 +248,36 @@ func (r *Renter) managedInitUploadStream(up modules.FileUploadParams) (*filesyst
 // the TurtleDex network, this will happen faster than the entire upload is complete -
 // the streamer may continue uploading in the background after returning while
 // it is boosting redundancy.
-func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader io.Reader) (fileNode *filesystem.FileNode, err error) {
+func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader io.Reader) (*filesystem.FileNode, error) {
 	// Check the upload params first.
-	fileNode, err = r.managedInitUploadStream(up)
+	fileNode, err := r.managedInitUploadStream(up)
 	if err != nil {
 		return nil, err
 	}
-	// Need to make a copy of this value for the defer statement. Because
-	// 'fileNode' is a named value, if you run the call `return nil, err`, then
-	// 'fileNode' will be set to 'nil' when 'fileNode.Close()' gets called in
-	// the 'defer'.
-	fn := fileNode
-	defer func() {
-		// Ensure the fileNode is closed if there is an error upon return.
-		if err != nil {
-			err = errors.Compose(err, fn.Close())
-		}
-	}()
+	// Close the fileNode if the upload fails.
+	err = r.callUploadStreamToFileNode(fileNode, reader)
+	if err != nil {
+		return nil, errors.Compose(err, fileNode.Close())
+	}
+	return fileNode, nil
+}
+
+// callUploadStreamToFileNode reads from the provided reader until io.EOF is
+// reached and uploads the data to the chunks of an existing TurtleDexFile.
+// Like callUploadStreamFromReader, it returns as soon as the data is available
+// on the TurtleDex network.
+func (r *Renter) callUploadStreamToFileNode(fileNode *filesystem.FileNode, reader io.Reader) error {
+	// Compress the data if the file is compressed and lay it out if it is
+	// deduplicated. When repairing a file, this results in the same data
+	// again. The original data is counted to record its size once the upload
+	// is done.
+	compression := fileNode.Compression()
+	dedupLayout := fileNode.DedupLayout()
+	cr := &countingReader{staticReader: reader}
+	reader, err := newUploadDataReader(cr, compression, dedupLayout, fileNode.ChunkSize())
+	if err != nil {
+		return err
+	}
 
 	// Build a map of host public keys.
 	pks := make(map[string]types.TurtleDexPublicKey)
@@ -233,7 +294,7 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 	availableWorkers := len(r.staticWorkerPool.workers)
 	r.staticWorkerPool.mu.RUnlock()
 	if availableWorkers < minWorkers {
-		return nil, fmt.Errorf("Need at least %v workers for upload but got only %v", minWorkers, availableWorkers)
+		return fmt.Errorf("Need at least %v workers for upload but got only %v", minWorkers, availableWorkers)
 	}
 
 	// Read the chunks we want to upload one by one from the input stream using
@@ -253,14 +314,14 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 		// Grow the TurtleDexFile to the right size. Otherwise buildUnfinishedChunk
 		// won't realize that there are pieces which haven't been repaired yet.
 		if err := fileNode.TurtleDexFile.GrowNumChunks(chunkIndex + 1); err != nil {
-			return nil, err
+			return err
 		}
 
 		// Start the chunk upload.
 		offline, goodForRenew, _ := r.managedContractUtilityMaps()
 		uuc, err := r.managedBuildUnfinishedChunk(fileNode, chunkIndex, hosts, pks, memoryPriorityHigh, offline, goodForRenew, r.userUploadMemoryManager)
 		if err != nil {
-			return nil, errors.AddContext(err, "unable to fetch chunk for stream")
+			return errors.AddContext(err, "unable to fetch chunk for stream")
 		}
 
 		// Create a new shard set it to be the source reader of the chunk.
@@ -272,14 +333,14 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 			// Add the chunk to the upload heap's repair map.
 			pushed, err := r.managedPushChunkForRepair(uuc, chunkTypeStreamChunk)
 			if err != nil {
-				return nil, errors.AddContext(err, "unable to push chunk")
+				return errors.AddContext(err, "unable to push chunk")
 			}
 			if !pushed {
 				// The chunk wasn't added to the repair map meaning it must have
 				// already been in the repair map
 				_, _ = io.ReadFull(ss, make([]byte, fileNode.ChunkSize()))
 				if err := ss.Close(); err != nil {
-					return nil, err
+					return err
 				}
 			}
 			chunks = append(chunks, uuc)
@@ -290,13 +351,13 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 			// since we check that anyway at the end of the loop.
 			_, _ = io.ReadFull(ss, make([]byte, fileNode.ChunkSize()))
 			if err := ss.Close(); err != nil {
-				return nil, err
+				return err
 			}
 		}
 		// Wait for the shard to be read.
 		select {
 		case <-r.tg.StopChan():
-			return nil, errors.New("interrupted by shutdown")
+			return errors.New("interrupted by shutdown")
 		case <-ss.signalChan:
 		}
 
@@ -306,7 +367,7 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 			// All chunks successfully submitted.
 			break
 		} else if ss.err != nil {
-			return nil, ss.err
+			return ss.err
 		}
 
 		// Call Peek to make sure that there's more data for another shard.
@@ -314,7 +375,7 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 		if errors.Contains(err, io.EOF) || errors.Contains(err, io.ErrUnexpectedEOF) {
 			break
 		} else if err != nil {
-			return nil, ss.err
+			return ss.err
 		}
 	}
 
@@ -329,14 +390,22 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 			chunk.mu.Unlock()
 		}
 		if err != nil {
-			return nil, errors.AddContext(err, "upload streamer failed to get all data available")
+			return errors.AddContext(err, "upload streamer failed to get all data available")
+		}
+	}
+
+	// Record the size of the original data.
+	if compression != modules.CompressionNone || dedupLayout {
+		err = fileNode.SetDataSize(cr.n)
+		if err != nil {
+			return errors.AddContext(err, "unable to set data size")
 		}
 	}
 
 	// Disrupt to force an error and ensure the fileNode is being closed
 	// correctly.
 	if r.deps.Disrupt("failUploadStreamFromReader") {
-		return nil, errors.New("disrupted by failUploadStreamFromReader")
+		return errors.New("disrupted by failUploadStreamFromReader")
 	}
-	return fileNode, nil
+	return nil
 }
@@ -0,0 +1,376 @@
+package renter
+
+// versions.go contains the logic for retaining prior versions of siafiles.
+//
+// Versioning is enabled by setting a VersioningPolicy on a directory. Whenever
+// a file within that directory or any of its subdirectories is deleted or
+// overwritten, the siafile is moved into the VersionsFolder instead of being
+// deleted. The versions of a file are stored as regular siafiles in a
+// directory mirroring the siapath of the file, named after the time they were
+// created. Since they are regular siafiles, the health and repair loops
+// account for them and keep them alive just like any other file until they are
+// pruned or purged.
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem"
+)
+
+var (
+	// errVarFolderVersioning is returned when trying to use versioning for a
+	// file or directory within the VarFolder.
+	errVarFolderVersioning = errors.New("versioning is not supported within the var folder")
+
+	// errInvalidVersionID is returned when a version id can't be parsed.
+	errInvalidVersionID = errors.New("invalid version id")
+)
+
+// isVarPath returns whether the siapath is the VarFolder or within it.
+func isVarPath(siaPath modules.TurtleDexPath) bool {
+	return siaPath.Equals(modules.VarFolder) || strings.HasPrefix(siaPath.Path, modules.VarFolder.Path+"/")
+}
+
+// newVersionID returns the id of a version created at the provided time.
+func newVersionID(t time.Time) string {
+	return fmt.Sprintf("%020d", t.UnixNano())
+}
+
+// versionCreateTime returns the time a version was created at.
+func versionCreateTime(id string) (time.Time, error) {
+	nanos, err := strconv.ParseUint(id, 10, 63)
+	if err != nil || len(id) != 20 {
+		return time.Time{}, errInvalidVersionID
+	}
+	return time.Unix(0, int64(nanos)), nil
+}
+
+// versionsDir returns the siapath of the directory containing the versions of
+// a file.
+func versionsDir(siaPath modules.TurtleDexPath) (modules.TurtleDexPath, error) {
+	return modules.VersionsFolder.Join(siaPath.Path)
+}
+
+// versionPath returns the siapath of the siafile containing a version of a
+// file.
+func versionPath(siaPath modules.TurtleDexPath, id string) (modules.TurtleDexPath, error) {
+	if _, err := versionCreateTime(id); err != nil {
+		return modules.TurtleDexPath{}, err
+	}
+	dir, err := versionsDir(siaPath)
+	if err != nil {
+		return modules.TurtleDexPath{}, err
+	}
+	return dir.Join(id)
+}
+
+// versionsToPrune returns the versions which are not retained by the policy.
+// The versions are expected to be sorted from newest to oldest.
+func versionsToPrune(versions []modules.FileVersion, policy modules.VersioningPolicy, now time.Time) []modules.FileVersion {
+	var prune []modules.FileVersion
+	for i, v := range versions {
+		if uint64(i) < policy.MaxVersions {
+			continue
+		}
+		if policy.RetentionWindow > 0 && now.Sub(v.CreateTime) < policy.RetentionWindow {
+			continue
+		}
+		prune = append(prune, v)
+	}
+	return prune
+}
+
+// SetDirVersioning sets the versioning policy of a directory.
+func (r *Renter) SetDirVersioning(siaPath modules.TurtleDexPath, policy modules.VersioningPolicy) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if isVarPath(siaPath) {
+		return errVarFolderVersioning
+	}
+	return r.staticFileSystem.UpdateDirVersioning(siaPath, policy)
+}
+
+// FileVersions returns the retained versions of a file sorted from newest to
+// oldest.
+func (r *Renter) FileVersions(siaPath modules.TurtleDexPath) ([]modules.FileVersion, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+	if isVarPath(siaPath) {
+		return nil, errVarFolderVersioning
+	}
+	return r.managedFileVersions(siaPath)
+}
+
+// RestoreFileVersion restores a version of a file. If the file currently
+// exists, it is retained as a new version before being replaced.
+func (r *Renter) RestoreFileVersion(siaPath modules.TurtleDexPath, id string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if isVarPath(siaPath) {
+		return errVarFolderVersioning
+	}
+	vp, err := versionPath(siaPath, id)
+	if err != nil {
+		return err
+	}
+	exists, err := r.staticFileSystem.FileExists(vp)
+	if err != nil {
+		return errors.AddContext(err, "unable to check for version")
+	}
+	if !exists {
+		return errors.AddContext(filesystem.ErrNotExist, "version not found")
+	}
+
+	// Retain the current file. This happens regardless of the policy to avoid
+	// losing data by restoring a version.
+	err = r.managedArchiveFile(siaPath)
+	if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+		return errors.AddContext(err, "unable to retain the current file")
+	}
+
+	// Move the version back into place.
+	err = r.managedRenameFile(vp, siaPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to restore version")
+	}
+
+	// Prune the versions since restoring might have created a new one.
+	policy, err := r.managedVersioningPolicy(siaPath)
+	if err != nil {
+		return errors.AddContext(err, "unable to get versioning policy")
+	}
+	if !policy.Enabled() {
+		return nil
+	}
+	return errors.AddContext(r.managedPruneFileVersions(siaPath, policy), "unable to prune versions")
+}
+
+// PurgeFileVersions deletes a version of a file. If no id is provided, all
+// versions of the file are deleted.
+func (r *Renter) PurgeFileVersions(siaPath modules.TurtleDexPath, id string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if isVarPath(siaPath) {
+		return errVarFolderVersioning
+	}
+	if id != "" {
+		vp, err := versionPath(siaPath, id)
+		if err != nil {
+			return err
+		}
+		return r.managedDeleteFile(vp)
+	}
+
+	versions, err := r.managedFileVersions(siaPath)
+	if err != nil {
+		return err
+	}
+	for _, v := range versions {
+		if err := r.managedDeleteFile(v.VersionPath); err != nil {
+			return errors.AddContext(err, fmt.Sprintf("unable to delete version %v", v.ID))
+		}
+	}
+	// Remove the now empty directory of the versions.
+	dir, err := versionsDir(siaPath)
+	if err != nil {
+		return err
+	}
+	err = r.staticFileSystem.DeleteDir(dir)
+	if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+		return errors.AddContext(err, "unable to delete versions directory")
+	}
+	return nil
+}
+
+// managedArchiveFile moves a file into the VersionsFolder, retaining it as a
+// new version.
+func (r *Renter) managedArchiveFile(siaPath modules.TurtleDexPath) error {
+	vp, err := versionPath(siaPath, newVersionID(time.Now()))
+	if err != nil {
+		return err
+	}
+	return r.managedRenameFile(siaPath, vp)
+}
+
+// managedFileVersions returns the retained versions of a file sorted from
+// newest to oldest.
+func (r *Renter) managedFileVersions(siaPath modules.TurtleDexPath) ([]modules.FileVersion, error) {
+	dir, err := versionsDir(siaPath)
+	if err != nil {
+		return nil, err
+	}
+	var versions []modules.FileVersion
+	var mu sync.Mutex
+	flf := func(fi modules.FileInfo) {
+		id := fi.TurtleDexPath.Name()
+		createTime, err := versionCreateTime(id)
+		if err != nil {
+			r.log.Printf("Ignoring unexpected file %v in versions directory", fi.TurtleDexPath)
+			return
+		}
+		mu.Lock()
+		versions = append(versions, modules.FileVersion{
+			ID:               id,
+			CreateTime:       createTime,
+			TurtleDexPath:    siaPath,
+			VersionPath:      fi.TurtleDexPath,
+			Filesize:         fi.Filesize,
+			Health:           fi.Health,
+			ModificationTime: fi.ModificationTime,
+			Recoverable:      fi.Recoverable,
+			Redundancy:       fi.Redundancy,
+		})
+		mu.Unlock()
+	}
+	err = r.staticFileSystem.CachedList(dir, false, flf, func(modules.DirectoryInfo) {})
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to list versions")
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].ID > versions[j].ID
+	})
+	return versions, nil
+}
+
+// managedPruneFileVersions deletes the versions of a file which are no longer
+// retained by the policy.
+func (r *Renter) managedPruneFileVersions(siaPath modules.TurtleDexPath, policy modules.VersioningPolicy) error {
+	versions, err := r.managedFileVersions(siaPath)
+	if err != nil {
+		return err
+	}
+	for _, v := range versionsToPrune(versions, policy, time.Now()) {
+		if err := r.managedDeleteFile(v.VersionPath); err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+			return errors.AddContext(err, fmt.Sprintf("unable to delete version %v", v.ID))
+		}
+	}
+	return nil
+}
+
+// managedPruneAllFileVersions prunes the versions of all files. Versions of
+// files without an enabled versioning policy are kept until they are purged.
+func (r *Renter) managedPruneAllFileVersions() error {
+	// Collect the directories containing versions.
+	dirs := make(map[modules.TurtleDexPath]struct{})
+	var mu sync.Mutex
+	flf := func(fi modules.FileInfo) {
+		dir, err := fi.TurtleDexPath.Dir()
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		dirs[dir] = struct{}{}
+		mu.Unlock()
+	}
+	err := r.staticFileSystem.CachedList(modules.VersionsFolder, true, flf, func(modules.DirectoryInfo) {})
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return errors.AddContext(err, "unable to list versions folder")
+	}
+
+	for dir := range dirs {
+		siaPath, err := modules.NewTurtleDexPath(strings.TrimPrefix(dir.Path, modules.VersionsFolder.Path+"/"))
+		if err != nil {
+			r.log.Printf("Unable to determine siapath of versions directory %v: %v", dir, err)
+			continue
+		}
+		policy, err := r.managedVersioningPolicy(siaPath)
+		if err != nil {
+			r.log.Printf("Unable to get versioning policy of %v: %v", siaPath, err)
+			continue
+		}
+		if !policy.Enabled() {
+			continue
+		}
+		if err := r.managedPruneFileVersions(siaPath, policy); err != nil {
+			r.log.Printf("Unable to prune versions of %v: %v", siaPath, err)
+		}
+	}
+	return nil
+}
+
+// managedVersioningPolicy returns the versioning policy which applies to a
+// file. This is the policy of the closest ancestor directory which has
+// versioning enabled.
+func (r *Renter) managedVersioningPolicy(siaPath modules.TurtleDexPath) (modules.VersioningPolicy, error) {
+	if isVarPath(siaPath) {
+		return modules.VersioningPolicy{}, nil
+	}
+	dir, err := siaPath.Dir()
+	if err != nil {
+		return modules.VersioningPolicy{}, err
+	}
+	for {
+		policy, err := r.managedDirVersioning(dir)
+		if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+			return modules.VersioningPolicy{}, err
+		}
+		if err == nil && policy.Enabled() {
+			return policy, nil
+		}
+		if dir.IsRoot() {
+			return modules.VersioningPolicy{}, nil
+		}
+		dir, err = dir.Dir()
+		if err != nil {
+			return modules.VersioningPolicy{}, err
+		}
+	}
+}
+
+// managedDirVersioning returns the versioning policy set on a directory.
+func (r *Renter) managedDirVersioning(siaPath modules.TurtleDexPath) (_ modules.VersioningPolicy, err error) {
+	dir, err := r.staticFileSystem.OpenTurtleDexDir(siaPath)
+	if err != nil {
+		return modules.VersioningPolicy{}, err
+	}
+	defer func() {
+		err = errors.Compose(err, dir.Close())
+	}()
+	md, err := dir.Metadata()
+	if err != nil {
+		return modules.VersioningPolicy{}, err
+	}
+	return md.Versioning, nil
+}
+
+// threadedPruneFileVersions periodically prunes the versions of all files.
+func (r *Renter) threadedPruneFileVersions() {
+	err := r.tg.Add()
+	if err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	for {
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-time.After(pruneFileVersionsInterval):
+		}
+		if err := r.managedPruneAllFileVersions(); err != nil {
+			r.log.Println("WARN: Unable to prune file versions:", err)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package renter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem"
+)
+
+// TestVersionsToPrune is a unit test for versionsToPrune.
+func TestVersionsToPrune(t *testing.T) {
+	now := time.Now()
+	var versions []modules.FileVersion
+	for i := 0; i < 5; i++ {
+		createTime := now.Add(-time.Duration(i) * time.Hour)
+		versions = append(versions, modules.FileVersion{
+			ID:         newVersionID(createTime),
+			CreateTime: createTime,
+		})
+	}
+
+	tests := []struct {
+		policy modules.VersioningPolicy
+		pruned int
+	}{
+		{modules.VersioningPolicy{MaxVersions: 5}, 0},
+		{modules.VersioningPolicy{MaxVersions: 2}, 3},
+		{modules.VersioningPolicy{RetentionWindow: 150 * time.Minute}, 2},
+		{modules.VersioningPolicy{MaxVersions: 4, RetentionWindow: 150 * time.Minute}, 1},
+		{modules.VersioningPolicy{MaxVersions: 1, RetentionWindow: 30 * time.Minute}, 4},
+	}
+	for i, test := range tests {
+		pruned := versionsToPrune(versions, test.policy, now)
+		if len(pruned) != test.pruned {
+			t.Fatalf("%v: expected %v pruned versions but got %v", i, test.pruned, len(pruned))
+		}
+		// The oldest versions should be pruned.
+		for j, v := range pruned {
+			if v.ID != versions[len(versions)-len(pruned)+j].ID {
+				t.Fatalf("%v: wrong version pruned", i)
+			}
+		}
+	}
+}
+
+// TestVersionCreateTime tests converting between version ids and times.
+func TestVersionCreateTime(t *testing.T) {
+	now := time.Now()
+	createTime, err := versionCreateTime(newVersionID(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !createTime.Equal(time.Unix(0, now.UnixNano())) {
+		t.Fatal("wrong create time", createTime, now)
+	}
+	for _, id := range []string{"", "abc", "123", "-0000000000000000001", "+0000000000000000001"} {
+		if _, err := versionCreateTime(id); !errors.Contains(err, errInvalidVersionID) {
+			t.Fatalf("%v: expected %v but got %v", id, errInvalidVersionID, err)
+		}
+	}
+}
+
+// TestRenterFileVersions tests retaining, restoring and purging versions of a
+// file.
+func TestRenterFileVersions(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	// Create a directory with versioning enabled and a file within one of its
+	// subdirectories.
+	dir, err := modules.NewTurtleDexPath("versioned")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.CreateDir(dir, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetDirVersioning(dir, modules.VersioningPolicy{MaxVersions: 2}); err != nil {
+		t.Fatal(err)
+	}
+	siaPath, err := dir.Join("sub/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	createAndDelete := func() {
+		entry, err := r.createRenterTestFile(siaPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := entry.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.DeleteFile(siaPath); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Deleting the file should retain it as a version.
+	createAndDelete()
+	versions, err := r.FileVersions(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 1 || !versions[0].TurtleDexPath.Equals(siaPath) {
+		t.Fatal("expected one version", versions)
+	}
+	if exists, _ := r.staticFileSystem.FileExists(siaPath); exists {
+		t.Fatal("file should have been deleted")
+	}
+
+	// Only the two most recent versions should be retained.
+	createAndDelete()
+	createAndDelete()
+	versions, err = r.FileVersions(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 || versions[0].ID <= versions[1].ID {
+		t.Fatal("expected two versions sorted from newest to oldest", versions)
+	}
+
+	// Restore the older version.
+	if err := r.RestoreFileVersion(siaPath, versions[1].ID); err != nil {
+		t.Fatal(err)
+	}
+	if exists, _ := r.staticFileSystem.FileExists(siaPath); !exists {
+		t.Fatal("file should have been restored")
+	}
+	if exists, _ := r.staticFileSystem.FileExists(versions[1].VersionPath); exists {
+		t.Fatal("restored version should no longer exist")
+	}
+
+	// Restoring over the existing file retains it as a new version.
+	if err := r.RestoreFileVersion(siaPath, versions[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	versions, err = r.FileVersions(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 1 {
+		t.Fatal("expected one version", versions)
+	}
+
+	// Purge a single version and then all versions. Deleting the restored file
+	// retains it as a second version first.
+	if err := r.DeleteFile(siaPath); err != nil {
+		t.Fatal(err)
+	}
+	versions, err = r.FileVersions(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 {
+		t.Fatal("expected two versions", versions)
+	}
+	if err := r.PurgeFileVersions(siaPath, versions[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.PurgeFileVersions(siaPath, ""); err != nil {
+		t.Fatal(err)
+	}
+	versions, err = r.FileVersions(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 0 {
+		t.Fatal("expected no versions", versions)
+	}
+
+	// Disabling versioning deletes files again.
+	if err := r.SetDirVersioning(dir, modules.VersioningPolicy{}); err != nil {
+		t.Fatal(err)
+	}
+	createAndDelete()
+	versions, err = r.FileVersions(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 0 {
+		t.Fatal("expected no versions", versions)
+	}
+
+	// Versioning can't be enabled within the var folder.
+	err = r.SetDirVersioning(modules.SkynetFolder, modules.VersioningPolicy{MaxVersions: 1})
+	if !errors.Contains(err, errVarFolderVersioning) {
+		t.Fatalf("expected %v but got %v", errVarFolderVersioning, err)
+	}
+	err = r.RestoreFileVersion(siaPath, "abc")
+	if !errors.Contains(err, errInvalidVersionID) {
+		t.Fatalf("expected %v but got %v", errInvalidVersionID, err)
+	}
+	err = r.RestoreFileVersion(siaPath, newVersionID(time.Now()))
+	if !errors.Contains(err, filesystem.ErrNotExist) {
+		t.Fatalf("expected %v but got %v", filesystem.ErrNotExist, err)
+	}
+}
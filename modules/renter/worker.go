@@ -34,6 +34,18 @@ const (
 	// host to support the registry.
 	minRegistryVersion = "1.5.1"
 
+	// minRegistryEIDVersion defines the minimum version that is required for
+	// a host to support looking up registry entries by their entry id.
+	minRegistryEIDVersion = "1.5.6"
+
+	// minRegistrySubscriptionVersion defines the minimum version that is
+	// required for a host to support subscribing to registry entries.
+	minRegistrySubscriptionVersion = "1.5.6"
+
+	// minUpdateSectorVersion defines the minimum version that is required for
+	// a host to support updating a range of bytes within a sector.
+	minUpdateSectorVersion = "1.5.6"
+
 	// registryCacheSize is the cache size used by a single worker for the
 	// registry cache.
 	registryCacheSize = 1 << 20 // 1 MiB
@@ -85,6 +97,7 @@ type (
 		staticJobReadRegistryQueue     *jobReadRegistryQueue
 		staticJobRenewQueue            *jobRenewQueue
 		staticJobUpdateRegistryQueue   *jobUpdateRegistryQueue
+		staticJobUpdateSectorQueue     *jobUpdateSectorQueue
 		staticJobUploadSnapshotQueue   *jobUploadSnapshotQueue
 
 		// Upload variables.
@@ -256,6 +269,7 @@ func (r *Renter) newWorker(hostPubKey types.TurtleDexPublicKey) (*worker, error)
 	w.initJobDownloadSnapshotQueue()
 	w.initJobReadRegistryQueue()
 	w.initJobUpdateRegistryQueue()
+	w.initJobUpdateSectorQueue()
 	w.initJobUploadSnapshotQueue()
 
 	// Close the worker when the renter is stopped.
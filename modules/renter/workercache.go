@@ -33,6 +33,10 @@ type (
 		staticHostMuxAddress  string
 		staticSynced          bool
 
+		// staticPlacementDomains are the failure domains of the host which
+		// are limited by the placement policy.
+		staticPlacementDomains []modules.PlacementDomain
+
 		staticLastUpdate time.Time
 	}
 )
@@ -69,6 +73,13 @@ func (w *worker) managedUpdateCache() {
 		return
 	}
 
+	// Grab the failure domains of the host. Failing to do so isn't fatal,
+	// the host just won't count towards the limits of the placement policy.
+	domains, _, err := w.renter.hostDB.PlacementDomains(w.staticHostPubKey)
+	if err != nil {
+		w.renter.log.Debugf("Worker %v could not fetch the placement domains of its host: %v", w.staticHostPubKeyStr, err)
+	}
+
 	// Create the cache object.
 	newCache := &workerCache{
 		staticBlockHeight:     w.renter.cs.Height(),
@@ -79,6 +90,8 @@ func (w *worker) managedUpdateCache() {
 		staticRenterAllowance: w.renter.hostContractor.Allowance(),
 		staticSynced:          w.renter.cs.Synced(),
 
+		staticPlacementDomains: domains,
+
 		staticLastUpdate: time.Now(),
 	}
 
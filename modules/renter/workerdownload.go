@@ -129,34 +129,38 @@ func (w *worker) threadedPerformDownloadChunkJob(udc *unfinishedDownloadChunk) {
 		return
 	}
 
-	// Fetch the sector. If fetching the sector fails, the worker needs to be
-	// unregistered with the chunk.
+	// Fetch the sector from the sector cache or from the host. If fetching
+	// the sector fails, the worker needs to be unregistered with the chunk.
 	fetchOffset, fetchLength := sectorOffsetAndLength(udc.staticFetchOffset, udc.staticFetchLength, udc.erasureCode)
 	root := udc.staticChunkMap[w.staticHostPubKey.String()].root
-	pieceData, err := w.ReadSectorLowPrio(w.renter.tg.StopCtx(), root, fetchOffset, fetchLength)
-	if err != nil {
-		w.renter.log.Debugln("worker failed to download sector:", err)
-		udc.managedUnregisterWorker(w)
-		return
-	}
+	pieceIndex := udc.staticChunkMap[w.staticHostPubKey.String()].index
+	key := udc.masterKey.Derive(udc.staticKeyIndex, pieceIndex)
+	decryptedPiece, cached := w.renter.staticSectorCache.managedGetPiece(root, key, fetchOffset, fetchLength)
+	if !cached {
+		pieceData, err := w.ReadSectorLowPrio(w.renter.tg.StopCtx(), root, fetchOffset, fetchLength)
+		if err != nil {
+			w.renter.log.Debugln("worker failed to download sector:", err)
+			udc.managedUnregisterWorker(w)
+			return
+		}
 
-	// TODO: Instead of adding the whole sector after the download completes,
-	// have the 'd.Sector' call add to this value ongoing as the sector comes
-	// in. Perhaps even include the data from creating the downloader and other
-	// data sent to and received from the host (like signatures) that aren't
-	// actually payload data.
-	atomic.AddUint64(&udc.download.atomicTotalDataTransferred, udc.staticPieceSize)
+		// TODO: Instead of adding the whole sector after the download completes,
+		// have the 'd.Sector' call add to this value ongoing as the sector comes
+		// in. Perhaps even include the data from creating the downloader and other
+		// data sent to and received from the host (like signatures) that aren't
+		// actually payload data.
+		atomic.AddUint64(&udc.download.atomicTotalDataTransferred, udc.staticPieceSize)
 
-	// Decrypt the piece. This might introduce some overhead for downloads with
-	// a large overdrive. It shouldn't be a bottleneck though since bandwidth
-	// is usually a lot more scarce than CPU processing power.
-	pieceIndex := udc.staticChunkMap[w.staticHostPubKey.String()].index
-	key := udc.masterKey.Derive(udc.staticChunkIndex, pieceIndex)
-	decryptedPiece, err := key.DecryptBytesInPlace(pieceData, uint64(fetchOffset/crypto.SegmentSize))
-	if err != nil {
-		w.renter.log.Debugln("worker failed to decrypt piece:", err)
-		udc.managedUnregisterWorker(w)
-		return
+		// Decrypt the piece. This might introduce some overhead for downloads with
+		// a large overdrive. It shouldn't be a bottleneck though since bandwidth
+		// is usually a lot more scarce than CPU processing power.
+		decryptedPiece, err = key.DecryptBytesInPlace(pieceData, uint64(fetchOffset/crypto.SegmentSize))
+		if err != nil {
+			w.renter.log.Debugln("worker failed to decrypt piece:", err)
+			udc.managedUnregisterWorker(w)
+			return
+		}
+		w.renter.callAddToSectorCache(root, key, fetchOffset, decryptedPiece)
 	}
 
 	// Mark the piece as completed. Perform chunk recovery if we newly have
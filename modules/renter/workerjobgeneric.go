@@ -230,6 +230,8 @@ func (jq *jobGenericQueue) callOnCooldown() bool {
 // cause all remaining jobs in the queue to be discarded, and will put the queue
 // on cooldown.
 func (jq *jobGenericQueue) callReportFailure(err error) {
+	jq.callRecordJob(false)
+
 	jq.mu.Lock()
 	defer jq.mu.Unlock()
 
@@ -241,12 +243,27 @@ func (jq *jobGenericQueue) callReportFailure(err error) {
 	jq.recentErrTime = time.Now()
 }
 
+// callRecordJob records the outcome of a job in the history the hostdb keeps
+// about the worker's host.
+func (jq *jobGenericQueue) callRecordJob(success bool) {
+	w := jq.staticWorkerObj
+	if w == nil || w.renter == nil || w.renter.hostDB == nil {
+		return
+	}
+	err := w.renter.hostDB.RecordJob(w.staticHostPubKey, success)
+	if err != nil {
+		w.renter.log.Debugln("unable to record job outcome:", err)
+	}
+}
+
 // callReportSuccess lets the job queue know that there was a successsful job.
 // Note that this will reset the consecutive failure count, but will not reset
 // the recentErr value - the recentErr value is left as an error so that when
 // debugging later, developers and users can see what errors had been caused by
 // past issues.
 func (jq *jobGenericQueue) callReportSuccess() {
+	jq.callRecordJob(true)
+
 	jq.mu.Lock()
 	jq.consecutiveFailures = 0
 	jq.mu.Unlock()
@@ -10,6 +10,12 @@ import (
 	"github.com/turtledex/errors"
 )
 
+var (
+	// errOffsetProofInvalid is returned if the proof of a ReadOffset job
+	// doesn't match the merkle root of the contract.
+	errOffsetProofInvalid = errors.New("verifying proof failed")
+)
+
 type (
 	// jobReadOffset contains information about a ReadOffset job.
 	jobReadOffset struct {
@@ -92,7 +98,7 @@ func (j *jobReadOffset) managedReadOffset() ([]byte, error) {
 	proofEnd := int(j.staticOffset+j.staticLength) / crypto.SegmentSize
 	ok = crypto.VerifyMixedRangeProof(downloadResponse.Output, downloadResponse.Proof, rev.NewFileMerkleRoot, proofStart, proofEnd)
 	if !ok {
-		return nil, errors.New("verifying proof failed")
+		return nil, errOffsetProofInvalid
 	}
 	return downloadResponse.Output, nil
 }
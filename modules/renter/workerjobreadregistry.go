@@ -1,8 +1,10 @@
 package renter
 
 import (
+	"bytes"
 	"context"
 	"encoding/binary"
+	"strings"
 	"time"
 
 	"github.com/turtledex/TurtleDexCore/build"
@@ -10,6 +12,7 @@ import (
 	"github.com/turtledex/TurtleDexCore/modules"
 	"github.com/turtledex/TurtleDexCore/types"
 
+	"github.com/turtledex/encoding"
 	"github.com/turtledex/errors"
 )
 
@@ -22,13 +25,20 @@ const (
 	// performance is decayed each time a new datapoint is added. The jobs use
 	// an exponential weighted average.
 	jobReadRegistryPerformanceDecay = 0.9
+
+	// errUnknownInstructionStr is the error a host returns when it is asked
+	// to execute an instruction it doesn't know.
+	errUnknownInstructionStr = "unknown instruction specifier"
 )
 
 type (
-	// jobReadRegistry contains information about a ReadRegistry query.
+	// jobReadRegistry contains information about a ReadRegistry query. A
+	// query either specifies the public key and tweak of the entry or only its
+	// entry id. In the latter case the public key and tweak are nil.
 	jobReadRegistry struct {
-		staticTurtleDexPublicKey types.TurtleDexPublicKey
-		staticTweak        crypto.Hash
+		staticRegistryEntryID    modules.RegistryEntryID
+		staticTurtleDexPublicKey *types.TurtleDexPublicKey
+		staticTweak              *crypto.Hash
 
 		staticResponseChan chan *jobReadRegistryResponse // Channel to send a response down
 
@@ -48,6 +58,7 @@ type (
 	// jobReadRegistryResponse contains the result of a ReadRegistry query.
 	jobReadRegistryResponse struct {
 		staticSignedRegistryValue *modules.SignedRegistryValue
+		staticTurtleDexPublicKey  *types.TurtleDexPublicKey
 		staticErr                 error
 	}
 )
@@ -65,6 +76,29 @@ func parseSignedRegistryValueResponse(resp []byte, tweak crypto.Hash) (modules.S
 	return modules.NewSignedRegistryValue(tweak, data, rev, sig), nil
 }
 
+// parseReadRegistryEIDResponse is a helper function to parse the response of
+// a ReadRegistryEID instruction. Apart from the signed registry value, the
+// response also contains the public key and tweak of the entry.
+func parseReadRegistryEIDResponse(resp []byte) (types.TurtleDexPublicKey, modules.SignedRegistryValue, error) {
+	r := bytes.NewReader(resp)
+	var spk types.TurtleDexPublicKey
+	err := encoding.NewDecoder(r, encoding.DefaultAllocLimit).Decode(&spk)
+	if err != nil {
+		return types.TurtleDexPublicKey{}, modules.SignedRegistryValue{}, errors.AddContext(err, "failed to parse public key")
+	}
+	resp = resp[len(resp)-r.Len():]
+	if len(resp) < crypto.HashSize {
+		return types.TurtleDexPublicKey{}, modules.SignedRegistryValue{}, errors.New("failed to parse response due to invalid size")
+	}
+	var tweak crypto.Hash
+	copy(tweak[:], resp[:crypto.HashSize])
+	srv, err := parseSignedRegistryValueResponse(resp[crypto.HashSize:], tweak)
+	if err != nil {
+		return types.TurtleDexPublicKey{}, modules.SignedRegistryValue{}, err
+	}
+	return spk, srv, nil
+}
+
 // lookupsRegistry looks up a registry on the host and verifies its signature.
 func lookupRegistry(w *worker, spk types.TurtleDexPublicKey, tweak crypto.Hash) (*modules.SignedRegistryValue, error) {
 	// Create the program.
@@ -125,13 +159,85 @@ func lookupRegistry(w *worker, spk types.TurtleDexPublicKey, tweak crypto.Hash)
 	return &rv, nil
 }
 
+// lookupRegistryEID looks up a registry entry on the host by its entry id and
+// verifies its signature. It returns the public key of the entry together
+// with the value.
+func lookupRegistryEID(w *worker, eid modules.RegistryEntryID) (*types.TurtleDexPublicKey, *modules.SignedRegistryValue, error) {
+	// Create the program.
+	pt := w.staticPriceTable().staticPriceTable
+	pb := modules.NewProgramBuilder(&pt, 0) // 0 duration since ReadRegistryEID doesn't depend on it.
+	refund, err := pb.AddReadRegistryEIDInstruction(eid)
+	if err != nil {
+		return nil, nil, errors.AddContext(err, "Unable to add read registry eid instruction")
+	}
+	program, programData := pb.Program()
+	cost, _, _ := pb.Cost(true)
+
+	// take into account bandwidth costs
+	ulBandwidth, dlBandwidth := readRegistryJobExpectedBandwidth()
+	bandwidthCost := modules.MDMBandwidthCost(pt, ulBandwidth, dlBandwidth)
+	cost = cost.Add(bandwidthCost)
+
+	// Execute the program and parse the responses.
+	responses, _, err := w.managedExecuteProgram(program, programData, types.FileContractID{}, cost)
+	if err != nil {
+		return nil, nil, errors.AddContext(err, "Unable to execute program")
+	}
+	for _, resp := range responses {
+		if resp.Error != nil {
+			return nil, nil, errors.AddContext(resp.Error, "Output error")
+		}
+		break
+	}
+	if len(responses) != len(program) {
+		return nil, nil, errors.New("received invalid number of responses but no error")
+	}
+
+	// Check if entry was found.
+	resp := responses[0]
+	if resp.OutputLength == 0 {
+		// If the entry wasn't found, we are issued a refund.
+		w.staticAccount.managedTrackDeposit(refund)
+		w.staticAccount.managedCommitDeposit(refund, true)
+		return nil, nil, nil
+	}
+
+	// Parse response.
+	spk, rv, err := parseReadRegistryEIDResponse(resp.Output)
+	if err != nil {
+		return nil, nil, errors.AddContext(err, "failed to parse signed revision response")
+	}
+
+	// Make sure the host returned the entry we asked for.
+	if modules.DeriveRegistryEntryID(spk, rv.Tweak) != eid {
+		return nil, nil, errors.New("host returned entry with wrong entry id")
+	}
+
+	// Verify signature.
+	if rv.Verify(spk.ToPublicKey()) != nil {
+		return nil, nil, errors.New("failed to verify returned registry value's signature")
+	}
+	return &spk, &rv, nil
+}
+
 // newJobReadRegistry is a helper method to create a new ReadRegistry job.
 func (w *worker) newJobReadRegistry(ctx context.Context, responseChan chan *jobReadRegistryResponse, spk types.TurtleDexPublicKey, tweak crypto.Hash) *jobReadRegistry {
 	return &jobReadRegistry{
-		staticTurtleDexPublicKey: spk,
-		staticTweak:        tweak,
-		staticResponseChan: responseChan,
-		jobGeneric:         newJobGeneric(ctx, w.staticJobReadRegistryQueue, nil),
+		staticRegistryEntryID:    modules.DeriveRegistryEntryID(spk, tweak),
+		staticTurtleDexPublicKey: &spk,
+		staticTweak:              &tweak,
+		staticResponseChan:       responseChan,
+		jobGeneric:               newJobGeneric(ctx, w.staticJobReadRegistryQueue, nil),
+	}
+}
+
+// newJobReadRegistryEID is a helper method to create a new ReadRegistry job
+// which looks up an entry by its entry id.
+func (w *worker) newJobReadRegistryEID(ctx context.Context, responseChan chan *jobReadRegistryResponse, eid modules.RegistryEntryID) *jobReadRegistry {
+	return &jobReadRegistry{
+		staticRegistryEntryID: eid,
+		staticResponseChan:    responseChan,
+		jobGeneric:            newJobGeneric(ctx, w.staticJobReadRegistryQueue, nil),
 	}
 }
 
@@ -159,10 +265,11 @@ func (j *jobReadRegistry) callExecute() {
 	w := j.staticQueue.staticWorker()
 
 	// Prepare a method to send a response asynchronously.
-	sendResponse := func(srv *modules.SignedRegistryValue, err error) {
+	sendResponse := func(spk *types.TurtleDexPublicKey, srv *modules.SignedRegistryValue, err error) {
 		errLaunch := w.renter.tg.Launch(func() {
 			response := &jobReadRegistryResponse{
 				staticSignedRegistryValue: srv,
+				staticTurtleDexPublicKey:  spk,
 				staticErr:                 err,
 			}
 			select {
@@ -176,11 +283,24 @@ func (j *jobReadRegistry) callExecute() {
 		}
 	}
 
-	// Read the value.
-	srv, err := lookupRegistry(w, j.staticTurtleDexPublicKey, j.staticTweak)
+	// Read the value. If we know the public key and tweak we use a regular
+	// lookup. Otherwise we look up the entry by its id.
+	var spk *types.TurtleDexPublicKey
+	var srv *modules.SignedRegistryValue
+	var err error
+	if j.staticTurtleDexPublicKey != nil && j.staticTweak != nil {
+		spk = j.staticTurtleDexPublicKey
+		srv, err = lookupRegistry(w, *j.staticTurtleDexPublicKey, *j.staticTweak)
+	} else {
+		spk, srv, err = lookupRegistryEID(w, j.staticRegistryEntryID)
+	}
 	if err != nil {
-		sendResponse(nil, err)
-		j.staticQueue.callReportFailure(err)
+		sendResponse(nil, nil, err)
+		// A host that doesn't know the instruction yet didn't fail the job.
+		// Putting the queue on cooldown would also block regular lookups.
+		if !isUnknownInstructionErr(err) {
+			j.staticQueue.callReportFailure(err)
+		}
 		return
 	}
 
@@ -188,14 +308,14 @@ func (j *jobReadRegistry) callExecute() {
 	// has a higher revision number we update it. If it has a lower one we know that
 	// the host should be punished for losing it or trying to cheat us.
 	if srv != nil {
-		cachedRevision, cached := w.staticRegistryCache.Get(j.staticTurtleDexPublicKey, j.staticTweak)
+		cachedRevision, cached := w.staticRegistryCache.Get(*spk, srv.Tweak)
 		if cached && cachedRevision > srv.Revision {
-			sendResponse(nil, errHostLowerRevisionThanCache)
+			sendResponse(nil, nil, errHostLowerRevisionThanCache)
 			j.staticQueue.callReportFailure(errHostLowerRevisionThanCache)
-			w.staticRegistryCache.Set(j.staticTurtleDexPublicKey, *srv, true) // adjust the cache
+			w.staticRegistryCache.Set(*spk, *srv, true) // adjust the cache
 			return
 		} else if !cached || srv.Revision > cachedRevision {
-			w.staticRegistryCache.Set(j.staticTurtleDexPublicKey, *srv, false) // adjust the cache
+			w.staticRegistryCache.Set(*spk, *srv, false) // adjust the cache
 		}
 	}
 
@@ -203,7 +323,7 @@ func (j *jobReadRegistry) callExecute() {
 	jobTime := time.Since(start)
 
 	// Send the response and report success.
-	sendResponse(srv, nil)
+	sendResponse(spk, srv, nil)
 	j.staticQueue.callReportSuccess()
 
 	// Update the performance stats on the queue.
@@ -252,6 +372,33 @@ func (w *worker) ReadRegistry(ctx context.Context, spk types.TurtleDexPublicKey,
 	return resp.staticSignedRegistryValue, resp.staticErr
 }
 
+// ReadRegistryEID is a helper method to run a ReadRegistry job which looks up
+// an entry by its entry id on a worker.
+func (w *worker) ReadRegistryEID(ctx context.Context, eid modules.RegistryEntryID) (*types.TurtleDexPublicKey, *modules.SignedRegistryValue, error) {
+	readRegistryRespChan := make(chan *jobReadRegistryResponse)
+	jur := w.newJobReadRegistryEID(ctx, readRegistryRespChan, eid)
+
+	// Add the job to the queue.
+	if !w.staticJobReadRegistryQueue.callAdd(jur) {
+		return nil, nil, errors.New("worker unavailable")
+	}
+
+	// Wait for the response.
+	var resp *jobReadRegistryResponse
+	select {
+	case <-ctx.Done():
+		return nil, nil, errors.New("ReadRegistryEID interrupted")
+	case resp = <-readRegistryRespChan:
+	}
+	return resp.staticTurtleDexPublicKey, resp.staticSignedRegistryValue, resp.staticErr
+}
+
+// isUnknownInstructionErr returns whether an error returned by a host was
+// caused by the host not supporting one of the instructions of a program.
+func isUnknownInstructionErr(err error) bool {
+	return err != nil && strings.Contains(err.Error(), errUnknownInstructionStr)
+}
+
 // readRegistryJobExpectedBandwidth is a helper function that returns the
 // expected bandwidth consumption of a ReadRegistry job. This helper function
 // enables getting at the expected bandwidth without having to instantiate a
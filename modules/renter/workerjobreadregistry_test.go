@@ -2,6 +2,8 @@ package renter
 
 import (
 	"context"
+	"encoding/binary"
+	"fmt"
 	"reflect"
 	"testing"
 	"time"
@@ -10,6 +12,7 @@ import (
 	"github.com/turtledex/TurtleDexCore/modules"
 	"github.com/turtledex/TurtleDexCore/siatest/dependencies"
 	"github.com/turtledex/TurtleDexCore/types"
+	"github.com/turtledex/encoding"
 	"github.com/turtledex/errors"
 	"github.com/turtledex/fastrand"
 )
@@ -63,6 +66,110 @@ func TestReadRegistryJob(t *testing.T) {
 	}
 }
 
+// TestReadRegistryEIDJob tests running a ReadRegistry job which looks up an
+// entry by its entry id on a host.
+func TestReadRegistryEIDJob(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	wt, err := newWorkerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Create a registry value.
+	sk, pk := crypto.GenerateKeyPair()
+	var tweak crypto.Hash
+	fastrand.Read(tweak[:])
+	data := fastrand.Bytes(modules.RegistryDataSize)
+	rev := fastrand.Uint64n(1000)
+	spk := types.TurtleDexPublicKey{
+		Algorithm: types.SignatureEd25519,
+		Key:       pk[:],
+	}
+	rv := modules.NewRegistryValue(tweak, data, rev).Sign(sk)
+
+	// Looking up the entry before it exists should return no value.
+	eid := modules.DeriveRegistryEntryID(spk, tweak)
+	lookedUpSPK, lookedUpRV, err := wt.ReadRegistryEID(context.Background(), eid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lookedUpSPK != nil || lookedUpRV != nil {
+		t.Fatal("expected no entry")
+	}
+
+	// Run the UpdateRegistry job.
+	err = wt.UpdateRegistry(context.Background(), spk, rv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create a ReadRegistryEID job to read the entry.
+	lookedUpSPK, lookedUpRV, err = wt.ReadRegistryEID(context.Background(), eid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The entries should match.
+	if !lookedUpSPK.Equals(spk) {
+		t.Fatal("wrong public key")
+	}
+	if !reflect.DeepEqual(*lookedUpRV, rv) {
+		t.Log(lookedUpRV)
+		t.Log(rv)
+		t.Fatal("entries don't match")
+	}
+}
+
+// TestParseReadRegistryEIDResponse is a unit test for
+// parseReadRegistryEIDResponse.
+func TestParseReadRegistryEIDResponse(t *testing.T) {
+	t.Parallel()
+
+	sk, pk := crypto.GenerateKeyPair()
+	spk := types.Ed25519PublicKey(pk)
+	var tweak crypto.Hash
+	fastrand.Read(tweak[:])
+	rv := modules.NewRegistryValue(tweak, fastrand.Bytes(modules.RegistryDataSize), fastrand.Uint64n(1000)).Sign(sk)
+
+	// Build the response like the host would.
+	rev := make([]byte, 8)
+	binary.LittleEndian.PutUint64(rev, rv.Revision)
+	resp := encoding.Marshal(spk)
+	resp = append(resp, tweak[:]...)
+	resp = append(resp, rv.Signature[:]...)
+	resp = append(resp, rev...)
+	resp = append(resp, rv.Data...)
+
+	// Parse it.
+	spk2, rv2, err := parseReadRegistryEIDResponse(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !spk2.Equals(spk) {
+		t.Fatal("wrong public key")
+	}
+	if !reflect.DeepEqual(rv, rv2) {
+		t.Log(rv)
+		t.Log(rv2)
+		t.Fatal("values don't match")
+	}
+
+	// A truncated response should fail.
+	_, _, err = parseReadRegistryEIDResponse(resp[:len(encoding.Marshal(spk))+crypto.HashSize])
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
 // TestReadRegistryInvalidCached checks that a host can't provide an older
 // revision for an entry if we have seen a more recent one from it in the past
 // already.
@@ -234,3 +341,18 @@ func TestReadRegistryCachedUpdated(t *testing.T) {
 		t.Fatal("invalid cached value")
 	}
 }
+
+// TestIsUnknownInstructionErr is a unit test for isUnknownInstructionErr.
+func TestIsUnknownInstructionErr(t *testing.T) {
+	t.Parallel()
+
+	var unknown types.Specifier
+	copy(unknown[:], "Unknown")
+	hostErr := fmt.Errorf("unknown instruction specifier: %v", unknown)
+	if !isUnknownInstructionErr(errors.AddContext(hostErr, "Unable to execute program")) {
+		t.Fatal("host error wasn't recognized")
+	}
+	if isUnknownInstructionErr(errors.New("host is offline")) || isUnknownInstructionErr(nil) {
+		t.Fatal("unrelated error was recognized")
+	}
+}
@@ -9,6 +9,12 @@ import (
 	"github.com/turtledex/errors"
 )
 
+var (
+	// errSectorProofInvalid is returned if the proof of a ReadSector job
+	// doesn't match the requested sector.
+	errSectorProofInvalid = errors.New("proof verification failed")
+)
+
 type (
 	// jobReadSector contains information about a readSector query.
 	jobReadSector struct {
@@ -56,7 +62,7 @@ func (j *jobReadSector) managedReadSector() ([]byte, error) {
 	proofStart := int(j.staticOffset) / crypto.SegmentSize
 	proofEnd := int(j.staticOffset+j.staticLength) / crypto.SegmentSize
 	if !crypto.VerifyRangeProof(data, proof, proofStart, proofEnd, j.staticSector) {
-		return nil, errors.New("proof verification failed")
+		return nil, errSectorProofInvalid
 	}
 	return data, nil
 }
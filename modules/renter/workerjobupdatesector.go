@@ -0,0 +1,188 @@
+package renter
+
+import (
+	"context"
+
+	"github.com/turtledex/TurtleDexCore/build"
+	"github.com/turtledex/TurtleDexCore/modules"
+
+	"github.com/turtledex/errors"
+)
+
+type (
+	// jobUpdateSector contains information about an UpdateSector query.
+	jobUpdateSector struct {
+		staticResponseChan chan *jobUpdateSectorResponse
+		staticSectorIndex  uint64
+		staticOffset       uint64
+		staticData         []byte
+
+		*jobGeneric
+	}
+
+	// jobUpdateSectorQueue is a list of UpdateSector queries that have been
+	// assigned to the worker.
+	jobUpdateSectorQueue struct {
+		*jobGenericQueue
+	}
+
+	// jobUpdateSectorResponse contains the result of an UpdateSector query.
+	jobUpdateSectorResponse struct {
+		staticContract modules.RenterContract
+		staticErr      error
+	}
+)
+
+var (
+	// errUpdateSectorUnsupported is returned if the worker's host doesn't
+	// support updating sectors.
+	errUpdateSectorUnsupported = errors.New("host doesn't support updating sectors")
+)
+
+// callDiscard will discard a job, sending the provided error.
+func (j *jobUpdateSector) callDiscard(err error) {
+	w := j.staticQueue.staticWorker()
+	w.renter.tg.Launch(func() {
+		response := &jobUpdateSectorResponse{
+			staticErr: errors.Extend(err, ErrJobDiscarded),
+		}
+		select {
+		case j.staticResponseChan <- response:
+		case <-j.staticCtx.Done():
+		case <-w.renter.tg.StopChan():
+		}
+	})
+}
+
+// callExecute will run the update sector job.
+func (j *jobUpdateSector) callExecute() {
+	w := j.staticQueue.staticWorker()
+
+	// Proactively try to fix a revision mismatch.
+	w.externTryFixRevisionMismatch()
+
+	contract, err := w.managedUpdateSector(j.staticSectorIndex, j.staticOffset, j.staticData)
+
+	// If the error could be caused by a revision number mismatch,
+	// signal it by setting the flag.
+	if errCausedByRevisionMismatch(err) {
+		w.staticSetSuspectRevisionMismatch()
+		w.staticWake()
+	}
+
+	// Send the response.
+	response := &jobUpdateSectorResponse{
+		staticContract: contract,
+		staticErr:      err,
+	}
+	w.renter.tg.Launch(func() {
+		select {
+		case j.staticResponseChan <- response:
+		case <-j.staticCtx.Done():
+		case <-w.renter.tg.StopChan():
+		}
+	})
+
+	// Report success or failure to the queue.
+	if err != nil {
+		j.staticQueue.callReportFailure(err)
+		return
+	}
+	j.staticQueue.callReportSuccess()
+}
+
+// callExpectedBandwidth returns the amount of bandwidth this job is expected to
+// consume.
+func (j *jobUpdateSector) callExpectedBandwidth() (ul, dl uint64) {
+	// The data is uploaded together with the program and the host responds
+	// with the old segments of the updated range and the proofs.
+	ul = uint64(len(j.staticData)) + 4380
+	dl = uint64(len(j.staticData)) + 4380
+	return
+}
+
+// initJobUpdateSectorQueue will initialize a queue for updating sectors on a
+// host for the worker. This is only meant to be run once at startup.
+func (w *worker) initJobUpdateSectorQueue() {
+	// Sanity check that there is no existing job queue.
+	if w.staticJobUpdateSectorQueue != nil {
+		w.renter.log.Critical("incorrect call on initJobUpdateSectorQueue")
+		return
+	}
+
+	w.staticJobUpdateSectorQueue = &jobUpdateSectorQueue{
+		jobGenericQueue: newJobGenericQueue(w),
+	}
+}
+
+// managedUpdateSector overwrites the bytes at offset within the sector at
+// sectorIndex of the worker's contract with data.
+func (w *worker) managedUpdateSector(sectorIndex, offset uint64, data []byte) (_ modules.RenterContract, err error) {
+	// create a new stream
+	stream, err := w.staticNewStream()
+	if err != nil {
+		return modules.RenterContract{}, errors.AddContext(err, "managedUpdateSector: unable to create a new stream")
+	}
+	defer func() {
+		if err := stream.Close(); err != nil {
+			w.renter.log.Println("managedUpdateSector: failed to close stream", err)
+		}
+	}()
+
+	// write the specifier.
+	err = modules.RPCWrite(stream, modules.RPCExecuteProgram)
+	if err != nil {
+		return modules.RenterContract{}, errors.AddContext(err, "managedUpdateSector: failed to write RPC specifier")
+	}
+
+	// send price table uid
+	pt := w.staticPriceTable().staticPriceTable
+	err = modules.RPCWrite(stream, pt.UID)
+	if err != nil {
+		return modules.RenterContract{}, errors.AddContext(err, "managedUpdateSector: failed to write price table uid")
+	}
+
+	// have the contractset handle the update and track the withdrawal from
+	// the ephemeral account.
+	contract, cost, err := w.renter.hostContractor.UpdateSector(stream, w.staticCache().staticContractID, &pt, w.staticAccount, w.staticAccount.staticID, sectorIndex, offset, data)
+	if !cost.IsZero() {
+		w.staticAccount.managedTrackWithdrawal(cost)
+		w.staticAccount.managedCommitWithdrawal(cost, err == nil)
+	}
+	if err != nil {
+		return modules.RenterContract{}, errors.AddContext(err, "managedUpdateSector: call to UpdateSector failed")
+	}
+	return contract, nil
+}
+
+// UpdateSector overwrites the bytes at offset within the sector at
+// sectorIndex of the contract with the worker's host with data.
+func (w *worker) UpdateSector(ctx context.Context, sectorIndex, offset uint64, data []byte) (modules.RenterContract, error) {
+	// Check that the host supports the instruction.
+	if build.VersionCmp(w.staticCache().staticHostVersion, minUpdateSectorVersion) < 0 {
+		return modules.RenterContract{}, errUpdateSectorUnsupported
+	}
+
+	responseChan := make(chan *jobUpdateSectorResponse)
+	j := &jobUpdateSector{
+		staticResponseChan: responseChan,
+		staticSectorIndex:  sectorIndex,
+		staticOffset:       offset,
+		staticData:         data,
+		jobGeneric:         newJobGeneric(ctx, w.staticJobUpdateSectorQueue, nil),
+	}
+
+	// Add the job to the queue.
+	if !w.staticJobUpdateSectorQueue.callAdd(j) {
+		return modules.RenterContract{}, errors.New("worker unavailable")
+	}
+
+	// Wait for the response.
+	var resp *jobUpdateSectorResponse
+	select {
+	case <-ctx.Done():
+		return modules.RenterContract{}, errors.New("UpdateSector interrupted")
+	case resp = <-responseChan:
+	}
+	return resp.staticContract, resp.staticErr
+}
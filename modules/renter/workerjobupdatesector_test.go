@@ -0,0 +1,102 @@
+package renter
+
+import (
+	"bytes"
+	"context"
+	"sync/atomic"
+	"testing"
+	"unsafe"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/types"
+	"github.com/turtledex/errors"
+	"github.com/turtledex/fastrand"
+)
+
+// TestUpdateSectorJob tests updating a range of bytes within a sector using
+// an UpdateSector job.
+func TestUpdateSectorJob(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	wt, err := newWorkerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Upload a snapshot to fill the first sector of the contract.
+	backup := modules.UploadedBackup{
+		Name:           "foo",
+		CreationDate:   types.CurrentTimestamp(),
+		Size:           10,
+		UploadProgress: 0,
+	}
+	err = wt.UploadSnapshot(context.Background(), backup, fastrand.Bytes(int(backup.Size)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, err := wt.ReadOffset(context.Background(), 0, modules.SectorSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldContract, ok := wt.rt.renter.hostContractor.ContractByPublicKey(wt.staticHostPubKey)
+	if !ok {
+		t.Fatal("contract not found")
+	}
+	oldRev := oldContract.Transaction.FileContractRevisions[0]
+
+	// Update a random range of the sector.
+	offset := fastrand.Uint64n(modules.SectorSize)
+	length := fastrand.Uint64n(modules.SectorSize-offset) + 1
+	data := fastrand.Bytes(int(length))
+	contract, err := wt.UpdateSector(context.Background(), 0, offset, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := append([]byte{}, before...)
+	copy(expected[offset:], data)
+
+	// The contract should have a new merkle root but the same size.
+	rev := contract.Transaction.FileContractRevisions[0]
+	if rev.NewRevisionNumber <= oldRev.NewRevisionNumber {
+		t.Fatal("revision number wasn't increased")
+	}
+	if rev.NewFileMerkleRoot == oldRev.NewFileMerkleRoot {
+		t.Fatal("merkle root wasn't updated")
+	}
+	if rev.NewFileSize != oldRev.NewFileSize {
+		t.Fatal("contract size changed", rev.NewFileSize, oldRev.NewFileSize)
+	}
+
+	// Reading the sector should return the updated data.
+	after, err := wt.ReadOffset(context.Background(), 0, modules.SectorSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(after, expected) {
+		t.Fatal("sector wasn't updated")
+	}
+
+	// Updating a sector which doesn't exist should fail.
+	_, err = wt.UpdateSector(context.Background(), oldRev.NewFileSize/modules.SectorSize, 0, data)
+	if err == nil {
+		t.Fatal("expected update of out-of-bounds sector to fail")
+	}
+
+	// Updating a sector on a host which doesn't support it should fail.
+	wc := *wt.staticCache()
+	wc.staticHostVersion = "1.5.5"
+	atomic.StorePointer(&wt.atomicCache, unsafe.Pointer(&wc))
+	_, err = wt.UpdateSector(context.Background(), 0, offset, data)
+	if !errors.Contains(err, errUpdateSectorUnsupported) {
+		t.Fatal("expected update to fail on an old host", err)
+	}
+}
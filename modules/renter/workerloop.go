@@ -115,6 +115,11 @@ func (w *worker) externTryLaunchSerialJob() {
 		w.externLaunchSerialJob(job.callExecute)
 		return
 	}
+	job = w.staticJobUpdateSectorQueue.callNext()
+	if job != nil {
+		w.externLaunchSerialJob(job.callExecute)
+		return
+	}
 	if w.managedNeedsToRefillAccount() {
 		w.externLaunchSerialJob(w.managedRefillAccount)
 		return
@@ -303,6 +308,7 @@ func (w *worker) threadedWorkLoop() {
 	defer w.staticJobLowPrioReadQueue.callKill()
 	defer w.staticJobHasSectorQueue.callKill()
 	defer w.staticJobUpdateRegistryQueue.callKill()
+	defer w.staticJobUpdateSectorQueue.callKill()
 	defer w.staticJobReadQueue.callKill()
 	defer w.staticJobDownloadSnapshotQueue.callKill()
 	defer w.staticJobUploadSnapshotQueue.callKill()
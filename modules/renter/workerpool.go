@@ -144,6 +144,21 @@ func (wp *workerPool) callWorker(hostPubKey types.TurtleDexPublicKey) (*worker,
 	return worker, nil
 }
 
+// callPlacementDomains returns the cached failure domains of the host with the
+// provided public key. If there is no worker for the host, no domains are
+// returned.
+func (wp *workerPool) callPlacementDomains(hostPubKey types.TurtleDexPublicKey) []modules.PlacementDomain {
+	w, err := wp.callWorker(hostPubKey)
+	if err != nil {
+		return nil
+	}
+	cache := w.staticCache()
+	if cache == nil {
+		return nil
+	}
+	return cache.staticPlacementDomains
+}
+
 // WorkerPoolStatus returns the current status of the Renter's worker pool
 func (r *Renter) WorkerPoolStatus() (modules.WorkerPoolStatus, error) {
 	if err := r.tg.Add(); err != nil {
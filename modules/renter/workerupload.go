@@ -265,8 +265,9 @@ func (w *worker) managedProcessUploadChunk(uc *unfinishedUploadChunk) (nextChunk
 	uc.mu.Lock()
 	_, candidateHost := uc.unusedHosts[w.staticHostPubKey.String()]
 	chunkComplete := uc.staticPiecesNeeded <= uc.piecesCompleted
+	placementAllowed := uc.placementAllowed(cache.staticPlacementDomains)
 	// If the chunk does not need help from this worker, release the chunk.
-	if chunkComplete || !candidateHost || !goodForUpload || onCooldown {
+	if chunkComplete || !candidateHost || !placementAllowed || !goodForUpload || onCooldown {
 		// This worker no longer needs to track this chunk.
 		uc.mu.Unlock()
 		w.managedDropChunk(uc)
@@ -307,6 +308,7 @@ func (w *worker) managedProcessUploadChunk(uc *unfinishedUploadChunk) (nextChunk
 		return nil, 0
 	}
 	delete(uc.unusedHosts, w.staticHostPubKey.String())
+	uc.addPlacement(uint64(index), cache.staticPlacementDomains)
 	uc.piecesRegistered++
 	uc.workersRemaining--
 	uc.mu.Unlock()
@@ -331,6 +333,7 @@ func (w *worker) managedUploadFailed(uc *unfinishedUploadChunk, pieceIndex uint6
 	uc.mu.Lock()
 	uc.piecesRegistered--
 	uc.pieceUsage[pieceIndex] = false
+	uc.removePlacement(pieceIndex)
 	uc.chunkFailedProcessTimes = append(uc.chunkFailedProcessTimes, time.Now())
 	uc.mu.Unlock()
 
@@ -128,6 +128,10 @@ type RPCPriceTable struct {
 	// SwapSectorCost is the cost of swapping 2 full sectors by root.
 	SwapSectorCost types.Currency `json:"swapsectorcost"`
 
+	// Cost values specific to the UpdateSector instruction.
+	UpdateSectorBaseCost   types.Currency `json:"updatesectorbasecost"`   // per update
+	UpdateSectorLengthCost types.Currency `json:"updatesectorlengthcost"` // per byte updated
+
 	// Cost values specific to the Write instruction.
 	WriteBaseCost   types.Currency `json:"writebasecost"`   // per write
 	WriteLengthCost types.Currency `json:"writelengthcost"` // per byte written
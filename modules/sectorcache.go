@@ -0,0 +1,77 @@
+package modules
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/turtledex/errors"
+)
+
+const (
+	// SectorCacheEvictLRU evicts the least recently used sectors from the
+	// sector cache first.
+	SectorCacheEvictLRU SectorCacheEvictionPolicy = "lru"
+
+	// SectorCacheEvictLFU evicts the least frequently used sectors from the
+	// sector cache first.
+	SectorCacheEvictLFU SectorCacheEvictionPolicy = "lfu"
+)
+
+var (
+	// ErrUnknownSectorCacheEvictionPolicy is returned when parsing an unknown
+	// eviction policy.
+	ErrUnknownSectorCacheEvictionPolicy = errors.New("unknown sector cache eviction policy")
+)
+
+type (
+	// SectorCacheEvictionPolicy determines which sectors are evicted from the
+	// sector cache once it reaches its maximum size.
+	SectorCacheEvictionPolicy string
+
+	// SectorCacheSettings are the settings of the renter's on-disk cache of
+	// downloaded sectors. A MaxSize of 0 disables the cache.
+	SectorCacheSettings struct {
+		MaxSize        uint64                    `json:"maxsize"`
+		EvictionPolicy SectorCacheEvictionPolicy `json:"evictionpolicy"`
+	}
+
+	// SectorCacheStatus contains information about the renter's on-disk cache
+	// of downloaded sectors.
+	SectorCacheStatus struct {
+		SectorCacheSettings
+
+		// Entries is the number of cached sectors and Size is the number of
+		// bytes they use on disk.
+		Entries uint64 `json:"entries"`
+		Size    uint64 `json:"size"`
+
+		// Hits is the number of reads which were served from the cache and
+		// Misses is the number of reads which had to fetch data from hosts.
+		// HitBytes is the amount of data served from the cache.
+		Hits     uint64 `json:"hits"`
+		Misses   uint64 `json:"misses"`
+		HitBytes uint64 `json:"hitbytes"`
+
+		// Evictions is the number of sectors which were evicted from the
+		// cache to stay within its maximum size.
+		Evictions uint64 `json:"evictions"`
+	}
+)
+
+// NewSectorCacheEvictionPolicy parses an eviction policy from a string. The
+// empty string results in SectorCacheEvictLRU.
+func NewSectorCacheEvictionPolicy(s string) (SectorCacheEvictionPolicy, error) {
+	switch p := SectorCacheEvictionPolicy(strings.ToLower(s)); p {
+	case "", SectorCacheEvictLRU:
+		return SectorCacheEvictLRU, nil
+	case SectorCacheEvictLFU:
+		return p, nil
+	default:
+		return "", errors.AddContext(ErrUnknownSectorCacheEvictionPolicy, fmt.Sprintf("'%v', supported policies are '%v' and '%v'", s, SectorCacheEvictLRU, SectorCacheEvictLFU))
+	}
+}
+
+// Enabled returns whether the sector cache is enabled.
+func (scs SectorCacheSettings) Enabled() bool {
+	return scs.MaxSize > 0
+}
@@ -75,6 +75,22 @@ type (
 		metadata      SkyfileMetadata
 		metadataAvail chan struct{}
 	}
+
+	// compressedSkyfileReader is a helper struct that implements the
+	// SkyfileUploadReader interface by compressing the data of another
+	// SkyfileUploadReader.
+	//
+	// NOTE: reading from this object is not threadsafe and thus should not be
+	// done from more than one thread if you want the reads to be deterministic.
+	compressedSkyfileReader struct {
+		reader  io.Reader
+		readBuf []byte
+
+		fanoutReader io.Reader
+
+		staticCompression   CompressionType
+		staticSkyfileReader SkyfileUploadReader
+	}
 )
 
 // NewSkyfileReader wraps the given reader and metadata and returns a
@@ -92,6 +108,7 @@ func NewSkyfileReader(reader io.Reader, sup SkyfileUploadParameters) SkyfileUplo
 		metadata: SkyfileMetadata{
 			Filename: sup.Filename,
 			Mode:     sup.Mode,
+			Headers:  sup.Headers,
 		},
 		metadataAvail: make(chan struct{}),
 	}
@@ -157,6 +174,66 @@ func (sr *skyfileReader) Read(p []byte) (n int, err error) {
 	return
 }
 
+// NewCompressedSkyfileReader wraps the given SkyfileUploadReader and returns
+// a SkyfileUploadReader which compresses the data using the given compression
+// type. The compression type is recorded in the SkyfileMetadata. If ct is
+// CompressionNone, the given reader is returned.
+func NewCompressedSkyfileReader(sr SkyfileUploadReader, ct CompressionType) (SkyfileUploadReader, error) {
+	if ct == CompressionNone {
+		return sr, nil
+	}
+	cr, err := NewCompressionReader(ct, sr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Split the compressed data using a TeeReader since the fanout needs to
+	// be created from the compressed data.
+	var buf bytes.Buffer
+	return &compressedSkyfileReader{
+		reader:              io.TeeReader(cr, &buf),
+		fanoutReader:        &buf,
+		staticCompression:   ct,
+		staticSkyfileReader: sr,
+	}, nil
+}
+
+// AddReadBuffer adds the given bytes to the read buffer. The next reads will
+// read from this buffer until it is entirely consumed, after which we continue
+// reading from the underlying reader.
+func (sr *compressedSkyfileReader) AddReadBuffer(b []byte) {
+	sr.readBuf = append(sr.readBuf, b...)
+}
+
+// FanoutReader returns the reader to be used for generating the encoded fanout.
+func (sr *compressedSkyfileReader) FanoutReader() io.Reader {
+	return sr.fanoutReader
+}
+
+// SkyfileMetadata returns the SkyfileMetadata of the underlying reader with
+// the compression type set.
+//
+// NOTE: this method will block until the metadata becomes available
+func (sr *compressedSkyfileReader) SkyfileMetadata(ctx context.Context) (SkyfileMetadata, error) {
+	metadata, err := sr.staticSkyfileReader.SkyfileMetadata(ctx)
+	if err != nil {
+		return SkyfileMetadata{}, err
+	}
+	metadata.Compression = sr.staticCompression
+	return metadata, nil
+}
+
+// Read implements the io.Reader part of the interface and reads compressed
+// data from the underlying reader.
+func (sr *compressedSkyfileReader) Read(p []byte) (n int, err error) {
+	if len(sr.readBuf) > 0 {
+		n = copy(p, sr.readBuf)
+		sr.readBuf = sr.readBuf[n:]
+		return n, nil
+	}
+	return sr.reader.Read(p)
+}
+
 // NewMultipartReader creates a multipart.Reader from an io.Reader and the
 // provided subfiles. This reader can then be used to create
 // a NewSkyfileMultipartReader.
@@ -267,6 +344,9 @@ func newSkyfileMultipartReader(reader *multipart.Reader, fanoutReader *multipart
 			Mode:               sup.Mode,
 			DefaultPath:        sup.DefaultPath,
 			DisableDefaultPath: sup.DisableDefaultPath,
+			Headers:            sup.Headers,
+			TryFiles:           sup.TryFiles,
+			NotFoundPath:       sup.NotFoundPath,
 			Subfiles:           make(SkyfileSubfiles),
 		},
 		metadataAvail: make(chan struct{}),
@@ -20,6 +20,75 @@ func TestSkyfileReader(t *testing.T) {
 	t.Run("Basic", testSkyfileReaderBasic)
 	t.Run("ReadBuffer", testSkyfileReaderReadBuffer)
 	t.Run("MetadataTimeout", testSkyfileReaderMetadataTimeout)
+	t.Run("Compressed", testSkyfileReaderCompressed)
+}
+
+// testSkyfileReaderCompressed verifies the functionality of the compressed
+// SkyfileReader.
+func testSkyfileReaderCompressed(t *testing.T) {
+	t.Parallel()
+
+	// create upload parameters
+	sup := SkyfileUploadParameters{
+		Filename: t.Name(),
+		Mode:     DefaultFilePerm,
+	}
+
+	// create a compressed reader
+	data := bytes.Repeat([]byte{1, 2, 3}, fastrand.Intn(1000)+10)
+	sfReader, err := NewCompressedSkyfileReader(NewSkyfileReader(bytes.NewReader(data), sup), CompressionGzip)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// read 1 byte and add it back to the read buffer
+	peek := make([]byte, 1)
+	if _, err := io.ReadFull(sfReader, peek); err != nil {
+		t.Fatal(err)
+	}
+	sfReader.AddReadBuffer(peek)
+
+	// read the compressed data
+	compressed, err := ioutil.ReadAll(sfReader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// check the metadata
+	metadata, err := sfReader.SkyfileMetadata(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(metadata, SkyfileMetadata{
+		Filename:    sup.Filename,
+		Mode:        sup.Mode,
+		Length:      uint64(len(data)),
+		Compression: CompressionGzip,
+	}) {
+		t.Fatal("unexpected metadata", metadata)
+	}
+
+	// the fanout reader should contain the compressed data
+	fanoutData, err := ioutil.ReadAll(sfReader.FanoutReader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(fanoutData, compressed) {
+		t.Fatal("unexpected fanout data")
+	}
+
+	// the compressed data should decompress to the original data
+	ds, err := NewDecompressionStreamer(CompressionGzip, testStreamer{bytes.NewReader(compressed)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	decompressed, err := ioutil.ReadAll(ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decompressed, data) {
+		t.Fatal("unexpected decompressed data")
+	}
 }
 
 // testSkyfileReaderBasic verifies the basic use case of the SkyfileReader
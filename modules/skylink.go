@@ -13,6 +13,7 @@ import (
 
 	"github.com/turtledex/TurtleDexCore/build"
 	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/types"
 
 	"github.com/turtledex/errors"
 )
@@ -35,12 +36,21 @@ const (
 
 	// rawSkylinkSize is the raw size of the data that gets put into a link.
 	rawSkylinkSize = 34
+
+	// skylinkV2Bitfield is the only valid bitfield of a v2 skylink. The
+	// version bits are set to '01' and all other bits are reserved and need
+	// to be zero.
+	skylinkV2Bitfield = 1
 )
 
 var (
 	// ErrSkylinkIncorrectSize is returned when a string could not be decoded
 	// into a Skylink due to it having an incorrect size.
 	ErrSkylinkIncorrectSize = errors.New("skylink has incorrect size")
+
+	// ErrSkylinkV2InvalidData is returned when the data of the registry entry
+	// a v2 skylink points to doesn't contain a valid v1 skylink.
+	ErrSkylinkV2InvalidData = errors.New("registry entry of v2 skylink doesn't contain a valid v1 skylink")
 )
 
 type (
@@ -51,6 +61,12 @@ type (
 	// The first two bits of the bitfield (values 1 and 2 in decimal) determine
 	// the version of the skylink. The skylink version determines how the
 	// remaining bits are used. Not all values of the bitfield are legal.
+	//
+	// For v1 skylinks the MerkleRoot is the root of the sector that contains
+	// the skyfile. For v2 skylinks the MerkleRoot is the RegistryEntryID of a
+	// registry entry which contains a v1 skylink in its data. This makes v2
+	// skylinks mutable since the owner of the entry can update it to point to
+	// a different v1 skylink.
 	Skylink struct {
 		bitfield   uint16
 		merkleRoot crypto.Hash
@@ -73,11 +89,54 @@ func NewSkylinkV1(merkleRoot crypto.Hash, offset, length uint64) (Skylink, error
 	return sl, nil
 }
 
+// NewSkylinkV2 returns a v2 Skylink object which points to the registry entry
+// identified by the provided public key and tweak.
+func NewSkylinkV2(spk types.TurtleDexPublicKey, tweak crypto.Hash) Skylink {
+	return Skylink{
+		bitfield:   skylinkV2Bitfield,
+		merkleRoot: crypto.Hash(DeriveRegistryEntryID(spk, tweak)),
+	}
+}
+
+// NewSkylinkV2FromRegistryValue parses the v1 skylink that is stored in the
+// data of a registry value which a v2 skylink points to.
+func NewSkylinkV2FromRegistryValue(rv RegistryValue) (Skylink, error) {
+	if len(rv.Data) != rawSkylinkSize {
+		return Skylink{}, ErrSkylinkV2InvalidData
+	}
+	var sl Skylink
+	err := sl.LoadBytes(rv.Data)
+	if err != nil {
+		return Skylink{}, errors.Compose(err, ErrSkylinkV2InvalidData)
+	}
+	if !sl.IsSkylinkV1() {
+		return Skylink{}, ErrSkylinkV2InvalidData
+	}
+	return sl, nil
+}
+
 // isSkylinkV1 returns a boolean indicating if the Skylink is a V1 skylink
 func isSkylinkV1(bitfield uint16) bool {
 	return bitfield&3 == 0
 }
 
+// isSkylinkV2 returns a boolean indicating if the Skylink is a V2 skylink
+func isSkylinkV2(bitfield uint16) bool {
+	return bitfield&3 == 1
+}
+
+// validateBitfield validates the bitfield of a skylink of any known version.
+func validateBitfield(bitfield uint16) error {
+	if isSkylinkV2(bitfield) {
+		if bitfield != skylinkV2Bitfield {
+			return errors.New("v2 skylink has reserved bits set")
+		}
+		return nil
+	}
+	_, _, err := validateAndParseV1Bitfield(bitfield)
+	return err
+}
+
 // validateAndParseV1Bitfield is a helper method which validates that a bitfield
 // is valid and also parses the offset and fetch size from the bitfield. These
 // two actions are performed at once because performing full validation requires
@@ -164,6 +223,11 @@ func (sl Skylink) IsSkylinkV1() bool {
 	return isSkylinkV1(sl.bitfield)
 }
 
+// IsSkylinkV2 returns a boolean indicating if the Skylink is a V2 skylink
+func (sl Skylink) IsSkylinkV2() bool {
+	return isSkylinkV2(sl.bitfield)
+}
+
 // LoadString converts from a string and loads the result into sl.
 func (sl *Skylink) LoadString(s string) error {
 	// Trim any parameters that may exist after a question mark. Eventually, it
@@ -197,6 +261,15 @@ func (sl Skylink) MerkleRoot() crypto.Hash {
 	return sl.merkleRoot
 }
 
+// RegistryEntryID returns the id of the registry entry a v2 Skylink points
+// to. For v1 skylinks this is not a valid entry id.
+func (sl Skylink) RegistryEntryID() RegistryEntryID {
+	if !sl.IsSkylinkV2() {
+		build.Critical("RegistryEntryID called on skylink that is not a v2 skylink")
+	}
+	return RegistryEntryID(sl.merkleRoot)
+}
+
 // OffsetAndFetchSize returns the offset and fetch size of a file that sits
 // within a skylink sector. All skylinks point to one sector of data. If the
 // file is large enough that more data is necessary, a "fanout" is used to point
@@ -332,7 +405,7 @@ func (sl *Skylink) LoadBytes(data []byte) error {
 	// Skylink so that the Skylink remains unchanged if there is any error
 	// parsing the string.
 	bitfield := binary.LittleEndian.Uint16(data)
-	_, _, err := validateAndParseV1Bitfield(bitfield)
+	err := validateBitfield(bitfield)
 	if err != nil {
 		return errors.AddContext(err, "skylink failed verification")
 	}
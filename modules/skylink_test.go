@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/types"
 	"github.com/turtledex/errors"
 
 	"github.com/turtledex/fastrand"
@@ -202,9 +203,10 @@ func TestSkylink(t *testing.T) {
 		t.Error("expecting error when loading a string containing an illegal character")
 	}
 
-	// Try loading a base32 encoded string with invalid bitfield
+	// Try loading a base32 encoded string with invalid bitfield. The bitfield
+	// indicates a v2 skylink with reserved bits set.
 	var slInvalidBitfield Skylink
-	slInvalidBitfield.bitfield = 1
+	slInvalidBitfield.bitfield = 5
 	b32BadBitfield := slInvalidBitfield.Base32EncodedString()
 	err = slMaxB32Decoded.LoadString(b32BadBitfield)
 	if err == nil {
@@ -287,6 +289,73 @@ func TestSkylink(t *testing.T) {
 	}
 }
 
+// TestSkylinkV2 tests creating, encoding and decoding v2 skylinks.
+func TestSkylinkV2(t *testing.T) {
+	// Create a v2 skylink.
+	_, pk := crypto.GenerateKeyPair()
+	spk := types.Ed25519PublicKey(pk)
+	tweak := crypto.HashObject("tweak")
+	sl := NewSkylinkV2(spk, tweak)
+	if !sl.IsSkylinkV2() || sl.IsSkylinkV1() {
+		t.Fatal("skylink should be v2")
+	}
+	if sl.Version() != 2 {
+		t.Fatal("bad version:", sl.Version())
+	}
+	if sl.RegistryEntryID() != DeriveRegistryEntryID(spk, tweak) {
+		t.Fatal("wrong entry id")
+	}
+	// A v2 skylink doesn't have an offset and fetch size.
+	_, _, err := sl.OffsetAndFetchSize()
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	// Encode and decode it.
+	var sl2 Skylink
+	err = sl2.LoadString(sl.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sl2 != sl {
+		t.Fatal("skylinks don't match")
+	}
+	err = sl2.LoadString(sl.Base32EncodedString())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sl2 != sl {
+		t.Fatal("skylinks don't match")
+	}
+
+	// Create a registry value pointing to a v1 skylink and parse it.
+	slV1, err := NewSkylinkV1(crypto.HashObject("root"), 0, 4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rv := NewRegistryValue(tweak, slV1.Bytes(), 0)
+	resolved, err := NewSkylinkV2FromRegistryValue(rv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved != slV1 {
+		t.Fatal("resolved skylink doesn't match")
+	}
+
+	// A registry value pointing to another v2 skylink is invalid.
+	rv = NewRegistryValue(tweak, sl.Bytes(), 0)
+	_, err = NewSkylinkV2FromRegistryValue(rv)
+	if !errors.Contains(err, ErrSkylinkV2InvalidData) {
+		t.Fatal("wrong error", err)
+	}
+	// So is a registry value with the wrong length.
+	rv = NewRegistryValue(tweak, slV1.Bytes()[1:], 0)
+	_, err = NewSkylinkV2FromRegistryValue(rv)
+	if !errors.Contains(err, ErrSkylinkV2InvalidData) {
+		t.Fatal("wrong error", err)
+	}
+}
+
 // TestSkylinkAutoExamples performs a brute force test over lots of values for
 // the skylink bitfield to ensure correctness.
 func TestSkylinkAutoExamples(t *testing.T) {
@@ -6,6 +6,7 @@ import (
 	"io"
 	"math"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"time"
@@ -27,6 +28,18 @@ const (
 
 	// layoutKeyDataSize is the size of the key-data field in a skyfileLayout.
 	layoutKeyDataSize = 64
+
+	// SkyfileMaxHeaders is the maximum number of custom headers a skyfile can
+	// specify.
+	SkyfileMaxHeaders = 32
+
+	// SkyfileMaxTryFiles is the maximum number of try files a skyfile can
+	// specify.
+	SkyfileMaxTryFiles = 16
+
+	// SkyfileTryFilesURI is the placeholder within a skyfile's try files which
+	// is replaced with the requested path.
+	SkyfileTryFilesURI = "$uri"
 )
 
 var (
@@ -55,6 +68,12 @@ var (
 	SkyfileFormatTarGz = SkyfileFormat("targz")
 	// SkyfileFormatZip returns the skyfiles as a .zip.
 	SkyfileFormatZip = SkyfileFormat("zip")
+	// SkyfileFormatHTML returns an HTML listing of the skyfiles in a
+	// directory.
+	SkyfileFormatHTML = SkyfileFormat("html")
+	// SkyfileFormatJSON returns a JSON listing of the skyfiles in a
+	// directory.
+	SkyfileFormatJSON = SkyfileFormat("json")
 )
 
 type (
@@ -105,6 +124,26 @@ type (
 		// content will be automatically served for the skyfile.
 		DisableDefaultPath bool
 
+		// Headers are custom HTTP headers which are set on every response
+		// serving the skyfile, e.g. Cache-Control or CORS headers.
+		Headers map[string]string
+
+		// TryFiles are the paths which are tried in order when a requested
+		// path doesn't exist within the skyfile. Every '$uri' within a path
+		// is replaced with the requested path. This allows single-page apps
+		// to serve their index for deep links.
+		TryFiles []string
+
+		// NotFoundPath is the path of the file which is served with a 404
+		// status when a requested path doesn't exist within the skyfile and
+		// none of the TryFiles match.
+		NotFoundPath string
+
+		// Compression is the type of compression applied to the file data
+		// before it is uploaded. It is recorded in the skyfile's metadata
+		// which allows downloads to decompress the data transparently.
+		Compression CompressionType
+
 		// Reader supplies the file data for the skyfile.
 		Reader io.Reader
 
@@ -115,10 +154,20 @@ type (
 		// SkykeyID is the ID of Skykey that should be used to encrypt the file.
 		SkykeyID skykey.SkykeyID
 
+		// SkykeyRecipients are the public keys of the recipient skykeys the
+		// Skyfile should be encrypted for. It can't be combined with
+		// SkykeyName or SkykeyID.
+		SkykeyRecipients []skykey.RecipientPublicKey
+
 		// If Encrypt is set to true and one of SkykeyName or SkykeyID was set,
 		// a Skykey will be derived from the Master Skykey found under that
 		// name/ID to be used for this specific upload.
 		FileSpecificSkykey skykey.Skykey
+
+		// FileSpecificKeyData is the key data which is stored in the layout of
+		// a Skyfile encrypted for recipients. It allows the recipients to
+		// recover the FileSpecificSkykey.
+		FileSpecificKeyData []byte
 	}
 
 	// SkyfileMultipartUploadParameters defines the parameters specific to
@@ -143,8 +192,18 @@ type (
 		// content will be automatically served for the skyfile.
 		DisableDefaultPath bool
 
+		// Headers, TryFiles and NotFoundPath control how the skyfile is
+		// served. See SkyfileUploadParameters.
+		Headers      map[string]string
+		TryFiles     []string
+		NotFoundPath string
+
 		// ContentType indicates the media of the data supplied by the reader.
 		ContentType string
+
+		// Compression is the type of compression applied to the data before
+		// it is uploaded.
+		Compression CompressionType
 	}
 
 	// SkyfilePinParameters defines the parameters specific to pinning a
@@ -169,6 +228,13 @@ type (
 		Subfiles           SkyfileSubfiles `json:"subfiles,omitempty"`
 		DefaultPath        string          `json:"defaultpath,omitempty"`
 		DisableDefaultPath bool            `json:"disabledefaultpath,omitempty"`
+		Compression        CompressionType `json:"compression,omitempty"`
+
+		// Headers, TryFiles and NotFoundPath control how the skyfile is
+		// served. See SkyfileUploadParameters.
+		Headers      map[string]string `json:"headers,omitempty"`
+		TryFiles     []string          `json:"tryfiles,omitempty"`
+		NotFoundPath string            `json:"notfoundpath,omitempty"`
 	}
 
 	// SkynetPortal contains information identifying a Skynet portal.
@@ -226,6 +292,19 @@ func (sm SkyfileMetadata) ForPath(path string) (SkyfileMetadata, bool, uint64, u
 	return metadata, isFile, offset, metadata.size()
 }
 
+// TryFilesPath returns the first of the skyfile's TryFiles which refers to a
+// file for the requested uri.
+func (sm SkyfileMetadata) TryFilesPath(uri string) (string, bool) {
+	uri = EnsurePrefix(uri, "/")
+	for _, tf := range sm.TryFiles {
+		candidate := path.Clean(EnsurePrefix(strings.Replace(tf, SkyfileTryFilesURI, uri, -1), "/"))
+		if _, isFile, _, _ := sm.ForPath(candidate); isFile {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
 // ContentType returns the Content Type of the data. We only return a
 // content-type if it has exactly one subfile. As that is the only case where we
 // can be sure of it.
@@ -464,3 +543,9 @@ func (sf SkyfileFormat) IsArchive() bool {
 		sf == SkyfileFormatTarGz ||
 		sf == SkyfileFormatZip
 }
+
+// IsListing returns true if the format is a directory listing.
+func (sf SkyfileFormat) IsListing() bool {
+	return sf == SkyfileFormatHTML ||
+		sf == SkyfileFormatJSON
+}
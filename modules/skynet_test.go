@@ -226,6 +226,48 @@ func TestSkyfileMetadata_ForPath(t *testing.T) {
 	}
 }
 
+// TestSkyfileMetadata_TryFilesPath tests the TryFilesPath method.
+func TestSkyfileMetadata_TryFilesPath(t *testing.T) {
+	t.Parallel()
+
+	sm := SkyfileMetadata{
+		Subfiles: SkyfileSubfiles{
+			"index.html":       SkyfileSubfileMetadata{Filename: "index.html"},
+			"about.html":       SkyfileSubfileMetadata{Filename: "about.html"},
+			"docs/index.html":  SkyfileSubfileMetadata{Filename: "docs/index.html"},
+			"assets/style.css": SkyfileSubfileMetadata{Filename: "assets/style.css"},
+		},
+		TryFiles: []string{"$uri.html", "$uri/index.html", "/index.html"},
+	}
+	tests := []struct {
+		uri      string
+		expected string
+	}{
+		{uri: "/about", expected: "/about.html"},
+		{uri: "about", expected: "/about.html"},
+		{uri: "/docs", expected: "/docs/index.html"},
+		{uri: "/docs/", expected: "/docs/index.html"},
+		{uri: "/users/123", expected: "/index.html"},
+		{uri: "/../../about", expected: "/about.html"},
+	}
+	for _, test := range tests {
+		path, ok := sm.TryFilesPath(test.uri)
+		if !ok || path != test.expected {
+			t.Fatalf("'%s' failed: expected '%s', got '%s'", test.uri, test.expected, path)
+		}
+	}
+
+	// Without a matching try file nothing is found.
+	sm.TryFiles = []string{"$uri.html"}
+	if path, ok := sm.TryFilesPath("/users/123"); ok {
+		t.Fatal("unexpected path", path)
+	}
+	sm.TryFiles = nil
+	if path, ok := sm.TryFilesPath("/about"); ok {
+		t.Fatal("unexpected path", path)
+	}
+}
+
 // TestSkyfileMetadata_IsDirectory is a table test for the IsDirectory method.
 func TestSkyfileMetadata_IsDirectory(t *testing.T) {
 	tests := []struct {
@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"net/textproto"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -18,12 +19,43 @@ import (
 	"github.com/turtledex/TurtleDexCore/crypto"
 	"github.com/turtledex/TurtleDexCore/skykey"
 	"github.com/turtledex/errors"
+	"golang.org/x/net/http/httpguts"
 )
 
 var (
 	// ErrInvalidDefaultPath is returned when the specified default path is not
 	// valid, e.g. the file it points to does not exist.
 	ErrInvalidDefaultPath = errors.New("invalid default path provided")
+
+	// ErrInvalidSkyfileHeaders is returned when the custom headers of a
+	// skyfile are not valid, e.g. they override a header set by skynet.
+	ErrInvalidSkyfileHeaders = errors.New("invalid skyfile headers provided")
+
+	// ErrInvalidSkyfileRouting is returned when the try files or the not found
+	// path of a skyfile are not valid, e.g. they point to a file which does
+	// not exist.
+	ErrInvalidSkyfileRouting = errors.New("invalid skyfile routing provided")
+
+	// ErrSkyfileNotForRecipient is returned when a skyfile was not encrypted
+	// for a recipient skykey.
+	ErrSkyfileNotForRecipient = errors.New("skyfile was not encrypted for recipient skykey")
+
+	// allowedSkyfileHeaders are the headers a skyfile's custom headers can
+	// set. The headers set by skynet, e.g. the content type or the skylink,
+	// can't be overridden.
+	allowedSkyfileHeaders = map[string]struct{}{
+		"Access-Control-Allow-Headers":  {},
+		"Access-Control-Allow-Methods":  {},
+		"Access-Control-Allow-Origin":   {},
+		"Access-Control-Expose-Headers": {},
+		"Access-Control-Max-Age":        {},
+		"Cache-Control":                 {},
+		"Content-Language":              {},
+		"Content-Security-Policy":       {},
+		"Referrer-Policy":               {},
+		"X-Content-Type-Options":        {},
+		"X-Frame-Options":               {},
+	}
 )
 
 // AddMultipartFile is a helper function to add a file to multipart form-data.
@@ -133,8 +165,15 @@ func DecryptBaseSector(baseSector []byte, sk skykey.Skykey) (skykey.Skykey, erro
 	var keyID skykey.SkykeyID
 	copy(keyID[:], sl.KeyData[:skykey.SkykeyIDLen])
 
-	// Derive the file-specific key.
-	fileSkykey, err := sk.SubkeyWithNonce(nonce)
+	// Derive the file-specific key. Skyfiles encrypted for recipients don't
+	// store a nonce, their file-specific key is recovered from the key data.
+	var fileSkykey skykey.Skykey
+	var err error
+	if sk.Type == skykey.TypeRecipient {
+		fileSkykey, err = RecipientFileSkykey(baseSector, sk)
+	} else {
+		fileSkykey, err = sk.SubkeyWithNonce(nonce)
+	}
 	if err != nil {
 		return skykey.Skykey{}, errors.AddContext(err, "Unable to derive file-specific subkey")
 	}
@@ -177,6 +216,22 @@ func DecryptBaseSector(baseSector []byte, sk skykey.Skykey) (skykey.Skykey, erro
 	return fileSkykey, nil
 }
 
+// RecipientFileSkykey returns the file-specific skykey of a skyfile encrypted
+// for the given TypeRecipient skykey. The base sector is not modified. An error
+// is returned if the skyfile wasn't encrypted for the skykey.
+func RecipientFileSkykey(baseSector []byte, sk skykey.Skykey) (skykey.Skykey, error) {
+	if len(baseSector) < SkyfileLayoutSize {
+		return skykey.Skykey{}, errors.New("baseSector too small")
+	}
+	var sl SkyfileLayout
+	sl.Decode(baseSector)
+	fileSkykey, err := sk.RecipientSubkey(sl.KeyData[:])
+	if errors.Contains(err, skykey.ErrNotRecipient) {
+		return skykey.Skykey{}, ErrSkyfileNotForRecipient
+	}
+	return fileSkykey, err
+}
+
 // DeriveFanoutKey returns the crypto.CipherKey that should be used for
 // decrypting the fanout stream from the skyfile stored using this layout.
 func DeriveFanoutKey(sl *SkyfileLayout, fileSkykey skykey.Skykey) (crypto.CipherKey, error) {
@@ -322,6 +377,18 @@ func ValidateSkyfileMetadata(metadata SkyfileMetadata) error {
 		}
 	}
 
+	// validate the custom headers
+	err = validateHeaders(metadata.Headers)
+	if err != nil {
+		return errors.Compose(ErrInvalidSkyfileHeaders, err)
+	}
+
+	// validate the try files and the not found path
+	err = validateRouting(metadata.TryFiles, metadata.NotFoundPath, metadata.Subfiles)
+	if err != nil {
+		return errors.Compose(ErrInvalidSkyfileRouting, err)
+	}
+
 	return nil
 }
 
@@ -384,3 +451,66 @@ func validateDefaultPath(defaultPath string, subfiles SkyfileSubfiles) (string,
 
 	return defaultPath, nil
 }
+
+// IsAllowedSkyfileHeader returns whether a skyfile's custom headers can set
+// the header with the given name.
+func IsAllowedSkyfileHeader(name string) bool {
+	_, allowed := allowedSkyfileHeaders[http.CanonicalHeaderKey(name)]
+	return allowed
+}
+
+// validateHeaders ensures the given custom headers are valid HTTP headers and
+// are allowed to be set by a skyfile.
+func validateHeaders(headers map[string]string) error {
+	if len(headers) > SkyfileMaxHeaders {
+		return fmt.Errorf("too many headers, %v > %v", len(headers), SkyfileMaxHeaders)
+	}
+	seen := make(map[string]struct{}, len(headers))
+	for name, value := range headers {
+		if !httpguts.ValidHeaderFieldName(name) {
+			return fmt.Errorf("invalid header name '%s'", name)
+		}
+		if !httpguts.ValidHeaderFieldValue(value) {
+			return fmt.Errorf("invalid value for header '%s'", name)
+		}
+		canonical := http.CanonicalHeaderKey(name)
+		if _, exists := seen[canonical]; exists {
+			return fmt.Errorf("header '%s' specified more than once", canonical)
+		}
+		seen[canonical] = struct{}{}
+		if !IsAllowedSkyfileHeader(canonical) {
+			return fmt.Errorf("header '%s' is not allowed", canonical)
+		}
+	}
+	return nil
+}
+
+// validateRouting ensures the given try files and not found path make sense in
+// relation to the subfiles being uploaded. Try files which contain the
+// SkyfileTryFilesURI placeholder can only be checked when they are used.
+func validateRouting(tryFiles []string, notFoundPath string, subfiles SkyfileSubfiles) error {
+	if len(tryFiles) > SkyfileMaxTryFiles {
+		return fmt.Errorf("too many try files, %v > %v", len(tryFiles), SkyfileMaxTryFiles)
+	}
+	if (len(tryFiles) > 0 || notFoundPath != "") && len(subfiles) == 0 {
+		return errors.New("try files and not found path require a skyfile with subfiles")
+	}
+	for _, tf := range tryFiles {
+		if tf == "" {
+			return errors.New("try files can't be empty")
+		}
+		if strings.Contains(tf, SkyfileTryFilesURI) {
+			continue
+		}
+		if _, found := subfiles[strings.TrimPrefix(path.Clean(EnsurePrefix(tf, "/")), "/")]; !found {
+			return fmt.Errorf("no such try file: %s", tf)
+		}
+	}
+	if notFoundPath == "" {
+		return nil
+	}
+	if _, found := subfiles[strings.TrimPrefix(path.Clean(EnsurePrefix(notFoundPath, "/")), "/")]; !found {
+		return fmt.Errorf("no such not found path: %s", notFoundPath)
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package modules
 
 import (
+	"fmt"
 	"math"
 	"strings"
 	"testing"
@@ -14,6 +15,8 @@ import (
 // TestSkynet` from the command line.
 func TestSkynetHelpers(t *testing.T) {
 	t.Run("ValidateDefaultPath", testValidateDefaultPath)
+	t.Run("ValidateHeaders", testValidateHeaders)
+	t.Run("ValidateRouting", testValidateRouting)
 	t.Run("ValidateSkyfileMetadata", testValidateSkyfileMetadata)
 	t.Run("EnsurePrefix", testEnsurePrefix)
 	t.Run("EnsureSuffix", testEnsureSuffix)
@@ -184,6 +187,154 @@ func testValidateSkyfileMetadata(t *testing.T) {
 	if err != nil {
 		t.Fatal("unexpected outcome")
 	}
+
+	// verify invalid headers
+	invalid = metadata
+	invalid.Headers = map[string]string{"Content-Type": "text/plain"}
+	err = ValidateSkyfileMetadata(invalid)
+	if !errors.Contains(err, ErrInvalidSkyfileHeaders) {
+		t.Fatal("unexpected outcome")
+	}
+
+	// verify invalid routing
+	invalid = metadata
+	invalid.NotFoundPath = "/404.html"
+	err = ValidateSkyfileMetadata(invalid)
+	if !errors.Contains(err, ErrInvalidSkyfileRouting) {
+		t.Fatal("unexpected outcome")
+	}
+}
+
+// testValidateHeaders ensures the functionality of 'validateHeaders'
+func testValidateHeaders(t *testing.T) {
+	t.Parallel()
+
+	// valid headers
+	valid := []map[string]string{
+		nil,
+		{"Cache-Control": "max-age=3600", "access-control-allow-origin": "*"},
+		{"Content-Security-Policy": "default-src 'self'", "X-Frame-Options": "DENY"},
+		{"Cache-Control": ""},
+	}
+	for _, headers := range valid {
+		if err := validateHeaders(headers); err != nil {
+			t.Fatal("unexpected error", headers, err)
+		}
+	}
+
+	// invalid headers
+	tooMany := make(map[string]string)
+	for i := 0; i <= SkyfileMaxHeaders; i++ {
+		tooMany[fmt.Sprintf("X-Header-%v", i)] = "value"
+	}
+	invalid := []map[string]string{
+		tooMany,
+		{"": "value"},
+		{"Invalid Name": "value"},
+		{"Cache-Control": "foo\r\nbar"},
+		{"Cache-Control": "a", "cache-control": "b"},
+	}
+	for _, headers := range invalid {
+		if err := validateHeaders(headers); err == nil {
+			t.Fatal("expected error", headers)
+		}
+	}
+
+	// headers which aren't allowed
+	rejected := []string{
+		"content-type",
+		"Content-Disposition",
+		"Content-Encoding",
+		"Content-Length",
+		"ETag",
+		"Location",
+		"Set-Cookie",
+		"Skynet-Skylink",
+		"Strict-Transport-Security",
+		"Transfer-Encoding",
+		"X-Custom",
+	}
+	for _, name := range rejected {
+		err := validateHeaders(map[string]string{name: "value"})
+		if err == nil || !strings.Contains(err.Error(), "is not allowed") {
+			t.Fatal("expected header to be rejected", name, err)
+		}
+	}
+}
+
+// testValidateRouting ensures the functionality of 'validateRouting'
+func testValidateRouting(t *testing.T) {
+	t.Parallel()
+
+	subfiles := SkyfileSubfiles{
+		"index.html":      SkyfileSubfileMetadata{Filename: "index.html"},
+		"errors/404.html": SkyfileSubfileMetadata{Filename: "errors/404.html"},
+	}
+	tooMany := make([]string, SkyfileMaxTryFiles+1)
+	for i := range tooMany {
+		tooMany[i] = "/index.html"
+	}
+
+	tests := []struct {
+		name         string
+		tryFiles     []string
+		notFoundPath string
+		subfiles     SkyfileSubfiles
+		err          string
+	}{
+		{
+			name:     "no routing",
+			subfiles: nil,
+		},
+		{
+			name:         "valid routing",
+			tryFiles:     []string{"$uri", "$uri.html", "/index.html", "errors/../index.html"},
+			notFoundPath: "/errors/404.html",
+			subfiles:     subfiles,
+		},
+		{
+			name:     "single file",
+			tryFiles: []string{"/index.html"},
+			subfiles: nil,
+			err:      "require a skyfile with subfiles",
+		},
+		{
+			name:     "too many try files",
+			tryFiles: tooMany,
+			subfiles: subfiles,
+			err:      "too many try files",
+		},
+		{
+			name:     "empty try file",
+			tryFiles: []string{""},
+			subfiles: subfiles,
+			err:      "try files can't be empty",
+		},
+		{
+			name:     "missing try file",
+			tryFiles: []string{"/app.html"},
+			subfiles: subfiles,
+			err:      "no such try file",
+		},
+		{
+			name:         "missing not found path",
+			notFoundPath: "/404.html",
+			subfiles:     subfiles,
+			err:          "no such not found path",
+		},
+	}
+
+	for _, subtest := range tests {
+		t.Run(subtest.name, func(t *testing.T) {
+			err := validateRouting(subtest.tryFiles, subtest.notFoundPath, subtest.subfiles)
+			if subtest.err == "" && err != nil {
+				t.Fatal("Unexpected error", err)
+			}
+			if subtest.err != "" && (err == nil || !strings.Contains(err.Error(), subtest.err)) {
+				t.Fatal("Unexpected error", err)
+			}
+		})
+	}
 }
 
 // testEnsurePrefix ensures EnsurePrefix is properly adding prefixes.
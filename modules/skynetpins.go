@@ -0,0 +1,36 @@
+package modules
+
+import (
+	"time"
+)
+
+type (
+	// SkynetPin is a skylink which the renter keeps pinned. The renter
+	// periodically checks how many hosts store the base sector of the skylink
+	// and pins it again if its siafile is missing or too few hosts store it.
+	SkynetPin struct {
+		Skylink       string        `json:"skylink"`
+		Label         string        `json:"label"`
+		TurtleDexPath TurtleDexPath `json:"siapath"`
+
+		// BaseChunkRedundancy is the redundancy the base chunk is pinned with
+		// and the number of hosts which should store the base sector.
+		BaseChunkRedundancy uint8 `json:"basechunkredundancy"`
+	}
+
+	// SkynetPinStatus is the status of a skylink pinned by the renter.
+	SkynetPinStatus struct {
+		SkynetPin
+
+		// Hosts is the number of hosts which stored the base sector during the
+		// last check. Healthy is true if the siafile exists and the base
+		// sector is stored by enough hosts.
+		Hosts   uint64 `json:"hosts"`
+		Healthy bool   `json:"healthy"`
+
+		LastCheck time.Time `json:"lastcheck"`
+		LastPin   time.Time `json:"lastpin"`
+		Repins    uint64    `json:"repins"`
+		LastError string    `json:"lasterror"`
+	}
+)
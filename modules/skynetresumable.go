@@ -0,0 +1,43 @@
+package modules
+
+import (
+	"time"
+
+	"github.com/turtledex/TurtleDexCore/build"
+)
+
+var (
+	// ResumableUploadMaxSize is the maximum length of a single resumable
+	// skyfile upload.
+	ResumableUploadMaxSize = build.Select(build.Var{
+		Dev:      uint64(1 << 30), // 1 GiB
+		Standard: uint64(1 << 34), // 16 GiB
+		Testing:  uint64(1 << 20), // 1 MiB
+	}).(uint64)
+)
+
+type (
+	// ResumableUploadID uniquely identifies a resumable skyfile upload.
+	ResumableUploadID string
+
+	// ResumableSkyfileUpload describes a skyfile upload which receives its
+	// data in multiple requests. The data received so far is staged on disk
+	// and survives restarts. Once Offset reaches Length, the staged data is
+	// uploaded as a skyfile in the background and Skylink is set. If the
+	// upload of the skyfile fails, Error is set instead.
+	ResumableSkyfileUpload struct {
+		ID            ResumableUploadID `json:"id"`
+		TurtleDexPath TurtleDexPath     `json:"siapath"`
+		Filename      string            `json:"filename"`
+		Length        uint64            `json:"length"`
+		Offset        uint64            `json:"offset"`
+		Expires       time.Time         `json:"expires"`
+		Skylink       string            `json:"skylink"`
+		Error         string            `json:"error"`
+	}
+)
+
+// Complete returns true if the upload received all of its data.
+func (u ResumableSkyfileUpload) Complete() bool {
+	return u.Offset == u.Length
+}
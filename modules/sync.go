@@ -0,0 +1,60 @@
+package modules
+
+import (
+	"time"
+)
+
+const (
+	// SyncWatchInotify indicates that a sync folder is watched for filesystem
+	// events using inotify.
+	SyncWatchInotify SyncWatchMode = "inotify"
+
+	// SyncWatchPolling indicates that a sync folder is periodically scanned
+	// for changes because it can't be watched for filesystem events.
+	SyncWatchPolling SyncWatchMode = "polling"
+)
+
+type (
+	// SyncWatchMode describes how the renter detects changes within a sync
+	// folder.
+	SyncWatchMode string
+
+	// SyncFolderConfig configures a local directory which the renter keeps in
+	// sync with a TurtleDex directory. New and changed local files are
+	// uploaded. Deleting or renaming a local file only deletes or renames the
+	// corresponding TurtleDex file if PropagateDeletions or PropagateRenames
+	// is set respectively.
+	SyncFolderConfig struct {
+		LocalPath          string        `json:"localpath"`
+		TurtleDexPath      TurtleDexPath `json:"siapath"`
+		PropagateDeletions bool          `json:"propagatedeletions"`
+		PropagateRenames   bool          `json:"propagaterenames"`
+	}
+
+	// SyncConflict describes a local file which wasn't synced because the
+	// corresponding TurtleDex file was changed by someone other than the sync
+	// folder since it was last synced. The conflict is resolved once the
+	// TurtleDex file is deleted or the local file is removed.
+	SyncConflict struct {
+		LocalPath     string        `json:"localpath"`
+		TurtleDexPath TurtleDexPath `json:"siapath"`
+		Reason        string        `json:"reason"`
+		Time          time.Time     `json:"time"`
+	}
+
+	// SyncFolderStatus contains the status of a sync folder. The counters
+	// cover all syncs since the folder was added.
+	SyncFolderStatus struct {
+		SyncFolderConfig
+		WatchMode SyncWatchMode `json:"watchmode"`
+
+		LastSync    time.Time `json:"lastsync"`
+		SyncedFiles uint64    `json:"syncedfiles"`
+		Uploads     uint64    `json:"uploads"`
+		Deletions   uint64    `json:"deletions"`
+		Renames     uint64    `json:"renames"`
+
+		Conflicts []SyncConflict `json:"conflicts"`
+		LastError string         `json:"lasterror"`
+	}
+)
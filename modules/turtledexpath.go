@@ -57,11 +57,23 @@ var (
 	// default.
 	SkynetFolder = NewGlobalTurtleDexPath("/var/skynet")
 
+	// ReencodeFolder is the TurtleDex folder where siafiles are stored while
+	// they are re-encoded to match the redundancy policy of their directory.
+	ReencodeFolder = NewGlobalTurtleDexPath("/var/reencode")
+
+	// TempFolder is the TurtleDex folder where siafiles are kept temporarily,
+	// e.g. while they are replaced by another file.
+	TempFolder = NewGlobalTurtleDexPath("/var/tmp")
+
 	// UserFolder is the TurtleDex folder that is used to store the renter's siafiles.
 	UserFolder = NewGlobalTurtleDexPath("/home/user")
 
 	// VarFolder is the TurtleDex folder that contains the skynet folder.
 	VarFolder = NewGlobalTurtleDexPath("/var")
+
+	// VersionsFolder is the TurtleDex folder where the retained prior versions
+	// of siafiles are stored.
+	VersionsFolder = NewGlobalTurtleDexPath("/var/versions")
 )
 
 type (
@@ -127,6 +127,7 @@ type (
 
 		requiredUserAgent string
 		requiredPassword  string
+		staticTokens      *modules.APITokens
 		Shutdown          func() error
 		ttdxdConfig        *modules.TurtleDexdConfig
 
@@ -152,9 +153,12 @@ type (
 
 // api.ServeHTTP implements the http.Handler interface.
 func (api *API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Don't hold the lock while serving the request, streaming calls might
+	// never return.
 	api.routerMu.RLock()
-	api.router.ServeHTTP(w, r)
+	router := api.router
 	api.routerMu.RUnlock()
+	router.ServeHTTP(w, r)
 }
 
 // SetModules allows for replacing the modules in the API at runtime.
@@ -194,8 +198,10 @@ func (api *API) StartTime() time.Time {
 // New creates a new TurtleDex API from the provided modules. The API will require
 // authentication using HTTP basic auth for certain endpoints of the supplied
 // password is not the empty string.  Usernames are ignored for authentication.
-func New(cfg *modules.TurtleDexdConfig, requiredUserAgent string, requiredPassword string, cs modules.ConsensusSet, e modules.Explorer, fm modules.FeeManager, g modules.Gateway, h modules.Host, m modules.Miner, r modules.Renter, tp modules.TransactionPool, w modules.Wallet) *API {
-	return NewCustom(cfg, requiredUserAgent, requiredPassword, cs, e, fm, g, h, m, r, tp, w, modules.ProdDependencies)
+// The provided API tokens may be used instead of the password to access the
+// endpoints of their scopes.
+func New(cfg *modules.TurtleDexdConfig, tokens *modules.APITokens, requiredUserAgent string, requiredPassword string, cs modules.ConsensusSet, e modules.Explorer, fm modules.FeeManager, g modules.Gateway, h modules.Host, m modules.Miner, r modules.Renter, tp modules.TransactionPool, w modules.Wallet) *API {
+	return NewCustom(cfg, tokens, requiredUserAgent, requiredPassword, cs, e, fm, g, h, m, r, tp, w, modules.ProdDependencies)
 }
 
 // NewCustom creates a new TurtleDex API from the provided modules. The API will
@@ -203,7 +209,7 @@ func New(cfg *modules.TurtleDexdConfig, requiredUserAgent string, requiredPasswo
 // supplied password is not the empty string. Usernames are ignored for
 // authentication. It is custom because it allows to inject custom dependencies
 // into the API.
-func NewCustom(cfg *modules.TurtleDexdConfig, requiredUserAgent string, requiredPassword string, cs modules.ConsensusSet, e modules.Explorer, fm modules.FeeManager, g modules.Gateway, h modules.Host, m modules.Miner, r modules.Renter, tp modules.TransactionPool, w modules.Wallet, a modules.Dependencies) *API {
+func NewCustom(cfg *modules.TurtleDexdConfig, tokens *modules.APITokens, requiredUserAgent string, requiredPassword string, cs modules.ConsensusSet, e modules.Explorer, fm modules.FeeManager, g modules.Gateway, h modules.Host, m modules.Miner, r modules.Renter, tp modules.TransactionPool, w modules.Wallet, a modules.Dependencies) *API {
 	api := &API{
 		cs:                cs,
 		explorer:          e,
@@ -217,6 +223,7 @@ func NewCustom(cfg *modules.TurtleDexdConfig, requiredUserAgent string, required
 		downloads:         make(map[modules.DownloadID]func()),
 		requiredUserAgent: requiredUserAgent,
 		requiredPassword:  requiredPassword,
+		staticTokens:      tokens,
 		ttdxdConfig:        cfg,
 
 		staticDeps:      a,
@@ -3,7 +3,9 @@ package client
 import (
 	"net/url"
 	"strconv"
+	"strings"
 
+	"github.com/turtledex/TurtleDexCore/modules"
 	"github.com/turtledex/TurtleDexCore/node/api"
 )
 
@@ -24,6 +26,13 @@ func (c *Client) DaemonAlertsGet() (dag api.DaemonAlertsGet, err error) {
 	return
 }
 
+// DaemonMetricsGet requests the /daemon/metrics resource. The metrics are
+// returned in the Prometheus text format.
+func (c *Client) DaemonMetricsGet() (metrics string, err error) {
+	_, data, err := c.getRawResponse("/daemon/metrics")
+	return string(data), err
+}
+
 // DaemonVersionGet requests the /daemon/version resource.
 func (c *Client) DaemonVersionGet() (dvg api.DaemonVersionGet, err error) {
 	err = c.get("/daemon/version", &dvg)
@@ -63,6 +72,35 @@ func (c *Client) DaemonStopGet() (err error) {
 	return
 }
 
+// DaemonTokensGet uses the /daemon/tokens endpoint to list the API tokens.
+func (c *Client) DaemonTokensGet() (dtg api.DaemonTokensGet, err error) {
+	err = c.get("/daemon/tokens", &dtg)
+	return
+}
+
+// DaemonTokensCreatePost uses the /daemon/tokens/create endpoint to create a
+// new API token with the provided scopes.
+func (c *Client) DaemonTokensCreatePost(name string, scopes []modules.APIScope) (dtcp api.DaemonTokensCreatePOST, err error) {
+	strs := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		strs = append(strs, string(scope))
+	}
+	values := url.Values{}
+	values.Set("name", name)
+	values.Set("scopes", strings.Join(strs, ","))
+	err = c.post("/daemon/tokens/create", values.Encode(), &dtcp)
+	return
+}
+
+// DaemonTokensRevokePost uses the /daemon/tokens/revoke endpoint to revoke an
+// API token.
+func (c *Client) DaemonTokensRevokePost(id string) (err error) {
+	values := url.Values{}
+	values.Set("id", id)
+	err = c.post("/daemon/tokens/revoke", values.Encode(), nil)
+	return
+}
+
 // DaemonUpdateGet checks for an available daemon update.
 func (c *Client) DaemonUpdateGet() (dig api.DaemonUpdateGet, err error) {
 	err = c.get("/daemon/update", &dig)
@@ -2,6 +2,9 @@ package client
 
 import (
 	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
 
 	"github.com/turtledex/TurtleDexCore/modules"
 	"github.com/turtledex/TurtleDexCore/node/api"
@@ -53,3 +56,38 @@ func (c *Client) HostDbHostsGet(pk types.TurtleDexPublicKey) (hhg api.HostdbHost
 	err = c.get("/hostdb/hosts/"+pk.String(), &hhg)
 	return
 }
+
+// HostDbHostsHistoryGet requests the /hostdb/hosts/:pubkey/history endpoint's
+// resources.
+func (c *Client) HostDbHostsHistoryGet(pk types.TurtleDexPublicKey) (hhhg api.HostdbHostsHistoryGET, err error) {
+	err = c.get("/hostdb/hosts/"+pk.String()+"/history", &hhhg)
+	return
+}
+
+// HostDbHostsScoringPolicyGet requests the /hostdb/hosts/:pubkey endpoint's
+// resources together with a preview of the host's score under the provided
+// scoring policy.
+func (c *Client) HostDbHostsScoringPolicyGet(pk types.TurtleDexPublicKey, policy modules.HostScoringPolicy) (hhg api.HostdbHostsGET, err error) {
+	values := scoringPolicyValues(policy)
+	err = c.get("/hostdb/hosts/"+pk.String()+"?"+values.Encode(), &hhg)
+	return
+}
+
+// scoringPolicyValues encodes a scoring policy into the parameters expected by
+// the API.
+func scoringPolicyValues(policy modules.HostScoringPolicy) url.Values {
+	values := url.Values{}
+	for _, t := range modules.HostScoreAdjustmentTypes {
+		values.Set(string(t)+"weight", strconv.FormatFloat(policy.Weight(t), 'g', -1, 64))
+	}
+	values.Set("minage", fmt.Sprint(policy.MinAge))
+	values.Set("mincollateral", policy.MinCollateral.String())
+	values.Set("minremainingstorage", strconv.FormatUint(policy.MinRemainingStorage, 10))
+	values.Set("minuptime", strconv.FormatFloat(policy.MinUptime, 'g', -1, 64))
+	values.Set("minversion", policy.MinVersion)
+	values.Set("maxcontractprice", policy.MaxContractPrice.String())
+	values.Set("maxdownloadbandwidthprice", policy.MaxDownloadBandwidthPrice.String())
+	values.Set("maxstorageprice", policy.MaxStoragePrice.String())
+	values.Set("maxuploadbandwidthprice", policy.MaxUploadBandwidthPrice.String())
+	return values
+}
@@ -154,6 +154,20 @@ func escapeTurtleDexPath(siaPath modules.TurtleDexPath) string {
 	return strings.Join(escapedSegments, "/")
 }
 
+// RenterAuditGet uses the /renter/audit endpoint to get the results of the
+// renter's integrity audits.
+func (c *Client) RenterAuditGet() (rag api.RenterAuditGET, err error) {
+	err = c.get("/renter/audit", &rag)
+	return
+}
+
+// RenterAuditPost uses the /renter/audit endpoint to trigger an integrity
+// audit.
+func (c *Client) RenterAuditPost() (err error) {
+	err = c.post("/renter/audit", "", nil)
+	return
+}
+
 // RenterCleanPost uses the /renter/clean endpoint to clean any lost files from
 // the renter
 func (c *Client) RenterCleanPost() (err error) {
@@ -168,6 +182,14 @@ func (c *Client) RenterContractorChurnStatus() (churnStatus modules.ContractorCh
 	return
 }
 
+// RenterContractorRebalanceStatus uses the /renter/contractorrebalancestatus
+// endpoint to get the status of the migrations of data away from degraded or
+// overpriced hosts.
+func (c *Client) RenterContractorRebalanceStatus() (status modules.RebalanceStatus, err error) {
+	err = c.get("/renter/contractorrebalancestatus", &status)
+	return
+}
+
 // RenterContractCancelPost uses the /renter/contract/cancel endpoint to cancel
 // a contract
 func (c *Client) RenterContractCancelPost(id types.FileContractID) (err error) {
@@ -195,6 +217,16 @@ func (c *Client) RenterContractsGet() (rc api.RenterContracts, err error) {
 	return
 }
 
+// RenterContractsPlanGet requests the /renter/contracts/plan resource to plan
+// the next round of contract maintenance. The current allowance is used if the
+// empty allowance is provided.
+func (c *Client) RenterContractsPlanGet(allowance modules.Allowance) (plan modules.ContractMaintenancePlan, err error) {
+	query := fmt.Sprintf("?funds=%v&hosts=%v&period=%v&renewwindow=%v",
+		allowance.Funds, allowance.Hosts, allowance.Period, allowance.RenewWindow)
+	err = c.get("/renter/contracts/plan"+query, &plan)
+	return
+}
+
 // RenterContractStatus requests the /watchdog/contractstatus resource and returns
 // the status of a contract.
 func (c *Client) RenterContractStatus(fcID types.FileContractID) (status modules.ContractWatchStatus, err error) {
@@ -505,6 +537,52 @@ func (c *Client) RenterRateLimitPost(readBPS, writeBPS int64) (err error) {
 	return
 }
 
+// RenterSectorCachePost uses the /renter endpoint to update the settings of
+// the renter's sector cache.
+func (c *Client) RenterSectorCachePost(settings modules.SectorCacheSettings) (err error) {
+	values := url.Values{}
+	values.Set("sectorcachemaxsize", strconv.FormatUint(settings.MaxSize, 10))
+	if settings.EvictionPolicy != "" {
+		values.Set("sectorcachepolicy", string(settings.EvictionPolicy))
+	}
+	err = c.post("/renter", values.Encode(), nil)
+	return
+}
+
+// RenterPlacementPolicyPost uses the /renter endpoint to update the placement
+// policy which limits the number of hosts and pieces per failure domain.
+func (c *Client) RenterPlacementPolicyPost(policy modules.PlacementPolicy) (err error) {
+	values := url.Values{}
+	values.Set("maxpiecesperipv4subnet", strconv.FormatUint(policy.MaxPiecesPerIPv4Subnet, 10))
+	values.Set("maxpiecesperipv6subnet", strconv.FormatUint(policy.MaxPiecesPerIPv6Subnet, 10))
+	values.Set("maxpiecesperasn", strconv.FormatUint(policy.MaxPiecesPerASN, 10))
+	values.Set("maxpiecesperhostgroup", strconv.FormatUint(policy.MaxPiecesPerHostGroup, 10))
+	values.Set("asnfile", policy.ASNFile)
+	values.Set("hostgroupsfile", policy.HostGroupsFile)
+	err = c.post("/renter", values.Encode(), nil)
+	return
+}
+
+// RenterScoringPolicyPost uses the /renter endpoint to update the scoring
+// policy which tunes the weights of the host score adjustments and sets hard
+// limits for hosts.
+func (c *Client) RenterScoringPolicyPost(policy modules.HostScoringPolicy) (err error) {
+	values := scoringPolicyValues(policy)
+	err = c.post("/renter", values.Encode(), nil)
+	return
+}
+
+// RenterRebalancePolicyPost uses the /renter endpoint to update the policy for
+// migrating data away from degraded or overpriced hosts.
+func (c *Client) RenterRebalancePolicyPost(policy modules.RebalancePolicy) (err error) {
+	values := url.Values{}
+	values.Set("rebalancebudget", policy.Budget.String())
+	values.Set("rebalancemaxcostratio", fmt.Sprint(policy.MaxCostRatio))
+	values.Set("rebalanceminscorepercentile", fmt.Sprint(policy.MinScorePercentile))
+	err = c.post("/renter", values.Encode(), nil)
+	return
+}
+
 // RenterRenamePost uses the /renter/rename/:siapath endpoint to rename a file.
 func (c *Client) RenterRenamePost(siaPathOld, siaPathNew modules.TurtleDexPath, root bool) (err error) {
 	spo := escapeTurtleDexPath(siaPathOld)
@@ -594,6 +672,34 @@ func (c *Client) RenterUploadForcePost(path string, siaPath modules.TurtleDexPat
 	return
 }
 
+// RenterUploadDedupPost uses the /renter/upload endpoint to upload a file
+// with deduplication enabled.
+func (c *Client) RenterUploadDedupPost(path string, siaPath modules.TurtleDexPath, dataPieces, parityPieces uint64, force bool) (err error) {
+	sp := escapeTurtleDexPath(siaPath)
+	values := url.Values{}
+	values.Set("source", path)
+	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
+	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	values.Set("force", strconv.FormatBool(force))
+	values.Set("dedup", strconv.FormatBool(true))
+	err = c.post(fmt.Sprintf("/renter/upload/%s", sp), values.Encode(), nil)
+	return
+}
+
+// RenterUploadCompressedPost uses the /renter/upload endpoint to upload a
+// file which is compressed using the given compression type.
+func (c *Client) RenterUploadCompressedPost(path string, siaPath modules.TurtleDexPath, dataPieces, parityPieces uint64, force bool, compression modules.CompressionType) (err error) {
+	sp := escapeTurtleDexPath(siaPath)
+	values := url.Values{}
+	values.Set("source", path)
+	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
+	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	values.Set("force", strconv.FormatBool(force))
+	values.Set("compression", string(compression))
+	err = c.post(fmt.Sprintf("/renter/upload/%s", sp), values.Encode(), nil)
+	return
+}
+
 // RenterUploadDefaultPost uses the /renter/upload endpoint with default
 // redundancy settings to upload a file.
 func (c *Client) RenterUploadDefaultPost(path string, siaPath modules.TurtleDexPath) (err error) {
@@ -616,6 +722,34 @@ func (c *Client) RenterUploadStreamPost(r io.Reader, siaPath modules.TurtleDexPa
 	return err
 }
 
+// RenterUploadStreamDedupPost uploads data using a stream with deduplication
+// enabled.
+func (c *Client) RenterUploadStreamDedupPost(r io.Reader, siaPath modules.TurtleDexPath, dataPieces, parityPieces uint64, force bool) error {
+	sp := escapeTurtleDexPath(siaPath)
+	values := url.Values{}
+	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
+	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	values.Set("force", strconv.FormatBool(force))
+	values.Set("dedup", strconv.FormatBool(true))
+	values.Set("stream", strconv.FormatBool(true))
+	_, _, err := c.postRawResponse(fmt.Sprintf("/renter/uploadstream/%s?%s", sp, values.Encode()), r)
+	return err
+}
+
+// RenterUploadStreamCompressedPost uploads data using a stream and compresses
+// it using the given compression type.
+func (c *Client) RenterUploadStreamCompressedPost(r io.Reader, siaPath modules.TurtleDexPath, dataPieces, parityPieces uint64, force bool, compression modules.CompressionType) error {
+	sp := escapeTurtleDexPath(siaPath)
+	values := url.Values{}
+	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
+	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	values.Set("force", strconv.FormatBool(force))
+	values.Set("compression", string(compression))
+	values.Set("stream", strconv.FormatBool(true))
+	_, _, err := c.postRawResponse(fmt.Sprintf("/renter/uploadstream/%s?%s", sp, values.Encode()), r)
+	return err
+}
+
 // RenterUploadStreamRepairPost a siafile using a stream. If the data provided
 // by r is not the same as the previously uploaded data, the data will be
 // corrupted.
@@ -669,6 +803,62 @@ func (c *Client) RenterDirRenamePost(siaPath, newTurtleDexPath modules.TurtleDex
 	return
 }
 
+// RenterDirSetVersioningPost uses the /renter/dir/ endpoint to set the
+// versioning policy of a directory.
+func (c *Client) RenterDirSetVersioningPost(siaPath modules.TurtleDexPath, policy modules.VersioningPolicy) (err error) {
+	sp := escapeTurtleDexPath(siaPath)
+	values := url.Values{}
+	values.Set("action", "setversioning")
+	values.Set("maxversions", fmt.Sprint(policy.MaxVersions))
+	values.Set("retentionwindow", fmt.Sprint(uint64(policy.RetentionWindow.Seconds())))
+	err = c.post(fmt.Sprintf("/renter/dir/%s", sp), values.Encode(), nil)
+	return
+}
+
+// RenterDirSetRedundancyPolicyPost uses the /renter/dir/ endpoint to set the
+// redundancy policy of a directory. A disabled policy removes the policy.
+func (c *Client) RenterDirSetRedundancyPolicyPost(siaPath modules.TurtleDexPath, policy modules.RedundancyPolicy) (err error) {
+	sp := escapeTurtleDexPath(siaPath)
+	values := url.Values{}
+	values.Set("action", "setredundancy")
+	if policy.Enabled() {
+		values.Set("datapieces", fmt.Sprint(policy.DataPieces))
+		values.Set("paritypieces", fmt.Sprint(policy.ParityPieces))
+	}
+	err = c.post(fmt.Sprintf("/renter/dir/%s", sp), values.Encode(), nil)
+	return
+}
+
+// RenterVersionsGet uses the /renter/versions/ endpoint to query the
+// retained versions of a file.
+func (c *Client) RenterVersionsGet(siaPath modules.TurtleDexPath) (rfv api.RenterFileVersions, err error) {
+	sp := escapeTurtleDexPath(siaPath)
+	err = c.get(fmt.Sprintf("/renter/versions/%s", sp), &rfv)
+	return
+}
+
+// RenterVersionRestorePost uses the /renter/versions/ endpoint to restore a
+// version of a file.
+func (c *Client) RenterVersionRestorePost(siaPath modules.TurtleDexPath, id string) (err error) {
+	sp := escapeTurtleDexPath(siaPath)
+	values := url.Values{}
+	values.Set("action", "restore")
+	values.Set("id", id)
+	err = c.post(fmt.Sprintf("/renter/versions/%s", sp), values.Encode(), nil)
+	return
+}
+
+// RenterVersionsPurgePost uses the /renter/versions/ endpoint to delete a
+// version of a file. All versions are deleted if no id is provided.
+func (c *Client) RenterVersionsPurgePost(siaPath modules.TurtleDexPath, id string) (err error) {
+	sp := escapeTurtleDexPath(siaPath)
+	values := url.Values{}
+	values.Set("action", "purge")
+	values.Set("id", id)
+	err = c.post(fmt.Sprintf("/renter/versions/%s", sp), values.Encode(), nil)
+	return
+}
+
 // RenterDirRootGet uses the /renter/dir/ endpoint to query a directory,
 // starting from the root path.
 func (c *Client) RenterDirRootGet(siaPath modules.TurtleDexPath) (rd api.RenterDirectory, err error) {
@@ -741,6 +931,34 @@ func (c *Client) RenterFuseUnmount(mount string) (err error) {
 	return
 }
 
+// RenterSyncGet uses the /renter/sync endpoint to get the status of the
+// renter's sync folders.
+func (c *Client) RenterSyncGet() (rsg api.RenterSyncGET, err error) {
+	err = c.get("/renter/sync", &rsg)
+	return
+}
+
+// RenterSyncAddPost uses the /renter/sync/add endpoint to start syncing a
+// local directory with a TurtleDex directory.
+func (c *Client) RenterSyncAddPost(config modules.SyncFolderConfig) (err error) {
+	values := url.Values{}
+	values.Set("localpath", config.LocalPath)
+	values.Set("siapath", escapeTurtleDexPath(config.TurtleDexPath))
+	values.Set("propagatedeletions", strconv.FormatBool(config.PropagateDeletions))
+	values.Set("propagaterenames", strconv.FormatBool(config.PropagateRenames))
+	err = c.post("/renter/sync/add", values.Encode(), nil)
+	return
+}
+
+// RenterSyncRemovePost uses the /renter/sync/remove endpoint to stop syncing
+// a local directory.
+func (c *Client) RenterSyncRemovePost(localPath string) (err error) {
+	values := url.Values{}
+	values.Set("localpath", localPath)
+	err = c.post("/renter/sync/remove", values.Encode(), nil)
+	return
+}
+
 // RenterUploadsPausePost uses the /renter/uploads/pause endpoint to pause the
 // renter's uploads and repairs
 func (c *Client) RenterUploadsPausePost(duration time.Duration) (err error) {
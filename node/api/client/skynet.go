@@ -1,6 +1,8 @@
 package client
 
 import (
+	"bufio"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -68,6 +70,10 @@ func (uc *UnsafeClient) SkynetSkyfilePostRawResponse(params modules.SkyfileUploa
 	values.Set("basechunkredundancy", redundancyStr)
 	rootStr := fmt.Sprintf("%t", params.Root)
 	values.Set("root", rootStr)
+	if params.Compression != modules.CompressionNone {
+		values.Set("compression", string(params.Compression))
+	}
+	setSkyfileServingValues(values, params.Headers, nil, "")
 
 	// Encode SkykeyName or SkykeyID.
 	if params.SkykeyName != "" {
@@ -77,6 +83,7 @@ func (uc *UnsafeClient) SkynetSkyfilePostRawResponse(params modules.SkyfileUploa
 	if hasSkykeyID {
 		values.Set("skykeyid", params.SkykeyID.ToString())
 	}
+	setSkyfileRecipientValues(values, params.SkykeyRecipients)
 
 	// Make the call to upload the file.
 	query := fmt.Sprintf("/skynet/skyfile/%s?%s", params.TurtleDexPath.String(), values.Encode())
@@ -427,6 +434,15 @@ func (c *Client) SkynetSkylinkFormatGet(skylink string, format modules.SkyfileFo
 	return header, reader, errors.AddContext(err, "unable to fetch skylink data")
 }
 
+// SkynetSkylinkListingGet uses the /skynet/skylink endpoint to fetch the JSON
+// listing of the directory at the given skylink and path.
+func (c *Client) SkynetSkylinkListingGet(skylink string) (listing api.SkynetDirectoryListing, err error) {
+	values := url.Values{}
+	values.Set("format", string(modules.SkyfileFormatJSON))
+	err = c.get(skylinkQueryWithValues(skylink, values), &listing)
+	return listing, errors.AddContext(err, "unable to fetch directory listing")
+}
+
 // SkynetSkylinkTarReaderGet uses the /skynet/skylink endpoint to fetch a
 // reader of the file data with the 'tar' format specified.
 func (c *Client) SkynetSkylinkTarReaderGet(skylink string) (http.Header, io.ReadCloser, error) {
@@ -489,6 +505,10 @@ func (c *Client) SkynetSkyfilePost(params modules.SkyfileUploadParameters) (stri
 	values.Set("basechunkredundancy", redundancyStr)
 	rootStr := fmt.Sprintf("%t", params.Root)
 	values.Set("root", rootStr)
+	if params.Compression != modules.CompressionNone {
+		values.Set("compression", string(params.Compression))
+	}
+	setSkyfileServingValues(values, params.Headers, nil, "")
 
 	// Encode SkykeyName or SkykeyID.
 	if params.SkykeyName != "" {
@@ -498,6 +518,7 @@ func (c *Client) SkynetSkyfilePost(params modules.SkyfileUploadParameters) (stri
 	if hasSkykeyID {
 		values.Set("skykeyid", params.SkykeyID.ToString())
 	}
+	setSkyfileRecipientValues(values, params.SkykeyRecipients)
 
 	// Make the call to upload the file.
 	query := fmt.Sprintf("/skynet/skyfile/%s?%s", params.TurtleDexPath.String(), values.Encode())
@@ -553,6 +574,35 @@ func (c *Client) SkynetSkyfilePostDisableForce(params modules.SkyfileUploadParam
 	return rshp.Skylink, rshp, err
 }
 
+// setSkyfileServingValues sets the query values for a skyfile's custom headers,
+// try files and not found path.
+func setSkyfileServingValues(values url.Values, headers map[string]string, tryFiles []string, notFoundPath string) {
+	if len(headers) > 0 {
+		b, _ := json.Marshal(headers)
+		values.Set("headers", string(b))
+	}
+	if len(tryFiles) > 0 {
+		b, _ := json.Marshal(tryFiles)
+		values.Set("tryfiles", string(b))
+	}
+	if notFoundPath != "" {
+		values.Set("notfoundpath", notFoundPath)
+	}
+}
+
+// setSkyfileRecipientValues sets the url values for the public keys of the
+// recipient skykeys a skyfile is encrypted for.
+func setSkyfileRecipientValues(values url.Values, recipients []skykey.RecipientPublicKey) {
+	if len(recipients) == 0 {
+		return
+	}
+	pks := make([]string, 0, len(recipients))
+	for _, pk := range recipients {
+		pks = append(pks, pk.ToString())
+	}
+	values.Set("recipients", strings.Join(pks, ","))
+}
+
 // SkynetSkyfileMultiPartPost uses the /skynet/skyfile endpoint to upload a
 // skyfile using multipart form data.  The resulting skylink is returned along
 // with an error.
@@ -564,6 +614,19 @@ func (c *Client) SkynetSkyfileMultiPartPost(params modules.SkyfileMultipartUploa
 // upload a skyfile using multipart form data with Skykey params.  The resulting
 // skylink is returned along with an error.
 func (c *Client) SkynetSkyfileMultiPartEncryptedPost(params modules.SkyfileMultipartUploadParameters, skykeyName string, skykeyID skykey.SkykeyID) (string, api.SkynetSkyfileHandlerPOST, error) {
+	return c.skynetSkyfileMultiPartPost(params, skykeyName, skykeyID, nil)
+}
+
+// SkynetSkyfileMultiPartRecipientsPost uses the /skynet/skyfile endpoint to
+// upload a skyfile using multipart form data which is encrypted for the given
+// recipients. The resulting skylink is returned along with an error.
+func (c *Client) SkynetSkyfileMultiPartRecipientsPost(params modules.SkyfileMultipartUploadParameters, recipients []skykey.RecipientPublicKey) (string, api.SkynetSkyfileHandlerPOST, error) {
+	return c.skynetSkyfileMultiPartPost(params, "", skykey.SkykeyID{}, recipients)
+}
+
+// skynetSkyfileMultiPartPost uploads a skyfile using multipart form data with
+// the given encryption params.
+func (c *Client) skynetSkyfileMultiPartPost(params modules.SkyfileMultipartUploadParameters, skykeyName string, skykeyID skykey.SkykeyID, recipients []skykey.RecipientPublicKey) (string, api.SkynetSkyfileHandlerPOST, error) {
 	// Set the url values.
 	values := url.Values{}
 	values.Set("filename", params.Filename)
@@ -575,10 +638,15 @@ func (c *Client) SkynetSkyfileMultiPartEncryptedPost(params modules.SkyfileMulti
 	values.Set("basechunkredundancy", redundancyStr)
 	rootStr := fmt.Sprintf("%t", params.Root)
 	values.Set("root", rootStr)
+	if params.Compression != modules.CompressionNone {
+		values.Set("compression", string(params.Compression))
+	}
+	setSkyfileServingValues(values, params.Headers, params.TryFiles, params.NotFoundPath)
 	values.Set("skykeyname", skykeyName)
 	if skykeyID != (skykey.SkykeyID{}) {
 		values.Set("skykeyid", skykeyID.ToString())
 	}
+	setSkyfileRecipientValues(values, recipients)
 
 	// Make the call to upload the file.
 	query := fmt.Sprintf("/skynet/skyfile/%s?%s", params.TurtleDexPath.String(), values.Encode())
@@ -682,6 +750,36 @@ func (c *Client) SkynetPortalsPost(additions []modules.SkynetPortal, removals []
 	return
 }
 
+// SkynetPinsGet requests the /skynet/pins Get endpoint.
+func (c *Client) SkynetPinsGet() (spg api.SkynetPinsGET, err error) {
+	err = c.get("/skynet/pins", &spg)
+	return
+}
+
+// SkynetPinsAddPost requests the /skynet/pins/add Post endpoint. The siapath
+// of the pin is relative to the root folder. If it is empty, the renter picks
+// one.
+func (c *Client) SkynetPinsAddPost(pin modules.SkynetPin) (err error) {
+	values := url.Values{}
+	values.Set("skylink", pin.Skylink)
+	values.Set("label", pin.Label)
+	values.Set("basechunkredundancy", fmt.Sprint(pin.BaseChunkRedundancy))
+	if !pin.TurtleDexPath.IsEmpty() {
+		values.Set("siapath", pin.TurtleDexPath.String())
+		values.Set("root", "true")
+	}
+	err = c.post("/skynet/pins/add", values.Encode(), nil)
+	return
+}
+
+// SkynetPinsRemovePost requests the /skynet/pins/remove Post endpoint.
+func (c *Client) SkynetPinsRemovePost(skylink string) (err error) {
+	values := url.Values{}
+	values.Set("skylink", skylink)
+	err = c.post("/skynet/pins/remove", values.Encode(), nil)
+	return
+}
+
 // SkynetStatsGet requests the /skynet/stats Get endpoint
 func (c *Client) SkynetStatsGet() (stats api.SkynetStatsGET, err error) {
 	err = c.get("/skynet/stats", &stats)
@@ -842,6 +940,25 @@ func (c *Client) RegistryReadWithTimeout(spk types.TurtleDexPublicKey, dataKey c
 	return modules.NewSignedRegistryValue(dataKey, data, rhg.Revision, sig), nil
 }
 
+// RegistrySkylinkV2 queries the /skynet/registry/skylinkv2 [GET] endpoint.
+func (c *Client) RegistrySkylinkV2(spk types.TurtleDexPublicKey, dataKey crypto.Hash) (modules.Skylink, error) {
+	values := url.Values{}
+	values.Set("publickey", spk.String())
+	values.Set("datakey", dataKey.String())
+
+	var rsg api.RegistrySkylinkV2GET
+	err := c.get(fmt.Sprintf("/skynet/registry/skylinkv2?%v", values.Encode()), &rsg)
+	if err != nil {
+		return modules.Skylink{}, err
+	}
+	var skylink modules.Skylink
+	err = skylink.LoadString(rsg.Skylink)
+	if err != nil {
+		return modules.Skylink{}, errors.AddContext(err, "failed to decode skylink")
+	}
+	return skylink, nil
+}
+
 // RegistryUpdate queries the /skynet/registry [POST] endpoint.
 func (c *Client) RegistryUpdate(spk types.TurtleDexPublicKey, dataKey crypto.Hash, revision uint64, sig crypto.Signature, skylink modules.Skylink) error {
 	req := api.RegistryHandlerRequestPOST{
@@ -858,6 +975,193 @@ func (c *Client) RegistryUpdate(spk types.TurtleDexPublicKey, dataKey crypto.Has
 	return c.post("/skynet/registry", string(reqBytes), nil)
 }
 
+// RegistrySubscription is a subscription to registry entries created by
+// RegistrySubscribe.
+type RegistrySubscription struct {
+	staticBody    io.ReadCloser
+	staticScanner *bufio.Scanner
+}
+
+// RegistrySubscribe queries the /skynet/registry/subscription [GET] endpoint to
+// subscribe to the entries specified by the n-th public key and n-th data key.
+func (c *Client) RegistrySubscribe(spks []types.TurtleDexPublicKey, dataKeys []crypto.Hash) (*RegistrySubscription, error) {
+	if len(spks) != len(dataKeys) {
+		return nil, errors.New("number of public keys doesn't match number of data keys")
+	}
+	values := url.Values{}
+	for i := range spks {
+		values.Add("publickey", spks[i].String())
+		values.Add("datakey", dataKeys[i].String())
+	}
+	_, body, err := c.getReaderResponse(fmt.Sprintf("/skynet/registry/subscription?%v", values.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	return &RegistrySubscription{
+		staticBody:    body,
+		staticScanner: bufio.NewScanner(body),
+	}, nil
+}
+
+// Close closes the subscription.
+func (rs *RegistrySubscription) Close() error {
+	return rs.staticBody.Close()
+}
+
+// Next blocks until the next update is received and returns it.
+func (rs *RegistrySubscription) Next() (api.RegistrySubscriptionNotification, error) {
+	var event string
+	var data []string
+	for rs.staticScanner.Scan() {
+		line := rs.staticScanner.Text()
+		switch {
+		case line == "":
+			// An empty line terminates an event.
+			if event == "update" {
+				var rsn api.RegistrySubscriptionNotification
+				err := json.Unmarshal([]byte(strings.Join(data, "\n")), &rsn)
+				return rsn, errors.AddContext(err, "failed to decode update")
+			}
+			event, data = "", nil
+		case strings.HasPrefix(line, ":"):
+			// Comments are used for keepalives.
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+		}
+	}
+	if err := rs.staticScanner.Err(); err != nil {
+		return api.RegistrySubscriptionNotification{}, err
+	}
+	return api.RegistrySubscriptionNotification{}, io.EOF
+}
+
+// SkynetTusOptions uses the /skynet/tus endpoint to fetch the tus versions and
+// extensions supported by the node.
+func (c *Client) SkynetTusOptions() (http.Header, error) {
+	return c.tusRequest("OPTIONS", "/skynet/tus", nil, nil)
+}
+
+// SkynetTusPost uses the /skynet/tus endpoint to create a resumable skyfile
+// upload of length bytes. The metadata keys match the query parameters of a
+// regular skyfile upload.
+func (c *Client) SkynetTusPost(length uint64, metadata map[string]string) (modules.ResumableUploadID, error) {
+	// Encode the metadata in a deterministic order.
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key+" "+base64.StdEncoding.EncodeToString([]byte(metadata[key])))
+	}
+	headers := http.Header{}
+	headers.Set("Upload-Length", strconv.FormatUint(length, 10))
+	if len(pairs) > 0 {
+		headers.Set("Upload-Metadata", strings.Join(pairs, ","))
+	}
+	h, err := c.tusRequest("POST", "/skynet/tus", nil, headers)
+	if err != nil {
+		return "", err
+	}
+	location := h.Get("Location")
+	if !strings.HasPrefix(location, "/skynet/tus/") {
+		return "", fmt.Errorf("invalid location '%v'", location)
+	}
+	return modules.ResumableUploadID(strings.TrimPrefix(location, "/skynet/tus/")), nil
+}
+
+// SkynetTusHead uses the /skynet/tus/:id endpoint to fetch the state of a
+// resumable skyfile upload.
+func (c *Client) SkynetTusHead(id modules.ResumableUploadID) (modules.ResumableSkyfileUpload, error) {
+	h, err := c.tusRequest("HEAD", "/skynet/tus/"+string(id), nil, nil)
+	if err != nil {
+		return modules.ResumableSkyfileUpload{}, err
+	}
+	u, err := tusUpload(id, h)
+	if err != nil {
+		return modules.ResumableSkyfileUpload{}, err
+	}
+	u.Length, err = strconv.ParseUint(h.Get("Upload-Length"), 10, 64)
+	if err != nil {
+		return modules.ResumableSkyfileUpload{}, errors.AddContext(err, "unable to parse 'Upload-Length' header")
+	}
+	return u, nil
+}
+
+// SkynetTusPatch uses the /skynet/tus/:id endpoint to append data to a
+// resumable skyfile upload at the given offset. The returned upload only
+// contains the offset, expiry, skylink and error of the upload. Once all data
+// was received, the skyfile is uploaded in the background and SkynetTusHead
+// returns its skylink.
+func (c *Client) SkynetTusPatch(id modules.ResumableUploadID, offset uint64, data io.Reader) (modules.ResumableSkyfileUpload, error) {
+	headers := http.Header{}
+	headers.Set("Content-Type", "application/offset+octet-stream")
+	headers.Set("Upload-Offset", strconv.FormatUint(offset, 10))
+	h, err := c.tusRequest("PATCH", "/skynet/tus/"+string(id), data, headers)
+	if err != nil {
+		return modules.ResumableSkyfileUpload{}, err
+	}
+	return tusUpload(id, h)
+}
+
+// SkynetTusDelete uses the /skynet/tus/:id endpoint to abort a resumable
+// skyfile upload.
+func (c *Client) SkynetTusDelete(id modules.ResumableUploadID) error {
+	_, err := c.tusRequest("DELETE", "/skynet/tus/"+string(id), nil, nil)
+	return err
+}
+
+// tusRequest performs a tus request and returns the response headers.
+func (c *Client) tusRequest(method, resource string, body io.Reader, headers http.Header) (http.Header, error) {
+	req, err := c.NewRequest(method, resource, body)
+	if err != nil {
+		return nil, errors.AddContext(err, fmt.Sprintf("failed to construct %v request", method))
+	}
+	req.Header.Set("Tus-Resumable", "1.0.0")
+	for k, v := range headers {
+		for _, vv := range v {
+			req.Header.Add(k, vv)
+		}
+	}
+	httpClient := http.Client{CheckRedirect: c.CheckRedirect}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, errors.AddContext(err, fmt.Sprintf("%v request failed", method))
+	}
+	defer drainAndClose(res.Body)
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		if method == "HEAD" {
+			return nil, fmt.Errorf("HEAD request error: %v", res.Status)
+		}
+		return nil, errors.AddContext(readAPIError(res.Body), fmt.Sprintf("%v request error", method))
+	}
+	return res.Header, nil
+}
+
+// tusUpload returns the upload described by the headers of a tus response.
+func tusUpload(id modules.ResumableUploadID, h http.Header) (modules.ResumableSkyfileUpload, error) {
+	u := modules.ResumableSkyfileUpload{
+		ID:      id,
+		Skylink: h.Get("Skynet-Skylink"),
+		Error:   h.Get("Skynet-Upload-Error"),
+	}
+	var err error
+	u.Offset, err = strconv.ParseUint(h.Get("Upload-Offset"), 10, 64)
+	if err != nil {
+		return modules.ResumableSkyfileUpload{}, errors.AddContext(err, "unable to parse 'Upload-Offset' header")
+	}
+	if expires := h.Get("Upload-Expires"); expires != "" {
+		u.Expires, err = http.ParseTime(expires)
+		if err != nil {
+			return modules.ResumableSkyfileUpload{}, errors.AddContext(err, "unable to parse 'Upload-Expires' header")
+		}
+	}
+	return u, nil
+}
+
 // skylinkQueryWithValues returns a skylink query based on the given skylink and
 // values. If the values are empty it will not append a `?` to the query.
 func skylinkQueryWithValues(skylink string, values url.Values) string {
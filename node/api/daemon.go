@@ -145,6 +145,19 @@ type (
 		Modules          configModules `json:"modules"`
 	}
 
+	// DaemonTokensGet contains the API tokens of the daemon.
+	DaemonTokensGet struct {
+		Tokens []modules.APIToken `json:"tokens"`
+	}
+
+	// DaemonTokensCreatePOST contains a newly created API token. The token
+	// field contains the secret which needs to be provided to the API and
+	// which can't be retrieved again.
+	DaemonTokensCreatePOST struct {
+		modules.APIToken
+		Token string `json:"token"`
+	}
+
 	// DaemonVersion holds the version information for ttdxd
 	DaemonVersion struct {
 		Version     string `json:"version"`
@@ -506,6 +519,70 @@ func (api *API) daemonVersionHandler(w http.ResponseWriter, _ *http.Request, _ h
 	WriteJSON(w, DaemonVersion{Version: version, GitRevision: build.GitRevision, BuildTime: build.BuildTime})
 }
 
+// daemonTokensHandlerGET handles the API call that lists the API tokens.
+func (api *API) daemonTokensHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	if api.staticTokens == nil {
+		WriteError(w, Error{"API tokens are not available"}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, DaemonTokensGet{
+		Tokens: api.staticTokens.Tokens(),
+	})
+}
+
+// daemonTokensCreateHandlerPOST handles the API call that creates a new API
+// token.
+func (api *API) daemonTokensCreateHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	if api.staticTokens == nil {
+		WriteError(w, Error{"API tokens are not available"}, http.StatusBadRequest)
+		return
+	}
+	// Without an API password every endpoint is accessible without
+	// authentication, so a token wouldn't restrict anything.
+	if api.requiredPassword == "" {
+		WriteError(w, Error{"API tokens require an API password"}, http.StatusBadRequest)
+		return
+	}
+	scopes, err := modules.ParseAPIScopes(req.FormValue("scopes"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse scopes: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	token, secret, err := api.staticTokens.Create(req.FormValue("name"), scopes)
+	if err != nil {
+		WriteError(w, Error{"unable to create token: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, DaemonTokensCreatePOST{
+		APIToken: token,
+		Token:    secret,
+	})
+}
+
+// daemonTokensRevokeHandlerPOST handles the API call that revokes an API
+// token.
+func (api *API) daemonTokensRevokeHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	if api.staticTokens == nil {
+		WriteError(w, Error{"API tokens are not available"}, http.StatusBadRequest)
+		return
+	}
+	id := req.FormValue("id")
+	if id == "" {
+		WriteError(w, Error{"you must specify the id of the token"}, http.StatusBadRequest)
+		return
+	}
+	err := api.staticTokens.Revoke(id)
+	if errors.Contains(err, modules.ErrUnknownAPIToken) {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{"unable to revoke token: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // daemonStopHandler handles the API call to stop the daemon cleanly.
 func (api *API) daemonStopHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	// can't write after we stop the server, so lie a bit.
@@ -35,6 +35,17 @@ type (
 	HostdbHostsGET struct {
 		Entry          ExtendedHostDBEntry        `json:"entry"`
 		ScoreBreakdown modules.HostScoreBreakdown `json:"scorebreakdown"`
+
+		// ScoringPolicyPreview is the score breakdown of the host under the
+		// scoring policy provided with the request. It is only set if the
+		// request contained scoring policy parameters.
+		ScoringPolicyPreview *modules.HostScoreBreakdown `json:"scoringpolicypreview,omitempty"`
+	}
+
+	// HostdbHostsHistoryGET contains the time series of scans, prices and job
+	// outcomes the hostdb keeps about a particular host, selected by pubkey.
+	HostdbHostsHistoryGET struct {
+		modules.HostHistory
 	}
 
 	// HostdbGet holds information about the hostdb.
@@ -134,7 +145,7 @@ func (api *API) hostdbAllHandler(w http.ResponseWriter, _ *http.Request, _ httpr
 
 // hostdbHostsHandler handles the API call asking for a specific host,
 // returning detailed information about that host.
-func (api *API) hostdbHostsHandler(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+func (api *API) hostdbHostsHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	var pk types.TurtleDexPublicKey
 	pk.LoadString(ps.ByName("pubkey"))
 
@@ -153,17 +164,144 @@ func (api *API) hostdbHostsHandler(w http.ResponseWriter, _ *http.Request, ps ht
 		return
 	}
 
+	// If the request contains scoring policy parameters, preview the score
+	// of the host under the current policy updated with those parameters.
+	settings, err := api.renter.Settings()
+	if err != nil {
+		WriteError(w, Error{"unable to get renter settings: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	policy := settings.ScoringPolicy
+	var preview *modules.HostScoreBreakdown
+	set, err := parseScoringPolicy(req, &policy)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if set {
+		previewBreakdown, err := api.renter.ScoreBreakdownWithPolicy(entry, policy)
+		if err != nil {
+			WriteError(w, Error{"error calculating score breakdown with scoring policy: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		preview = &previewBreakdown
+	}
+
 	// Extend the hostdb entry  to have the public key string.
 	extendedEntry := ExtendedHostDBEntry{
 		HostDBEntry:     entry,
 		PublicKeyString: entry.PublicKey.String(),
 	}
 	WriteJSON(w, HostdbHostsGET{
-		Entry:          extendedEntry,
-		ScoreBreakdown: breakdown,
+		Entry:                extendedEntry,
+		ScoreBreakdown:       breakdown,
+		ScoringPolicyPreview: preview,
 	})
 }
 
+// hostdbHostsHistoryHandler handles the API call asking for the time series
+// the hostdb keeps about a specific host.
+func (api *API) hostdbHostsHistoryHandler(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	var pk types.TurtleDexPublicKey
+	if err := pk.LoadString(ps.ByName("pubkey")); err != nil {
+		WriteError(w, Error{"unable to parse pubkey: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	history, exists, err := api.renter.HostHistory(pk)
+	if err != nil {
+		WriteError(w, Error{"unable to get host history: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if !exists {
+		_, exists, err = api.renter.Host(pk)
+		if err != nil {
+			WriteError(w, Error{"unable to get host: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if !exists {
+			WriteError(w, Error{"requested host does not exist"}, http.StatusBadRequest)
+			return
+		}
+	}
+	WriteJSON(w, HostdbHostsHistoryGET{history})
+}
+
+// parseScoringPolicy applies the scoring policy parameters of the request to
+// the provided policy. It returns whether the request contained any scoring
+// policy parameters.
+func parseScoringPolicy(req *http.Request, policy *modules.HostScoringPolicy) (bool, error) {
+	if err := req.ParseForm(); err != nil {
+		return false, fmt.Errorf("unable to parse form: %v", err)
+	}
+	var set bool
+
+	// Scan the weights of the adjustments. (optional parameters)
+	for _, t := range modules.HostScoreAdjustmentTypes {
+		param := string(t) + "weight"
+		str := req.FormValue(param)
+		if str == "" {
+			continue
+		}
+		var weight float64
+		if _, err := fmt.Sscan(str, &weight); err != nil {
+			return false, fmt.Errorf("unable to parse %v: %v", param, err)
+		}
+		if policy.Weights == nil {
+			policy.Weights = make(map[modules.HostScoreAdjustmentType]float64)
+		}
+		policy.Weights[t] = weight
+		set = true
+	}
+
+	// Scan the numeric limits. (optional parameters)
+	limits := []struct {
+		param string
+		limit interface{}
+	}{
+		{"minage", &policy.MinAge},
+		{"minremainingstorage", &policy.MinRemainingStorage},
+		{"minuptime", &policy.MinUptime},
+	}
+	for _, l := range limits {
+		if str := req.FormValue(l.param); str != "" {
+			if _, err := fmt.Sscan(str, l.limit); err != nil {
+				return false, fmt.Errorf("unable to parse %v: %v", l.param, err)
+			}
+			set = true
+		}
+	}
+
+	// Scan the price limits. (optional parameters)
+	prices := []struct {
+		param string
+		price *types.Currency
+	}{
+		{"mincollateral", &policy.MinCollateral},
+		{"maxcontractprice", &policy.MaxContractPrice},
+		{"maxdownloadbandwidthprice", &policy.MaxDownloadBandwidthPrice},
+		{"maxstorageprice", &policy.MaxStoragePrice},
+		{"maxuploadbandwidthprice", &policy.MaxUploadBandwidthPrice},
+	}
+	for _, p := range prices {
+		if str := req.FormValue(p.param); str != "" {
+			price, ok := scanAmount(str)
+			if !ok {
+				return false, fmt.Errorf("unable to parse %v", p.param)
+			}
+			*p.price = price
+			set = true
+		}
+	}
+
+	// Scan the minimum version. An empty value removes the limit. (optional
+	// parameter)
+	if _, exists := req.Form["minversion"]; exists {
+		policy.MinVersion = req.FormValue("minversion")
+		set = true
+	}
+	return set, nil
+}
+
 // hostdbFilterModeHandlerGET handles the API call to get the hostdb's filter
 // mode
 func (api *API) hostdbFilterModeHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
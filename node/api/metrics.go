@@ -0,0 +1,267 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/errors"
+)
+
+const (
+	// metricsContentType is the content type of the Prometheus text exposition
+	// format.
+	metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+	// metricsNamespace is the prefix of all exported metric names.
+	metricsNamespace = "turtledex_"
+)
+
+var (
+	// metricsHelpEscaper escapes the help text of a metric.
+	metricsHelpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+	// metricsLabelEscaper escapes the value of a label.
+	metricsLabelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+type (
+	// metricsWriter writes metrics in the Prometheus text exposition format.
+	metricsWriter struct {
+		buf bytes.Buffer
+	}
+
+	// metricSample is a single sample of a metric. The labels are a list of
+	// alternating label names and label values.
+	metricSample struct {
+		labels []string
+		value  float64
+	}
+)
+
+// newMetricSample creates a sample from a value and a list of alternating label
+// names and label values.
+func newMetricSample(value float64, labels ...string) metricSample {
+	return metricSample{
+		labels: labels,
+		value:  value,
+	}
+}
+
+// boolToFloat converts a bool to a metric value.
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Bytes returns the metrics that have been written so far.
+func (mw *metricsWriter) Bytes() []byte {
+	return mw.buf.Bytes()
+}
+
+// WriteGauge writes a gauge with a single unlabeled sample.
+func (mw *metricsWriter) WriteGauge(name, help string, value float64) {
+	mw.WriteMetric(name, "gauge", help, newMetricSample(value))
+}
+
+// WriteMetric writes a metric together with all of its samples. All samples of
+// a metric need to be written at once since Prometheus requires them to be
+// grouped together.
+func (mw *metricsWriter) WriteMetric(name, typ, help string, samples ...metricSample) {
+	if len(samples) == 0 {
+		return
+	}
+	name = metricsNamespace + name
+	fmt.Fprintf(&mw.buf, "# HELP %s %s\n", name, metricsHelpEscaper.Replace(help))
+	fmt.Fprintf(&mw.buf, "# TYPE %s %s\n", name, typ)
+	for _, sample := range samples {
+		mw.buf.WriteString(name)
+		if len(sample.labels) > 0 {
+			mw.buf.WriteByte('{')
+			for i := 0; i+1 < len(sample.labels); i += 2 {
+				if i > 0 {
+					mw.buf.WriteByte(',')
+				}
+				fmt.Fprintf(&mw.buf, `%s="%s"`, sample.labels[i], metricsLabelEscaper.Replace(sample.labels[i+1]))
+			}
+			mw.buf.WriteByte('}')
+		}
+		mw.buf.WriteByte(' ')
+		mw.buf.WriteString(strconv.FormatFloat(sample.value, 'g', -1, 64))
+		mw.buf.WriteByte('\n')
+	}
+}
+
+// daemonMetricsHandlerGET handles the API call that exports the metrics of all
+// loaded modules in the Prometheus text format.
+func (api *API) daemonMetricsHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	var mw metricsWriter
+	if api.cs != nil {
+		writeConsensusMetrics(&mw, api.cs)
+	}
+	if api.gateway != nil {
+		writeGatewayMetrics(&mw, api.gateway)
+	}
+	if api.tpool != nil {
+		writeTransactionPoolMetrics(&mw, api.tpool)
+	}
+	if api.host != nil {
+		writeHostMetrics(&mw, api.host)
+	}
+	if api.renter != nil {
+		err := writeRenterMetrics(&mw, api.renter)
+		if err != nil {
+			WriteError(w, Error{"unable to collect renter metrics: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", metricsContentType)
+	_, _ = w.Write(mw.Bytes())
+}
+
+// writeConsensusMetrics writes the metrics of the consensus set.
+func writeConsensusMetrics(mw *metricsWriter, cs modules.ConsensusSet) {
+	mw.WriteGauge("consensus_height", "Current block height of the consensus set.", float64(cs.Height()))
+	mw.WriteGauge("consensus_synced", "Whether the consensus set is synced with the network.", boolToFloat(cs.Synced()))
+}
+
+// writeGatewayMetrics writes the metrics of the gateway.
+func writeGatewayMetrics(mw *metricsWriter, g modules.Gateway) {
+	var inbound, outbound int
+	for _, peer := range g.Peers() {
+		if peer.Inbound {
+			inbound++
+		} else {
+			outbound++
+		}
+	}
+	mw.WriteMetric("gateway_peers", "gauge", "Number of peers the gateway is connected to.",
+		newMetricSample(float64(inbound), "direction", "inbound"),
+		newMetricSample(float64(outbound), "direction", "outbound"),
+	)
+}
+
+// writeTransactionPoolMetrics writes the metrics of the transaction pool.
+func writeTransactionPoolMetrics(mw *metricsWriter, tpool modules.TransactionPool) {
+	mw.WriteGauge("tpool_transactions", "Number of transactions in the transaction pool.", float64(len(tpool.TransactionList())))
+}
+
+// writeHostMetrics writes the metrics of the host.
+func writeHostMetrics(mw *metricsWriter, h modules.Host) {
+	nm := h.NetworkMetrics()
+	mw.WriteMetric("host_rpc_calls_total", "counter", "Number of RPC calls made to the host by type.",
+		newMetricSample(float64(nm.DownloadCalls), "rpc", "download"),
+		newMetricSample(float64(nm.ErrorCalls), "rpc", "error"),
+		newMetricSample(float64(nm.FormContractCalls), "rpc", "formcontract"),
+		newMetricSample(float64(nm.RenewCalls), "rpc", "renew"),
+		newMetricSample(float64(nm.ReviseCalls), "rpc", "revise"),
+		newMetricSample(float64(nm.SettingsCalls), "rpc", "settings"),
+		newMetricSample(float64(nm.UnrecognizedCalls), "rpc", "unrecognized"),
+	)
+
+	fm := h.FinancialMetrics()
+	mw.WriteGauge("host_contracts", "Number of storage obligations of the host.", float64(fm.ContractCount))
+
+	pt := h.PriceTable()
+	mw.WriteGauge("host_registry_entries", "Number of entries stored in the host's registry.", float64(pt.RegistryEntriesTotal-pt.RegistryEntriesLeft))
+	mw.WriteGauge("host_registry_capacity", "Maximum number of entries the host's registry can store.", float64(pt.RegistryEntriesTotal))
+}
+
+// writeRenterMetrics writes the metrics of the renter.
+func writeRenterMetrics(mw *metricsWriter, r modules.Renter) error {
+	// Repair health of the whole filesystem.
+	dis, err := r.DirList(modules.RootTurtleDexPath())
+	if err != nil {
+		return errors.AddContext(err, "unable to get root directory info")
+	}
+	if len(dis) < 1 {
+		return errors.New("calling DirList on root directory returned no results")
+	}
+	root := dis[0]
+	mw.WriteGauge("renter_health", "Aggregate health of the renter's files, 0 is full health.", root.AggregateHealth)
+	mw.WriteGauge("renter_stuck_health", "Aggregate health of the renter's stuck chunks.", root.AggregateStuckHealth)
+	mw.WriteGauge("renter_max_health_percentage", "Health percentage of the least healthy file.", root.AggregateMaxHealthPercentage)
+	mw.WriteGauge("renter_min_redundancy", "Redundancy of the least redundant file.", root.AggregateMinRedundancy)
+	mw.WriteGauge("renter_stuck_chunks", "Number of stuck chunks.", float64(root.AggregateNumStuckChunks))
+	mw.WriteGauge("renter_files", "Number of files stored by the renter.", float64(root.AggregateNumFiles))
+	mw.WriteGauge("renter_stored_bytes", "Total size of the renter's files.", float64(root.AggregateSize))
+	mw.WriteGauge("renter_repair_bytes", "Amount of data that needs to be repaired.", float64(root.AggregateRepairSize))
+	mw.WriteGauge("renter_stuck_bytes", "Amount of data in stuck chunks.", float64(root.AggregateStuckSize))
+
+	// Memory managers.
+	ms, err := r.MemoryStatus()
+	if err != nil {
+		return errors.AddContext(err, "unable to get memory status")
+	}
+	managers := []struct {
+		name   string
+		status modules.MemoryManagerStatus
+	}{
+		{"total", ms.MemoryManagerStatus},
+		{"registry", ms.Registry},
+		{"system", ms.System},
+		{"userdownload", ms.UserDownload},
+		{"userupload", ms.UserUpload},
+	}
+	var available, base, requested []metricSample
+	for _, m := range managers {
+		available = append(available, newMetricSample(float64(m.status.Available), "manager", m.name))
+		base = append(base, newMetricSample(float64(m.status.Base), "manager", m.name))
+		requested = append(requested, newMetricSample(float64(m.status.Requested), "manager", m.name))
+	}
+	mw.WriteMetric("renter_memory_available_bytes", "gauge", "Memory available to a memory manager.", available...)
+	mw.WriteMetric("renter_memory_base_bytes", "gauge", "Memory a memory manager starts out with.", base...)
+	mw.WriteMetric("renter_memory_requested_bytes", "gauge", "Memory requested from a memory manager that is still outstanding.", requested...)
+
+	// Workers.
+	wps, err := r.WorkerPoolStatus()
+	if err != nil {
+		return errors.AddContext(err, "unable to get worker pool status")
+	}
+	mw.WriteGauge("renter_workers", "Number of workers in the renter's worker pool.", float64(wps.NumWorkers))
+	mw.WriteMetric("renter_workers_on_cooldown", "gauge", "Number of workers on cooldown by type.",
+		newMetricSample(float64(wps.TotalDownloadCoolDown), "type", "download"),
+		newMetricSample(float64(wps.TotalMaintenanceCoolDown), "type", "maintenance"),
+		newMetricSample(float64(wps.TotalUploadCoolDown), "type", "upload"),
+	)
+	var queueSizes, cooldowns, failures, priceTables []metricSample
+	for _, ws := range wps.Workers {
+		host := ws.HostPubKey.String()
+		queueSizes = append(queueSizes,
+			newMetricSample(float64(ws.DownloadQueueSize), "host", host, "queue", "download"),
+			newMetricSample(float64(ws.UploadQueueSize), "host", host, "queue", "upload"),
+			newMetricSample(float64(ws.DownloadSnapshotJobQueueSize), "host", host, "queue", "downloadsnapshot"),
+			newMetricSample(float64(ws.UploadSnapshotJobQueueSize), "host", host, "queue", "uploadsnapshot"),
+			newMetricSample(float64(ws.ReadJobsStatus.JobQueueSize), "host", host, "queue", "read"),
+			newMetricSample(float64(ws.HasSectorJobsStatus.JobQueueSize), "host", host, "queue", "hassector"),
+			newMetricSample(float64(ws.ReadRegistryJobsStatus.JobQueueSize), "host", host, "queue", "readregistry"),
+			newMetricSample(float64(ws.UpdateRegistryJobsStatus.JobQueueSize), "host", host, "queue", "updateregistry"),
+		)
+		cooldowns = append(cooldowns,
+			newMetricSample(boolToFloat(ws.DownloadOnCoolDown), "host", host, "type", "download"),
+			newMetricSample(boolToFloat(ws.UploadOnCoolDown), "host", host, "type", "upload"),
+			newMetricSample(boolToFloat(ws.MaintenanceOnCooldown), "host", host, "type", "maintenance"),
+			newMetricSample(boolToFloat(ws.ReadRegistryJobsStatus.OnCooldown), "host", host, "type", "readregistry"),
+			newMetricSample(boolToFloat(ws.UpdateRegistryJobsStatus.OnCooldown), "host", host, "type", "updateregistry"),
+		)
+		failures = append(failures,
+			newMetricSample(float64(ws.ReadJobsStatus.ConsecutiveFailures), "host", host, "queue", "read"),
+			newMetricSample(float64(ws.HasSectorJobsStatus.ConsecutiveFailures), "host", host, "queue", "hassector"),
+			newMetricSample(float64(ws.ReadRegistryJobsStatus.ConsecutiveFailures), "host", host, "queue", "readregistry"),
+			newMetricSample(float64(ws.UpdateRegistryJobsStatus.ConsecutiveFailures), "host", host, "queue", "updateregistry"),
+		)
+		priceTables = append(priceTables, newMetricSample(boolToFloat(ws.PriceTableStatus.Active), "host", host))
+	}
+	mw.WriteMetric("renter_worker_queue_size", "gauge", "Number of jobs in a worker's job queue.", queueSizes...)
+	mw.WriteMetric("renter_worker_on_cooldown", "gauge", "Whether a worker is on cooldown for a type of work.", cooldowns...)
+	mw.WriteMetric("renter_worker_consecutive_failures", "gauge", "Number of consecutive failures of a worker's job queue.", failures...)
+	mw.WriteMetric("renter_worker_price_table_active", "gauge", "Whether a worker has an active price table.", priceTables...)
+	return nil
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+// TestMetricsWriter probes the output of the metricsWriter.
+func TestMetricsWriter(t *testing.T) {
+	var mw metricsWriter
+
+	// A metric without samples shouldn't be written at all.
+	mw.WriteMetric("empty", "gauge", "empty metric")
+	if len(mw.Bytes()) != 0 {
+		t.Fatal("metric without samples was written", string(mw.Bytes()))
+	}
+
+	mw.WriteGauge("gauge", "a gauge", 1.5)
+	mw.WriteMetric("labeled", "counter", "help with \\ and\nnewline",
+		newMetricSample(1, "a", "b"),
+		newMetricSample(2, "a", "quote\"slash\\", "c", "new\nline"),
+	)
+	expected := `# HELP turtledex_gauge a gauge
+# TYPE turtledex_gauge gauge
+turtledex_gauge 1.5
+# HELP turtledex_labeled help with \\ and\nnewline
+# TYPE turtledex_labeled counter
+turtledex_labeled{a="b"} 1
+turtledex_labeled{a="quote\"slash\\",c="new\nline"} 2
+`
+	if string(mw.Bytes()) != expected {
+		t.Fatalf("unexpected output\nexpected:\n%v\ngot:\n%v", expected, string(mw.Bytes()))
+	}
+}
+
+// TestIntegrationDaemonMetrics probes the GET call to /daemon/metrics.
+func TestIntegrationDaemonMetrics(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	resp, err := HttpGET("http://" + st.server.listener.Addr().String() + "/daemon/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err := resp.Body.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if non2xx(resp.StatusCode) {
+		t.Fatal("unexpected status code", resp.StatusCode, string(body))
+	}
+	if resp.Header.Get("Content-Type") != metricsContentType {
+		t.Fatal("wrong content type", resp.Header.Get("Content-Type"))
+	}
+
+	// Check that the metrics of all modules are present.
+	metrics := string(body)
+	height := fmt.Sprintf("turtledex_consensus_height %v\n", 4+types.TaxHardforkHeight)
+	for _, m := range []string{height, "turtledex_gateway_peers{", "turtledex_tpool_transactions ", "turtledex_host_rpc_calls_total{", "turtledex_host_registry_capacity ", "turtledex_renter_health ", "turtledex_renter_memory_available_bytes{", "turtledex_renter_workers "} {
+		if !strings.Contains(metrics, m) {
+			t.Errorf("metrics are missing %q", m)
+		}
+	}
+}
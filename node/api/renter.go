@@ -85,7 +85,14 @@ type (
 		CurrentPeriod    types.BlockHeight          `json:"currentperiod"`
 		NextPeriod       types.BlockHeight          `json:"nextperiod"`
 
-		MemoryStatus modules.MemoryStatus `json:"memorystatus"`
+		DedupStatus       modules.DedupStatus       `json:"dedupstatus"`
+		MemoryStatus      modules.MemoryStatus      `json:"memorystatus"`
+		SectorCacheStatus modules.SectorCacheStatus `json:"sectorcachestatus"`
+	}
+
+	// RenterAuditGET contains the results of the renter's integrity audits.
+	RenterAuditGET struct {
+		modules.RenterAuditStatus
 	}
 
 	// RenterContract represents a contract formed by the renter.
@@ -167,6 +174,11 @@ type (
 		File modules.FileInfo `json:"file"`
 	}
 
+	// RenterFileVersions lists the retained versions of a file.
+	RenterFileVersions struct {
+		Versions []modules.FileVersion `json:"versions"`
+	}
+
 	// RenterFiles lists the files known to the renter.
 	RenterFiles struct {
 		Files []modules.FileInfo `json:"files"`
@@ -177,6 +189,11 @@ type (
 		MountPoints []modules.MountInfo `json:"mountpoints"`
 	}
 
+	// RenterSyncGET contains the status of the renter's sync folders.
+	RenterSyncGET struct {
+		Folders []modules.SyncFolderStatus `json:"folders"`
+	}
+
 	// RenterLoad lists files that were loaded into the renter.
 	RenterLoad struct {
 		FilesAdded []string `json:"filesadded"`
@@ -664,13 +681,25 @@ func (api *API) renterHandlerGET(w http.ResponseWriter, _ *http.Request, _ httpr
 		WriteError(w, Error{"unable to get renter memory information: " + err.Error()}, http.StatusBadRequest)
 		return
 	}
+	dedupStatus, err := api.renter.DedupStatus()
+	if err != nil {
+		WriteError(w, Error{"unable to get renter dedup information: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	sectorCacheStatus, err := api.renter.SectorCacheStatus()
+	if err != nil {
+		WriteError(w, Error{"unable to get renter sector cache information: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
 	WriteJSON(w, RenterGET{
 		Settings:         settings,
 		FinancialMetrics: spending,
 		CurrentPeriod:    currentPeriod,
 		NextPeriod:       nextPeriod,
 
-		MemoryStatus: memoryStatus,
+		DedupStatus:       dedupStatus,
+		MemoryStatus:      memoryStatus,
+		SectorCacheStatus: sectorCacheStatus,
 	})
 }
 
@@ -956,6 +985,81 @@ func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ ht
 		settings.IPViolationCheck = ipviolationcheck
 	}
 
+	// Scan the sector cache size. (optional parameter)
+	if size := req.FormValue("sectorcachemaxsize"); size != "" {
+		var maxSize uint64
+		if _, err := fmt.Sscan(size, &maxSize); err != nil {
+			WriteError(w, Error{"unable to parse sectorcachemaxsize: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.SectorCache.MaxSize = maxSize
+	}
+	// Scan the sector cache eviction policy. (optional parameter)
+	if policy := req.FormValue("sectorcachepolicy"); policy != "" {
+		p, err := modules.NewSectorCacheEvictionPolicy(policy)
+		if err != nil {
+			WriteError(w, Error{"unable to parse sectorcachepolicy: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.SectorCache.EvictionPolicy = p
+	}
+
+	// Scan the limits of the placement policy. (optional parameters)
+	placementLimits := []struct {
+		param string
+		limit *uint64
+	}{
+		{"maxpiecesperipv4subnet", &settings.PlacementPolicy.MaxPiecesPerIPv4Subnet},
+		{"maxpiecesperipv6subnet", &settings.PlacementPolicy.MaxPiecesPerIPv6Subnet},
+		{"maxpiecesperasn", &settings.PlacementPolicy.MaxPiecesPerASN},
+		{"maxpiecesperhostgroup", &settings.PlacementPolicy.MaxPiecesPerHostGroup},
+	}
+	for _, pl := range placementLimits {
+		if limit := req.FormValue(pl.param); limit != "" {
+			if _, err := fmt.Sscan(limit, pl.limit); err != nil {
+				WriteError(w, Error{"unable to parse " + pl.param + ": " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+		}
+	}
+	// Scan the files of the placement policy. An empty value removes the
+	// file. (optional parameters)
+	if _, exists := req.Form["asnfile"]; exists {
+		settings.PlacementPolicy.ASNFile = req.FormValue("asnfile")
+	}
+	if _, exists := req.Form["hostgroupsfile"]; exists {
+		settings.PlacementPolicy.HostGroupsFile = req.FormValue("hostgroupsfile")
+	}
+
+	// Scan the weights and limits of the scoring policy. (optional
+	// parameters)
+	if _, err := parseScoringPolicy(req, &settings.ScoringPolicy); err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Scan the rebalance policy. (optional parameters)
+	if b := req.FormValue("rebalancebudget"); b != "" {
+		budget, ok := scanAmount(b)
+		if !ok {
+			WriteError(w, Error{"unable to parse rebalancebudget"}, http.StatusBadRequest)
+			return
+		}
+		settings.RebalancePolicy.Budget = budget
+	}
+	if ratio := req.FormValue("rebalancemaxcostratio"); ratio != "" {
+		if _, err := fmt.Sscan(ratio, &settings.RebalancePolicy.MaxCostRatio); err != nil {
+			WriteError(w, Error{"unable to parse rebalancemaxcostratio: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if percentile := req.FormValue("rebalanceminscorepercentile"); percentile != "" {
+		if _, err := fmt.Sscan(percentile, &settings.RebalancePolicy.MinScorePercentile); err != nil {
+			WriteError(w, Error{"unable to parse rebalanceminscorepercentile: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
 	// Set the settings in the renter.
 	err = api.renter.SetSettings(settings)
 	if err != nil {
@@ -987,6 +1091,25 @@ func (api *API) renterAllowanceCancelHandlerPOST(w http.ResponseWriter, _ *http.
 	WriteSuccess(w)
 }
 
+// renterAuditHandlerGET handles the API call to /renter/audit.
+func (api *API) renterAuditHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	status, err := api.renter.AuditStatus()
+	if err != nil {
+		WriteError(w, Error{"unable to get renter audit status: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterAuditGET{status})
+}
+
+// renterAuditHandlerPOST handles the API call to trigger an integrity audit.
+func (api *API) renterAuditHandlerPOST(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	if err := api.renter.Audit(); err != nil {
+		WriteError(w, Error{"unable to start audit: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // renterCleanHandlerPOST handles the API call to clean lost files from a Renter.
 func (api *API) renterCleanHandlerPOST(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	var deleteErrs error
@@ -1098,6 +1221,36 @@ func (api *API) renterContractsHandler(w http.ResponseWriter, req *http.Request,
 	WriteJSON(w, contracts)
 }
 
+// renterContractsPlanHandlerGET handles the API call to plan the next round
+// of contract maintenance without forming or renewing any contracts. The
+// optional allowance parameters replace the ones of the current allowance.
+func (api *API) renterContractsPlanHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	allowance, err := parseOptionalAllowance(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if !reflect.DeepEqual(allowance, modules.Allowance{}) {
+		settings, err := api.renter.Settings()
+		if err != nil {
+			WriteError(w, Error{"unable to get renter settings: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		a := settings.Allowance
+		a.Funds = allowance.Funds
+		a.Hosts = allowance.Hosts
+		a.Period = allowance.Period
+		a.RenewWindow = allowance.RenewWindow
+		allowance = a
+	}
+	plan, err := api.renter.ContractMaintenancePlan(allowance)
+	if err != nil {
+		WriteError(w, Error{"unable to plan contract maintenance: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, plan)
+}
+
 // parseRenterContracts categorized the Renter's contracts from Contracts() and
 // OldContracts().
 func (api *API) parseRenterContracts(disabled, inactive, expired bool) RenterContracts {
@@ -1255,6 +1408,12 @@ func (api *API) renterClearDownloadsHandler(w http.ResponseWriter, req *http.Req
 	WriteSuccess(w)
 }
 
+// renterContractorRebalanceStatus handles the API call to request the status
+// of the migrations of data away from degraded or overpriced hosts.
+func (api *API) renterContractorRebalanceStatus(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, api.renter.ContractorRebalanceStatus())
+}
+
 // renterContractorChurnStatus handles the API call to request the churn status
 // from the renter's contractor.
 func (api *API) renterContractorChurnStatus(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
@@ -1405,6 +1564,87 @@ func (api *API) renterFuseUnmountHandlerPOST(w http.ResponseWriter, req *http.Re
 	WriteSuccess(w)
 }
 
+// renterSyncHandlerGET handles the API call to /renter/sync.
+func (api *API) renterSyncHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	folders, err := api.renter.SyncFolders()
+	if err != nil {
+		WriteError(w, Error{"unable to get sync folders: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	rebase := func(siaPath modules.TurtleDexPath) (modules.TurtleDexPath, error) {
+		return siaPath.Rebase(modules.UserFolder, modules.RootTurtleDexPath())
+	}
+	for i := range folders {
+		folders[i].TurtleDexPath, err = rebase(folders[i].TurtleDexPath)
+		if err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+		for j := range folders[i].Conflicts {
+			folders[i].Conflicts[j].TurtleDexPath, err = rebase(folders[i].Conflicts[j].TurtleDexPath)
+			if err != nil {
+				WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+				return
+			}
+		}
+	}
+	WriteJSON(w, RenterSyncGET{Folders: folders})
+}
+
+// renterSyncAddHandlerPOST handles the API call to /renter/sync/add.
+func (api *API) renterSyncAddHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var siaPath modules.TurtleDexPath
+	var err error
+	spfv := req.FormValue("siapath")
+	if spfv == "" {
+		siaPath = modules.RootTurtleDexPath()
+	} else {
+		siaPath, err = modules.NewTurtleDexPath(spfv)
+		if err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	siaPath, err = rebaseInputTurtleDexPath(siaPath)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	config := modules.SyncFolderConfig{
+		LocalPath:     req.FormValue("localpath"),
+		TurtleDexPath: siaPath,
+	}
+	if req.FormValue("propagatedeletions") != "" {
+		config.PropagateDeletions, err = scanBool(req.FormValue("propagatedeletions"))
+		if err != nil {
+			WriteError(w, Error{"unable to parse propagatedeletions: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if req.FormValue("propagaterenames") != "" {
+		config.PropagateRenames, err = scanBool(req.FormValue("propagaterenames"))
+		if err != nil {
+			WriteError(w, Error{"unable to parse propagaterenames: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if err := api.renter.AddSyncFolder(config); err != nil {
+		WriteError(w, Error{"unable to add sync folder: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterSyncRemoveHandlerPOST handles the API call to /renter/sync/remove.
+func (api *API) renterSyncRemoveHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	if err := api.renter.RemoveSyncFolder(req.FormValue("localpath")); err != nil {
+		WriteError(w, Error{"unable to remove sync folder: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // renterRecoveryScanHandlerPOST handles the API call to /renter/recoveryscan.
 func (api *API) renterRecoveryScanHandlerPOST(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	if err := api.renter.InitRecoveryScan(); err != nil {
@@ -1512,6 +1752,102 @@ func (api *API) renterFileHandlerGET(w http.ResponseWriter, req *http.Request, p
 	})
 }
 
+// renterVersionsHandlerGET handles GET requests to the
+// /renter/versions/:siapath API endpoint.
+func (api *API) renterVersionsHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	siaPath, err := modules.NewTurtleDexPath(ps.ByName("siapath"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	root, err := isCalledWithRootFlag(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if !root {
+		siaPath, err = rebaseInputTurtleDexPath(siaPath)
+		if err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	versions, err := api.renter.FileVersions(siaPath)
+	if err != nil {
+		WriteError(w, Error{"failed to get file versions: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	// Trim the user folder off the siapaths of the versioned files. The
+	// siapaths of the versions themselves are always absolute.
+	if !root {
+		for i := range versions {
+			versions[i].TurtleDexPath, err = versions[i].TurtleDexPath.Rebase(modules.UserFolder, modules.RootTurtleDexPath())
+			if err != nil {
+				WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+				return
+			}
+		}
+	}
+	if versions == nil {
+		versions = []modules.FileVersion{}
+	}
+	WriteJSON(w, RenterFileVersions{
+		Versions: versions,
+	})
+}
+
+// renterVersionsHandlerPOST handles POST requests to the
+// /renter/versions/:siapath API endpoint.
+func (api *API) renterVersionsHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	action := req.FormValue("action")
+	if action == "" {
+		WriteError(w, Error{"you must set the action you wish to execute"}, http.StatusBadRequest)
+		return
+	}
+	id := req.FormValue("id")
+	siaPath, err := modules.NewTurtleDexPath(ps.ByName("siapath"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	root, err := isCalledWithRootFlag(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if !root {
+		siaPath, err = rebaseInputTurtleDexPath(siaPath)
+		if err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	switch action {
+	case "restore":
+		if id == "" {
+			WriteError(w, Error{"you must specify the id of the version to restore"}, http.StatusBadRequest)
+			return
+		}
+		err = api.renter.RestoreFileVersion(siaPath, id)
+		if err != nil {
+			WriteError(w, Error{"failed to restore version: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+	case "purge":
+		err = api.renter.PurgeFileVersions(siaPath, id)
+		if err != nil {
+			WriteError(w, Error{"failed to purge versions: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+	default:
+		WriteError(w, Error{fmt.Sprintf("unknown action '%v'", action)}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // renterFileHandler handles POST requests to the /renter/file/:siapath API endpoint.
 func (api *API) renterFileHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	newTrackingPath := req.FormValue("trackingpath")
@@ -1591,16 +1927,16 @@ func (api *API) renterFilesHandler(w http.ResponseWriter, req *http.Request, _ h
 	})
 }
 
-// renterPricesHandler reports the expected costs of various actions given the
-// renter settings and the set of available hosts.
-func (api *API) renterPricesHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+// parseOptionalAllowance parses the optional allowance parameters funds, hosts,
+// period and renewwindow of a request. Either all or none of the parameters
+// have to be set. If none are set, the empty allowance is returned.
+func parseOptionalAllowance(req *http.Request) (modules.Allowance, error) {
 	allowance := modules.Allowance{}
 	// Scan the allowance amount. (optional parameter)
 	if f := req.FormValue("funds"); f != "" {
 		funds, ok := scanAmount(f)
 		if !ok {
-			WriteError(w, Error{"unable to parse funds"}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("unable to parse funds")
 		}
 		allowance.Funds = funds
 	}
@@ -1608,11 +1944,9 @@ func (api *API) renterPricesHandler(w http.ResponseWriter, req *http.Request, _
 	if h := req.FormValue("hosts"); h != "" {
 		var hosts uint64
 		if _, err := fmt.Sscan(h, &hosts); err != nil {
-			WriteError(w, Error{"unable to parse hosts: " + err.Error()}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("unable to parse hosts: " + err.Error())
 		} else if hosts != 0 && hosts < requiredHosts {
-			WriteError(w, Error{fmt.Sprintf("insufficient number of hosts, need at least %v but have %v", modules.DefaultAllowance.Hosts, hosts)}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, fmt.Errorf("insufficient number of hosts, need at least %v but have %v", modules.DefaultAllowance.Hosts, hosts)
 		} else {
 			allowance.Hosts = hosts
 		}
@@ -1621,8 +1955,7 @@ func (api *API) renterPricesHandler(w http.ResponseWriter, req *http.Request, _
 	if p := req.FormValue("period"); p != "" {
 		var period types.BlockHeight
 		if _, err := fmt.Sscan(p, &period); err != nil {
-			WriteError(w, Error{"unable to parse period: " + err.Error()}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("unable to parse period: " + err.Error())
 		}
 		allowance.Period = types.BlockHeight(period)
 	}
@@ -1630,11 +1963,9 @@ func (api *API) renterPricesHandler(w http.ResponseWriter, req *http.Request, _
 	if rw := req.FormValue("renewwindow"); rw != "" {
 		var renewWindow types.BlockHeight
 		if _, err := fmt.Sscan(rw, &renewWindow); err != nil {
-			WriteError(w, Error{"unable to parse renewwindow: " + err.Error()}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("unable to parse renewwindow: " + err.Error())
 		} else if renewWindow != 0 && types.BlockHeight(renewWindow) < requiredRenewWindow {
-			WriteError(w, Error{fmt.Sprintf("renew window is too small, must be at least %v blocks but have %v blocks", requiredRenewWindow, renewWindow)}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, fmt.Errorf("renew window is too small, must be at least %v blocks but have %v blocks", requiredRenewWindow, renewWindow)
 		} else {
 			allowance.RenewWindow = types.BlockHeight(renewWindow)
 		}
@@ -1645,22 +1976,29 @@ func (api *API) renterPricesHandler(w http.ResponseWriter, req *http.Request, _
 	// above so that an empty allowance can still be submitted
 	if !reflect.DeepEqual(allowance, modules.Allowance{}) {
 		if allowance.Funds.Cmp(types.ZeroCurrency) == 0 {
-			WriteError(w, Error{fmt.Sprint("Allowance not set correctly, `funds` parameter left empty")}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("Allowance not set correctly, `funds` parameter left empty")
 		}
 		if allowance.Period == 0 {
-			WriteError(w, Error{fmt.Sprint("Allowance not set correctly, `period` parameter left empty")}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("Allowance not set correctly, `period` parameter left empty")
 		}
 		if allowance.Hosts == 0 {
-			WriteError(w, Error{fmt.Sprint("Allowance not set correctly, `hosts` parameter left empty")}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("Allowance not set correctly, `hosts` parameter left empty")
 		}
 		if allowance.RenewWindow == 0 {
-			WriteError(w, Error{fmt.Sprint("Allowance not set correctly, `renewwindow` parameter left empty")}, http.StatusBadRequest)
-			return
+			return modules.Allowance{}, errors.New("Allowance not set correctly, `renewwindow` parameter left empty")
 		}
 	}
+	return allowance, nil
+}
+
+// renterPricesHandler reports the expected costs of various actions given the
+// renter settings and the set of available hosts.
+func (api *API) renterPricesHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	allowance, err := parseOptionalAllowance(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
 
 	estimate, a, err := api.renter.PriceEstimation(allowance)
 	if err != nil {
@@ -1936,6 +2274,21 @@ func (api *API) renterUploadHandler(w http.ResponseWriter, req *http.Request, ps
 			return
 		}
 	}
+	// Parse the compression type.
+	compression, err := modules.NewCompressionType(req.FormValue("compression"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse 'compression' parameter: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	// Check whether the file's chunks should be deduplicated
+	dedup := false
+	if d := req.FormValue("dedup"); d != "" {
+		dedup, err = strconv.ParseBool(d)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'dedup' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
 	// Parse the erasure coder.
 	ec, err := parseErasureCodingParameters(req.FormValue("datapieces"), req.FormValue("paritypieces"))
 	if err != nil {
@@ -1959,6 +2312,8 @@ func (api *API) renterUploadHandler(w http.ResponseWriter, req *http.Request, ps
 		TurtleDexPath:             siaPath,
 		ErasureCode:         ec,
 		Force:               force,
+		Dedup:               dedup,
+		Compression:         compression,
 		DisablePartialChunk: true, // TODO: remove this
 
 		// NOTE: can make this an optional param.
@@ -2063,6 +2418,29 @@ func (api *API) renterUploadStreamHandler(w http.ResponseWriter, req *http.Reque
 			return
 		}
 	}
+	// Parse the compression type.
+	compression, err := modules.NewCompressionType(queryForm.Get("compression"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse 'compression' parameter: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if repair && compression != modules.CompressionNone {
+		WriteError(w, Error{"can't provide compression settings when doing a repair"}, http.StatusBadRequest)
+		return
+	}
+	// Check whether the file's chunks should be deduplicated
+	dedup := false
+	if d := queryForm.Get("dedup"); d != "" {
+		dedup, err = strconv.ParseBool(d)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'dedup' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if repair && dedup {
+		WriteError(w, Error{"can't enable deduplication when doing a repair"}, http.StatusBadRequest)
+		return
+	}
 	// Parse the erasure coder.
 	ec, err := parseErasureCodingParameters(queryForm.Get("datapieces"), queryForm.Get("paritypieces"))
 	if err != nil && !repair {
@@ -2090,6 +2468,8 @@ func (api *API) renterUploadStreamHandler(w http.ResponseWriter, req *http.Reque
 		ErasureCode: ec,
 		Force:       force,
 		Repair:      repair,
+		Dedup:       dedup,
+		Compression: compression,
 
 		// NOTE: can make this an optional param.
 		CipherType: crypto.TypeDefaultRenter,
@@ -2244,6 +2624,52 @@ func (api *API) renterDirHandlerPOST(w http.ResponseWriter, req *http.Request, p
 		WriteSuccess(w)
 		return
 	}
+	if action == "setversioning" {
+		var policy modules.VersioningPolicy
+		if mv := req.FormValue("maxversions"); mv != "" {
+			policy.MaxVersions, err = strconv.ParseUint(mv, 10, 64)
+			if err != nil {
+				WriteError(w, Error{"failed to parse maxversions: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+		}
+		if rw := req.FormValue("retentionwindow"); rw != "" {
+			seconds, err := strconv.ParseUint(rw, 10, 64)
+			if err != nil {
+				WriteError(w, Error{"failed to parse retentionwindow: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+			policy.RetentionWindow = time.Second * time.Duration(seconds)
+		}
+		err = api.renter.SetDirVersioning(siaPath, policy)
+		if err != nil {
+			WriteError(w, Error{"failed to set versioning policy: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		WriteSuccess(w)
+		return
+	}
+	if action == "setredundancy" {
+		// Validate the erasure code like for uploads. Leaving out both
+		// parameters disables the policy.
+		ec, err := parseErasureCodingParameters(req.FormValue("datapieces"), req.FormValue("paritypieces"))
+		if err != nil {
+			WriteError(w, Error{"unable to parse erasure code settings: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		var policy modules.RedundancyPolicy
+		if ec != nil {
+			policy.DataPieces = ec.MinPieces()
+			policy.ParityPieces = ec.NumPieces() - ec.MinPieces()
+		}
+		err = api.renter.SetDirRedundancyPolicy(siaPath, policy)
+		if err != nil {
+			WriteError(w, Error{"failed to set redundancy policy: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		WriteSuccess(w)
+		return
+	}
 	if action == "rename" {
 		newTurtleDexPath, err := modules.NewTurtleDexPath(req.FormValue("newsiapath"))
 		if err != nil {
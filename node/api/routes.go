@@ -10,6 +10,7 @@ import (
 	"github.com/julienschmidt/httprouter"
 
 	"github.com/turtledex/TurtleDexCore/build"
+	"github.com/turtledex/TurtleDexCore/modules"
 )
 
 var (
@@ -29,6 +30,7 @@ func (api *API) buildHTTPRoutes() {
 	router := httprouter.New()
 	requiredPassword := api.requiredPassword
 	requiredUserAgent := api.requiredUserAgent
+	tokens := api.staticTokens
 
 	router.NotFound = http.HandlerFunc(api.UnrecognizedCallHandler)
 	router.RedirectTrailingSlash = false
@@ -36,12 +38,16 @@ func (api *API) buildHTTPRoutes() {
 	// Daemon API Calls
 	router.GET("/daemon/alerts", api.daemonAlertsHandlerGET)
 	router.GET("/daemon/constants", api.daemonConstantsHandler)
+	router.GET("/daemon/metrics", api.daemonMetricsHandlerGET)
 	router.GET("/daemon/settings", api.daemonSettingsHandlerGET)
 	router.POST("/daemon/settings", api.daemonSettingsHandlerPOST)
 	router.GET("/daemon/stack", api.daemonStackHandlerGET)
 	router.POST("/daemon/startprofile", api.daemonStartProfileHandlerPOST)
-	router.GET("/daemon/stop", RequirePassword(api.daemonStopHandler, requiredPassword))
+	router.GET("/daemon/stop", RequireScope(api.daemonStopHandler, requiredPassword, tokens, modules.APIScopeAdmin))
 	router.POST("/daemon/stopprofile", api.daemonStopProfileHandlerPOST)
+	router.GET("/daemon/tokens", RequireScope(api.daemonTokensHandlerGET, requiredPassword, tokens, modules.APIScopeAdmin))
+	router.POST("/daemon/tokens/create", RequireScope(api.daemonTokensCreateHandlerPOST, requiredPassword, tokens, modules.APIScopeAdmin))
+	router.POST("/daemon/tokens/revoke", RequireScope(api.daemonTokensRevokeHandlerPOST, requiredPassword, tokens, modules.APIScopeAdmin))
 	router.GET("/daemon/update", api.daemonUpdateHandlerGET)
 	router.POST("/daemon/update", api.daemonUpdateHandlerPOST)
 	router.GET("/daemon/version", api.daemonVersionHandler)
@@ -64,8 +70,8 @@ func (api *API) buildHTTPRoutes() {
 	// FeeManager API Calls
 	if api.feemanager != nil {
 		router.GET("/feemanager", api.feemanagerHandlerGET)
-		router.POST("/feemanager/add", RequirePassword(api.feemanagerAddHandlerPOST, requiredPassword))
-		router.POST("/feemanager/cancel", RequirePassword(api.feemanagerCancelHandlerPOST, requiredPassword))
+		router.POST("/feemanager/add", RequireScope(api.feemanagerAddHandlerPOST, requiredPassword, tokens, modules.APIScopeAdmin))
+		router.POST("/feemanager/cancel", RequireScope(api.feemanagerCancelHandlerPOST, requiredPassword, tokens, modules.APIScopeAdmin))
 		router.GET("/feemanager/paidfees", api.feemanagerPaidFeesHandlerGET)
 		router.GET("/feemanager/pendingfees", api.feemanagerPendingFeesHandlerGET)
 	}
@@ -75,107 +81,126 @@ func (api *API) buildHTTPRoutes() {
 		router.GET("/gateway", api.gatewayHandlerGET)
 		router.POST("/gateway", api.gatewayHandlerPOST)
 		router.GET("/gateway/bandwidth", api.gatewayBandwidthHandlerGET)
-		router.POST("/gateway/connect/:netaddress", RequirePassword(api.gatewayConnectHandler, requiredPassword))
-		router.POST("/gateway/disconnect/:netaddress", RequirePassword(api.gatewayDisconnectHandler, requiredPassword))
+		router.POST("/gateway/connect/:netaddress", RequireScope(api.gatewayConnectHandler, requiredPassword, tokens, modules.APIScopeAdmin))
+		router.POST("/gateway/disconnect/:netaddress", RequireScope(api.gatewayDisconnectHandler, requiredPassword, tokens, modules.APIScopeAdmin))
 		router.GET("/gateway/blocklist", api.gatewayBlocklistHandlerGET)
-		router.POST("/gateway/blocklist", RequirePassword(api.gatewayBlocklistHandlerPOST, requiredPassword))
+		router.POST("/gateway/blocklist", RequireScope(api.gatewayBlocklistHandlerPOST, requiredPassword, tokens, modules.APIScopeAdmin))
 
 		// Deprecated fields
 		router.GET("/gateway/blacklist", api.gatewayBlocklistHandlerGET)
-		router.POST("/gateway/blacklist", RequirePassword(api.gatewayBlocklistHandlerPOST, requiredPassword))
+		router.POST("/gateway/blacklist", RequireScope(api.gatewayBlocklistHandlerPOST, requiredPassword, tokens, modules.APIScopeAdmin))
 	}
 
 	// Host API Calls
 	if api.host != nil {
 		// Calls directly pertaining to the host.
-		router.GET("/host", api.hostHandlerGET)                                                   // Get the host status.
-		router.POST("/host", RequirePassword(api.hostHandlerPOST, requiredPassword))              // Change the settings of the host.
-		router.POST("/host/announce", RequirePassword(api.hostAnnounceHandler, requiredPassword)) // Announce the host to the network.
-		router.GET("/host/contracts", api.hostContractInfoHandler)                                // Get info about contracts.
+		router.GET("/host", api.hostHandlerGET)                                                                                   // Get the host status.
+		router.POST("/host", RequireScope(api.hostHandlerPOST, requiredPassword, tokens, modules.APIScopeHostAdmin))              // Change the settings of the host.
+		router.POST("/host/announce", RequireScope(api.hostAnnounceHandler, requiredPassword, tokens, modules.APIScopeHostAdmin)) // Announce the host to the network.
+		router.GET("/host/contracts", api.hostContractInfoHandler)                                                                // Get info about contracts.
 		router.GET("/host/estimatescore", api.hostEstimateScoreGET)
 		router.GET("/host/bandwidth", api.hostBandwidthHandlerGET)
 
 		// Calls pertaining to the storage manager that the host uses.
 		router.GET("/host/storage", api.storageHandler)
-		router.POST("/host/storage/folders/add", RequirePassword(api.storageFoldersAddHandler, requiredPassword))
-		router.POST("/host/storage/folders/remove", RequirePassword(api.storageFoldersRemoveHandler, requiredPassword))
-		router.POST("/host/storage/folders/resize", RequirePassword(api.storageFoldersResizeHandler, requiredPassword))
-		router.POST("/host/storage/sectors/delete/:merkleroot", RequirePassword(api.storageSectorsDeleteHandler, requiredPassword))
+		router.POST("/host/storage/folders/add", RequireScope(api.storageFoldersAddHandler, requiredPassword, tokens, modules.APIScopeHostAdmin))
+		router.POST("/host/storage/folders/remove", RequireScope(api.storageFoldersRemoveHandler, requiredPassword, tokens, modules.APIScopeHostAdmin))
+		router.POST("/host/storage/folders/resize", RequireScope(api.storageFoldersResizeHandler, requiredPassword, tokens, modules.APIScopeHostAdmin))
+		router.POST("/host/storage/sectors/delete/:merkleroot", RequireScope(api.storageSectorsDeleteHandler, requiredPassword, tokens, modules.APIScopeHostAdmin))
 	}
 
 	// Miner API Calls
 	if api.miner != nil {
 		router.GET("/miner", api.minerHandler)
-		router.POST("/miner/block", RequirePassword(api.minerBlockHandlerPOST, requiredPassword))
-		router.GET("/miner/header", RequirePassword(api.minerHeaderHandlerGET, requiredPassword))
-		router.POST("/miner/header", RequirePassword(api.minerHeaderHandlerPOST, requiredPassword))
-		router.GET("/miner/start", RequirePassword(api.minerStartHandler, requiredPassword))
-		router.GET("/miner/stop", RequirePassword(api.minerStopHandler, requiredPassword))
+		router.POST("/miner/block", RequireScope(api.minerBlockHandlerPOST, requiredPassword, tokens, modules.APIScopeAdmin))
+		router.GET("/miner/header", RequireScope(api.minerHeaderHandlerGET, requiredPassword, tokens, modules.APIScopeAdmin))
+		router.POST("/miner/header", RequireScope(api.minerHeaderHandlerPOST, requiredPassword, tokens, modules.APIScopeAdmin))
+		router.GET("/miner/start", RequireScope(api.minerStartHandler, requiredPassword, tokens, modules.APIScopeAdmin))
+		router.GET("/miner/stop", RequireScope(api.minerStopHandler, requiredPassword, tokens, modules.APIScopeAdmin))
 	}
 
 	// Renter API Calls
 	if api.renter != nil {
 		router.GET("/renter", api.renterHandlerGET)
-		router.POST("/renter", RequirePassword(api.renterHandlerPOST, requiredPassword))
-		router.POST("/renter/allowance/cancel", RequirePassword(api.renterAllowanceCancelHandlerPOST, requiredPassword))
+		router.POST("/renter", RequireScope(api.renterHandlerPOST, requiredPassword, tokens, modules.APIScopeRenter))
+		router.POST("/renter/allowance/cancel", RequireScope(api.renterAllowanceCancelHandlerPOST, requiredPassword, tokens, modules.APIScopeRenter))
+		router.GET("/renter/audit", api.renterAuditHandlerGET)
+		router.POST("/renter/audit", RequireScope(api.renterAuditHandlerPOST, requiredPassword, tokens, modules.APIScopeRenter))
 		router.POST("/renter/bubble", api.renterBubbleHandlerPOST)
-		router.GET("/renter/backups", RequirePassword(api.renterBackupsHandlerGET, requiredPassword))
-		router.POST("/renter/backups/create", RequirePassword(api.renterBackupsCreateHandlerPOST, requiredPassword))
-		router.POST("/renter/backups/restore", RequirePassword(api.renterBackupsRestoreHandlerGET, requiredPassword))
-		router.POST("/renter/clean", RequirePassword(api.renterCleanHandlerPOST, requiredPassword))
-		router.POST("/renter/contract/cancel", RequirePassword(api.renterContractCancelHandler, requiredPassword))
+		router.GET("/renter/backups", RequireScope(api.renterBackupsHandlerGET, requiredPassword, tokens, modules.APIScopeRead))
+		router.POST("/renter/backups/create", RequireScope(api.renterBackupsCreateHandlerPOST, requiredPassword, tokens, modules.APIScopeRenter))
+		router.POST("/renter/backups/restore", RequireScope(api.renterBackupsRestoreHandlerGET, requiredPassword, tokens, modules.APIScopeRenter))
+		router.POST("/renter/clean", RequireScope(api.renterCleanHandlerPOST, requiredPassword, tokens, modules.APIScopeRenter))
+		router.POST("/renter/contract/cancel", RequireScope(api.renterContractCancelHandler, requiredPassword, tokens, modules.APIScopeRenter))
 		router.GET("/renter/contracts", api.renterContractsHandler)
+		router.GET("/renter/contracts/plan", api.renterContractsPlanHandlerGET)
 		router.GET("/renter/contractorchurnstatus", api.renterContractorChurnStatus)
+		router.GET("/renter/contractorrebalancestatus", api.renterContractorRebalanceStatus)
 		router.GET("/renter/downloadinfo/*uid", api.renterDownloadByUIDHandlerGET)
 		router.GET("/renter/downloads", api.renterDownloadsHandler)
-		router.POST("/renter/downloads/clear", RequirePassword(api.renterClearDownloadsHandler, requiredPassword))
+		router.POST("/renter/downloads/clear", RequireScope(api.renterClearDownloadsHandler, requiredPassword, tokens, modules.APIScopeRenter))
 		router.GET("/renter/files", api.renterFilesHandler)
 		router.GET("/renter/file/*siapath", api.renterFileHandlerGET)
-		router.POST("/renter/file/*siapath", RequirePassword(api.renterFileHandlerPOST, requiredPassword))
+		router.POST("/renter/file/*siapath", RequireScope(api.renterFileHandlerPOST, requiredPassword, tokens, modules.APIScopeRenter))
 		router.GET("/renter/prices", api.renterPricesHandler)
-		router.POST("/renter/recoveryscan", RequirePassword(api.renterRecoveryScanHandlerPOST, requiredPassword))
+		router.POST("/renter/recoveryscan", RequireScope(api.renterRecoveryScanHandlerPOST, requiredPassword, tokens, modules.APIScopeRenter))
 		router.GET("/renter/recoveryscan", api.renterRecoveryScanHandlerGET)
 		router.GET("/renter/fuse", api.renterFuseHandlerGET)
-		router.POST("/renter/fuse/mount", RequirePassword(api.renterFuseMountHandlerPOST, requiredPassword))
-		router.POST("/renter/fuse/unmount", RequirePassword(api.renterFuseUnmountHandlerPOST, requiredPassword))
+		router.POST("/renter/fuse/mount", RequireScope(api.renterFuseMountHandlerPOST, requiredPassword, tokens, modules.APIScopeRenter))
+		router.POST("/renter/fuse/unmount", RequireScope(api.renterFuseUnmountHandlerPOST, requiredPassword, tokens, modules.APIScopeRenter))
 
-		router.POST("/renter/delete/*siapath", RequirePassword(api.renterDeleteHandler, requiredPassword))
-		router.GET("/renter/download/*siapath", RequirePassword(api.renterDownloadHandler, requiredPassword))
-		router.POST("/renter/download/cancel", RequirePassword(api.renterCancelDownloadHandler, requiredPassword))
-		router.GET("/renter/downloadasync/*siapath", RequirePassword(api.renterDownloadAsyncHandler, requiredPassword))
-		router.POST("/renter/rename/*siapath", RequirePassword(api.renterRenameHandler, requiredPassword))
+		router.POST("/renter/delete/*siapath", RequireScope(api.renterDeleteHandler, requiredPassword, tokens, modules.APIScopeRenter))
+		router.GET("/renter/download/*siapath", RequireScope(api.renterDownloadHandler, requiredPassword, tokens, modules.APIScopeRenter))
+		router.POST("/renter/download/cancel", RequireScope(api.renterCancelDownloadHandler, requiredPassword, tokens, modules.APIScopeRenter))
+		router.GET("/renter/downloadasync/*siapath", RequireScope(api.renterDownloadAsyncHandler, requiredPassword, tokens, modules.APIScopeRenter))
+		router.POST("/renter/rename/*siapath", RequireScope(api.renterRenameHandler, requiredPassword, tokens, modules.APIScopeRenter))
 		router.GET("/renter/stream/*siapath", api.renterStreamHandler)
-		router.POST("/renter/upload/*siapath", RequirePassword(api.renterUploadHandler, requiredPassword))
+		router.GET("/renter/sync", api.renterSyncHandlerGET)
+		router.POST("/renter/sync/add", RequireScope(api.renterSyncAddHandlerPOST, requiredPassword, tokens, modules.APIScopeRenter))
+		router.POST("/renter/sync/remove", RequireScope(api.renterSyncRemoveHandlerPOST, requiredPassword, tokens, modules.APIScopeRenter))
+		router.POST("/renter/upload/*siapath", RequireScope(api.renterUploadHandler, requiredPassword, tokens, modules.APIScopeRenter))
 		router.GET("/renter/uploadready", api.renterUploadReadyHandler)
-		router.POST("/renter/uploads/pause", RequirePassword(api.renterUploadsPauseHandler, requiredPassword))
-		router.POST("/renter/uploads/resume", RequirePassword(api.renterUploadsResumeHandler, requiredPassword))
-		router.POST("/renter/uploadstream/*siapath", RequirePassword(api.renterUploadStreamHandler, requiredPassword))
-		router.POST("/renter/validatesiapath/*siapath", RequirePassword(api.renterValidateTurtleDexPathHandler, requiredPassword))
+		router.POST("/renter/uploads/pause", RequireScope(api.renterUploadsPauseHandler, requiredPassword, tokens, modules.APIScopeRenter))
+		router.POST("/renter/uploads/resume", RequireScope(api.renterUploadsResumeHandler, requiredPassword, tokens, modules.APIScopeRenter))
+		router.POST("/renter/uploadstream/*siapath", RequireScope(api.renterUploadStreamHandler, requiredPassword, tokens, modules.APIScopeRenter))
+		router.POST("/renter/validatesiapath/*siapath", RequireScope(api.renterValidateTurtleDexPathHandler, requiredPassword, tokens, modules.APIScopeRenter))
+		router.GET("/renter/versions/*siapath", api.renterVersionsHandlerGET)
+		router.POST("/renter/versions/*siapath", RequireScope(api.renterVersionsHandlerPOST, requiredPassword, tokens, modules.APIScopeRenter))
 		router.GET("/renter/workers", api.renterWorkersHandler)
 
 		// Skynet endpoints
 		router.GET("/skynet/basesector/*skylink", api.skynetBaseSectorHandlerGET)
 		router.GET("/skynet/blocklist", api.skynetBlocklistHandlerGET)
-		router.POST("/skynet/blocklist", RequirePassword(api.skynetBlocklistHandlerPOST, requiredPassword))
-		router.POST("/skynet/pin/:skylink", RequirePassword(api.skynetSkylinkPinHandlerPOST, requiredPassword))
+		router.POST("/skynet/blocklist", RequireScope(api.skynetBlocklistHandlerPOST, requiredPassword, tokens, modules.APIScopeAdmin))
+		router.POST("/skynet/pin/:skylink", RequireScope(api.skynetSkylinkPinHandlerPOST, requiredPassword, tokens, modules.APIScopeSkynetUpload))
+		router.GET("/skynet/pins", api.skynetPinsHandlerGET)
+		router.POST("/skynet/pins/add", RequireScope(api.skynetPinsAddHandlerPOST, requiredPassword, tokens, modules.APIScopeSkynetUpload))
+		router.POST("/skynet/pins/remove", RequireScope(api.skynetPinsRemoveHandlerPOST, requiredPassword, tokens, modules.APIScopeSkynetUpload))
 		router.GET("/skynet/portals", api.skynetPortalsHandlerGET)
-		router.POST("/skynet/portals", RequirePassword(api.skynetPortalsHandlerPOST, requiredPassword))
+		router.POST("/skynet/portals", RequireScope(api.skynetPortalsHandlerPOST, requiredPassword, tokens, modules.APIScopeAdmin))
 		router.GET("/skynet/root", api.skynetRootHandlerGET)
 		router.GET("/skynet/skylink/*skylink", api.skynetSkylinkHandlerGET)
 		router.HEAD("/skynet/skylink/*skylink", api.skynetSkylinkHandlerGET)
-		router.POST("/skynet/skyfile/*siapath", RequirePassword(api.skynetSkyfileHandlerPOST, requiredPassword))
-		router.POST("/skynet/registry", RequirePassword(api.registryHandlerPOST, requiredPassword))
+		router.POST("/skynet/skyfile/*siapath", RequireScope(api.skynetSkyfileHandlerPOST, requiredPassword, tokens, modules.APIScopeSkynetUpload))
+		router.POST("/skynet/registry", RequireScope(api.registryHandlerPOST, requiredPassword, tokens, modules.APIScopeSkynetUpload))
 		router.GET("/skynet/registry", api.registryHandlerGET)
-		router.POST("/skynet/restore", RequirePassword(api.skynetRestoreHandlerPOST, requiredPassword))
+		router.GET("/skynet/registry/subscription", api.registrySubscriptionHandlerGET)
+		router.GET("/skynet/registry/skylinkv2", api.registrySkylinkV2HandlerGET)
+		router.POST("/skynet/restore", RequireScope(api.skynetRestoreHandlerPOST, requiredPassword, tokens, modules.APIScopeSkynetUpload))
 		router.GET("/skynet/stats", api.skynetStatsHandlerGET)
-		router.GET("/skynet/skykey", RequirePassword(api.skykeyHandlerGET, requiredPassword))
-		router.POST("/skynet/addskykey", RequirePassword(api.skykeyAddKeyHandlerPOST, requiredPassword))
-		router.POST("/skynet/createskykey", RequirePassword(api.skykeyCreateKeyHandlerPOST, requiredPassword))
-		router.POST("/skynet/deleteskykey", RequirePassword(api.skykeyDeleteHandlerPOST, requiredPassword))
-		router.GET("/skynet/skykeys", RequirePassword(api.skykeysHandlerGET, requiredPassword))
+		router.OPTIONS("/skynet/tus", api.skynetTusHandlerOPTIONS)
+		router.POST("/skynet/tus", RequireScope(api.skynetTusHandlerPOST, requiredPassword, tokens, modules.APIScopeSkynetUpload))
+		router.HEAD("/skynet/tus/:id", RequireScope(api.skynetTusUploadHandlerHEAD, requiredPassword, tokens, modules.APIScopeSkynetUpload))
+		router.PATCH("/skynet/tus/:id", RequireScope(api.skynetTusUploadHandlerPATCH, requiredPassword, tokens, modules.APIScopeSkynetUpload))
+		router.DELETE("/skynet/tus/:id", RequireScope(api.skynetTusUploadHandlerDELETE, requiredPassword, tokens, modules.APIScopeSkynetUpload))
+		router.GET("/skynet/skykey", RequireScope(api.skykeyHandlerGET, requiredPassword, tokens, modules.APIScopeAdmin))
+		router.POST("/skynet/addskykey", RequireScope(api.skykeyAddKeyHandlerPOST, requiredPassword, tokens, modules.APIScopeAdmin))
+		router.POST("/skynet/createskykey", RequireScope(api.skykeyCreateKeyHandlerPOST, requiredPassword, tokens, modules.APIScopeAdmin))
+		router.POST("/skynet/deleteskykey", RequireScope(api.skykeyDeleteHandlerPOST, requiredPassword, tokens, modules.APIScopeAdmin))
+		router.GET("/skynet/skykeys", RequireScope(api.skykeysHandlerGET, requiredPassword, tokens, modules.APIScopeAdmin))
 
 		// Directory endpoints
-		router.POST("/renter/dir/*siapath", RequirePassword(api.renterDirHandlerPOST, requiredPassword))
+		router.POST("/renter/dir/*siapath", RequireScope(api.renterDirHandlerPOST, requiredPassword, tokens, modules.APIScopeRenter))
 		router.GET("/renter/dir/*siapath", api.renterDirHandlerGET)
 
 		// HostDB endpoints.
@@ -183,17 +208,18 @@ func (api *API) buildHTTPRoutes() {
 		router.GET("/hostdb/active", api.hostdbActiveHandler)
 		router.GET("/hostdb/all", api.hostdbAllHandler)
 		router.GET("/hostdb/hosts/:pubkey", api.hostdbHostsHandler)
+		router.GET("/hostdb/hosts/:pubkey/history", api.hostdbHostsHistoryHandler)
 		router.GET("/hostdb/filtermode", api.hostdbFilterModeHandlerGET)
-		router.POST("/hostdb/filtermode", RequirePassword(api.hostdbFilterModeHandlerPOST, requiredPassword))
+		router.POST("/hostdb/filtermode", RequireScope(api.hostdbFilterModeHandlerPOST, requiredPassword, tokens, modules.APIScopeRenter))
 
 		// Renter watchdog endpoints.
 		router.GET("/renter/contractstatus", api.renterContractStatusHandler)
 
 		// Deprecated endpoints.
-		router.POST("/renter/backup", RequirePassword(api.renterBackupHandlerPOST, requiredPassword))
-		router.POST("/renter/recoverbackup", RequirePassword(api.renterLoadBackupHandlerPOST, requiredPassword))
+		router.POST("/renter/backup", RequireScope(api.renterBackupHandlerPOST, requiredPassword, tokens, modules.APIScopeRenter))
+		router.POST("/renter/recoverbackup", RequireScope(api.renterLoadBackupHandlerPOST, requiredPassword, tokens, modules.APIScopeRenter))
 		router.GET("/skynet/blacklist", api.skynetBlocklistHandlerGET)
-		router.POST("/skynet/blacklist", RequirePassword(api.skynetBlocklistHandlerPOST, requiredPassword))
+		router.POST("/skynet/blacklist", RequireScope(api.skynetBlocklistHandlerPOST, requiredPassword, tokens, modules.APIScopeAdmin))
 	}
 
 	// Transaction pool API Calls
@@ -208,33 +234,33 @@ func (api *API) buildHTTPRoutes() {
 	// Wallet API Calls
 	if api.wallet != nil {
 		router.GET("/wallet", api.walletHandler)
-		router.POST("/wallet/033x", RequirePassword(api.wallet033xHandler, requiredPassword))
-		router.GET("/wallet/address", RequirePassword(api.walletAddressHandler, requiredPassword))
+		router.POST("/wallet/033x", RequireScope(api.wallet033xHandler, requiredPassword, tokens, modules.APIScopeAdmin))
+		router.GET("/wallet/address", RequireScope(api.walletAddressHandler, requiredPassword, tokens, modules.APIScopeWalletSpend))
 		router.GET("/wallet/addresses", api.walletAddressesHandler)
 		router.GET("/wallet/seedaddrs", api.walletSeedAddressesHandler)
-		router.GET("/wallet/backup", RequirePassword(api.walletBackupHandler, requiredPassword))
-		router.POST("/wallet/init", RequirePassword(api.walletInitHandler, requiredPassword))
-		router.POST("/wallet/init/seed", RequirePassword(api.walletInitSeedHandler, requiredPassword))
-		router.POST("/wallet/lock", RequirePassword(api.walletLockHandler, requiredPassword))
-		router.POST("/wallet/seed", RequirePassword(api.walletSeedHandler, requiredPassword))
-		router.GET("/wallet/seeds", RequirePassword(api.walletSeedsHandler, requiredPassword))
-		router.POST("/wallet/ttdcs", RequirePassword(api.walletTurtleDexcoinsHandler, requiredPassword))
-		router.POST("/wallet/siafunds", RequirePassword(api.walletTurtleDexfundsHandler, requiredPassword))
-		router.POST("/wallet/siagkey", RequirePassword(api.walletTurtleDexgkeyHandler, requiredPassword))
-		router.POST("/wallet/sweep/seed", RequirePassword(api.walletSweepSeedHandler, requiredPassword))
+		router.GET("/wallet/backup", RequireScope(api.walletBackupHandler, requiredPassword, tokens, modules.APIScopeAdmin))
+		router.POST("/wallet/init", RequireScope(api.walletInitHandler, requiredPassword, tokens, modules.APIScopeAdmin))
+		router.POST("/wallet/init/seed", RequireScope(api.walletInitSeedHandler, requiredPassword, tokens, modules.APIScopeAdmin))
+		router.POST("/wallet/lock", RequireScope(api.walletLockHandler, requiredPassword, tokens, modules.APIScopeAdmin))
+		router.POST("/wallet/seed", RequireScope(api.walletSeedHandler, requiredPassword, tokens, modules.APIScopeAdmin))
+		router.GET("/wallet/seeds", RequireScope(api.walletSeedsHandler, requiredPassword, tokens, modules.APIScopeAdmin))
+		router.POST("/wallet/ttdcs", RequireScope(api.walletTurtleDexcoinsHandler, requiredPassword, tokens, modules.APIScopeWalletSpend))
+		router.POST("/wallet/siafunds", RequireScope(api.walletTurtleDexfundsHandler, requiredPassword, tokens, modules.APIScopeWalletSpend))
+		router.POST("/wallet/siagkey", RequireScope(api.walletTurtleDexgkeyHandler, requiredPassword, tokens, modules.APIScopeAdmin))
+		router.POST("/wallet/sweep/seed", RequireScope(api.walletSweepSeedHandler, requiredPassword, tokens, modules.APIScopeAdmin))
 		router.GET("/wallet/transaction/:id", api.walletTransactionHandler)
 		router.GET("/wallet/transactions", api.walletTransactionsHandler)
 		router.GET("/wallet/transactions/:addr", api.walletTransactionsAddrHandler)
 		router.GET("/wallet/verify/address/:addr", api.walletVerifyAddressHandler)
-		router.POST("/wallet/unlock", RequirePassword(api.walletUnlockHandler, requiredPassword))
-		router.POST("/wallet/changepassword", RequirePassword(api.walletChangePasswordHandler, requiredPassword))
-		router.GET("/wallet/verifypassword", RequirePassword(api.walletVerifyPasswordHandler, requiredPassword))
-		router.GET("/wallet/unlockconditions/:addr", RequirePassword(api.walletUnlockConditionsHandlerGET, requiredPassword))
-		router.POST("/wallet/unlockconditions", RequirePassword(api.walletUnlockConditionsHandlerPOST, requiredPassword))
-		router.GET("/wallet/unspent", RequirePassword(api.walletUnspentHandler, requiredPassword))
-		router.POST("/wallet/sign", RequirePassword(api.walletSignHandler, requiredPassword))
-		router.GET("/wallet/watch", RequirePassword(api.walletWatchHandlerGET, requiredPassword))
-		router.POST("/wallet/watch", RequirePassword(api.walletWatchHandlerPOST, requiredPassword))
+		router.POST("/wallet/unlock", RequireScope(api.walletUnlockHandler, requiredPassword, tokens, modules.APIScopeAdmin))
+		router.POST("/wallet/changepassword", RequireScope(api.walletChangePasswordHandler, requiredPassword, tokens, modules.APIScopeAdmin))
+		router.GET("/wallet/verifypassword", RequireScope(api.walletVerifyPasswordHandler, requiredPassword, tokens, modules.APIScopeAdmin))
+		router.GET("/wallet/unlockconditions/:addr", RequireScope(api.walletUnlockConditionsHandlerGET, requiredPassword, tokens, modules.APIScopeRead))
+		router.POST("/wallet/unlockconditions", RequireScope(api.walletUnlockConditionsHandlerPOST, requiredPassword, tokens, modules.APIScopeAdmin))
+		router.GET("/wallet/unspent", RequireScope(api.walletUnspentHandler, requiredPassword, tokens, modules.APIScopeRead))
+		router.POST("/wallet/sign", RequireScope(api.walletSignHandler, requiredPassword, tokens, modules.APIScopeWalletSpend))
+		router.GET("/wallet/watch", RequireScope(api.walletWatchHandlerGET, requiredPassword, tokens, modules.APIScopeRead))
+		router.POST("/wallet/watch", RequireScope(api.walletWatchHandlerPOST, requiredPassword, tokens, modules.APIScopeAdmin))
 	}
 
 	// Apply UserAgent middleware and return the Router
@@ -243,8 +269,18 @@ func (api *API) buildHTTPRoutes() {
 	if err != nil {
 		build.Critical("marshalling error on object that should be safe to marshal:", err)
 	}
+	handler := RequireUserAgent(router, requiredUserAgent)
+	timeoutHandler := http.TimeoutHandler(handler, httpServerTimeout, string(jsonErr))
 	api.routerMu.Lock()
-	api.router = http.TimeoutHandler(RequireUserAgent(router, requiredUserAgent), httpServerTimeout, string(jsonErr))
+	api.router = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		// The timeout handler buffers the response, streaming calls need to
+		// bypass it.
+		if isStreaming(req) {
+			handler.ServeHTTP(w, req)
+			return
+		}
+		timeoutHandler.ServeHTTP(w, req)
+	})
 	api.routerMu.Unlock()
 	return
 }
@@ -265,13 +301,23 @@ func RequireUserAgent(h http.Handler, ua string) http.Handler {
 // password using HTTP basic auth. Usernames are ignored. Empty passwords
 // indicate no authentication is required.
 func RequirePassword(h httprouter.Handle, password string) httprouter.Handle {
+	return RequireScope(h, password, nil, modules.APIScopeAdmin)
+}
+
+// RequireScope is middleware that requires a request to authenticate with
+// either the password or an API token which grants access to the scope using
+// HTTP basic auth. Usernames are ignored. Empty passwords indicate no
+// authentication is required. In that case API tokens are not checked at all,
+// which is why they can't be created without an API password.
+func RequireScope(h httprouter.Handle, password string, tokens *modules.APITokens, scope modules.APIScope) httprouter.Handle {
 	// An empty password is equivalent to no password.
 	if password == "" {
 		return h
 	}
 	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		_, pass, ok := req.BasicAuth()
-		if !ok || pass != password {
+		authorized := ok && (pass == password || (tokens != nil && tokens.Authorize(pass, scope)))
+		if !authorized {
 			w.Header().Set("WWW-Authenticate", "Basic realm=\"TurtleDexAPI\"")
 			WriteError(w, Error{"API authentication failed."}, http.StatusUnauthorized)
 			return
@@ -280,6 +326,12 @@ func RequirePassword(h httprouter.Handle, password string) httprouter.Handle {
 	}
 }
 
+// isStreaming checks if a request streams its response to the client until
+// the client disconnects.
+func isStreaming(req *http.Request) bool {
+	return req.URL.Path == "/skynet/registry/subscription"
+}
+
 // isUnrestricted checks if a request may bypass the useragent check.
 func isUnrestricted(req *http.Request) bool {
 	return strings.HasPrefix(req.URL.Path, "/renter/stream/") || strings.HasPrefix(req.URL.Path, "/skynet/skylink")
@@ -22,6 +22,8 @@ import (
 	"github.com/turtledex/TurtleDexCore/modules"
 	"github.com/turtledex/TurtleDexCore/node"
 	"github.com/turtledex/TurtleDexCore/node/api"
+	"github.com/turtledex/TurtleDexCore/node/s3gateway"
+	"github.com/turtledex/TurtleDexCore/node/webdav"
 	"github.com/turtledex/TurtleDexCore/types"
 )
 
@@ -32,7 +34,9 @@ type Server struct {
 	apiServer         *http.Server
 	listener          net.Listener
 	node              *node.Node
+	requiredPassword  string
 	requiredUserAgent string
+	tokens            *modules.APITokens
 	Dir               string
 
 	serveChan chan struct{}
@@ -40,6 +44,15 @@ type Server struct {
 
 	closeChan chan struct{}
 
+	// s3Gateway and s3Server are only set if the S3 gateway was started
+	// using ServeS3.
+	s3Gateway *s3gateway.Gateway
+	s3Server  *http.Server
+
+	// webDAVServer is only set if the WebDAV server was started using
+	// ServeWebDAV.
+	webDAVServer *http.Server
+
 	closeMu sync.Mutex
 }
 
@@ -67,6 +80,15 @@ func (srv *Server) Close() error {
 	if !errors.Contains(srv.serveErr, http.ErrServerClosed) {
 		err = errors.Compose(err, srv.serveErr)
 	}
+	// Stop the S3 gateway before the renter is closed.
+	if srv.s3Server != nil {
+		err = errors.Compose(err, srv.s3Server.Shutdown(context.Background()))
+		err = errors.Compose(err, srv.s3Gateway.Close())
+	}
+	// Stop the WebDAV server before the renter is closed.
+	if srv.webDAVServer != nil {
+		err = errors.Compose(err, srv.webDAVServer.Shutdown(context.Background()))
+	}
 	// Shutdown modules.
 	if srv.node != nil {
 		err = errors.Compose(err, srv.node.Close())
@@ -116,6 +138,66 @@ func (srv *Server) RenterSettings() (modules.RenterSettings, error) {
 	return srv.node.Renter.Settings()
 }
 
+// ServeS3 starts an S3 gateway for the node's renter which listens on the
+// provided address. It returns an error if the node has no renter.
+func (srv *Server) ServeS3(addr string, creds s3gateway.Credentials) error {
+	srv.closeMu.Lock()
+	defer srv.closeMu.Unlock()
+	if srv.node.Renter == nil {
+		return errors.New("can't start s3 gateway for a non-renter node")
+	}
+	if srv.s3Server != nil {
+		return errors.New("s3 gateway is already running")
+	}
+	g, err := s3gateway.New(srv.node.Renter, creds, filepath.Join(srv.Dir, "s3gateway"))
+	if err != nil {
+		return errors.AddContext(err, "failed to create s3 gateway")
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.AddContext(err, "failed to listen on s3 address")
+	}
+	srv.s3Gateway = g
+	srv.s3Server = &http.Server{Handler: g}
+	go func() {
+		err := srv.s3Server.Serve(l)
+		if err != nil && !errors.Contains(err, http.ErrServerClosed) {
+			fmt.Println("s3 gateway stopped:", err)
+		}
+	}()
+	return nil
+}
+
+// ServeWebDAV starts a WebDAV server for the node's renter which listens on the
+// provided address. It accepts the same password and API tokens as the API and
+// returns an error if the node has no renter.
+func (srv *Server) ServeWebDAV(addr string) error {
+	srv.closeMu.Lock()
+	defer srv.closeMu.Unlock()
+	if srv.node.Renter == nil {
+		return errors.New("can't start webdav server for a non-renter node")
+	}
+	if srv.webDAVServer != nil {
+		return errors.New("webdav server is already running")
+	}
+	h, err := webdav.New(srv.node.Renter, srv.requiredPassword, srv.tokens)
+	if err != nil {
+		return errors.AddContext(err, "failed to create webdav server")
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.AddContext(err, "failed to listen on webdav address")
+	}
+	srv.webDAVServer = &http.Server{Handler: h}
+	go func() {
+		err := srv.webDAVServer.Serve(l)
+		if err != nil && !errors.Contains(err, http.ErrServerClosed) {
+			fmt.Println("webdav server stopped:", err)
+		}
+	}()
+	return nil
+}
+
 // ServeErr is a blocking call that will return the result of srv.serve after
 // the server stopped.
 func (srv *Server) ServeErr() <-chan error {
@@ -174,8 +256,14 @@ func NewAsync(APIaddr string, requiredUserAgent string, requiredPassword string,
 			return nil, errors.AddContext(err, "failed to load ttdxd config")
 		}
 
+		// Load the API tokens.
+		tokens, err := modules.NewAPITokens(filepath.Join(nodeParams.Dir, modules.APITokensName))
+		if err != nil {
+			return nil, errors.AddContext(err, "failed to load API tokens")
+		}
+
 		// Create the api for the server.
-		api := api.New(cfg, requiredUserAgent, requiredPassword, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+		api := api.New(cfg, tokens, requiredUserAgent, requiredPassword, nil, nil, nil, nil, nil, nil, nil, nil, nil)
 		srv := &Server{
 			api: api,
 			apiServer: &http.Server{
@@ -201,7 +289,9 @@ func NewAsync(APIaddr string, requiredUserAgent string, requiredPassword string,
 			closeChan:         make(chan struct{}),
 			serveChan:         make(chan struct{}),
 			listener:          listener,
+			requiredPassword:  requiredPassword,
 			requiredUserAgent: requiredUserAgent,
+			tokens:            tokens,
 			Dir:               nodeParams.Dir,
 		}
 
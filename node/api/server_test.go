@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"testing"
 
 	"github.com/turtledex/TurtleDexCore/modules"
@@ -190,3 +191,113 @@ func TestAuthentication(t *testing.T) {
 		t.Fatal("authenticated API call failed with the correct password")
 	}
 }
+
+// TestAuthenticationTokens tests that API tokens only grant access to the
+// endpoints of their scopes.
+func TestAuthenticationTokens(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createAuthenticatedServerTester(t.Name(), "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	// Create a renter, a wallet-spend and an admin token.
+	tokens := st.server.api.staticTokens
+	_, renterToken, err := tokens.Create("renter", []modules.APIScope{modules.APIScopeRenter})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wallet, walletToken, err := tokens.Create("wallet", []modules.APIScope{modules.APIScopeWalletSpend})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, adminToken, err := tokens.Create("admin", []modules.APIScope{modules.APIScopeAdmin})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := "http://" + st.server.listener.Addr().String()
+	walletURL := addr + "/wallet/ttdcs"
+	tokensURL := addr + "/daemon/tokens"
+
+	// A renter token can't spend coins.
+	resp, err := HttpPOSTAuthenticated(walletURL, "", renterToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatal("renter token was able to access /wallet/ttdcs", resp.StatusCode)
+	}
+	// A wallet-spend token is authorized. The request still fails since it
+	// is missing the amount and destination.
+	resp, err = HttpPOSTAuthenticated(walletURL, "", walletToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		t.Fatal("wallet-spend token wasn't able to access /wallet/ttdcs")
+	}
+
+	// Listing the tokens requires the admin scope.
+	for _, token := range []string{renterToken, walletToken} {
+		resp, err = HttpGETAuthenticated(tokensURL, token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatal("non-admin token was able to access /daemon/tokens", resp.StatusCode)
+		}
+	}
+	resp, err = HttpGETAuthenticated(tokensURL, adminToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if non2xx(resp.StatusCode) {
+		t.Fatal("admin token wasn't able to access /daemon/tokens", resp.StatusCode)
+	}
+
+	// Revoked tokens are rejected.
+	resp, err = HttpPOSTAuthenticated(tokensURL+"/revoke", "id="+wallet.ID, adminToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if non2xx(resp.StatusCode) {
+		t.Fatal("unable to revoke token", resp.StatusCode)
+	}
+	resp, err = HttpPOSTAuthenticated(walletURL, "", walletToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatal("revoked token was able to access /wallet/ttdcs", resp.StatusCode)
+	}
+}
+
+// TestAuthenticationTokensNoPassword tests that API tokens can't be created if
+// the API doesn't require a password.
+func TestAuthenticationTokensNoPassword(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	values := url.Values{}
+	values.Set("name", "renter")
+	values.Set("scopes", string(modules.APIScopeRenter))
+	err = st.stdPostAPI("/daemon/tokens/create", values)
+	if err == nil {
+		t.Fatal("expected token creation to fail without an API password")
+	}
+	if len(st.server.api.staticTokens.Tokens()) != 0 {
+		t.Fatal("token was created")
+	}
+}
@@ -61,6 +61,15 @@ var (
 	// is able to spend on faster workers when downloading a Skyfile. By default
 	// this is a sane default of 100 nS.
 	DefaultSkynetPricePerMS = types.TurtleDexcoinPrecision.MulFloat(1e-7) // 100 nS
+
+	// registrySubscriptionKeepalive is the interval at which a comment is sent
+	// to clients of the registry subscription endpoint to keep idle
+	// connections from timing out.
+	registrySubscriptionKeepalive = build.Select(build.Var{
+		Dev:      15 * time.Second,
+		Standard: 30 * time.Second,
+		Testing:  time.Second,
+	}).(time.Duration)
 )
 
 type (
@@ -107,6 +116,33 @@ type (
 		Remove []modules.NetAddress   `json:"remove"`
 	}
 
+	// SkynetDirectoryListing is the listing of a directory within a skyfile
+	// which is returned by the /skynet/skylink GET endpoint for the 'json'
+	// format.
+	SkynetDirectoryListing struct {
+		Path    string                 `json:"path"`
+		Entries []SkynetDirectoryEntry `json:"entries"`
+	}
+
+	// SkynetDirectoryEntry is a file or directory within a directory listing.
+	// The path is the absolute path within the skyfile and the link is
+	// relative to the listed directory. The size of a directory is the total
+	// size of the files within it.
+	SkynetDirectoryEntry struct {
+		Name        string `json:"name"`
+		Path        string `json:"path"`
+		Link        string `json:"link"`
+		IsDir       bool   `json:"isdir"`
+		Size        uint64 `json:"size"`
+		ContentType string `json:"contenttype,omitempty"`
+	}
+
+	// SkynetPinsGET contains the information queried for the /skynet/pins GET
+	// endpoint.
+	SkynetPinsGET struct {
+		Pins []modules.SkynetPinStatus `json:"pins"`
+	}
+
 	// SkynetRestorePOST is the response that the api returns after the
 	// /skynet/restore POST endpoint has been used.
 	SkynetRestorePOST struct {
@@ -116,7 +152,8 @@ type (
 	// SkynetStatsGET contains the information queried for the /skynet/stats
 	// GET endpoint
 	SkynetStatsGET struct {
-		PerformanceStats modules.SkynetPerformanceStats `json:"performancestats"`
+		PerformanceStats  modules.SkynetPerformanceStats `json:"performancestats"`
+		SectorCacheStatus modules.SectorCacheStatus      `json:"sectorcachestatus"`
 
 		Uptime      int64               `json:"uptime"`
 		UploadStats modules.SkynetStats `json:"uploadstats"`
@@ -150,6 +187,22 @@ type (
 		Signature string `json:"signature"`
 	}
 
+	// RegistrySkylinkV2GET is the response returned by the
+	// registrySkylinkV2HandlerGET handler.
+	RegistrySkylinkV2GET struct {
+		Skylink string `json:"skylink"`
+	}
+
+	// RegistrySubscriptionNotification is the data sent for every update event
+	// of the /skynet/registry/subscription [GET] endpoint.
+	RegistrySubscriptionNotification struct {
+		PublicKey types.TurtleDexPublicKey `json:"publickey"`
+		DataKey   crypto.Hash              `json:"datakey"`
+		Data      string                   `json:"data"`
+		Revision  uint64                   `json:"revision"`
+		Signature string                   `json:"signature"`
+	}
+
 	// RegistryHandlerRequestPOST is the expected format of the json request for
 	// /skynet/registry [POST].
 	RegistryHandlerRequestPOST struct {
@@ -407,6 +460,94 @@ func (api *API) skynetPortalsHandlerPOST(w http.ResponseWriter, req *http.Reques
 	WriteSuccess(w)
 }
 
+// skynetPinsHandlerGET handles the API call to get the status of the skylinks
+// kept pinned by the renter.
+func (api *API) skynetPinsHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	pins, err := api.renter.SkynetPins()
+	if err != nil {
+		WriteError(w, Error{"unable to get the skynet pins: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, SkynetPinsGET{
+		Pins: pins,
+	})
+}
+
+// skynetPinsAddHandlerPOST handles the API call to keep a skylink pinned.
+func (api *API) skynetPinsAddHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var skylink modules.Skylink
+	err := skylink.LoadString(req.FormValue("skylink"))
+	if err != nil {
+		WriteError(w, Error{fmt.Sprintf("error parsing skylink: %v", err)}, http.StatusBadRequest)
+		return
+	}
+
+	// Parse whether the siapath should be from root or from the skynet folder.
+	var root bool
+	if rootStr := req.FormValue("root"); rootStr != "" {
+		root, err = strconv.ParseBool(rootStr)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'root' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Parse the siapath. If none is provided, the renter picks one.
+	var siaPath modules.TurtleDexPath
+	if siaPathStr := req.FormValue("siapath"); siaPathStr != "" {
+		if root {
+			siaPath, err = modules.NewTurtleDexPath(siaPathStr)
+		} else {
+			siaPath, err = modules.SkynetFolder.Join(siaPathStr)
+		}
+		if err != nil {
+			WriteError(w, Error{"invalid siapath provided: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Parse the redundancy.
+	redundancy := uint8(0)
+	if rStr := req.FormValue("basechunkredundancy"); rStr != "" {
+		if _, err := fmt.Sscan(rStr, &redundancy); err != nil {
+			WriteError(w, Error{"unable to parse basechunkredundancy: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	err = api.renter.AddSkynetPin(modules.SkynetPin{
+		Skylink:             skylink.String(),
+		Label:               req.FormValue("label"),
+		TurtleDexPath:       siaPath,
+		BaseChunkRedundancy: redundancy,
+	})
+	if errors.Contains(err, renter.ErrSkylinkBlocked) {
+		WriteError(w, Error{err.Error()}, http.StatusUnavailableForLegalReasons)
+		return
+	} else if err != nil {
+		WriteError(w, Error{"unable to add skynet pin: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// skynetPinsRemoveHandlerPOST handles the API call to stop keeping a skylink
+// pinned. The skylink is unpinned as well.
+func (api *API) skynetPinsRemoveHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var skylink modules.Skylink
+	err := skylink.LoadString(req.FormValue("skylink"))
+	if err != nil {
+		WriteError(w, Error{fmt.Sprintf("error parsing skylink: %v", err)}, http.StatusBadRequest)
+		return
+	}
+	err = api.renter.RemoveSkynetPin(skylink)
+	if err != nil {
+		WriteError(w, Error{"unable to remove skynet pin: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // skynetRootHandlerGET handles the api call for a download by root request.
 // This call returns the encoded sector.
 func (api *API) skynetRootHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
@@ -596,8 +737,10 @@ func (api *API) skynetSkylinkHandlerGET(w http.ResponseWriter, req *http.Request
 	case modules.SkyfileFormatTar:
 	case modules.SkyfileFormatTarGz:
 	case modules.SkyfileFormatZip:
+	case modules.SkyfileFormatHTML:
+	case modules.SkyfileFormatJSON:
 	default:
-		WriteError(w, Error{"unable to parse 'format' parameter, allowed values are: 'concat', 'tar', 'targz' and 'zip'"}, http.StatusBadRequest)
+		WriteError(w, Error{"unable to parse 'format' parameter, allowed values are: 'concat', 'tar', 'targz', 'zip', 'html' and 'json'"}, http.StatusBadRequest)
 		return
 	}
 
@@ -656,6 +799,23 @@ func (api *API) skynetSkylinkHandlerGET(w http.ResponseWriter, req *http.Request
 		}
 	}
 
+	// Resolve the skylink in case it's a v2 skylink. From here on we use the
+	// resolved skylink since the ETag and the performance stats need to be
+	// based on the skylink of the actual content.
+	skylink, err = api.renter.ResolveSkylinkV2(skylink, timeout)
+	if errors.Contains(err, renter.ErrSkylinkBlocked) {
+		WriteError(w, Error{err.Error()}, http.StatusUnavailableForLegalReasons)
+		return
+	}
+	if errors.Contains(err, renter.ErrRootNotFound) {
+		WriteError(w, Error{fmt.Sprintf("failed to resolve skylink: %v", err)}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{fmt.Sprintf("failed to resolve skylink: %v", err)}, http.StatusInternalServerError)
+		return
+	}
+
 	// Fetch the skyfile's metadata and a streamer to download the file
 	layout, metadata, streamer, err := api.renter.DownloadSkylink(skylink, timeout, pricePerMS)
 	if errors.Contains(err, renter.ErrSkylinkBlocked) {
@@ -703,8 +863,9 @@ func (api *API) skynetSkylinkHandlerGET(w http.ResponseWriter, req *http.Request
 		}
 	}
 
-	var isSubfile bool
+	var isSubfile, isNotFound bool
 	responseContentType := metadata.ContentType()
+	customHeaders := metadata.Headers
 
 	// Serve the contents of the file at the default path if one is set. Note
 	// that we return the metadata for the entire Skylink when we serve the
@@ -759,7 +920,19 @@ func (api *API) skynetSkylinkHandlerGET(w http.ResponseWriter, req *http.Request
 	// Serve the contents of the skyfile at path if one is set
 	if path != "/" {
 		metadataForPath, file, offset, size := metadata.ForPath(path)
-		if len(metadataForPath.Subfiles) == 0 {
+		// If nothing exists at the path, fall back to the skyfile's try files
+		// and not found path. Those only apply when serving files directly.
+		if len(metadataForPath.Subfiles) == 0 && format == modules.SkyfileFormatNotSpecified {
+			if tryFilesPath, ok := metadata.TryFilesPath(path); ok {
+				metadataForPath, file, offset, size = metadata.ForPath(tryFilesPath)
+				responseContentType = metadataForPath.ContentType()
+			} else if metadata.NotFoundPath != "" {
+				metadataForPath, file, offset, size = metadata.ForPath(metadata.NotFoundPath)
+				responseContentType = metadataForPath.ContentType()
+				isNotFound = true
+			}
+		}
+		if len(metadataForPath.Subfiles) == 0 || (isNotFound && !file) {
 			WriteError(w, Error{fmt.Sprintf("failed to download contents for path: %v", path)}, http.StatusNotFound)
 			return
 		}
@@ -773,10 +946,34 @@ func (api *API) skynetSkylinkHandlerGET(w http.ResponseWriter, req *http.Request
 		isSubfile = file
 	}
 
-	// If we are serving more than one file, and the format is not
-	// specified, default to downloading it as a zip archive.
-	if !isSubfile && metadata.IsDirectory() && format == modules.SkyfileFormatNotSpecified {
-		format = modules.SkyfileFormatZip
+	// Directory listings can only be served for directories.
+	isDirectory := !isSubfile && metadata.IsDirectory()
+	if format.IsListing() && !isDirectory {
+		WriteError(w, Error{fmt.Sprintf("unable to serve a directory listing for path: %v, it is not a directory", path)}, http.StatusBadRequest)
+		return
+	}
+
+	// If we are serving more than one file, and the format is not specified,
+	// serve a directory listing if the client accepts one. Otherwise default
+	// to downloading it as a zip archive.
+	if isDirectory && format == modules.SkyfileFormatNotSpecified {
+		w.Header().Add("Vary", "Accept")
+		format = listingFormatFromAccept(req.Header.Get("Accept"))
+		if format == modules.SkyfileFormatNotSpecified {
+			format = modules.SkyfileFormatZip
+		}
+	}
+
+	// The links of an HTML listing are relative to the directory. Just like
+	// for skapps we need to redirect in order to add the trailing slash.
+	if format == modules.SkyfileFormatHTML && req.Method == http.MethodGet && !strings.HasSuffix(skylinkStringNoQuery, "/") {
+		location := "./" + skylinkStringNoQuery[strings.LastIndex(skylinkStringNoQuery, "/")+1:] + "/"
+		if req.URL.RawQuery != "" {
+			location += "?" + req.URL.RawQuery
+		}
+		w.Header().Set("Location", location)
+		w.WriteHeader(http.StatusTemporaryRedirect)
+		return
 	}
 
 	// Encode the metadata
@@ -821,6 +1018,16 @@ func (api *API) skynetSkylinkHandlerGET(w http.ResponseWriter, req *http.Request
 
 	// Set the common Header fields
 	//
+	// Set the skyfile's custom headers first. They were validated on upload
+	// to not collide with any of the headers set below. Skyfiles which were
+	// uploaded by other nodes might not have been validated, so headers which
+	// aren't allowed are skipped.
+	for name, value := range customHeaders {
+		if modules.IsAllowedSkyfileHeader(name) {
+			w.Header().Set(name, value)
+		}
+	}
+
 	// Set the Skylink response header
 	w.Header().Set("Skynet-Skylink", skylink.String())
 
@@ -835,7 +1042,9 @@ func (api *API) skynetSkylinkHandlerGET(w http.ResponseWriter, req *http.Request
 	// Set an appropriate Content-Disposition header
 	var cdh string
 	filename := filepath.Base(metadata.Filename)
-	if format.IsArchive() {
+	if format.IsListing() {
+		cdh = "inline"
+	} else if format.IsArchive() {
 		cdh = fmt.Sprintf("attachment; filename=%s", strconv.Quote(filename+format.Extension()))
 	} else if attachment {
 		cdh = fmt.Sprintf("attachment; filename=%s", strconv.Quote(filename))
@@ -850,6 +1059,21 @@ func (api *API) skynetSkylinkHandlerGET(w http.ResponseWriter, req *http.Request
 		w.Header().Set("Skynet-File-Metadata", string(encMetadata))
 	}
 
+	// If requested, serve a listing of the directory.
+	if format.IsListing() {
+		listing := buildDirectoryListing(path, metadata.Subfiles)
+		if format == modules.SkyfileFormatJSON {
+			WriteJSON(w, listing)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		err = skynetDirectoryListingTemplate.Execute(w, listing)
+		if err != nil {
+			WriteError(w, Error{fmt.Sprintf("failed to serve directory listing: %v", err)}, http.StatusInternalServerError)
+		}
+		return
+	}
+
 	// If requested, serve the content as a tar archive, compressed tar
 	// archive or zip archive.
 	if format == modules.SkyfileFormatTar {
@@ -886,6 +1110,14 @@ func (api *API) skynetSkylinkHandlerGET(w http.ResponseWriter, req *http.Request
 		w.Header().Set("Content-Type", responseContentType)
 	}
 
+	// Serve the skyfile's not found page with a 404 status. Ranges and
+	// conditional requests don't apply to error pages which is why
+	// http.ServeContent is not used.
+	if isNotFound {
+		serveNotFoundPage(w, req, metadata, streamer)
+		return
+	}
+
 	http.ServeContent(w, req, metadata.Filename, time.Time{}, streamer)
 }
 
@@ -1037,6 +1269,7 @@ func (api *API) skynetSkyfileHandlerPOST(w http.ResponseWriter, req *http.Reques
 	// build the upload parameters
 	sup := modules.SkyfileUploadParameters{
 		BaseChunkRedundancy: params.baseChunkRedundancy,
+		Compression:         params.compression,
 		DryRun:              params.dryRun,
 		Force:               params.force,
 		TurtleDexPath:             params.siaPath,
@@ -1049,9 +1282,15 @@ func (api *API) skynetSkyfileHandlerPOST(w http.ResponseWriter, req *http.Reques
 		DefaultPath:        params.defaultPath,
 		DisableDefaultPath: params.disableDefaultPath,
 
+		// Set the serving params
+		Headers:      params.headers,
+		TryFiles:     params.tryFiles,
+		NotFoundPath: params.notFoundPath,
+
 		// Set encryption key details
-		SkykeyName: params.skyKeyName,
-		SkykeyID:   params.skyKeyID,
+		SkykeyName:       params.skyKeyName,
+		SkykeyID:         params.skyKeyID,
+		SkykeyRecipients: params.recipients,
 	}
 
 	// set the reader
@@ -1207,11 +1446,16 @@ func (api *API) skynetStatsHandlerGET(w http.ResponseWriter, req *http.Request,
 	perfStats := skynetPerformanceStats.Copy()
 	skynetPerformanceStatsMu.Unlock()
 
+	// Grab the sector cache stats. If there is an error we just return null
+	// stats.
+	sectorCacheStatus, _ := api.renter.SectorCacheStatus()
+
 	// Grab the ttdxd uptime
 	uptime := time.Since(api.StartTime()).Seconds()
 
 	WriteJSON(w, &SkynetStatsGET{
-		PerformanceStats: perfStats,
+		PerformanceStats:  perfStats,
+		SectorCacheStatus: sectorCacheStatus,
 
 		Uptime:      int64(uptime),
 		UploadStats: stats,
@@ -1441,6 +1685,33 @@ func (api *API) registryHandlerPOST(w http.ResponseWriter, req *http.Request, _
 	WriteSuccess(w)
 }
 
+// registrySkylinkV2HandlerGET handles the GET calls to
+// /skynet/registry/skylinkv2. It returns the v2 skylink which points to the
+// registry entry of the provided public key and data key. The skylink resolves
+// to the v1 skylink stored in that entry which can be updated using the
+// /skynet/registry [POST] endpoint.
+func (api *API) registrySkylinkV2HandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Parse public key
+	var spk types.TurtleDexPublicKey
+	err := spk.LoadString(req.FormValue("publickey"))
+	if err != nil {
+		WriteError(w, Error{"Unable to parse publickey param: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	// Parse datakey.
+	var dataKey crypto.Hash
+	err = dataKey.LoadString(req.FormValue("datakey"))
+	if err != nil {
+		WriteError(w, Error{"Unable to decode dataKey param: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	WriteJSON(w, RegistrySkylinkV2GET{
+		Skylink: modules.NewSkylinkV2(spk, dataKey).String(),
+	})
+}
+
 // registryHandlerGET handles the GET calls to /skynet/registry.
 func (api *API) registryHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	// Grab a start time for the registry read stats.
@@ -1503,6 +1774,104 @@ func (api *API) registryHandlerGET(w http.ResponseWriter, req *http.Request, _ h
 	})
 }
 
+// registrySubscriptionHandlerGET handles the GET calls to
+// /skynet/registry/subscription. It subscribes to the entries specified by the
+// publickey and datakey params and streams updates to the client as
+// server-sent events until the client disconnects.
+func (api *API) registrySubscriptionHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Parse the entries. The n-th publickey belongs to the n-th datakey.
+	if err := req.ParseForm(); err != nil {
+		WriteError(w, Error{"Unable to parse form: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	pubKeyStrs := req.Form["publickey"]
+	dataKeyStrs := req.Form["datakey"]
+	if len(pubKeyStrs) == 0 {
+		WriteError(w, Error{"At least one publickey and datakey are required"}, http.StatusBadRequest)
+		return
+	}
+	if len(pubKeyStrs) != len(dataKeyStrs) {
+		WriteError(w, Error{fmt.Sprintf("Number of publickey params (%v) doesn't match number of datakey params (%v)", len(pubKeyStrs), len(dataKeyStrs))}, http.StatusBadRequest)
+		return
+	}
+	spks := make([]types.TurtleDexPublicKey, len(pubKeyStrs))
+	dataKeys := make([]crypto.Hash, len(dataKeyStrs))
+	for i := range pubKeyStrs {
+		err := spks[i].LoadString(pubKeyStrs[i])
+		if err != nil {
+			WriteError(w, Error{"Unable to parse publickey param: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		err = dataKeys[i].LoadString(dataKeyStrs[i])
+		if err != nil {
+			WriteError(w, Error{"Unable to decode datakey param: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		WriteError(w, Error{"Streaming is not supported by the connection"}, http.StatusInternalServerError)
+		return
+	}
+
+	// Subscribe to the entries.
+	subscriber, err := api.renter.NewRegistrySubscriber()
+	if err != nil {
+		WriteError(w, Error{"Unable to create subscriber: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	defer func() {
+		_ = subscriber.Close()
+	}()
+	for i := range spks {
+		err = subscriber.Subscribe(spks[i], dataKeys[i])
+		if err != nil {
+			WriteError(w, Error{"Unable to subscribe: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+	}
+
+	// Stream the notifications.
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepalive := time.NewTicker(registrySubscriptionKeepalive)
+	defer keepalive.Stop()
+	for {
+		var err error
+		select {
+		case <-req.Context().Done():
+			return
+		case <-keepalive.C:
+			_, err = fmt.Fprint(w, ": keepalive\n\n")
+		case update, ok := <-subscriber.Notifications():
+			if !ok {
+				return
+			}
+			var data []byte
+			data, err = json.Marshal(RegistrySubscriptionNotification{
+				PublicKey: update.PubKey,
+				DataKey:   update.Entry.Tweak,
+				Data:      hex.EncodeToString(update.Entry.Data),
+				Revision:  update.Entry.Revision,
+				Signature: hex.EncodeToString(update.Entry.Signature[:]),
+			})
+			if err != nil {
+				build.Critical("failed to marshal notification", err)
+				return
+			}
+			_, err = fmt.Fprintf(w, "event: update\ndata: %s\n\n", data)
+		}
+		if err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
+
 // skynetRestoreHandlerPOST handles the POST calls to /skynet/restore.
 func (api *API) skynetRestoreHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	// Restore Skyfile
@@ -0,0 +1,285 @@
+package api
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/turtledex/fastrand"
+
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+type (
+	// subscriptionTestRenter is a renter which only supports creating
+	// registry subscribers. All subscribers share the same notification
+	// channel.
+	subscriptionTestRenter struct {
+		modules.Renter
+		subscriber *subscriptionTestSubscriber
+	}
+
+	// listingTestRenter is a renter which serves a single skyfile for every
+	// skylink.
+	listingTestRenter struct {
+		modules.Renter
+		metadata modules.SkyfileMetadata
+		data     []byte
+	}
+
+	// subscriptionTestSubscriber is a registry subscriber which records the
+	// entries it is subscribed to.
+	subscriptionTestSubscriber struct {
+		subscribed    chan modules.SubscriptionID
+		notifications chan modules.RPCRegistrySubscriptionNotificationEntryUpdate
+	}
+)
+
+// DownloadSkylink returns the skyfile of the renter.
+func (r *listingTestRenter) DownloadSkylink(modules.Skylink, time.Duration, types.Currency) (modules.SkyfileLayout, modules.SkyfileMetadata, modules.Streamer, error) {
+	return modules.SkyfileLayout{}, r.metadata, streamerFromSlice(r.data), nil
+}
+
+// ResolveSkylinkV2 returns the skylink unchanged.
+func (r *listingTestRenter) ResolveSkylinkV2(link modules.Skylink, _ time.Duration) (modules.Skylink, error) {
+	return link, nil
+}
+
+// NewRegistrySubscriber returns the subscriber of the renter.
+func (r *subscriptionTestRenter) NewRegistrySubscriber() (modules.RegistrySubscriber, error) {
+	return r.subscriber, nil
+}
+
+// Subscribe records the subscription.
+func (s *subscriptionTestSubscriber) Subscribe(spk types.TurtleDexPublicKey, tweak crypto.Hash) error {
+	s.subscribed <- modules.RegistrySubscriptionID(spk, tweak)
+	return nil
+}
+
+// Unsubscribe does nothing.
+func (s *subscriptionTestSubscriber) Unsubscribe(types.TurtleDexPublicKey, crypto.Hash) {}
+
+// Notifications returns the notification channel.
+func (s *subscriptionTestSubscriber) Notifications() <-chan modules.RPCRegistrySubscriptionNotificationEntryUpdate {
+	return s.notifications
+}
+
+// Close does nothing.
+func (s *subscriptionTestSubscriber) Close() error { return nil }
+
+// TestRegistrySubscriptionHandler tests that updates of registry entries are
+// streamed to clients of /skynet/registry/subscription.
+func TestRegistrySubscriptionHandler(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	subscriber := &subscriptionTestSubscriber{
+		subscribed:    make(chan modules.SubscriptionID, 1),
+		notifications: make(chan modules.RPCRegistrySubscriptionNotificationEntryUpdate),
+	}
+	renter := &subscriptionTestRenter{subscriber: subscriber}
+	api := New(nil, nil, "TurtleDex-Agent", "", nil, nil, nil, nil, nil, nil, renter, nil, nil)
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	// Subscribe to an entry.
+	_, pk := crypto.GenerateKeyPair()
+	spk := types.Ed25519PublicKey(pk)
+	var dataKey crypto.Hash
+	fastrand.Read(dataKey[:])
+	values := url.Values{}
+	values.Set("publickey", spk.String())
+	values.Set("datakey", dataKey.String())
+	req, err := http.NewRequest("GET", server.URL+"/skynet/registry/subscription?"+values.Encode(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("User-Agent", "TurtleDex-Agent")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("unexpected status", resp.Status)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatal("unexpected content type", ct)
+	}
+	if id := <-subscriber.subscribed; id != modules.RegistrySubscriptionID(spk, dataKey) {
+		t.Fatal("subscribed to wrong entry")
+	}
+
+	// Update the entry. The update should be streamed to the client.
+	var sig crypto.Signature
+	fastrand.Read(sig[:])
+	srv := modules.NewSignedRegistryValue(dataKey, fastrand.Bytes(10), 1, sig)
+	subscriber.notifications <- modules.RPCRegistrySubscriptionNotificationEntryUpdate{
+		Entry:  srv,
+		PubKey: spk,
+	}
+	scanner := bufio.NewScanner(resp.Body)
+	var event, data string
+	for (event == "" || data == "") && scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "event: ") {
+			event = strings.TrimPrefix(line, "event: ")
+		} else if strings.HasPrefix(line, "data: ") {
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if event != "update" {
+		t.Fatal("unexpected event", event)
+	}
+	var rsn RegistrySubscriptionNotification
+	if err := json.Unmarshal([]byte(data), &rsn); err != nil {
+		t.Fatal(err)
+	}
+	if rsn.PublicKey.String() != spk.String() || rsn.DataKey != dataKey || rsn.Revision != srv.Revision {
+		t.Fatal("unexpected notification", rsn)
+	}
+	if rsn.Data != hex.EncodeToString(srv.Data) || rsn.Signature != hex.EncodeToString(srv.Signature[:]) {
+		t.Fatal("unexpected notification", rsn)
+	}
+}
+
+// TestSkylinkDirectoryListingHandler tests that directories of skyfiles are
+// served as listings depending on the Accept header and that HTML listings are
+// redirected to the URL with a trailing slash.
+func TestSkylinkDirectoryListingHandler(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	renter := &listingTestRenter{
+		metadata: modules.SkyfileMetadata{
+			Filename: "dir",
+			Length:   6,
+			Subfiles: modules.SkyfileSubfiles{
+				"a:b.txt":        {Filename: "a:b.txt", Offset: 0, Len: 2},
+				"c:d/readme.md":  {Filename: "c:d/readme.md", Offset: 2, Len: 3},
+				"c:d/index.json": {Filename: "c:d/index.json", Offset: 5, Len: 1},
+			},
+		},
+		data: fastrand.Bytes(6),
+	}
+	api := New(nil, nil, "TurtleDex-Agent", "", nil, nil, nil, nil, nil, nil, renter, nil, nil)
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	var mr crypto.Hash
+	fastrand.Read(mr[:])
+	skylink, err := modules.NewSkylinkV1(mr, 0, 6)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// get requests the path of the skylink with the given Accept header
+	// without following redirects.
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	get := func(path, accept string) (*http.Response, []byte) {
+		req, err := http.NewRequest("GET", server.URL+"/skynet/skylink/"+skylink.String()+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("User-Agent", "TurtleDex-Agent")
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, body
+	}
+
+	// Browsers are redirected to the URL with a trailing slash. The
+	// redirect is relative to the last path segment, even if it contains a
+	// colon.
+	resp, _ := get("", "text/html")
+	if resp.StatusCode != http.StatusTemporaryRedirect {
+		t.Fatal("unexpected status", resp.Status)
+	}
+	if location := resp.Header.Get("Location"); location != "./"+skylink.String()+"/" {
+		t.Fatal("unexpected location", location)
+	}
+	resp, _ = get("/c:d", "text/html")
+	if resp.StatusCode != http.StatusTemporaryRedirect {
+		t.Fatal("unexpected status", resp.Status)
+	}
+	if location := resp.Header.Get("Location"); location != "./c:d/" {
+		t.Fatal("unexpected location", location)
+	}
+
+	// With the trailing slash, the HTML listing is served.
+	resp, body := get("/", "text/html,application/xhtml+xml,*/*;q=0.8")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("unexpected status", resp.Status, string(body))
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Fatal("unexpected content type", ct)
+	}
+	if vary := resp.Header.Get("Vary"); vary != "Accept" {
+		t.Fatal("unexpected Vary header", vary)
+	}
+	for _, s := range []string{`href="./a:b.txt"`, `href="./c:d/"`} {
+		if !strings.Contains(string(body), s) {
+			t.Fatal("HTML listing doesn't contain", s, string(body))
+		}
+	}
+
+	// JSON listings are served without a redirect.
+	resp, body = get("/c:d", "application/json")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("unexpected status", resp.Status, string(body))
+	}
+	var listing SkynetDirectoryListing
+	if err := json.Unmarshal(body, &listing); err != nil {
+		t.Fatal(err)
+	}
+	if listing.Path != "/c:d/" || len(listing.Entries) != 2 || listing.Entries[0].Link != "./index.json" {
+		t.Fatal("unexpected listing", listing)
+	}
+
+	// Clients which don't accept a listing get a zip archive.
+	resp, _ = get("", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("unexpected status", resp.Status)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/zip" {
+		t.Fatal("unexpected content type", ct)
+	}
+
+	// Files aren't listed.
+	resp, _ = get("/a:b.txt", "application/json")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("unexpected status", resp.Status)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct == "application/json" {
+		t.Fatal("file was served as a listing")
+	}
+}
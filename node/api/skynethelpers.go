@@ -3,12 +3,15 @@ package api
 import (
 	"archive/tar"
 	"archive/zip"
+	"encoding/json"
 	"fmt"
+	"html/template"
 	"io"
 	"mime"
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
@@ -27,17 +30,22 @@ type (
 	// string parameters on upload
 	skyfileUploadParams struct {
 		baseChunkRedundancy uint8
+		compression         modules.CompressionType
 		defaultPath         string
 		convertPath         string
 		disableDefaultPath  bool
 		dryRun              bool
 		filename            string
 		force               bool
+		headers             map[string]string
 		mode                os.FileMode
+		notFoundPath        string
+		recipients          []skykey.RecipientPublicKey
 		root                bool
 		siaPath             modules.TurtleDexPath
 		skyKeyID            skykey.SkykeyID
 		skyKeyName          string
+		tryFiles            []string
 	}
 
 	// skyfileUploadHeaders is a helper struct that contains all of the request
@@ -48,6 +56,88 @@ type (
 	}
 )
 
+// skynetDirectoryListingTemplate is the template used to render HTML directory
+// listings of skyfiles.
+var skynetDirectoryListingTemplate = template.Must(template.New("listing").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Index of {{.Path}}</title>
+</head>
+<body>
+<h1>Index of {{.Path}}</h1>
+<table>
+<tr><th>Name</th><th>Size</th><th>Content Type</th></tr>
+{{- if ne .Path "/"}}
+<tr><td><a href="../">../</a></td><td></td><td></td></tr>
+{{- end}}
+{{- range .Entries}}
+<tr><td><a href="{{.Link}}">{{.Name}}{{if .IsDir}}/{{end}}</a></td><td>{{.Size}}</td><td>{{.ContentType}}</td></tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
+
+// buildDirectoryListing builds the listing of the directory at dir from the
+// subfiles of a skyfile. Only the direct children of the directory are listed,
+// directories first.
+func buildDirectoryListing(dir string, subfiles modules.SkyfileSubfiles) SkynetDirectoryListing {
+	dir = modules.EnsureSuffix(modules.EnsurePrefix(dir, "/"), "/")
+	entries := []SkynetDirectoryEntry{}
+	dirs := make(map[string]int)
+	for _, sf := range subfiles {
+		filePath := modules.EnsurePrefix(sf.Filename, "/")
+		if !strings.HasPrefix(filePath, dir) {
+			continue
+		}
+		name := strings.TrimPrefix(filePath, dir)
+
+		// Files within subdirectories add to the size of the subdirectory.
+		if i := strings.Index(name, "/"); i >= 0 {
+			name = name[:i]
+			index, exists := dirs[name]
+			if !exists {
+				index = len(entries)
+				dirs[name] = index
+				entries = append(entries, SkynetDirectoryEntry{
+					Name:  name,
+					Path:  dir + name + "/",
+					Link:  directoryListingLink(name) + "/",
+					IsDir: true,
+				})
+			}
+			entries[index].Size += sf.Len
+			continue
+		}
+		entries = append(entries, SkynetDirectoryEntry{
+			Name:        name,
+			Path:        filePath,
+			Link:        directoryListingLink(name),
+			Size:        sf.Len,
+			ContentType: sf.ContentType,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].IsDir != entries[j].IsDir {
+			return entries[i].IsDir
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return SkynetDirectoryListing{
+		Path:    dir,
+		Entries: entries,
+	}
+}
+
+// directoryListingLink returns the link to an entry of a directory listing
+// relative to the directory. url.PathEscape doesn't escape ':' which is why the
+// link starts with "./". Otherwise a name like "a:b" would be mistaken for a
+// URL with the scheme "a".
+func directoryListingLink(name string) string {
+	return "./" + url.PathEscape(name)
+}
+
 // buildETag is a helper function that returns an ETag.
 func buildETag(skylink modules.Skylink, method, path string, format modules.SkyfileFormat) string {
 	return crypto.HashAll(
@@ -64,6 +154,29 @@ func isMultipartRequest(mediaType string) bool {
 	return strings.HasPrefix(mediaType, "multipart/form-data")
 }
 
+// listingFormatFromAccept returns the directory listing format accepted by the
+// given Accept header. The first of 'text/html' and 'application/json' which is
+// accepted wins. If neither is accepted explicitly SkyfileFormatNotSpecified is
+// returned.
+func listingFormatFromAccept(accept string) modules.SkyfileFormat {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		if q, err := strconv.ParseFloat(params["q"], 64); err == nil && q == 0 {
+			continue
+		}
+		switch mediaType {
+		case "text/html":
+			return modules.SkyfileFormatHTML
+		case "application/json":
+			return modules.SkyfileFormatJSON
+		}
+	}
+	return modules.SkyfileFormatNotSpecified
+}
+
 // parseSkylinkURL splits a raw skylink URL into its components - a skylink, a
 // string representation of the skylink with the query parameters stripped, and
 // a path. The input skylink URL should not have been URL-decoded. The path is
@@ -145,6 +258,12 @@ func parseUploadHeadersAndRequestParameters(req *http.Request, ps httprouter.Par
 		}
 	}
 
+	// parse 'compression' query parameter
+	compression, err := modules.NewCompressionType(queryForm.Get("compression"))
+	if err != nil {
+		return nil, nil, errors.AddContext(err, "unable to parse 'compression' parameter")
+	}
+
 	// parse 'convertpath' query parameter
 	convertPath := queryForm.Get("convertpath")
 
@@ -187,6 +306,16 @@ func parseUploadHeadersAndRequestParameters(req *http.Request, ps httprouter.Par
 		}
 	}
 
+	// parse 'headers' query parameter
+	var headers map[string]string
+	headersStr := queryForm.Get("headers")
+	if headersStr != "" {
+		err = json.Unmarshal([]byte(headersStr), &headers)
+		if err != nil {
+			return nil, nil, errors.AddContext(err, "unable to parse 'headers' parameter")
+		}
+	}
+
 	// parse 'mode' query parameter
 	modeStr := queryForm.Get("mode")
 	var mode os.FileMode
@@ -197,6 +326,12 @@ func parseUploadHeadersAndRequestParameters(req *http.Request, ps httprouter.Par
 		}
 	}
 
+	// parse 'notfoundpath' query parameter
+	notFoundPath := queryForm.Get("notfoundpath")
+	if notFoundPath != "" {
+		notFoundPath = modules.EnsurePrefix(notFoundPath, "/")
+	}
+
 	// parse 'root' query parameter
 	var root bool
 	rootStr := queryForm.Get("root")
@@ -232,6 +367,34 @@ func parseUploadHeadersAndRequestParameters(req *http.Request, ps httprouter.Par
 		}
 	}
 
+	// parse 'recipients' query parameter, a skyfile can be encrypted for at
+	// most skykey.MaxRecipients recipients
+	var recipients []skykey.RecipientPublicKey
+	recipientsStr := queryForm.Get("recipients")
+	if recipientsStr != "" {
+		for _, pkStr := range strings.Split(recipientsStr, ",") {
+			var pk skykey.RecipientPublicKey
+			err = pk.FromString(strings.TrimSpace(pkStr))
+			if err != nil {
+				return nil, nil, errors.AddContext(err, "unable to parse 'recipients' parameter")
+			}
+			recipients = append(recipients, pk)
+		}
+		if len(recipients) > skykey.MaxRecipients {
+			return nil, nil, errors.AddContext(skykey.ErrTooManyRecipients, fmt.Sprintf("a skyfile can be encrypted for at most %v recipients", skykey.MaxRecipients))
+		}
+	}
+
+	// parse 'tryfiles' query parameter
+	var tryFiles []string
+	tryFilesStr := queryForm.Get("tryfiles")
+	if tryFilesStr != "" {
+		err = json.Unmarshal([]byte(tryFilesStr), &tryFiles)
+		if err != nil {
+			return nil, nil, errors.AddContext(err, "unable to parse 'tryfiles' parameter")
+		}
+	}
+
 	// validate parameter combos
 
 	// verify force is not set if disable force header was set
@@ -254,36 +417,75 @@ func parseUploadHeadersAndRequestParameters(req *http.Request, ps httprouter.Par
 		return nil, nil, errors.New("DefaultPath and DisableDefaultPath can only be set on multipart uploads")
 	}
 
+	// verify routing params are not set if it's not a multipart upload
+	if !isMultipartRequest(mediaType) && (len(tryFiles) > 0 || notFoundPath != "") {
+		return nil, nil, errors.AddContext(modules.ErrInvalidSkyfileRouting, "TryFiles and NotFoundPath can only be set on multipart uploads")
+	}
+
 	// verify convertpath and filename are not combined
 	if convertPath != "" && filename != "" {
 		return nil, nil, errors.New("cannot set both a 'convertpath' and a 'filename'")
 	}
 
+	// verify convertpath and compression are not combined
+	if convertPath != "" && compression != modules.CompressionNone {
+		return nil, nil, errors.New("cannot set both a 'convertpath' and a 'compression'")
+	}
+
 	// verify skykeyname and skykeyid are not combined
 	if skykeyName != "" && skykeyIDStr != "" {
 		return nil, nil, errors.New("cannot set both a 'skykeyname' and 'skykeyid'")
 	}
 
+	// verify recipients are not combined with skykeyname or skykeyid
+	if len(recipients) > 0 && (skykeyName != "" || skykeyIDStr != "") {
+		return nil, nil, errors.New("cannot set 'recipients' together with a 'skykeyname' or 'skykeyid'")
+	}
+
 	// create headers and parameters
-	headers := &skyfileUploadHeaders{
+	uploadHeaders := &skyfileUploadHeaders{
 		disableForce: disableForce,
 		mediaType:    mediaType,
 	}
 	params := &skyfileUploadParams{
 		baseChunkRedundancy: baseChunkRedundancy,
+		compression:         compression,
 		convertPath:         convertPath,
 		defaultPath:         defaultPath,
 		disableDefaultPath:  disableDefaultPath,
 		dryRun:              dryRun,
 		filename:            filename,
 		force:               force,
+		headers:             headers,
 		mode:                mode,
+		notFoundPath:        notFoundPath,
+		recipients:          recipients,
 		root:                root,
 		siaPath:             siaPath,
 		skyKeyID:            skykeyID,
 		skyKeyName:          skykeyName,
+		tryFiles:            tryFiles,
+	}
+	return uploadHeaders, params, nil
+}
+
+// serveNotFoundPage serves the not found page of a skyfile described by md
+// with a 404 status.
+func serveNotFoundPage(w http.ResponseWriter, req *http.Request, md modules.SkyfileMetadata, src io.Reader) {
+	if w.Header().Get("Content-Type") == "" {
+		ct := mime.TypeByExtension(filepath.Ext(md.Filename))
+		if ct == "" {
+			ct = "text/html; charset=utf-8"
+		}
+		w.Header().Set("Content-Type", ct)
+	}
+	w.Header().Del("ETag")
+	w.Header().Set("Content-Length", strconv.FormatUint(md.Length, 10))
+	w.WriteHeader(http.StatusNotFound)
+	if req.Method == http.MethodHead {
+		return
 	}
-	return headers, params, nil
+	_, _ = io.CopyN(w, src, int64(md.Length))
 }
 
 // serveArchive serves skyfiles as an archive by reading them from r and writing
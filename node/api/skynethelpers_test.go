@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -19,7 +20,9 @@ import (
 // helper tests, this ensures these tests are ran when supplying `-run
 // TestSkynet` from the command line.
 func TestSkynetHelpers(t *testing.T) {
+	t.Run("BuildDirectoryListing", testBuildDirectoryListing)
 	t.Run("BuildETag", testBuildETag)
+	t.Run("ListingFormatFromAccept", testListingFormatFromAccept)
 	t.Run("ParseSkylinkURL", testParseSkylinkURL)
 	t.Run("ParseUploadRequestParameters", testParseUploadRequestParameters)
 }
@@ -418,3 +421,103 @@ func testParseUploadRequestParameters(t *testing.T) {
 		t.Fatal("Unexpected")
 	}
 }
+
+// testBuildDirectoryListing verifies the functionality of
+// 'buildDirectoryListing'
+func testBuildDirectoryListing(t *testing.T) {
+	t.Parallel()
+
+	subfiles := modules.SkyfileSubfiles{
+		"index.html":           {Filename: "index.html", Len: 10, ContentType: "text/html"},
+		"a file.txt":           {Filename: "a file.txt", Len: 5, ContentType: "text/plain"},
+		"c:d.txt":              {Filename: "c:d.txt", Len: 1},
+		"docs/readme.md":       {Filename: "docs/readme.md", Len: 3},
+		"docs/img/logo.png":    {Filename: "docs/img/logo.png", Len: 7, ContentType: "image/png"},
+		"assets/css/style.css": {Filename: "assets/css/style.css", Len: 2},
+	}
+
+	// List the root. Directories are listed first and their size is the
+	// total size of their files.
+	listing := buildDirectoryListing("/", subfiles)
+	expected := SkynetDirectoryListing{
+		Path: "/",
+		Entries: []SkynetDirectoryEntry{
+			{Name: "assets", Path: "/assets/", Link: "./assets/", IsDir: true, Size: 2},
+			{Name: "docs", Path: "/docs/", Link: "./docs/", IsDir: true, Size: 10},
+			{Name: "a file.txt", Path: "/a file.txt", Link: "./a%20file.txt", Size: 5, ContentType: "text/plain"},
+			{Name: "c:d.txt", Path: "/c:d.txt", Link: "./c:d.txt", Size: 1},
+			{Name: "index.html", Path: "/index.html", Link: "./index.html", Size: 10, ContentType: "text/html"},
+		},
+	}
+	if !reflect.DeepEqual(listing, expected) {
+		t.Fatal("unexpected listing", listing)
+	}
+
+	// List a subdirectory with and without the trailing slash.
+	expected = SkynetDirectoryListing{
+		Path: "/docs/",
+		Entries: []SkynetDirectoryEntry{
+			{Name: "img", Path: "/docs/img/", Link: "./img/", IsDir: true, Size: 7},
+			{Name: "readme.md", Path: "/docs/readme.md", Link: "./readme.md", Size: 3},
+		},
+	}
+	for _, dir := range []string{"docs", "/docs", "/docs/"} {
+		listing = buildDirectoryListing(dir, subfiles)
+		if !reflect.DeepEqual(listing, expected) {
+			t.Fatal("unexpected listing", dir, listing)
+		}
+	}
+
+	// List a directory which doesn't exist.
+	listing = buildDirectoryListing("/doc", subfiles)
+	if len(listing.Entries) != 0 {
+		t.Fatal("unexpected listing", listing)
+	}
+
+	// Render the HTML listing.
+	var b strings.Builder
+	err := skynetDirectoryListingTemplate.Execute(&b, buildDirectoryListing("/docs", subfiles))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"Index of /docs/", `href="../"`, `href="./img/"`, `href="./readme.md"`} {
+		if !strings.Contains(b.String(), s) {
+			t.Fatal("HTML listing doesn't contain", s, b.String())
+		}
+	}
+
+	// Names with a colon are linked relative to the directory.
+	b.Reset()
+	err = skynetDirectoryListingTemplate.Execute(&b, buildDirectoryListing("/", subfiles))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), `href="./c:d.txt"`) {
+		t.Fatal("HTML listing doesn't link the file with a colon", b.String())
+	}
+}
+
+// testListingFormatFromAccept verifies the functionality of
+// 'listingFormatFromAccept'
+func testListingFormatFromAccept(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		accept string
+		format modules.SkyfileFormat
+	}{
+		{"", modules.SkyfileFormatNotSpecified},
+		{"*/*", modules.SkyfileFormatNotSpecified},
+		{"application/zip", modules.SkyfileFormatNotSpecified},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", modules.SkyfileFormatHTML},
+		{"application/json", modules.SkyfileFormatJSON},
+		{"application/json, text/html", modules.SkyfileFormatJSON},
+		{"text/html;q=0, application/json", modules.SkyfileFormatJSON},
+		{"invalid;;, text/html", modules.SkyfileFormatHTML},
+	}
+	for _, test := range tests {
+		if format := listingFormatFromAccept(test.accept); format != test.format {
+			t.Fatalf("unexpected format for '%v', expected '%v' got '%v'", test.accept, test.format, format)
+		}
+	}
+}
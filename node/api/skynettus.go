@@ -0,0 +1,299 @@
+package api
+
+// skynettus.go implements the tus resumable upload protocol for skyfiles. See
+// https://tus.io/protocols/resumable-upload.html for the specification. The
+// core protocol as well as the creation, termination and expiration
+// extensions are supported.
+//
+// Once all data was received, the skyfile is uploaded in the background. Its
+// skylink is returned in the 'Skynet-Skylink' header of HEAD requests once the
+// upload is done. If the upload fails, the error is returned in the
+// 'Skynet-Upload-Error' header instead and a PATCH request without data at
+// the final offset retries the upload.
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/modules/renter"
+	"github.com/turtledex/TurtleDexCore/skykey"
+)
+
+const (
+	// tusVersion is the version of the tus protocol supported by the API.
+	tusVersion = "1.0.0"
+
+	// tusExtensions are the supported extensions of the tus protocol.
+	tusExtensions = "creation,termination,expiration"
+
+	// tusContentType is the content type of a PATCH request.
+	tusContentType = "application/offset+octet-stream"
+)
+
+// parseTusMetadata parses the value of the 'Upload-Metadata' header. The
+// header consists of comma separated key value pairs. The key and value are
+// separated by a space and the value is base64 encoded. The value is
+// optional.
+func parseTusMetadata(header string) (map[string]string, error) {
+	metadata := make(map[string]string)
+	if strings.TrimSpace(header) == "" {
+		return metadata, nil
+	}
+	for _, pair := range strings.Split(header, ",") {
+		fields := strings.Fields(pair)
+		if len(fields) == 0 || len(fields) > 2 {
+			return nil, fmt.Errorf("invalid metadata pair '%v'", pair)
+		}
+		key := fields[0]
+		if _, exists := metadata[key]; exists {
+			return nil, fmt.Errorf("duplicate metadata key '%v'", key)
+		}
+		var value []byte
+		if len(fields) == 2 {
+			var err error
+			value, err = base64.StdEncoding.DecodeString(fields[1])
+			if err != nil {
+				return nil, errors.AddContext(err, fmt.Sprintf("unable to decode value of metadata key '%v'", key))
+			}
+		}
+		metadata[key] = string(value)
+	}
+	return metadata, nil
+}
+
+// parseTusUploadParameters parses the skyfile upload parameters from the
+// metadata of a tus upload. The supported keys match the query parameters of
+// a regular skyfile upload. If no siapath is provided, the skyfile is
+// uploaded to a random siapath within the skynet folder.
+func parseTusUploadParameters(req *http.Request) (modules.SkyfileUploadParameters, error) {
+	var sup modules.SkyfileUploadParameters
+	metadata, err := parseTusMetadata(req.Header.Get("Upload-Metadata"))
+	if err != nil {
+		return sup, errors.AddContext(err, "unable to parse 'Upload-Metadata' header")
+	}
+	parseBool := func(key string) (bool, error) {
+		if metadata[key] == "" {
+			return false, nil
+		}
+		b, err := strconv.ParseBool(metadata[key])
+		if err != nil {
+			return false, errors.AddContext(err, fmt.Sprintf("unable to parse '%v' metadata", key))
+		}
+		return b, nil
+	}
+
+	// parse 'Skynet-Disable-Force' request header
+	var disableForce bool
+	if str := req.Header.Get("Skynet-Disable-Force"); str != "" {
+		disableForce, err = strconv.ParseBool(str)
+		if err != nil {
+			return sup, errors.AddContext(err, "unable to parse 'Skynet-Disable-Force' header")
+		}
+	}
+
+	// parse 'basechunkredundancy' metadata
+	if str := metadata["basechunkredundancy"]; str != "" {
+		if _, err := fmt.Sscan(str, &sup.BaseChunkRedundancy); err != nil {
+			return sup, errors.AddContext(err, "unable to parse 'basechunkredundancy' metadata")
+		}
+	}
+
+	// parse 'compression' metadata
+	sup.Compression, err = modules.NewCompressionType(metadata["compression"])
+	if err != nil {
+		return sup, errors.AddContext(err, "unable to parse 'compression' metadata")
+	}
+
+	// parse 'filename' metadata, most tus clients set 'filename' but some
+	// only set 'name'
+	sup.Filename = metadata["filename"]
+	if sup.Filename == "" {
+		sup.Filename = metadata["name"]
+	}
+
+	// parse 'force' metadata
+	sup.Force, err = parseBool("force")
+	if err != nil {
+		return sup, err
+	}
+	if disableForce && sup.Force {
+		return sup, errors.New("'force' has been disabled on this node")
+	}
+
+	// parse 'mode' metadata
+	if str := metadata["mode"]; str != "" {
+		var mode os.FileMode
+		if _, err := fmt.Sscanf(str, "%o", &mode); err != nil {
+			return sup, errors.AddContext(err, "unable to parse 'mode' metadata")
+		}
+		sup.Mode = mode
+	}
+
+	// parse 'root' and 'siapath' metadata
+	root, err := parseBool("root")
+	if err != nil {
+		return sup, err
+	}
+	siaPathStr := metadata["siapath"]
+	switch {
+	case siaPathStr == "":
+		sup.TurtleDexPath, err = modules.SkynetFolder.Join(modules.RandomTurtleDexPath().String())
+	case root:
+		sup.TurtleDexPath, err = modules.NewTurtleDexPath(siaPathStr)
+	default:
+		sup.TurtleDexPath, err = modules.SkynetFolder.Join(siaPathStr)
+	}
+	if err != nil {
+		return sup, errors.AddContext(err, "unable to parse 'siapath' metadata")
+	}
+
+	// parse 'skykeyname' and 'skykeyid' metadata
+	sup.SkykeyName = metadata["skykeyname"]
+	if str := metadata["skykeyid"]; str != "" {
+		var skykeyID skykey.SkykeyID
+		if err := skykeyID.FromString(str); err != nil {
+			return sup, errors.AddContext(err, "unable to parse 'skykeyid' metadata")
+		}
+		sup.SkykeyID = skykeyID
+		if sup.SkykeyName != "" {
+			return sup, errors.New("cannot set both a 'skykeyname' and 'skykeyid'")
+		}
+	}
+	return sup, nil
+}
+
+// writeTusUploadHeaders sets the headers describing the state of a resumable
+// upload.
+func writeTusUploadHeaders(w http.ResponseWriter, u modules.ResumableSkyfileUpload) {
+	w.Header().Set("Upload-Offset", strconv.FormatUint(u.Offset, 10))
+	w.Header().Set("Upload-Expires", u.Expires.UTC().Format(http.TimeFormat))
+	if u.Skylink != "" {
+		w.Header().Set("Skynet-Skylink", u.Skylink)
+	}
+	if u.Error != "" {
+		w.Header().Set("Skynet-Upload-Error", u.Error)
+	}
+}
+
+// writeTusMaxSize sets the header with the maximum size of an upload.
+func writeTusMaxSize(w http.ResponseWriter) {
+	w.Header().Set("Tus-Max-Size", strconv.FormatUint(modules.ResumableUploadMaxSize, 10))
+}
+
+// writeTusError writes an error returned by the renter with the matching
+// status code.
+func writeTusError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Contains(err, renter.ErrResumableUploadNotFound):
+		WriteError(w, Error{err.Error()}, http.StatusNotFound)
+	case errors.Contains(err, renter.ErrResumableUploadOffset):
+		WriteError(w, Error{err.Error()}, http.StatusConflict)
+	case errors.Contains(err, renter.ErrResumableUploadLocked):
+		WriteError(w, Error{err.Error()}, http.StatusLocked)
+	case errors.Contains(err, renter.ErrResumableUploadTooLarge):
+		WriteError(w, Error{err.Error()}, http.StatusRequestEntityTooLarge)
+	case errors.Contains(err, renter.ErrResumableUploadMaxSize):
+		writeTusMaxSize(w)
+		WriteError(w, Error{err.Error()}, http.StatusRequestEntityTooLarge)
+	case errors.Contains(err, renter.ErrResumableUploadQuota):
+		WriteError(w, Error{err.Error()}, http.StatusInsufficientStorage)
+	case errors.Contains(err, renter.ErrSkylinkBlocked):
+		WriteError(w, Error{err.Error()}, http.StatusUnavailableForLegalReasons)
+	default:
+		WriteError(w, Error{fmt.Sprintf("failed to upload file to Skynet: %v", err)}, http.StatusBadRequest)
+	}
+}
+
+// skynetTusHandlerOPTIONS responds with the tus protocol versions and
+// extensions supported by the API.
+func (api *API) skynetTusHandlerOPTIONS(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	w.Header().Set("Tus-Resumable", tusVersion)
+	w.Header().Set("Tus-Version", tusVersion)
+	w.Header().Set("Tus-Extension", tusExtensions)
+	writeTusMaxSize(w)
+	WriteSuccess(w)
+}
+
+// skynetTusHandlerPOST creates a new resumable skyfile upload.
+func (api *API) skynetTusHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	w.Header().Set("Tus-Resumable", tusVersion)
+	if req.Header.Get("Upload-Defer-Length") != "" {
+		WriteError(w, Error{"deferring the upload length is not supported"}, http.StatusBadRequest)
+		return
+	}
+	length, err := strconv.ParseUint(req.Header.Get("Upload-Length"), 10, 64)
+	if err != nil {
+		WriteError(w, Error{"unable to parse 'Upload-Length' header: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	sup, err := parseTusUploadParameters(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	u, err := api.renter.CreateResumableSkyfileUpload(sup, length)
+	if err != nil {
+		writeTusError(w, err)
+		return
+	}
+	writeTusUploadHeaders(w, u)
+	w.Header().Set("Location", "/skynet/tus/"+string(u.ID))
+	w.WriteHeader(http.StatusCreated)
+}
+
+// skynetTusUploadHandlerHEAD responds with the state of a resumable skyfile
+// upload.
+func (api *API) skynetTusUploadHandlerHEAD(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	w.Header().Set("Tus-Resumable", tusVersion)
+	w.Header().Set("Cache-Control", "no-store")
+	u, err := api.renter.ResumableSkyfileUpload(modules.ResumableUploadID(ps.ByName("id")))
+	if err != nil {
+		writeTusError(w, err)
+		return
+	}
+	writeTusUploadHeaders(w, u)
+	w.Header().Set("Upload-Length", strconv.FormatUint(u.Length, 10))
+	w.WriteHeader(http.StatusOK)
+}
+
+// skynetTusUploadHandlerPATCH appends the request body to a resumable skyfile
+// upload. The skyfile is uploaded in the background once all data was
+// received.
+func (api *API) skynetTusUploadHandlerPATCH(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	w.Header().Set("Tus-Resumable", tusVersion)
+	if req.Header.Get("Content-Type") != tusContentType {
+		WriteError(w, Error{fmt.Sprintf("'Content-Type' must be '%v'", tusContentType)}, http.StatusUnsupportedMediaType)
+		return
+	}
+	offset, err := strconv.ParseUint(req.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil {
+		WriteError(w, Error{"unable to parse 'Upload-Offset' header: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	u, err := api.renter.WriteResumableSkyfileUpload(modules.ResumableUploadID(ps.ByName("id")), offset, req.Body)
+	if err != nil {
+		writeTusError(w, err)
+		return
+	}
+	writeTusUploadHeaders(w, u)
+	WriteSuccess(w)
+}
+
+// skynetTusUploadHandlerDELETE aborts a resumable skyfile upload.
+func (api *API) skynetTusUploadHandlerDELETE(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	w.Header().Set("Tus-Resumable", tusVersion)
+	err := api.renter.DeleteResumableSkyfileUpload(modules.ResumableUploadID(ps.ByName("id")))
+	if err != nil {
+		writeTusError(w, err)
+		return
+	}
+	WriteSuccess(w)
+}
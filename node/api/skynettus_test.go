@@ -0,0 +1,123 @@
+package api
+
+import (
+	"encoding/base64"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+)
+
+// TestParseTusMetadata is a unit test for parseTusMetadata.
+func TestParseTusMetadata(t *testing.T) {
+	t.Parallel()
+
+	enc := func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	}
+
+	// valid headers
+	metadata, err := parseTusMetadata("")
+	if err != nil || len(metadata) != 0 {
+		t.Fatal("unexpected result", metadata, err)
+	}
+	metadata, err = parseTusMetadata("filename " + enc("file.txt") + ", empty,force " + enc("true"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"filename": "file.txt", "empty": "", "force": "true"}
+	if !reflect.DeepEqual(metadata, expected) {
+		t.Fatal("wrong metadata", metadata)
+	}
+
+	// invalid headers
+	for _, header := range []string{
+		"filename " + enc("a") + ",filename " + enc("b"),
+		"filename a b",
+		"filename !!!",
+		"filename " + enc("a") + ",",
+	} {
+		if _, err := parseTusMetadata(header); err == nil {
+			t.Fatal("expected error for header", header)
+		}
+	}
+}
+
+// TestParseTusUploadParameters is a unit test for parseTusUploadParameters.
+func TestParseTusUploadParameters(t *testing.T) {
+	t.Parallel()
+
+	request := func(metadata map[string]string) *http.Request {
+		var pairs []string
+		for k, v := range metadata {
+			pairs = append(pairs, k+" "+base64.StdEncoding.EncodeToString([]byte(v)))
+		}
+		req, err := http.NewRequest("POST", "/skynet/tus", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Upload-Metadata", strings.Join(pairs, ","))
+		return req
+	}
+
+	// Without a siapath the skyfile is uploaded to a random siapath in the
+	// skynet folder.
+	sup, err := parseTusUploadParameters(request(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir, err := sup.TurtleDexPath.Dir(); err != nil || !dir.Equals(modules.SkynetFolder) {
+		t.Fatal("wrong siapath", sup.TurtleDexPath, err)
+	}
+
+	// Check the parsed parameters.
+	sup, err = parseTusUploadParameters(request(map[string]string{
+		"basechunkredundancy": "3",
+		"force":               "true",
+		"mode":                "640",
+		"name":                "file.txt",
+		"siapath":             "foo/bar",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := modules.SkynetFolder.Join("foo/bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sup.TurtleDexPath.Equals(expected) || sup.BaseChunkRedundancy != 3 || !sup.Force || sup.Mode != os.FileMode(0640) || sup.Filename != "file.txt" {
+		t.Fatal("wrong parameters", sup)
+	}
+
+	// Uploading to the root.
+	sup, err = parseTusUploadParameters(request(map[string]string{"siapath": "foo/bar", "root": "true"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sup.TurtleDexPath.String() != "foo/bar" {
+		t.Fatal("wrong siapath", sup.TurtleDexPath)
+	}
+
+	// Invalid parameters.
+	for _, metadata := range []map[string]string{
+		{"force": "maybe"},
+		{"mode": "abc"},
+		{"basechunkredundancy": "-1"},
+		{"skykeyid": "invalid"},
+		{"compression": "invalid"},
+	} {
+		if _, err := parseTusUploadParameters(request(metadata)); err == nil {
+			t.Fatal("expected error for metadata", metadata)
+		}
+	}
+
+	// Force can be disabled.
+	req := request(map[string]string{"force": "true"})
+	req.Header.Set("Skynet-Disable-Force", "true")
+	if _, err := parseTusUploadParameters(req); err == nil {
+		t.Fatal("force should be disabled")
+	}
+}
@@ -0,0 +1,227 @@
+package s3gateway
+
+import (
+	"encoding/xml"
+	"net/http"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem"
+)
+
+// apiError is an error which is returned to S3 clients using the S3 error
+// format.
+type apiError struct {
+	Code       string
+	Message    string
+	StatusCode int
+}
+
+// errorResponse is the XML body of an S3 error response.
+type errorResponse struct {
+	XMLName  xml.Name `xml:"Error"`
+	Code     string   `xml:"Code"`
+	Message  string   `xml:"Message"`
+	Resource string   `xml:"Resource"`
+}
+
+var (
+	errAccessDenied = apiError{
+		Code:       "AccessDenied",
+		Message:    "Access Denied.",
+		StatusCode: http.StatusForbidden,
+	}
+	errAuthorizationHeaderMalformed = apiError{
+		Code:       "AuthorizationHeaderMalformed",
+		Message:    "The authorization header is malformed.",
+		StatusCode: http.StatusBadRequest,
+	}
+	errBadDigest = apiError{
+		Code:       "BadDigest",
+		Message:    "The Content-MD5 you specified did not match what we received.",
+		StatusCode: http.StatusBadRequest,
+	}
+	errBucketAlreadyOwnedByYou = apiError{
+		Code:       "BucketAlreadyOwnedByYou",
+		Message:    "The bucket you tried to create already exists, and you own it.",
+		StatusCode: http.StatusConflict,
+	}
+	errBucketNotEmpty = apiError{
+		Code:       "BucketNotEmpty",
+		Message:    "The bucket you tried to delete is not empty.",
+		StatusCode: http.StatusConflict,
+	}
+	errExpiredPresignRequest = apiError{
+		Code:       "AccessDenied",
+		Message:    "Request has expired.",
+		StatusCode: http.StatusForbidden,
+	}
+	errIncompleteBody = apiError{
+		Code:       "IncompleteBody",
+		Message:    "You did not provide the number of bytes specified by the Content-Length HTTP header.",
+		StatusCode: http.StatusBadRequest,
+	}
+	errInternalError = apiError{
+		Code:       "InternalError",
+		Message:    "We encountered an internal error, please try again.",
+		StatusCode: http.StatusInternalServerError,
+	}
+	errInvalidAccessKeyID = apiError{
+		Code:       "InvalidAccessKeyId",
+		Message:    "The access key ID you provided does not exist in our records.",
+		StatusCode: http.StatusForbidden,
+	}
+	errInvalidArgument = apiError{
+		Code:       "InvalidArgument",
+		Message:    "Invalid argument.",
+		StatusCode: http.StatusBadRequest,
+	}
+	errInvalidBucketName = apiError{
+		Code:       "InvalidBucketName",
+		Message:    "The specified bucket is not valid.",
+		StatusCode: http.StatusBadRequest,
+	}
+	errInvalidDigest = apiError{
+		Code:       "InvalidDigest",
+		Message:    "The Content-MD5 you specified is not valid.",
+		StatusCode: http.StatusBadRequest,
+	}
+	errInvalidPart = apiError{
+		Code:       "InvalidPart",
+		Message:    "One or more of the specified parts could not be found or doesn't match the uploaded part.",
+		StatusCode: http.StatusBadRequest,
+	}
+	errInvalidPartOrder = apiError{
+		Code:       "InvalidPartOrder",
+		Message:    "The list of parts was not in ascending order.",
+		StatusCode: http.StatusBadRequest,
+	}
+	errMalformedExpires = apiError{
+		Code:       "AuthorizationQueryParametersError",
+		Message:    "X-Amz-Expires must be a non-negative integer of at most 604800 seconds.",
+		StatusCode: http.StatusBadRequest,
+	}
+	errMalformedPresignedDate = apiError{
+		Code:       "AuthorizationQueryParametersError",
+		Message:    "X-Amz-Date must be in the ISO8601 Long Format \"yyyyMMdd'T'HHmmss'Z'\".",
+		StatusCode: http.StatusBadRequest,
+	}
+	errMalformedXML = apiError{
+		Code:       "MalformedXML",
+		Message:    "The XML you provided was not well-formed or did not validate against our published schema.",
+		StatusCode: http.StatusBadRequest,
+	}
+	errMethodNotAllowed = apiError{
+		Code:       "MethodNotAllowed",
+		Message:    "The specified method is not allowed against this resource.",
+		StatusCode: http.StatusMethodNotAllowed,
+	}
+	errMissingSecurityHeader = apiError{
+		Code:       "MissingSecurityHeader",
+		Message:    "Your request is missing a required header.",
+		StatusCode: http.StatusBadRequest,
+	}
+	errNoSuchBucket = apiError{
+		Code:       "NoSuchBucket",
+		Message:    "The specified bucket does not exist.",
+		StatusCode: http.StatusNotFound,
+	}
+	errNoSuchKey = apiError{
+		Code:       "NoSuchKey",
+		Message:    "The specified key does not exist.",
+		StatusCode: http.StatusNotFound,
+	}
+	errNoSuchUpload = apiError{
+		Code:       "NoSuchUpload",
+		Message:    "The specified multipart upload does not exist.",
+		StatusCode: http.StatusNotFound,
+	}
+	errNotImplemented = apiError{
+		Code:       "NotImplemented",
+		Message:    "A header or query you provided implies functionality that is not implemented.",
+		StatusCode: http.StatusNotImplemented,
+	}
+	errRequestTimeTooSkewed = apiError{
+		Code:       "RequestTimeTooSkewed",
+		Message:    "The difference between the request time and the server's time is too large.",
+		StatusCode: http.StatusForbidden,
+	}
+	errRequestNotReadyYet = apiError{
+		Code:       "AccessDenied",
+		Message:    "Request is not valid yet.",
+		StatusCode: http.StatusForbidden,
+	}
+	errSignatureDoesNotMatch = apiError{
+		Code:       "SignatureDoesNotMatch",
+		Message:    "The request signature we calculated does not match the signature you provided.",
+		StatusCode: http.StatusForbidden,
+	}
+	errUnsupportedSignature = apiError{
+		Code:       "AccessDenied",
+		Message:    "Only AWS Signature Version 4 is supported.",
+		StatusCode: http.StatusBadRequest,
+	}
+	errXAmzContentSHA256Mismatch = apiError{
+		Code:       "XAmzContentSHA256Mismatch",
+		Message:    "The provided 'x-amz-content-sha256' header does not match what was computed.",
+		StatusCode: http.StatusBadRequest,
+	}
+)
+
+// bodyErrors are the errors which can be returned while reading the body of
+// an authenticated request.
+var bodyErrors = []apiError{
+	errBadDigest,
+	errIncompleteBody,
+	errSignatureDoesNotMatch,
+	errXAmzContentSHA256Mismatch,
+}
+
+// Error implements the error interface.
+func (e apiError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// toAPIError converts an error returned by the renter into an apiError.
+func toAPIError(err error, notExist apiError) apiError {
+	if apiErr, ok := err.(apiError); ok {
+		return apiErr
+	}
+	// Errors returned by the request body might be wrapped by the renter.
+	for _, apiErr := range bodyErrors {
+		if errors.Contains(err, apiErr) {
+			return apiErr
+		}
+	}
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		return notExist
+	}
+	return errInternalError
+}
+
+// writeError writes an S3 error response for err to w.
+func writeError(w http.ResponseWriter, req *http.Request, err apiError) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(err.StatusCode)
+	if req.Method == http.MethodHead {
+		return
+	}
+	writeXMLBody(w, errorResponse{
+		Code:     err.Code,
+		Message:  err.Message,
+		Resource: req.URL.Path,
+	})
+}
+
+// writeXML writes a successful XML response to w.
+func writeXML(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(http.StatusOK)
+	writeXMLBody(w, v)
+}
+
+// writeXMLBody writes the XML encoding of v to w.
+func writeXMLBody(w http.ResponseWriter, v interface{}) {
+	_, _ = w.Write([]byte(xml.Header))
+	_ = xml.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,357 @@
+package s3gateway
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/turtledex/errors"
+	"github.com/turtledex/fastrand"
+
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
+)
+
+const (
+	// maxPartNumber is the highest part number of a multipart upload.
+	maxPartNumber = 10000
+
+	// multipartDir is the directory within the renter's var folder in which
+	// objects and multipart uploads are stored until they are completed.
+	multipartDir = "s3gateway"
+)
+
+type (
+	// multipartUpload is an in-progress multipart upload. Every part is
+	// staged on disk until the upload is completed. The parts of the
+	// completed upload are then streamed to the renter in order using a
+	// single call to UploadStreamFromReader. The renter uploads the file to a
+	// temporary siapath which is renamed to the object's siapath once the
+	// upload is finished.
+	multipartUpload struct {
+		staticBucket     string
+		staticKey        string
+		staticID         string
+		staticRenter     modules.Renter
+		staticSiaPath    modules.TurtleDexPath
+		staticStagingDir string
+		staticTmpPath    modules.TurtleDexPath
+
+		// staged contains the parts which were uploaded so far. Once the
+		// upload is completing or aborted, no more parts are accepted.
+		staged map[int]stagedPart
+		closed bool
+		mu     sync.Mutex
+	}
+
+	// stagedPart is a part of a multipart upload which is stored on disk.
+	stagedPart struct {
+		md5  [md5.Size]byte
+		path string
+	}
+)
+
+// createMultipartUpload handles a CreateMultipartUpload request.
+func (g *Gateway) createMultipartUpload(w http.ResponseWriter, req *http.Request, bucket, key string) {
+	if err := g.managedCheckBucket(bucket); err != nil {
+		writeError(w, req, toAPIError(err, errNoSuchBucket))
+		return
+	}
+	if strings.HasSuffix(key, "/") {
+		writeError(w, req, errInvalidArgument)
+		return
+	}
+	sp, err := objectPath(bucket, key)
+	if err != nil {
+		writeError(w, req, toAPIError(err, errInvalidArgument))
+		return
+	}
+	u, err := g.managedNewMultipartUpload(bucket, key, sp)
+	if err != nil {
+		writeError(w, req, toAPIError(err, errInternalError))
+		return
+	}
+	writeXML(w, initiateMultipartUploadResult{
+		Bucket:   bucket,
+		Key:      key,
+		UploadID: u.staticID,
+	})
+}
+
+// uploadPart handles an UploadPart request.
+func (g *Gateway) uploadPart(w http.ResponseWriter, req *http.Request, bucket, key string) {
+	u, err := g.managedMultipartUpload(req, bucket, key)
+	if err != nil {
+		writeError(w, req, toAPIError(err, errNoSuchUpload))
+		return
+	}
+	partNumber, err := strconv.Atoi(req.URL.Query().Get("partNumber"))
+	if err != nil || partNumber < 1 || partNumber > maxPartNumber {
+		writeError(w, req, errInvalidArgument)
+		return
+	}
+
+	// Stage the part on disk.
+	f, err := ioutil.TempFile(u.staticStagingDir, fmt.Sprintf("part%d-", partNumber))
+	if err != nil {
+		writeError(w, req, errInternalError)
+		return
+	}
+	h := md5.New()
+	_, err = io.Copy(io.MultiWriter(f, h), req.Body)
+	err = errors.Compose(err, f.Close())
+	if err != nil {
+		err = errors.Compose(err, os.Remove(f.Name()))
+		writeError(w, req, toAPIError(err, errInternalError))
+		return
+	}
+	var sum [md5.Size]byte
+	copy(sum[:], h.Sum(nil))
+
+	// Add it to the upload.
+	if err := u.managedAddPart(partNumber, stagedPart{md5: sum, path: f.Name()}); err != nil {
+		writeError(w, req, toAPIError(err, errInternalError))
+		return
+	}
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+	w.WriteHeader(http.StatusOK)
+}
+
+// completeMultipartUpload handles a CompleteMultipartUpload request.
+func (g *Gateway) completeMultipartUpload(w http.ResponseWriter, req *http.Request, bucket, key string) {
+	u, err := g.managedMultipartUpload(req, bucket, key)
+	if err != nil {
+		writeError(w, req, toAPIError(err, errNoSuchUpload))
+		return
+	}
+	var cmu completeMultipartUpload
+	if err := xml.NewDecoder(io.LimitReader(req.Body, maxXMLBodySize)).Decode(&cmu); err != nil {
+		writeError(w, req, toAPIError(err, errMalformedXML))
+		return
+	}
+	parts := make([]int, 0, len(cmu.Parts))
+	etags := make([][md5.Size]byte, 0, len(cmu.Parts))
+	for _, p := range cmu.Parts {
+		b, err := hex.DecodeString(strings.Trim(p.ETag, `"`))
+		if err != nil || len(b) != md5.Size {
+			writeError(w, req, errInvalidPart)
+			return
+		}
+		var sum [md5.Size]byte
+		copy(sum[:], b)
+		parts = append(parts, p.PartNumber)
+		etags = append(etags, sum)
+	}
+
+	// Finish the upload and move the file to its final location. If the
+	// upload failed after it was started, it can't be retried.
+	if err := u.managedComplete(parts, etags); err != nil {
+		u.mu.Lock()
+		closed := u.closed
+		u.mu.Unlock()
+		if closed {
+			g.managedRemoveMultipartUpload(u)
+		}
+		writeError(w, req, toAPIError(err, errInternalError))
+		return
+	}
+	err = g.staticReplaceObject(u.staticTmpPath, u.staticSiaPath)
+	g.managedRemoveMultipartUpload(u)
+	if err != nil {
+		writeError(w, req, toAPIError(err, errInternalError))
+		return
+	}
+
+	// The ETag of a multipart upload is the md5 of the concatenated md5
+	// hashes of its parts followed by the number of parts.
+	h := md5.New()
+	for _, sum := range etags {
+		h.Write(sum[:])
+	}
+	writeXML(w, completeMultipartUploadResult{
+		Location: "/" + bucket + "/" + key,
+		Bucket:   bucket,
+		Key:      key,
+		ETag:     fmt.Sprintf(`"%s-%d"`, hex.EncodeToString(h.Sum(nil)), len(etags)),
+	})
+}
+
+// abortMultipartUpload handles an AbortMultipartUpload request.
+func (g *Gateway) abortMultipartUpload(w http.ResponseWriter, req *http.Request, bucket, key string) {
+	u, err := g.managedMultipartUpload(req, bucket, key)
+	if err != nil {
+		writeError(w, req, toAPIError(err, errNoSuchUpload))
+		return
+	}
+	g.managedRemoveMultipartUpload(u)
+	if err := u.managedAbort(); err != nil {
+		writeError(w, req, toAPIError(err, errInternalError))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// managedNewMultipartUpload creates a new multipart upload.
+func (g *Gateway) managedNewMultipartUpload(bucket, key string, sp modules.TurtleDexPath) (*multipartUpload, error) {
+	id := hex.EncodeToString(fastrand.Bytes(16))
+	tmpPath, err := tmpObjectPath(id)
+	if err != nil {
+		return nil, err
+	}
+	stagingDir := filepath.Join(g.staticStagingDir, id)
+	if err := os.MkdirAll(stagingDir, modules.DefaultDirPerm); err != nil {
+		return nil, errors.AddContext(err, "failed to create staging dir")
+	}
+	u := &multipartUpload{
+		staticBucket:     bucket,
+		staticKey:        key,
+		staticID:         id,
+		staticRenter:     g.staticRenter,
+		staticSiaPath:    sp,
+		staticStagingDir: stagingDir,
+		staticTmpPath:    tmpPath,
+		staged:           make(map[int]stagedPart),
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.closed {
+		return nil, errors.Compose(errInternalError, os.RemoveAll(stagingDir))
+	}
+	g.uploads[id] = u
+	return u, nil
+}
+
+// managedMultipartUpload returns the multipart upload specified by the
+// uploadId of a request.
+func (g *Gateway) managedMultipartUpload(req *http.Request, bucket, key string) (*multipartUpload, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	u, exists := g.uploads[req.URL.Query().Get("uploadId")]
+	if !exists || u.staticBucket != bucket || u.staticKey != key {
+		return nil, errNoSuchUpload
+	}
+	return u, nil
+}
+
+// managedRemoveMultipartUpload removes a multipart upload from the gateway.
+func (g *Gateway) managedRemoveMultipartUpload(u *multipartUpload) {
+	g.mu.Lock()
+	delete(g.uploads, u.staticID)
+	g.mu.Unlock()
+}
+
+// managedAddPart adds a staged part to the upload. Uploading a part again
+// replaces the previously staged part.
+func (u *multipartUpload) managedAddPart(partNumber int, part stagedPart) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.closed {
+		return errors.Compose(errNoSuchUpload, os.Remove(part.path))
+	}
+	var err error
+	if old, exists := u.staged[partNumber]; exists {
+		err = os.Remove(old.path)
+	}
+	u.staged[partNumber] = part
+	return err
+}
+
+// managedComplete streams the provided parts to the renter and waits for the
+// upload to finish. The parts need to be in ascending order but don't need to
+// be consecutive. Staged parts which are not provided are dropped.
+func (u *multipartUpload) managedComplete(parts []int, etags [][md5.Size]byte) (err error) {
+	// Check the parts and close the upload to prevent parts from being
+	// changed while they are streamed to the renter.
+	u.mu.Lock()
+	if u.closed {
+		u.mu.Unlock()
+		return errNoSuchUpload
+	}
+	if len(parts) == 0 {
+		u.mu.Unlock()
+		return errMalformedXML
+	}
+	paths := make([]string, 0, len(parts))
+	for i, p := range parts {
+		if i > 0 && p <= parts[i-1] {
+			u.mu.Unlock()
+			return errInvalidPartOrder
+		}
+		part, exists := u.staged[p]
+		if !exists || part.md5 != etags[i] {
+			u.mu.Unlock()
+			return errInvalidPart
+		}
+		paths = append(paths, part.path)
+	}
+	u.closed = true
+	u.mu.Unlock()
+
+	// The staged parts are removed from disk no matter the outcome.
+	defer func() {
+		err = errors.Compose(err, os.RemoveAll(u.staticStagingDir))
+	}()
+
+	// Stream the parts to the renter.
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(writeParts(pw, paths))
+	}()
+	up := modules.FileUploadParams{
+		TurtleDexPath: u.staticTmpPath,
+		Force:         true,
+		CipherType:    crypto.TypeDefaultRenter,
+	}
+	err = u.staticRenter.UploadStreamFromReader(up, pr)
+	// Unblock the writer if the upload failed.
+	pr.CloseWithError(err)
+	if err != nil {
+		return errors.Compose(err, u.staticDeleteTmpFile())
+	}
+	return nil
+}
+
+// managedAbort aborts the upload and removes all of its data. An upload which
+// is already completing can't be aborted.
+func (u *multipartUpload) managedAbort() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.closed {
+		return nil
+	}
+	u.closed = true
+	u.staged = make(map[int]stagedPart)
+	return os.RemoveAll(u.staticStagingDir)
+}
+
+// staticDeleteTmpFile deletes the temporary file of the upload from the
+// renter.
+func (u *multipartUpload) staticDeleteTmpFile() error {
+	return deleteTmpObject(u.staticRenter, u.staticTmpPath)
+}
+
+// writeParts writes the staged parts at the provided paths to w in order.
+func writeParts(w io.Writer, paths []string) error {
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return errors.AddContext(err, "failed to open staged part")
+		}
+		_, err = io.Copy(w, f)
+		err = errors.Compose(err, f.Close())
+		if err != nil {
+			return errors.AddContext(err, "failed to write part")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package s3gateway
+
+import (
+	"bytes"
+	"crypto/md5"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/turtledex/errors"
+	"github.com/turtledex/fastrand"
+
+	"github.com/turtledex/TurtleDexCore/build"
+	"github.com/turtledex/TurtleDexCore/modules"
+)
+
+// multipartTestRenter is a renter which only supports streaming uploads. It
+// records the data of every uploaded file.
+type multipartTestRenter struct {
+	modules.Renter
+	files map[modules.TurtleDexPath][]byte
+	mu    sync.Mutex
+}
+
+// UploadStreamFromReader records the data read from reader.
+func (r *multipartTestRenter) UploadStreamFromReader(up modules.FileUploadParams, reader io.Reader) error {
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.files[up.TurtleDexPath] = data
+	r.mu.Unlock()
+	return nil
+}
+
+// DeleteFile deletes the recorded data of a file.
+func (r *multipartTestRenter) DeleteFile(siaPath modules.TurtleDexPath) error {
+	r.mu.Lock()
+	delete(r.files, siaPath)
+	r.mu.Unlock()
+	return nil
+}
+
+// DeleteFileUnversioned deletes the recorded data of a file.
+func (r *multipartTestRenter) DeleteFileUnversioned(siaPath modules.TurtleDexPath) error {
+	return r.DeleteFile(siaPath)
+}
+
+// TestMultipartUploadComplete tests completing multipart uploads with sparse
+// part numbers.
+func TestMultipartUploadComplete(t *testing.T) {
+	renter := &multipartTestRenter{files: make(map[modules.TurtleDexPath][]byte)}
+	stagingDir := build.TempDir("s3gateway", t.Name())
+	g, err := New(renter, Credentials{testAccessKeyID, testSecretAccessKey}, stagingDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sp, err := objectPath("bucket", "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := g.managedNewMultipartUpload("bucket", "key", sp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Stage some parts with gaps in between. Part 3 is uploaded twice.
+	data := make(map[int][]byte)
+	etags := make(map[int][md5.Size]byte)
+	for i, p := range []int{1, 2, 3, 3, 7} {
+		data[p] = fastrand.Bytes(100 + fastrand.Intn(100))
+		etags[p] = md5.Sum(data[p])
+		path := filepath.Join(u.staticStagingDir, fmt.Sprintf("part%d", i))
+		if err := ioutil.WriteFile(path, data[p], modules.DefaultFilePerm); err != nil {
+			t.Fatal(err)
+		}
+		if err := u.managedAddPart(p, stagedPart{md5: etags[p], path: path}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Parts need to be in ascending order and match the uploaded parts.
+	err = u.managedComplete([]int{3, 1}, [][md5.Size]byte{etags[3], etags[1]})
+	if !errors.Contains(err, errInvalidPartOrder) {
+		t.Fatal("expected errInvalidPartOrder but got", err)
+	}
+	err = u.managedComplete([]int{1, 3}, [][md5.Size]byte{etags[1], etags[2]})
+	if !errors.Contains(err, errInvalidPart) {
+		t.Fatal("expected errInvalidPart but got", err)
+	}
+	err = u.managedComplete([]int{1, 4}, [][md5.Size]byte{etags[1], etags[3]})
+	if !errors.Contains(err, errInvalidPart) {
+		t.Fatal("expected errInvalidPart but got", err)
+	}
+
+	// Complete the upload without part 2.
+	err = u.managedComplete([]int{1, 3, 7}, [][md5.Size]byte{etags[1], etags[3], etags[7]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := append(append(append([]byte{}, data[1]...), data[3]...), data[7]...)
+	if !bytes.Equal(renter.files[u.staticTmpPath], expected) {
+		t.Fatal("uploaded data doesn't match")
+	}
+	if _, err := os.Stat(u.staticStagingDir); !os.IsNotExist(err) {
+		t.Fatal("staging dir wasn't removed", err)
+	}
+
+	// The completed upload doesn't accept any more parts.
+	path := filepath.Join(stagingDir, "part")
+	if err := ioutil.WriteFile(path, data[1], modules.DefaultFilePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := u.managedAddPart(1, stagedPart{md5: etags[1], path: path}); !errors.Contains(err, errNoSuchUpload) {
+		t.Fatal("expected errNoSuchUpload but got", err)
+	}
+	if err := g.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,550 @@
+package s3gateway
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turtledex/errors"
+	"github.com/turtledex/fastrand"
+
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem"
+)
+
+const (
+	// defaultMaxKeys is the default and maximum number of keys returned by a
+	// single ListObjects request.
+	defaultMaxKeys = 1000
+
+	// maxDeleteObjects is the maximum number of objects which can be deleted
+	// by a single DeleteObjects request.
+	maxDeleteObjects = 1000
+
+	// maxXMLBodySize is the maximum size of the XML body of a request.
+	maxXMLBodySize = 1 << 21 // 2 MiB
+)
+
+// objectInfo contains the information about an object which is required for
+// listing it.
+type objectInfo struct {
+	key     string
+	size    uint64
+	modTime time.Time
+	etag    string
+}
+
+// objectETag returns the ETag of an object. The renter doesn't store the MD5
+// of a file's contents so the ETag is derived from the file's siapath, size
+// and modification time instead. The '-1' suffix signals S3 clients that the
+// ETag is not the MD5 of the object, like for objects created by multipart
+// uploads.
+func objectETag(fi modules.FileInfo) string {
+	h := crypto.HashAll(fi.TurtleDexPath.String(), fi.Filesize, fi.ModificationTime.UnixNano())
+	return `"` + hex.EncodeToString(h[:16]) + `-1"`
+}
+
+// listBuckets handles a ListBuckets request.
+func (g *Gateway) listBuckets(w http.ResponseWriter, req *http.Request) {
+	dis, err := g.staticRenter.DirList(modules.UserFolder)
+	if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+		writeError(w, req, toAPIError(err, errInternalError))
+		return
+	}
+	result := listAllMyBucketsResult{
+		Owner:   defaultOwner,
+		Buckets: []bucketXML{},
+	}
+	for _, di := range dis {
+		if di.TurtleDexPath.Equals(modules.UserFolder) {
+			continue
+		}
+		name := di.TurtleDexPath.Name()
+		if !validBucketName(name) {
+			continue
+		}
+		result.Buckets = append(result.Buckets, bucketXML{
+			Name:         name,
+			CreationDate: formatTime(di.MostRecentModTime),
+		})
+	}
+	sort.Slice(result.Buckets, func(i, j int) bool {
+		return result.Buckets[i].Name < result.Buckets[j].Name
+	})
+	writeXML(w, result)
+}
+
+// getBucketLocation handles a GetBucketLocation request. The gateway doesn't
+// have a region so the location is always empty which corresponds to
+// us-east-1.
+func (g *Gateway) getBucketLocation(w http.ResponseWriter, req *http.Request, bucket string) {
+	if err := g.managedCheckBucket(bucket); err != nil {
+		writeError(w, req, toAPIError(err, errNoSuchBucket))
+		return
+	}
+	writeXML(w, locationConstraint{})
+}
+
+// headBucket handles a HeadBucket request.
+func (g *Gateway) headBucket(w http.ResponseWriter, req *http.Request, bucket string) {
+	if err := g.managedCheckBucket(bucket); err != nil {
+		writeError(w, req, toAPIError(err, errNoSuchBucket))
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// createBucket handles a CreateBucket request.
+func (g *Gateway) createBucket(w http.ResponseWriter, req *http.Request, bucket string) {
+	sp, err := bucketPath(bucket)
+	if err != nil {
+		writeError(w, req, errInvalidBucketName)
+		return
+	}
+	err = g.staticRenter.CreateDir(sp, modules.DefaultDirPerm)
+	if errors.Contains(err, filesystem.ErrExists) {
+		writeError(w, req, errBucketAlreadyOwnedByYou)
+		return
+	}
+	if err != nil {
+		writeError(w, req, toAPIError(err, errInternalError))
+		return
+	}
+	w.Header().Set("Location", "/"+bucket)
+	w.WriteHeader(http.StatusOK)
+}
+
+// deleteBucket handles a DeleteBucket request. Only buckets without any
+// objects can be deleted.
+func (g *Gateway) deleteBucket(w http.ResponseWriter, req *http.Request, bucket string) {
+	sp, err := bucketPath(bucket)
+	if err != nil {
+		writeError(w, req, errInvalidBucketName)
+		return
+	}
+	empty, err := g.managedDirIsEmpty(sp)
+	if err != nil {
+		writeError(w, req, toAPIError(err, errNoSuchBucket))
+		return
+	}
+	if !empty {
+		writeError(w, req, errBucketNotEmpty)
+		return
+	}
+	if err := g.staticRenter.DeleteDir(sp); err != nil {
+		writeError(w, req, toAPIError(err, errNoSuchBucket))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listObjects handles both the ListObjects and the ListObjectsV2 request.
+func (g *Gateway) listObjects(w http.ResponseWriter, req *http.Request, bucket string) {
+	query := req.URL.Query()
+	prefix := query.Get("prefix")
+	delimiter := query.Get("delimiter")
+	encodingType := query.Get("encoding-type")
+	if encodingType != "" && encodingType != "url" {
+		writeError(w, req, errInvalidArgument)
+		return
+	}
+	maxKeys := defaultMaxKeys
+	if mk := query.Get("max-keys"); mk != "" {
+		n, err := strconv.Atoi(mk)
+		if err != nil || n < 0 {
+			writeError(w, req, errInvalidArgument)
+			return
+		}
+		if n < maxKeys {
+			maxKeys = n
+		}
+	}
+
+	// Figure out where to continue the listing.
+	v2 := query.Get("list-type") == "2"
+	var after string
+	if v2 {
+		after = query.Get("start-after")
+		if token := query.Get("continuation-token"); token != "" {
+			b, err := base64.RawURLEncoding.DecodeString(token)
+			if err != nil {
+				writeError(w, req, errInvalidArgument)
+				return
+			}
+			after = string(b)
+		}
+	} else {
+		after = query.Get("marker")
+	}
+
+	// Fetch the objects.
+	objects, err := g.managedBucketObjects(bucket, prefix)
+	if err != nil {
+		writeError(w, req, toAPIError(err, errNoSuchBucket))
+		return
+	}
+	contents, prefixes, truncated, next := listObjectsPage(objects, prefix, delimiter, after, maxKeys)
+
+	// Build the response.
+	encode := func(s string) string {
+		if encodingType == "url" {
+			return uriEncode(s, false)
+		}
+		return s
+	}
+	fetchOwner := !v2 || query.Get("fetch-owner") == "true"
+	contentsXML := make([]objectXML, 0, len(contents))
+	for _, oi := range contents {
+		o := objectXML{
+			Key:          encode(oi.key),
+			LastModified: formatTime(oi.modTime),
+			ETag:         oi.etag,
+			Size:         oi.size,
+			StorageClass: storageClass,
+		}
+		if fetchOwner {
+			o.Owner = &defaultOwner
+		}
+		contentsXML = append(contentsXML, o)
+	}
+	prefixesXML := make([]commonPrefix, 0, len(prefixes))
+	for _, p := range prefixes {
+		prefixesXML = append(prefixesXML, commonPrefix{Prefix: encode(p)})
+	}
+
+	if v2 {
+		result := listBucketResultV2{
+			Name:              bucket,
+			Prefix:            encode(prefix),
+			StartAfter:        encode(query.Get("start-after")),
+			ContinuationToken: query.Get("continuation-token"),
+			KeyCount:          len(contentsXML) + len(prefixesXML),
+			MaxKeys:           maxKeys,
+			Delimiter:         encode(delimiter),
+			IsTruncated:       truncated,
+			EncodingType:      encodingType,
+			Contents:          contentsXML,
+			CommonPrefixes:    prefixesXML,
+		}
+		if truncated {
+			result.NextContinuationToken = base64.RawURLEncoding.EncodeToString([]byte(next))
+		}
+		writeXML(w, result)
+		return
+	}
+	result := listBucketResult{
+		Name:           bucket,
+		Prefix:         encode(prefix),
+		Marker:         encode(after),
+		MaxKeys:        maxKeys,
+		Delimiter:      encode(delimiter),
+		IsTruncated:    truncated,
+		EncodingType:   encodingType,
+		Contents:       contentsXML,
+		CommonPrefixes: prefixesXML,
+	}
+	if truncated {
+		result.NextMarker = encode(next)
+	}
+	writeXML(w, result)
+}
+
+// getObject handles both the GetObject and the HeadObject request. Range
+// requests are served by seeking within the renter's streamer.
+func (g *Gateway) getObject(w http.ResponseWriter, req *http.Request, bucket, key string) {
+	sp, err := objectPath(bucket, key)
+	if err != nil {
+		writeError(w, req, errNoSuchKey)
+		return
+	}
+	fi, err := g.staticRenter.File(sp)
+	if err != nil {
+		writeError(w, req, toAPIError(err, errNoSuchKey))
+		return
+	}
+	name, streamer, err := g.staticRenter.Streamer(sp, false)
+	if err != nil {
+		writeError(w, req, toAPIError(err, errNoSuchKey))
+		return
+	}
+	defer streamer.Close()
+
+	w.Header().Set("ETag", objectETag(fi))
+	http.ServeContent(w, req, name, fi.ModificationTime, streamer)
+}
+
+// putObject handles a PutObject request. Existing objects are overwritten.
+// Keys ending with a slash create an empty directory.
+func (g *Gateway) putObject(w http.ResponseWriter, req *http.Request, bucket, key string) {
+	if err := g.managedCheckBucket(bucket); err != nil {
+		writeError(w, req, toAPIError(err, errNoSuchBucket))
+		return
+	}
+	sp, err := objectPath(bucket, key)
+	if err != nil {
+		writeError(w, req, toAPIError(err, errInvalidArgument))
+		return
+	}
+
+	// Hash the body to return its MD5 as the ETag.
+	h := md5.New()
+	body := io.TeeReader(req.Body, h)
+
+	if strings.HasSuffix(key, "/") {
+		// Directory markers can't contain any data.
+		if n, err := io.Copy(h, req.Body); err != nil || n > 0 {
+			writeError(w, req, errInvalidArgument)
+			return
+		}
+		err = g.staticRenter.CreateDir(sp, modules.DefaultDirPerm)
+		if err != nil && !errors.Contains(err, filesystem.ErrExists) {
+			writeError(w, req, toAPIError(err, errInternalError))
+			return
+		}
+	} else {
+		// Upload the object to a temporary path first to not replace the
+		// existing object before the upload succeeded.
+		tmpPath, err := tmpObjectPath(hex.EncodeToString(fastrand.Bytes(16)))
+		if err != nil {
+			writeError(w, req, toAPIError(err, errInternalError))
+			return
+		}
+		up := modules.FileUploadParams{
+			TurtleDexPath: tmpPath,
+			CipherType:    crypto.TypeDefaultRenter,
+		}
+		if err := g.staticRenter.UploadStreamFromReader(up, body); err != nil {
+			// Don't leave a partial file behind.
+			_ = deleteTmpObject(g.staticRenter, tmpPath)
+			writeError(w, req, toAPIError(err, errInternalError))
+			return
+		}
+		if err := g.staticReplaceObject(tmpPath, sp); err != nil {
+			writeError(w, req, toAPIError(err, errInternalError))
+			return
+		}
+	}
+	w.Header().Set("ETag", `"`+hex.EncodeToString(h.Sum(nil))+`"`)
+	w.WriteHeader(http.StatusOK)
+}
+
+// staticReplaceObject replaces the object at sp with the uploaded file at
+// tmpPath. The replaced object is only removed once the uploaded file took its
+// place and is archived if versioning is enabled. If the replacement fails,
+// the replaced object is restored and the uploaded file is deleted.
+func (g *Gateway) staticReplaceObject(tmpPath, sp modules.TurtleDexPath) error {
+	err := g.staticRenter.ReplaceFile(tmpPath, sp)
+	if err != nil {
+		return errors.Compose(err, deleteTmpObject(g.staticRenter, tmpPath))
+	}
+	return nil
+}
+
+// tmpObjectPath returns the temporary path within the renter's var folder at
+// which an object with the provided id is uploaded before it replaces the
+// object at its final path.
+func tmpObjectPath(id string) (modules.TurtleDexPath, error) {
+	return modules.VarFolder.Join(multipartDir + "/" + id)
+}
+
+// deleteTmpObject deletes a temporarily uploaded object. The object was never
+// visible to clients, so it is deleted without being archived.
+func deleteTmpObject(r modules.Renter, tmpPath modules.TurtleDexPath) error {
+	err := r.DeleteFileUnversioned(tmpPath)
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// deleteObject handles a DeleteObject request. Deleting an object that
+// doesn't exist is not an error.
+func (g *Gateway) deleteObject(w http.ResponseWriter, req *http.Request, bucket, key string) {
+	if err := g.managedDeleteObject(bucket, key); err != nil {
+		writeError(w, req, toAPIError(err, errInternalError))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// deleteObjects handles a DeleteObjects request.
+func (g *Gateway) deleteObjects(w http.ResponseWriter, req *http.Request, bucket string) {
+	var dr deleteRequest
+	if err := xml.NewDecoder(io.LimitReader(req.Body, maxXMLBodySize)).Decode(&dr); err != nil {
+		writeError(w, req, toAPIError(err, errMalformedXML))
+		return
+	}
+	if len(dr.Objects) == 0 || len(dr.Objects) > maxDeleteObjects {
+		writeError(w, req, errMalformedXML)
+		return
+	}
+	result := deleteResult{}
+	for _, obj := range dr.Objects {
+		err := g.managedDeleteObject(bucket, obj.Key)
+		if err != nil {
+			apiErr := toAPIError(err, errInternalError)
+			result.Errors = append(result.Errors, deleteErrorXML{
+				Key:     obj.Key,
+				Code:    apiErr.Code,
+				Message: apiErr.Message,
+			})
+			continue
+		}
+		if !dr.Quiet {
+			result.Deleted = append(result.Deleted, deletedXML{Key: obj.Key})
+		}
+	}
+	writeXML(w, result)
+}
+
+// managedCheckBucket returns an error if the bucket doesn't exist.
+func (g *Gateway) managedCheckBucket(bucket string) error {
+	sp, err := bucketPath(bucket)
+	if err != nil {
+		return errInvalidBucketName
+	}
+	_, err = g.staticRenter.DirList(sp)
+	return err
+}
+
+// managedDirIsEmpty returns whether the directory doesn't contain any files.
+// Empty subdirectories are ignored since they are not visible to S3 clients.
+func (g *Gateway) managedDirIsEmpty(sp modules.TurtleDexPath) (bool, error) {
+	var mu sync.Mutex
+	empty := true
+	err := g.staticRenter.FileList(sp, true, true, func(modules.FileInfo) {
+		mu.Lock()
+		empty = false
+		mu.Unlock()
+	})
+	return empty, err
+}
+
+// managedDeleteObject deletes the file backing an object. Keys ending with a
+// slash delete the corresponding directory if it doesn't contain any files.
+func (g *Gateway) managedDeleteObject(bucket, key string) error {
+	sp, err := objectPath(bucket, key)
+	if err != nil {
+		return err
+	}
+	if strings.HasSuffix(key, "/") {
+		empty, err := g.managedDirIsEmpty(sp)
+		if errors.Contains(err, filesystem.ErrNotExist) {
+			return nil
+		}
+		if err != nil || !empty {
+			return err
+		}
+		err = g.staticRenter.DeleteDir(sp)
+		if errors.Contains(err, filesystem.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	err = g.staticRenter.DeleteFile(sp)
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// managedBucketObjects returns all the objects within a bucket which start
+// with the provided prefix, sorted by key.
+func (g *Gateway) managedBucketObjects(bucket, prefix string) ([]objectInfo, error) {
+	bucketSP, err := bucketPath(bucket)
+	if err != nil {
+		return nil, errInvalidBucketName
+	}
+	if err := g.managedCheckBucket(bucket); err != nil {
+		return nil, err
+	}
+
+	// Only list the deepest directory which contains all the matching keys.
+	root := bucketSP
+	if i := strings.LastIndex(prefix, "/"); i > 0 {
+		root, err = bucketSP.Join(prefix[:i])
+		if err != nil {
+			// The prefix can't match any valid siapath.
+			return nil, nil
+		}
+	}
+
+	var objects []objectInfo
+	var mu sync.Mutex
+	err = g.staticRenter.FileList(root, true, true, func(fi modules.FileInfo) {
+		key := strings.TrimPrefix(fi.TurtleDexPath.Path, bucketSP.Path+"/")
+		if !strings.HasPrefix(key, prefix) {
+			return
+		}
+		mu.Lock()
+		objects = append(objects, objectInfo{
+			key:     key,
+			size:    fi.Filesize,
+			modTime: fi.ModificationTime,
+			etag:    objectETag(fi),
+		})
+		mu.Unlock()
+	})
+	if errors.Contains(err, filesystem.ErrNotExist) && !root.Equals(bucketSP) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].key < objects[j].key
+	})
+	return objects, nil
+}
+
+// listObjectsPage returns a single page of a listing. The objects need to be
+// sorted by key. Keys which contain the delimiter after the prefix are rolled
+// up into a common prefix. Only keys and prefixes greater than after are
+// returned. If the listing is truncated, next is the last key or prefix of the
+// page.
+func listObjectsPage(objects []objectInfo, prefix, delimiter, after string, maxKeys int) (contents []objectInfo, prefixes []string, truncated bool, next string) {
+	var lastPrefix string
+	for _, oi := range objects {
+		if oi.key <= after || !strings.HasPrefix(oi.key, prefix) {
+			continue
+		}
+
+		// Check if the key belongs to a common prefix.
+		var cp string
+		if delimiter != "" {
+			if i := strings.Index(oi.key[len(prefix):], delimiter); i != -1 {
+				cp = oi.key[:len(prefix)+i+len(delimiter)]
+			}
+		}
+		if cp != "" && (cp == lastPrefix || cp <= after) {
+			continue
+		}
+
+		// Check if the page is full.
+		if len(contents)+len(prefixes) >= maxKeys {
+			truncated = maxKeys > 0
+			break
+		}
+		if cp != "" {
+			prefixes = append(prefixes, cp)
+			lastPrefix = cp
+			next = cp
+		} else {
+			contents = append(contents, oi)
+			next = oi.key
+		}
+	}
+	if !truncated {
+		next = ""
+	}
+	return
+}
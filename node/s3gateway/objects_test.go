@@ -0,0 +1,79 @@
+package s3gateway
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestListObjectsPage tests paginating a listing with and without a
+// delimiter.
+func TestListObjectsPage(t *testing.T) {
+	var objects []objectInfo
+	for _, key := range []string{"a", "b/1", "b/2", "b/c/3", "c", "d/4"} {
+		objects = append(objects, objectInfo{key: key})
+	}
+	keys := func(ois []objectInfo) []string {
+		var keys []string
+		for _, oi := range ois {
+			keys = append(keys, oi.key)
+		}
+		return keys
+	}
+
+	tests := []struct {
+		prefix    string
+		delimiter string
+		after     string
+		maxKeys   int
+
+		contents  []string
+		prefixes  []string
+		truncated bool
+		next      string
+	}{
+		// List everything.
+		{"", "", "", 1000, []string{"a", "b/1", "b/2", "b/c/3", "c", "d/4"}, nil, false, ""},
+		// Paginate.
+		{"", "", "", 2, []string{"a", "b/1"}, nil, true, "b/1"},
+		{"", "", "b/1", 2, []string{"b/2", "b/c/3"}, nil, true, "b/c/3"},
+		{"", "", "b/c/3", 2, []string{"c", "d/4"}, nil, false, ""},
+		// Use a delimiter.
+		{"", "/", "", 1000, []string{"a", "c"}, []string{"b/", "d/"}, false, ""},
+		{"", "/", "", 2, []string{"a"}, []string{"b/"}, true, "b/"},
+		{"", "/", "b/", 2, []string{"c"}, []string{"d/"}, false, ""},
+		// Use a prefix.
+		{"b/", "/", "", 1000, []string{"b/1", "b/2"}, []string{"b/c/"}, false, ""},
+		{"b/", "", "", 1000, []string{"b/1", "b/2", "b/c/3"}, nil, false, ""},
+		{"x", "", "", 1000, nil, nil, false, ""},
+		// No keys.
+		{"", "", "", 0, nil, nil, false, ""},
+	}
+	for i, test := range tests {
+		contents, prefixes, truncated, next := listObjectsPage(objects, test.prefix, test.delimiter, test.after, test.maxKeys)
+		if !reflect.DeepEqual(keys(contents), test.contents) {
+			t.Errorf("%v: expected contents %v but got %v", i, test.contents, keys(contents))
+		}
+		if !reflect.DeepEqual(prefixes, test.prefixes) {
+			t.Errorf("%v: expected prefixes %v but got %v", i, test.prefixes, prefixes)
+		}
+		if truncated != test.truncated || next != test.next {
+			t.Errorf("%v: expected %v %v but got %v %v", i, test.truncated, test.next, truncated, next)
+		}
+	}
+}
+
+// TestValidBucketName tests the validation of bucket names.
+func TestValidBucketName(t *testing.T) {
+	valid := []string{"abc", "my-bucket", "my.bucket", "123"}
+	invalid := []string{"", "ab", "ABC", "-abc", "abc-", "a..b", "a_b", "a/b"}
+	for _, name := range valid {
+		if !validBucketName(name) {
+			t.Errorf("%v should be valid", name)
+		}
+	}
+	for _, name := range invalid {
+		if validBucketName(name) {
+			t.Errorf("%v should be invalid", name)
+		}
+	}
+}
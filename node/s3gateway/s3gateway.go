@@ -0,0 +1,219 @@
+// Package s3gateway provides an http.Handler which exposes the renter's
+// filesystem using a subset of the Amazon S3 REST API.
+//
+// Buckets are mapped to the top-level directories of the renter's user folder
+// and object keys are mapped to the siapaths of the files within these
+// directories. Only path-style requests are supported, e.g.
+// http://localhost:9985/bucket/key. Requests are authenticated using AWS
+// Signature Version 4 with a single pair of access keys.
+package s3gateway
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+)
+
+var (
+	// errNilRenter is returned by New if no renter was provided.
+	errNilRenter = errors.New("s3 gateway requires a renter")
+
+	// errNoCredentials is returned by New if the credentials are incomplete.
+	errNoCredentials = errors.New("s3 gateway requires an access key id and a secret access key")
+)
+
+type (
+	// Credentials are the access keys which are accepted by the gateway.
+	Credentials struct {
+		AccessKeyID     string
+		SecretAccessKey string
+	}
+
+	// Gateway is an http.Handler which translates S3 requests into calls to
+	// the renter.
+	Gateway struct {
+		staticCredentials Credentials
+		staticRenter      modules.Renter
+		staticStagingDir  string
+
+		// uploads contains the in-progress multipart uploads.
+		uploads map[string]*multipartUpload
+		closed  bool
+		mu      sync.Mutex
+	}
+)
+
+// New creates a new Gateway for the provided renter. The staging directory is
+// used to store the parts of multipart uploads on disk.
+func New(r modules.Renter, creds Credentials, stagingDir string) (*Gateway, error) {
+	if r == nil {
+		return nil, errNilRenter
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return nil, errNoCredentials
+	}
+	return &Gateway{
+		staticCredentials: creds,
+		staticRenter:      r,
+		staticStagingDir:  stagingDir,
+		uploads:           make(map[string]*multipartUpload),
+	}, nil
+}
+
+// Close aborts all in-progress multipart uploads.
+func (g *Gateway) Close() error {
+	g.mu.Lock()
+	g.closed = true
+	uploads := g.uploads
+	g.uploads = make(map[string]*multipartUpload)
+	g.mu.Unlock()
+
+	var err error
+	for _, u := range uploads {
+		err = errors.Compose(err, u.managedAbort())
+	}
+	return errors.AddContext(err, "failed to abort multipart uploads")
+}
+
+// ServeHTTP implements http.Handler.
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	// Authenticate the request and wrap the body to verify its signature.
+	body, err := g.staticAuthenticate(req)
+	if err != nil {
+		writeError(w, req, toAPIError(err, errAccessDenied))
+		return
+	}
+	req.Body = body
+
+	// Split the path into bucket and key.
+	bucket, key := splitPath(req.URL.Path)
+	query := req.URL.Query()
+
+	switch {
+	case bucket == "":
+		g.serveService(w, req)
+	case key == "":
+		g.serveBucket(w, req, bucket, query)
+	default:
+		g.serveObject(w, req, bucket, key, query)
+	}
+}
+
+// serveService handles requests which don't specify a bucket.
+func (g *Gateway) serveService(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		writeError(w, req, errMethodNotAllowed)
+		return
+	}
+	g.listBuckets(w, req)
+}
+
+// serveBucket handles requests for a bucket.
+func (g *Gateway) serveBucket(w http.ResponseWriter, req *http.Request, bucket string, query map[string][]string) {
+	if !validBucketName(bucket) {
+		writeError(w, req, errInvalidBucketName)
+		return
+	}
+	_, hasDelete := query["delete"]
+	_, hasLocation := query["location"]
+	_, hasUploads := query["uploads"]
+	switch {
+	case req.Method == http.MethodGet && hasLocation:
+		g.getBucketLocation(w, req, bucket)
+	case req.Method == http.MethodGet && hasUploads:
+		writeError(w, req, errNotImplemented)
+	case req.Method == http.MethodGet:
+		g.listObjects(w, req, bucket)
+	case req.Method == http.MethodHead:
+		g.headBucket(w, req, bucket)
+	case req.Method == http.MethodPut:
+		g.createBucket(w, req, bucket)
+	case req.Method == http.MethodDelete:
+		g.deleteBucket(w, req, bucket)
+	case req.Method == http.MethodPost && hasDelete:
+		g.deleteObjects(w, req, bucket)
+	default:
+		writeError(w, req, errMethodNotAllowed)
+	}
+}
+
+// serveObject handles requests for an object.
+func (g *Gateway) serveObject(w http.ResponseWriter, req *http.Request, bucket, key string, query map[string][]string) {
+	if !validBucketName(bucket) {
+		writeError(w, req, errInvalidBucketName)
+		return
+	}
+	_, hasUploads := query["uploads"]
+	_, hasUploadID := query["uploadId"]
+	switch {
+	case req.Method == http.MethodGet && hasUploadID:
+		writeError(w, req, errNotImplemented)
+	case req.Method == http.MethodGet:
+		g.getObject(w, req, bucket, key)
+	case req.Method == http.MethodHead:
+		g.getObject(w, req, bucket, key)
+	case req.Method == http.MethodPut && hasUploadID:
+		g.uploadPart(w, req, bucket, key)
+	case req.Method == http.MethodPut && req.Header.Get("X-Amz-Copy-Source") != "":
+		writeError(w, req, errNotImplemented)
+	case req.Method == http.MethodPut:
+		g.putObject(w, req, bucket, key)
+	case req.Method == http.MethodDelete && hasUploadID:
+		g.abortMultipartUpload(w, req, bucket, key)
+	case req.Method == http.MethodDelete:
+		g.deleteObject(w, req, bucket, key)
+	case req.Method == http.MethodPost && hasUploads:
+		g.createMultipartUpload(w, req, bucket, key)
+	case req.Method == http.MethodPost && hasUploadID:
+		g.completeMultipartUpload(w, req, bucket, key)
+	default:
+		writeError(w, req, errMethodNotAllowed)
+	}
+}
+
+// splitPath splits the path of a path-style request into the bucket and the
+// object key.
+func splitPath(path string) (bucket, key string) {
+	path = strings.TrimPrefix(path, "/")
+	i := strings.IndexByte(path, '/')
+	if i == -1 {
+		return path, ""
+	}
+	return path[:i], path[i+1:]
+}
+
+// validBucketName checks whether the name is a valid S3 bucket name. Only the
+// characters allowed by S3 are accepted which also guarantees that the name is
+// a valid siapath element.
+func validBucketName(name string) bool {
+	if len(name) < 3 || len(name) > 63 {
+		return false
+	}
+	for i, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+		case (c == '-' || c == '.') && i > 0 && i < len(name)-1:
+		default:
+			return false
+		}
+	}
+	return !strings.Contains(name, "..")
+}
+
+// bucketPath returns the siapath of the directory backing a bucket.
+func bucketPath(bucket string) (modules.TurtleDexPath, error) {
+	return modules.UserFolder.Join(bucket)
+}
+
+// objectPath returns the siapath of the file backing an object.
+func objectPath(bucket, key string) (modules.TurtleDexPath, error) {
+	sp, err := modules.UserFolder.Join(bucket + "/" + key)
+	if err != nil {
+		return modules.TurtleDexPath{}, errInvalidArgument
+	}
+	return sp, nil
+}
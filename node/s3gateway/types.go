@@ -0,0 +1,162 @@
+package s3gateway
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+const (
+	// iso8601Format is the time format used in XML responses.
+	iso8601Format = "2006-01-02T15:04:05.000Z"
+
+	// ownerID is the id of the single owner of all buckets and objects.
+	ownerID = "turtledex"
+
+	// storageClass is the storage class reported for all objects.
+	storageClass = "STANDARD"
+)
+
+type (
+	// owner is the owner of a bucket or object.
+	owner struct {
+		ID          string `xml:"ID"`
+		DisplayName string `xml:"DisplayName"`
+	}
+
+	// bucketXML describes a single bucket in a ListAllMyBucketsResult.
+	bucketXML struct {
+		Name         string `xml:"Name"`
+		CreationDate string `xml:"CreationDate"`
+	}
+
+	// listAllMyBucketsResult is the response to a ListBuckets request.
+	listAllMyBucketsResult struct {
+		XMLName xml.Name    `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListAllMyBucketsResult"`
+		Owner   owner       `xml:"Owner"`
+		Buckets []bucketXML `xml:"Buckets>Bucket"`
+	}
+
+	// locationConstraint is the response to a GetBucketLocation request.
+	locationConstraint struct {
+		XMLName  xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ LocationConstraint"`
+		Location string   `xml:",chardata"`
+	}
+
+	// objectXML describes a single object in a ListBucketResult.
+	objectXML struct {
+		Key          string `xml:"Key"`
+		LastModified string `xml:"LastModified"`
+		ETag         string `xml:"ETag"`
+		Size         uint64 `xml:"Size"`
+		StorageClass string `xml:"StorageClass"`
+		Owner        *owner `xml:"Owner,omitempty"`
+	}
+
+	// commonPrefix describes a group of keys which were rolled up into a
+	// single prefix in a ListBucketResult.
+	commonPrefix struct {
+		Prefix string `xml:"Prefix"`
+	}
+
+	// listBucketResult is the response to a ListObjects request.
+	listBucketResult struct {
+		XMLName        xml.Name       `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListBucketResult"`
+		Name           string         `xml:"Name"`
+		Prefix         string         `xml:"Prefix"`
+		Marker         string         `xml:"Marker"`
+		NextMarker     string         `xml:"NextMarker,omitempty"`
+		MaxKeys        int            `xml:"MaxKeys"`
+		Delimiter      string         `xml:"Delimiter,omitempty"`
+		IsTruncated    bool           `xml:"IsTruncated"`
+		EncodingType   string         `xml:"EncodingType,omitempty"`
+		Contents       []objectXML    `xml:"Contents"`
+		CommonPrefixes []commonPrefix `xml:"CommonPrefixes"`
+	}
+
+	// listBucketResultV2 is the response to a ListObjectsV2 request.
+	listBucketResultV2 struct {
+		XMLName               xml.Name       `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListBucketResult"`
+		Name                  string         `xml:"Name"`
+		Prefix                string         `xml:"Prefix"`
+		StartAfter            string         `xml:"StartAfter,omitempty"`
+		ContinuationToken     string         `xml:"ContinuationToken,omitempty"`
+		NextContinuationToken string         `xml:"NextContinuationToken,omitempty"`
+		KeyCount              int            `xml:"KeyCount"`
+		MaxKeys               int            `xml:"MaxKeys"`
+		Delimiter             string         `xml:"Delimiter,omitempty"`
+		IsTruncated           bool           `xml:"IsTruncated"`
+		EncodingType          string         `xml:"EncodingType,omitempty"`
+		Contents              []objectXML    `xml:"Contents"`
+		CommonPrefixes        []commonPrefix `xml:"CommonPrefixes"`
+	}
+
+	// deleteRequest is the body of a DeleteObjects request.
+	deleteRequest struct {
+		XMLName xml.Name `xml:"Delete"`
+		Quiet   bool     `xml:"Quiet"`
+		Objects []struct {
+			Key string `xml:"Key"`
+		} `xml:"Object"`
+	}
+
+	// deletedXML describes an object which was deleted by a DeleteObjects
+	// request.
+	deletedXML struct {
+		Key string `xml:"Key"`
+	}
+
+	// deleteErrorXML describes an object which couldn't be deleted by a
+	// DeleteObjects request.
+	deleteErrorXML struct {
+		Key     string `xml:"Key"`
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+
+	// deleteResult is the response to a DeleteObjects request.
+	deleteResult struct {
+		XMLName xml.Name         `xml:"http://s3.amazonaws.com/doc/2006-03-01/ DeleteResult"`
+		Deleted []deletedXML     `xml:"Deleted"`
+		Errors  []deleteErrorXML `xml:"Error"`
+	}
+
+	// initiateMultipartUploadResult is the response to a
+	// CreateMultipartUpload request.
+	initiateMultipartUploadResult struct {
+		XMLName  xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ InitiateMultipartUploadResult"`
+		Bucket   string   `xml:"Bucket"`
+		Key      string   `xml:"Key"`
+		UploadID string   `xml:"UploadId"`
+	}
+
+	// completeMultipartUpload is the body of a CompleteMultipartUpload
+	// request.
+	completeMultipartUpload struct {
+		XMLName xml.Name `xml:"CompleteMultipartUpload"`
+		Parts   []struct {
+			PartNumber int    `xml:"PartNumber"`
+			ETag       string `xml:"ETag"`
+		} `xml:"Part"`
+	}
+
+	// completeMultipartUploadResult is the response to a
+	// CompleteMultipartUpload request.
+	completeMultipartUploadResult struct {
+		XMLName  xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ CompleteMultipartUploadResult"`
+		Location string   `xml:"Location"`
+		Bucket   string   `xml:"Bucket"`
+		Key      string   `xml:"Key"`
+		ETag     string   `xml:"ETag"`
+	}
+)
+
+// defaultOwner is the owner reported for all buckets and objects.
+var defaultOwner = owner{
+	ID:          ownerID,
+	DisplayName: ownerID,
+}
+
+// formatTime formats a time for an XML response.
+func formatTime(t time.Time) string {
+	return t.UTC().Format(iso8601Format)
+}
@@ -0,0 +1,434 @@
+package webdav
+
+import (
+	"context"
+	"io"
+	"mime"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/turtledex/errors"
+	dav "golang.org/x/net/webdav"
+
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/modules/renter/filesystem"
+	"github.com/turtledex/TurtleDexCore/persist"
+)
+
+var (
+	// errIsDir is returned when reading from or writing to a directory.
+	errIsDir = errors.New("path is a directory")
+
+	// errNotDir is returned when listing the entries of a file.
+	errNotDir = errors.New("path is not a directory")
+
+	// errReadOnly is returned when writing to a file which was opened for
+	// reading.
+	errReadOnly = errors.New("file was opened for reading")
+
+	// errWriteOnly is returned when reading from a file which was opened for
+	// writing.
+	errWriteOnly = errors.New("file was opened for writing")
+)
+
+type (
+	// fileSystem implements webdav.FileSystem for the renter's user folder.
+	fileSystem struct {
+		staticRenter modules.Renter
+	}
+
+	// fileInfo wraps a modules.FileInfo to avoid sniffing the content type
+	// of files by downloading them when listing directories.
+	fileInfo struct {
+		modules.FileInfo
+	}
+
+	// dirFile is a webdav.File for a directory of the renter.
+	dirFile struct {
+		staticInfo    modules.DirectoryInfo
+		staticEntries []os.FileInfo
+		pos           int
+	}
+
+	// streamFile is a webdav.File which streams a file from the renter.
+	streamFile struct {
+		modules.Streamer
+		staticInfo os.FileInfo
+	}
+
+	// uploadFile is a webdav.File which streams the data written to it to the
+	// renter. The upload is complete once the file is closed.
+	uploadFile struct {
+		staticCtx  context.Context
+		staticDone chan error
+		staticName string
+		staticPipe *io.PipeWriter
+
+		written int64
+		mu      sync.Mutex
+	}
+
+	// uploadInfo is the os.FileInfo of a file which is being uploaded.
+	uploadInfo struct {
+		name    string
+		size    int64
+		modTime time.Time
+	}
+)
+
+// davTurtleDexPath converts the name of a WebDAV resource into a siapath
+// within the user folder.
+func davTurtleDexPath(name string) (modules.TurtleDexPath, error) {
+	name = strings.Trim(path.Clean("/"+name), "/")
+	if name == "" {
+		return modules.UserFolder, nil
+	}
+	sp, err := modules.UserFolder.Join(name)
+	if err != nil {
+		return modules.TurtleDexPath{}, os.ErrNotExist
+	}
+	return sp, nil
+}
+
+// toOSError converts the renter's filesystem errors into the errors expected
+// by the webdav handler.
+func toOSError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Contains(err, filesystem.ErrNotExist):
+		return os.ErrNotExist
+	case errors.Contains(err, filesystem.ErrExists):
+		return os.ErrExist
+	default:
+		return err
+	}
+}
+
+// Mkdir implements webdav.FileSystem.
+func (fs *fileSystem) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	sp, err := davTurtleDexPath(name)
+	if err != nil {
+		return err
+	}
+	if sp.Equals(modules.UserFolder) {
+		return os.ErrExist
+	}
+	// WebDAV doesn't create missing parents.
+	if err := fs.managedParentExists(sp); err != nil {
+		return err
+	}
+	if _, err := fs.staticRenter.File(sp); err == nil {
+		return os.ErrExist
+	}
+	return toOSError(fs.staticRenter.CreateDir(sp, modules.DefaultDirPerm))
+}
+
+// OpenFile implements webdav.FileSystem. Files opened for writing are always
+// truncated and replace the existing file once they are closed.
+func (fs *fileSystem) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (dav.File, error) {
+	sp, err := davTurtleDexPath(name)
+	if err != nil {
+		return nil, err
+	}
+	if flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		return fs.managedOpenRead(sp)
+	}
+
+	// Check that the file can be created.
+	info, err := fs.managedStat(sp)
+	if err == nil && info.IsDir() {
+		return nil, errIsDir
+	} else if err == nil && flag&os.O_EXCL != 0 {
+		return nil, os.ErrExist
+	} else if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	} else if err != nil && flag&os.O_CREATE == 0 {
+		return nil, os.ErrNotExist
+	}
+	if err := fs.managedParentExists(sp); err != nil {
+		return nil, err
+	}
+
+	// Start the upload. The file is uploaded to a temporary path first to not
+	// replace the existing file before the upload succeeded.
+	tmpPath, err := modules.TempFolder.Join(persist.RandomSuffix())
+	if err != nil {
+		return nil, err
+	}
+	pr, pw := io.Pipe()
+	f := &uploadFile{
+		staticCtx:  ctx,
+		staticDone: make(chan error, 1),
+		staticName: sp.Name(),
+		staticPipe: pw,
+	}
+	go func() {
+		up := modules.FileUploadParams{
+			TurtleDexPath: tmpPath,
+			CipherType:    crypto.TypeDefaultRenter,
+		}
+		err := fs.staticRenter.UploadStreamFromReader(up, pr)
+		if err == nil {
+			err = fs.staticRenter.ReplaceFile(tmpPath, sp)
+		}
+		if err != nil {
+			// Don't leave a partially uploaded file behind. It was never
+			// visible to clients, so it is not archived.
+			_ = fs.staticRenter.DeleteFileUnversioned(tmpPath)
+		}
+		pr.CloseWithError(err)
+		f.staticDone <- err
+	}()
+	return f, nil
+}
+
+// RemoveAll implements webdav.FileSystem.
+func (fs *fileSystem) RemoveAll(ctx context.Context, name string) error {
+	sp, err := davTurtleDexPath(name)
+	if err != nil {
+		return err
+	}
+	if sp.Equals(modules.UserFolder) {
+		return os.ErrPermission
+	}
+	info, err := fs.managedStat(sp)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return toOSError(fs.staticRenter.DeleteDir(sp))
+	}
+	return toOSError(fs.staticRenter.DeleteFile(sp))
+}
+
+// Rename implements webdav.FileSystem.
+func (fs *fileSystem) Rename(ctx context.Context, oldName, newName string) error {
+	oldPath, err := davTurtleDexPath(oldName)
+	if err != nil {
+		return err
+	}
+	newPath, err := davTurtleDexPath(newName)
+	if err != nil {
+		return err
+	}
+	if oldPath.Equals(modules.UserFolder) || newPath.Equals(modules.UserFolder) {
+		return os.ErrPermission
+	}
+	info, err := fs.managedStat(oldPath)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return toOSError(fs.staticRenter.RenameDir(oldPath, newPath))
+	}
+	return toOSError(fs.staticRenter.RenameFile(oldPath, newPath))
+}
+
+// Stat implements webdav.FileSystem.
+func (fs *fileSystem) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	sp, err := davTurtleDexPath(name)
+	if err != nil {
+		return nil, err
+	}
+	return fs.managedStat(sp)
+}
+
+// managedDirInfo returns the info of a directory and the infos of its
+// entries.
+func (fs *fileSystem) managedDirInfo(sp modules.TurtleDexPath) (modules.DirectoryInfo, []modules.DirectoryInfo, error) {
+	dis, err := fs.staticRenter.DirList(sp)
+	if err != nil {
+		return modules.DirectoryInfo{}, nil, toOSError(err)
+	}
+	for i, di := range dis {
+		if di.TurtleDexPath.Equals(sp) {
+			return di, append(dis[:i:i], dis[i+1:]...), nil
+		}
+	}
+	return modules.DirectoryInfo{}, nil, os.ErrNotExist
+}
+
+// managedOpenRead opens a file or directory for reading.
+func (fs *fileSystem) managedOpenRead(sp modules.TurtleDexPath) (dav.File, error) {
+	fi, err := fs.staticRenter.File(sp)
+	if err == nil {
+		_, s, err := fs.staticRenter.Streamer(sp, false)
+		if err != nil {
+			return nil, toOSError(err)
+		}
+		return &streamFile{
+			Streamer:   s,
+			staticInfo: fileInfo{fi},
+		}, nil
+	} else if !errors.Contains(err, filesystem.ErrNotExist) {
+		return nil, err
+	}
+
+	// Not a file, try to open a directory.
+	di, subDirs, err := fs.managedDirInfo(sp)
+	if err != nil {
+		return nil, err
+	}
+	var entries []os.FileInfo
+	for _, subDir := range subDirs {
+		entries = append(entries, subDir)
+	}
+	var mu sync.Mutex
+	err = fs.staticRenter.FileList(sp, false, true, func(fi modules.FileInfo) {
+		mu.Lock()
+		entries = append(entries, fileInfo{fi})
+		mu.Unlock()
+	})
+	if err != nil {
+		return nil, toOSError(err)
+	}
+	return &dirFile{
+		staticInfo:    di,
+		staticEntries: entries,
+	}, nil
+}
+
+// managedParentExists returns os.ErrNotExist if the parent of the siapath is
+// not a directory.
+func (fs *fileSystem) managedParentExists(sp modules.TurtleDexPath) error {
+	parent, err := sp.Dir()
+	if err != nil {
+		return err
+	}
+	info, err := fs.managedStat(parent)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return os.ErrNotExist
+	}
+	return nil
+}
+
+// managedStat returns the info of a file or directory.
+func (fs *fileSystem) managedStat(sp modules.TurtleDexPath) (os.FileInfo, error) {
+	fi, err := fs.staticRenter.File(sp)
+	if err == nil {
+		return fileInfo{fi}, nil
+	} else if !errors.Contains(err, filesystem.ErrNotExist) {
+		return nil, err
+	}
+	di, _, err := fs.managedDirInfo(sp)
+	if err != nil {
+		return nil, err
+	}
+	return di, nil
+}
+
+// ContentType implements webdav.ContentTyper. The content type is determined
+// by the file's extension.
+func (fi fileInfo) ContentType(ctx context.Context) (string, error) {
+	if ct := mime.TypeByExtension(path.Ext(fi.Name())); ct != "" {
+		return ct, nil
+	}
+	return "application/octet-stream", nil
+}
+
+// Close implements io.Closer.
+func (f *dirFile) Close() error { return nil }
+
+// Read implements io.Reader.
+func (f *dirFile) Read([]byte) (int, error) { return 0, errIsDir }
+
+// Readdir implements http.File.
+func (f *dirFile) Readdir(count int) ([]os.FileInfo, error) {
+	remaining := f.staticEntries[f.pos:]
+	if count <= 0 {
+		f.pos = len(f.staticEntries)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	if count > len(remaining) {
+		count = len(remaining)
+	}
+	f.pos += count
+	return remaining[:count], nil
+}
+
+// Seek implements io.Seeker.
+func (f *dirFile) Seek(int64, int) (int64, error) { return 0, errIsDir }
+
+// Stat implements http.File.
+func (f *dirFile) Stat() (os.FileInfo, error) { return f.staticInfo, nil }
+
+// Write implements io.Writer.
+func (f *dirFile) Write([]byte) (int, error) { return 0, errIsDir }
+
+// Readdir implements http.File.
+func (f *streamFile) Readdir(int) ([]os.FileInfo, error) { return nil, errNotDir }
+
+// Stat implements http.File.
+func (f *streamFile) Stat() (os.FileInfo, error) { return f.staticInfo, nil }
+
+// Write implements io.Writer.
+func (f *streamFile) Write([]byte) (int, error) { return 0, errReadOnly }
+
+// Close implements io.Closer. It waits for the upload to finish. If the
+// request was aborted the upload is aborted as well.
+func (f *uploadFile) Close() error {
+	if err := f.staticCtx.Err(); err != nil {
+		f.staticPipe.CloseWithError(err)
+	} else {
+		f.staticPipe.Close()
+	}
+	return <-f.staticDone
+}
+
+// Read implements io.Reader.
+func (f *uploadFile) Read([]byte) (int, error) { return 0, errWriteOnly }
+
+// Readdir implements http.File.
+func (f *uploadFile) Readdir(int) ([]os.FileInfo, error) { return nil, errNotDir }
+
+// Seek implements io.Seeker.
+func (f *uploadFile) Seek(int64, int) (int64, error) { return 0, errWriteOnly }
+
+// Stat implements http.File.
+func (f *uploadFile) Stat() (os.FileInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return uploadInfo{
+		name:    f.staticName,
+		size:    f.written,
+		modTime: time.Now(),
+	}, nil
+}
+
+// Write implements io.Writer.
+func (f *uploadFile) Write(b []byte) (int, error) {
+	n, err := f.staticPipe.Write(b)
+	f.mu.Lock()
+	f.written += int64(n)
+	f.mu.Unlock()
+	return n, err
+}
+
+// Name implements os.FileInfo.
+func (ui uploadInfo) Name() string { return ui.name }
+
+// Size implements os.FileInfo.
+func (ui uploadInfo) Size() int64 { return ui.size }
+
+// Mode implements os.FileInfo.
+func (ui uploadInfo) Mode() os.FileMode { return modules.DefaultFilePerm }
+
+// ModTime implements os.FileInfo.
+func (ui uploadInfo) ModTime() time.Time { return ui.modTime }
+
+// IsDir implements os.FileInfo.
+func (ui uploadInfo) IsDir() bool { return false }
+
+// Sys implements os.FileInfo.
+func (ui uploadInfo) Sys() interface{} { return nil }
@@ -0,0 +1,119 @@
+// Package webdav provides an http.Handler which exposes the renter's
+// filesystem over WebDAV.
+//
+// The root of the WebDAV share is the renter's user folder. Requests are
+// authenticated using HTTP basic auth with either the API password or an API
+// token. Tokens with the read scope may only list and download files while
+// tokens with the renter scope have full access to the share.
+package webdav
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/turtledex/errors"
+	dav "golang.org/x/net/webdav"
+
+	"github.com/turtledex/TurtleDexCore/modules"
+)
+
+var (
+	// errNilRenter is returned by New if no renter was provided.
+	errNilRenter = errors.New("webdav server requires a renter")
+)
+
+type (
+	// Server is an http.Handler which translates WebDAV requests into calls
+	// to the renter.
+	Server struct {
+		staticHandler  *dav.Handler
+		staticPassword string
+		staticTokens   *modules.APITokens
+	}
+
+	// abortBody wraps a request body and cancels the request's context if the
+	// body can't be read completely.
+	abortBody struct {
+		io.ReadCloser
+		cancel context.CancelFunc
+	}
+)
+
+// New creates a new Server for the provided renter. An empty password disables
+// authentication, the same way it does for the API.
+func New(r modules.Renter, password string, tokens *modules.APITokens) (*Server, error) {
+	if r == nil {
+		return nil, errNilRenter
+	}
+	return &Server{
+		staticHandler: &dav.Handler{
+			FileSystem: &fileSystem{staticRenter: r},
+			LockSystem: dav.NewMemLS(),
+		},
+		staticPassword: password,
+		staticTokens:   tokens,
+	}, nil
+}
+
+// Read implements io.Reader.
+func (b *abortBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF {
+		b.cancel()
+	}
+	return n, err
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if !s.authorized(req) {
+		w.Header().Set("WWW-Authenticate", "Basic realm=\"TurtleDexWebDAV\"")
+		http.Error(w, "WebDAV authentication failed.", http.StatusUnauthorized)
+		return
+	}
+	// Uploads which are interrupted by the client are only detected by the
+	// failed read of the body. Cancel the request's context in that case to
+	// abort the upload instead of storing a truncated file.
+	if req.Method == http.MethodPut {
+		ctx, cancel := context.WithCancel(req.Context())
+		defer cancel()
+		req = req.WithContext(ctx)
+		req.Body = &abortBody{ReadCloser: req.Body, cancel: cancel}
+	}
+	s.staticHandler.ServeHTTP(w, req)
+}
+
+// authorized returns whether the request provides the password or a token with
+// the scope required by the request's method.
+func (s *Server) authorized(req *http.Request) bool {
+	// An empty password is equivalent to no password.
+	if s.staticPassword == "" {
+		return true
+	}
+	_, pass, ok := req.BasicAuth()
+	if !ok {
+		return false
+	}
+	if pass == s.staticPassword {
+		return true
+	}
+	if s.staticTokens == nil {
+		return false
+	}
+	if readOnlyMethod(req.Method) && s.staticTokens.Authorize(pass, modules.APIScopeRead) {
+		return true
+	}
+	return s.staticTokens.Authorize(pass, modules.APIScopeRenter)
+}
+
+// readOnlyMethod returns whether the WebDAV method doesn't modify the renter's
+// filesystem.
+func readOnlyMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, "PROPFIND":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,134 @@
+package webdav
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/turtledex/TurtleDexCore/build"
+	"github.com/turtledex/TurtleDexCore/modules"
+	"github.com/turtledex/TurtleDexCore/persist"
+)
+
+// TestDavTurtleDexPath tests converting WebDAV names into siapaths.
+func TestDavTurtleDexPath(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"", ""},
+		{"/", ""},
+		{"/a", "a"},
+		{"/a/b/", "a/b"},
+		{"a//b", "a/b"},
+		{"/../a", "a"},
+		{"/a/./b/../c", "a/c"},
+	}
+	for _, test := range tests {
+		sp, err := davTurtleDexPath(test.name)
+		if err != nil {
+			t.Fatal(test.name, err)
+		}
+		expected := modules.UserFolder
+		if test.path != "" {
+			expected, err = modules.UserFolder.Join(test.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if !sp.Equals(expected) {
+			t.Errorf("%v: expected %v but got %v", test.name, expected, sp)
+		}
+	}
+}
+
+// TestDirFileReaddir tests reading the entries of a directory in batches.
+func TestDirFileReaddir(t *testing.T) {
+	f := &dirFile{
+		staticEntries: []os.FileInfo{modules.FileInfo{}, modules.FileInfo{}, modules.FileInfo{}},
+	}
+	if entries, err := f.Readdir(2); err != nil || len(entries) != 2 {
+		t.Fatal("expected 2 entries", len(entries), err)
+	}
+	if entries, err := f.Readdir(2); err != nil || len(entries) != 1 {
+		t.Fatal("expected 1 entry", len(entries), err)
+	}
+	if _, err := f.Readdir(2); err != io.EOF {
+		t.Fatal("expected EOF but got", err)
+	}
+	if entries, err := f.Readdir(0); err != nil || len(entries) != 0 {
+		t.Fatal("expected no entries", len(entries), err)
+	}
+}
+
+// TestAuthorized tests authenticating requests using the API password and API
+// tokens.
+func TestAuthorized(t *testing.T) {
+	testDir := build.TempDir("webdav", t.Name())
+	if err := os.MkdirAll(testDir, persist.DefaultDiskPermissionsTest); err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := modules.NewAPITokens(filepath.Join(testDir, modules.APITokensName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, readToken, err := tokens.Create("read", []modules.APIScope{modules.APIScopeRead})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, renterToken, err := tokens.Create("renter", []modules.APIScope{modules.APIScopeRenter})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, walletToken, err := tokens.Create("wallet", []modules.APIScope{modules.APIScopeWalletSpend})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &Server{
+		staticPassword: "password",
+		staticTokens:   tokens,
+	}
+	tests := []struct {
+		method     string
+		password   string
+		authorized bool
+	}{
+		{http.MethodGet, "", false},
+		{http.MethodGet, "wrong", false},
+		{http.MethodGet, "password", true},
+		{http.MethodPut, "password", true},
+		{"PROPFIND", readToken, true},
+		{http.MethodGet, readToken, true},
+		{http.MethodPut, readToken, false},
+		{"MKCOL", readToken, false},
+		{http.MethodGet, renterToken, true},
+		{"MOVE", renterToken, true},
+		{http.MethodGet, walletToken, false},
+		{http.MethodDelete, walletToken, false},
+	}
+	for i, test := range tests {
+		req, err := http.NewRequest(test.method, "/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if test.password != "" {
+			req.SetBasicAuth("", test.password)
+		}
+		if s.authorized(req) != test.authorized {
+			t.Errorf("%v: expected authorized to be %v", i, test.authorized)
+		}
+	}
+
+	// Without a password every request is authorized.
+	s.staticPassword = ""
+	req, err := http.NewRequest(http.MethodDelete, "/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.authorized(req) {
+		t.Fatal("request should be authorized without a password")
+	}
+}
@@ -0,0 +1,248 @@
+package skykey
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"net/url"
+
+	"github.com/aead/chacha20/chacha"
+	"github.com/turtledex/errors"
+	"github.com/turtledex/fastrand"
+
+	"github.com/turtledex/TurtleDexCore/crypto"
+	"github.com/turtledex/TurtleDexCore/types"
+)
+
+// Skyfiles encrypted for recipients store an ephemeral X25519 public key, the
+// wrapped seeds of their file-specific skykey and a key check tag in the key
+// data of their layout. The seed is derived from the shared secret of the
+// ephemeral key and the first recipient. For every other recipient it is
+// wrapped by XORing it with the seed derived from the shared secret of that
+// recipient. Unused seed slots are filled with random data, which means that
+// neither the recipients nor their number are revealed.
+//
+// Recipients can't tell which slot belongs to them. They try all of them and
+// compare the tag derived from the resulting seed with the key check tag. The
+// tag is derived from the seed using a different specifier than the
+// file-specific skykey, so it doesn't reveal anything about the skykey.
+
+const (
+	// MaxRecipients is the maximum number of recipients a skyfile can be
+	// encrypted for. It is limited by the size of the layout's key data.
+	MaxRecipients = 2
+
+	// RecipientKeyDataLen is the length of the key data of a skyfile which is
+	// encrypted for recipients.
+	RecipientKeyDataLen = recipientPublicKeyLen + (MaxRecipients-1)*recipientSeedLen + recipientTagLen
+
+	// RecipientPublicKeyScheme is the URI scheme for encoded recipient public
+	// keys.
+	RecipientPublicKeyScheme = "skyrecipient"
+
+	// recipientEntropyLen is the length of the entropy of a recipient skykey,
+	// an X25519 secret key.
+	recipientEntropyLen = 32
+
+	// recipientPublicKeyLen is the length of an X25519 public key.
+	recipientPublicKeyLen = 32
+
+	// recipientSeedLen is the length of the seed a file-specific skykey of a
+	// skyfile encrypted for recipients is derived from.
+	recipientSeedLen = 16
+
+	// recipientTagLen is the length of the key check tag which identifies the
+	// seed of a skyfile encrypted for recipients.
+	recipientTagLen = 16
+
+	// recipientTagOffset is the offset of the key check tag within the key
+	// data.
+	recipientTagOffset = recipientPublicKeyLen + (MaxRecipients-1)*recipientSeedLen
+)
+
+var (
+	recipientSeedDerivation  = types.NewSpecifier("RecipientSeed")
+	recipientKeyDerivation   = types.NewSpecifier("RecipientKey")
+	recipientNonceDerivation = types.NewSpecifier("RecipientNonce")
+	recipientTagDerivation   = types.NewSpecifier("RecipientTag")
+
+	// ErrTooManyRecipients is returned when a skyfile is encrypted for more
+	// than MaxRecipients recipients.
+	ErrTooManyRecipients = errors.New("Too many skykey recipients")
+
+	// ErrNotRecipient is returned when recovering the file-specific skykey of
+	// a skyfile which wasn't encrypted for the recipient skykey.
+	ErrNotRecipient = errors.New("Skyfile was not encrypted for the recipient skykey")
+
+	errNoRecipients                = errors.New("No skykey recipients provided")
+	errInvalidRecipientKeyDataSize = errors.New("Invalid length of recipient key data")
+)
+
+// RecipientPublicKey is the public key of a TypeRecipient skykey. It is shared
+// with uploaders to encrypt skyfiles for the holder of the skykey.
+type RecipientPublicKey [recipientPublicKeyLen]byte
+
+// ToString encodes the RecipientPublicKey as a URI.
+func (pk RecipientPublicKey) ToString() string {
+	u := url.URL{
+		Scheme: RecipientPublicKeyScheme,
+		Opaque: base64.URLEncoding.EncodeToString(pk[:]),
+	}
+	return u.String()
+}
+
+// FromString decodes the URI or base64 string into a RecipientPublicKey.
+func (pk *RecipientPublicKey) FromString(s string) error {
+	u, err := url.Parse(s)
+	if err != nil {
+		return err
+	}
+	var data string
+	if u.Scheme == RecipientPublicKeyScheme {
+		data = u.Opaque
+	} else if u.Scheme == "" {
+		data = u.Path
+	} else {
+		return errors.New("Unknown URI scheme for recipient public key")
+	}
+	pkBytes, err := base64.URLEncoding.DecodeString(data)
+	if err != nil {
+		return err
+	}
+	if len(pkBytes) != len(pk) {
+		return errors.New("Recipient public key has invalid length")
+	}
+	copy(pk[:], pkBytes)
+	return nil
+}
+
+// generateRecipientSkykey generates a new TypeRecipient skykey.
+func generateRecipientSkykey(name string) Skykey {
+	xsk, _ := crypto.GenerateX25519KeyPair()
+	return Skykey{name, TypeRecipient, xsk[:]}
+}
+
+// isRecipientMasterKey returns true if the skykey is a TypeRecipient skykey
+// holding an X25519 secret key, as opposed to a file-specific skykey of a
+// skyfile encrypted for recipients.
+func (sk *Skykey) isRecipientMasterKey() bool {
+	return sk.Type == TypeRecipient && len(sk.Entropy) == recipientEntropyLen
+}
+
+// RecipientPublicKey returns the public key of a TypeRecipient skykey.
+func (sk *Skykey) RecipientPublicKey() (RecipientPublicKey, error) {
+	if !sk.isRecipientMasterKey() {
+		return RecipientPublicKey{}, errSkykeyTypeDoesNotSupportFunction
+	}
+	var xsk crypto.X25519SecretKey
+	copy(xsk[:], sk.Entropy)
+	return RecipientPublicKey(xsk.PublicKey()), nil
+}
+
+// GenerateRecipientSubkey creates a new file-specific skykey for a skyfile
+// that is encrypted for the given recipients. It also returns the key data
+// which needs to be stored in the skyfile's layout for the recipients to
+// recover the file-specific skykey.
+func GenerateRecipientSubkey(recipients []RecipientPublicKey) (Skykey, []byte, error) {
+	if len(recipients) == 0 {
+		return Skykey{}, nil, errNoRecipients
+	}
+	if len(recipients) > MaxRecipients {
+		return Skykey{}, nil, ErrTooManyRecipients
+	}
+
+	// Generate the ephemeral key pair and derive the seed from the first
+	// recipient.
+	esk, epk := crypto.GenerateX25519KeyPair()
+	keyData := fastrand.Bytes(RecipientKeyDataLen)
+	copy(keyData, epk[:])
+	seed := recipientSeed(crypto.DeriveSharedSecret(esk, crypto.X25519PublicKey(recipients[0])), epk, recipients[0])
+
+	// Wrap the seed for all other recipients.
+	for i, pk := range recipients[1:] {
+		wrap := recipientSeed(crypto.DeriveSharedSecret(esk, crypto.X25519PublicKey(pk)), epk, pk)
+		offset := recipientPublicKeyLen + i*recipientSeedLen
+		for j := range wrap {
+			keyData[offset+j] = seed[j] ^ wrap[j]
+		}
+	}
+
+	// Add the key check tag.
+	copy(keyData[recipientTagOffset:], recipientTag(seed, epk))
+
+	fileSkykey, err := recipientSubkey("", seed, epk)
+	if err != nil {
+		return Skykey{}, nil, err
+	}
+	return fileSkykey, keyData, nil
+}
+
+// RecipientSubkey returns the file-specific skykey a TypeRecipient skykey
+// recovers from the key data of a skyfile's layout. ErrNotRecipient is
+// returned if the skyfile wasn't encrypted for the skykey.
+func (sk *Skykey) RecipientSubkey(keyData []byte) (Skykey, error) {
+	pk, err := sk.RecipientPublicKey()
+	if err != nil {
+		return Skykey{}, err
+	}
+	if len(keyData) < RecipientKeyDataLen {
+		return Skykey{}, errInvalidRecipientKeyDataSize
+	}
+	var xsk crypto.X25519SecretKey
+	copy(xsk[:], sk.Entropy)
+	var epk crypto.X25519PublicKey
+	copy(epk[:], keyData)
+	tag := keyData[recipientTagOffset:RecipientKeyDataLen]
+
+	// The derived seed is either the seed itself or the wrapping of one of
+	// the slots. The seed is the one that matches the key check tag.
+	derived := recipientSeed(crypto.DeriveSharedSecret(xsk, epk), epk, pk)
+	seeds := [][]byte{derived}
+	for i := 0; i < MaxRecipients-1; i++ {
+		offset := recipientPublicKeyLen + i*recipientSeedLen
+		seed := make([]byte, recipientSeedLen)
+		for j := range seed {
+			seed[j] = derived[j] ^ keyData[offset+j]
+		}
+		seeds = append(seeds, seed)
+	}
+	for _, seed := range seeds {
+		if subtle.ConstantTimeCompare(recipientTag(seed, epk), tag) == 1 {
+			return recipientSubkey(sk.Name, seed, epk)
+		}
+	}
+	return Skykey{}, ErrNotRecipient
+}
+
+// recipientSeed derives the seed for a recipient from the secret the
+// recipient shares with the ephemeral key of a skyfile.
+func recipientSeed(secret [32]byte, epk crypto.X25519PublicKey, pk RecipientPublicKey) []byte {
+	h := crypto.HashAll(recipientSeedDerivation, secret, epk, pk)
+	return h[:recipientSeedLen]
+}
+
+// recipientTag derives the key check tag of a skyfile encrypted for
+// recipients from its seed and ephemeral public key.
+func recipientTag(seed []byte, epk crypto.X25519PublicKey) []byte {
+	h := crypto.HashAll(recipientTagDerivation, seed, epk)
+	return h[:recipientTagLen]
+}
+
+// recipientSubkey derives the file-specific skykey of a skyfile encrypted for
+// recipients from its seed and ephemeral public key. Like other file-specific
+// skykeys it holds a cipher key and a nonce. It can't be stored or shared
+// because it is always recovered from the key data of the skyfile's layout.
+func recipientSubkey(name string, seed []byte, epk crypto.X25519PublicKey) (Skykey, error) {
+	key := crypto.HashAll(recipientKeyDerivation, seed, epk)
+	nonce := crypto.HashAll(recipientNonceDerivation, epk)
+
+	entropy := make([]byte, chacha.KeySize+chacha.XNonceSize)
+	copy(entropy[:chacha.KeySize], key[:])
+	copy(entropy[chacha.KeySize:], nonce[:chacha.XNonceSize])
+
+	// Sanity check that we can actually make a CipherKey with this.
+	_, err := crypto.NewTurtleDexKey(TypeRecipient.CipherType(), entropy)
+	if err != nil {
+		return Skykey{}, errors.AddContext(err, "error creating recipient subkey")
+	}
+	return Skykey{name, TypeRecipient, entropy}, nil
+}
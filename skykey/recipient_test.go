@@ -0,0 +1,177 @@
+package skykey
+
+import (
+	"testing"
+
+	"github.com/turtledex/errors"
+
+	"github.com/turtledex/TurtleDexCore/build"
+)
+
+// TestRecipientSkykeys tests creating recipient skykeys and recovering the
+// file-specific skykeys of skyfiles encrypted for them.
+func TestRecipientSkykeys(t *testing.T) {
+	// Create a key manager and the recipient skykeys.
+	persistDir := build.TempDir("skykey", t.Name())
+	keyMan, err := NewSkykeyManager(persistDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var recipients []Skykey
+	var pks []RecipientPublicKey
+	for i := 0; i < MaxRecipients+1; i++ {
+		sk, err := keyMan.CreateKey(t.Name()+string(rune('a'+i)), TypeRecipient)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pk, err := sk.RecipientPublicKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		recipients = append(recipients, sk)
+		pks = append(pks, pk)
+	}
+
+	// The keys should survive reloading the key manager and sharing them.
+	keyMan, err = NewSkykeyManager(persistDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sk := range recipients {
+		loaded, err := keyMan.KeyByName(sk.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !loaded.equals(sk) {
+			t.Fatal("loaded skykey doesn't match")
+		}
+		skStr, err := sk.ToString()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded Skykey
+		if err := decoded.FromString(skStr); err != nil {
+			t.Fatal(err)
+		}
+		if !decoded.equals(sk) {
+			t.Fatal("decoded skykey doesn't match")
+		}
+	}
+
+	// Public keys can be encoded as strings.
+	var pk RecipientPublicKey
+	if err := pk.FromString(pks[0].ToString()); err != nil {
+		t.Fatal(err)
+	}
+	if pk != pks[0] {
+		t.Fatal("decoded public key doesn't match")
+	}
+	if err := pk.FromString("skykey:" + pks[0].ToString()[len(RecipientPublicKeyScheme)+1:]); err == nil {
+		t.Fatal("expected error for wrong scheme")
+	}
+
+	// Only recipient skykeys have a public key.
+	publicIDKey, err := keyMan.CreateKey(t.Name()+"public-id", TypePublicID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := publicIDKey.RecipientPublicKey(); !errors.Contains(err, errSkykeyTypeDoesNotSupportFunction) {
+		t.Fatal("expected errSkykeyTypeDoesNotSupportFunction", err)
+	}
+
+	// The number of recipients is limited.
+	if _, _, err := GenerateRecipientSubkey(nil); !errors.Contains(err, errNoRecipients) {
+		t.Fatal("expected errNoRecipients", err)
+	}
+	if _, _, err := GenerateRecipientSubkey(pks); !errors.Contains(err, ErrTooManyRecipients) {
+		t.Fatal("expected ErrTooManyRecipients", err)
+	}
+
+	// Every recipient recovers the file-specific skykey, the other skykey
+	// doesn't.
+	fileSkykey, keyData, err := GenerateRecipientSubkey(pks[:MaxRecipients])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keyData) != RecipientKeyDataLen {
+		t.Fatal("wrong key data length", len(keyData))
+	}
+	if _, err := fileSkykey.CipherKey(); err != nil {
+		t.Fatal(err)
+	}
+
+	// File-specific skykeys are valid and derive subkeys, but can't be
+	// stored. Master recipient skykeys don't derive subkeys because their
+	// entropy is not a cipher key.
+	if err := fileSkykey.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fileSkykey.DeriveSubkey([]byte("derivation")); err != nil {
+		t.Fatal(err)
+	}
+	if err := keyMan.AddKey(fileSkykey); !errors.Contains(err, errSkykeyTypeDoesNotSupportFunction) {
+		t.Fatal("expected errSkykeyTypeDoesNotSupportFunction", err)
+	}
+	if _, err := fileSkykey.RecipientPublicKey(); !errors.Contains(err, errSkykeyTypeDoesNotSupportFunction) {
+		t.Fatal("expected errSkykeyTypeDoesNotSupportFunction", err)
+	}
+	if _, err := recipients[0].GenerateFileSpecificSubkey(); !errors.Contains(err, errSkykeyTypeDoesNotSupportFunction) {
+		t.Fatal("expected errSkykeyTypeDoesNotSupportFunction", err)
+	}
+	if _, err := recipients[0].DeriveSubkey([]byte("derivation")); !errors.Contains(err, errSkykeyTypeDoesNotSupportFunction) {
+		t.Fatal("expected errSkykeyTypeDoesNotSupportFunction", err)
+	}
+	for i, sk := range recipients {
+		subkey, err := sk.RecipientSubkey(keyData)
+		if i >= MaxRecipients {
+			if !errors.Contains(err, ErrNotRecipient) {
+				t.Fatal("expected ErrNotRecipient for recipient", i, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if subkey.Name != sk.Name || !subkey.equalData(fileSkykey) {
+			t.Fatal("wrong file-specific skykey for recipient", i)
+		}
+	}
+	if _, err := recipients[0].RecipientSubkey(keyData[:RecipientKeyDataLen-1]); !errors.Contains(err, errInvalidRecipientKeyDataSize) {
+		t.Fatal("expected errInvalidRecipientKeyDataSize", err)
+	}
+
+	// If the skyfile is only encrypted for the second skykey, the first one
+	// is reliably rejected.
+	for i := 0; i < 100; i++ {
+		fileSkykey, keyData, err = GenerateRecipientSubkey(pks[1:2])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := recipients[0].RecipientSubkey(keyData); !errors.Contains(err, ErrNotRecipient) {
+			t.Fatal("expected ErrNotRecipient", err)
+		}
+		subkey, err := recipients[1].RecipientSubkey(keyData)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !subkey.equalData(fileSkykey) {
+			t.Fatal("wrong file-specific skykey")
+		}
+	}
+
+	// Tampering with the key check tag makes the skyfile unrecoverable.
+	keyData[RecipientKeyDataLen-1] ^= 1
+	if _, err := recipients[1].RecipientSubkey(keyData); !errors.Contains(err, ErrNotRecipient) {
+		t.Fatal("expected ErrNotRecipient", err)
+	}
+
+	// Encrypting for the same recipients again results in a different
+	// file-specific skykey.
+	fileSkykey2, keyData2, err := GenerateRecipientSubkey(pks[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fileSkykey2.equalData(fileSkykey) || string(keyData2) == string(keyData) {
+		t.Fatal("expected different file-specific skykey")
+	}
+}
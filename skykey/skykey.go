@@ -51,6 +51,13 @@ const (
 	// successfully decrypted with the correct skykey.
 	TypePrivateID = SkykeyType(0x02)
 
+	// TypeRecipient is a Skykey that holds an X25519 secret key. Skyfiles are
+	// not encrypted with the Skykey itself but for its RecipientPublicKey,
+	// which allows uploaders to encrypt skyfiles for the holder of the Skykey
+	// without sharing a secret. The skyfiles reveal neither the Skykey nor
+	// its public key.
+	TypeRecipient = SkykeyType(0x03)
+
 	// typeDeletedSkykey is used internally to mark a key as deleted in the
 	// skykey manager. It is different from TypeInvalid because TypeInvalid can
 	// be used to catch other kinds of errors, i.e. accidentally using a
@@ -106,6 +113,8 @@ func (t SkykeyType) ToString() string {
 		return "public-id"
 	case TypePrivateID:
 		return "private-id"
+	case TypeRecipient:
+		return "recipient"
 	default:
 		return "invalid"
 	}
@@ -118,6 +127,8 @@ func (t *SkykeyType) FromString(s string) error {
 		*t = TypePublicID
 	case "private-id":
 		*t = TypePrivateID
+	case "recipient":
+		*t = TypeRecipient
 	default:
 		return ErrInvalidSkykeyType
 	}
@@ -199,10 +210,12 @@ func (sk *Skykey) unmarshalAndConvertFromOldFormat(r io.Reader) error {
 	return sk.IsValid()
 }
 
-// CipherType returns the crypto.CipherType used by this Skykey.
+// CipherType returns the crypto.CipherType used by this Skykey. For
+// TypeRecipient skykeys it is the crypto.CipherType of the file-specific
+// skykeys.
 func (t SkykeyType) CipherType() crypto.CipherType {
 	switch t {
-	case TypePublicID, TypePrivateID:
+	case TypePublicID, TypePrivateID, TypeRecipient:
 		return crypto.TypeXChaCha20
 	default:
 		return crypto.TypeInvalid
@@ -224,6 +237,8 @@ func (sk *Skykey) unmarshalDataOnly(r io.Reader) error {
 	switch sk.Type {
 	case TypePublicID, TypePrivateID:
 		entropyLen = chacha.KeySize + chacha.XNonceSize
+	case TypeRecipient:
+		entropyLen = recipientEntropyLen
 	case TypeInvalid:
 		return errCannotMarshalTypeInvalidSkykey
 	case typeDeletedSkykey:
@@ -272,6 +287,10 @@ func (sk Skykey) marshalDataOnly(w io.Writer) error {
 	switch sk.Type {
 	case TypePublicID, TypePrivateID:
 		entropyLen = chacha.KeySize + chacha.XNonceSize
+	// Only master recipient skykeys are marshalled. File-specific recipient
+	// skykeys are recovered from the skyfile's layout instead.
+	case TypeRecipient:
+		entropyLen = recipientEntropyLen
 	case TypeInvalid:
 		return errCannotMarshalTypeInvalidSkykey
 	default:
@@ -369,6 +388,11 @@ func (sk Skykey) ID() (keyID SkykeyID) {
 	case TypePublicID, TypePrivateID:
 		entropy = sk.Entropy[:chacha.KeySize]
 
+	// The entropy of recipient skykeys is the secret key. File-specific
+	// subkeys have a nonce which is ignored.
+	case TypeRecipient:
+		entropy = sk.Entropy[:recipientEntropyLen]
+
 	default:
 		build.Critical("Computing ID with skykey of unknown type: ", sk.Type)
 	}
@@ -436,6 +460,12 @@ func (sk *Skykey) SubkeyWithNonce(nonce []byte) (Skykey, error) {
 	if len(nonce) != chacha.XNonceSize {
 		return Skykey{}, errors.New("Incorrect nonce size")
 	}
+	// The entropy of master recipient skykeys is an X25519 secret key which
+	// must not be used as a cipher key. Their file-specific skykeys are
+	// recovered using RecipientSubkey instead.
+	if sk.isRecipientMasterKey() {
+		return Skykey{}, errSkykeyTypeDoesNotSupportFunction
+	}
 
 	entropy := make([]byte, chacha.KeySize+chacha.XNonceSize)
 	copy(entropy[:chacha.KeySize], sk.Entropy[:chacha.KeySize])
@@ -544,6 +574,16 @@ func (sk *Skykey) IsValid() error {
 			return errInvalidEntropyLength
 		}
 
+	// Master recipient skykeys hold an X25519 secret key, not a cipher key.
+	// Their file-specific skykeys hold a cipher key like the other types.
+	case TypeRecipient:
+		if sk.isRecipientMasterKey() {
+			return nil
+		}
+		if len(sk.Entropy) != chacha.KeySize+chacha.XNonceSize {
+			return errInvalidEntropyLength
+		}
+
 	default:
 		return errUnsupportedSkykeyType
 	}
@@ -676,6 +676,19 @@ func TestSkykeyTypeStrings(t *testing.T) {
 	if st != TypePrivateID {
 		t.Fatal("Wrong SkykeyType", st)
 	}
+
+	recipientString := TypeRecipient.ToString()
+	if recipientString != "recipient" {
+		t.Fatal("Incorrect skykeytype name", recipientString)
+	}
+
+	err = st.FromString(recipientString)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st != TypeRecipient {
+		t.Fatal("Wrong SkykeyType", st)
+	}
 }
 
 // TestSkyfileEncryptionIDs tests the generation and verification of skyfile
@@ -189,6 +189,9 @@ func (sm *SkykeyManager) AddKey(sk Skykey) error {
 	if err := sk.IsValid(); err != nil {
 		return errors.AddContext(err, "Invalid skykey cannot be added")
 	}
+	if sk.Type == TypeRecipient && !sk.isRecipientMasterKey() {
+		return errors.AddContext(errSkykeyTypeDoesNotSupportFunction, "file-specific recipient skykeys cannot be added")
+	}
 
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
@@ -222,8 +225,13 @@ func (sm *SkykeyManager) CreateKey(name string, skykeyType SkykeyType) (Skykey,
 	}
 
 	// Generate the new key.
-	cipherKey := crypto.GenerateTurtleDexKey(skykeyType.CipherType())
-	skykey := Skykey{name, skykeyType, cipherKey.Key()}
+	var skykey Skykey
+	if skykeyType == TypeRecipient {
+		skykey = generateRecipientSkykey(name)
+	} else {
+		cipherKey := crypto.GenerateTurtleDexKey(skykeyType.CipherType())
+		skykey = Skykey{name, skykeyType, cipherKey.Key()}
+	}
 
 	err := sm.saveKey(skykey)
 	if err != nil {
@@ -310,7 +318,7 @@ func (sm *SkykeyManager) Skykeys() []Skykey {
 // skykeys with the given type.
 func (sm *SkykeyManager) SupportsSkykeyType(skykeyType SkykeyType) bool {
 	switch skykeyType {
-	case TypePublicID, TypePrivateID:
+	case TypePublicID, TypePrivateID, TypeRecipient:
 		return true
 	default:
 		return false
@@ -732,9 +732,141 @@ func TestFuse(t *testing.T) {
 		t.Fatal("should not be able to make a directory in a read-only fuse system")
 	}
 
-	// TODO: When read-write fuse is supported, switch over to read-write mode
-	// here and begin testing the write features. Extend the concurrency test to
-	// probe write features as well, probably by adding more phases.
+	// Mount the root in read-write mode and test the write features.
+	//
+	// TODO: Extend the concurrency test to probe write features as well,
+	// probably by adding more phases.
+	rwMount := filepath.Join(testDir, "rwMount")
+	err = os.MkdirAll(rwMount, persist.DefaultDiskPermissionsTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rwOpts := modules.MountOptions{
+		ReadOnly:   false,
+		AllowOther: false,
+	}
+	err = r.RenterFuseMount(rwMount, modules.RootTurtleDexPath(), rwOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create a new file and check that it gets uploaded once it is closed.
+	rwFileTurtleDexPath, err := modules.NewTurtleDexPath("rwFile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rwFilePath, err := siaPathToFusePath(rwFileTurtleDexPath, modules.RootTurtleDexPath(), rwMount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rwData := fastrand.Bytes(1 << 12)
+	err = ioutil.WriteFile(rwFilePath, rwData, persist.DefaultDiskPermissionsTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rf, err := r.RenterFileRootGet(rwFileTurtleDexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rf.File.Filesize != uint64(len(rwData)) {
+		t.Fatal("uploaded file has the wrong size", rf.File.Filesize, len(rwData))
+	}
+	readData, err := ioutil.ReadFile(rwFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(readData, rwData) {
+		t.Fatal("data mismatch after writing file through fuse")
+	}
+
+	// Append to the file and check that the existing contents are kept.
+	rwFile, err := os.OpenFile(rwFilePath, os.O_WRONLY, persist.DefaultDiskPermissionsTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendData := fastrand.Bytes(1 << 10)
+	_, err = rwFile.WriteAt(appendData, int64(len(rwData)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = rwFile.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rwData = append(rwData, appendData...)
+	readData, err = ioutil.ReadFile(rwFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(readData, rwData) {
+		t.Fatal("data mismatch after appending to file through fuse")
+	}
+
+	// Create a directory and move the file into it.
+	rwDirTurtleDexPath, err := modules.NewTurtleDexPath("rwDir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rwDirPath, err := siaPathToFusePath(rwDirTurtleDexPath, modules.RootTurtleDexPath(), rwMount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Mkdir(rwDirPath, persist.DefaultDiskPermissionsTest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.RenterDirRootGet(rwDirTurtleDexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	renamedTurtleDexPath, err := rwDirTurtleDexPath.Join("renamed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	renamedPath, err := siaPathToFusePath(renamedTurtleDexPath, modules.RootTurtleDexPath(), rwMount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Rename(rwFilePath, renamedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.RenterFileRootGet(rwFileTurtleDexPath)
+	if err == nil {
+		t.Fatal("file should no longer exist at its old siapath")
+	}
+	_, err = r.RenterFileRootGet(renamedTurtleDexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Removing the non-empty directory should fail.
+	err = os.Remove(rwDirPath)
+	if err == nil {
+		t.Fatal("should not be able to remove a non-empty directory")
+	}
+
+	// Delete the file and then the directory.
+	err = os.Remove(renamedPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.RenterFileRootGet(renamedTurtleDexPath)
+	if err == nil {
+		t.Fatal("file should have been deleted")
+	}
+	err = os.Remove(rwDirPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.RenterDirRootGet(rwDirTurtleDexPath)
+	if err == nil {
+		t.Fatal("dir should have been deleted")
+	}
+	err = r.RenterFuseUnmount(rwMount)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	// Inode check. Mount the root siafile to a special inode mountpoint then
 	// open several files and directoriesk. Grab their inodes. Keep the folder
@@ -181,6 +181,7 @@ func TestRenterStream(t *testing.T) {
 		{Name: "TestStreamLargeFile", Test: testStreamLargeFile},
 		{Name: "TestStreamRepair", Test: testStreamRepair},
 		{Name: "TestUploadStreaming", Test: testUploadStreaming},
+		{Name: "TestUploadStreamingCompressed", Test: testUploadStreamingCompressed},
 		{Name: "TestUploadStreamingWithBadDeps", Test: testUploadStreamingWithBadDeps},
 	}
 
@@ -344,6 +345,64 @@ func testUploadStreaming(t *testing.T, tg *siatest.TestGroup) {
 	}
 }
 
+// testUploadStreamingCompressed uploads compressible data using the upload
+// streaming API with compression enabled and verifies that streaming the file
+// returns the uncompressed data.
+func testUploadStreamingCompressed(t *testing.T, tg *siatest.TestGroup) {
+	if len(tg.Renters()) == 0 {
+		t.Fatal("Test requires at least 1 renter")
+	}
+	// Create some compressible data to write.
+	fileSize := fastrand.Intn(2*int(modules.SectorSize)) + siatest.Fuzz() + 2
+	data := bytes.Repeat([]byte("turtledex"), fileSize/9+1)[:fileSize]
+	d := bytes.NewReader(data)
+
+	// Upload the data.
+	siaPath, err := modules.NewTurtleDexPath("/compressed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := tg.Renters()[0]
+	err = r.RenterUploadStreamCompressedPost(d, siaPath, 1, uint64(len(tg.Hosts())-1), false, modules.CompressionGzip)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The file should report its compression and the size of the
+	// uncompressed data.
+	rfg, err := r.RenterFileGet(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rfg.File.Compression != modules.CompressionGzip {
+		t.Fatalf("expected compression %v but was %v", modules.CompressionGzip, rfg.File.Compression)
+	}
+	if rfg.File.Filesize != uint64(len(data)) {
+		t.Fatalf("expected size %v but was %v", len(data), rfg.File.Filesize)
+	}
+
+	// Stream the file and compare it to the original data.
+	downloadedData, err := r.RenterStreamGet(siaPath, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, downloadedData) {
+		t.Fatal("Downloaded data doesn't match uploaded data")
+	}
+
+	// Download a range of the file. The offset and length refer to the
+	// uncompressed data.
+	offset := uint64(fastrand.Intn(fileSize))
+	length := uint64(fastrand.Intn(fileSize-int(offset))) + 1
+	_, downloadedData, err = r.RenterDownloadHTTPResponseGet(siaPath, offset, length, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data[offset:offset+length], downloadedData) {
+		t.Fatal("Downloaded range doesn't match uploaded data")
+	}
+}
+
 // testUploadStreamingWithBadDeps uploads random data using the upload streaming
 // API, depending on a disrupt to cause a failure. This is a regression test
 // that would have caused a production build panic.